@@ -0,0 +1,63 @@
+package xsd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/stream"
+)
+
+// Charset decodes characters of one non-UTF-8 document encoding.
+type Charset interface {
+	// DecodeRune decodes the first character in p and returns it with its
+	// encoded width in bytes. It returns width 0 when p ends inside a
+	// character and a negative rune when the bytes are not a valid character.
+	DecodeRune(p []byte) (r rune, width int)
+}
+
+// CharsetFunc adapts a function to Charset.
+type CharsetFunc func(p []byte) (r rune, width int)
+
+// DecodeRune decodes the first character in p.
+func (f CharsetFunc) DecodeRune(p []byte) (rune, int) {
+	if f == nil {
+		return -1, len(p)
+	}
+	return f(p)
+}
+
+// Charsets registers document encodings by the name used in the XML
+// declaration's encoding attribute. Names match case-insensitively and take
+// precedence over the built-in ISO-8859-1, windows-1252 and US-ASCII
+// charsets; registering two names that differ only in case is an option
+// error. UTF-8 is never looked up, and UTF-16 input is recognized from its
+// byte order mark or its UTF-16 encoded declaration.
+type Charsets map[string]Charset
+
+// adaptPublicCharsets indexes c by lower-cased name once, so lookups do not
+// scan the caller's map. optionError builds the error reported when two names
+// differ only in case.
+func adaptPublicCharsets(c Charsets, optionError func(msg string) error) (stream.CharsetLookup, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	byName := make(map[string]Charset, len(c))
+	labels := make(map[string]string, len(c))
+	for _, label := range slices.Sorted(maps.Keys(c)) {
+		name := strings.ToLower(label)
+		if other, ok := labels[name]; ok {
+			return nil, optionError(fmt.Sprintf("Charsets registers both %q and %q, which differ only in case", other, label))
+		}
+		labels[name] = label
+		byName[name] = c[label]
+	}
+	return func(name string) (stream.Charset, bool) {
+		charset, ok := byName[strings.ToLower(name)]
+		if !ok || charset == nil {
+			return nil, false
+		}
+		return charset, true
+	}, nil
+}
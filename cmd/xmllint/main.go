@@ -17,9 +17,12 @@ import (
 type config struct {
 	schema             string
 	doc                string
+	format             string
 	maxErrors          int
 	maxIdentityEntries int
 	maxBytes           int64
+	xml11              bool
+	xsd11              bool
 }
 
 func main() {
@@ -36,9 +39,24 @@ func runWithOpen(ctx context.Context, args []string, stderr io.Writer, openDoc f
 	if err != nil {
 		return writeStatus(stderr, 2, "%v\n", err)
 	}
-	engine, err := xsd.Compile(ctx, xsd.File(cfg.schema))
+	renderer := diagnosticRenderer(cfg.format, stderr)
+	engine, err := xsd.CompileWithOptions(ctx, xsd.CompileOptions{
+		XML11:                 cfg.xml11,
+		XSD11:                 cfg.xsd11,
+		RetainSourceLocations: renderer != nil,
+	}, xsd.File(cfg.schema))
 	if err != nil {
-		return writeStatus(stderr, 1, "%s fails to compile\n%v\n", cfg.schema, err)
+		if renderer == nil {
+			return writeStatus(stderr, 1, "%s fails to compile\n%v\n", cfg.schema, err)
+		}
+		if code := writeStatus(stderr, 1, "%s fails to compile\n", cfg.schema); code != 1 {
+			return code
+		}
+		renderer.Open = openFile
+		if renderErr := renderer.Render(stderr, err); renderErr != nil {
+			return 2
+		}
+		return 1
 	}
 	f, err := openDoc(cfg.doc)
 	if err != nil {
@@ -48,10 +66,24 @@ func runWithOpen(ctx context.Context, args []string, stderr io.Writer, openDoc f
 		MaxErrors:          cfg.maxErrors,
 		MaxIdentityEntries: cfg.maxIdentityEntries,
 		MaxInstanceBytes:   cfg.maxBytes,
+		XML11:              cfg.xml11,
 	})
 	closeErr := f.Close()
 	if validationErr != nil {
-		if writeErr := printValidationErrors(stderr, validationErr); writeErr != nil {
+		var writeErr error
+		if renderer != nil {
+			renderer.Document = cfg.doc
+			renderer.Open = func(name string) (io.ReadCloser, error) {
+				if name == cfg.doc {
+					return openDoc(name)
+				}
+				return openFile(name)
+			}
+			writeErr = renderer.Render(stderr, validationErr)
+		} else {
+			writeErr = printValidationErrors(stderr, validationErr)
+		}
+		if writeErr != nil {
 			return 2
 		}
 		if closeErr != nil {
@@ -80,6 +112,9 @@ func parseArgs(args []string) (config, error) {
 	fs.IntVar(&cfg.maxIdentityEntries, "max-identity-entries", 0, "maximum retained identity entries")
 	fs.Int64Var(&cfg.maxBytes, "max-instance-bytes", 0, "maximum raw XML bytes to read")
 	fs.StringVar(&cfg.schema, "schema", "", "schema path")
+	fs.BoolVar(&cfg.xml11, "xml11", false, "accept XML 1.1 schema and instance documents")
+	fs.BoolVar(&cfg.xsd11, "xsd11", false, "enable supported XSD 1.1 schema components")
+	fs.StringVar(&cfg.format, "format", "auto", "error format: line, snippet, color, or auto for color snippets on a terminal")
 	if err := fs.Parse(args); err != nil {
 		return cfg, err
 	}
@@ -95,6 +130,11 @@ func parseArgs(args []string) (config, error) {
 	if cfg.maxBytes < 0 {
 		return cfg, errors.New("--max-instance-bytes cannot be negative")
 	}
+	switch cfg.format {
+	case "auto", "line", "snippet", "color":
+	default:
+		return cfg, errors.New("--format must be auto, line, snippet or color")
+	}
 	if fs.NArg() != 1 {
 		return cfg, errors.New("one XML document path is required")
 	}
@@ -115,3 +155,33 @@ func printValidationErrors(w io.Writer, err error) error {
 	_, writeErr := fmt.Fprintln(w, err)
 	return writeErr
 }
+
+// diagnosticRenderer returns the renderer for errors written to w in format,
+// or nil when errors are written one per line. The auto format renders
+// snippets on a terminal, in color unless NO_COLOR is set.
+func diagnosticRenderer(format string, w io.Writer) *xsderrors.Renderer {
+	switch format {
+	case "snippet":
+		return &xsderrors.Renderer{}
+	case "color":
+		return &xsderrors.Renderer{Color: true}
+	case "auto":
+		if isTerminal(w) {
+			return &xsderrors.Renderer{Color: os.Getenv("NO_COLOR") == ""}
+		}
+	}
+	return nil
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func openFile(name string) (io.ReadCloser, error) {
+	return os.Open(name) //nolint:gosec // Diagnostics quote the schema and document files being validated.
+}
@@ -34,6 +34,7 @@ func TestParseArgsRejectsInvalidInputs(t *testing.T) {
 		{name: "negative_max_errors", args: []string{"--schema", "schema.xsd", "--max-errors", "-1", "doc.xml"}, want: "--max-errors cannot be negative"},
 		{name: "negative_max_identity_entries", args: []string{"--schema", "schema.xsd", "--max-identity-entries", "-1", "doc.xml"}, want: "--max-identity-entries cannot be negative"},
 		{name: "negative_max_instance_bytes", args: []string{"--schema", "schema.xsd", "--max-instance-bytes", "-1", "doc.xml"}, want: "--max-instance-bytes cannot be negative"},
+		{name: "unknown_format", args: []string{"--schema", "schema.xsd", "--format", "json", "doc.xml"}, want: "--format must be auto, line, snippet or color"},
 		{name: "rejects_noout", args: []string{"--noout", "--schema", "schema.xsd", "doc.xml"}, want: "flag provided but not defined: -noout"},
 		{name: "rejects_huge", args: []string{"--huge", "--schema", "schema.xsd", "doc.xml"}, want: "flag provided but not defined: -huge"},
 	}
@@ -85,6 +86,65 @@ func TestRunReportsValidationFailure(t *testing.T) {
 	}
 }
 
+func TestRunRendersSnippets(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeXMLLintTestFile(t, dir, "schema.xsd", xmllintTestSchema)
+	doc := writeXMLLintTestFile(t, dir, "invalid.xml", "<root>\n  <v>x</v>\n</root>")
+
+	var stderr bytes.Buffer
+	if code := runWithOpen(context.Background(), []string{"--schema", schema, "--format", "snippet", doc}, &stderr, func(path string) (io.ReadCloser, error) {
+		return os.Open(path) //nolint:gosec // Test opens files created under t.TempDir.
+	}); code != 1 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	for _, want := range []string{
+		"error[validation.facet]",
+		" --> " + doc + ":2:",
+		"2 |   <v>x</v>",
+		"^ /root/v",
+		"note: violates xs:element",
+		" --> " + schema + ":5:9",
+		`5 |         <xs:element name="v" type="xs:int"/>`,
+		doc + " fails to validate",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("run() stderr = %q, want it to contain %q", stderr.String(), want)
+		}
+	}
+	if strings.Contains(stderr.String(), "\x1b[") {
+		t.Fatalf("run() stderr = %q, want no color", stderr.String())
+	}
+}
+
+func TestRunRendersSchemaSnippets(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeXMLLintTestFile(t, dir, "schema.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root" type="xs:int" default="x"/>
+</xs:schema>`)
+
+	var stderr bytes.Buffer
+	if code := runWithOpen(context.Background(), []string{"--schema", schema, "--format", "color", "doc.xml"}, &stderr, nil); code != 1 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	for _, want := range []string{schema + " fails to compile", "\x1b[1;31merror[", `   <xs:element name="root" type="xs:int" default="x"/>`} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("run() stderr = %q, want it to contain %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestDiagnosticRendererAutoDetectsTerminal(t *testing.T) {
+	if r := diagnosticRenderer("auto", &bytes.Buffer{}); r != nil {
+		t.Fatalf("diagnosticRenderer(auto, buffer) = %+v, want nil", r)
+	}
+	if r := diagnosticRenderer("line", os.Stderr); r != nil {
+		t.Fatalf("diagnosticRenderer(line) = %+v, want nil", r)
+	}
+	if r := diagnosticRenderer("color", &bytes.Buffer{}); r == nil || !r.Color {
+		t.Fatalf("diagnosticRenderer(color) = %+v, want a color renderer", r)
+	}
+}
+
 func TestRunEnforcesMaxInstanceBytes(t *testing.T) {
 	dir := t.TempDir()
 	schema := writeXMLLintTestFile(t, dir, "schema.xsd", xmllintTestSchema)
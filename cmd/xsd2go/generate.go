@@ -0,0 +1,781 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"go/format"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jacoelho/xsd"
+)
+
+const xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+
+// builtinGoTypes maps the built-in types whose values fit a Go numeric or
+// boolean type. Other built-in types are held as strings.
+var builtinGoTypes = map[string]string{
+	"boolean":       "bool",
+	"long":          "int64",
+	"int":           "int32",
+	"short":         "int16",
+	"byte":          "int8",
+	"unsignedLong":  "uint64",
+	"unsignedInt":   "uint32",
+	"unsignedShort": "uint16",
+	"unsignedByte":  "uint8",
+}
+
+// fieldMode is how a struct field holds its value.
+type fieldMode uint8
+
+const (
+	fieldValue fieldMode = iota
+	fieldPointer
+	fieldSlice
+)
+
+type field struct {
+	name string
+	typ  string
+	tag  string
+	// ref is the struct a value field holds, so cycles of value fields can
+	// be broken with pointers.
+	ref      *goStruct
+	mode     fieldMode
+	embedded bool
+}
+
+type goStruct struct {
+	name   string
+	doc    string
+	fields []*field
+	// elements indexes the fields of child elements by element name, so a
+	// name that occurs in several particles shares one field.
+	elements map[xml.Name]*field
+	used     map[string]bool
+}
+
+// decl is one generated declaration, in output order: a struct, or the
+// rendered text of a simple type.
+type decl struct {
+	st   *goStruct
+	text string
+}
+
+type generator struct {
+	model *xsd.SchemaModel
+	used  map[string]bool
+	// names holds the Go names of generated types, assigned before the
+	// types are generated so references may precede definitions.
+	names   map[*xsd.TypeDefinition]string
+	done    map[*xsd.TypeDefinition]bool
+	structs map[*xsd.TypeDefinition]*goStruct
+	// elementTypes are the anonymous types of top-level elements, whose
+	// structs carry the element name.
+	elementTypes map[*xsd.TypeDefinition]*xsd.ElementDeclaration
+	members      map[*xsd.ElementDeclaration][]*xsd.ElementDeclaration
+	decls        []*decl
+	anyElement   string
+	imports      map[string]bool
+}
+
+// generate renders the Go file for model. Top-level elements become structs
+// with an XMLName field; complex types become structs, simple types named
+// Go types with a Validate method, and lists slices.
+func generate(cfg config, model *xsd.SchemaModel) ([]byte, error) {
+	g := &generator{
+		model:        model,
+		used:         make(map[string]bool),
+		names:        make(map[*xsd.TypeDefinition]string),
+		done:         make(map[*xsd.TypeDefinition]bool),
+		structs:      make(map[*xsd.TypeDefinition]*goStruct),
+		elementTypes: make(map[*xsd.TypeDefinition]*xsd.ElementDeclaration),
+		members:      make(map[*xsd.ElementDeclaration][]*xsd.ElementDeclaration),
+		imports:      make(map[string]bool),
+	}
+	for _, group := range model.SubstitutionGroups {
+		g.members[group.Head] = group.Members
+	}
+	elements := g.elements()
+	elementNames := make(map[*xsd.ElementDeclaration]string, len(elements))
+	for _, elem := range elements {
+		elementNames[elem] = g.unique(goName(elem.Name.Local))
+		if elem.Type.Name.Local == "" && elem.Type.Kind == xsd.TypeComplex {
+			g.names[elem.Type] = elementNames[elem]
+			g.elementTypes[elem.Type] = elem
+		}
+	}
+	for _, typ := range model.Types {
+		if builtin(typ) {
+			continue
+		}
+		name := goName(typ.Name.Local)
+		if g.used[name] {
+			name += "Type"
+		}
+		g.names[typ] = g.unique(name)
+	}
+	for _, elem := range elements {
+		g.element(elem, elementNames[elem])
+	}
+	for _, typ := range model.Types {
+		if !builtin(typ) {
+			g.define(typ)
+		}
+	}
+	g.breakCycles()
+	return g.render(cfg)
+}
+
+// elements returns the top-level element declarations that may appear in an
+// instance.
+func (g *generator) elements() []*xsd.ElementDeclaration {
+	var out []*xsd.ElementDeclaration
+	for _, elem := range g.model.Elements {
+		if !elem.Abstract {
+			out = append(out, elem)
+		}
+	}
+	return out
+}
+
+// element defines the struct of a top-level element declaration. Elements of
+// anonymous complex types are that type's struct; others wrap their type.
+func (g *generator) element(elem *xsd.ElementDeclaration, name string) {
+	if g.elementTypes[elem.Type] == elem {
+		g.define(elem.Type)
+		return
+	}
+	st := g.newStruct(name, fmt.Sprintf("%s is the element %s.", name, clark(elem.Name)), elem.Annotation)
+	st.add(&field{name: "XMLName", typ: "xml.Name", tag: xmlName(elem.Name)})
+	typ := elem.Type
+	switch {
+	case typ.Kind == xsd.TypeComplex && builtin(typ):
+		g.anyContent(st)
+	case typ.Kind == xsd.TypeComplex:
+		g.define(typ)
+		st.add(&field{name: g.names[typ], typ: g.names[typ], ref: g.structs[typ], embedded: true})
+	default:
+		st.add(&field{name: "Value", typ: g.simpleType(typ, name+"Value"), tag: ",chardata"})
+	}
+}
+
+// define generates the declaration of typ, once.
+func (g *generator) define(typ *xsd.TypeDefinition) {
+	if g.done[typ] {
+		return
+	}
+	g.done[typ] = true
+	if typ.Kind == xsd.TypeSimple {
+		g.defineSimple(typ)
+		return
+	}
+	name := g.names[typ]
+	doc := fmt.Sprintf("%s is the complex type %s.", name, clark(typ.Name))
+	elem := g.elementTypes[typ]
+	if elem != nil {
+		doc = fmt.Sprintf("%s is the element %s.", name, clark(elem.Name))
+	} else if typ.Name.Local == "" {
+		doc = fmt.Sprintf("%s is an anonymous complex type.", name)
+	}
+	annotation := typ.Annotation
+	if elem != nil && len(annotation.Documentation) == 0 {
+		annotation = elem.Annotation
+	}
+	st := g.newStruct(name, doc, annotation)
+	g.structs[typ] = st
+	if elem != nil {
+		st.add(&field{name: "XMLName", typ: "xml.Name", tag: xmlName(elem.Name)})
+	}
+	for _, use := range typ.Attributes {
+		mode, tag := fieldPointer, xmlName(use.Name)+",attr,omitempty"
+		if use.Required {
+			mode, tag = fieldValue, xmlName(use.Name)+",attr"
+		}
+		st.add(&field{
+			name: goName(use.Name.Local),
+			typ:  g.simpleType(use.Type, name+goName(use.Name.Local)),
+			tag:  tag,
+			mode: mode,
+		})
+	}
+	if typ.AttributeWildcard != nil {
+		st.add(&field{name: "AnyAttrs", typ: "xml.Attr", tag: ",any,attr", mode: fieldSlice})
+	}
+	switch typ.ContentType {
+	case xsd.ContentSimple:
+		st.add(&field{name: "Value", typ: g.simpleType(typ.SimpleContentType, name+"Value"), tag: ",chardata"})
+	case xsd.ContentMixed:
+		st.add(&field{name: "Text", typ: "string", tag: ",chardata"})
+	}
+	if typ.Particle != nil {
+		g.particle(st, typ.Particle, false, false)
+	}
+}
+
+// particle adds the fields of the elements p admits. Elements that may be
+// absent get pointer fields and elements that may repeat slice fields; each
+// branch of a choice is optional.
+func (g *generator) particle(st *goStruct, p *xsd.Particle, optional, repeated bool) {
+	optional = optional || p.MinOccurs == 0
+	repeated = repeated || p.MaxOccurs == xsd.Unbounded || p.MaxOccurs > 1
+	switch p.Kind {
+	case xsd.ParticleElement:
+		if !p.Element.Abstract {
+			g.elementField(st, p.Element, optional, repeated)
+		}
+		for _, member := range g.members[p.Element] {
+			if !member.Abstract {
+				g.elementField(st, member, true, repeated)
+			}
+		}
+	case xsd.ParticleWildcard:
+		if st.elements[xml.Name{}] == nil {
+			f := &field{name: "Any", typ: g.anyElementType(), tag: ",any", mode: fieldSlice}
+			st.elements[xml.Name{}] = f
+			st.add(f)
+		}
+	case xsd.ParticleChoice:
+		for _, child := range p.Particles {
+			g.particle(st, child, optional || len(p.Particles) > 1, repeated)
+		}
+	default:
+		for _, child := range p.Particles {
+			g.particle(st, child, optional, repeated)
+		}
+	}
+}
+
+func (g *generator) elementField(st *goStruct, elem *xsd.ElementDeclaration, optional, repeated bool) {
+	if f := st.elements[elem.Name]; f != nil {
+		f.mode, f.ref = fieldSlice, nil
+		return
+	}
+	f := &field{name: goName(elem.Name.Local), tag: xmlName(elem.Name)}
+	switch {
+	case repeated:
+		f.mode = fieldSlice
+	case optional:
+		f.mode = fieldPointer
+	}
+	typ := elem.Type
+	switch {
+	case typ.Kind == xsd.TypeSimple:
+		f.typ = g.simpleType(typ, st.name+f.name)
+	case builtin(typ):
+		f.typ = g.anyElementType()
+	default:
+		if _, ok := g.names[typ]; !ok {
+			g.names[typ] = g.unique(st.name + f.name)
+		}
+		g.define(typ)
+		f.typ = g.names[typ]
+		if f.mode == fieldValue {
+			f.ref = g.structs[typ]
+		}
+	}
+	st.elements[elem.Name] = f
+	st.add(f)
+}
+
+// anyContent adds the fields that hold the attributes and content of an
+// element of type xs:anyType.
+func (g *generator) anyContent(st *goStruct) {
+	st.add(&field{name: "Attrs", typ: "xml.Attr", tag: ",any,attr", mode: fieldSlice})
+	st.add(&field{name: "Content", typ: "string", tag: ",innerxml"})
+}
+
+// anyElementType returns the name of the struct that holds elements matched
+// by wildcards or declared with type xs:anyType, defining it on first use.
+func (g *generator) anyElementType() string {
+	if g.anyElement != "" {
+		return g.anyElement
+	}
+	g.anyElement = g.unique("AnyElement")
+	st := g.newStruct(g.anyElement, g.anyElement+" holds an element matched by a wildcard or of type xs:anyType.", xsd.Annotation{})
+	st.add(&field{name: "XMLName", typ: "xml.Name"})
+	g.anyContent(st)
+	return g.anyElement
+}
+
+// simpleType returns the Go type of values of typ, generating a named type
+// called name when typ is anonymous.
+func (g *generator) simpleType(typ *xsd.TypeDefinition, name string) string {
+	if builtin(typ) {
+		if goType, ok := builtinGoTypes[typ.Name.Local]; ok {
+			return goType
+		}
+		return "string"
+	}
+	if _, ok := g.names[typ]; !ok {
+		g.names[typ] = g.unique(name)
+	}
+	g.define(typ)
+	return g.names[typ]
+}
+
+// defineSimple renders a simple type: atomic and union types as a named
+// string, boolean or integer type, lists as a slice of their item type
+// encoded as space-separated text. Validate checks the facets with a
+// datatypes.Restriction.
+func (g *generator) defineSimple(typ *xsd.TypeDefinition) {
+	name := g.names[typ]
+	d := &decl{}
+	g.decls = append(g.decls, d)
+	var b bytes.Buffer
+	doc := fmt.Sprintf("%s is the simple type %s.", name, clark(typ.Name))
+	if typ.Name.Local == "" {
+		doc = fmt.Sprintf("%s is an anonymous simple type.", name)
+	}
+	writeDoc(&b, doc, typ.Annotation)
+	restriction := lowerFirst(name) + "Restriction"
+	g.imports["github.com/jacoelho/xsd/datatypes"] = true
+	if typ.Variety == xsd.VarietyList {
+		item := g.simpleType(typ.ItemType, name+"Item")
+		itemBase := g.underlying(typ.ItemType)
+		g.imports["strings"] = true
+		fmt.Fprintf(&b, "type %s []%s\n\n", name, item)
+		fmt.Fprintf(&b, "// MarshalText encodes v as space-separated items.\n")
+		fmt.Fprintf(&b, "func (v %s) MarshalText() ([]byte, error) {\n", name)
+		b.WriteString("\titems := make([]string, len(v))\n\tfor i, item := range v {\n")
+		fmt.Fprintf(&b, "\t\titems[i] = %s\n\t}\n", g.format(itemBase, "item"))
+		b.WriteString("\treturn []byte(strings.Join(items, \" \")), nil\n}\n\n")
+		fmt.Fprintf(&b, "// UnmarshalText decodes space-separated items.\n")
+		fmt.Fprintf(&b, "func (v *%s) UnmarshalText(text []byte) error {\n", name)
+		fmt.Fprintf(&b, "\t*v = (*v)[:0]\n\tfor _, item := range strings.Fields(string(text)) {\n")
+		if itemBase == "string" {
+			fmt.Fprintf(&b, "\t\t*v = append(*v, %s(item))\n", item)
+		} else {
+			fmt.Fprintf(&b, "\t\tparsed, err := %s\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n", g.parse(itemBase, "item"))
+			fmt.Fprintf(&b, "\t\t*v = append(*v, %s(parsed))\n", item)
+		}
+		b.WriteString("\t}\n\treturn nil\n}\n\n")
+		fmt.Fprintf(&b, "// Validate reports whether v satisfies the facets of %s.\n", name)
+		fmt.Fprintf(&b, "func (v %s) Validate() error {\n\ttext, err := v.MarshalText()\n\tif err != nil {\n\t\treturn err\n\t}\n", name)
+		fmt.Fprintf(&b, "\treturn %s.Validate(string(text))\n}\n\n", restriction)
+	} else {
+		base := g.underlying(typ)
+		fmt.Fprintf(&b, "type %s %s\n\n", name, base)
+		g.enumeration(&b, typ, name, base)
+		fmt.Fprintf(&b, "// Validate reports whether v satisfies the facets of %s.\n", name)
+		fmt.Fprintf(&b, "func (v %s) Validate() error {\n\treturn %s.Validate(%s)\n}\n\n", name, restriction, g.format(base, "v"))
+	}
+	fmt.Fprintf(&b, "var %s = %s\n\n", restriction, g.restriction(typ, name))
+	d.text = b.String()
+}
+
+// enumeration declares a constant for each enumerated value of a string
+// type whose value spells a Go identifier.
+func (g *generator) enumeration(b *bytes.Buffer, typ *xsd.TypeDefinition, name, base string) {
+	if base != "string" || !typ.Facets.Has(xsd.FacetEnumeration) || typ.Variety != xsd.VarietyAtomic {
+		return
+	}
+	var consts []string
+	for _, value := range typ.Facets.Enumeration {
+		if !strings.ContainsFunc(value, isWordRune) {
+			continue
+		}
+		consts = append(consts, fmt.Sprintf("\t%s %s = %s\n", g.unique(name+goName(value)), name, strconv.Quote(value)))
+	}
+	if len(consts) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "// Enumerated values of %s.\nconst (\n%s)\n\n", name, strings.Join(consts, ""))
+}
+
+// underlying returns the Go type that holds values of typ: the numeric or
+// boolean type of its nearest built-in ancestor, or string.
+func (g *generator) underlying(typ *xsd.TypeDefinition) string {
+	if typ.Variety != xsd.VarietyAtomic {
+		return "string"
+	}
+	for _, t := range typ.DerivationChain() {
+		if builtin(t) {
+			if goType, ok := builtinGoTypes[t.Name.Local]; ok {
+				return goType
+			}
+			return "string"
+		}
+	}
+	return "string"
+}
+
+// format returns the expression spelling the value v held in a Go type with
+// underlying type base.
+func (g *generator) format(base, v string) string {
+	switch base {
+	case "string":
+		return "string(" + v + ")"
+	case "bool":
+		g.imports["strconv"] = true
+		return "strconv.FormatBool(bool(" + v + "))"
+	case "int64", "int32", "int16", "int8":
+		g.imports["strconv"] = true
+		return "strconv.FormatInt(int64(" + v + "), 10)"
+	default:
+		g.imports["strconv"] = true
+		return "strconv.FormatUint(uint64(" + v + "), 10)"
+	}
+}
+
+// parse returns the call that parses the text s into base, for types other
+// than string.
+func (g *generator) parse(base, s string) string {
+	g.imports["strconv"] = true
+	switch base {
+	case "bool":
+		return "strconv.ParseBool(" + s + ")"
+	case "int64", "int32", "int16", "int8":
+		return "strconv.ParseInt(" + s + ", 10, " + strings.TrimPrefix(base, "int") + ")"
+	default:
+		return "strconv.ParseUint(" + s + ", 10, " + strings.TrimPrefix(base, "uint") + ")"
+	}
+}
+
+// restriction returns the datatypes.Restriction literal for typ, named
+// name. Item and member types are referred to by the restriction variables
+// of their generated types.
+func (g *generator) restriction(typ *xsd.TypeDefinition, name string) string {
+	if builtin(typ) {
+		return fmt.Sprintf("&datatypes.Restriction{Base: %q}", typ.Name.Local)
+	}
+	var b strings.Builder
+	b.WriteString("&datatypes.Restriction{\n")
+	switch typ.Variety {
+	case xsd.VarietyList:
+		fmt.Fprintf(&b, "Item: %s,\n", g.restrictionRef(typ.ItemType, name+"Item"))
+	case xsd.VarietyUnion:
+		b.WriteString("Members: []*datatypes.Restriction{\n")
+		for i, member := range typ.MemberTypes {
+			ref := g.restrictionRef(member, name+"Member"+strconv.Itoa(i+1))
+			fmt.Fprintf(&b, "%s,\n", strings.TrimPrefix(ref, "&datatypes.Restriction"))
+		}
+		b.WriteString("},\n")
+	default:
+		fmt.Fprintf(&b, "Base: %q,\n", builtinBase(typ).Name.Local)
+	}
+	if facets := g.facets(typ); len(facets) != 0 {
+		b.WriteString("Facets: []datatypes.Facet{\n")
+		for _, f := range facets {
+			fmt.Fprintf(&b, "{Name: %q, Value: %s},\n", f[0], strconv.Quote(f[1]))
+		}
+		b.WriteString("},\n")
+	}
+	if len(typ.Facets.Patterns) != 0 {
+		b.WriteString("Patterns: [][]string{\n")
+		for _, group := range typ.Facets.Patterns {
+			b.WriteString("{")
+			for i, pattern := range group {
+				if i != 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(strconv.Quote(pattern))
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (g *generator) restrictionRef(typ *xsd.TypeDefinition, name string) string {
+	if builtin(typ) {
+		return g.restriction(typ, name)
+	}
+	return lowerFirst(g.simpleType(typ, name)) + "Restriction"
+}
+
+// facets returns the facets in effect for typ as name and value pairs, in
+// schema order. Enumerations of QName and NOTATION types are left out
+// because their prefixes cannot be resolved outside the schema, and the
+// whiteSpace facet is spelled only where it differs from the built-in base.
+func (g *generator) facets(typ *xsd.TypeDefinition) [][2]string {
+	f := typ.Facets
+	var out [][2]string
+	ints := []struct {
+		facet xsd.Facet
+		name  string
+		value int
+	}{
+		{xsd.FacetLength, "length", f.Length},
+		{xsd.FacetMinLength, "minLength", f.MinLength},
+		{xsd.FacetMaxLength, "maxLength", f.MaxLength},
+		{xsd.FacetTotalDigits, "totalDigits", f.TotalDigits},
+		{xsd.FacetFractionDigits, "fractionDigits", f.FractionDigits},
+	}
+	for _, facet := range ints {
+		if f.Has(facet.facet) {
+			out = append(out, [2]string{facet.name, strconv.Itoa(facet.value)})
+		}
+	}
+	bounds := []struct {
+		facet xsd.Facet
+		name  string
+		value string
+	}{
+		{xsd.FacetMinInclusive, "minInclusive", f.MinInclusive},
+		{xsd.FacetMaxInclusive, "maxInclusive", f.MaxInclusive},
+		{xsd.FacetMinExclusive, "minExclusive", f.MinExclusive},
+		{xsd.FacetMaxExclusive, "maxExclusive", f.MaxExclusive},
+	}
+	for _, facet := range bounds {
+		if f.Has(facet.facet) {
+			out = append(out, [2]string{facet.name, facet.value})
+		}
+	}
+	if f.Has(xsd.FacetEnumeration) && !qnameBased(typ) {
+		for _, value := range f.Enumeration {
+			out = append(out, [2]string{"enumeration", value})
+		}
+	}
+	if typ.Variety == xsd.VarietyAtomic {
+		if base := builtinBase(typ); base != nil && base.Facets.WhiteSpace != f.WhiteSpace {
+			out = append(out, [2]string{"whiteSpace", [...]string{"preserve", "replace", "collapse"}[f.WhiteSpace]})
+		}
+	}
+	if f.Has(xsd.FacetExplicitTimezone) {
+		out = append(out, [2]string{"explicitTimezone", [...]string{"optional", "required", "prohibited"}[f.ExplicitTimezone]})
+	}
+	return out
+}
+
+func qnameBased(typ *xsd.TypeDefinition) bool {
+	if typ.Variety != xsd.VarietyAtomic || typ.Primitive == nil {
+		return false
+	}
+	return typ.Primitive.Name.Local == "QName" || typ.Primitive.Name.Local == "NOTATION"
+}
+
+// breakCycles turns value fields that close a cycle of structs into pointer
+// fields, which Go requires for recursive types.
+func (g *generator) breakCycles() {
+	state := make(map[*goStruct]uint8)
+	var visit func(*goStruct)
+	visit = func(st *goStruct) {
+		state[st] = 1
+		for _, f := range st.fields {
+			if f.ref == nil {
+				continue
+			}
+			switch state[f.ref] {
+			case 0:
+				visit(f.ref)
+			case 1:
+				f.mode = fieldPointer
+			}
+		}
+		state[st] = 2
+	}
+	for _, d := range g.decls {
+		if d.st != nil && state[d.st] == 0 {
+			visit(d.st)
+		}
+	}
+}
+
+func (g *generator) render(cfg config) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by xsd2go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", cfg.pkg)
+	for _, d := range g.decls {
+		if d.st != nil && slices.ContainsFunc(d.st.fields, func(f *field) bool { return strings.HasPrefix(f.typ, "xml.") }) {
+			g.imports["encoding/xml"] = true
+		}
+	}
+	if len(g.imports) != 0 {
+		b.WriteString("import (\n")
+		paths := slices.Sorted(maps.Keys(g.imports))
+		for i, path := range paths {
+			if i != 0 && strings.Contains(path, ".") && !strings.Contains(paths[i-1], ".") {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+	for _, d := range g.decls {
+		if d.st == nil {
+			b.WriteString(d.text)
+			continue
+		}
+		b.WriteString(d.st.doc)
+		fmt.Fprintf(&b, "type %s struct {\n", d.st.name)
+		for _, f := range d.st.fields {
+			typ := f.typ
+			switch f.mode {
+			case fieldPointer:
+				typ = "*" + typ
+			case fieldSlice:
+				typ = "[]" + typ
+			}
+			if f.embedded {
+				b.WriteString("\t" + typ)
+			} else {
+				b.WriteString("\t" + f.name + " " + typ)
+			}
+			if f.tag != "" {
+				fmt.Fprintf(&b, " `xml:%q`", f.tag)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n\n")
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) newStruct(name, doc string, annotation xsd.Annotation) *goStruct {
+	var b bytes.Buffer
+	writeDoc(&b, doc, annotation)
+	st := &goStruct{
+		name:     name,
+		doc:      b.String(),
+		elements: make(map[xml.Name]*field),
+		used:     make(map[string]bool),
+	}
+	g.decls = append(g.decls, &decl{st: st})
+	return st
+}
+
+// add appends f, renaming it when another field already has its name.
+func (st *goStruct) add(f *field) {
+	name := f.name
+	for i := 2; st.used[name]; i++ {
+		name = f.name + strconv.Itoa(i)
+	}
+	f.name = name
+	st.used[name] = true
+	st.fields = append(st.fields, f)
+}
+
+// unique reserves a package-level identifier based on name.
+func (g *generator) unique(name string) string {
+	out := name
+	for i := 2; g.used[out]; i++ {
+		out = name + strconv.Itoa(i)
+	}
+	g.used[out] = true
+	return out
+}
+
+// writeDoc writes a doc comment of the sentence doc followed by the
+// schema's documentation of the component.
+func writeDoc(b *bytes.Buffer, doc string, annotation xsd.Annotation) {
+	fmt.Fprintf(b, "// %s\n", doc)
+	for _, d := range annotation.Documentation {
+		text := strings.TrimSpace(d.Text)
+		if text == "" {
+			continue
+		}
+		b.WriteString("//\n")
+		for line := range strings.Lines(text) {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				b.WriteString("//\n")
+			} else {
+				b.WriteString("// " + line + "\n")
+			}
+		}
+	}
+}
+
+func builtin(typ *xsd.TypeDefinition) bool {
+	return typ.Name.Space == xsdNamespace
+}
+
+// builtinBase returns the nearest built-in ancestor of typ.
+func builtinBase(typ *xsd.TypeDefinition) *xsd.TypeDefinition {
+	for _, t := range typ.DerivationChain() {
+		if builtin(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+// xmlName returns the name part of an encoding/xml struct tag.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + " " + name.Local
+}
+
+func clark(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+// initialisms are the words goName spells in upper case, as Go names do.
+var initialisms = map[string]bool{
+	"ID": true, "URI": true, "URL": true, "UUID": true, "XML": true, "HTML": true,
+	"HTTP": true, "JSON": true, "API": true,
+}
+
+// goName returns an exported Go identifier for an XML name. Words split at
+// characters other than letters and digits and at lower-to-upper case
+// changes start with an upper-case letter, or are upper case when they are
+// initialisms. Names that do not start with a letter get an X prefix.
+func goName(s string) string {
+	var b strings.Builder
+	for _, word := range words(s) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	out := b.String()
+	if first, _ := utf8.DecodeRuneInString(out); !unicode.IsUpper(first) {
+		out = "X" + out
+	}
+	return out
+}
+
+func words(s string) []string {
+	var out []string
+	start := -1
+	prev := rune(0)
+	for i, r := range s {
+		switch {
+		case !isWordRune(r):
+			if start >= 0 {
+				out = append(out, s[start:i])
+			}
+			start = -1
+		case start < 0:
+			start = i
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			out = append(out, s[start:i])
+			start = i
+		}
+		prev = r
+	}
+	if start >= 0 {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
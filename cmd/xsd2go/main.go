@@ -0,0 +1,109 @@
+// Package main implements xsd2go, which compiles a schema set and emits Go
+// types with encoding/xml struct tags for its elements and types.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/jacoelho/xsd"
+)
+
+type config struct {
+	schemas []string
+	pkg     string
+	out     string
+	xml11   bool
+	xsd11   bool
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseArgs(args)
+	if err != nil {
+		return writeStatus(stderr, 2, "%v\n", err)
+	}
+	sources := make([]xsd.SchemaSource, 0, len(cfg.schemas))
+	for _, path := range cfg.schemas {
+		sources = append(sources, xsd.File(path))
+	}
+	engine, err := xsd.CompileWithOptions(ctx, xsd.CompileOptions{
+		XML11:             cfg.xml11,
+		XSD11:             cfg.xsd11,
+		RetainAnnotations: true,
+	}, sources...)
+	if err != nil {
+		return writeStatus(stderr, 1, "%s fails to compile\n%v\n", strings.Join(cfg.schemas, ", "), err)
+	}
+	model, err := engine.Model()
+	if err != nil {
+		return writeStatus(stderr, 1, "%v\n", err)
+	}
+	src, err := generate(cfg, model)
+	if err != nil {
+		return writeStatus(stderr, 1, "%v\n", err)
+	}
+	return writeOutput(stdout, stderr, cfg.out, src)
+}
+
+// writeOutput writes data to the file at path, or to stdout when path is
+// empty.
+func writeOutput(stdout, stderr io.Writer, path string, data []byte) int {
+	if path == "" {
+		if _, err := stdout.Write(data); err != nil {
+			return 2
+		}
+		return 0
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec // Generated files are meant to be readable.
+		return writeStatus(stderr, 1, "%v\n", err)
+	}
+	return 0
+}
+
+func writeStatus(w io.Writer, code int, format string, args ...any) int {
+	if _, err := fmt.Fprintf(w, format, args...); err != nil {
+		return 2
+	}
+	return code
+}
+
+func parseArgs(args []string) (config, error) {
+	var cfg config
+	fs := flag.NewFlagSet("xsd2go", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("schema", "schema path; repeat to compile a schema set", func(path string) error {
+		cfg.schemas = append(cfg.schemas, path)
+		return nil
+	})
+	fs.StringVar(&cfg.pkg, "package", "", "package name of the generated file")
+	fs.StringVar(&cfg.out, "o", "", "output path; standard output when empty")
+	fs.BoolVar(&cfg.xml11, "xml11", false, "accept XML 1.1 schema documents")
+	fs.BoolVar(&cfg.xsd11, "xsd11", false, "enable supported XSD 1.1 schema components")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if len(cfg.schemas) == 0 {
+		return cfg, errors.New("--schema is required")
+	}
+	if !token.IsIdentifier(cfg.pkg) || cfg.pkg == "_" {
+		return cfg, errors.New("--package must be a Go package name")
+	}
+	if fs.NArg() != 0 {
+		return cfg, errors.New("unexpected arguments")
+	}
+	return cfg, nil
+}
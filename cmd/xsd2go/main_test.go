@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+const ordersSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" xmlns:c="urn:common"
+    targetNamespace="urn:orders" elementFormDefault="qualified">
+  <xs:import namespace="urn:common" schemaLocation="common.xsd"/>
+  <xs:include schemaLocation="address.xsd"/>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="id" type="c:code"/>
+        <xs:element name="line" type="o:line" maxOccurs="unbounded"/>
+        <xs:choice>
+          <xs:element name="card" type="xs:string"/>
+          <xs:element name="invoice" type="xs:boolean"/>
+        </xs:choice>
+        <xs:element name="tags" type="o:tags" minOccurs="0"/>
+        <xs:element ref="o:party" minOccurs="0"/>
+        <xs:element ref="o:address" minOccurs="0"/>
+      </xs:sequence>
+      <xs:attribute name="status" type="o:status" use="required"/>
+      <xs:attribute name="priority">
+        <xs:simpleType>
+          <xs:restriction base="xs:int"><xs:minInclusive value="1"/><xs:maxExclusive value="10"/></xs:restriction>
+        </xs:simpleType>
+      </xs:attribute>
+    </xs:complexType>
+  </xs:element>
+  <xs:complexType name="line">
+    <xs:sequence>
+      <xs:element name="qty" type="xs:unsignedShort"/>
+      <xs:element name="price" type="o:money"/>
+      <xs:element name="line" type="o:line" minOccurs="0" maxOccurs="unbounded"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="money">
+    <xs:simpleContent>
+      <xs:extension base="o:amount"><xs:attribute name="currency" type="xs:string"/></xs:extension>
+    </xs:simpleContent>
+  </xs:complexType>
+  <xs:simpleType name="amount">
+    <xs:restriction base="xs:decimal"><xs:minExclusive value="0"/><xs:fractionDigits value="2"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="status">
+    <xs:restriction base="xs:token">
+      <xs:enumeration value="open"/><xs:enumeration value="on-hold"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="tags"><xs:list itemType="o:status"/></xs:simpleType>
+  <xs:simpleType name="sizes">
+    <xs:restriction>
+      <xs:simpleType><xs:list itemType="xs:int"/></xs:simpleType>
+      <xs:maxLength value="3"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:element name="party" type="o:partyType" abstract="true"/>
+  <xs:complexType name="partyType"><xs:sequence><xs:element name="name" type="xs:string"/></xs:sequence></xs:complexType>
+  <xs:element name="buyer" type="o:partyType" substitutionGroup="o:party"/>
+  <xs:element name="seller" substitutionGroup="o:party">
+    <xs:complexType>
+      <xs:complexContent>
+        <xs:extension base="o:partyType"><xs:attribute name="vat" type="o:sizes"/></xs:extension>
+      </xs:complexContent>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+const commonSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:common">
+  <xs:simpleType name="code">
+    <xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}\d{3}"/></xs:restriction>
+  </xs:simpleType>
+</xs:schema>`
+
+const addressSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">
+  <xs:element name="address">
+    <xs:complexType>
+      <xs:sequence><xs:element name="street" type="xs:string"/></xs:sequence>
+      <xs:attribute name="kind" type="kind"/>
+    </xs:complexType>
+  </xs:element>
+  <xs:simpleType name="kind">
+    <xs:restriction base="xs:string"><xs:enumeration value="home"/><xs:enumeration value="work"/></xs:restriction>
+  </xs:simpleType>
+</xs:schema>`
+
+func TestParseArgsRejectsInvalidInputs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "missing_schema", args: []string{"--package", "p"}, want: "--schema is required"},
+		{name: "missing_package", args: []string{"--schema", "schema.xsd"}, want: "--package must be a Go package name"},
+		{name: "keyword_package", args: []string{"--schema", "schema.xsd", "--package", "type"}, want: "--package must be a Go package name"},
+		{name: "extra_args", args: []string{"--schema", "schema.xsd", "--package", "p", "doc.xml"}, want: "unexpected arguments"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseArgs(test.args)
+			if err == nil {
+				t.Fatal("parseArgs() succeeded")
+			}
+			if err.Error() != test.want {
+				t.Fatalf("parseArgs() error = %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRunReportsSchemaErrors(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeTestFile(t, dir, "schema.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="stamp" type="xs:dateTimeStamp"/>
+</xs:schema>`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--schema", schema, "--package", "p"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), string(xsderrors.CodeSchemaReference)) {
+		t.Fatalf("run() stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestGenerateNamesTypes(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeTestFile(t, dir, "orders.xsd", ordersSchema)
+	writeTestFile(t, dir, "common.xsd", commonSchema)
+	writeTestFile(t, dir, "address.xsd", addressSchema)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--schema", schema, "--package", "orders"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	src := stdout.String()
+	for _, want := range []string{
+		"type Order struct {\n\tXMLName  xml.Name       `xml:\"urn:orders order\"`",
+		"\tLine     []Line         `xml:\"urn:orders line\"`",
+		"\tCard     *string        `xml:\"urn:orders card\"`",
+		"\tBuyer    *PartyType     `xml:\"urn:orders buyer\"`",
+		"\tPriority *OrderPriority `xml:\"priority,attr,omitempty\"`",
+		"type Code string",
+		"type Sizes []int32",
+		"\tStatusOnHold Status = \"on-hold\"",
+		"\tKindHome Kind = \"home\"",
+	} {
+		if !strings.Contains(src, want) {
+			t.Fatalf("generated source lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "type Party ") {
+		t.Fatalf("generated source declares abstract element party:\n%s", src)
+	}
+}
+
+func TestGeneratedTypesRoundTrip(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	schema := writeTestFile(t, dir, "orders.xsd", ordersSchema)
+	writeTestFile(t, dir, "common.xsd", commonSchema)
+	writeTestFile(t, dir, "address.xsd", addressSchema)
+	if err := os.Mkdir(filepath.Join(dir, "orders"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--schema", schema, "--package", "orders", "-o", filepath.Join(dir, "orders", "orders.go")}, nil, &stderr); code != 0 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	writeTestFile(t, dir, "go.mod", "module example.com/generated\n\ngo 1.26.2\n\nrequire github.com/jacoelho/xsd v0.0.0\n\nreplace github.com/jacoelho/xsd => "+root+"\n")
+	writeTestFile(t, dir, "main.go", `package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"example.com/generated/orders"
+	"github.com/jacoelho/xsd"
+)
+
+const doc = `+"`"+`<order xmlns="urn:orders" status="open" priority="3"><id>AB123</id><line><qty>2</qty><price currency="USD">9.50</price><line><qty>1</qty><price>1</price></line></line><invoice>true</invoice><tags>open on-hold</tags><seller vat="1 2"><name>S</name></seller><address kind="home"><street>Main</street></address></order>`+"`"+`
+
+func main() {
+	var order orders.Order
+	if err := xml.Unmarshal([]byte(doc), &order); err != nil {
+		panic(err)
+	}
+	fmt.Println(order.Line[0].Line[0].Qty, *order.Invoice, *order.Tags, *order.Seller.Vat, order.Address.Street)
+	for _, err := range []error{
+		order.ID.Validate(), order.Tags.Validate(), order.Line[0].Price.Value.Validate(), order.Priority.Validate(),
+		orders.Code("A1").Validate(), orders.Tags{"closed"}.Validate(), orders.Sizes{1, 2, 3, 4}.Validate(),
+		orders.Amount("0.125").Validate(), orders.OrderPriority(10).Validate(),
+	} {
+		fmt.Println(err == nil)
+	}
+	out, err := xml.Marshal(order)
+	if err != nil {
+		panic(err)
+	}
+	engine, err := xsd.Compile(context.Background(), xsd.File("orders.xsd"))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(engine.Validate(context.Background(), bytes.NewReader(out)))
+}
+`)
+	cmd := exec.CommandContext(t.Context(), "go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	got, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run generated package failed: %v\n%s", err, got)
+	}
+	want := "1 true [open on-hold] [1 2] Main\n" + strings.Repeat("true\n", 4) + strings.Repeat("false\n", 5) + "<nil>\n"
+	if string(got) != want {
+		t.Fatalf("generated types output = %q, want %q", got, want)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"order":          "Order",
+		"purchase-order": "PurchaseOrder",
+		"line_item.v2":   "LineItemV2",
+		"id":             "ID",
+		"orderId":        "OrderID",
+		"url":            "URL",
+		"2nd":            "X2nd",
+		"_":              "X",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Fatalf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", path, err)
+	}
+	return path
+}
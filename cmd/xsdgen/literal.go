@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+)
+
+// tablesPackage is the package name the generated file qualifies table types
+// with.
+const tablesPackage = "schematables"
+
+// writeLiteral writes v as a Go expression. Zero struct fields are omitted and
+// element types are elided inside slices, so the output grows with the
+// content of the tables rather than with their shape. A nil slice is omitted
+// or written as nil and an empty one as an empty literal, so the distinction
+// survives.
+func writeLiteral(b *bytes.Buffer, v reflect.Value, elided bool) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if !elided {
+			b.WriteByte('&')
+		}
+		writeLiteral(b, v.Elem(), elided)
+	case reflect.Struct:
+		if !elided {
+			b.WriteString(typeName(v.Type()))
+		}
+		b.WriteString("{")
+		if !v.IsZero() {
+			for i := range v.NumField() {
+				field := v.Field(i)
+				if field.IsZero() {
+					continue
+				}
+				b.WriteString("\n")
+				b.WriteString(v.Type().Field(i).Name)
+				b.WriteString(": ")
+				writeLiteral(b, field, false)
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if !elided {
+			b.WriteString(typeName(v.Type()))
+		}
+		b.WriteString("{")
+		// Numbers and booleans share a line; strings and composite values
+		// take one line each.
+		inline := isScalar(v.Type().Elem().Kind())
+		for i := range v.Len() {
+			if inline {
+				if i != 0 {
+					b.WriteString(", ")
+				}
+				writeLiteral(b, v.Index(i), true)
+				continue
+			}
+			b.WriteString("\n")
+			writeLiteral(b, v.Index(i), true)
+			b.WriteString(",")
+		}
+		if !inline && v.Len() != 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()))
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Int:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	default:
+		panic("xsdgen: no literal form for " + v.Type().String())
+	}
+}
+
+func isScalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Int:
+		return true
+	}
+	return false
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + typeName(t.Elem())
+	}
+	if t.PkgPath() != "" {
+		return tablesPackage + "." + t.Name()
+	}
+	return t.Name()
+}
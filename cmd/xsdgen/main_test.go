@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+const xsdgenTestSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="v" type="xs:int"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+func TestParseArgsRejectsInvalidInputs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "missing_schema", args: []string{"--package", "p"}, want: "--schema is required"},
+		{name: "missing_package", args: []string{"--schema", "schema.xsd"}, want: "--package must be a Go package name"},
+		{name: "keyword_package", args: []string{"--schema", "schema.xsd", "--package", "func"}, want: "--package must be a Go package name"},
+		{name: "invalid_func", args: []string{"--schema", "schema.xsd", "--package", "p", "--func", "New-Engine"}, want: "--func must be a Go identifier"},
+		{name: "extra_args", args: []string{"--schema", "schema.xsd", "--package", "p", "doc.xml"}, want: "unexpected arguments"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseArgs(test.args)
+			if err == nil {
+				t.Fatal("parseArgs() succeeded")
+			}
+			if err.Error() != test.want {
+				t.Fatalf("parseArgs() error = %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRunReportsSchemaErrors(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeXSDGenTestFile(t, dir, "schema.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="stamp" type="xs:dateTimeStamp"/>
+</xs:schema>`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--schema", schema, "--package", "p"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), string(xsderrors.CodeSchemaReference)) {
+		t.Fatalf("run() stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestGenerateDoesNotDependOnSchemaDirectory(t *testing.T) {
+	var outputs []string
+	for range 2 {
+		schema := writeXSDGenTestFile(t, t.TempDir(), "schema.xsd", xsdgenTestSchema)
+		var stdout, stderr bytes.Buffer
+		if code := run(context.Background(), []string{"--schema", schema, "--package", "p"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+		}
+		outputs = append(outputs, stdout.String())
+	}
+	if outputs[0] != outputs[1] {
+		t.Fatal("generated files differ for the same schema in different directories")
+	}
+	if !strings.Contains(outputs[0], "// Engine returns the engine compiled from schema.xsd.") {
+		t.Fatalf("generated file = %q, want the schema base name in the doc comment", outputs[0])
+	}
+	if !strings.Contains(outputs[0], "var engineTables = schematables.Schema{") {
+		t.Fatalf("generated file = %q, want the schema tables as a composite literal", outputs[0])
+	}
+}
+
+func TestGeneratedNamesChangeCaseOfFirstRune(t *testing.T) {
+	tests := []struct {
+		fn, loader, tables string
+	}{
+		{fn: "Engine", loader: "loadEngine", tables: "engineTables"},
+		{fn: "orders", loader: "loadOrders", tables: "ordersTables"},
+		{fn: "Éngine", loader: "loadÉngine", tables: "éngineTables"},
+		{fn: "über", loader: "loadÜber", tables: "überTables"},
+	}
+	for _, test := range tests {
+		if got := loaderName(test.fn); got != test.loader {
+			t.Errorf("loaderName(%q) = %q, want %q", test.fn, got, test.loader)
+		}
+		if got := tablesName(test.fn); got != test.tables {
+			t.Errorf("tablesName(%q) = %q, want %q", test.fn, got, test.tables)
+		}
+	}
+}
+
+func TestGeneratedEngineValidates(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	schema := writeXSDGenTestFile(t, dir, "schema.xsd", xsdgenTestSchema)
+	out := filepath.Join(dir, "engine_gen.go")
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--schema", schema, "--package", "main", "--func", "órders", "-o", out}, nil, &stderr); code != 0 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	writeXSDGenTestFile(t, dir, "go.mod", "module example.com/generated\n\ngo 1.26.2\n\nrequire github.com/jacoelho/xsd v0.0.0\n\nreplace github.com/jacoelho/xsd => "+root+"\n")
+	writeXSDGenTestFile(t, dir, "main.go", `package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func main() {
+	engine, err := órders()
+	if err != nil {
+		panic(err)
+	}
+	for _, doc := range []string{"<root><v>7</v></root>", "<root><v>x</v></root>"} {
+		fmt.Println(engine.Validate(context.Background(), strings.NewReader(doc)) == nil)
+	}
+}
+`)
+	cmd := exec.CommandContext(t.Context(), "go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	got, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run generated package failed: %v\n%s", err, got)
+	}
+	if string(got) != "true\nfalse\n" {
+		t.Fatalf("generated engine output = %q", got)
+	}
+}
+
+func writeXSDGenTestFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", path, err)
+	}
+	return path
+}
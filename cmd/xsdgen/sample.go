@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/jacoelho/xsd"
+)
+
+type sampleConfig struct {
+	schemas []string
+	root    xml.Name
+	out     string
+	opts    xsd.SampleOptions
+	xml11   bool
+	xsd11   bool
+}
+
+func runSample(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseSampleArgs(args)
+	if err != nil {
+		return writeStatus(stderr, 2, "%v\n", err)
+	}
+	engine, err := compileSchemas(ctx, cfg.schemas, xsd.CompileOptions{XML11: cfg.xml11, XSD11: cfg.xsd11})
+	if err != nil {
+		return writeStatus(stderr, 1, "%s fails to compile\n%v\n", strings.Join(cfg.schemas, ", "), err)
+	}
+	doc, err := engine.Sample(ctx, cfg.root, cfg.opts)
+	if err != nil {
+		return writeStatus(stderr, 1, "%v\n", err)
+	}
+	return writeOutput(stdout, stderr, cfg.out, doc)
+}
+
+func parseSampleArgs(args []string) (sampleConfig, error) {
+	var cfg sampleConfig
+	fs := flag.NewFlagSet("xsdgen sample", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("schema", "schema path; repeat to compile a schema set", func(path string) error {
+		cfg.schemas = append(cfg.schemas, path)
+		return nil
+	})
+	root := fs.String("root", "", "root element name, as local or {namespace}local")
+	choice := fs.String("choice", "shortest", "choice branch policy: shortest, first or random")
+	fs.Uint64Var(&cfg.opts.Seed, "seed", 0, "seed of the random choice policy")
+	fs.IntVar(&cfg.opts.Repeat, "repeat", 0, "occurrences of repeatable particles")
+	fs.BoolVar(&cfg.opts.Optional, "optional", false, "include optional particles and attributes")
+	fs.StringVar(&cfg.out, "o", "", "output path; standard output when empty")
+	fs.BoolVar(&cfg.xml11, "xml11", false, "accept XML 1.1 schema documents")
+	fs.BoolVar(&cfg.xsd11, "xsd11", false, "enable supported XSD 1.1 schema components")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if len(cfg.schemas) == 0 {
+		return cfg, errors.New("--schema is required")
+	}
+	name, ok := parseName(*root)
+	if !ok {
+		return cfg, errors.New("--root must be local or {namespace}local")
+	}
+	cfg.root = name
+	switch *choice {
+	case "shortest":
+		cfg.opts.Choice = xsd.SampleChoiceShortest
+	case "first":
+		cfg.opts.Choice = xsd.SampleChoiceFirst
+	case "random":
+		cfg.opts.Choice = xsd.SampleChoiceRandom
+	default:
+		return cfg, errors.New("--choice must be shortest, first or random")
+	}
+	if cfg.opts.Repeat < 0 {
+		return cfg, errors.New("--repeat must not be negative")
+	}
+	if fs.NArg() != 0 {
+		return cfg, errors.New("unexpected arguments")
+	}
+	return cfg, nil
+}
+
+// parseName parses a name in Clark notation.
+func parseName(s string) (xml.Name, bool) {
+	if rest, ok := strings.CutPrefix(s, "{"); ok {
+		space, local, ok := strings.Cut(rest, "}")
+		return xml.Name{Space: space, Local: local}, ok && local != ""
+	}
+	return xml.Name{Local: s}, s != ""
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jacoelho/xsd"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+func TestParseSampleArgs(t *testing.T) {
+	cfg, err := parseSampleArgs([]string{"--schema", "a.xsd", "--schema", "b.xsd", "--root", "{urn:x}order", "--choice", "random", "--seed", "9", "--repeat", "2", "--optional"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := xsd.SampleOptions{Seed: 9, Repeat: 2, Choice: xsd.SampleChoiceRandom, Optional: true}
+	if len(cfg.schemas) != 2 || cfg.root != (xml.Name{Space: "urn:x", Local: "order"}) || cfg.opts != want {
+		t.Fatalf("parseSampleArgs() = %+v", cfg)
+	}
+}
+
+func TestParseSampleArgsRejectsInvalidInputs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "missing_schema", args: []string{"--root", "r"}, want: "--schema is required"},
+		{name: "missing_root", args: []string{"--schema", "schema.xsd"}, want: "--root must be local or {namespace}local"},
+		{name: "unclosed_root", args: []string{"--schema", "schema.xsd", "--root", "{urn:x"}, want: "--root must be local or {namespace}local"},
+		{name: "choice", args: []string{"--schema", "schema.xsd", "--root", "r", "--choice", "last"}, want: "--choice must be shortest, first or random"},
+		{name: "repeat", args: []string{"--schema", "schema.xsd", "--root", "r", "--repeat", "-1"}, want: "--repeat must not be negative"},
+		{name: "extra_args", args: []string{"--schema", "schema.xsd", "--root", "r", "doc.xml"}, want: "unexpected arguments"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseSampleArgs(test.args)
+			if err == nil {
+				t.Fatal("parseSampleArgs() succeeded")
+			}
+			if err.Error() != test.want {
+				t.Fatalf("parseSampleArgs() error = %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRunSampleWritesValidInstance(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeXSDGenTestFile(t, dir, "schema.xsd", xsdgenTestSchema)
+	out := filepath.Join(dir, "sample.xml")
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"sample", "--schema", schema, "--root", "root", "--repeat", "2", "-o", out}, nil, &stderr); code != 0 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	doc, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<root>\n  <v>0</v>\n</root>\n"; string(doc) != want {
+		t.Fatalf("sample = %q, want %q", doc, want)
+	}
+}
+
+func TestRunSampleReportsUnknownRoot(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeXSDGenTestFile(t, dir, "schema.xsd", xsdgenTestSchema)
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"sample", "--schema", schema, "--root", "{urn:x}root"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() code = %d, stderr = %q", code, stderr.String())
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), string(xsderrors.CodeValidationRoot)) {
+		t.Fatalf("run() stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+}
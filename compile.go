@@ -2,14 +2,27 @@ package xsd
 
 import (
 	"context"
+	"slices"
 
 	"github.com/jacoelho/xsd/internal/compile"
+	"github.com/jacoelho/xsd/internal/introspect"
 	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/schematables"
+	"github.com/jacoelho/xsd/xsderrors"
 )
 
 // Engine is an immutable compiled schema validator.
 type Engine struct {
 	rt *runtime.Schema
+	// sources, documents and opts recompile the schema with instance
+	// schema-location hints; schemaLocations caches those runtimes.
+	// documents holds the bytes of every schema document read by Compile
+	// when RetainSchemaDocuments is set, so the recompile does not read the
+	// sources again.
+	sources         []SchemaSource
+	documents       compile.Documents
+	opts            CompileOptions
+	schemaLocations *schemaLocationCache
 }
 
 // CompileOptions controls schema compilation resource limits.
@@ -44,6 +57,38 @@ type CompileOptions struct {
 	MaxSubstitutionClosureEntries int
 	// MaxSimpleUnionMemberEntries caps aggregate flattened simple-union members. Zero uses the default.
 	MaxSimpleUnionMemberEntries int
+	// Charsets decodes schema documents whose XML declaration names an
+	// encoding beyond the built-in UTF-8, UTF-16, ISO-8859-1, windows-1252
+	// and US-ASCII support.
+	Charsets Charsets
+	// XML11 accepts schema documents that declare XML version 1.1, applying
+	// its NEL and LS line-end normalization and its character rules. Without
+	// it such documents fail with CodeUnsupportedXML11.
+	XML11 bool
+	// XSD11 enables the supported XSD 1.1 schema components: xs:assert on
+	// complex types and the xs:assertion facet, xs:alternative type
+	// alternatives, xs:openContent and xs:defaultOpenContent, negated
+	// wildcards with notNamespace and notQName, xs:override, the
+	// xpathDefaultNamespace attribute, the explicitTimezone facet, and the
+	// xs:anyAtomicType, xs:dateTimeStamp, xs:dayTimeDuration,
+	// xs:yearMonthDuration and xs:error datatypes. Without it the components
+	// fail with CodeUnsupportedXSD11 and the datatypes are unknown types.
+	XSD11 bool
+	// RetainAnnotations keeps the xs:documentation and xs:appinfo content of
+	// schema components so Model can report it. Each retained documentation
+	// or appinfo payload is bounded by MaxSchemaTokenBytes.
+	RetainAnnotations bool
+	// RetainSourceLocations keeps the schema document name, line and column
+	// of element and attribute declarations, attribute uses, type
+	// definitions, facets and identity constraints, so validation errors
+	// report the violated component in xsderrors.Error.SchemaLocation.
+	RetainSourceLocations bool
+	// RetainSchemaDocuments keeps the bytes of every schema document read by
+	// Compile, bounded by MaxSchemaTotalBytes, so that
+	// ValidateOptions.SchemaLocationResolver compiles hinted documents
+	// against the schema documents as they were compiled. Without it that
+	// compilation reads File and Open sources again.
+	RetainSchemaDocuments bool
 }
 
 // Compile compiles schema sources into an immutable validation engine. ctx must
@@ -54,14 +99,94 @@ func Compile(ctx context.Context, sources ...SchemaSource) (*Engine, error) {
 
 // CompileWithOptions compiles schema sources with explicit resource limits.
 func CompileWithOptions(ctx context.Context, opts CompileOptions, sources ...SchemaSource) (*Engine, error) {
-	rt, err := compile.CompileMappedSources(ctx, internalCompileOptions(opts), sources, internalSchemaSource)
+	internal, err := internalCompileOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RetainSchemaDocuments {
+		internal.Documents = make(compile.Documents)
+	}
+	rt, err := compile.CompileMappedSources(ctx, internal, sources, internalSchemaSource)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{
+		rt:              rt,
+		sources:         slices.Clone(sources),
+		documents:       internal.Documents,
+		opts:            opts,
+		schemaLocations: new(schemaLocationCache),
+	}, nil
+}
+
+// Model returns a read-only component model of the compiled schema. Every call
+// builds a new model, so callers may keep or modify it freely.
+func (e *Engine) Model() (*SchemaModel, error) {
+	var rt *runtime.Schema
+	if e != nil {
+		rt = e.rt
+	}
+	model, err := introspect.Build(rt)
+	if err != nil {
+		return nil, err
+	}
+	return adaptSchemaModel(model), nil
+}
+
+// MarshalBinary encodes the compiled schema as a versioned, checksummed
+// snapshot that LoadEngine restores without compiling. It encodes the
+// engine's schema tables in memory; schema sources are not read again.
+func (e *Engine) MarshalBinary() ([]byte, error) {
+	if e == nil || e.rt == nil {
+		return nil, xsderrors.InternalInvariant("schema snapshot requires a compiled engine")
+	}
+	return runtime.EncodeSchema(e.rt)
+}
+
+// LoadEngine restores an engine from a snapshot written by
+// Engine.MarshalBinary. The snapshot's schema tables are checked against the
+// same invariants as a freshly compiled schema before the engine is returned.
+// A snapshot that is not from this package version, or is truncated or
+// corrupted, fails with CodeSchemaSnapshot. Snapshots carry no schema sources,
+// so a loaded engine ignores ValidateOptions.SchemaLocationResolver.
+func LoadEngine(ctx context.Context, data []byte) (*Engine, error) {
+	rt, err := runtime.DecodeSchema(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{rt: rt}, nil
+}
+
+// Tables returns the compiled schema tables as plain Go values, which
+// cmd/xsdgen writes as composite literals. Like MarshalBinary it reads the
+// tables the engine holds in memory, and every call returns new values.
+func (e *Engine) Tables() (*schematables.Schema, error) {
+	if e == nil || e.rt == nil {
+		return nil, xsderrors.InternalInvariant("schema tables require a compiled engine")
+	}
+	return runtime.ExportTables(e.rt)
+}
+
+// LoadEngineTables publishes tables returned by Engine.Tables as an engine
+// without parsing or compiling schema documents; pattern facets and XPath
+// expressions are compiled from their sources. Tables from another module
+// version or with out-of-range shared value references fail with
+// CodeSchemaSnapshot, and the tables are checked against the same invariants
+// as a freshly compiled schema. The engine does not retain tables. Like a
+// loaded snapshot, it ignores ValidateOptions.SchemaLocationResolver.
+func LoadEngineTables(ctx context.Context, tables *schematables.Schema) (*Engine, error) {
+	rt, err := runtime.ImportTables(ctx, tables)
 	if err != nil {
 		return nil, err
 	}
 	return &Engine{rt: rt}, nil
 }
 
-func internalCompileOptions(opts CompileOptions) compile.Options {
+func internalCompileOptions(opts CompileOptions) (compile.Options, error) {
+	charsets, err := adaptPublicCharsets(opts.Charsets, compileOptionError)
+	if err != nil {
+		return compile.Options{}, err
+	}
 	return compile.Options{
 		MaxSchemaDepth:                opts.MaxSchemaDepth,
 		MaxSchemaAttributes:           opts.MaxSchemaAttributes,
@@ -77,5 +202,14 @@ func internalCompileOptions(opts CompileOptions) compile.Options {
 		MaxContentModelStates:         opts.MaxContentModelStates,
 		MaxSubstitutionClosureEntries: opts.MaxSubstitutionClosureEntries,
 		MaxSimpleUnionMemberEntries:   opts.MaxSimpleUnionMemberEntries,
-	}
+		Charsets:                      charsets,
+		XML11:                         opts.XML11,
+		XSD11:                         opts.XSD11,
+		RetainAnnotations:             opts.RetainAnnotations,
+		RetainSourceLocations:         opts.RetainSourceLocations,
+	}, nil
+}
+
+func compileOptionError(msg string) error {
+	return xsderrors.SchemaCompile(xsderrors.CodeSchemaLimit, msg)
 }
@@ -0,0 +1,100 @@
+package datatypes
+
+import "github.com/jacoelho/xsd/internal/runtime"
+
+// Order is the result of comparing two values in the XML Schema partial order.
+type Order int8
+
+const (
+	// OrderIncomparable reports values with no order between them: values of
+	// different primitive types, unordered primitives that are not equal,
+	// and date/time or duration values whose order is indeterminate.
+	OrderIncomparable Order = iota
+	// OrderLess reports that the first value precedes the second.
+	OrderLess
+	// OrderEqual reports values that are equal in the value space.
+	OrderEqual
+	// OrderGreater reports that the first value follows the second.
+	OrderGreater
+)
+
+// Equal reports whether a and b are the same value in the XML Schema value
+// space. Values derived from the same primitive type compare by value, so
+// int 5 equals decimal 5.0, while float and double values are never equal.
+// List values are equal when their items are pairwise equal.
+func Equal(a, b Value) bool {
+	if a.list || b.list {
+		if !a.list || !b.list || len(a.items) != len(b.items) {
+			return false
+		}
+		for i := range a.items {
+			if !Equal(a.items[i], b.items[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if a.primitive != b.primitive {
+		return false
+	}
+	switch a.primitive {
+	case QName, Notation:
+		return a.qname == b.qname
+	default:
+		return runtime.EqualPrimitiveActualValues(a.actual, a.canonical, b.actual, b.canonical)
+	}
+}
+
+// Compare orders a and b in the XML Schema partial order. Primitives without
+// an order report OrderEqual or OrderIncomparable.
+func Compare(a, b Value) Order {
+	if a.list || b.list || a.primitive != b.primitive {
+		if Equal(a, b) {
+			return OrderEqual
+		}
+		return OrderIncomparable
+	}
+	switch a.primitive {
+	case Decimal:
+		return orderFromInt(runtime.CompareDecimalValues(a.actual.Decimal, b.actual.Decimal))
+	case Float, Double:
+		return orderFromRelation(runtime.FloatRelation(a.actual.Float, b.actual.Float))
+	case Duration:
+		return orderFromRelation(runtime.CompareDurationValues(a.actual.Duration, b.actual.Duration))
+	case DateTime, Date:
+		return orderFromRelation(runtime.CompareTemporalValues(a.actual.Temporal, b.actual.Temporal))
+	case Time:
+		return orderFromRelation(runtime.CompareTimePartial(a.actual.Time, b.actual.Time))
+	case GYearMonth, GYear, GMonthDay, GDay, GMonth:
+		return orderFromRelation(runtime.CompareGValues(a.actual.G, b.actual.G))
+	default:
+		if Equal(a, b) {
+			return OrderEqual
+		}
+		return OrderIncomparable
+	}
+}
+
+func orderFromInt(n int) Order {
+	switch {
+	case n < 0:
+		return OrderLess
+	case n > 0:
+		return OrderGreater
+	default:
+		return OrderEqual
+	}
+}
+
+func orderFromRelation(r runtime.OrderedFacetRelation) Order {
+	switch r {
+	case runtime.OrderedFacetLess:
+		return OrderLess
+	case runtime.OrderedFacetEqual:
+		return OrderEqual
+	case runtime.OrderedFacetGreater:
+		return OrderGreater
+	default:
+		return OrderIncomparable
+	}
+}
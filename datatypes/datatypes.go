@@ -0,0 +1,420 @@
+// Package datatypes parses XML Schema built-in simple type values with the
+// same lexical rules, facets and canonical forms the validator applies.
+//
+// Parse accepts the local name of any built-in simple type in the XML Schema
+// namespace, including the XSD 1.1 additions, and returns a Value that keeps
+// both the canonical form and the primitive value-space projection. Equal and
+// Compare implement XML Schema value-space equality and the partial order.
+package datatypes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacoelho/xsd/internal/compile"
+	"github.com/jacoelho/xsd/internal/lex"
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/source"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// Primitive identifies the primitive type whose value space a Value belongs to.
+type Primitive uint8
+
+// Primitive types, named after their XML Schema local names.
+const (
+	String Primitive = iota
+	Boolean
+	Decimal
+	Float
+	Double
+	Duration
+	DateTime
+	Time
+	Date
+	GYearMonth
+	GYear
+	GMonthDay
+	GDay
+	GMonth
+	HexBinary
+	Base64Binary
+	AnyURI
+	QName
+	Notation
+)
+
+var primitiveNames = [...]string{
+	String:       "string",
+	Boolean:      "boolean",
+	Decimal:      "decimal",
+	Float:        "float",
+	Double:       "double",
+	Duration:     "duration",
+	DateTime:     "dateTime",
+	Time:         "time",
+	Date:         "date",
+	GYearMonth:   "gYearMonth",
+	GYear:        "gYear",
+	GMonthDay:    "gMonthDay",
+	GDay:         "gDay",
+	GMonth:       "gMonth",
+	HexBinary:    "hexBinary",
+	Base64Binary: "base64Binary",
+	AnyURI:       "anyURI",
+	QName:        "QName",
+	Notation:     "NOTATION",
+}
+
+// primitiveKinds maps the validator's primitive kinds to Primitive, so the
+// public values do not depend on the order of the internal enumeration.
+var primitiveKinds = [...]Primitive{
+	runtime.PrimitiveString:       String,
+	runtime.PrimitiveBoolean:      Boolean,
+	runtime.PrimitiveDecimal:      Decimal,
+	runtime.PrimitiveFloat:        Float,
+	runtime.PrimitiveDouble:       Double,
+	runtime.PrimitiveDuration:     Duration,
+	runtime.PrimitiveDateTime:     DateTime,
+	runtime.PrimitiveTime:         Time,
+	runtime.PrimitiveDate:         Date,
+	runtime.PrimitiveGYearMonth:   GYearMonth,
+	runtime.PrimitiveGYear:        GYear,
+	runtime.PrimitiveGMonthDay:    GMonthDay,
+	runtime.PrimitiveGDay:         GDay,
+	runtime.PrimitiveGMonth:       GMonth,
+	runtime.PrimitiveHexBinary:    HexBinary,
+	runtime.PrimitiveBase64Binary: Base64Binary,
+	runtime.PrimitiveAnyURI:       AnyURI,
+	runtime.PrimitiveQName:        QName,
+	runtime.PrimitiveNotation:     Notation,
+}
+
+func primitiveOf(kind runtime.PrimitiveKind) (Primitive, bool) {
+	if !runtime.ValidPrimitiveKind(kind) || int(kind) >= len(primitiveKinds) {
+		return 0, false
+	}
+	return primitiveKinds[kind], true
+}
+
+// String returns the primitive type's local name.
+func (p Primitive) String() string {
+	if int(p) < len(primitiveNames) {
+		return primitiveNames[p]
+	}
+	return "unknown"
+}
+
+// Resolver returns the namespace URI bound to prefix. The empty prefix asks
+// for the default namespace; ok false for it means no default namespace.
+type Resolver func(prefix string) (namespace string, ok bool)
+
+// TimeValue is a date/time or g* value. Components the type does not carry
+// are taken from 2000-01-01T00:00:00, the reference the partial order uses.
+// Without a timezone the instant is reported in UTC and HasTimezone is false.
+// Fractional seconds beyond nanoseconds are truncated; the canonical form
+// keeps them.
+type TimeValue struct {
+	time.Time
+	HasTimezone bool
+}
+
+// DurationValue is a duration split into the two independent components of
+// its value space.
+type DurationValue struct {
+	// Seconds holds the day and time components.
+	Seconds *big.Rat
+	// Months holds the year and month components.
+	Months int64
+}
+
+// Value is a parsed built-in simple type value.
+type Value struct {
+	qname     xml.Name
+	typeName  string
+	canonical string
+	// normalized is the whitespace-normalized lexical form.
+	normalized string
+	items      []Value
+	actual     runtime.PrimitiveActualValue
+	primitive  Primitive
+	list       bool
+}
+
+// Type returns the local name of the built-in type v was parsed as.
+func (v Value) Type() string {
+	return v.typeName
+}
+
+// Primitive returns the primitive type of v, or of its items for list types.
+func (v Value) Primitive() Primitive {
+	return v.primitive
+}
+
+// Canonical returns the canonical lexical form of v. QName and NOTATION
+// values have no prefix-independent canonical form and report the
+// whitespace-normalized lexical form.
+func (v Value) Canonical() string {
+	return v.canonical
+}
+
+// String returns the canonical lexical form of v.
+func (v Value) String() string {
+	return v.canonical
+}
+
+// Items returns the items of a list type value such as NMTOKENS. It is nil
+// for atomic values.
+func (v Value) Items() []Value {
+	return v.items
+}
+
+// IsList reports whether v is a list type value.
+func (v Value) IsList() bool {
+	return v.list
+}
+
+// Bool returns the value of a boolean.
+func (v Value) Bool() (bool, bool) {
+	if v.list || v.primitive != Boolean {
+		return false, false
+	}
+	return v.actual.Boolean, true
+}
+
+// Decimal returns the exact value of a decimal or of a type derived from it,
+// such as integer or unsignedByte.
+func (v Value) Decimal() (*big.Rat, bool) {
+	if v.list || v.primitive != Decimal {
+		return nil, false
+	}
+	return new(big.Rat).SetString(v.actual.Decimal.Canonical)
+}
+
+// Float returns the value of a float or double.
+func (v Value) Float() (float64, bool) {
+	if v.list || v.primitive != Float && v.primitive != Double {
+		return 0, false
+	}
+	return v.actual.Float, true
+}
+
+// Duration returns the value of a duration or of a type derived from it.
+func (v Value) Duration() (DurationValue, bool) {
+	if v.list || v.primitive != Duration {
+		return DurationValue{}, false
+	}
+	seconds, ok := new(big.Rat).SetString(v.actual.Duration.SecondsText())
+	if !ok {
+		return DurationValue{}, false
+	}
+	return DurationValue{Months: v.actual.Duration.Months(), Seconds: seconds}, true
+}
+
+// Time returns the value of a date/time or g* type. ok is false when the year
+// is outside the range time.Time represents.
+func (v Value) Time() (TimeValue, bool) {
+	if v.list || !isTemporal(v.primitive) {
+		return TimeValue{}, false
+	}
+	fields, err := runtime.ParseTemporalFields(runtime.PrimitiveKind(v.primitive), v.normalized)
+	if err != nil {
+		return TimeValue{}, false
+	}
+	loc := time.UTC
+	if fields.HasTimezone {
+		loc = time.FixedZone("", fields.TimezoneMinutes*60)
+	}
+	year := int(fields.Year)
+	if int64(year) != fields.Year {
+		return TimeValue{}, false
+	}
+	if v.primitive == Time && fields.Hour == 24 {
+		// 24:00:00 is the first instant of the day for xs:time.
+		fields.Hour = 0
+	}
+	t := time.Date(year, time.Month(fields.Month), fields.Day, fields.Hour, fields.Minute, fields.Second, fractionNanos(fields.Fraction), loc)
+	if fields.Hour != 24 && t.Year() != year {
+		return TimeValue{}, false
+	}
+	return TimeValue{Time: t, HasTimezone: fields.HasTimezone}, true
+}
+
+// Bytes returns the octets of a hexBinary or base64Binary value.
+func (v Value) Bytes() ([]byte, bool) {
+	if v.list {
+		return nil, false
+	}
+	var b []byte
+	var err error
+	switch v.primitive {
+	case HexBinary:
+		b, err = hex.DecodeString(v.canonical)
+	case Base64Binary:
+		b, err = base64.StdEncoding.DecodeString(v.canonical)
+	default:
+		return nil, false
+	}
+	return b, err == nil
+}
+
+// QName returns the expanded name of a QName or NOTATION value.
+func (v Value) QName() (xml.Name, bool) {
+	if v.list || v.primitive != QName && v.primitive != Notation {
+		return xml.Name{}, false
+	}
+	return v.qname, true
+}
+
+// Parse parses lexical as a value of the built-in simple type typeName, given
+// as a local name such as "decimal" or "NMTOKENS". Invalid values fail with
+// xsderrors.CodeValidationFacet and unknown type names with
+// xsderrors.CodeValidationType. Prefixed QName values need ParseWithResolver.
+func Parse(typeName, lexical string) (Value, error) {
+	return ParseWithResolver(typeName, lexical, nil)
+}
+
+// ParseWithResolver is Parse with resolve used to bind the prefixes of QName
+// values. The xml prefix is always bound.
+func ParseWithResolver(typeName, lexical string, resolve Resolver) (Value, error) {
+	rt, err := builtinSchema()
+	if err != nil {
+		return Value{}, err
+	}
+	id, ok := builtinSimpleType(rt, typeName)
+	if !ok {
+		return Value{}, xsderrors.Validation(xsderrors.CodeValidationType, 0, 0, "", "unknown built-in simple type "+typeName)
+	}
+	return parseValue(rt, typeName, id, lexical, resolve)
+}
+
+var builtinSchema = sync.OnceValues(func() (*runtime.Schema, error) {
+	src := source.Bytes("datatypes.xsd", []byte(`<xs:schema xmlns:xs="`+vocab.XSDNamespaceURI+`"/>`))
+	return compile.Compile(context.Background(), compile.Options{XSD11: true}, []source.Source{src})
+})
+
+func builtinSimpleType(rt *runtime.Schema, local string) (runtime.SimpleTypeID, bool) {
+	name, ok := rt.LookupQName(vocab.XSDNamespaceURI, local)
+	if !ok {
+		return 0, false
+	}
+	typ, ok := rt.GlobalType(name)
+	if !ok {
+		return 0, false
+	}
+	return typ.Simple()
+}
+
+// listItemTypes names the item types of the built-in list types.
+var listItemTypes = map[string]string{
+	"NMTOKENS": "NMTOKEN",
+	"IDREFS":   "IDREF",
+	"ENTITIES": "ENTITY",
+}
+
+func parseValue(rt *runtime.Schema, typeName string, id runtime.SimpleTypeID, lexical string, resolve Resolver) (Value, error) {
+	value, err := rt.ValidateSimpleValue(id, lexical, qnameResolver(resolve), runtime.SimpleNeedCanonical)
+	if err != nil {
+		return Value{}, invalidValue(typeName, err)
+	}
+	kind, ok := rt.SimpleTypePrimitive(id)
+	if !ok {
+		return Value{}, xsderrors.InternalInvariant("built-in type " + typeName + " has no primitive type")
+	}
+	primitive, ok := primitiveOf(kind)
+	if !ok {
+		return Value{}, xsderrors.InternalInvariant("built-in type " + typeName + " has an unknown primitive type")
+	}
+	out := Value{
+		typeName:   typeName,
+		canonical:  value.Canonical,
+		normalized: rt.NormalizeSimpleValue(id, lexical),
+		primitive:  primitive,
+	}
+	if itemName, ok := listItemTypes[typeName]; ok {
+		itemID, ok := builtinSimpleType(rt, itemName)
+		if !ok {
+			return Value{}, xsderrors.InternalInvariant("built-in list item type " + itemName + " is missing")
+		}
+		out.list = true
+		for item := range strings.FieldsSeq(out.normalized) {
+			parsed, err := parseValue(rt, itemName, itemID, item, resolve)
+			if err != nil {
+				return Value{}, err
+			}
+			out.items = append(out.items, parsed)
+		}
+		return out, nil
+	}
+	switch out.primitive {
+	case QName, Notation:
+		uri, local, ok := qnameResolver(resolve)(out.normalized)
+		if !ok {
+			return Value{}, invalidValue(typeName, nil)
+		}
+		out.qname = xml.Name{Space: uri, Local: local}
+		out.canonical = out.normalized
+		return out, nil
+	}
+	actual, err := runtime.ParsePrimitiveActual(kind, out.normalized, runtime.PrimitiveNeedCanonical)
+	if err != nil {
+		return Value{}, invalidValue(typeName, err)
+	}
+	out.actual = actual.Actual
+	return out, nil
+}
+
+func qnameResolver(resolve Resolver) runtime.ResolveQNameParts {
+	return func(lexical string) (string, string, bool) {
+		prefix, local, prefixed, ok := lex.SplitQName(lex.CollapseXMLWhitespace(lexical))
+		if !ok {
+			return "", "", false
+		}
+		switch {
+		case prefix == "xml":
+			return vocab.XMLNamespaceURI, local, true
+		case resolve == nil:
+			return "", local, !prefixed
+		}
+		uri, ok := resolve(prefix)
+		if !ok && !prefixed {
+			return "", local, true
+		}
+		return uri, local, ok
+	}
+}
+
+func invalidValue(typeName string, err error) error {
+	msg := "invalid " + typeName + " value"
+	if err != nil {
+		msg += ": " + err.Error()
+	}
+	return xsderrors.Validation(xsderrors.CodeValidationFacet, 0, 0, "", msg)
+}
+
+func isTemporal(p Primitive) bool {
+	switch p {
+	case DateTime, Time, Date, GYearMonth, GYear, GMonthDay, GDay, GMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+func fractionNanos(frac string) int {
+	nanos := 0
+	for i := range 9 {
+		nanos *= 10
+		if i < len(frac) {
+			nanos += int(frac[i] - '0')
+		}
+	}
+	return nanos
+}
@@ -0,0 +1,262 @@
+package datatypes_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jacoelho/xsd/datatypes"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+func TestParseReportsValidatorCanonicalForms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		typeName  string
+		lexical   string
+		canonical string
+		primitive datatypes.Primitive
+	}{
+		{typeName: "decimal", lexical: "+1.50", canonical: "1.5", primitive: datatypes.Decimal},
+		{typeName: "decimal", lexical: "5", canonical: "5.0", primitive: datatypes.Decimal},
+		{typeName: "int", lexical: " 007 ", canonical: "7", primitive: datatypes.Decimal},
+		{typeName: "boolean", lexical: "1", canonical: "true", primitive: datatypes.Boolean},
+		{typeName: "float", lexical: "NaN", canonical: "NaN", primitive: datatypes.Float},
+		{typeName: "dateTime", lexical: "2026-05-18T23:30:15.250-05:30", canonical: "2026-05-19T05:00:15.25Z", primitive: datatypes.DateTime},
+		{typeName: "time", lexical: "24:00:00", canonical: "00:00:00", primitive: datatypes.Time},
+		{typeName: "hexBinary", lexical: "0aff", canonical: "0AFF", primitive: datatypes.HexBinary},
+		{typeName: "base64Binary", lexical: "AQ ID", canonical: "AQID", primitive: datatypes.Base64Binary},
+		{typeName: "token", lexical: "  a \n b ", canonical: "a b", primitive: datatypes.String},
+		{typeName: "dateTimeStamp", lexical: "2026-01-01T00:00:00Z", canonical: "2026-01-01T00:00:00Z", primitive: datatypes.DateTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typeName+"_"+tt.lexical, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := datatypes.Parse(tt.typeName, tt.lexical)
+			if err != nil {
+				t.Fatalf("Parse(%q, %q) error = %v", tt.typeName, tt.lexical, err)
+			}
+			if v.Type() != tt.typeName || v.Primitive() != tt.primitive || v.Canonical() != tt.canonical {
+				t.Fatalf("Parse(%q, %q) = %s %s %q, want %s %s %q",
+					tt.typeName, tt.lexical, v.Type(), v.Primitive(), v.Canonical(), tt.typeName, tt.primitive, tt.canonical)
+			}
+		})
+	}
+}
+
+func TestParseReportsThePrimitiveOfEveryPrimitiveType(t *testing.T) {
+	t.Parallel()
+
+	// xs:NOTATION is left out: no value is valid without a declared notation.
+	samples := map[datatypes.Primitive]string{
+		datatypes.String:       "a",
+		datatypes.Boolean:      "true",
+		datatypes.Decimal:      "1.5",
+		datatypes.Float:        "1.5",
+		datatypes.Double:       "1.5",
+		datatypes.Duration:     "P1D",
+		datatypes.DateTime:     "2026-01-01T00:00:00",
+		datatypes.Time:         "12:00:00",
+		datatypes.Date:         "2026-01-01",
+		datatypes.GYearMonth:   "2026-01",
+		datatypes.GYear:        "2026",
+		datatypes.GMonthDay:    "--01-01",
+		datatypes.GDay:         "---01",
+		datatypes.GMonth:       "--01",
+		datatypes.HexBinary:    "0A",
+		datatypes.Base64Binary: "AQID",
+		datatypes.AnyURI:       "urn:x",
+		datatypes.QName:        "p:x",
+	}
+	resolve := func(string) (string, bool) { return "urn:x", true }
+	for primitive, lexical := range samples {
+		v, err := datatypes.ParseWithResolver(primitive.String(), lexical, resolve)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) error = %v", primitive, lexical, err)
+		}
+		if v.Primitive() != primitive {
+			t.Fatalf("Parse(%q, %q).Primitive() = %s", primitive, lexical, v.Primitive())
+		}
+	}
+}
+
+func TestParseRejectsInvalidValuesAndUnknownTypes(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		typeName string
+		lexical  string
+		code     xsderrors.Code
+	}{
+		{typeName: "int", lexical: "99999999999", code: xsderrors.CodeValidationFacet},
+		{typeName: "dayTimeDuration", lexical: "P1Y", code: xsderrors.CodeValidationFacet},
+		{typeName: "dateTimeStamp", lexical: "2026-01-01T00:00:00", code: xsderrors.CodeValidationFacet},
+		{typeName: "QName", lexical: "p:name", code: xsderrors.CodeValidationFacet},
+		{typeName: "anyType", lexical: "x", code: xsderrors.CodeValidationType},
+		{typeName: "integer2", lexical: "1", code: xsderrors.CodeValidationType},
+	} {
+		_, err := datatypes.Parse(tt.typeName, tt.lexical)
+		xerr, ok := errors.AsType[*xsderrors.Error](err)
+		if !ok || xerr.Code != tt.code {
+			t.Fatalf("Parse(%q, %q) error = %v, want code %s", tt.typeName, tt.lexical, err, tt.code)
+		}
+	}
+}
+
+func TestValueAccessorsReturnTypedValues(t *testing.T) {
+	t.Parallel()
+
+	dec := mustParse(t, "decimal", "-12.340")
+	if got, ok := dec.Decimal(); !ok || got.Cmp(big.NewRat(-617, 50)) != 0 {
+		t.Fatalf("Decimal() = %v, %v, want -617/50", got, ok)
+	}
+	if _, ok := dec.Float(); ok {
+		t.Fatal("Float() on decimal ok = true")
+	}
+
+	dt := mustParse(t, "dateTime", "2026-05-18T23:30:15.250-05:30")
+	tv, ok := dt.Time()
+	want := time.Date(2026, 5, 19, 5, 0, 15, 250_000_000, time.UTC)
+	if !ok || !tv.HasTimezone || !tv.Equal(want) {
+		t.Fatalf("Time() = %v, %v, want %v with timezone", tv, ok, want)
+	}
+	if _, offset := tv.Zone(); offset != -330*60 {
+		t.Fatalf("Time() offset = %d, want %d", offset, -330*60)
+	}
+
+	local, ok := mustParse(t, "gMonthDay", "--02-29").Time()
+	if !ok || local.HasTimezone || !local.Equal(time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("gMonthDay Time() = %v, %v", local, ok)
+	}
+	if _, ok := mustParse(t, "gYear", "99999999999999999999").Time(); ok {
+		t.Fatal("Time() for a year beyond time.Time ok = true")
+	}
+
+	d, ok := mustParse(t, "duration", "-P1Y2M3DT1.5S").Duration()
+	if !ok || d.Months != -14 || d.Seconds.Cmp(big.NewRat(-518403, 2)) != 0 {
+		t.Fatalf("Duration() = %d months %v seconds, %v", d.Months, d.Seconds, ok)
+	}
+
+	b, ok := mustParse(t, "base64Binary", "AQ ID").Bytes()
+	if !ok || !slices.Equal(b, []byte{1, 2, 3}) {
+		t.Fatalf("Bytes() = %v, %v", b, ok)
+	}
+
+	q, err := datatypes.ParseWithResolver("QName", " p:name ", func(prefix string) (string, bool) {
+		return "urn:p", prefix == "p"
+	})
+	if err != nil {
+		t.Fatalf("ParseWithResolver(QName) error = %v", err)
+	}
+	if got, ok := q.QName(); !ok || got != (xml.Name{Space: "urn:p", Local: "name"}) {
+		t.Fatalf("QName() = %v, %v", got, ok)
+	}
+
+	list := mustParse(t, "NMTOKENS", " a  b ")
+	if !list.IsList() || list.Canonical() != "a b" || len(list.Items()) != 2 || list.Items()[1].Type() != "NMTOKEN" {
+		t.Fatalf("NMTOKENS = list %v canonical %q items %v", list.IsList(), list.Canonical(), list.Items())
+	}
+}
+
+func TestEqualAndCompareUseValueSpace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b  [2]string
+		order datatypes.Order
+		equal bool
+	}{
+		{a: [2]string{"int", "5"}, b: [2]string{"decimal", "5.0"}, order: datatypes.OrderEqual, equal: true},
+		{a: [2]string{"decimal", "1.5"}, b: [2]string{"integer", "2"}, order: datatypes.OrderLess},
+		{a: [2]string{"float", "1"}, b: [2]string{"double", "1"}, order: datatypes.OrderIncomparable},
+		{a: [2]string{"duration", "P1Y"}, b: [2]string{"duration", "P12M"}, order: datatypes.OrderEqual, equal: true},
+		{a: [2]string{"duration", "P1Y"}, b: [2]string{"duration", "P365D"}, order: datatypes.OrderIncomparable},
+		{a: [2]string{"duration", "P1Y"}, b: [2]string{"dayTimeDuration", "P367D"}, order: datatypes.OrderLess},
+		{a: [2]string{"dateTime", "2026-01-01T12:00:00+01:00"}, b: [2]string{"dateTime", "2026-01-01T11:00:00Z"}, order: datatypes.OrderEqual, equal: true},
+		{a: [2]string{"dateTime", "2026-01-01T12:00:00"}, b: [2]string{"dateTime", "2026-01-01T12:00:00Z"}, order: datatypes.OrderIncomparable},
+		{a: [2]string{"date", "2026-01-02"}, b: [2]string{"date", "2026-01-01"}, order: datatypes.OrderGreater},
+		{a: [2]string{"string", "a"}, b: [2]string{"token", "a"}, order: datatypes.OrderEqual, equal: true},
+		{a: [2]string{"string", "a"}, b: [2]string{"string", "b"}, order: datatypes.OrderIncomparable},
+		{a: [2]string{"hexBinary", "0aff"}, b: [2]string{"hexBinary", "0AFF"}, order: datatypes.OrderEqual, equal: true},
+		{a: [2]string{"NMTOKENS", "a b"}, b: [2]string{"NMTOKENS", " a b "}, order: datatypes.OrderEqual, equal: true},
+	}
+	for _, tt := range tests {
+		a := mustParse(t, tt.a[0], tt.a[1])
+		b := mustParse(t, tt.b[0], tt.b[1])
+		if got := datatypes.Equal(a, b); got != tt.equal {
+			t.Fatalf("Equal(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.equal)
+		}
+		if got := datatypes.Compare(a, b); got != tt.order {
+			t.Fatalf("Compare(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.order)
+		}
+	}
+}
+
+func TestRestrictionValidatesFacets(t *testing.T) {
+	t.Parallel()
+
+	percent := &datatypes.Restriction{
+		Base: "int",
+		Facets: []datatypes.Facet{
+			{Name: "minInclusive", Value: "-2147483648"},
+			{Name: "minExclusive", Value: "0"},
+			{Name: "maxInclusive", Value: "100"},
+		},
+		Patterns: [][]string{{`\d+`}, {`\d{1,2}`, `100`}},
+	}
+	codes := &datatypes.Restriction{
+		Item: &datatypes.Restriction{Base: "token", Facets: []datatypes.Facet{{Name: "enumeration", Value: "a"}, {Name: "enumeration", Value: "b"}}},
+		Facets: []datatypes.Facet{
+			{Name: "maxLength", Value: "3"},
+			{Name: "length", Value: "2"},
+		},
+	}
+	level := &datatypes.Restriction{Members: []*datatypes.Restriction{percent, {Base: "token", Facets: []datatypes.Facet{{Name: "enumeration", Value: "max"}}}}}
+	for _, tt := range []struct {
+		restriction *datatypes.Restriction
+		lexical     string
+		valid       bool
+	}{
+		{restriction: percent, lexical: " 42 ", valid: true},
+		{restriction: percent, lexical: "100", valid: true},
+		{restriction: percent, lexical: "0", valid: false},
+		{restriction: percent, lexical: "101", valid: false},
+		{restriction: percent, lexical: "+5", valid: false},
+		{restriction: codes, lexical: "a b", valid: true},
+		{restriction: codes, lexical: "a", valid: false},
+		{restriction: codes, lexical: "a c", valid: false},
+		{restriction: level, lexical: "max", valid: true},
+		{restriction: level, lexical: "7", valid: true},
+		{restriction: level, lexical: "min", valid: false},
+	} {
+		err := tt.restriction.Validate(tt.lexical)
+		if tt.valid {
+			if err != nil {
+				t.Fatalf("Validate(%q) error = %v", tt.lexical, err)
+			}
+			continue
+		}
+		if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeValidationFacet {
+			t.Fatalf("Validate(%q) error = %v, want code %s", tt.lexical, err, xsderrors.CodeValidationFacet)
+		}
+	}
+
+	invalid := &datatypes.Restriction{Base: "int", Facets: []datatypes.Facet{{Name: "maxLength", Value: "2"}}}
+	if xerr, ok := errors.AsType[*xsderrors.Error](invalid.Validate("1")); !ok || xerr.Category != xsderrors.CategorySchemaCompile {
+		t.Fatalf("Validate() of an invalid restriction error = %v, want a schema compile error", invalid.Validate("1"))
+	}
+}
+
+func mustParse(t *testing.T, typeName, lexical string) datatypes.Value {
+	t.Helper()
+	v, err := datatypes.Parse(typeName, lexical)
+	if err != nil {
+		t.Fatalf("Parse(%q, %q) error = %v", typeName, lexical, err)
+	}
+	return v
+}
@@ -0,0 +1,25 @@
+package datatypes_test
+
+import (
+	"fmt"
+
+	"github.com/jacoelho/xsd/datatypes"
+)
+
+func ExampleParse() {
+	price, err := datatypes.Parse("decimal", " 012.50 ")
+	if err != nil {
+		panic(err)
+	}
+	limit, err := datatypes.Parse("int", "12")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(price.Canonical(), datatypes.Compare(price, limit) == datatypes.OrderGreater)
+
+	_, err = datatypes.Parse("unsignedByte", "256")
+	fmt.Println(err)
+	// Output:
+	// 12.5 true
+	// validation.facet: invalid unsignedByte value: maxInclusive facet failed
+}
@@ -0,0 +1,219 @@
+package datatypes
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"sync"
+
+	"github.com/jacoelho/xsd/internal/compile"
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/source"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// Restriction describes a simple type as a built-in type, a list or a union
+// restricted by constraining facets. Validate compiles it on first use with
+// the schema compiler, so facets are checked exactly as the validator checks
+// them. A Restriction must not be copied after first use.
+type Restriction struct {
+	// Base is the local name of the restricted built-in type, such as
+	// "decimal". It is ignored when Item or Members is set.
+	Base string
+	// Item is the item type of a list.
+	Item *Restriction
+	// Members are the member types of a union.
+	Members []*Restriction
+	// Facets are the constraining facets other than patterns.
+	Facets []Facet
+	// Patterns holds one group per derivation step that added pattern
+	// facets. A value must match one pattern of every group.
+	Patterns [][]string
+
+	once sync.Once
+	rt   *runtime.Schema
+	id   runtime.SimpleTypeID
+	err  error
+}
+
+// Facet is a constraining facet named by its schema element, such as
+// "maxLength" or "enumeration", with its value as written in a schema.
+type Facet struct {
+	Name  string
+	Value string
+}
+
+// Validate reports whether lexical is a value of r. Invalid values fail with
+// xsderrors.CodeValidationFacet; a Restriction whose facets do not restrict
+// its base fails with the schema compile error. The prefixes of QName and
+// NOTATION values are not resolved, so their enumerations are not supported.
+func (r *Restriction) Validate(lexical string) error {
+	r.once.Do(r.compile)
+	if r.err != nil {
+		return r.err
+	}
+	if _, err := r.rt.ValidateSimpleValue(r.id, lexical, unresolvedQName, 0); err != nil {
+		return invalidValue(r.name(), err)
+	}
+	return nil
+}
+
+func (r *Restriction) compile() {
+	var b bytes.Buffer
+	b.WriteString(`<xs:schema xmlns:xs="` + vocab.XSDNamespaceURI + `"><xs:simpleType name="r">`)
+	r.write(&b)
+	b.WriteString(`</xs:simpleType></xs:schema>`)
+	src := source.Bytes("restriction.xsd", b.Bytes())
+	r.rt, r.err = compile.Compile(context.Background(), compile.Options{XSD11: true}, []source.Source{src})
+	if r.err != nil {
+		return
+	}
+	name, ok := r.rt.LookupQName("", "r")
+	if !ok {
+		r.err = xsderrors.InternalInvariant("restriction type is missing")
+		return
+	}
+	typ, ok := r.rt.GlobalType(name)
+	if ok {
+		r.id, ok = typ.Simple()
+	}
+	if !ok {
+		r.err = xsderrors.InternalInvariant("restriction type is not simple")
+	}
+}
+
+func (r *Restriction) name() string {
+	switch {
+	case r.Item != nil:
+		return "list"
+	case len(r.Members) != 0:
+		return "union"
+	default:
+		return r.Base
+	}
+}
+
+// write spells r as the content of an xs:simpleType: the base variety
+// wrapped in one restriction step per pattern group and per facet group.
+// A length facet gets a step of its own, since XML Schema 1.0 rejects it
+// beside minLength or maxLength in one step, and the looser of two bounds
+// on the same side is dropped for the same reason.
+func (r *Restriction) write(b *bytes.Buffer) {
+	steps := make([][]Facet, 0, len(r.Patterns)+2)
+	for _, group := range r.Patterns {
+		step := make([]Facet, 0, len(group))
+		for _, pattern := range group {
+			step = append(step, Facet{Name: "pattern", Value: pattern})
+		}
+		steps = append(steps, step)
+	}
+	var length, rest []Facet
+	for _, f := range r.tightest() {
+		if f.Name == "length" {
+			length = append(length, f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+	steps = append(steps, rest, length)
+	r.writeStep(b, steps)
+}
+
+func (r *Restriction) writeStep(b *bytes.Buffer, steps [][]Facet) {
+	for len(steps) != 0 && len(steps[len(steps)-1]) == 0 {
+		steps = steps[:len(steps)-1]
+	}
+	if len(steps) == 0 {
+		r.writeBase(b)
+		return
+	}
+	last := steps[len(steps)-1]
+	b.WriteString(`<xs:restriction><xs:simpleType>`)
+	r.writeStep(b, steps[:len(steps)-1])
+	b.WriteString(`</xs:simpleType>`)
+	for _, f := range last {
+		b.WriteString(`<xs:` + f.Name + ` value="`)
+		writeAttr(b, f.Value)
+		b.WriteString(`"/>`)
+	}
+	b.WriteString(`</xs:restriction>`)
+}
+
+func (r *Restriction) writeBase(b *bytes.Buffer) {
+	switch {
+	case r.Item != nil:
+		b.WriteString(`<xs:list><xs:simpleType>`)
+		r.Item.write(b)
+		b.WriteString(`</xs:simpleType></xs:list>`)
+	case len(r.Members) != 0:
+		b.WriteString(`<xs:union>`)
+		for _, member := range r.Members {
+			b.WriteString(`<xs:simpleType>`)
+			member.write(b)
+			b.WriteString(`</xs:simpleType>`)
+		}
+		b.WriteString(`</xs:union>`)
+	default:
+		b.WriteString(`<xs:restriction base="xs:`)
+		writeAttr(b, r.Base)
+		b.WriteString(`"/>`)
+	}
+}
+
+// tightest returns the facets of r without the looser of an inclusive and an
+// exclusive bound on the same side, when their values compare.
+func (r *Restriction) tightest() []Facet {
+	drop := make(map[string]bool)
+	for _, side := range [][2]string{{"minInclusive", "minExclusive"}, {"maxInclusive", "maxExclusive"}} {
+		inclusive, ok := r.facet(side[0])
+		if !ok {
+			continue
+		}
+		exclusive, ok := r.facet(side[1])
+		if !ok {
+			continue
+		}
+		a, errA := Parse(r.Base, inclusive)
+		b, errB := Parse(r.Base, exclusive)
+		if errA != nil || errB != nil {
+			continue
+		}
+		order := Compare(a, b)
+		if side[0] == "maxInclusive" {
+			order = Compare(b, a)
+		}
+		switch order {
+		case OrderLess, OrderEqual:
+			drop[side[0]] = true
+		case OrderGreater:
+			drop[side[1]] = true
+		}
+	}
+	out := make([]Facet, 0, len(r.Facets))
+	for _, f := range r.Facets {
+		if !drop[f.Name] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (r *Restriction) facet(name string) (string, bool) {
+	for _, f := range r.Facets {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+func writeAttr(b *bytes.Buffer, s string) {
+	// EscapeText only fails when the writer does.
+	_ = xml.EscapeText(b, []byte(s))
+}
+
+// unresolvedQName accepts QName values with any prefix, in no namespace.
+func unresolvedQName(lexical string) (string, string, bool) {
+	return qnameResolver(func(string) (string, bool) { return "", true })(lexical)
+}
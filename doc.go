@@ -14,6 +14,7 @@
 // forcibly interrupted.
 //
 // Instance validation is streaming. Engine.Validate consumes an io.Reader with
-// a low-level byte XML parser, rejects DTD declarations, rejects non-UTF-8
-// instance documents, and keeps mutable validation state inside the call.
+// a low-level byte XML parser, rejects DTD declarations, transcodes UTF-16 and
+// declared single-byte encodings to UTF-8, and keeps mutable validation state
+// inside the call.
 package xsd
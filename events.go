@@ -0,0 +1,138 @@
+package xsd
+
+import (
+	"encoding/xml"
+
+	"github.com/jacoelho/xsd/internal/validate"
+)
+
+// EventKind identifies a post-schema-validation infoset event.
+type EventKind uint8
+
+const (
+	// EventStartElement is reported after an element's start tag, its
+	// governing type and its attributes have been assessed.
+	EventStartElement EventKind = iota + 1
+	// EventEndElement is reported after an element's content has been
+	// assessed.
+	EventEndElement
+)
+
+// Validity is the schema validity of an element or attribute.
+type Validity uint8
+
+const (
+	// ValidityNotKnown marks items that were not assessed, such as elements
+	// matched by a skip wildcard.
+	ValidityNotKnown Validity = iota
+	// ValidityValid marks items assessed without errors.
+	ValidityValid
+	// ValidityInvalid marks items with at least one validation error.
+	ValidityInvalid
+)
+
+// Event is one post-schema-validation infoset event.
+type Event struct {
+	// Name is the element's expanded name.
+	Name xml.Name
+	// Type is the name of the governing type. It is empty for anonymous
+	// types and for elements that were not assessed.
+	Type xml.Name
+	// Declaration is the name of the governing element declaration. It is
+	// empty for elements without one, such as those matched by a lax
+	// wildcard with no global declaration.
+	Declaration xml.Name
+	// Attributes are the element's attributes on start events, followed by
+	// attributes supplied by default or fixed values. Namespace declarations
+	// are omitted. The slice is reused and only valid during the handler call.
+	Attributes []EventAttribute
+	// Value is the whitespace-normalized value of an element with simple
+	// content, reported on end events when HasValue is true.
+	Value string
+	// Canonical is the canonical form of Value. It is empty when the element
+	// is not valid.
+	Canonical string
+	// Line and Column locate the start or end tag.
+	Line   int
+	Column int
+	Kind   EventKind
+	// Validity is the outcome of assessing the element and its descendants.
+	// It is reported on end events only.
+	Validity Validity
+	// Assessed reports whether the element had a governing type.
+	Assessed bool
+	// GlobalDeclaration reports whether Declaration is a top-level
+	// declaration.
+	GlobalDeclaration bool
+	// Nilled reports whether the element was nilled with xsi:nil.
+	Nilled bool
+	// HasValue reports whether the element has simple content.
+	HasValue bool
+	// DefaultApplied reports whether Value came from the declaration's
+	// default or fixed value because the element was empty.
+	DefaultApplied bool
+}
+
+// EventAttribute describes one attribute of an EventStartElement.
+type EventAttribute struct {
+	// Name is the attribute's expanded name.
+	Name xml.Name
+	// Type is the name of the attribute's type. It is empty for anonymous
+	// types and for attributes that were not assessed, including xsi
+	// attributes.
+	Type xml.Name
+	// Value is the whitespace-normalized value.
+	Value string
+	// Canonical is the canonical form of Value. It is empty when the
+	// attribute is not valid.
+	Canonical string
+	Validity  Validity
+	// Default reports whether the attribute was absent and supplied by a
+	// default or fixed value.
+	Default bool
+}
+
+// EventHandler receives post-schema-validation infoset events in document
+// order. A non-nil error stops validation, and ValidateEvents returns it
+// unchanged.
+type EventHandler func(Event) error
+
+func adaptEventHandler(handler EventHandler) validate.EventHandler {
+	if handler == nil {
+		return nil
+	}
+	var attrs []EventAttribute
+	return func(ev validate.Event) error {
+		attrs = attrs[:0]
+		for _, a := range ev.Attributes {
+			attrs = append(attrs, EventAttribute{
+				Name:      a.Name,
+				Type:      a.Type,
+				Value:     a.Value,
+				Canonical: a.Canonical,
+				Validity:  Validity(a.Validity),
+				Default:   a.Default,
+			})
+		}
+		out := Event{
+			Name:              ev.Name,
+			Type:              ev.Type,
+			Declaration:       ev.Declaration,
+			Value:             ev.Value,
+			Canonical:         ev.Canonical,
+			Line:              ev.Line,
+			Column:            ev.Column,
+			Kind:              EventKind(ev.Kind),
+			Validity:          Validity(ev.Validity),
+			Assessed:          ev.Assessed,
+			GlobalDeclaration: ev.GlobalDeclaration,
+			Nilled:            ev.Nilled,
+			HasValue:          ev.HasValue,
+			DefaultApplied:    ev.DefaultApplied,
+		}
+		if len(attrs) != 0 {
+			out.Attributes = attrs
+		}
+		return handler(out)
+	}
+}
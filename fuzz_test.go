@@ -0,0 +1,47 @@
+package xsd_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/jacoelho/xsd"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// FuzzSampleInvalid validates mutated samples of a fixed schema and checks
+// that validation accepts each instance before its mutation and reports the
+// error code the mutation is labelled with after it.
+func FuzzSampleInvalid(f *testing.F) {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(sampleSchema)))
+	if err != nil {
+		f.Fatal(err)
+	}
+	root := xml.Name{Space: "urn:orders", Local: "order"}
+	for seed := range uint64(8) {
+		f.Add(seed, uint8(seed))
+	}
+	f.Fuzz(func(t *testing.T, seed uint64, shape uint8) {
+		opts := xsd.SampleOptions{
+			Seed:     seed,
+			Repeat:   int(shape % 4),
+			Optional: shape&4 != 0,
+			Choice:   xsd.SampleChoice(shape >> 3 % 3),
+		}
+		got, err := engine.SampleInvalid(context.Background(), root, opts)
+		if err != nil {
+			if x, ok := errors.AsType[*xsderrors.Error](err); ok && x.Code == xsderrors.CodeUnsupportedSample {
+				t.Skip()
+			}
+			t.Fatalf("SampleInvalid(%+v) error = %v", opts, err)
+		}
+		if err := engine.Validate(context.Background(), bytes.NewReader(got.Valid)); err != nil {
+			t.Fatalf("valid instance error = %v\n%s", err, got.Valid)
+		}
+		if err := engine.Validate(context.Background(), bytes.NewReader(got.Document)); !errorTreeHasCode(err, got.Code) {
+			t.Fatalf("mutation %d at %s: error = %v, want %s\n%s", got.Mutation, got.Path, err, got.Code, got.Document)
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package compile
+
+import (
+	"encoding/xml"
+	"slices"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+const annotationPayloadLimitMsg = "schema annotation payload exceeds configured limit"
+
+// annotationPayload is the retained content of one xs:documentation or
+// xs:appinfo element.
+type annotationPayload struct {
+	lang    string
+	text    string
+	content string
+}
+
+// annotationCapture serializes the content of the open xs:documentation or
+// xs:appinfo element. Elements keep their lexical prefixes; namespace
+// bindings declared outside the payload are redeclared where they are first
+// used so the content stands alone.
+type annotationCapture struct {
+	node    *rawNode
+	lang    string
+	content []byte
+	text    []byte
+	scopes  []map[string]string
+	depth   int
+}
+
+func (s *schemaParseState) startAnnotationCapture(n *rawNode) {
+	lang, ok := n.attrNS(vocab.XMLNamespaceURI, vocab.XMLAttrLang)
+	for i := len(s.stack) - 2; !ok && i >= 0; i-- {
+		if parent := s.stack[i].node; parent != nil {
+			lang, ok = parent.attrNS(vocab.XMLNamespaceURI, vocab.XMLAttrLang)
+		}
+	}
+	s.capture = &annotationCapture{node: n, lang: lang, depth: len(s.stack)}
+}
+
+func (s *schemaParseState) captureStart(raw xml.Name, rawAttrs []xml.Name, prepared xml.StartElement, ns map[string]string, line, col int) error {
+	c := s.capture
+	declared := make(map[string]string)
+	for i, a := range rawAttrs {
+		if a.Space == vocab.XMLNSPrefix {
+			declared[a.Local] = prepared.Attr[i].Value
+		} else if a.Space == "" && a.Local == vocab.XMLNSPrefix {
+			declared[""] = prepared.Attr[i].Value
+		}
+	}
+	c.content = append(c.content, '<')
+	c.content = appendRawName(c.content, raw)
+	c.redeclare(declared, raw.Space, ns)
+	for _, a := range rawAttrs {
+		if a.Space != "" && a.Space != vocab.XMLNSPrefix && a.Space != vocab.XMLPrefix {
+			c.redeclare(declared, a.Space, ns)
+		}
+	}
+	for i, a := range rawAttrs {
+		c.content = append(c.content, ' ')
+		c.content = appendRawName(c.content, a)
+		c.content = append(c.content, `="`...)
+		c.content = appendEscapedXML(c.content, prepared.Attr[i].Value, true)
+		c.content = append(c.content, '"')
+	}
+	c.content = append(c.content, '>')
+	c.scopes = append(c.scopes, declared)
+	return checkSchemaTokenLimit(int64(len(c.content)), s.limits, line, col, annotationPayloadLimitMsg)
+}
+
+// redeclare writes a declaration of prefix when the serialized content does
+// not already bind it to its in-scope namespace, and records it in declared.
+func (c *annotationCapture) redeclare(declared map[string]string, prefix string, ns map[string]string) {
+	uri := ns[prefix]
+	if _, ok := declared[prefix]; ok {
+		return
+	}
+	if bound, ok := c.binding(prefix); bound == uri && (ok || uri == "") {
+		return
+	}
+	declared[prefix] = uri
+	c.content = append(c.content, " xmlns"...)
+	if prefix != "" {
+		c.content = append(c.content, ':')
+		c.content = append(c.content, prefix...)
+	}
+	c.content = append(c.content, `="`...)
+	c.content = appendEscapedXML(c.content, uri, true)
+	c.content = append(c.content, '"')
+}
+
+func (c *annotationCapture) binding(prefix string) (string, bool) {
+	for _, scope := range slices.Backward(c.scopes) {
+		if uri, ok := scope[prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", false
+}
+
+func (s *schemaParseState) captureChars(t []byte, line, col int) error {
+	c := s.capture
+	c.text = append(c.text, t...)
+	c.content = appendEscapedXML(c.content, string(t), false)
+	return checkSchemaTokenLimit(int64(len(c.content)), s.limits, line, col, annotationPayloadLimitMsg)
+}
+
+// captureEnd serializes an end tag, or finishes the capture when the
+// xs:documentation or xs:appinfo element itself ends.
+func (s *schemaParseState) captureEnd(raw xml.Name) {
+	c := s.capture
+	if len(s.stack) < c.depth {
+		c.node.payload = &annotationPayload{lang: c.lang, text: string(c.text), content: string(c.content)}
+		s.capture = nil
+		return
+	}
+	c.content = append(c.content, "</"...)
+	c.content = appendRawName(c.content, raw)
+	c.content = append(c.content, '>')
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func appendRawName(dst []byte, name xml.Name) []byte {
+	if name.Space != "" {
+		dst = append(dst, name.Space...)
+		dst = append(dst, ':')
+	}
+	return append(dst, name.Local...)
+}
+
+func appendEscapedXML(dst []byte, s string, attr bool) []byte {
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '&':
+			dst = append(dst, "&amp;"...)
+		case ch == '<':
+			dst = append(dst, "&lt;"...)
+		case ch == '>':
+			dst = append(dst, "&gt;"...)
+		case ch == '\r':
+			dst = append(dst, "&#xD;"...)
+		case attr && ch == '"':
+			dst = append(dst, "&quot;"...)
+		case attr && ch == '\n':
+			dst = append(dst, "&#xA;"...)
+		case attr && ch == '\t':
+			dst = append(dst, "&#x9;"...)
+		default:
+			dst = append(dst, ch)
+		}
+	}
+	return dst
+}
+
+// componentAnnotation collects the retained annotations of the component
+// declared by n. Type definitions also collect the annotations of their
+// content and derivation children.
+func componentAnnotation(kind runtime.AnnotatedKind, n *rawNode) runtime.Annotation {
+	var a runtime.Annotation
+	appendNodeAnnotations(&a, n)
+	if kind != runtime.AnnotatedSimpleType && kind != runtime.AnnotatedComplexType {
+		return a
+	}
+	for child := range n.xsdChildren() {
+		switch child.Name.Local {
+		case vocab.XSDElemSimpleContent, vocab.XSDElemComplexContent:
+			appendNodeAnnotations(&a, child)
+			for derivation := range child.xsdChildren() {
+				if derivation.Name.Local == vocab.XSDElemRestriction || derivation.Name.Local == vocab.XSDElemExtension {
+					appendNodeAnnotations(&a, derivation)
+				}
+			}
+		case vocab.XSDElemRestriction, vocab.XSDElemList, vocab.XSDElemUnion:
+			appendNodeAnnotations(&a, child)
+		}
+	}
+	return a
+}
+
+func appendNodeAnnotations(a *runtime.Annotation, n *rawNode) {
+	for annotation := range n.xsdChildren() {
+		if annotation.Name.Local != annotationChild {
+			continue
+		}
+		for child := range annotation.xsdChildren() {
+			if child.payload == nil {
+				continue
+			}
+			source := child.attrValue(vocab.XSDAttrSource)
+			switch child.Name.Local {
+			case vocab.XSDElemDocumentation:
+				a.Documentation = append(a.Documentation, runtime.Documentation{
+					Source:  source,
+					Lang:    child.payload.lang,
+					Text:    child.payload.text,
+					Content: child.payload.content,
+				})
+			case vocab.XSDElemAppinfo:
+				a.AppInfo = append(a.AppInfo, runtime.AppInfo{Source: source, Content: child.payload.content})
+			}
+		}
+	}
+}
+
+func (c *compiler) annotateComponent(kind runtime.AnnotatedKind, id uint32, n *rawNode) {
+	if !c.limits.RetainAnnotations {
+		return
+	}
+	c.recordAnnotation(runtime.AnnotationKey{Kind: kind, ID: id}, componentAnnotation(kind, n))
+}
+
+// annotateSchemaDocument records the top-level annotations of a schema
+// document once, however many target contexts include it.
+func (c *compiler) annotateSchemaDocument(doc *rawDoc) {
+	if !c.limits.RetainAnnotations || c.annotatedDocs[doc] {
+		return
+	}
+	c.annotatedDocs[doc] = true
+	c.recordAnnotation(runtime.AnnotationKey{Kind: runtime.AnnotatedSchema}, componentAnnotation(runtime.AnnotatedSchema, doc.root))
+}
+
+// attributeUseAnnotations collects the annotations of the attribute uses of
+// one attribute-use set while it is compiled. It is nil when annotations are
+// not retained.
+type attributeUseAnnotations map[runtime.QName]runtime.Annotation
+
+func (c *compiler) newAttributeUseAnnotations() attributeUseAnnotations {
+	if !c.limits.RetainAnnotations {
+		return nil
+	}
+	return make(attributeUseAnnotations)
+}
+
+func (notes attributeUseAnnotations) addUse(name runtime.QName, n *rawNode) {
+	if notes == nil {
+		return
+	}
+	notes[name] = componentAnnotation(runtime.AnnotatedAttributeUse, n)
+}
+
+// addGroupAttributeUseAnnotations copies the attribute-use annotations of
+// the attribute group referenced by n.
+func (c *compiler) addGroupAttributeUseAnnotations(notes attributeUseAnnotations, n *rawNode, ctx *schemaContext) {
+	if notes == nil {
+		return
+	}
+	set, ok := c.referencedAttributeGroup(n, ctx)
+	if !ok {
+		return
+	}
+	uses, _ := c.rt.attributeUsesAndWildcard(set)
+	for _, use := range uses {
+		if a, ok := c.attributeUseAnnotation(set, use.Name); ok {
+			notes[use.Name] = a
+		}
+	}
+}
+
+// referencedAttributeGroup returns the attribute-use set of the compiled
+// attribute group referenced by n.
+func (c *compiler) referencedAttributeGroup(n *rawNode, ctx *schemaContext) (runtime.AttributeUseSetID, bool) {
+	ref, _ := n.attr(vocab.XSDAttrRef)
+	q, err := c.resolveQNameChecked(n, ctx, ref)
+	if err != nil {
+		return runtime.NoAttributeUseSet, false
+	}
+	set, ok := c.attrGroupDone[q]
+	return set, ok
+}
+
+func (c *compiler) annotateAttributeUses(set runtime.AttributeUseSetID, notes attributeUseAnnotations) {
+	for name, a := range notes {
+		c.recordAnnotation(runtime.AnnotationKey{Kind: runtime.AnnotatedAttributeUse, ID: uint32(set), Name: name}, a)
+	}
+}
@@ -0,0 +1,45 @@
+package compile
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAnnotationCaptureRedeclaresOuterNamespaces(t *testing.T) {
+	t.Parallel()
+
+	limits, err := NormalizeOptions(Options{RetainAnnotations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"default namespace", `<p>x<q/></p>`, `<p xmlns="urn:doc">x<q></q></p>`},
+		{"undeclared default", `<p xmlns=""><q/></p>`, `<p xmlns=""><q></q></p>`},
+		{"prefixed attribute", `<m:p m:a="1"><m:q/></m:p>`, `<m:p xmlns:m="urn:m" m:a="1"><m:q></m:q></m:p>`},
+		{"local declaration", `<n:p xmlns:n="urn:n"><n:q/></n:p>`, `<n:p xmlns:n="urn:n"><n:q></n:q></n:p>`},
+		{"text escapes", "a &lt; b&#xD;", "a &lt; b&#xD;"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns="urn:doc" xmlns:m="urn:m">` +
+				`<xs:annotation><xs:appinfo>` + test.payload + `</xs:appinfo></xs:annotation></xs:schema>`
+			doc, err := parseRawSchemaDocument(context.Background(), "schema.xsd", "schema.xsd", []byte(schema), limits)
+			if err != nil {
+				t.Fatalf("parseRawSchemaDocument() error = %v", err)
+			}
+			appinfo := doc.root.Children[0].Children[0]
+			if appinfo.payload == nil {
+				t.Fatal("appinfo payload was not retained")
+			}
+			if got := appinfo.payload.content; got != test.want {
+				t.Fatalf("payload = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
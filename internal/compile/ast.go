@@ -32,8 +32,11 @@ type rawNode struct {
 	text     []byte
 	Attr     []xml.Attr
 	Children []*rawNode
-	Line     int
-	Column   int
+	// payload is the retained content of an xs:documentation or xs:appinfo
+	// element when annotations are retained.
+	payload *annotationPayload
+	Line    int
+	Column  int
 }
 
 func parseSchemaDocument(ctx context.Context, name, key string, data []byte, limits Limits) (*rawDoc, error) {
@@ -41,7 +44,7 @@ func parseSchemaDocument(ctx context.Context, name, key string, data []byte, lim
 	if err != nil {
 		return nil, err
 	}
-	if admitErr := admitSchemaDocument(doc); admitErr != nil {
+	if admitErr := admitSchemaDocument(doc, limits.XSD11); admitErr != nil {
 		return nil, xsderrors.WithPath(name, admitErr)
 	}
 	defaults, err := parseSchemaDefaults(doc.root)
@@ -76,8 +79,10 @@ func parseRawSchemaDocument(ctx context.Context, name, key string, data []byte,
 	parser := new(stream.Parser)
 	if err := parser.ResetWithLimits(bytes.NewReader(data), &names, &values, stream.Limits{
 		Context:       ctx,
+		Charsets:      limits.Charsets,
 		MaxTokenBytes: limits.MaxSchemaTokenBytes,
 		MaxAttrs:      limits.MaxSchemaAttributes,
+		XML11:         limits.XML11,
 	}); err != nil {
 		if contextErr := compileContextErrorWith(ctx, err); contextErr != nil {
 			return nil, xsderrors.WithPath(name, contextErr)
@@ -117,8 +122,10 @@ type schemaParseState struct {
 	root   *rawNode
 	stack  []schemaParseFrame
 	ns     xmlns.Stack
-	nodes  int
-	limits Limits
+	// capture is the open annotation payload when annotations are retained.
+	capture *annotationCapture
+	nodes   int
+	limits  Limits
 }
 
 func (s *schemaParseState) parse() error {
@@ -152,8 +159,9 @@ func schemaReaderError(err error) error {
 }
 
 func schemaStreamError(line, col int, err error) error {
-	if errors.Is(err, stream.ErrUnsupportedNonUTF8) {
-		return xsderrors.Unsupported(xsderrors.CodeUnsupportedNonUTF8, "schema documents must be UTF-8")
+	var encodingErr stream.UnsupportedEncodingError
+	if errors.As(err, &encodingErr) {
+		return xsderrors.Unsupported(xsderrors.CodeUnsupportedNonUTF8, encodingErr.Error())
 	}
 	var versionErr stream.UnsupportedXMLVersionError
 	if errors.As(err, &versionErr) {
@@ -196,6 +204,13 @@ func (s *schemaParseState) start(start stream.StartElement, line, col int) error
 	if err := s.ns.PushStream(start.Attr, s.values); err != nil {
 		return xsderrors.SchemaParse(xsderrors.CodeSchemaXML, line, col, "invalid schema XML", err)
 	}
+	var rawAttrs []xml.Name
+	if s.capture != nil {
+		rawAttrs = make([]xml.Name, len(start.Attr))
+		for i := range start.Attr {
+			rawAttrs[i] = start.Attr[i].Name
+		}
+	}
 	prepared, err := s.prepareSchemaStart(start, line, col)
 	if err != nil {
 		s.ns.Pop()
@@ -240,9 +255,17 @@ func (s *schemaParseState) start(start stream.StartElement, line, col int) error
 		parent := s.stack[len(s.stack)-1].node
 		parent.Children = append(parent.Children, n)
 	}
+	if s.capture != nil {
+		if err := s.captureStart(xml.Name{Space: prefix, Local: start.Name.Local}, rawAttrs, prepared, ns, line, col); err != nil {
+			return err
+		}
+	}
 	s.stack = append(s.stack, schemaParseFrame{
 		node: n, namespaces: ns, name: prepared.Name, prefix: prefix,
 	})
+	if s.limits.RetainAnnotations && s.capture == nil && s.annotationPayloadEnvelopeOpen() {
+		s.startAnnotationCapture(n)
+	}
 	return nil
 }
 
@@ -295,6 +318,9 @@ func (s *schemaParseState) handleEndElement(end stream.EndElement, line, col int
 	}
 	s.stack = s.stack[:len(s.stack)-1]
 	s.ns.Pop()
+	if s.capture != nil {
+		s.captureEnd(end.Name)
+	}
 	return nil
 }
 
@@ -313,6 +339,9 @@ func (s *schemaParseState) chars(t []byte, line, col int) error {
 	if err := checkSchemaTokenLimit(s.stack[last].textBytes, s.limits, line, col, "schema XML text exceeds configured limit"); err != nil {
 		return err
 	}
+	if s.capture != nil {
+		return s.captureChars(t, line, col)
+	}
 	if s.stack[last].node == nil || s.annotationPayloadEnvelopeOpen() {
 		return nil
 	}
@@ -342,11 +371,11 @@ func (s *schemaParseState) annotationPayloadEnvelopeOpen() bool {
 		(n.Name.Local == vocab.XSDElemAppinfo || n.Name.Local == vocab.XSDElemDocumentation)
 }
 
-func admitSchemaDocument(doc *rawDoc) error {
+func admitSchemaDocument(doc *rawDoc, xsd11 bool) error {
 	if err := validateSchemaRoot(doc.root); err != nil {
 		return err
 	}
-	if err := rejectUnsupportedSchemaNodes(doc.root, nil); err != nil {
+	if err := rejectUnsupportedSchemaNodes(doc.root, nil, xsd11); err != nil {
 		return err
 	}
 	if err := validateSchemaTopLevelOrder(doc.root); err != nil {
@@ -368,15 +397,24 @@ func admitSchemaDocument(doc *rawDoc) error {
 }
 
 func validateSchemaTopLevelOrder(root *rawNode) error {
+	sawDefaultOpenContent := false
 	sawDeclaration := false
 	for child := range root.xsdChildren() {
 		switch child.Name.Local {
 		case annotationChild:
 			continue
-		case includeChild, importChild:
-			if sawDeclaration {
+		case includeChild, importChild, redefineChild, overrideChild:
+			if sawDeclaration || sawDefaultOpenContent {
 				return schemaCompileAt(child, xsderrors.CodeSchemaContentModel, "xs:"+child.Name.Local+" must precede global declarations")
 			}
+		case defaultOpenChild:
+			if sawDefaultOpenContent {
+				return schemaCompileAt(child, xsderrors.CodeSchemaContentModel, "schema can contain at most one xs:"+defaultOpenChild)
+			}
+			if sawDeclaration {
+				return schemaCompileAt(child, xsderrors.CodeSchemaContentModel, "xs:"+defaultOpenChild+" must precede global declarations")
+			}
+			sawDefaultOpenContent = true
 		default:
 			sawDeclaration = true
 		}
@@ -394,6 +432,16 @@ func rejectInvalidSchemaTextAndDirectives(n *rawNode) error {
 			return err
 		}
 	}
+	if n.Name.Space == vocab.XSDNamespaceURI && n.Name.Local == redefineChild {
+		if err := checkRedefineChildren(n); err != nil {
+			return err
+		}
+	}
+	if n.Name.Space == vocab.XSDNamespaceURI && n.Name.Local == overrideChild {
+		if err := checkOverrideChildren(n); err != nil {
+			return err
+		}
+	}
 	for _, child := range n.Children {
 		if err := rejectInvalidSchemaTextAndDirectives(child); err != nil {
 			return err
@@ -465,13 +513,13 @@ func cloneNS(src map[string]string) map[string]string {
 	return dst
 }
 
-func rejectUnsupportedSchemaNodes(n, parent *rawNode) error {
-	skipChildren, err := checkUnsupportedSchemaNode(n, parent)
+func rejectUnsupportedSchemaNodes(n, parent *rawNode, xsd11 bool) error {
+	skipChildren, err := checkUnsupportedSchemaNode(n, parent, xsd11)
 	if err != nil || skipChildren {
 		return err
 	}
 	for _, c := range n.Children {
-		if err := rejectUnsupportedSchemaNodes(c, n); err != nil {
+		if err := rejectUnsupportedSchemaNodes(c, n, xsd11); err != nil {
 			return err
 		}
 	}
@@ -534,7 +582,7 @@ func (n *rawNode) attr(local string) (string, bool) {
 
 func (n *rawNode) collapseAttributeWhitespace(local string) bool {
 	switch local {
-	case vocab.XSDAttrDefault, vocab.XSDAttrValue:
+	case vocab.XSDAttrDefault, vocab.XSDAttrValue, vocab.XSDAttrTest:
 		return false
 	case vocab.XSDAttrFixed:
 		return n.Name.Space == vocab.XSDNamespaceURI &&
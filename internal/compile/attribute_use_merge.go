@@ -105,7 +105,7 @@ func (m *AttributeUseMerger) inheritedWildcardAllows(rt AttributeUseMergeRuntime
 		return false
 	}
 	wildcard, ok := rt.Wildcard(m.inheritedWildcard)
-	return ok && runtime.WildcardAllowsNamespace(wildcard, name.Namespace)
+	return ok && runtime.WildcardAllowsQName(wildcard, name)
 }
 
 // RemoveProhibitedAttributeUses removes prohibited uses from the final
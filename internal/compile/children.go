@@ -13,11 +13,13 @@ const (
 	allChild         = vocab.XSDElemAll
 	anyChild         = vocab.XSDElemAny
 	anyAttribute     = vocab.XSDElemAnyAttribute
+	alternativeChild = vocab.XSDElemAlternative
 	attributeChild   = vocab.XSDElemAttribute
 	attributeGroup   = vocab.XSDElemAttributeGroup
 	choiceChild      = vocab.XSDElemChoice
 	complexContent   = vocab.XSDElemComplexContent
 	complexTypeChild = vocab.XSDElemComplexType
+	defaultOpenChild = vocab.XSDElemDefaultOpenContent
 	elementChild     = vocab.XSDElemElement
 	extensionChild   = vocab.XSDElemExtension
 	groupChild       = vocab.XSDElemGroup
@@ -28,8 +30,10 @@ const (
 	restrictionChild = vocab.XSDElemRestriction
 	listChild        = vocab.XSDElemList
 	notationChild    = vocab.XSDElemNotation
-	redefineChild    = "redefine"
-	assertChild      = "assert"
+	openContentChild = vocab.XSDElemOpenContent
+	overrideChild    = vocab.XSDElemOverride
+	redefineChild    = vocab.XSDElemRedefine
+	assertChild      = vocab.XSDElemAssert
 	sequenceChild    = vocab.XSDElemSequence
 	simpleContent    = vocab.XSDElemSimpleContent
 	simpleTypeChild  = vocab.XSDElemSimpleType
@@ -42,6 +46,7 @@ const (
 	annotationMustBeFirstSuffix                   = " annotation must be first"
 	attributeOutOfOrderSuffix                     = " attribute is out of order"
 	modelGroupOutOfOrderSuffix                    = " model group is out of order"
+	openContentOutOfOrderSuffix                   = " openContent is out of order"
 	oneAnyAttributeSuffix                         = " can contain at most one anyAttribute"
 	complexTypeModelGroupOutOfOrder               = "complexType" + modelGroupOutOfOrderSuffix
 	simpleContentSimpleTypeOutOfOrder             = "simpleContent simpleType is out of order"
@@ -172,26 +177,61 @@ var complexTypeChildOrder = ChildOrder{
 			OrderMsg: "complexType content model is out of order",
 		},
 		{
-			Match:    matchChildLocal(sequenceChild, choiceChild, allChild, groupChild),
+			Match:    matchChildLocal(openContentChild),
 			Level:    1,
 			MaxOne:   true,
+			OrderMsg: "complexType" + openContentOutOfOrderSuffix,
+			DupMsg:   "complexType" + openContentOutOfOrderSuffix,
+		},
+		{
+			Match:    matchChildLocal(sequenceChild, choiceChild, allChild, groupChild),
+			Level:    2,
+			MaxOne:   true,
 			OrderMsg: complexTypeModelGroupOutOfOrder,
 			DupMsg:   complexTypeModelGroupOutOfOrder,
 		},
 		{
 			Match:    matchChildLocal(attributeChild, attributeGroup),
-			Level:    2,
+			Level:    3,
 			OrderMsg: "complexType" + attributeOutOfOrderSuffix,
 		},
 		{
 			Match:  matchChildLocal(anyAttribute),
-			Level:  3,
+			Level:  4,
 			MaxOne: true,
 			DupMsg: "complexType" + oneAnyAttributeSuffix,
 		},
+		{
+			Match: matchChildLocal(assertChild),
+			Level: 5,
+		},
 	},
 	InvalidMsg: func(local string) string { return "invalid complexType child " + local },
 }
+var openContentChildOrder = ChildOrder{
+	AnnotationFirstMsg: openContentChild + annotationMustBeFirstSuffix,
+	SingleAnnotation:   true,
+	Rules: []ChildRule{
+		{
+			Match:  matchChildLocal(anyChild),
+			MaxOne: true,
+			DupMsg: openContentChild + " can contain at most one any",
+		},
+	},
+	InvalidMsg: func(local string) string { return "invalid " + openContentChild + " child " + local },
+}
+var defaultOpenContentChildOrder = ChildOrder{
+	AnnotationFirstMsg: defaultOpenChild + annotationMustBeFirstSuffix,
+	SingleAnnotation:   true,
+	Rules: []ChildRule{
+		{
+			Match:  matchChildLocal(anyChild),
+			MaxOne: true,
+			DupMsg: defaultOpenChild + " can contain at most one any",
+		},
+	},
+	InvalidMsg: func(local string) string { return "invalid " + defaultOpenChild + " child " + local },
+}
 
 var complexContentChildOrder = derivationContainerOrder(complexContent)
 var simpleContentChildOrder = derivationContainerOrder(simpleContent)
@@ -243,13 +283,29 @@ var elementDeclarationChildOrder = ChildOrder{
 			OrderMsg: "element anonymous type must precede identity constraints",
 			DupMsg:   "element can contain at most one anonymous type",
 		},
+		{
+			Match:    matchChildLocal(alternativeChild),
+			Level:    1,
+			OrderMsg: "element alternatives must precede identity constraints",
+		},
 		{
 			Match: matchChildLocal(uniqueChild, keyChild, keyrefChild),
-			Level: 1,
+			Level: 2,
 		},
 	},
 	InvalidMsg: func(local string) string { return "invalid element child " + local },
 }
+var typeAlternativeChildOrder = ChildOrder{
+	AnnotationFirstMsg: "alternative annotation must precede anonymous type",
+	Rules: []ChildRule{
+		{
+			Match:  matchChildLocal(simpleTypeChild, complexTypeChild),
+			MaxOne: true,
+			DupMsg: "alternative can contain at most one anonymous type",
+		},
+	},
+	InvalidMsg: func(local string) string { return "invalid alternative child " + local },
+}
 
 // ValidateComplexContentChildrenSyntax validates xs:complexContent child order
 // and returns the selected derivation child.
@@ -489,6 +545,10 @@ func simpleContentDerivationOrder(derivation string) ChildOrder {
 			MaxOne: true,
 			DupMsg: "simpleContent" + oneAnyAttributeSuffix,
 		},
+		{
+			Match: matchChildLocal(assertChild),
+			Level: 4,
+		},
 	}
 	if derivation == restrictionChild {
 		rules = append(rules, ChildRule{
@@ -513,23 +573,34 @@ func complexContentDerivationOrder(derivation string) ChildOrder {
 		AnnotationFirstMsg: derivation + annotationMustBeFirstSuffix,
 		Rules: []ChildRule{
 			{
-				Match:    matchChildLocal(sequenceChild, choiceChild, allChild, groupChild),
+				Match:    matchChildLocal(openContentChild),
 				Level:    0,
 				MaxOne:   true,
+				OrderMsg: derivation + openContentOutOfOrderSuffix,
+				DupMsg:   derivation + openContentOutOfOrderSuffix,
+			},
+			{
+				Match:    matchChildLocal(sequenceChild, choiceChild, allChild, groupChild),
+				Level:    1,
+				MaxOne:   true,
 				OrderMsg: derivation + modelGroupOutOfOrderSuffix,
 				DupMsg:   derivation + modelGroupOutOfOrderSuffix,
 			},
 			{
 				Match:    matchChildLocal(attributeChild, attributeGroup),
-				Level:    1,
+				Level:    2,
 				OrderMsg: derivation + attributeOutOfOrderSuffix,
 			},
 			{
 				Match:  matchChildLocal(anyAttribute),
-				Level:  2,
+				Level:  3,
 				MaxOne: true,
 				DupMsg: derivation + oneAnyAttributeSuffix,
 			},
+			{
+				Match: matchChildLocal(assertChild),
+				Level: 4,
+			},
 		},
 		InvalidMsg: func(local string) string { return "invalid complexContent child " + local },
 	}
@@ -547,7 +618,8 @@ func isFacetChild(local string) bool {
 	switch local {
 	case vocab.XSDFacetLength, vocab.XSDFacetMinLength, vocab.XSDFacetMaxLength, vocab.XSDFacetTotalDigits, vocab.XSDFacetFractionDigits,
 		vocab.XSDFacetMinInclusive, vocab.XSDFacetMaxInclusive, vocab.XSDFacetMinExclusive, vocab.XSDFacetMaxExclusive,
-		vocab.XSDFacetEnumeration, vocab.XSDFacetPattern, vocab.XSDFacetWhiteSpace:
+		vocab.XSDFacetEnumeration, vocab.XSDFacetPattern, vocab.XSDFacetWhiteSpace, vocab.XSDFacetAssertion,
+		vocab.XSDFacetExplicitTimezone:
 		return true
 	default:
 		return false
@@ -0,0 +1,138 @@
+package compile
+
+import (
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/internal/xpath"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+type pendingTypeAlternatives struct {
+	nodes   []*rawNode
+	element runtime.ElementID
+}
+
+// compileTypeAlternatives compiles the xs:alternative children of element
+// declaration n in document order.
+func (c *compiler) compileTypeAlternatives(n *rawNode, ctx *schemaContext) ([]runtime.TypeAlternative, error) {
+	nodes := typeAlternativeNodes(n)
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	alternatives := make([]runtime.TypeAlternative, 0, len(nodes))
+	for i, alt := range nodes {
+		compiled, err := c.compileTypeAlternative(alt, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if compiled.Test == nil && i != len(nodes)-1 {
+			return nil, schemaCompileAt(alt, xsderrors.CodeSchemaTypeAlternative, "alternative without test must be the last alternative")
+		}
+		alternatives = append(alternatives, compiled)
+	}
+	return alternatives, nil
+}
+
+func typeAlternativeNodes(n *rawNode) []*rawNode {
+	var nodes []*rawNode
+	for child := range n.xsdChildren() {
+		if child.Name.Local == alternativeChild {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
+
+func (c *compiler) compileTypeAlternative(n *rawNode, ctx *schemaContext) (runtime.TypeAlternative, error) {
+	if err := checkChildOrderRules(n, typeAlternativeChildOrder); err != nil {
+		return runtime.TypeAlternative{}, err
+	}
+	var alt runtime.TypeAlternative
+	if test, ok := n.attr(vocab.XSDAttrTest); ok {
+		expr, err := xpath.Compile(test, xpath.StaticContext{
+			Namespace: func(prefix string) (string, bool) {
+				uri, ok := n.NS[prefix]
+				return uri, ok && uri != ""
+			},
+			DefaultElementNamespace: assertionDefaultElementNamespace(n, ctx),
+		})
+		if err != nil {
+			return runtime.TypeAlternative{}, schemaCompileAt(n, xsderrors.CodeSchemaTypeAlternative, "invalid alternative test: "+err.Error())
+		}
+		if axis, ok := expr.ElementAxis(); ok {
+			return runtime.TypeAlternative{}, schemaCompileAt(n, xsderrors.CodeSchemaTypeAlternative,
+				"invalid alternative test: the "+axis+" axis is not allowed; a test may only read attributes of the element")
+		}
+		alt.Test = expr
+	}
+	typeLex, hasType := n.attr(vocab.XSDAttrType)
+	st := n.firstXS(vocab.XSDElemSimpleType)
+	ct := n.firstXS(vocab.XSDElemComplexType)
+	switch {
+	case hasType && (st != nil || ct != nil):
+		return runtime.TypeAlternative{}, schemaCompileAt(n, xsderrors.CodeSchemaInvalidAttribute, "alternative cannot have both type and anonymous type")
+	case hasType:
+		typ, err := c.compileElementTypeAttribute(n, ctx, typeLex)
+		if err != nil {
+			return runtime.TypeAlternative{}, err
+		}
+		alt.Type = typ
+	case st != nil:
+		id, err := c.compileAnonymousSimple(st, ctx)
+		if err != nil {
+			return runtime.TypeAlternative{}, err
+		}
+		alt.Type = runtime.SimpleRef(id)
+	case ct != nil:
+		id, err := c.compileAnonymousComplex(ct, ctx)
+		if err != nil {
+			return runtime.TypeAlternative{}, err
+		}
+		alt.Type = runtime.ComplexRef(id)
+	default:
+		return runtime.TypeAlternative{}, schemaCompileAt(n, xsderrors.CodeSchemaTypeAlternative, "alternative missing type")
+	}
+	return alt, nil
+}
+
+func (c *compiler) addPendingTypeAlternatives(id runtime.ElementID, n *rawNode, alternatives []runtime.TypeAlternative) {
+	if len(alternatives) == 0 {
+		return
+	}
+	c.pendingTypeAlternatives = append(c.pendingTypeAlternatives, pendingTypeAlternatives{nodes: typeAlternativeNodes(n), element: id})
+}
+
+// validateTypeAlternatives checks that every alternative type other than
+// xs:error derives from the declared type of its element. It runs once
+// substitution groups have settled the declared types.
+func (c *compiler) validateTypeAlternatives() error {
+	for _, pending := range c.pendingTypeAlternatives {
+		if err := compileContextError(c.ctx); err != nil {
+			return err
+		}
+		var derivationErr error
+		ok := c.rt.forEachTypeAlternative(pending.element, func(index int, alt, declared runtime.TypeID) bool {
+			if index >= len(pending.nodes) {
+				derivationErr = xsderrors.InternalInvariant("pending type alternatives do not match declaration")
+				return false
+			}
+			if alt == runtime.SimpleRef(c.rt.builtinIDs().Error) {
+				return true
+			}
+			if _, derived := runtime.TypeDerivationMask(&c.rt, alt, declared); !derived {
+				derivationErr = schemaCompileAt(pending.nodes[index], xsderrors.CodeSchemaTypeAlternative,
+					"alternative type "+c.rt.TypeLabel(alt)+" does not derive from element type "+c.rt.TypeLabel(declared))
+				return false
+			}
+			return true
+		})
+		if !ok {
+			return xsderrors.InternalInvariant("pending type alternatives reference invalid element")
+		}
+		if derivationErr != nil {
+			return derivationErr
+		}
+	}
+	c.pendingTypeAlternatives = nil
+	return nil
+}
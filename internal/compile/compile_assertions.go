@@ -0,0 +1,98 @@
+package compile
+
+import (
+	"slices"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/internal/xpath"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+const (
+	xpathDefaultNamespaceDefault = "##defaultNamespace"
+	xpathDefaultNamespaceTarget  = "##targetNamespace"
+	xpathDefaultNamespaceLocal   = "##local"
+)
+
+// compileAssertions compiles the XSD children of n named local (assert or
+// assertion) and returns them appended to inherited without aliasing it.
+func (c *compiler) compileAssertions(children []*rawNode, ctx *schemaContext, local string, inherited []runtime.Assertion) ([]runtime.Assertion, error) {
+	out := inherited
+	for _, child := range children {
+		if child.Name.Space != vocab.XSDNamespaceURI || child.Name.Local != local {
+			continue
+		}
+		assertion, err := compileAssertion(child, ctx)
+		if err != nil {
+			return nil, err
+		}
+		out = append(slices.Clip(out), assertion)
+	}
+	return out, nil
+}
+
+func compileAssertion(n *rawNode, ctx *schemaContext) (runtime.Assertion, error) {
+	test, ok := n.attr(vocab.XSDAttrTest)
+	if !ok {
+		return runtime.Assertion{}, schemaCompileAt(n, xsderrors.CodeSchemaAssertion, n.Name.Local+" missing test")
+	}
+	expr, err := xpath.Compile(test, xpath.StaticContext{
+		Namespace: func(prefix string) (string, bool) {
+			uri, ok := n.NS[prefix]
+			return uri, ok && uri != ""
+		},
+		DefaultElementNamespace: assertionDefaultElementNamespace(n, ctx),
+	})
+	if err != nil {
+		return runtime.Assertion{}, schemaCompileAt(n, xsderrors.CodeSchemaAssertion, "invalid "+n.Name.Local+" test: "+err.Error())
+	}
+	return runtime.Assertion{Test: expr}, nil
+}
+
+// assertionDefaultElementNamespace resolves xpathDefaultNamespace on the
+// assertion or, failing that, on its schema document element.
+func assertionDefaultElementNamespace(n *rawNode, ctx *schemaContext) string {
+	value, ok := n.attr(vocab.XSDAttrXPathDefaultNS)
+	if !ok && n.doc != nil && n.doc.root != nil {
+		value, ok = n.doc.root.attr(vocab.XSDAttrXPathDefaultNS)
+	}
+	if !ok {
+		return ""
+	}
+	switch value {
+	case xpathDefaultNamespaceDefault:
+		return n.NS[""]
+	case xpathDefaultNamespaceTarget:
+		return ctx.targetNS
+	case xpathDefaultNamespaceLocal:
+		return ""
+	default:
+		return value
+	}
+}
+
+// complexTypeAssertions returns the assertions of the complex type declared by
+// n: those of its complex base type followed by the xs:assert children of the
+// complexType element or of its content derivation.
+func (c *compiler) complexTypeAssertions(n *rawNode, ctx *schemaContext, base runtime.TypeID) ([]runtime.Assertion, error) {
+	var inherited []runtime.Assertion
+	if baseID, ok := base.Complex(); ok {
+		inherited = c.rt.complexType(baseID).Assertions
+	}
+	owner := n
+	if cc := n.firstXS(vocab.XSDElemComplexContent); cc != nil {
+		source, err := checkComplexContentSyntax(cc)
+		if err != nil {
+			return nil, err
+		}
+		owner = source.node
+	} else if sc := n.firstXS(vocab.XSDElemSimpleContent); sc != nil {
+		source, err := checkSimpleContentSyntax(sc)
+		if err != nil {
+			return nil, err
+		}
+		owner = source.node
+	}
+	return c.compileAssertions(owner.Children, ctx, assertChild, inherited)
+}
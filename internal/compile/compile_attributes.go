@@ -24,6 +24,8 @@ func (c *compiler) compileAttributeByQName(q runtime.QName) (runtime.AttributeID
 		return 0, err
 	}
 	c.attributeDone[q] = id
+	c.annotateComponent(runtime.AnnotatedAttribute, uint32(id), raw.node)
+	c.locateComponent(runtime.AnnotatedAttribute, uint32(id), raw.node)
 	return id, nil
 }
 
@@ -154,6 +156,8 @@ func (c *compiler) compileAttributeUses(parent *rawNode, ctx *schemaContext, inh
 	uses := inherited
 	merger := NewAttributeUseMerger(inherited, inheritedWildcard, mode)
 	wildcards := NewAttributeWildcardBuilder(inheritedWildcard, mode)
+	notes := c.newAttributeUseAnnotations()
+	locs := c.newAttributeUseLocations()
 	for _, child := range parent.Children {
 		if child.Name.Space != runtime.XSDNamespaceURI || child.Name.Local == vocab.XSDElemAnnotation {
 			continue
@@ -168,6 +172,8 @@ func (c *compiler) compileAttributeUses(parent *rawNode, ctx *schemaContext, inh
 			if err != nil {
 				return runtime.NoAttributeUseSet, withSchemaCompileLocation(child, err)
 			}
+			notes.addUse(u.Name, child)
+			locs.addUse(u.Name, child)
 		case AttributeUseChildGroup:
 			groupUses, groupWildcard, err := c.compileAttributeGroupUse(child, ctx)
 			if err != nil {
@@ -182,6 +188,8 @@ func (c *compiler) compileAttributeUses(parent *rawNode, ctx *schemaContext, inh
 			if err := wildcards.AddGroup(c, groupWildcard); err != nil {
 				return runtime.NoAttributeUseSet, withSchemaCompileLocation(child, err)
 			}
+			c.addGroupAttributeUseAnnotations(notes, child, ctx)
+			c.addGroupAttributeUseLocations(locs, child, ctx)
 		case AttributeUseChildWildcard:
 			id, err := c.compileAttributeWildcard(child, ctx)
 			if err != nil {
@@ -210,7 +218,13 @@ func (c *compiler) compileAttributeUses(parent *rawNode, ctx *schemaContext, inh
 	if err = c.validateAttributeUseSet(set); err != nil {
 		return runtime.NoAttributeUseSet, withSchemaCompileLocation(parent, err)
 	}
-	return c.addAttributeUseSet(set)
+	id, err := c.addAttributeUseSet(set)
+	if err != nil {
+		return runtime.NoAttributeUseSet, err
+	}
+	c.annotateAttributeUses(id, notes)
+	c.locateAttributeUses(id, locs)
+	return id, nil
 }
 
 func (c *compiler) mergeAttributeUse(uses []runtime.AttributeUse, merger *AttributeUseMerger, use runtime.AttributeUse) ([]runtime.AttributeUse, error) {
@@ -424,7 +438,9 @@ func (c *compiler) compileAttributeGroupByQName(q runtime.QName) ([]runtime.Attr
 	if err != nil {
 		return nil, runtime.NoWildcard, err
 	}
-	c.attrGroupDone[q] = id
+	c.registerAttributeGroup(q, id)
+	c.annotateComponent(runtime.AnnotatedAttributeGroup, uint32(id), raw.node)
+	c.locateComponent(runtime.AnnotatedAttributeGroup, uint32(id), raw.node)
 	uses, wildcard := c.rt.attributeUsesAndWildcard(id)
 	return uses, wildcard, nil
 }
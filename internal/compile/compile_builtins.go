@@ -37,7 +37,9 @@ func (c *compiler) addBuiltinSimpleSeed(seed *runtime.BuiltinSimpleSeed) (runtim
 	if err != nil {
 		return runtime.NoSimpleType, err
 	}
-	c.simpleDone[q] = id
+	if !seed.XSD11 || c.limits.XSD11 {
+		c.simpleDone[q] = id
+	}
 	return id, nil
 }
 
@@ -103,7 +103,7 @@ func checkRawSchemaAttributes(n *rawNode) error {
 	return nil
 }
 
-func checkUnsupportedSchemaNode(n, parent *rawNode) (bool, error) {
+func checkUnsupportedSchemaNode(n, parent *rawNode, xsd11 bool) (bool, error) {
 	var parentLocal string
 	var parentXSD bool
 	if parent != nil {
@@ -115,6 +115,9 @@ func checkUnsupportedSchemaNode(n, parent *rawNode) (bool, error) {
 			return false, schemaCompileAt(n, xsderrors.CodeSchemaInvalidAttribute, "schema namespace attribute "+attr.Name.Local+" is not allowed")
 		}
 	}
+	if _, ok := n.attr(vocab.XSDAttrXPathDefaultNS); ok && !xsd11 && n.Name.Space == vocab.XSDNamespaceURI {
+		return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 attribute "+vocab.XSDAttrXPathDefaultNS+" is not supported")
+	}
 	if parentXSD && parentLocal == annotationChild &&
 		n.Name.Space == vocab.XSDNamespaceURI &&
 		(n.Name.Local == vocab.XSDElemAppinfo || n.Name.Local == vocab.XSDElemDocumentation) {
@@ -125,14 +128,36 @@ func checkUnsupportedSchemaNode(n, parent *rawNode) (bool, error) {
 	}
 	switch n.Name.Local {
 	case redefineChild:
-		return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedRedefine, "xs:redefine is not supported")
-	case notationChild:
 		if !parentXSD || parentLocal != vocab.XSDElemSchema {
+			return false, schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "xs:redefine must be a top-level schema child")
+		}
+	case notationChild:
+		if !parentXSD || (parentLocal != vocab.XSDElemSchema && parentLocal != vocab.XSDElemOverride) {
 			return false, schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "xs:notation must be a top-level schema child")
 		}
-	case assertChild, "alternative", "override", "openContent", "defaultOpenContent":
-		return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 feature "+n.Name.Local+" is not supported")
+	case assertChild, vocab.XSDFacetAssertion, vocab.XSDFacetExplicitTimezone:
+		if !xsd11 {
+			return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 feature "+n.Name.Local+" is not supported")
+		}
+	case alternativeChild:
+		if !xsd11 {
+			return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 feature "+n.Name.Local+" is not supported")
+		}
+	case overrideChild, defaultOpenChild:
+		if !xsd11 {
+			return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 feature "+n.Name.Local+" is not supported")
+		}
+		if !parentXSD || parentLocal != vocab.XSDElemSchema {
+			return false, schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "xs:"+n.Name.Local+" must be a top-level schema child")
+		}
+	case openContentChild:
+		if !xsd11 {
+			return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 feature "+n.Name.Local+" is not supported")
+		}
 	case anyChild, anyAttribute:
+		if xsd11 {
+			break
+		}
 		for _, attr := range []string{vocab.XSDAttrNotNamespace, vocab.XSDAttrNotQName} {
 			if _, ok := n.attr(attr); ok {
 				return false, unsupportedAtSchemaNode(n, xsderrors.CodeUnsupportedXSD11, "XSD 1.1 wildcard attribute "+attr+" is not supported")
@@ -300,7 +325,7 @@ func validateRawSchemaAnnotationNode(n *rawNode) (schemaAnnotationAction, error)
 		return schemaAnnotationAction{SkipChildren: true}, nil
 	case annotationChild:
 		return schemaAnnotationAction{}, validateRawAnnotationElement(n)
-	case vocab.XSDElemSchema:
+	case vocab.XSDElemSchema, vocab.XSDElemRedefine, vocab.XSDElemOverride:
 		return schemaAnnotationAction{}, nil
 	default:
 		return schemaAnnotationAction{}, validateRawComponentAnnotationPlacement(n)
@@ -379,6 +404,32 @@ func checkLocalComplexTypeAttributes(n *rawNode) error {
 	return nil
 }
 
+// checkRedefineChildren admits annotations and redefined type, group, and
+// attributeGroup components in any order.
+func checkRedefineChildren(n *rawNode) error {
+	for child := range n.xsdChildren() {
+		switch child.Name.Local {
+		case annotationChild, simpleTypeChild, complexTypeChild, groupChild, attributeGroup:
+		default:
+			return schemaCompileAt(child, xsderrors.CodeSchemaContentModel, "redefine cannot contain "+child.Name.Local)
+		}
+	}
+	return nil
+}
+
+// checkOverrideChildren admits annotations and overriding top-level
+// components in any order.
+func checkOverrideChildren(n *rawNode) error {
+	for child := range n.xsdChildren() {
+		switch child.Name.Local {
+		case annotationChild, simpleTypeChild, complexTypeChild, groupChild, attributeGroup, elementChild, attributeChild, notationChild:
+		default:
+			return schemaCompileAt(child, xsderrors.CodeSchemaContentModel, "override cannot contain "+child.Name.Local)
+		}
+	}
+	return nil
+}
+
 func checkAttributeGroupDeclarationChildren(n *rawNode) error {
 	return checkChildOrderRules(n, attributeGroupDeclarationChildOrder)
 }
@@ -47,6 +47,8 @@ func (c *compiler) compileComplexByQName(q runtime.QName) (runtime.ComplexTypeID
 	ct.Block = block
 	ct.Final = final
 	c.completeComplexType(id, ct)
+	c.annotateComponent(runtime.AnnotatedComplexType, uint32(id), raw.node)
+	c.locateComponent(runtime.AnnotatedComplexType, uint32(id), raw.node)
 	return id, nil
 }
 
@@ -90,6 +92,8 @@ func (c *compiler) completeAnonymousComplex(id runtime.ComplexTypeID, q runtime.
 	ct.Name = q
 	ct.Final = final
 	c.completeComplexType(id, ct)
+	c.annotateComponent(runtime.AnnotatedComplexType, uint32(id), n)
+	c.locateComponent(runtime.AnnotatedComplexType, uint32(id), n)
 	return id, nil
 }
 
@@ -157,6 +161,18 @@ func schemaBoolAttrDefault(n *rawNode, name string, def bool) (bool, error) {
 }
 
 func (c *compiler) compileComplexType(n *rawNode, ctx *schemaContext, name runtime.QName, anonymous bool) (runtime.ComplexType, error) {
+	ct, err := c.compileComplexTypeContent(n, ctx, name, anonymous)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	ct.Assertions, err = c.complexTypeAssertions(n, ctx, ct.Base)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	return ct, nil
+}
+
+func (c *compiler) compileComplexTypeContent(n *rawNode, ctx *schemaContext, name runtime.QName, anonymous bool) (runtime.ComplexType, error) {
 	if err := checkComplexTypeChildren(n); err != nil {
 		return runtime.ComplexType{}, err
 	}
@@ -211,6 +227,10 @@ func (c *compiler) compileComplexType(n *rawNode, ctx *schemaContext, name runti
 			return runtime.ComplexType{}, err
 		}
 	}
+	ct.Content, err = c.applyDeclaredOpenContent(n, ctx, ct.Content)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
 	attrs, err := c.compileAttributeUses(n, ctx, nil, runtime.NoWildcard, AttributeMergeNormal)
 	if err != nil {
 		return runtime.ComplexType{}, err
@@ -286,6 +306,14 @@ func (c *compiler) compileComplexContentExtension(child *rawNode, ctx *schemaCon
 	}
 	ct.Derivation = runtime.DerivationKindExtension
 	ct.ExplicitDerivation = true
+	baseModel, err := c.contentModel(base.Content, "complex extension references missing base content model")
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	base.Content, err = c.withOpenContent(base.Content, runtime.OpenContent{})
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
 	ct.Content = base.Content
 	ct.Attrs = base.Attrs
 	if modelNode := firstModelChild(child); modelNode != nil {
@@ -295,6 +323,22 @@ func (c *compiler) compileComplexContentExtension(child *rawNode, ctx *schemaCon
 		}
 		ct.Content = content
 	}
+	content, err := c.contentModel(ct.Content, "complex extension references missing content model")
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	declared, hasDeclared, err := c.declaredOpenContent(child.firstXS(openContentChild), ctx, content.Kind == runtime.ModelEmpty)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	open, err := c.extendOpenContent(child, baseModel.Open, declared, hasDeclared)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	ct.Content, err = c.withOpenContent(ct.Content, open)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
 	baseUses, baseWildcard := c.rt.attributeUsesAndWildcard(base.Attrs)
 	attrs, err := c.compileAttributeUses(child, ctx, baseUses, baseWildcard, AttributeMergeNormal)
 	if err != nil {
@@ -366,7 +410,21 @@ func (c *compiler) compileComplexContentRestriction(child *rawNode, ctx *schemaC
 	if err != nil {
 		return runtime.ComplexType{}, err
 	}
-	ct.Content = content
+	ct.Content, err = c.applyDeclaredOpenContent(child, ctx, content)
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	derived, err := c.contentModel(ct.Content, "complex restriction references missing content model")
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	baseModel, err := c.contentModel(base.Content, "complex restriction references missing base content model")
+	if err != nil {
+		return runtime.ComplexType{}, err
+	}
+	if err := c.checkOpenContentRestriction(child, baseModel, derived.Open); err != nil {
+		return runtime.ComplexType{}, err
+	}
 	baseUses, baseWildcard := c.rt.attributeUsesAndWildcard(base.Attrs)
 	attrs, err := c.compileAttributeUses(child, ctx, baseUses, baseWildcard, AttributeMergeRestriction)
 	if err != nil {
@@ -509,7 +567,7 @@ func (c *compiler) compileSimpleContentRestrictionType(child *rawNode, ctx *sche
 		return runtime.NoSimpleType, withSchemaCompileLocation(child, err)
 	}
 	if len(facetChildren) != 0 {
-		simpleID, err := c.compileSimpleContentFacetRestriction(facetChildren, textType)
+		simpleID, err := c.compileSimpleContentFacetRestriction(facetChildren, ctx, textType)
 		if err != nil {
 			return runtime.NoSimpleType, err
 		}
@@ -521,8 +579,8 @@ func (c *compiler) compileSimpleContentRestrictionType(child *rawNode, ctx *sche
 	return textType, nil
 }
 
-func (c *compiler) compileSimpleContentFacetRestriction(facetChildren []*rawNode, baseID runtime.SimpleTypeID) (runtime.SimpleTypeID, error) {
-	if err := CheckSimpleRestrictionBase(baseID, c.rt.builtinIDs().AnySimpleType); err != nil {
+func (c *compiler) compileSimpleContentFacetRestriction(facetChildren []*rawNode, ctx *schemaContext, baseID runtime.SimpleTypeID) (runtime.SimpleTypeID, error) {
+	if err := CheckSimpleRestrictionBase(baseID, c.rt.builtinIDs()); err != nil {
 		return runtime.NoSimpleType, withSchemaCompileLocation(facetChildren[0], err)
 	}
 	if err := CheckSimpleTypeFinalAllows(c.rt.simpleTypeFinal(baseID), runtime.DerivationRestriction, SimpleTypeFinalBaseRestriction); err != nil {
@@ -541,6 +599,10 @@ func (c *compiler) compileSimpleContentFacetRestriction(facetChildren []*rawNode
 	if err != nil {
 		return runtime.NoSimpleType, withSchemaCompileLocation(facetChildren[0], err)
 	}
+	st.Assertions, err = c.compileAssertions(facetChildren, ctx, vocab.XSDFacetAssertion, st.Assertions)
+	if err != nil {
+		return runtime.NoSimpleType, err
+	}
 	if st.Variety == runtime.SimpleVarietyUnion {
 		if err := c.chargeSimpleUnionMemberEntries(facetChildren[0], len(st.Union)); err != nil {
 			return runtime.NoSimpleType, err
@@ -548,13 +610,18 @@ func (c *compiler) compileSimpleContentFacetRestriction(facetChildren []*rawNode
 	}
 	st.Identity = c.rt.DerivedSimpleIdentity(st)
 	st.Fast = runtime.DeriveSimpleFastPathForSimpleType(st)
-	return c.addSimpleType(st)
+	id, err := c.addSimpleType(st)
+	if err != nil {
+		return runtime.NoSimpleType, err
+	}
+	c.locateFacets(id, facetChildren)
+	return id, nil
 }
 
 func facetChildren(n *rawNode) []*rawNode {
 	var out []*rawNode
 	for _, child := range n.Children {
-		if child.Name.Space == vocab.XSDNamespaceURI && IsFacetLocal(child.Name.Local) {
+		if child.Name.Space == vocab.XSDNamespaceURI && (IsFacetLocal(child.Name.Local) || child.Name.Local == vocab.XSDFacetAssertion) {
 			out = append(out, child)
 		}
 	}
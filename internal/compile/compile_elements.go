@@ -61,7 +61,10 @@ func (c *compiler) compileElementByQName(q runtime.QName) (runtime.ElementID, er
 		return 0, err
 	}
 	c.completeElement(id, decl)
+	c.annotateComponent(runtime.AnnotatedElement, uint32(id), raw.node)
+	c.locateComponent(runtime.AnnotatedElement, uint32(id), raw.node)
 	c.addPendingElementConstraint(id, raw.node, pending)
+	c.addPendingTypeAlternatives(id, raw.node, decl.Alternatives)
 	return id, nil
 }
 
@@ -103,7 +106,10 @@ func (c *compiler) compileLocalElement(n *rawNode, ctx *schemaContext) (runtime.
 		return 0, err
 	}
 	c.completeElement(id, decl)
+	c.annotateComponent(runtime.AnnotatedElement, uint32(id), n)
+	c.locateComponent(runtime.AnnotatedElement, uint32(id), n)
 	c.addPendingElementConstraint(id, n, pending)
+	c.addPendingTypeAlternatives(id, n, decl.Alternatives)
 	return id, nil
 }
 
@@ -162,6 +168,10 @@ func (c *compiler) compileElementDecl(n *rawNode, ctx *schemaContext, q runtime.
 		}
 		typ = runtime.ComplexRef(id)
 	}
+	alternatives, err := c.compileTypeAlternatives(n, ctx)
+	if err != nil {
+		return runtime.ElementDecl{}, elementConstraintDraft{}, err
+	}
 	decl := runtime.ElementDecl{
 		Name:      q,
 		Type:      typ,
@@ -188,6 +198,7 @@ func (c *compiler) compileElementDecl(n *rawNode, ctx *schemaContext, q runtime.
 		return runtime.ElementDecl{}, elementConstraintDraft{}, err
 	}
 	decl.Identity = identityIDs
+	decl.Alternatives = alternatives
 	return decl, elementConstraintDraft{
 		defaultLexical: defaultLexical,
 		fixedLexical:   fixedLexical,
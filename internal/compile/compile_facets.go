@@ -17,7 +17,8 @@ func (c *compiler) compileFacetList(children []*rawNode, st *runtime.SimpleType,
 func (c *compiler) compileFacetChildren(children []*rawNode, st *runtime.SimpleType, base, literalType runtime.SimpleTypeID, skipNonFacets bool) error {
 	var state compiledFacetState
 	for _, child := range children {
-		if child.Name.Space != runtime.XSDNamespaceURI || child.Name.Local == vocab.XSDElemAnnotation || child.Name.Local == vocab.XSDElemSimpleType {
+		if child.Name.Space != runtime.XSDNamespaceURI || child.Name.Local == vocab.XSDElemAnnotation || child.Name.Local == vocab.XSDElemSimpleType ||
+			child.Name.Local == vocab.XSDFacetAssertion {
 			continue
 		}
 		if skipNonFacets && !IsFacetLocal(child.Name.Local) {
@@ -45,7 +46,8 @@ func (c *compiler) validateUnavailableFacetChildren(
 	var ordered runtime.OrderedFacetStep
 	var patterns []runtime.StringPattern
 	for _, child := range children {
-		if child.Name.Space != runtime.XSDNamespaceURI || child.Name.Local == vocab.XSDElemAnnotation || child.Name.Local == vocab.XSDElemSimpleType {
+		if child.Name.Space != runtime.XSDNamespaceURI || child.Name.Local == vocab.XSDElemAnnotation || child.Name.Local == vocab.XSDElemSimpleType ||
+			child.Name.Local == vocab.XSDFacetAssertion {
 			continue
 		}
 		if skipNonFacets && !IsFacetLocal(child.Name.Local) {
@@ -62,7 +64,7 @@ func (c *compiler) validateUnavailableFacetChildren(
 		if !compile {
 			continue
 		}
-		mask, _ := facetMaskForLocal(child.Name.Local)
+		mask, _ := runtime.FacetMaskForLocal(child.Name.Local)
 		if mask != runtime.FacetPattern && mask != runtime.FacetEnumeration {
 			if single&mask != 0 {
 				return withSchemaCompileLocation(child, xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "duplicate "+child.Name.Local+" facet"))
@@ -87,10 +89,7 @@ func (c *compiler) validateUnavailableFacetChildren(
 		case vocab.XSDFacetMaxExclusive:
 			ordered.MaxExclusive = true
 		case vocab.XSDFacetPattern:
-			if c.regexCategories == nil {
-				c.regexCategories = make(RegexCategoryCache)
-			}
-			pattern, err := CompilePatternFacet(facet.value, c.regexCategories)
+			pattern, err := CompilePatternFacet(facet.value)
 			if err != nil {
 				return withSchemaCompileLocation(child, err)
 			}
@@ -99,6 +98,10 @@ func (c *compiler) validateUnavailableFacetChildren(
 			if err := c.compileWhitespaceFacet(&probe, base, child, facet.value, facet.fixed); err != nil {
 				return err
 			}
+		case vocab.XSDFacetExplicitTimezone:
+			if err := compileExplicitTimezoneFacet(&probe, child, facet.value, facet.fixed); err != nil {
+				return err
+			}
 		}
 	}
 	if err := runtime.ValidateOrderedFacetStep(ordered); err != nil {
@@ -163,7 +166,7 @@ func (c *compiler) compileFacetChild(child *rawNode, st *runtime.SimpleType, bas
 	if !compile {
 		return nil
 	}
-	mask, _ := facetMaskForLocal(child.Name.Local)
+	mask, _ := runtime.FacetMaskForLocal(child.Name.Local)
 	if mask != runtime.FacetPattern && mask != runtime.FacetEnumeration {
 		if state.stepSingleFacets&mask != 0 {
 			return withSchemaCompileLocation(child, xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "duplicate "+child.Name.Local+" facet"))
@@ -188,16 +191,15 @@ func (c *compiler) compileFacetChild(child *rawNode, st *runtime.SimpleType, bas
 		state.restrictedEnumeration = append(state.restrictedEnumeration, lit)
 		state.sawEnumeration = true
 	case vocab.XSDFacetPattern:
-		if c.regexCategories == nil {
-			c.regexCategories = make(RegexCategoryCache)
-		}
-		p, err := CompilePatternFacet(facet.value, c.regexCategories)
+		p, err := CompilePatternFacet(facet.value)
 		if err != nil {
 			return withSchemaCompileLocation(child, err)
 		}
 		state.stepPatterns = append(state.stepPatterns, p)
 	case vocab.XSDFacetWhiteSpace:
 		return c.compileWhitespaceFacet(st, base, child, facet.value, facet.fixed)
+	case vocab.XSDFacetExplicitTimezone:
+		return compileExplicitTimezoneFacet(st, child, facet.value, facet.fixed)
 	}
 	return nil
 }
@@ -278,6 +280,15 @@ func (c *compiler) compileWhitespaceFacet(st *runtime.SimpleType, base runtime.S
 	return nil
 }
 
+func compileExplicitTimezoneFacet(st *runtime.SimpleType, n *rawNode, value string, fixed bool) error {
+	tz, err := ParseExplicitTimezoneFacetValue(value)
+	if err != nil {
+		return withSchemaCompileLocation(n, err)
+	}
+	runtime.SetExplicitTimezoneFacet(&st.Facets, tz, fixed)
+	return nil
+}
+
 func (c *compiler) compileLiteral(base runtime.SimpleTypeID, lexical string, resolve runtime.ResolveQNameParts) (runtime.CompiledLiteral, error) {
 	recorder := valueConstraintResolver{resolve: resolve}
 	replayResolve := resolve
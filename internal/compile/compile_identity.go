@@ -90,6 +90,8 @@ func (c *compiler) compileDeclaredIdentityConstraints(nodes []*rawNode, ids []ru
 			return err
 		}
 		c.completeIdentity(id, ic)
+		c.annotateComponent(runtime.AnnotatedIdentity, uint32(id), node)
+		c.locateComponent(runtime.AnnotatedIdentity, uint32(id), node)
 	}
 	return nil
 }
@@ -133,13 +135,15 @@ func (c *compiler) compileIdentityConstraint(n *rawNode, ctx *schemaContext, nam
 		if fieldErr != nil {
 			return empty, fieldErr
 		}
-		fields = append(fields, runtime.IdentityField{Paths: fieldPaths})
+		fields = append(fields, runtime.IdentityField{Paths: fieldPaths, XPath: xpath})
 	}
 	kind, kindErr := IdentityConstraintKindForLocal(n.Name.Local)
 	if kindErr != nil {
 		return empty, withSchemaCompileLocation(n, kindErr)
 	}
-	return runtime.NewIdentityConstraint(kind, name, refer, paths, fields), nil
+	ic := runtime.NewIdentityConstraint(kind, name, refer, paths, fields)
+	ic.SelectorXPath = xpath
+	return ic, nil
 }
 
 type identityConstraintSyntax struct {
@@ -3,6 +3,9 @@ package compile
 import "github.com/jacoelho/xsd/internal/vocab"
 
 func (c *compiler) index() error {
+	if err := c.resolveRedefinitions(); err != nil {
+		return err
+	}
 	for _, document := range c.schemas.documents {
 		if err := compileContextError(c.ctx); err != nil {
 			return err
@@ -10,6 +13,7 @@ func (c *compiler) index() error {
 		if !document.indexDeclarations {
 			continue
 		}
+		c.annotateSchemaDocument(document.doc)
 		if err := c.indexSchemaDocument(document); err != nil {
 			return err
 		}
@@ -19,12 +23,14 @@ func (c *compiler) index() error {
 
 func (c *compiler) indexSchemaDocument(document schemaSetDocument) error {
 	doc := document.doc
-	ctx := c.schemaContext(document)
-	c.contexts[doc] = ctx
-	for child := range doc.root.xsdChildren() {
+	ctx := c.documentContext(document)
+	for child := range redefinableComponents(doc.root) {
 		if err := compileContextError(c.ctx); err != nil {
 			return err
 		}
+		if c.overridden[child] {
+			continue
+		}
 		if err := c.indexTopLevelSchemaChild(child, ctx); err != nil {
 			return err
 		}
@@ -32,6 +38,15 @@ func (c *compiler) indexSchemaDocument(document schemaSetDocument) error {
 	return nil
 }
 
+func (c *compiler) documentContext(document schemaSetDocument) *schemaContext {
+	if ctx, ok := c.contexts[document.doc]; ok {
+		return ctx
+	}
+	ctx := c.schemaContext(document)
+	c.contexts[document.doc] = ctx
+	return ctx
+}
+
 func (c *compiler) schemaContext(document schemaSetDocument) *schemaContext {
 	doc := document.doc
 	defaults := doc.defaults
@@ -63,6 +78,9 @@ func (c *compiler) indexTopLevelSchemaChild(child *rawNode, ctx *schemaContext)
 	if err != nil {
 		return err
 	}
+	if hidden, ok := c.redefinedNames[child]; ok {
+		q = hidden
+	}
 	label := c.rt.formatName(q)
 	component := rawComponent{child, ctx}
 	switch child.Name.Local {
@@ -0,0 +1,203 @@
+package compile
+
+import (
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+const (
+	openContentModeNone       = "none"
+	openContentModeInterleave = "interleave"
+	openContentModeSuffix     = "suffix"
+)
+
+// declaredOpenContent returns the open content a complex type declares
+// through its xs:openContent child decl or, when decl is nil, through the
+// schema document's xs:defaultOpenContent. The default applies to empty
+// content only with appliesToEmpty. declared is false when neither applies.
+func (c *compiler) declaredOpenContent(decl *rawNode, ctx *schemaContext, empty bool) (open runtime.OpenContent, declared bool, err error) {
+	if decl == nil {
+		decl = ctx.doc.root.firstXS(defaultOpenChild)
+		if decl == nil {
+			return runtime.OpenContent{}, false, nil
+		}
+		if empty {
+			appliesToEmpty, err := schemaBoolAttr(decl, vocab.XSDAttrAppliesToEmpty)
+			if err != nil || !appliesToEmpty {
+				return runtime.OpenContent{}, false, err
+			}
+		}
+	}
+	open, err = c.compileOpenContent(decl, ctx)
+	return open, err == nil, err
+}
+
+// compileOpenContent compiles an xs:openContent or xs:defaultOpenContent
+// element. Compiled results are shared by every type that uses them.
+func (c *compiler) compileOpenContent(n *rawNode, ctx *schemaContext) (runtime.OpenContent, error) {
+	if open, ok := c.openContentDone[n]; ok {
+		return open, nil
+	}
+	order := openContentChildOrder
+	if n.Name.Local == defaultOpenChild {
+		order = defaultOpenContentChildOrder
+	}
+	if err := checkChildOrderRules(n, order); err != nil {
+		return runtime.OpenContent{}, err
+	}
+	mode, err := parseOpenContentMode(n)
+	if err != nil {
+		return runtime.OpenContent{}, err
+	}
+	open := runtime.OpenContent{Mode: mode}
+	if mode != runtime.OpenContentNone {
+		wildcard := n.firstXS(anyChild)
+		if wildcard == nil {
+			return runtime.OpenContent{}, schemaCompileAt(n, xsderrors.CodeSchemaContentModel, n.Name.Local+" must contain any")
+		}
+		if err := checkAnyParticleChildren(wildcard); err != nil {
+			return runtime.OpenContent{}, err
+		}
+		for _, attr := range []string{vocab.XSDAttrMinOccurs, vocab.XSDAttrMaxOccurs} {
+			if _, ok := wildcard.attr(attr); ok {
+				return runtime.OpenContent{}, schemaCompileAt(wildcard, xsderrors.CodeSchemaInvalidAttribute, n.Name.Local+" any cannot have "+attr)
+			}
+		}
+		open.Wildcard, err = c.compileWildcard(wildcard, ctx)
+		if err != nil {
+			return runtime.OpenContent{}, err
+		}
+	}
+	c.openContentDone[n] = open
+	return open, nil
+}
+
+// compileDefaultOpenContents compiles every document's xs:defaultOpenContent,
+// so invalid defaults are reported even when no complex type applies them.
+func (c *compiler) compileDefaultOpenContents() error {
+	for _, document := range c.schemas.documents {
+		if err := compileContextError(c.ctx); err != nil {
+			return err
+		}
+		doc := document.doc
+		decl := doc.root.firstXS(defaultOpenChild)
+		if decl == nil {
+			continue
+		}
+		if _, err := c.compileOpenContent(decl, c.contexts[doc]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseOpenContentMode(n *rawNode) (runtime.OpenContentMode, error) {
+	value, ok := n.attr(vocab.XSDAttrMode)
+	if !ok {
+		return runtime.OpenContentInterleave, nil
+	}
+	switch value {
+	case openContentModeInterleave:
+		return runtime.OpenContentInterleave, nil
+	case openContentModeSuffix:
+		return runtime.OpenContentSuffix, nil
+	case openContentModeNone:
+		if n.Name.Local == openContentChild {
+			return runtime.OpenContentNone, nil
+		}
+	}
+	return runtime.OpenContentNone, schemaCompileAt(n, xsderrors.CodeSchemaInvalidAttribute, "invalid "+n.Name.Local+" mode "+value)
+}
+
+// extendOpenContent combines the open content declared by an extension with
+// the open content of its base. An extension without its own open content
+// keeps the base's; otherwise the wildcards are unioned under the extension's
+// mode, which cannot turn interleaved base open content into a suffix.
+func (c *compiler) extendOpenContent(n *rawNode, base, declared runtime.OpenContent, hasDeclared bool) (runtime.OpenContent, error) {
+	if !hasDeclared || !declared.Present() {
+		return base, nil
+	}
+	if !base.Present() {
+		return declared, nil
+	}
+	if base.Mode == runtime.OpenContentInterleave && declared.Mode == runtime.OpenContentSuffix {
+		return runtime.OpenContent{}, schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "extension cannot change interleave open content to suffix")
+	}
+	baseWildcard, ok := c.rt.Wildcard(base.Wildcard)
+	if !ok {
+		return runtime.OpenContent{}, xsderrors.InternalInvariant("base open content references missing wildcard")
+	}
+	derivedWildcard, ok := c.rt.Wildcard(declared.Wildcard)
+	if !ok {
+		return runtime.OpenContent{}, xsderrors.InternalInvariant("open content references missing wildcard")
+	}
+	union, err := runtime.UnionWildcard(derivedWildcard, baseWildcard, derivedWildcard.Process)
+	if err != nil {
+		return runtime.OpenContent{}, withSchemaCompileLocation(n, err)
+	}
+	id, err := c.addWildcard(union)
+	if err != nil {
+		return runtime.OpenContent{}, err
+	}
+	return runtime.OpenContent{Wildcard: id, Mode: declared.Mode}, nil
+}
+
+// checkOpenContentRestriction checks that the open content of a restriction
+// admits no element its base's open content rejects.
+func (c *compiler) checkOpenContentRestriction(n *rawNode, base runtime.ContentModel, derived runtime.OpenContent) error {
+	if !derived.Present() || base.Kind == runtime.ModelAny {
+		return nil
+	}
+	if !base.Open.Present() {
+		return schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "restriction adds open content absent from base")
+	}
+	if base.Open.Mode == runtime.OpenContentSuffix && derived.Mode != runtime.OpenContentSuffix {
+		return schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "restriction cannot interleave suffix open content")
+	}
+	baseWildcard, ok := c.rt.Wildcard(base.Open.Wildcard)
+	if !ok {
+		return xsderrors.InternalInvariant("base open content references missing wildcard")
+	}
+	derivedWildcard, ok := c.rt.Wildcard(derived.Wildcard)
+	if !ok {
+		return xsderrors.InternalInvariant("open content references missing wildcard")
+	}
+	if !runtime.WildcardSubset(derivedWildcard, baseWildcard) {
+		return schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "restriction open content wildcard is not a subset of base open content")
+	}
+	return nil
+}
+
+// applyDeclaredOpenContent attaches the open content declared on the
+// complexType or complexContent derivation n to content.
+func (c *compiler) applyDeclaredOpenContent(n *rawNode, ctx *schemaContext, content runtime.ContentModelID) (runtime.ContentModelID, error) {
+	decl := n.firstXS(openContentChild)
+	if decl == nil && ctx.doc.root.firstXS(defaultOpenChild) == nil {
+		return content, nil
+	}
+	model, err := c.contentModel(content, "open content references missing content model")
+	if err != nil {
+		return runtime.NoContentModel, err
+	}
+	open, _, err := c.declaredOpenContent(decl, ctx, model.Kind == runtime.ModelEmpty)
+	if err != nil {
+		return runtime.NoContentModel, err
+	}
+	return c.withOpenContent(content, open)
+}
+
+// withOpenContent returns a content model equal to id with open content open,
+// adding a copy when id carries different open content. Content models are
+// shared between types, so they are never updated in place.
+func (c *compiler) withOpenContent(id runtime.ContentModelID, open runtime.OpenContent) (runtime.ContentModelID, error) {
+	model, err := c.contentModel(id, "open content references missing content model")
+	if err != nil {
+		return runtime.NoContentModel, err
+	}
+	if model.Open == open {
+		return id, nil
+	}
+	model.Open = open
+	return c.addModelAt(model, c.modelSources[id])
+}
@@ -199,8 +199,12 @@ func (c *compiler) typeQNameKnown(q runtime.QName) bool {
 	return c.simpleTypeQNameKnown(q) || c.complexTypeQNameKnown(q)
 }
 
+// typeQNameMayBeUnavailable reports whether an unresolved reference to type
+// q is a missing component, resolved to the unavailable type, rather than an
+// error.
 func (c *compiler) typeQNameMayBeUnavailable(q runtime.QName) bool {
-	return c.rt.namespaceURI(q.Namespace) != vocab.XSDNamespaceURI
+	ns := c.rt.namespaceURI(q.Namespace)
+	return ns != vocab.XSDNamespaceURI && !c.namespaceRedefined(ns)
 }
 
 func (c *compiler) simpleTypeQNameKnown(q runtime.QName) bool {
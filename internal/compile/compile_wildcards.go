@@ -1,8 +1,10 @@
 package compile
 
 import (
+	"github.com/jacoelho/xsd/internal/lex"
 	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
 )
 
 func (c *compiler) compileWildcardParticle(n *rawNode, ctx *schemaContext) (runtime.Particle, error) {
@@ -29,20 +31,62 @@ func (c *compiler) compileAttributeWildcard(n *rawNode, ctx *schemaContext) (run
 
 func (c *compiler) compileWildcard(n *rawNode, ctx *schemaContext) (runtime.WildcardID, error) {
 	ns, hasNS := n.attr(vocab.XSDAttrNamespace)
+	notNS, hasNotNS := n.attr(vocab.XSDAttrNotNamespace)
 	process, hasProcess := n.attr(vocab.XSDAttrProcessContents)
 	w, err := ParseWildcard(&c.rt, WildcardAttrs{
 		Namespace:          ns,
+		NotNamespace:       notNS,
 		ProcessContents:    process,
 		TargetNamespace:    ctx.targetNS,
 		HasNamespace:       hasNS,
+		HasNotNamespace:    hasNotNS,
 		HasProcessContents: hasProcess,
 	})
 	if err != nil {
 		return runtime.NoWildcard, withSchemaCompileLocation(n, err)
 	}
+	if err := c.compileWildcardNotQName(n, ctx, &w); err != nil {
+		return runtime.NoWildcard, err
+	}
 	return c.addWildcard(w)
 }
 
+// compileWildcardNotQName resolves the XSD 1.1 notQName exclusions of n into
+// w. Only element wildcards may exclude ##definedSibling names.
+func (c *compiler) compileWildcardNotQName(n *rawNode, ctx *schemaContext, w *runtime.Wildcard) error {
+	value, ok := n.attr(vocab.XSDAttrNotQName)
+	if !ok {
+		return nil
+	}
+	var names []runtime.QName
+	for part := range lex.XMLFieldsSeq(value) {
+		switch part {
+		case wildcardNotQNameDefined:
+			w.NotDefined = true
+		case wildcardNotQNameDefinedSibling:
+			if n.Name.Local != vocab.XSDElemAny {
+				return schemaCompileAt(n, xsderrors.CodeSchemaInvalidAttribute, "anyAttribute notQName cannot contain "+part)
+			}
+			w.NotDefinedSibling = true
+		default:
+			ns, local, err := n.resolveQName(part)
+			if err != nil {
+				return err
+			}
+			if ns == "" && ctx.adoptedTarget {
+				ns = ctx.targetNS
+			}
+			q, err := c.rt.internQName(ns, local)
+			if err != nil {
+				return err
+			}
+			names = append(names, q)
+		}
+	}
+	w.NotQNames = runtime.NormalizeQNameList(names)
+	return nil
+}
+
 // Wildcard returns compiler-owned wildcard metadata for internal compile
 // helpers.
 func (c *compiler) Wildcard(id runtime.WildcardID) (runtime.Wildcard, bool) {
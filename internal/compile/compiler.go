@@ -91,6 +91,14 @@ type compilerIndexState struct {
 	groupRaw     map[runtime.QName]rawComponent
 	attrGroupRaw map[runtime.QName]rawComponent
 	contexts     map[*rawDoc]*schemaContext
+	// redefinedNames maps components replaced by xs:redefine to their hidden
+	// names; redefineRefs maps the redefinitions' self-references to them.
+	redefinedNames map[*rawNode]runtime.QName
+	redefineRefs   map[*rawNode]runtime.QName
+	redefinitions  []redefinition
+	// overridden holds components replaced by xs:override and xs:override
+	// children that replace nothing; neither is indexed.
+	overridden map[*rawNode]bool
 }
 
 type compilerBuildState struct {
@@ -101,12 +109,14 @@ type compilerBuildState struct {
 	elementDone               map[runtime.QName]runtime.ElementID
 	localDone                 map[*rawNode]runtime.ElementID
 	identityDeclared          map[*rawNode]runtime.IdentityConstraintID
-	regexCategories           RegexCategoryCache
 	simpleListReach           simpleTypeListReachability
 	simpleFacetCache          simpleValueFacetCache
 	simpleTypeUnavailable     []bool
 	deferredAnonymousComplex  []deferredAnonymousComplex
 	pendingElementConstraints []pendingElementConstraint
+	pendingTypeAlternatives   []pendingTypeAlternatives
+	openContentDone           map[*rawNode]runtime.OpenContent
+	annotatedDocs             map[*rawDoc]bool
 	unionMemberEntries        int
 }
 
@@ -159,13 +169,16 @@ func newCompiler(ctx context.Context, limits Limits) (*compiler, error) {
 		ctx:           ctx,
 		builtinFacets: runtime.NewBuiltinSimpleFacetStorage(),
 		compilerIndexState: compilerIndexState{
-			simpleRaw:    make(map[runtime.QName]rawComponent),
-			complexRaw:   make(map[runtime.QName]rawComponent),
-			elementRaw:   make(map[runtime.QName]rawComponent),
-			attributeRaw: make(map[runtime.QName]rawComponent),
-			groupRaw:     make(map[runtime.QName]rawComponent),
-			attrGroupRaw: make(map[runtime.QName]rawComponent),
-			contexts:     make(map[*rawDoc]*schemaContext),
+			simpleRaw:      make(map[runtime.QName]rawComponent),
+			complexRaw:     make(map[runtime.QName]rawComponent),
+			elementRaw:     make(map[runtime.QName]rawComponent),
+			attributeRaw:   make(map[runtime.QName]rawComponent),
+			groupRaw:       make(map[runtime.QName]rawComponent),
+			attrGroupRaw:   make(map[runtime.QName]rawComponent),
+			contexts:       make(map[*rawDoc]*schemaContext),
+			redefinedNames: make(map[*rawNode]runtime.QName),
+			redefineRefs:   make(map[*rawNode]runtime.QName),
+			overridden:     make(map[*rawNode]bool),
 		},
 		compilerBuildState: compilerBuildState{
 			simpleDone:       make(map[runtime.QName]runtime.SimpleTypeID, builtinSimpleTypeCount),
@@ -175,6 +188,8 @@ func newCompiler(ctx context.Context, limits Limits) (*compiler, error) {
 			elementDone:      make(map[runtime.QName]runtime.ElementID),
 			localDone:        make(map[*rawNode]runtime.ElementID),
 			identityDeclared: make(map[*rawNode]runtime.IdentityConstraintID),
+			openContentDone:  make(map[*rawNode]runtime.OpenContent),
+			annotatedDocs:    make(map[*rawDoc]bool),
 		},
 		compilerCycleState: compilerCycleState{
 			compilingSimple:  make(map[runtime.QName]bool),
@@ -237,6 +252,9 @@ func (c *compiler) compileGlobals() error {
 			return err
 		}
 	}
+	if err := c.compileDefaultOpenContents(); err != nil {
+		return err
+	}
 	if err := c.declareAllIdentityConstraints(); err != nil {
 		return err
 	}
@@ -263,6 +281,12 @@ func (c *compiler) compileGlobals() error {
 	if err := c.validateCompiledComplexRestrictions(); err != nil {
 		return err
 	}
+	if err := c.validateRedefinitionRestrictions(); err != nil {
+		return err
+	}
+	if err := c.validateTypeAlternatives(); err != nil {
+		return err
+	}
 	if err := c.checkCompiledElementDeclarationsConsistent(); err != nil {
 		return err
 	}
@@ -341,6 +365,9 @@ func derivationMaskWithDefaultChecked(n *rawNode, def runtime.DerivationMask, ru
 }
 
 func (c *compiler) resolveQNameChecked(n *rawNode, ctx *schemaContext, lexical string) (runtime.QName, error) {
+	if q, ok := c.redefineRefs[n]; ok {
+		return q, nil
+	}
 	ns, local, err := n.resolveQName(lexical)
 	if err != nil {
 		return runtime.QName{}, err
@@ -470,6 +497,8 @@ func (c *compiler) compileSimpleByQName(q runtime.QName) (runtime.SimpleTypeID,
 	st.Identity = c.rt.DerivedSimpleIdentity(st)
 	st.Fast = runtime.DeriveSimpleFastPathForSimpleType(st)
 	c.completeSimpleType(id, st)
+	c.annotateComponent(runtime.AnnotatedSimpleType, uint32(id), raw.node)
+	c.locateSimpleType(id, raw.node)
 	return id, nil
 }
 
@@ -498,6 +527,8 @@ func (c *compiler) compileAnonymousSimple(n *rawNode, ctx *schemaContext) (runti
 	st.Identity = c.rt.DerivedSimpleIdentity(st)
 	st.Fast = runtime.DeriveSimpleFastPathForSimpleType(st)
 	c.completeSimpleType(id, st)
+	c.annotateComponent(runtime.AnnotatedSimpleType, uint32(id), n)
+	c.locateSimpleType(id, n)
 	return id, nil
 }
 
@@ -574,7 +605,7 @@ func (c *compiler) compileRestriction(n *rawNode, ctx *schemaContext, name runti
 		}
 		baseID = id
 	}
-	if err := CheckSimpleRestrictionBase(baseID, c.rt.builtinIDs().AnySimpleType); err != nil {
+	if err := CheckSimpleRestrictionBase(baseID, c.rt.builtinIDs()); err != nil {
 		return runtime.SimpleType{}, withSchemaCompileLocation(n, err)
 	}
 	if err := CheckSimpleTypeFinalAllows(c.rt.simpleTypeFinal(baseID), runtime.DerivationRestriction, SimpleTypeFinalBaseRestriction); err != nil {
@@ -588,6 +619,11 @@ func (c *compiler) compileRestriction(n *rawNode, ctx *schemaContext, name runti
 	} else if err := c.compileFacets(n, &st, baseID, baseID); err != nil {
 		return runtime.SimpleType{}, withSchemaCompileLocation(n, err)
 	}
+	assertions, err := c.compileAssertions(n.Children, ctx, vocab.XSDFacetAssertion, st.Assertions)
+	if err != nil {
+		return runtime.SimpleType{}, err
+	}
+	st.Assertions = assertions
 	if st.Variety == runtime.SimpleVarietyUnion {
 		if err := c.chargeSimpleUnionMemberEntries(n, len(st.Union)); err != nil {
 			return runtime.SimpleType{}, err
@@ -440,7 +440,7 @@ func (c *contentModelCompiler) compileContentModel(id runtime.ContentModelID) (r
 	}
 	switch model.Kind {
 	case runtime.ModelEmpty:
-		return runtime.CompiledModel{Kind: runtime.CompiledModelEmpty, Mixed: model.Mixed, Empty: true}, nil
+		return runtime.CompiledModel{Kind: runtime.CompiledModelEmpty, Mixed: model.Mixed, Empty: true, Open: model.Open}, nil
 	case runtime.ModelAny:
 		return runtime.CompiledModel{Kind: runtime.CompiledModelAny, Mixed: model.Mixed, Empty: true}, nil
 	case runtime.ModelAll:
@@ -538,6 +538,7 @@ func (c *contentModelCompiler) compileDirectSequenceModel(model runtime.ContentM
 		Start: 0,
 		Mixed: model.Mixed,
 		Empty: rows[0].Accept,
+		Open:  model.Open,
 	}, true, nil
 }
 
@@ -585,6 +586,7 @@ func (c *contentModelCompiler) compileDirectChoiceModel(model runtime.ContentMod
 		Start: 0,
 		Mixed: model.Mixed,
 		Empty: rows[0].Accept,
+		Open:  model.Open,
 	}, true, nil
 }
 
@@ -727,6 +729,7 @@ func (c *contentModelCompiler) compileAllModel(model runtime.ContentModel) (runt
 		AllBitLen: allBitLen,
 		Mixed:     model.Mixed,
 		Empty:     model.Occurs.Min == 0 || !required,
+		Open:      model.Open,
 	}, nil
 }
 
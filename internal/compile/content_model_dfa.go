@@ -82,6 +82,7 @@ func (b *dfaBuilder) compileDeterministicModel(id runtime.ContentModelID, start
 		Start: startID,
 		Mixed: model.Mixed,
 		Empty: rows[startID].Accept,
+		Open:  model.Open,
 	}, nil
 }
 
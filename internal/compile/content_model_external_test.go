@@ -971,6 +971,30 @@ func TestRestrictionNonPointlessChoiceCannotRestrictSequence(t *testing.T) {
 	}
 }
 
+func TestRestrictionPointlessChoiceCanRestrictAll(t *testing.T) {
+	mustCompileRuntime(t, `
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="base"><xs:all><xs:element name="e1"/><xs:element name="e2" minOccurs="0"/></xs:all></xs:complexType>
+  <xs:complexType name="derived"><xs:complexContent><xs:restriction base="base"><xs:choice><xs:element name="e1"/></xs:choice></xs:restriction></xs:complexContent></xs:complexType>
+</xs:schema>`)
+}
+
+func TestRestrictionChoiceCannotRestrictAll(t *testing.T) {
+	for _, derived := range []string{
+		`<xs:choice><xs:element name="e2"/></xs:choice>`,
+		`<xs:choice maxOccurs="2"><xs:element name="e1"/></xs:choice>`,
+		`<xs:choice><xs:element name="e1"/><xs:element name="e2"/></xs:choice>`,
+	} {
+		_, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(`
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="base"><xs:all><xs:element name="e1"/><xs:element name="e2" minOccurs="0"/></xs:all></xs:complexType>
+  <xs:complexType name="derived"><xs:complexContent><xs:restriction base="base">`+derived+`</xs:restriction></xs:complexContent></xs:complexType>
+</xs:schema>`))})
+
+		expectCode(t, err, xsderrors.CodeSchemaContentModel)
+	}
+}
+
 func TestRestrictionLocalIdentityConstraintsMustBeSubset(t *testing.T) {
 	common := `<xs:complexType name="T"><xs:sequence><xs:element name="row" maxOccurs="unbounded"><xs:complexType><xs:attribute name="id" type="xs:string"/></xs:complexType></xs:element></xs:sequence></xs:complexType>`
 	key := `<xs:key name="k"><xs:selector xpath="row"/><xs:field xpath="@id"/></xs:key>`
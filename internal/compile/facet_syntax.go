@@ -19,14 +19,14 @@ type FacetSource struct {
 
 // IsFacetLocal reports whether local is one of the XSD facet element names.
 func IsFacetLocal(local string) bool {
-	_, ok := facetMaskForLocal(local)
+	_, ok := runtime.FacetMaskForLocal(local)
 	return ok
 }
 
 // ValidateFacetSource validates a facet child and reports whether schema
 // compilation should compile it. Non-XSD non-facet children are skipped.
 func ValidateFacetSource(source FacetSource) (bool, error) {
-	mask, ok := facetMaskForLocal(source.Local)
+	mask, ok := runtime.FacetMaskForLocal(source.Local)
 	if !ok {
 		if source.InXSDNamespace {
 			return false, xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "unsupported facet "+source.Local)
@@ -63,33 +63,17 @@ func ParseWhitespaceFacetValue(value string, base runtime.WhitespaceMode) (runti
 	return mode, nil
 }
 
-func facetMaskForLocal(local string) (runtime.FacetMask, bool) {
-	switch local {
-	case vocab.XSDFacetLength:
-		return runtime.FacetLength, true
-	case vocab.XSDFacetMinLength:
-		return runtime.FacetMinLength, true
-	case vocab.XSDFacetMaxLength:
-		return runtime.FacetMaxLength, true
-	case vocab.XSDFacetTotalDigits:
-		return runtime.FacetTotalDigits, true
-	case vocab.XSDFacetFractionDigits:
-		return runtime.FacetFractionDigits, true
-	case vocab.XSDFacetMinInclusive:
-		return runtime.FacetMinInclusive, true
-	case vocab.XSDFacetMaxInclusive:
-		return runtime.FacetMaxInclusive, true
-	case vocab.XSDFacetMinExclusive:
-		return runtime.FacetMinExclusive, true
-	case vocab.XSDFacetMaxExclusive:
-		return runtime.FacetMaxExclusive, true
-	case vocab.XSDFacetEnumeration:
-		return runtime.FacetEnumeration, true
-	case vocab.XSDFacetPattern:
-		return runtime.FacetPattern, true
-	case vocab.XSDFacetWhiteSpace:
-		return runtime.FacetWhiteSpace, true
+// ParseExplicitTimezoneFacetValue parses an xs:explicitTimezone facet value.
+func ParseExplicitTimezoneFacetValue(value string) (runtime.ExplicitTimezone, error) {
+	value = lex.CollapseXMLWhitespace(value)
+	switch value {
+	case vocab.XSDTimezoneOptional:
+		return runtime.ExplicitTimezoneOptional, nil
+	case vocab.XSDTimezoneRequired:
+		return runtime.ExplicitTimezoneRequired, nil
+	case vocab.XSDTimezoneProhibited:
+		return runtime.ExplicitTimezoneProhibited, nil
 	default:
-		return 0, false
+		return 0, xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid explicitTimezone facet "+value)
 	}
 }
@@ -138,6 +138,40 @@ func TestParseWhitespaceFacetValue(t *testing.T) {
 	}
 }
 
+func TestParseExplicitTimezoneFacetValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		value       string
+		want        runtime.ExplicitTimezone
+		wantMessage string
+	}{
+		{name: "optional", value: "optional", want: runtime.ExplicitTimezoneOptional},
+		{name: "required", value: "required", want: runtime.ExplicitTimezoneRequired},
+		{name: "prohibited", value: "prohibited", want: runtime.ExplicitTimezoneProhibited},
+		{name: "collapsed lexical", value: " required\n", want: runtime.ExplicitTimezoneRequired},
+		{name: "invalid lexical", value: "always", wantMessage: "invalid explicitTimezone facet always"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseExplicitTimezoneFacetValue(tt.value)
+			if tt.wantMessage != "" {
+				expectSchemaFacetMessage(t, err, tt.wantMessage)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseExplicitTimezoneFacetValue() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseExplicitTimezoneFacetValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func expectSchemaFacetMessage(t *testing.T, err error, message string) {
 	t.Helper()
 	diag, ok := errors.AsType[*xsderrors.Error](err)
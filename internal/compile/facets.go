@@ -46,6 +46,9 @@ func ValidateCompiledFacets(st runtime.SimpleType, base runtime.SimpleType, orde
 	if err := runtime.ValidateFixedFacetPreservation(runtime.FixedFacetPreservationForSimpleTypes(st, base)); err != nil {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, err.Error())
 	}
+	if err := runtime.ValidateExplicitTimezoneRestriction(st.Facets, base.Facets); err != nil {
+		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, err.Error())
+	}
 	if err := runtime.ValidatePrimitiveFacetRestrictions(st, base.Facets, orderedStep); err != nil {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, err.Error())
 	}
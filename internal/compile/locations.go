@@ -0,0 +1,103 @@
+package compile
+
+import (
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+// locateComponent records where the component declared by n is, when source
+// locations are retained.
+func (c *compiler) locateComponent(kind runtime.AnnotatedKind, id uint32, n *rawNode) {
+	c.recordLocation(runtime.LocationKey{AnnotationKey: runtime.AnnotationKey{Kind: kind, ID: id}}, n)
+}
+
+// locateSimpleType records where simple type id and the facets of the
+// restriction that defines it are declared.
+func (c *compiler) locateSimpleType(id runtime.SimpleTypeID, n *rawNode) {
+	c.locateComponent(runtime.AnnotatedSimpleType, uint32(id), n)
+	if restriction := n.firstXS(vocab.XSDElemRestriction); restriction != nil {
+		c.locateFacets(id, restriction.Children)
+	}
+}
+
+// locateFacets records where the facets among children are declared for
+// simple type id. Facets that repeat, such as enumeration and pattern, are
+// located at their first declaration.
+func (c *compiler) locateFacets(id runtime.SimpleTypeID, children []*rawNode) {
+	for _, child := range children {
+		if child.Name.Space != vocab.XSDNamespaceURI {
+			continue
+		}
+		if mask, ok := runtime.FacetMaskForLocal(child.Name.Local); ok {
+			c.recordLocation(runtime.LocationKey{
+				AnnotationKey: runtime.AnnotationKey{Kind: runtime.AnnotatedSimpleType, ID: uint32(id)},
+				Facet:         mask,
+			}, child)
+		}
+	}
+}
+
+func (c *compiler) recordLocation(key runtime.LocationKey, n *rawNode) {
+	if !c.limits.RetainSourceLocations || n == nil {
+		return
+	}
+	if _, ok := c.sourceLocation(key); ok {
+		return
+	}
+	c.setSourceLocation(key, nodeSourceLocation(n))
+}
+
+func nodeSourceLocation(n *rawNode) runtime.SourceLocation {
+	loc := runtime.SourceLocation{Component: n.Name.Local, Line: n.Line, Column: n.Column}
+	if n.doc != nil {
+		loc.Source = n.doc.name
+	}
+	return loc
+}
+
+// attributeUseLocations collects where the attribute uses of one
+// attribute-use set are declared while it is compiled. It is nil when source
+// locations are not retained.
+type attributeUseLocations map[runtime.QName]runtime.SourceLocation
+
+func (c *compiler) newAttributeUseLocations() attributeUseLocations {
+	if !c.limits.RetainSourceLocations {
+		return nil
+	}
+	return make(attributeUseLocations)
+}
+
+func (locs attributeUseLocations) addUse(name runtime.QName, n *rawNode) {
+	if locs == nil {
+		return
+	}
+	locs[name] = nodeSourceLocation(n)
+}
+
+// addGroupAttributeUseLocations copies the attribute-use locations of the
+// attribute group referenced by n.
+func (c *compiler) addGroupAttributeUseLocations(locs attributeUseLocations, n *rawNode, ctx *schemaContext) {
+	if locs == nil {
+		return
+	}
+	group, ok := c.referencedAttributeGroup(n, ctx)
+	if !ok {
+		return
+	}
+	uses, _ := c.rt.attributeUsesAndWildcard(group)
+	for _, use := range uses {
+		key := runtime.LocationKey{AnnotationKey: runtime.AnnotationKey{Kind: runtime.AnnotatedAttributeUse, ID: uint32(group), Name: use.Name}}
+		if loc, ok := c.sourceLocation(key); ok {
+			locs[use.Name] = loc
+		}
+	}
+}
+
+func (c *compiler) locateAttributeUses(set runtime.AttributeUseSetID, locs attributeUseLocations) {
+	if len(locs) == 0 {
+		return
+	}
+	for name, loc := range locs {
+		c.setSourceLocation(runtime.LocationKey{AnnotationKey: runtime.AnnotationKey{Kind: runtime.AnnotatedAttributeUse, ID: uint32(set), Name: name}}, loc)
+	}
+}
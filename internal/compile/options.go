@@ -1,7 +1,10 @@
 // Package compile owns schema compilation concerns.
 package compile
 
-import "github.com/jacoelho/xsd/xsderrors"
+import (
+	"github.com/jacoelho/xsd/internal/stream"
+	"github.com/jacoelho/xsd/xsderrors"
+)
 
 const (
 	defaultMaxSchemaDepth                = 256
@@ -34,8 +37,28 @@ type Options struct {
 	MaxContentModelStates         int
 	MaxSubstitutionClosureEntries int
 	MaxSimpleUnionMemberEntries   int
+	// Charsets resolves declared schema document encodings before the
+	// built-in charsets. Nil uses only the built-in charsets.
+	Charsets stream.CharsetLookup
+	// XML11 accepts schema documents declaring XML version 1.1.
+	XML11 bool
+	// XSD11 enables the supported XSD 1.1 schema components.
+	XSD11 bool
+	// RetainAnnotations keeps xs:documentation and xs:appinfo content
+	// attached to the compiled components.
+	RetainAnnotations bool
+	// RetainSourceLocations keeps the schema document, line and column of
+	// the declarations, types, facets and identity constraints.
+	RetainSourceLocations bool
+	// Documents, when non-nil, supplies schema document bytes in place of
+	// reading the sources they are keyed by, and receives the bytes of every
+	// other document read.
+	Documents Documents
 }
 
+// Documents holds schema document bytes keyed by source.Key of their names.
+type Documents map[string][]byte
+
 // Limits is the normalized internal form of Options.
 type Limits struct {
 	MaxSchemaDepth                int
@@ -52,6 +75,12 @@ type Limits struct {
 	MaxSubstitutionClosureEntries int
 	MaxSimpleUnionMemberEntries   int
 	MaxFiniteOccurs               uint64
+	Charsets                      stream.CharsetLookup
+	XML11                         bool
+	XSD11                         bool
+	RetainAnnotations             bool
+	RetainSourceLocations         bool
+	Documents                     Documents
 }
 
 // NormalizeOptions validates options and fills default limits.
@@ -122,6 +151,12 @@ func NormalizeOptions(opts Options) (Limits, error) {
 		MaxSubstitutionClosureEntries: substitutionEntries,
 		MaxSimpleUnionMemberEntries:   unionEntries,
 		MaxFiniteOccurs:               opts.MaxFiniteOccurs,
+		Charsets:                      opts.Charsets,
+		XML11:                         opts.XML11,
+		XSD11:                         opts.XSD11,
+		RetainAnnotations:             opts.RetainAnnotations,
+		RetainSourceLocations:         opts.RetainSourceLocations,
+		Documents:                     opts.Documents,
 	}, nil
 }
 
@@ -0,0 +1,35 @@
+package compile
+
+import (
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// applyOverride hides every component of the overridden schema that an
+// xs:override child replaces. The overriding component then owns the name for
+// the whole schema set, including references inside the overridden schema.
+// Override children that match no component are ignored.
+func (c *compiler) applyOverride(edge schemaComposition) error {
+	for child := range edge.node.xsdChildren() {
+		if child.Name.Local == annotationChild {
+			continue
+		}
+		if err := checkTopLevelSchemaChild(child); err != nil {
+			return err
+		}
+		if edge.target < 0 {
+			return schemaCompileAt(edge.node, xsderrors.CodeSchemaReference, "override schemaLocation does not resolve to a schema document")
+		}
+		name, _ := child.attr(vocab.XSDAttrName)
+		original, err := c.redefinedComponent(edge.target, child, name)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			c.overridden[child] = true
+			continue
+		}
+		c.overridden[original] = true
+	}
+	return nil
+}
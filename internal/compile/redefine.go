@@ -0,0 +1,363 @@
+package compile
+
+import (
+	"fmt"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// redefinition records one component replaced through xs:redefine. The
+// redefining component owns the public name; the original is indexed under a
+// hidden name that only the redefinition's self-reference resolves to.
+type redefinition struct {
+	node     *rawNode
+	ctx      *schemaContext
+	name     runtime.QName
+	original runtime.QName
+	selfRef  bool
+}
+
+type redefineVisit uint8
+
+const (
+	redefineUnvisited redefineVisit = iota
+	redefineVisiting
+	redefineDone
+)
+
+// resolveRedefinitions renames every redefined component and hides every
+// overridden component before indexing. Documents are visited in post-order
+// over composition edges so a chain of redefinitions or overrides layers each
+// one over the previous holder of the name.
+func (c *compiler) resolveRedefinitions() error {
+	visits := make([]redefineVisit, len(c.schemas.documents))
+	for i, document := range c.schemas.documents {
+		if !document.indexDeclarations {
+			continue
+		}
+		if err := c.visitRedefinitions(i, visits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *compiler) visitRedefinitions(index int, visits []redefineVisit) error {
+	if visits[index] != redefineUnvisited {
+		return nil
+	}
+	if err := compileContextError(c.ctx); err != nil {
+		return err
+	}
+	visits[index] = redefineVisiting
+	document := c.schemas.documents[index]
+	for _, edge := range document.compositions {
+		if edge.target < 0 {
+			continue
+		}
+		if err := c.visitRedefinitions(edge.target, visits); err != nil {
+			return err
+		}
+	}
+	ctx := c.documentContext(document)
+	for _, edge := range document.compositions {
+		var err error
+		switch edge.kind {
+		case schemaReferenceRedefine:
+			err = c.applyRedefine(edge, ctx)
+		case schemaReferenceOverride:
+			err = c.applyOverride(edge)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	visits[index] = redefineDone
+	return nil
+}
+
+func (c *compiler) applyRedefine(edge schemaComposition, ctx *schemaContext) error {
+	for child := range edge.node.xsdChildren() {
+		if child.Name.Local == annotationChild {
+			continue
+		}
+		if err := checkTopLevelSchemaChild(child); err != nil {
+			return err
+		}
+		if edge.target < 0 {
+			return schemaCompileAt(edge.node, xsderrors.CodeSchemaReference, "redefine schemaLocation does not resolve to a schema document")
+		}
+		name, _ := child.attr(vocab.XSDAttrName)
+		q, err := c.rt.internQName(ctx.targetNS, name)
+		if err != nil {
+			return err
+		}
+		label := child.Name.Local + " " + c.rt.formatName(q)
+		original, err := c.redefinedComponent(edge.target, child, name)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			return schemaCompileAt(child, xsderrors.CodeSchemaReference, "redefined "+label+" is not declared in the redefined schema")
+		}
+		hidden, err := c.rt.internQName(ctx.targetNS, fmt.Sprintf("%s$redefined%d", name, len(c.redefinitions)))
+		if err != nil {
+			return err
+		}
+		refs, err := redefineSelfReferences(child, ctx, name)
+		if err != nil {
+			return err
+		}
+		c.redefinedNames[original] = hidden
+		for _, ref := range refs {
+			c.redefineRefs[ref] = hidden
+		}
+		c.redefinitions = append(c.redefinitions, redefinition{
+			node:     child,
+			ctx:      ctx,
+			name:     q,
+			original: hidden,
+			selfRef:  len(refs) != 0,
+		})
+	}
+	return nil
+}
+
+// namespaceRedefined reports whether a schema document for namespace ns
+// redefines another. xs:redefine requires the documents it names, so every
+// component of the namespace is present and a reference that resolves to
+// none is an error rather than a missing component.
+func (c *compiler) namespaceRedefined(ns string) bool {
+	for _, document := range c.schemas.documents {
+		if !document.indexDeclarations || document.effectiveTargetNS != ns {
+			continue
+		}
+		for _, edge := range document.compositions {
+			if edge.kind == schemaReferenceRedefine {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redefinedComponent finds the current holder of name in the schema rooted
+// at target: the top-level component itself or an earlier redefinition or
+// override of it inside that schema's composition closure.
+func (c *compiler) redefinedComponent(target int, child *rawNode, name string) (*rawNode, error) {
+	seen := map[int]bool{target: true}
+	queue := []int{target}
+	for len(queue) != 0 {
+		document := c.schemas.documents[queue[0]]
+		queue = queue[1:]
+		for node := range redefinableComponents(document.doc.root) {
+			if !sameRedefineSymbolSpace(node.Name.Local, child.Name.Local) {
+				continue
+			}
+			if declared, _ := node.attr(vocab.XSDAttrName); declared != name {
+				continue
+			}
+			if _, renamed := c.redefinedNames[node]; renamed || c.overridden[node] {
+				continue
+			}
+			if node.Name.Local != child.Name.Local {
+				return nil, schemaCompileAt(child, xsderrors.CodeSchemaReference, "replacement of "+name+" must be a "+node.Name.Local)
+			}
+			return node, nil
+		}
+		for _, edge := range document.compositions {
+			if edge.target >= 0 && !seen[edge.target] {
+				seen[edge.target] = true
+				queue = append(queue, edge.target)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// redefinableComponents yields top-level schema children and the components
+// nested directly inside xs:redefine and xs:override.
+func redefinableComponents(root *rawNode) func(func(*rawNode) bool) {
+	return func(yield func(*rawNode) bool) {
+		for child := range root.xsdChildren() {
+			if child.Name.Local != redefineChild && child.Name.Local != overrideChild {
+				if !yield(child) {
+					return
+				}
+				continue
+			}
+			for nested := range child.xsdChildren() {
+				if !yield(nested) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func sameRedefineSymbolSpace(a, b string) bool {
+	isType := func(local string) bool { return local == simpleTypeChild || local == complexTypeChild }
+	if isType(a) || isType(b) {
+		return isType(a) && isType(b)
+	}
+	return a == b && (a == groupChild || a == attributeGroup || a == elementChild || a == attributeChild || a == notationChild)
+}
+
+// redefineSelfReferences returns the nodes whose base or ref names the
+// redefined component, enforcing the per-kind self-reference rules.
+func redefineSelfReferences(n *rawNode, ctx *schemaContext, name string) ([]*rawNode, error) {
+	switch n.Name.Local {
+	case simpleTypeChild:
+		derivation := simpleTypeDerivationChild(n)
+		if derivation == nil || derivation.Name.Local != restrictionChild || !redefineSelfReference(derivation, ctx, vocab.XSDAttrBase, name) {
+			return nil, schemaCompileAt(n, xsderrors.CodeSchemaReference, "redefined simpleType "+name+" must restrict itself")
+		}
+		return []*rawNode{derivation}, nil
+	case complexTypeChild:
+		for content := range n.xsdChildren() {
+			if content.Name.Local != simpleContent && content.Name.Local != complexContent {
+				continue
+			}
+			for derivation := range content.xsdChildren() {
+				if (derivation.Name.Local == restrictionChild || derivation.Name.Local == extensionChild) &&
+					redefineSelfReference(derivation, ctx, vocab.XSDAttrBase, name) {
+					return []*rawNode{derivation}, nil
+				}
+			}
+		}
+		return nil, schemaCompileAt(n, xsderrors.CodeSchemaReference, "redefined complexType "+name+" must derive from itself")
+	case groupChild:
+		var refs []*rawNode
+		collectRedefineGroupSelfReferences(n, ctx, name, &refs)
+		if len(refs) > 1 {
+			return nil, schemaCompileAt(refs[1], xsderrors.CodeSchemaReference, "redefined group "+name+" can reference itself only once")
+		}
+		if len(refs) == 1 {
+			for _, attr := range []string{vocab.XSDAttrMinOccurs, vocab.XSDAttrMaxOccurs} {
+				if value, ok := refs[0].attr(attr); ok && value != "1" {
+					return nil, schemaCompileAt(refs[0], xsderrors.CodeSchemaOccurrence, "redefined group "+name+" self-reference must occur exactly once")
+				}
+			}
+		}
+		return refs, nil
+	case attributeGroup:
+		var refs []*rawNode
+		for child := range n.xsdChildren() {
+			if child.Name.Local == attributeGroup && redefineSelfReference(child, ctx, vocab.XSDAttrRef, name) {
+				refs = append(refs, child)
+			}
+		}
+		if len(refs) > 1 {
+			return nil, schemaCompileAt(refs[1], xsderrors.CodeSchemaReference, "redefined attributeGroup "+name+" can reference itself only once")
+		}
+		return refs, nil
+	default:
+		return nil, schemaCompileAt(n, xsderrors.CodeSchemaContentModel, "redefine cannot contain "+n.Name.Local)
+	}
+}
+
+func collectRedefineGroupSelfReferences(n *rawNode, ctx *schemaContext, name string, refs *[]*rawNode) {
+	for child := range n.xsdChildren() {
+		if child.Name.Local == groupChild && redefineSelfReference(child, ctx, vocab.XSDAttrRef, name) {
+			*refs = append(*refs, child)
+		}
+		collectRedefineGroupSelfReferences(child, ctx, name, refs)
+	}
+}
+
+// redefineSelfReference reports whether attr on n names the component being
+// redefined. Unresolvable names are left for the component compiler to report.
+func redefineSelfReference(n *rawNode, ctx *schemaContext, attr, name string) bool {
+	lexical, ok := n.attr(attr)
+	if !ok {
+		return false
+	}
+	ns, local, err := n.resolveQName(lexical)
+	if err != nil {
+		return false
+	}
+	if ns == "" && ctx.adoptedTarget {
+		ns = ctx.targetNS
+	}
+	return ns == ctx.targetNS && local == name
+}
+
+// validateRedefinitionRestrictions checks group and attributeGroup
+// redefinitions without a self-reference, which must restrict the original.
+func (c *compiler) validateRedefinitionRestrictions() error {
+	for _, redefined := range c.redefinitions {
+		if redefined.selfRef {
+			continue
+		}
+		var err error
+		switch redefined.node.Name.Local {
+		case groupChild:
+			err = c.validateRedefinedGroupRestriction(redefined)
+		case attributeGroup:
+			err = c.validateRedefinedAttributeGroupRestriction(redefined)
+		}
+		if err != nil {
+			label := redefined.node.Name.Local + " " + c.rt.formatName(redefined.name)
+			return schemaCompileAt(redefined.node, xsderrors.CodeSchemaContentModel, "redefined "+label+" is not a valid restriction: "+err.Error())
+		}
+	}
+	return nil
+}
+
+func (c *compiler) validateRedefinedGroupRestriction(redefined redefinition) error {
+	base, err := c.redefinedGroupModel(redefined.original)
+	if err != nil {
+		return err
+	}
+	derived, err := c.redefinedGroupModel(redefined.name)
+	if err != nil {
+		return err
+	}
+	return runtime.ValidateContentRestriction(&c.rt, base, derived)
+}
+
+func (c *compiler) redefinedGroupModel(q runtime.QName) (runtime.ContentModelID, error) {
+	raw, ok := c.groupRaw[q]
+	if !ok {
+		return runtime.NoContentModel, xsderrors.InternalInvariant("redefined group is not indexed")
+	}
+	modelNode, err := checkTopLevelGroupChildren(raw.node)
+	if err != nil {
+		return runtime.NoContentModel, err
+	}
+	return c.compileModel(modelNode, raw.ctx)
+}
+
+func (c *compiler) validateRedefinedAttributeGroupRestriction(redefined redefinition) error {
+	baseUses, baseWildcard, err := c.compileAttributeGroupByQName(redefined.original)
+	if err != nil {
+		return err
+	}
+	derivedUses, derivedWildcard, err := c.compileAttributeGroupByQName(redefined.name)
+	if err != nil {
+		return err
+	}
+	baseState := runtime.NoAttributeWildcardState()
+	baseState.Wildcard = baseWildcard
+	if err := runtime.ValidateAttributeUseSetRestriction(
+		&c.rt,
+		runtime.NewAttributeUseRestrictionValidationsForUses(baseUses),
+		runtime.NewAttributeUseRestrictionValidationsForUses(derivedUses),
+		baseState,
+		runtime.NoAttributeWildcardState(),
+		false,
+	); err != nil {
+		return err
+	}
+	if derivedWildcard == runtime.NoWildcard {
+		return nil
+	}
+	derived, _ := c.rt.Wildcard(derivedWildcard)
+	base, ok := c.rt.Wildcard(baseWildcard)
+	if !ok || !runtime.WildcardSubset(derived, base) {
+		return fmt.Errorf("attribute wildcard is not a subset of the original wildcard")
+	}
+	return nil
+}
@@ -1,30 +1,30 @@
 package compile
 
 import (
-	"regexp"
+	"errors"
+	"strconv"
 
+	"github.com/jacoelho/xsd/internal/regex"
 	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/xsderrors"
 )
 
 // CompilePatternFacet validates and compiles one XSD pattern facet into the
 // runtime matcher representation.
-func CompilePatternFacet(source string, categories RegexCategoryCache) (runtime.StringPattern, error) {
-	goUnsupported, err := CheckXSDRegexSyntax(source, categories)
-	if err != nil {
+func CompilePatternFacet(source string) (runtime.StringPattern, error) {
+	if err := ValidateXSDRegexSyntax(source); err != nil {
 		return runtime.StringPattern{}, err
 	}
 	if fast := runtime.CompileSimpleStringPattern(source); fast != nil {
 		return runtime.NewFastStringPattern(fast), nil
 	}
-	if goUnsupported {
-		return runtime.StringPattern{}, xsderrors.Unsupported(xsderrors.CodeUnsupportedRegex, "XSD regex is not representable by Go regexp: "+source)
+	re, err := regex.Compile(source)
+	if errors.Is(err, regex.ErrTooLarge) {
+		return runtime.StringPattern{}, xsderrors.SchemaCompile(xsderrors.CodeSchemaLimit,
+			"regex exceeds "+strconv.Itoa(regex.MaxProgramSize)+" compiled instructions: "+source)
 	}
-	goPattern := TranslateXSDRegexToGo(source)
-	goSource := "^(?:" + goPattern + ")$"
-	re, err := regexp.Compile(goSource)
 	if err != nil {
-		return runtime.StringPattern{}, xsderrors.Unsupported(xsderrors.CodeUnsupportedRegex, "invalid or unsupported regex "+source)
+		return runtime.StringPattern{}, xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex "+source+": "+err.Error())
 	}
-	return runtime.NewRegexpStringPattern(re), nil
+	return runtime.NewRegexStringPattern(re), nil
 }
@@ -2,42 +2,74 @@ package compile
 
 import (
 	"regexp"
+	"strings"
 	"testing"
 	"unicode/utf8"
+
+	"github.com/jacoelho/xsd/internal/regex"
 )
 
+// FuzzXSDRegexSyntax checks that every expression accepted by the syntax
+// validator compiles natively, and that the native matcher agrees with Go
+// regexp on the constructs both engines share.
 func FuzzXSDRegexSyntax(f *testing.F) {
-	for _, seed := range []string{
-		`[A-Z]{2}\d{4}`,
-		`\p{Lu}+`,
-		`a|b`,
-		`[abc-]`,
-		`([a-z]+)?`,
-		`\p{}0`,
-		`\p{Is}`,
-		`\C0`,
-		`0{0002}`,
-		`0{1001}`,
-		`0{1001,}`,
-		`0{0,1001}`,
-		`0{1001,1000}`,
-		`0{0001001}`,
+	for _, seed := range []struct{ source, input string }{
+		{`[A-Z]{2}\d{4}`, "AB1234"},
+		{`\p{Lu}+`, "ABC"},
+		{`a|b`, "b"},
+		{`[abc-]`, "-"},
+		{`([a-z]+)?`, ""},
+		{`\p{}0`, "0"},
+		{`\p{Is}`, "a"},
+		{`\C0`, " 0"},
+		{`0{0002}`, "00"},
+		{`0{1001}`, "0"},
+		{`0{1001,}`, "0"},
+		{`0{0,1001}`, "0"},
+		{`0{1001,1000}`, "0"},
+		{`0{0001001}`, "0"},
+		{`[^\s\d]+.`, "ab\n"},
+		{`(a|bc)*[x-z]?`, "abcbcz"},
 	} {
-		f.Add(seed)
+		f.Add(seed.source, seed.input)
 	}
-	f.Fuzz(func(t *testing.T, source string) {
-		if len(source) > 256 {
+	f.Fuzz(func(t *testing.T, source, input string) {
+		if len(source) > 256 || len(input) > 256 {
 			t.Skip()
 		}
-		if !utf8.ValidString(source) {
+		if !utf8.ValidString(source) || !utf8.ValidString(input) {
+			return
+		}
+		if err := ValidateXSDRegexSyntax(source); err != nil {
+			return
+		}
+		native, err := regex.Compile(source)
+		if err != nil {
+			if err == regex.ErrTooLarge { //nolint:errorlint // Compile returns the sentinel unwrapped.
+				return
+			}
+			t.Fatalf("validated regex does not compile natively: %q: %v", source, err)
+		}
+		if !sharedWithGoRegexp(source) {
 			return
 		}
-		if err := ValidateXSDRegexSyntax(source, nil); err != nil {
+		goRE, err := regexp.Compile("^(?:" + TranslateXSDRegexToGo(source) + ")$")
+		if err != nil {
 			return
 		}
-		goSource := "^(?:" + TranslateXSDRegexToGo(source) + ")$"
-		if _, err := regexp.Compile(goSource); err != nil {
-			t.Fatalf("validated regex does not compile: %q -> %q: %v", source, goSource, err)
+		if got, want := native.MatchString(input), goRE.MatchString(input); got != want {
+			t.Fatalf("native match of %q against %q = %v, Go regexp = %v", source, input, got, want)
 		}
 	})
 }
+
+// sharedWithGoRegexp reports whether source avoids the XSD constructs that
+// TranslateXSDRegexToGo cannot express or whose Go tables vary by release.
+func sharedWithGoRegexp(source string) bool {
+	for _, construct := range []string{`-[`, `\i`, `\I`, `\c`, `\C`, `\p{Is`, `\P{Is`, `\p{C`, `\P{C`, `\w`, `\W`} {
+		if strings.Contains(source, construct) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,48 +1,25 @@
 package compile
 
 import (
-	"regexp"
 	"strings"
 
+	"github.com/jacoelho/xsd/internal/regex"
 	"github.com/jacoelho/xsd/xsderrors"
 )
 
-const maxGoRegexpRepeat = "1000"
-
-// RegexCategoryCache caches Go regexp category support checks during schema
-// compilation.
-type RegexCategoryCache map[string]bool
-
-// ValidateXSDRegexSyntax validates XSD regex syntax and reports regexes that
-// are valid XSD but not representable by Go regexp.
-func ValidateXSDRegexSyntax(source string, categories RegexCategoryCache) error {
-	goUnsupported, err := CheckXSDRegexSyntax(source, categories)
-	if err != nil {
-		return err
-	}
-	if goUnsupported {
-		return xsderrors.Unsupported(xsderrors.CodeUnsupportedRegex, "XSD regex is not representable by Go regexp: "+source)
-	}
-	return nil
-}
-
-// CheckXSDRegexSyntax validates XSD regex syntax and reports whether the regex
-// needs a matcher other than Go regexp.
-func CheckXSDRegexSyntax(source string, categories RegexCategoryCache) (bool, error) {
-	v := xsdRegexSyntaxValidator{categories: categories}
+// ValidateXSDRegexSyntax validates XSD regex syntax and reports violations as
+// schema facet diagnostics.
+func ValidateXSDRegexSyntax(source string) error {
+	var v xsdRegexSyntaxValidator
 	for _, r := range source {
 		if err := v.consume(r); err != nil {
-			return false, err
+			return err
 		}
 	}
-	if err := v.finish(); err != nil {
-		return false, err
-	}
-	return v.unsupported, nil
+	return v.finish()
 }
 
 type xsdRegexSyntaxValidator struct {
-	categories         RegexCategoryCache
 	categoryName       string
 	classStack         []regexClassState
 	quantifierMin      []byte
@@ -63,16 +40,12 @@ type xsdRegexSyntaxValidator struct {
 	inCategory         bool
 	canQuantify        bool
 	prevQuantifier     bool
-	unsupported        bool
 }
 
 type regexClassState struct {
-	termCount    int
-	hasTerm      bool
-	first        bool
-	unsafeEscape bool
-	lastTermSet  bool
-	negated      bool
+	hasTerm     bool
+	first       bool
+	lastTermSet bool
 }
 
 func (v *xsdRegexSyntaxValidator) consume(r rune) error {
@@ -104,11 +77,10 @@ func (v *xsdRegexSyntaxValidator) consumeCategory(r rune) error {
 	case v.categoryName == "":
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex category escape")
 	case strings.HasPrefix(v.categoryName, "Is"):
-		if len(v.categoryName) == len("Is") {
-			return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex category escape")
+		if !regex.IsBlock(v.categoryName[len("Is"):]) {
+			return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex block escape")
 		}
-		v.unsupported = true
-	case !v.validGoRegexCategory(v.categoryName):
+	case !regex.IsCategory(v.categoryName):
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex category escape")
 	}
 	v.inCategory = false
@@ -130,28 +102,6 @@ func (v *xsdRegexSyntaxValidator) consumePendingCategory(r rune) error {
 	return nil
 }
 
-func validGoRegexCategory(name string) bool {
-	_, err := regexp.Compile(`\p{` + name + `}`)
-	return err == nil
-}
-
-func validGoRegexCategoryCached(categories RegexCategoryCache, name string) bool {
-	if categories == nil {
-		return validGoRegexCategory(name)
-	}
-	ok, found := categories[name]
-	if found {
-		return ok
-	}
-	ok = validGoRegexCategory(name)
-	categories[name] = ok
-	return ok
-}
-
-func (v *xsdRegexSyntaxValidator) validGoRegexCategory(name string) bool {
-	return validGoRegexCategoryCached(v.categories, name)
-}
-
 func (v *xsdRegexSyntaxValidator) consumeQuantifier(r rune) error {
 	switch {
 	case r >= '0' && r <= '9':
@@ -185,20 +135,12 @@ func (v *xsdRegexSyntaxValidator) finishQuantifier() error {
 	if v.quantifierSawComma && len(v.quantifierMax) != 0 && compareRegexQuantity(v.quantifierMax, v.quantifierMin) < 0 {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex quantifier")
 	}
-	if regexQuantityExceedsGoLimit(v.quantifierMin) ||
-		v.quantifierSawComma && len(v.quantifierMax) != 0 && regexQuantityExceedsGoLimit(v.quantifierMax) {
-		v.unsupported = true
-	}
 	v.inQuantifier = false
 	v.canQuantify = false
 	v.prevQuantifier = true
 	return nil
 }
 
-func regexQuantityExceedsGoLimit(s []byte) bool {
-	return compareRegexQuantity(s, []byte(maxGoRegexpRepeat)) > 0
-}
-
 func compareRegexQuantity(a, b []byte) int {
 	a = trimRegexQuantityBytes(a)
 	b = trimRegexQuantityBytes(b)
@@ -233,9 +175,6 @@ func (v *xsdRegexSyntaxValidator) consumeEscaped(r rune) error {
 	if err := v.checkEscapedClassRange(r); err != nil {
 		return err
 	}
-	if r == 'i' || r == 'I' || r == 'c' || r == 'C' {
-		v.unsupported = true
-	}
 	if r == 'p' || r == 'P' {
 		if v.insideClass() && v.classPendingRange {
 			return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid regex character range")
@@ -245,10 +184,6 @@ func (v *xsdRegexSyntaxValidator) consumeEscaped(r rune) error {
 		return nil
 	}
 	if v.insideClass() {
-		class := v.currentClass()
-		if isUnsafeXSDRegexClassEscape(r) {
-			class.unsafeEscape = true
-		}
 		if isXSDRegexMultiCharEscape(r) {
 			if err := v.acceptClassSet(); err != nil {
 				return err
@@ -291,7 +226,6 @@ func (v *xsdRegexSyntaxValidator) consumeClass(r rune) error {
 	class := v.currentClass()
 	if class.first && r == '^' {
 		class.first = false
-		class.negated = true
 		v.classLastHyphen = false
 		return nil
 	}
@@ -305,7 +239,6 @@ func (v *xsdRegexSyntaxValidator) openNestedClass() error {
 	if !v.classLastHyphen {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "invalid nested regex character class")
 	}
-	v.unsupported = true
 	v.classPendingRange = false
 	v.classStack = append(v.classStack, regexClassState{first: true})
 	v.classLastHyphen = false
@@ -321,12 +254,6 @@ func (v *xsdRegexSyntaxValidator) closeClass() error {
 	if !class.hasTerm {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaFacet, "empty regex character class")
 	}
-	if v.classPendingRange {
-		class.termCount++
-	}
-	if class.unsafeEscape && (class.negated || class.termCount != 1) {
-		v.unsupported = true
-	}
 	v.classStack = v.classStack[:last]
 	if len(v.classStack) == 0 {
 		v.canQuantify = true
@@ -335,7 +262,6 @@ func (v *xsdRegexSyntaxValidator) closeClass() error {
 		parent := v.currentClass()
 		parent.hasTerm = true
 		parent.first = false
-		parent.termCount++
 		parent.lastTermSet = true
 	}
 	v.classLastHyphen = false
@@ -372,7 +298,6 @@ func (v *xsdRegexSyntaxValidator) consumeClassHyphen() error {
 	} else {
 		class.hasTerm = true
 		class.first = false
-		class.termCount++
 		v.classLastHyphen = false
 		v.classPendingRange = false
 		v.classLastRune = '-'
@@ -396,7 +321,6 @@ func (v *xsdRegexSyntaxValidator) acceptClassRuneAt(r rune) error {
 	class := v.currentClass()
 	class.hasTerm = true
 	class.first = false
-	class.termCount++
 	v.classLastHyphen = false
 	v.classHyphenAfter = false
 	v.classLastRune = r
@@ -412,7 +336,6 @@ func (v *xsdRegexSyntaxValidator) acceptClassSet() error {
 	class := v.currentClass()
 	class.hasTerm = true
 	class.first = false
-	class.termCount++
 	v.classLastHyphen = false
 	v.classHyphenAfter = false
 	v.classHasLastRune = false
@@ -534,12 +457,3 @@ func isXSDRegexMultiCharEscape(r rune) bool {
 		return false
 	}
 }
-
-func isUnsafeXSDRegexClassEscape(r rune) bool {
-	switch r {
-	case 'D', 'S', 'w', 'W':
-		return true
-	default:
-		return false
-	}
-}
@@ -37,32 +37,23 @@ func TestTranslateXSDRegexToGoDotAndLiteralAnchors(t *testing.T) {
 	}
 }
 
-func TestValidateXSDRegexSyntaxReportsSchemaAndUnsupportedErrors(t *testing.T) {
-	err := ValidateXSDRegexSyntax(`a{,2}`, nil)
-	var diag *xsderrors.Error
-	if !errors.As(err, &diag) || diag.Code != xsderrors.CodeSchemaFacet {
-		t.Fatalf("ValidateXSDRegexSyntax invalid quantifier error = %v, want %s", err, xsderrors.CodeSchemaFacet)
-	}
-
-	err = ValidateXSDRegexSyntax(`\p{IsBasicLatin}`, nil)
-	if !xsderrors.IsUnsupported(err) {
-		t.Fatalf("ValidateXSDRegexSyntax(IsBasicLatin) error = %v, want unsupported", err)
-	}
-}
-
-func TestCheckXSDRegexSyntaxCachesGoCategorySupport(t *testing.T) {
-	cache := RegexCategoryCache{}
-	unsupported, err := CheckXSDRegexSyntax(`\p{Lu}+`, cache)
-	if err != nil || unsupported {
-		t.Fatalf("CheckXSDRegexSyntax() = (%v, %v), want supported nil", unsupported, err)
+func TestValidateXSDRegexSyntaxReportsSchemaErrors(t *testing.T) {
+	for _, source := range []string{`a{,2}`, `\p{IsFoo}`, `\p{Greek}`, `[a-\d]`} {
+		err := ValidateXSDRegexSyntax(source)
+		var diag *xsderrors.Error
+		if !errors.As(err, &diag) || diag.Code != xsderrors.CodeSchemaFacet {
+			t.Fatalf("ValidateXSDRegexSyntax(%q) error = %v, want %s", source, err, xsderrors.CodeSchemaFacet)
+		}
 	}
-	if got, ok := cache["Lu"]; !ok || !got {
-		t.Fatalf("category cache[Lu] = (%v, %v), want (true, true)", got, ok)
+	for _, source := range []string{`\p{IsBasicLatin}`, `\i\c*`, `[a-z-[aeiou]]`, `0{1001}`, `\p{Cn}`} {
+		if err := ValidateXSDRegexSyntax(source); err != nil {
+			t.Fatalf("ValidateXSDRegexSyntax(%q) error = %v", source, err)
+		}
 	}
 }
 
-func TestCompilePatternFacetUsesFastMatcherBeforeGoUnsupportedRepeatLimit(t *testing.T) {
-	pattern, err := CompilePatternFacet(`0{1001}`, nil)
+func TestCompilePatternFacetUsesFastMatcherForLargeExactRepeat(t *testing.T) {
+	pattern, err := CompilePatternFacet(`0{1001}`)
 	if err != nil {
 		t.Fatalf("CompilePatternFacet() error = %v", err)
 	}
@@ -74,15 +65,41 @@ func TestCompilePatternFacetUsesFastMatcherBeforeGoUnsupportedRepeatLimit(t *tes
 	}
 }
 
-func TestCompilePatternFacetRejectsSubtractionAsUnsupported(t *testing.T) {
-	_, err := CompilePatternFacet(`[a-z-[aeiou]]`, nil)
-	if !xsderrors.IsUnsupported(err) {
-		t.Fatalf("CompilePatternFacet(subtraction) error = %v, want unsupported", err)
+func TestCompilePatternFacetSupportsFullXSDGrammar(t *testing.T) {
+	tests := []struct {
+		source string
+		match  string
+		reject string
+	}{
+		{source: `[a-z-[aeiou]]+`, match: "xyz", reject: "bad"},
+		{source: `\i\c*`, match: "_a-1", reject: "1a"},
+		{source: `\p{IsBasicLatin}+`, match: "abc", reject: "\u00e9"},
+		{source: `(ab){1001,1002}`, match: strings.Repeat("ab", 1001), reject: strings.Repeat("ab", 1000)},
+	}
+	for _, tt := range tests {
+		pattern, err := CompilePatternFacet(tt.source)
+		if err != nil {
+			t.Fatalf("CompilePatternFacet(%q) error = %v", tt.source, err)
+		}
+		if !pattern.MatchString(tt.match) {
+			t.Fatalf("CompilePatternFacet(%q) does not match %q", tt.source, tt.match)
+		}
+		if pattern.MatchString(tt.reject) {
+			t.Fatalf("CompilePatternFacet(%q) matches %q", tt.source, tt.reject)
+		}
+	}
+}
+
+func TestCompilePatternFacetReportsProgramLimit(t *testing.T) {
+	_, err := CompilePatternFacet(`(a|b){70000}`)
+	var diag *xsderrors.Error
+	if !errors.As(err, &diag) || diag.Code != xsderrors.CodeSchemaLimit {
+		t.Fatalf("CompilePatternFacet() error = %v, want %s", err, xsderrors.CodeSchemaLimit)
 	}
 }
 
 func TestCompilePatternFacetFastDigitClassMatchesXSDDigits(t *testing.T) {
-	pattern, err := CompilePatternFacet(`[A-Z]{2}\d{4}`, nil)
+	pattern, err := CompilePatternFacet(`[A-Z]{2}\d{4}`)
 	if err != nil {
 		t.Fatalf("CompilePatternFacet() error = %v", err)
 	}
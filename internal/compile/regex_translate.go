@@ -2,8 +2,10 @@ package compile
 
 import "strings"
 
-// TranslateXSDRegexToGo translates XSD regex syntax to the Go regexp subset
-// used by compiled pattern facets. Callers must validate syntax first.
+// TranslateXSDRegexToGo translates XSD regex syntax to the Go regexp subset.
+// Constructs without a Go equivalent, such as class subtraction, \i, \c, and
+// block escapes, are passed through unchanged. Callers must validate syntax
+// first.
 func TranslateXSDRegexToGo(source string) string {
 	var b strings.Builder
 	escaped := false
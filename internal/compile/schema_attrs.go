@@ -1,6 +1,7 @@
 package compile
 
 import (
+	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/internal/vocab"
 	"github.com/jacoelho/xsd/xsderrors"
 )
@@ -42,7 +43,7 @@ func ValidateGroupUseSource(hasRef bool) error {
 
 func schemaElementAttributeAllowed(element, attr string) bool {
 	switch element {
-	case vocab.XSDElemSchema, vocab.XSDElemInclude, vocab.XSDElemImport, vocab.XSDElemAppinfo, vocab.XSDElemDocumentation:
+	case vocab.XSDElemSchema, vocab.XSDElemInclude, vocab.XSDElemImport, vocab.XSDElemRedefine, vocab.XSDElemOverride, vocab.XSDElemAppinfo, vocab.XSDElemDocumentation:
 		return schemaDocumentAttributeAllowed(element, attr)
 	case vocab.XSDElemSimpleType, vocab.XSDElemRestriction, vocab.XSDElemExtension, vocab.XSDElemList, vocab.XSDElemUnion:
 		return simpleDerivationAttributeAllowed(element, attr)
@@ -68,8 +69,16 @@ func schemaElementAttributeAllowed(element, attr string) bool {
 		return isIdentityXPathAttribute(attr)
 	case vocab.XSDElemNotation:
 		return isNotationAttribute(attr)
+	case vocab.XSDElemAssert, vocab.XSDFacetAssertion:
+		return attr == vocab.XSDAttrID || attr == vocab.XSDAttrTest || attr == vocab.XSDAttrXPathDefaultNS
+	case vocab.XSDElemAlternative:
+		return attr == vocab.XSDAttrID || attr == vocab.XSDAttrTest || attr == vocab.XSDAttrType || attr == vocab.XSDAttrXPathDefaultNS
+	case vocab.XSDElemOpenContent:
+		return attr == vocab.XSDAttrID || attr == vocab.XSDAttrMode
+	case vocab.XSDElemDefaultOpenContent:
+		return attr == vocab.XSDAttrID || attr == vocab.XSDAttrMode || attr == vocab.XSDAttrAppliesToEmpty
 	default:
-		if _, ok := facetMaskForLocal(element); ok {
+		if _, ok := runtime.FacetMaskForLocal(element); ok {
 			return facetAttributeAllowed(element, attr)
 		}
 		return true
@@ -80,7 +89,7 @@ func schemaAnyURIAttribute(element, attr string) bool {
 	switch element {
 	case vocab.XSDElemSchema:
 		return attr == vocab.XSDAttrTargetNamespace
-	case vocab.XSDElemInclude:
+	case vocab.XSDElemInclude, vocab.XSDElemRedefine, vocab.XSDElemOverride:
 		return attr == vocab.XSDAttrSchemaLocation
 	case vocab.XSDElemImport:
 		return attr == vocab.XSDAttrNamespace || attr == vocab.XSDAttrSchemaLocation
@@ -97,10 +106,11 @@ func schemaDocumentAttributeAllowed(element, attr string) bool {
 	switch element {
 	case vocab.XSDElemSchema:
 		switch attr {
-		case vocab.XSDAttrID, vocab.XSDAttrTargetNamespace, vocab.XSDAttrVersion, vocab.XSDAttrFinalDefault, vocab.XSDAttrBlockDefault, vocab.XSDAttrAttributeFormDefault, vocab.XSDAttrElementFormDefault:
+		case vocab.XSDAttrID, vocab.XSDAttrTargetNamespace, vocab.XSDAttrVersion, vocab.XSDAttrFinalDefault, vocab.XSDAttrBlockDefault, vocab.XSDAttrAttributeFormDefault, vocab.XSDAttrElementFormDefault,
+			vocab.XSDAttrXPathDefaultNS:
 			return true
 		}
-	case vocab.XSDElemInclude:
+	case vocab.XSDElemInclude, vocab.XSDElemRedefine, vocab.XSDElemOverride:
 		return attr == vocab.XSDAttrID || attr == vocab.XSDAttrSchemaLocation
 	case vocab.XSDElemImport:
 		return attr == vocab.XSDAttrID || attr == vocab.XSDAttrNamespace || attr == vocab.XSDAttrSchemaLocation
@@ -193,7 +203,7 @@ func isElementRefAttribute(name string) bool {
 
 func isAnyParticleAttribute(name string) bool {
 	switch name {
-	case vocab.XSDAttrID, vocab.XSDAttrNamespace, vocab.XSDAttrProcessContents, vocab.XSDAttrMinOccurs, vocab.XSDAttrMaxOccurs:
+	case vocab.XSDAttrID, vocab.XSDAttrNamespace, vocab.XSDAttrNotNamespace, vocab.XSDAttrNotQName, vocab.XSDAttrProcessContents, vocab.XSDAttrMinOccurs, vocab.XSDAttrMaxOccurs:
 		return true
 	default:
 		return false
@@ -202,7 +212,7 @@ func isAnyParticleAttribute(name string) bool {
 
 func isAnyAttributeAttribute(name string) bool {
 	switch name {
-	case vocab.XSDAttrID, vocab.XSDAttrNamespace, vocab.XSDAttrProcessContents:
+	case vocab.XSDAttrID, vocab.XSDAttrNamespace, vocab.XSDAttrNotNamespace, vocab.XSDAttrNotQName, vocab.XSDAttrProcessContents:
 		return true
 	default:
 		return false
@@ -18,6 +18,7 @@ func newCompilerSchemaBuild(names runtime.NameTable) compilerSchemaBuild {
 		GlobalAttributes: make(map[runtime.QName]runtime.AttributeID, runtime.BuiltinAttributeCount()),
 		GlobalTypes:      make(map[runtime.QName]runtime.TypeID, runtime.BuiltinGlobalTypeCount()),
 		GlobalIdentities: make(map[runtime.QName]runtime.IdentityConstraintID),
+		AttributeGroups:  make(map[runtime.QName]runtime.AttributeUseSetID),
 		Notations:        make(map[runtime.QName]bool),
 		SimpleTypes:      make([]runtime.SimpleType, 0, runtime.BuiltinSimpleTypeCount()),
 		Attributes:       make([]runtime.AttributeDecl, 0, runtime.BuiltinAttributeCount()),
@@ -68,6 +69,19 @@ func (rt *compilerSchemaBuild) ElementType(id runtime.ElementID) (runtime.TypeID
 	return rt.build.ElementType(id)
 }
 
+func (rt *compilerSchemaBuild) forEachTypeAlternative(id runtime.ElementID, fn func(index int, alt, declared runtime.TypeID) bool) bool {
+	decl, ok := runtime.ElementDeclByID(rt.build.Elements, id)
+	if !ok {
+		return false
+	}
+	for i, alt := range decl.Alternatives {
+		if !fn(i, alt.Type, decl.Type) {
+			break
+		}
+	}
+	return true
+}
+
 func (rt *compilerSchemaBuild) ElementRestriction(id runtime.ElementID) (runtime.ParticleRestrictionElement, bool) {
 	return rt.build.ElementRestriction(id)
 }
@@ -227,6 +241,7 @@ func cloneValueConstraint(vc *runtime.ValueConstraint) *runtime.ValueConstraint
 func (rt *compilerSchemaBuild) elementCopy(id runtime.ElementID) runtime.ElementDecl {
 	decl := rt.build.Elements[id]
 	decl.Identity = slices.Clone(decl.Identity)
+	decl.Alternatives = slices.Clone(decl.Alternatives)
 	decl.Default = cloneValueConstraint(decl.Default)
 	decl.Fixed = cloneValueConstraint(decl.Fixed)
 	return decl
@@ -393,6 +408,40 @@ func (c *compiler) registerGlobalIdentity(q runtime.QName, identity runtime.Iden
 	return id, nil
 }
 
+func (c *compiler) registerAttributeGroup(q runtime.QName, id runtime.AttributeUseSetID) {
+	c.attrGroupDone[q] = id
+	c.rt.build.AttributeGroups[q] = id
+}
+
+func (c *compiler) recordAnnotation(key runtime.AnnotationKey, a runtime.Annotation) {
+	if a.Empty() {
+		return
+	}
+	if c.rt.build.Annotations == nil {
+		c.rt.build.Annotations = make(map[runtime.AnnotationKey]runtime.Annotation)
+	}
+	existing := c.rt.build.Annotations[key]
+	existing.Append(a)
+	c.rt.build.Annotations[key] = existing
+}
+
+func (c *compiler) setSourceLocation(key runtime.LocationKey, loc runtime.SourceLocation) {
+	if c.rt.build.Locations == nil {
+		c.rt.build.Locations = make(map[runtime.LocationKey]runtime.SourceLocation)
+	}
+	c.rt.build.Locations[key] = loc
+}
+
+func (c *compiler) sourceLocation(key runtime.LocationKey) (runtime.SourceLocation, bool) {
+	loc, ok := c.rt.build.Locations[key]
+	return loc, ok
+}
+
+func (c *compiler) attributeUseAnnotation(set runtime.AttributeUseSetID, name runtime.QName) (runtime.Annotation, bool) {
+	a, ok := c.rt.build.Annotations[runtime.AnnotationKey{Kind: runtime.AnnotatedAttributeUse, ID: uint32(set), Name: name}]
+	return a, ok
+}
+
 func (c *compiler) addElement(decl runtime.ElementDecl) (runtime.ElementID, error) {
 	id, err := NextElementID(len(c.rt.build.Elements))
 	if err != nil {
@@ -2965,13 +2965,97 @@ func TestRecursiveComplexTypeThroughElementRefCompiles(t *testing.T) {
 }
 
 func TestUnsupportedFeaturesAreExplicit(t *testing.T) {
-	_, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:redefine schemaLocation="a.xsd"/></xs:schema>`))})
-	expectCode(t, err, xsderrors.CodeUnsupportedRedefine)
-
-	_, err = compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="r"><xs:complexType><xs:anyAttribute notQName="##defined"/></xs:complexType></xs:element></xs:schema>`))})
+	_, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="r"><xs:complexType><xs:anyAttribute notQName="##defined"/></xs:complexType></xs:element></xs:schema>`))})
 	expectCode(t, err, xsderrors.CodeUnsupportedXSD11)
 }
 
+func TestRedefineRejectsInvalidRedefinitions(t *testing.T) {
+	const original = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:simpleType name="code"><xs:restriction base="xs:string"/></xs:simpleType>
+  <xs:group name="g"><xs:sequence><xs:element name="a"/><xs:element name="b" minOccurs="0"/></xs:sequence></xs:group>
+  <xs:attributeGroup name="ag"><xs:attribute name="a" use="required"/></xs:attributeGroup>
+</xs:schema>`
+	resolver := source.Resolver(func(_ context.Context, _, location string) (source.Source, error) {
+		if location != "original.xsd" {
+			return source.Source{}, xsderrors.ErrSchemaNotFound
+		}
+		return source.Bytes("original.xsd", []byte(original)), nil
+	})
+	tests := []struct {
+		name       string
+		components string
+		code       xsderrors.Code
+		message    string
+	}{
+		{
+			name:       "missing original",
+			components: `<xs:complexType name="other"><xs:complexContent><xs:restriction base="other"/></xs:complexContent></xs:complexType>`,
+			code:       xsderrors.CodeSchemaReference,
+			message:    "not declared in the redefined schema",
+		},
+		{
+			name:       "simple type without self restriction",
+			components: `<xs:simpleType name="code"><xs:restriction base="xs:token"/></xs:simpleType>`,
+			code:       xsderrors.CodeSchemaReference,
+			message:    "must restrict itself",
+		},
+		{
+			name:       "type kind mismatch",
+			components: `<xs:complexType name="code"><xs:simpleContent><xs:extension base="code"/></xs:simpleContent></xs:complexType>`,
+			code:       xsderrors.CodeSchemaReference,
+			message:    "must be a simpleType",
+		},
+		{
+			name:       "group with two self references",
+			components: `<xs:group name="g"><xs:sequence><xs:group ref="g"/><xs:group ref="g"/></xs:sequence></xs:group>`,
+			code:       xsderrors.CodeSchemaReference,
+			message:    "can reference itself only once",
+		},
+		{
+			name:       "group self reference occurrence",
+			components: `<xs:group name="g"><xs:sequence><xs:group ref="g" minOccurs="0"/></xs:sequence></xs:group>`,
+			code:       xsderrors.CodeSchemaOccurrence,
+			message:    "must occur exactly once",
+		},
+		{
+			name:       "group not a restriction",
+			components: `<xs:group name="g"><xs:sequence><xs:element name="c"/></xs:sequence></xs:group>`,
+			code:       xsderrors.CodeSchemaContentModel,
+			message:    "is not a valid restriction",
+		},
+		{
+			name:       "attribute group drops required attribute",
+			components: `<xs:attributeGroup name="ag"><xs:attribute name="b"/></xs:attributeGroup>`,
+			code:       xsderrors.CodeSchemaContentModel,
+			message:    "is not a valid restriction",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:redefine schemaLocation="original.xsd">` + test.components + `</xs:redefine></xs:schema>`
+			_, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(schema)).WithResolver(resolver)})
+			expectCode(t, err, test.code)
+			if !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("Compile() error = %v, want %q", err, test.message)
+			}
+		})
+	}
+
+	restricted := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:redefine schemaLocation="original.xsd">
+  <xs:group name="g"><xs:sequence><xs:element name="a"/></xs:sequence></xs:group>
+  <xs:attributeGroup name="ag"><xs:attribute name="a" use="required" fixed="x"/></xs:attributeGroup>
+</xs:redefine></xs:schema>`
+	if _, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(restricted)).WithResolver(resolver)}); err != nil {
+		t.Fatalf("Compile() restricting redefinition error = %v", err)
+	}
+
+	unresolved := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:redefine schemaLocation="original.xsd">
+  <xs:simpleType name="code"><xs:restriction base="code"/></xs:simpleType>
+</xs:redefine><xs:element name="e" type="Code"/></xs:schema>`
+	_, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(unresolved)).WithResolver(resolver)})
+	expectCode(t, err, xsderrors.CodeSchemaReference)
+}
+
 func TestCompileOptionsSchemaXMLLimits(t *testing.T) {
 	deepSchema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:annotation><xs:documentation>ok</xs:documentation></xs:annotation></xs:schema>`
 	_, err := compile.Compile(context.Background(), compile.Options{MaxSchemaDepth: 2}, []source.Source{source.Bytes("schema.xsd", []byte(deepSchema))})
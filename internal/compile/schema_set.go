@@ -21,7 +21,9 @@ type schemaSet struct {
 type schemaSetDocument struct {
 	doc               *rawDoc
 	imports           map[string]bool
+	sourceKey         string
 	effectiveTargetNS string
+	compositions      []schemaComposition
 	contentIdentity   int
 	adoptedTarget     bool
 	explicitRoot      bool
@@ -70,8 +72,25 @@ type schemaReferenceKind uint8
 const (
 	schemaReferenceInclude schemaReferenceKind = iota
 	schemaReferenceImport
+	schemaReferenceRedefine
+	schemaReferenceOverride
 )
 
+// composes reports whether the referenced document contributes components
+// in the referencing document's target namespace.
+func (k schemaReferenceKind) composes() bool {
+	return k == schemaReferenceInclude || k == schemaReferenceRedefine || k == schemaReferenceOverride
+}
+
+// schemaComposition is one include, redefine, or override edge resolved to the
+// referenced document instance in the referencing document's target
+// namespace context. Target is -1 when the reference did not resolve.
+type schemaComposition struct {
+	node   *rawNode
+	target int
+	kind   schemaReferenceKind
+}
+
 type schemaReference struct {
 	node         *rawNode
 	target       string
@@ -197,6 +216,13 @@ func loadSchemaSetOwned(ctx context.Context, sources []source.Source, limits Lim
 	if err := l.loadOwned(sources); err != nil {
 		return schemaSet{}, err
 	}
+	if l.limits.Documents != nil {
+		for key, loaded := range l.byKey {
+			if _, ok := l.limits.Documents[key]; !ok && loaded.doc != nil {
+				l.limits.Documents[key] = loaded.data
+			}
+		}
+	}
 	slices.SortFunc(l.documents, func(a, b schemaSetDocument) int {
 		return cmp.Compare(a.doc.name, b.doc.name)
 	})
@@ -213,6 +239,7 @@ func loadSchemaSetOwned(ctx context.Context, sources []source.Source, limits Lim
 		return schemaSet{}, err
 	}
 	l.selectDeclarationDocuments()
+	l.linkCompositions()
 	return schemaSet{documents: l.documents}, nil
 }
 
@@ -276,6 +303,7 @@ func (l *schemaSetLoader) loadOwned(ordered []source.Source) error {
 		l.documents = append(l.documents, schemaSetDocument{
 			doc:             identified.source.doc,
 			imports:         schemaDocumentImports(identified.source.doc.references),
+			sourceKey:       identified.source.doc.key,
 			explicitRoot:    loaded.explicitRoot,
 			contentIdentity: identified.identity,
 		})
@@ -306,7 +334,7 @@ func (l *schemaSetLoader) read(item schemaLoadRequest, queue *[]schemaLoadReques
 	}
 	remaining := l.limits.MaxSchemaTotalBytes - l.totalBytes
 	readLimit := min(l.limits.MaxSchemaSourceBytes, remaining)
-	result := src.Acquire(l.ctx, readLimit)
+	result := l.acquire(src, key, readLimit)
 	if err := compileContextErrorWith(l.ctx, result.Err); err != nil {
 		return loadedSchemaSource{}, false, err
 	}
@@ -378,7 +406,7 @@ func (l *schemaSetLoader) readLoaded(
 	}
 	remaining := l.limits.MaxSchemaTotalBytes - l.totalBytes
 	readLimit := min(l.limits.MaxSchemaSourceBytes, remaining)
-	result := src.Acquire(l.ctx, readLimit)
+	result := l.acquire(src, key, readLimit)
 	if err := compileContextErrorWith(l.ctx, result.Err); err != nil {
 		return loadedSchemaSource{}, false, err
 	}
@@ -430,6 +458,15 @@ func (l *schemaSetLoader) readLoaded(
 	return loadedSchemaSource{}, false, nil
 }
 
+// acquire reads src, whose identity is key, unless Limits.Documents holds
+// its bytes.
+func (l *schemaSetLoader) acquire(src source.Source, key string, readLimit int64) source.ReadResult {
+	if data, ok := l.limits.Documents[key]; ok {
+		return source.Bytes(src.Name(), data).Acquire(l.ctx, readLimit)
+	}
+	return src.Acquire(l.ctx, readLimit)
+}
+
 func schemaTotalBytesLimitError(acquireErr error) error {
 	limitErr := xsderrors.SchemaCompile(xsderrors.CodeSchemaLimit, "schema sources exceed MaxSchemaTotalBytes")
 	if acquireErr == nil || !hasNonSchemaLimitCause(acquireErr) {
@@ -577,13 +614,17 @@ func schemaDocumentReferences(doc *rawDoc) ([]schemaReference, error) {
 			kind = schemaReferenceInclude
 		case vocab.XSDElemImport:
 			kind = schemaReferenceImport
+		case vocab.XSDElemRedefine:
+			kind = schemaReferenceRedefine
+		case vocab.XSDElemOverride:
+			kind = schemaReferenceOverride
 		default:
 			continue
 		}
 		locationRaw, hasLocation := schemaLocationAttr(child)
 		var location uriref.Reference
-		if kind == schemaReferenceInclude && !hasLocation {
-			return nil, schemaCompileAt(child, xsderrors.CodeSchemaReference, "include missing schemaLocation")
+		if kind.composes() && !hasLocation {
+			return nil, schemaCompileAt(child, xsderrors.CodeSchemaReference, child.Name.Local+" missing schemaLocation")
 		}
 		if hasLocation {
 			var err error
@@ -715,6 +756,16 @@ func validateSchemaReferenceTarget(ref *schemaReference, target *rawDoc) error {
 		if referencedTarget != "" && referencedTarget != declaredTarget {
 			return schemaCompileAt(ref.node, xsderrors.CodeSchemaReference, "included schema targetNamespace does not match including schema")
 		}
+	case schemaReferenceRedefine:
+		declaredTarget := ref.node.doc.defaults.TargetNamespace
+		if referencedTarget != "" && referencedTarget != declaredTarget {
+			return schemaCompileAt(ref.node, xsderrors.CodeSchemaReference, "redefined schema targetNamespace does not match redefining schema")
+		}
+	case schemaReferenceOverride:
+		declaredTarget := ref.node.doc.defaults.TargetNamespace
+		if referencedTarget != "" && referencedTarget != declaredTarget {
+			return schemaCompileAt(ref.node, xsderrors.CodeSchemaReference, "overridden schema targetNamespace does not match overriding schema")
+		}
 	case schemaReferenceImport:
 		if referencedTarget != ref.namespace {
 			return schemaCompileAt(ref.node, xsderrors.CodeSchemaReference, "import namespace does not match imported schema targetNamespace")
@@ -813,7 +864,7 @@ func (l *schemaSetLoader) propagateTargetContexts(contexts *schemaTargetContexts
 		contexts.next++
 		span := contexts.documents[context.source].references
 		for _, ref := range references[span.start : span.start+span.count] {
-			if ref.kind != schemaReferenceInclude {
+			if !ref.kind.composes() {
 				continue
 			}
 			referenced := l.documents[ref.target].doc
@@ -855,6 +906,7 @@ func (l *schemaSetLoader) applyTargetContexts(contexts schemaTargetContexts) err
 		clones = append(clones, schemaSetDocument{
 			doc:               clone,
 			imports:           document.imports,
+			sourceKey:         document.sourceKey,
 			effectiveTargetNS: context.target,
 			contentIdentity:   document.contentIdentity,
 			adoptedTarget:     declaredTarget == "" && context.target != "",
@@ -957,6 +1009,46 @@ func (l *schemaSetLoader) selectDeclarationDocuments() {
 	}
 }
 
+// linkCompositions resolves every include, redefine, and override edge to the document
+// instance that was instantiated for the referencing document's target
+// namespace. Edges point at the instance whose declarations are indexed, so
+// redefinition sees the same components the compiler publishes.
+func (l *schemaSetLoader) linkCompositions() {
+	type instanceKey struct {
+		key    string
+		target string
+	}
+	type declarationKey struct {
+		target  string
+		content int
+	}
+	instances := make(map[instanceKey]int, len(l.documents))
+	declarations := make(map[declarationKey]int, len(l.documents))
+	for i := range l.documents {
+		document := &l.documents[i]
+		instances[instanceKey{key: document.sourceKey, target: document.effectiveTargetNS}] = i
+		if document.indexDeclarations {
+			declarations[declarationKey{target: document.effectiveTargetNS, content: document.contentIdentity}] = i
+		}
+	}
+	for i := range l.documents {
+		document := &l.documents[i]
+		for _, ref := range document.doc.references {
+			if !ref.kind.composes() {
+				continue
+			}
+			target := -1
+			if index, ok := instances[instanceKey{key: ref.target, target: document.effectiveTargetNS}]; ok && ref.target != "" {
+				instance := l.documents[index]
+				if declared, ok := declarations[declarationKey{target: instance.effectiveTargetNS, content: instance.contentIdentity}]; ok {
+					target = declared
+				}
+			}
+			document.compositions = append(document.compositions, schemaComposition{node: ref.node, target: target, kind: ref.kind})
+		}
+	}
+}
+
 func (c *compiler) checkReferenceNamespace(n *rawNode, ctx *schemaContext, namespace string) (string, error) {
 	if ctx == nil {
 		return namespace, nil
@@ -141,7 +141,7 @@ func ValidateSchemaNodeNames(node SchemaNodeNames) error {
 	if node.HasName && !lex.IsNCName(node.Name) {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaInvalidAttribute, "schema component name must be NCName")
 	}
-	if node.Local == attributeGroup && node.HasName && (!node.ParentXSD || node.ParentLocal != vocab.XSDElemSchema) {
+	if node.Local == attributeGroup && node.HasName && (!node.ParentXSD || (node.ParentLocal != vocab.XSDElemSchema && node.ParentLocal != vocab.XSDElemRedefine && node.ParentLocal != vocab.XSDElemOverride)) {
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaInvalidAttribute, "attributeGroup use cannot have name")
 	}
 	return nil
@@ -178,7 +178,7 @@ func notationSyntaxError(index int, code xsderrors.Code, msg string) error {
 // child component declaration.
 func ValidateTopLevelSchemaChild(child TopLevelSchemaChild) error {
 	switch child.Local {
-	case annotationChild, includeChild, importChild, redefineChild, notationChild:
+	case annotationChild, includeChild, importChild, redefineChild, overrideChild, defaultOpenChild, notationChild:
 		return nil
 	case simpleTypeChild, complexTypeChild:
 		return requireTopLevelName(child)
@@ -112,6 +112,7 @@ func TestCheckUnsupportedSchemaNode(t *testing.T) {
 		name     string
 		node     *rawNode
 		parent   *rawNode
+		xsd11    bool
 		wantSkip bool
 		wantCat  xsderrors.Category
 		wantCode xsderrors.Code
@@ -142,12 +143,17 @@ func TestCheckUnsupportedSchemaNode(t *testing.T) {
 			wantMsg:  "schema namespace attribute foo is not allowed",
 		},
 		{
-			name:     "redefine",
+			name:   "top-level redefine",
+			node:   testRawNode(redefineChild, true, nil),
+			parent: testRawNode(vocab.XSDElemSchema, true, nil),
+		},
+		{
+			name:     "nested redefine",
 			node:     testRawNode(redefineChild, true, nil),
-			parent:   testRawNode(vocab.XSDElemSchema, true, nil),
-			wantCat:  xsderrors.CategoryUnsupported,
-			wantCode: xsderrors.CodeUnsupportedRedefine,
-			wantMsg:  "xs:redefine is not supported",
+			parent:   testRawNode(elementChild, true, nil),
+			wantCat:  xsderrors.CategorySchemaCompile,
+			wantCode: xsderrors.CodeSchemaContentModel,
+			wantMsg:  "xs:redefine must be a top-level schema child",
 		},
 		{
 			name:   "top-level notation",
@@ -169,6 +175,67 @@ func TestCheckUnsupportedSchemaNode(t *testing.T) {
 			wantCode: xsderrors.CodeUnsupportedXSD11,
 			wantMsg:  "XSD 1.1 feature assert is not supported",
 		},
+		{
+			name:  "xsd 1.1 assert with option",
+			node:  testRawNode("assert", true, nil),
+			xsd11: true,
+		},
+		{
+			name:     "xsd 1.1 assertion facet",
+			node:     testRawNode(vocab.XSDFacetAssertion, true, nil),
+			wantCat:  xsderrors.CategoryUnsupported,
+			wantCode: xsderrors.CodeUnsupportedXSD11,
+			wantMsg:  "XSD 1.1 feature assertion is not supported",
+		},
+		{
+			name: "xsd 1.1 xpathDefaultNamespace attribute",
+			node: testRawNode(vocab.XSDElemSchema, true, []xml.Attr{
+				testRawAttr("", vocab.XSDAttrXPathDefaultNS, "##targetNamespace"),
+			}),
+			wantCat:  xsderrors.CategoryUnsupported,
+			wantCode: xsderrors.CodeUnsupportedXSD11,
+			wantMsg:  "XSD 1.1 attribute xpathDefaultNamespace is not supported",
+		},
+		{
+			name:     "xsd 1.1 explicitTimezone facet",
+			node:     testRawNode(vocab.XSDFacetExplicitTimezone, true, nil),
+			wantCat:  xsderrors.CategoryUnsupported,
+			wantCode: xsderrors.CodeUnsupportedXSD11,
+			wantMsg:  "XSD 1.1 feature explicitTimezone is not supported",
+		},
+		{
+			name:  "xsd 1.1 explicitTimezone facet with option",
+			node:  testRawNode(vocab.XSDFacetExplicitTimezone, true, nil),
+			xsd11: true,
+		},
+		{
+			name:     "xsd 1.1 element without xsd 1.1",
+			node:     testRawNode(openContentChild, true, nil),
+			wantCat:  xsderrors.CategoryUnsupported,
+			wantCode: xsderrors.CodeUnsupportedXSD11,
+			wantMsg:  "XSD 1.1 feature openContent is not supported",
+		},
+		{
+			name:  "xsd 1.1 open content",
+			node:  testRawNode(openContentChild, true, nil),
+			xsd11: true,
+		},
+		{
+			name:     "override outside schema",
+			node:     testRawNode(overrideChild, true, nil),
+			parent:   testRawNode(redefineChild, true, nil),
+			xsd11:    true,
+			wantCat:  xsderrors.CategorySchemaCompile,
+			wantCode: xsderrors.CodeSchemaContentModel,
+			wantMsg:  "xs:override must be a top-level schema child",
+		},
+		{
+			name: "xsd 1.1 wildcard attribute accepted",
+			node: testRawNode(anyChild, true, []xml.Attr{
+				testRawAttr("", vocab.XSDAttrNotQName, "##defined"),
+			}),
+			xsd11: true,
+		},
 		{
 			name: "xsd 1.1 wildcard attribute",
 			node: testRawNode(anyAttribute, true, []xml.Attr{
@@ -192,7 +259,7 @@ func TestCheckUnsupportedSchemaNode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			skip, err := checkUnsupportedSchemaNode(tt.node, tt.parent)
+			skip, err := checkUnsupportedSchemaNode(tt.node, tt.parent, tt.xsd11)
 			if tt.wantMsg == "" {
 				if err != nil {
 					t.Fatalf("checkUnsupportedSchemaNode() error = %v", err)
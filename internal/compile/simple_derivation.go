@@ -18,10 +18,14 @@ const (
 	SimpleTypeFinalUnionMember
 )
 
-// CheckSimpleRestrictionBase rejects direct restriction of xs:anySimpleType.
-func CheckSimpleRestrictionBase(baseID, anySimpleType runtime.SimpleTypeID) error {
-	if baseID == anySimpleType {
+// CheckSimpleRestrictionBase rejects direct restriction of xs:anySimpleType
+// and xs:anyAtomicType.
+func CheckSimpleRestrictionBase(baseID runtime.SimpleTypeID, builtins runtime.BuiltinIDs) error {
+	switch baseID {
+	case builtins.AnySimpleType:
 		return xsderrors.SchemaCompile(xsderrors.CodeSchemaReference, "simple type cannot restrict xs:anySimpleType")
+	case builtins.AnyAtomicType:
+		return xsderrors.SchemaCompile(xsderrors.CodeSchemaReference, "simple type cannot restrict xs:anyAtomicType")
 	}
 	return nil
 }
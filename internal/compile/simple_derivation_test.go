@@ -11,11 +11,14 @@ import (
 func TestCheckSimpleRestrictionBase(t *testing.T) {
 	t.Parallel()
 
-	if err := CheckSimpleRestrictionBase(1, 0); err != nil {
+	builtins := runtime.BuiltinIDs{AnySimpleType: 1, AnyAtomicType: 2}
+	if err := CheckSimpleRestrictionBase(3, builtins); err != nil {
 		t.Fatalf("CheckSimpleRestrictionBase(non-anySimpleType) error = %v", err)
 	}
-	err := CheckSimpleRestrictionBase(1, 1)
+	err := CheckSimpleRestrictionBase(1, builtins)
 	expectCompileDiagnostic(t, err, xsderrors.CodeSchemaReference, "simple type cannot restrict xs:anySimpleType")
+	err = CheckSimpleRestrictionBase(2, builtins)
+	expectCompileDiagnostic(t, err, xsderrors.CodeSchemaReference, "simple type cannot restrict xs:anyAtomicType")
 }
 
 func TestCheckSimpleTypeFinalAllows(t *testing.T) {
@@ -0,0 +1,168 @@
+package compile_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jacoelho/xsd/internal/compile"
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/source"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+const snapshotSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+  xmlns:t="urn:snapshot" targetNamespace="urn:snapshot" elementFormDefault="qualified">
+  <xs:annotation><xs:documentation xml:lang="en">Snapshot <b>test</b></xs:documentation></xs:annotation>
+  <xs:simpleType name="code">
+    <xs:restriction base="xs:token">
+      <xs:pattern value="[A-Z]{3}"/>
+      <xs:pattern value="\p{Lu}+"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="shortCode">
+    <xs:restriction base="t:code"><xs:enumeration value="ABC"/><xs:enumeration value="XYZ"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="price">
+    <xs:restriction base="xs:decimal">
+      <xs:minExclusive value="0"/><xs:maxInclusive value="1000.50"/><xs:fractionDigits value="2"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="when">
+    <xs:restriction base="xs:dateTime"><xs:minInclusive value="2000-01-01T00:00:00Z"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="span">
+    <xs:restriction base="xs:duration"><xs:maxExclusive value="P1Y2M"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="month">
+    <xs:restriction base="xs:gYearMonth"><xs:minInclusive value="2001-02"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="clock">
+    <xs:restriction base="xs:time"><xs:maxInclusive value="18:30:00"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="ratio">
+    <xs:restriction base="xs:double"><xs:maxInclusive value="1.5E0"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="names">
+    <xs:restriction base="xs:QName"><xs:enumeration value="t:a"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="codes"><xs:list itemType="t:code"/></xs:simpleType>
+  <xs:simpleType name="either"><xs:union memberTypes="t:price t:code"/></xs:simpleType>
+  <xs:simpleType name="even">
+    <xs:restriction base="xs:integer"><xs:assertion test="$value mod 2 = 0"/></xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="base">
+    <xs:sequence>
+      <xs:element name="code" type="t:shortCode" default="ABC"/>
+      <xs:element ref="t:head" minOccurs="0" maxOccurs="3"/>
+      <xs:any namespace="##other" processContents="lax" minOccurs="0"/>
+    </xs:sequence>
+    <xs:attribute name="price" type="t:price" fixed="10.00"/>
+    <xs:anyAttribute namespace="##local"/>
+  </xs:complexType>
+  <xs:complexType name="derived">
+    <xs:complexContent>
+      <xs:extension base="t:base">
+        <xs:choice minOccurs="0" maxOccurs="unbounded">
+          <xs:element name="when" type="t:when"/>
+          <xs:element name="span" type="t:span"/>
+        </xs:choice>
+        <xs:attribute name="kind" type="xs:string"/>
+        <xs:assert test="count(t:when) le 5"/>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:complexType name="bag">
+    <xs:all>
+      <xs:element name="month" type="t:month"/>
+      <xs:element name="clock" type="t:clock" minOccurs="0"/>
+      <xs:element name="ratio" type="t:ratio" minOccurs="0"/>
+    </xs:all>
+  </xs:complexType>
+  <xs:element name="head" type="xs:string"/>
+  <xs:element name="member" type="xs:string" substitutionGroup="t:head"/>
+  <xs:element name="item" type="t:base">
+    <xs:alternative test="@kind = 'derived'" type="t:derived"/>
+  </xs:element>
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element ref="t:item" maxOccurs="unbounded"/>
+        <xs:element name="bag" type="t:bag" minOccurs="0"/>
+        <xs:element name="values" minOccurs="0">
+          <xs:complexType>
+            <xs:simpleContent>
+              <xs:extension base="t:codes"><xs:attribute name="n" type="t:names"/></xs:extension>
+            </xs:simpleContent>
+          </xs:complexType>
+        </xs:element>
+        <xs:element name="either" type="t:either" minOccurs="0"/>
+        <xs:element name="even" type="t:even" minOccurs="0"/>
+      </xs:sequence>
+    </xs:complexType>
+    <xs:key name="itemCode"><xs:selector xpath="t:item"/><xs:field xpath="t:code"/></xs:key>
+    <xs:keyref name="itemRef" refer="t:itemCode"><xs:selector xpath=".//t:item"/><xs:field xpath="@price"/></xs:keyref>
+  </xs:element>
+  <xs:notation name="png" public="image/png"/>
+</xs:schema>`
+
+func TestSnapshotRoundTripsCompiledSchema(t *testing.T) {
+	t.Parallel()
+
+	opts := compile.Options{XSD11: true, RetainAnnotations: true}
+	sources := []source.Source{source.Bytes("snapshot.xsd", []byte(snapshotSchema))}
+	compiled, err := compile.Compile(context.Background(), opts, sources)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	data, err := runtime.EncodeSchema(compiled)
+	if err != nil {
+		t.Fatalf("EncodeSchema() error = %v", err)
+	}
+	loaded, err := runtime.DecodeSchema(context.Background(), data)
+	if err != nil {
+		t.Fatalf("DecodeSchema() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, compiled) {
+		t.Fatal("decoded schema differs from compiled schema")
+	}
+	again, err := runtime.EncodeSchema(loaded)
+	if err != nil {
+		t.Fatalf("EncodeSchema() error = %v", err)
+	}
+	if string(again) != string(data) {
+		t.Fatal("re-encoding the decoded schema changed the snapshot")
+	}
+}
+
+func TestDecodeSchemaRejectsDamagedSnapshots(t *testing.T) {
+	t.Parallel()
+
+	sources := []source.Source{source.Bytes("snapshot.xsd", []byte(snapshotSchema))}
+	compiled, err := compile.Compile(context.Background(), compile.Options{XSD11: true}, sources)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	data, err := runtime.EncodeSchema(compiled)
+	if err != nil {
+		t.Fatalf("EncodeSchema() error = %v", err)
+	}
+	damage := map[string]func([]byte) []byte{
+		"empty":     func([]byte) []byte { return nil },
+		"magic":     func(b []byte) []byte { b[0] ^= 0xff; return b },
+		"version":   func(b []byte) []byte { b[11]++; return b },
+		"truncated": func(b []byte) []byte { return b[:len(b)-1] },
+		"checksum":  func(b []byte) []byte { b[len(b)/2] ^= 0x01; return b },
+	}
+	for name, fn := range damage {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := runtime.DecodeSchema(context.Background(), fn([]byte(string(data))))
+			var xerr *xsderrors.Error
+			if !errors.As(err, &xerr) || xerr.Code != xsderrors.CodeSchemaSnapshot {
+				t.Fatalf("DecodeSchema() error = %v, want %s", err, xsderrors.CodeSchemaSnapshot)
+			}
+		})
+	}
+}
@@ -14,6 +14,8 @@ const (
 	wildcardNamespaceOther           = "##other"
 	wildcardNamespaceLocal           = "##local"
 	wildcardNamespaceTargetNamespace = "##targetNamespace"
+	wildcardNotQNameDefined          = "##defined"
+	wildcardNotQNameDefinedSibling   = "##definedSibling"
 )
 
 // NamespaceInterner interns namespace URIs while parsing wildcard namespace
@@ -26,9 +28,11 @@ type NamespaceInterner interface {
 // xs:anyAttribute.
 type WildcardAttrs struct {
 	Namespace          string
+	NotNamespace       string
 	ProcessContents    string
 	TargetNamespace    string
 	HasNamespace       bool
+	HasNotNamespace    bool
 	HasProcessContents bool
 }
 
@@ -48,6 +52,19 @@ func ParseWildcard(names NamespaceInterner, attrs WildcardAttrs) (runtime.Wildca
 }
 
 func parseWildcardNamespace(names NamespaceInterner, attrs WildcardAttrs) (runtime.Wildcard, error) {
+	if attrs.HasNotNamespace {
+		if attrs.HasNamespace {
+			return runtime.Wildcard{}, xsderrors.SchemaCompile(xsderrors.CodeSchemaInvalidAttribute, "wildcard cannot have both namespace and notNamespace")
+		}
+		namespaces, err := parseWildcardNamespaceList(names, attrs.TargetNamespace, attrs.NotNamespace)
+		if err != nil {
+			return runtime.Wildcard{}, err
+		}
+		if len(namespaces) == 0 {
+			return runtime.Wildcard{}, xsderrors.SchemaCompile(xsderrors.CodeSchemaInvalidAttribute, "wildcard notNamespace cannot be empty")
+		}
+		return runtime.Wildcard{Mode: runtime.WildcardNot, Namespaces: namespaces}, nil
+	}
 	nsSpec := wildcardNamespaceAny
 	if attrs.HasNamespace {
 		nsSpec = attrs.Namespace
@@ -103,11 +103,13 @@ func formatReaderErr(err error) error {
 	switch {
 	case errors.Is(err, stream.ErrXMLInputNilReader):
 		return formatOptionErr(errors.New("nil reader"))
-	case errors.Is(err, stream.ErrUnsupportedNonUTF8):
-		return xsderrors.Unsupported(xsderrors.CodeUnsupportedNonUTF8, "XML documents must be UTF-8")
 	case errors.Is(err, errFormatInputLimit):
 		return formatLimitErr(0, 0, err)
 	default:
+		var encodingErr stream.UnsupportedEncodingError
+		if errors.As(err, &encodingErr) {
+			return xsderrors.Unsupported(xsderrors.CodeUnsupportedNonUTF8, encodingErr.Error())
+		}
 		var versionErr stream.UnsupportedXMLVersionError
 		if errors.As(err, &versionErr) {
 			return xsderrors.Unsupported(xsderrors.CodeUnsupportedXML11, versionErr.Error())
@@ -0,0 +1,794 @@
+// Package introspect builds a read-only component model of a published
+// schema for the public schema introspection API.
+package introspect
+
+import (
+	"cmp"
+	"encoding/xml"
+	"slices"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// TypeKind identifies simple and complex type definitions.
+type TypeKind uint8
+
+const (
+	// TypeSimple is a simple type definition.
+	TypeSimple TypeKind = iota + 1
+	// TypeComplex is a complex type definition.
+	TypeComplex
+)
+
+// ContentType is the content type variety of a complex type definition.
+type ContentType uint8
+
+const (
+	// ContentEmpty admits neither elements nor text.
+	ContentEmpty ContentType = iota
+	// ContentSimple admits text of a simple type.
+	ContentSimple
+	// ContentElementOnly admits elements and whitespace.
+	ContentElementOnly
+	// ContentMixed admits elements and text.
+	ContentMixed
+)
+
+// ParticleKind identifies the term of a particle.
+type ParticleKind uint8
+
+const (
+	// ParticleElement is an element declaration particle.
+	ParticleElement ParticleKind = iota + 1
+	// ParticleWildcard is an element wildcard particle.
+	ParticleWildcard
+	// ParticleSequence is an xs:sequence model group particle.
+	ParticleSequence
+	// ParticleChoice is an xs:choice model group particle.
+	ParticleChoice
+	// ParticleAll is an xs:all model group particle.
+	ParticleAll
+)
+
+// NamespaceConstraint is the variety of a wildcard namespace constraint.
+type NamespaceConstraint uint8
+
+const (
+	// NamespaceAny admits every namespace.
+	NamespaceAny NamespaceConstraint = iota
+	// NamespaceEnumeration admits only the listed namespaces.
+	NamespaceEnumeration
+	// NamespaceNot admits every namespace except the listed ones.
+	NamespaceNot
+)
+
+// Unbounded is the MaxOccurs of particles with maxOccurs="unbounded".
+const Unbounded = -1
+
+// Schema lists the top-level components of a schema sorted by name.
+type Schema struct {
+	Elements            []*Element
+	Attributes          []*Attribute
+	Types               []*Type
+	AttributeGroups     []*AttributeGroup
+	SubstitutionGroups  []*SubstitutionGroup
+	IdentityConstraints []*IdentityConstraint
+	// Annotation holds the top-level annotations of all schema documents.
+	Annotation Annotation
+}
+
+// Type is a simple or complex type definition.
+type Type struct {
+	Name              xml.Name
+	Base              *Type
+	Primitive         *Type
+	ItemType          *Type
+	SimpleContentType *Type
+	Particle          *Particle
+	AttributeWildcard *Wildcard
+	MemberTypes       []*Type
+	Attributes        []*AttributeUse
+	Facets            Facets
+	Annotation        Annotation
+	Kind              TypeKind
+	Derivation        runtime.DerivationKind
+	Variety           runtime.SimpleVariety
+	ContentType       ContentType
+	Abstract          bool
+}
+
+// Facets are the facets in effect for a simple type.
+type Facets struct {
+	Enumeration      []string
+	Patterns         [][]string
+	MinInclusive     string
+	MaxInclusive     string
+	MinExclusive     string
+	MaxExclusive     string
+	Length           int
+	MinLength        int
+	MaxLength        int
+	TotalDigits      int
+	FractionDigits   int
+	Present          runtime.FacetMask
+	Fixed            runtime.FacetMask
+	WhiteSpace       runtime.WhitespaceMode
+	ExplicitTimezone runtime.ExplicitTimezone
+}
+
+// Particle is a content-model particle.
+type Particle struct {
+	Element   *Element
+	Wildcard  *Wildcard
+	Particles []*Particle
+	MinOccurs int
+	MaxOccurs int
+	Kind      ParticleKind
+}
+
+// Wildcard is an element or attribute wildcard.
+type Wildcard struct {
+	Namespaces        []string
+	NotQNames         []xml.Name
+	Constraint        NamespaceConstraint
+	Process           runtime.ProcessContents
+	NotDefined        bool
+	NotDefinedSibling bool
+}
+
+// Element is an element declaration.
+type Element struct {
+	Name                xml.Name
+	Type                *Type
+	SubstitutionGroup   *Element
+	IdentityConstraints []*IdentityConstraint
+	Annotation          Annotation
+	Default             string
+	Fixed               string
+	Global              bool
+	Nillable            bool
+	Abstract            bool
+	HasDefault          bool
+	HasFixed            bool
+}
+
+// Attribute is a top-level attribute declaration.
+type Attribute struct {
+	Name       xml.Name
+	Type       *Type
+	Annotation Annotation
+	Default    string
+	Fixed      string
+	HasDefault bool
+	HasFixed   bool
+}
+
+// AttributeUse is an attribute admitted by a complex type or attribute group.
+type AttributeUse struct {
+	Name       xml.Name
+	Type       *Type
+	Annotation Annotation
+	Default    string
+	Fixed      string
+	Required   bool
+	HasDefault bool
+	HasFixed   bool
+}
+
+// AttributeGroup is a named attribute group.
+type AttributeGroup struct {
+	Name       xml.Name
+	Attributes []*AttributeUse
+	Wildcard   *Wildcard
+	Annotation Annotation
+}
+
+// SubstitutionGroup is a substitution group head with its members.
+type SubstitutionGroup struct {
+	Head    *Element
+	Members []*Element
+}
+
+// IdentityConstraint is a unique, key or keyref identity constraint.
+type IdentityConstraint struct {
+	Name       xml.Name
+	Refer      *IdentityConstraint
+	Selector   string
+	Fields     []string
+	Annotation Annotation
+	Kind       runtime.IdentityKind
+}
+
+// Annotation is the retained documentation and appinfo of a component.
+type Annotation struct {
+	Documentation []Documentation
+	AppInfo       []AppInfo
+}
+
+// Documentation is one retained xs:documentation element.
+type Documentation struct {
+	Source  string
+	Lang    string
+	Text    string
+	Content string
+}
+
+// AppInfo is one retained xs:appinfo element.
+type AppInfo struct {
+	Source  string
+	Content string
+}
+
+// Build returns the component model of rt. Every call builds a new model.
+func Build(rt *runtime.Schema) (*Schema, error) {
+	if rt == nil {
+		return nil, xsderrors.InternalInvariant("schema introspection requires a compiled engine")
+	}
+	b := &builder{
+		rt:         rt,
+		simple:     make(map[runtime.SimpleTypeID]*Type),
+		complex:    make(map[runtime.ComplexTypeID]*Type),
+		elements:   make(map[runtime.ElementID]*Element),
+		identities: make(map[runtime.IdentityConstraintID]*IdentityConstraint),
+	}
+	out := &Schema{Annotation: b.annotation(runtime.AnnotatedSchema, 0)}
+	for name, id := range rt.GlobalTypes() {
+		if !b.visible(name) {
+			continue
+		}
+		typ, err := b.typ(id)
+		if err != nil {
+			return nil, err
+		}
+		out.Types = append(out.Types, typ)
+	}
+	for name, id := range rt.GlobalElements() {
+		if !b.visible(name) {
+			continue
+		}
+		elem, err := b.element(id)
+		if err != nil {
+			return nil, err
+		}
+		out.Elements = append(out.Elements, elem)
+		if members := rt.SubstitutionMembers(id); len(members) != 0 {
+			group := &SubstitutionGroup{Head: elem}
+			for _, member := range members {
+				m, err := b.element(member)
+				if err != nil {
+					return nil, err
+				}
+				group.Members = append(group.Members, m)
+			}
+			out.SubstitutionGroups = append(out.SubstitutionGroups, group)
+		}
+	}
+	for name, id := range rt.GlobalAttributes() {
+		if !b.visible(name) {
+			continue
+		}
+		attr, err := b.attribute(id)
+		if err != nil {
+			return nil, err
+		}
+		out.Attributes = append(out.Attributes, attr)
+	}
+	for name, id := range rt.AttributeGroups() {
+		if !b.visible(name) {
+			continue
+		}
+		uses, wildcard, err := b.attributeUses(id, nil)
+		if err != nil {
+			return nil, err
+		}
+		out.AttributeGroups = append(out.AttributeGroups, &AttributeGroup{
+			Name:       b.name(name),
+			Attributes: uses,
+			Wildcard:   wildcard,
+			Annotation: b.annotation(runtime.AnnotatedAttributeGroup, uint32(id)),
+		})
+	}
+	for _, id := range rt.IdentityConstraints() {
+		ic, err := b.identity(id)
+		if err != nil {
+			return nil, err
+		}
+		out.IdentityConstraints = append(out.IdentityConstraints, ic)
+	}
+	slices.SortFunc(out.Types, func(a, b *Type) int { return compareNames(a.Name, b.Name) })
+	slices.SortFunc(out.Elements, func(a, b *Element) int { return compareNames(a.Name, b.Name) })
+	slices.SortFunc(out.SubstitutionGroups, func(a, b *SubstitutionGroup) int { return compareNames(a.Head.Name, b.Head.Name) })
+	slices.SortFunc(out.Attributes, func(a, b *Attribute) int { return compareNames(a.Name, b.Name) })
+	slices.SortFunc(out.AttributeGroups, func(a, b *AttributeGroup) int { return compareNames(a.Name, b.Name) })
+	slices.SortFunc(out.IdentityConstraints, func(a, b *IdentityConstraint) int { return compareNames(a.Name, b.Name) })
+	return out, nil
+}
+
+type builder struct {
+	rt         *runtime.Schema
+	simple     map[runtime.SimpleTypeID]*Type
+	complex    map[runtime.ComplexTypeID]*Type
+	elements   map[runtime.ElementID]*Element
+	identities map[runtime.IdentityConstraintID]*IdentityConstraint
+}
+
+// visible reports whether a top-level name was declared in a schema
+// document. Components replaced by xs:redefine are kept under hidden names
+// that are not NCNames.
+func (b *builder) visible(name runtime.QName) bool {
+	return !strings.Contains(b.rt.ExpandedName(name).Local, "$")
+}
+
+// name returns the expanded name of q. Components replaced by xs:redefine
+// report the name they were declared with.
+func (b *builder) name(q runtime.QName) xml.Name {
+	expanded := b.rt.ExpandedName(q)
+	local, _, _ := strings.Cut(expanded.Local, "$")
+	return xml.Name{Space: expanded.Namespace, Local: local}
+}
+
+// annotation returns the retained annotation of a component, which is empty
+// when the schema was compiled without annotation retention.
+func (b *builder) annotation(kind runtime.AnnotatedKind, id uint32) Annotation {
+	a, _ := b.rt.Annotation(runtime.AnnotationKey{Kind: kind, ID: id})
+	return newAnnotation(a)
+}
+
+func newAnnotation(in runtime.Annotation) Annotation {
+	var out Annotation
+	for _, doc := range in.Documentation {
+		out.Documentation = append(out.Documentation, Documentation(doc))
+	}
+	for _, info := range in.AppInfo {
+		out.AppInfo = append(out.AppInfo, AppInfo(info))
+	}
+	return out
+}
+
+func (b *builder) typ(id runtime.TypeID) (*Type, error) {
+	if simple, ok := id.Simple(); ok {
+		return b.simpleType(simple)
+	}
+	if complexID, ok := id.Complex(); ok {
+		return b.complexType(complexID)
+	}
+	return nil, missingComponent("type")
+}
+
+func (b *builder) typeName(id runtime.TypeID) xml.Name {
+	if name, ok := b.rt.TypeName(id); ok {
+		return b.name(name)
+	}
+	return xml.Name{}
+}
+
+func (b *builder) simpleType(id runtime.SimpleTypeID) (*Type, error) {
+	if typ, ok := b.simple[id]; ok {
+		return typ, nil
+	}
+	st, ok := b.rt.SimpleTypeComponent(id)
+	if !ok {
+		return nil, missingComponent("simple type")
+	}
+	typ := &Type{
+		Name:       b.typeName(runtime.SimpleRef(id)),
+		Kind:       TypeSimple,
+		Derivation: runtime.DerivationKindRestriction,
+		Variety:    st.Variety,
+		Facets:     newFacets(st.Facets, st.Whitespace),
+		Annotation: b.annotation(runtime.AnnotatedSimpleType, uint32(id)),
+	}
+	b.simple[id] = typ
+	var err error
+	if st.Base == runtime.NoSimpleType {
+		// xs:anySimpleType restricts xs:anyType.
+		typ.Base, err = b.typ(b.rt.AnyType())
+	} else {
+		typ.Base, err = b.simpleType(st.Base)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch typ.Variety {
+	case runtime.SimpleVarietyAtomic:
+		typ.Primitive = primitiveOf(typ)
+	case runtime.SimpleVarietyList:
+		if typ.ItemType, err = b.simpleType(st.ListItem); err != nil {
+			return nil, err
+		}
+	case runtime.SimpleVarietyUnion:
+		members := st.Members
+		if len(members) == 0 {
+			// Restrictions of a union share the members of their base.
+			if typ.Base != nil && typ.Base.Kind == TypeSimple {
+				typ.MemberTypes = typ.Base.MemberTypes
+			}
+			break
+		}
+		for _, member := range members {
+			m, err := b.simpleType(member)
+			if err != nil {
+				return nil, err
+			}
+			typ.MemberTypes = append(typ.MemberTypes, m)
+		}
+	}
+	return typ, nil
+}
+
+// primitiveOf returns the primitive type an atomic type derives from: the
+// ancestor whose base is xs:anySimpleType or xs:anyAtomicType.
+func primitiveOf(typ *Type) *Type {
+	if isUrType(typ) {
+		return nil
+	}
+	for t := typ; t.Base != nil; t = t.Base {
+		if isUrType(t.Base) {
+			return t
+		}
+	}
+	return nil
+}
+
+func isUrType(typ *Type) bool {
+	if typ.Name.Space != vocab.XSDNamespaceURI {
+		return false
+	}
+	switch typ.Name.Local {
+	case vocab.XSDValueAnyType, vocab.XSDValueAnySimpleType, vocab.XSDValueAnyAtomicType:
+		return true
+	default:
+		return false
+	}
+}
+
+func newFacets(f runtime.FacetComponent, ws runtime.WhitespaceMode) Facets {
+	return Facets{
+		Enumeration:      f.Enumeration,
+		Patterns:         f.Patterns,
+		MinInclusive:     f.MinInclusive,
+		MaxInclusive:     f.MaxInclusive,
+		MinExclusive:     f.MinExclusive,
+		MaxExclusive:     f.MaxExclusive,
+		Length:           int(f.Length),
+		MinLength:        int(f.MinLength),
+		MaxLength:        int(f.MaxLength),
+		TotalDigits:      int(f.TotalDigits),
+		FractionDigits:   int(f.FractionDigits),
+		Present:          f.Present,
+		Fixed:            f.Fixed,
+		WhiteSpace:       ws,
+		ExplicitTimezone: f.ExplicitTimezone,
+	}
+}
+
+func (b *builder) complexType(id runtime.ComplexTypeID) (*Type, error) {
+	if typ, ok := b.complex[id]; ok {
+		return typ, nil
+	}
+	ct, ok := b.rt.ComplexTypeComponent(id)
+	if !ok {
+		return nil, missingComponent("complex type")
+	}
+	typ := &Type{
+		Name:       b.typeName(runtime.ComplexRef(id)),
+		Kind:       TypeComplex,
+		Derivation: ct.Derivation,
+		Abstract:   ct.Abstract,
+		Annotation: b.annotation(runtime.AnnotatedComplexType, uint32(id)),
+	}
+	b.complex[id] = typ
+	var err error
+	if ct.Derivation != runtime.DerivationKindNone {
+		if typ.Base, err = b.typ(ct.Base); err != nil {
+			return nil, err
+		}
+	}
+	if typ.Attributes, typ.AttributeWildcard, err = b.attributeUses(ct.Attrs, b.inheritedAttributeSets(ct)); err != nil {
+		return nil, err
+	}
+	if ct.ContentKind.Simple() {
+		typ.ContentType = ContentSimple
+		typ.SimpleContentType, err = b.simpleType(ct.TextType)
+		return typ, err
+	}
+	model, ok := b.rt.ContentModelComponent(ct.Content)
+	if !ok {
+		return nil, missingComponent("content model")
+	}
+	if typ.Particle, err = b.modelParticle(model, model.Occurs); err != nil {
+		return nil, err
+	}
+	switch {
+	case ct.ContentKind.Mixed():
+		typ.ContentType = ContentMixed
+	case typ.Particle == nil:
+		typ.ContentType = ContentEmpty
+	default:
+		typ.ContentType = ContentElementOnly
+	}
+	return typ, nil
+}
+
+// modelParticle returns the particle for model with occurs, or nil for an
+// empty content model.
+func (b *builder) modelParticle(model runtime.ContentModel, occurs runtime.Occurrence) (*Particle, error) {
+	p := newParticle(occurs)
+	switch model.Kind {
+	case runtime.ModelEmpty:
+		return nil, nil
+	case runtime.ModelAny:
+		// The xs:anyType content model is a sequence of one lax wildcard.
+		p.Kind = ParticleSequence
+		p.Particles = []*Particle{{
+			Kind:      ParticleWildcard,
+			MaxOccurs: Unbounded,
+			Wildcard:  &Wildcard{Constraint: NamespaceAny, Process: runtime.ProcessLax},
+		}}
+		return p, nil
+	case runtime.ModelSequence:
+		p.Kind = ParticleSequence
+	case runtime.ModelChoice:
+		p.Kind = ParticleChoice
+	case runtime.ModelAll:
+		p.Kind = ParticleAll
+	default:
+		return nil, missingComponent("content model")
+	}
+	for _, child := range model.Particles {
+		cp, err := b.particle(child)
+		if err != nil {
+			return nil, err
+		}
+		p.Particles = append(p.Particles, cp)
+	}
+	return p, nil
+}
+
+func (b *builder) particle(in runtime.Particle) (*Particle, error) {
+	switch in.Kind {
+	case runtime.ParticleElement:
+		elem, err := b.element(in.Element)
+		if err != nil {
+			return nil, err
+		}
+		p := newParticle(in.Occurs)
+		p.Kind = ParticleElement
+		p.Element = elem
+		return p, nil
+	case runtime.ParticleWildcard:
+		wildcard, err := b.wildcard(in.Wildcard)
+		if err != nil {
+			return nil, err
+		}
+		p := newParticle(in.Occurs)
+		p.Kind = ParticleWildcard
+		p.Wildcard = wildcard
+		return p, nil
+	case runtime.ParticleModel:
+		model, ok := b.rt.ContentModelComponent(in.Model)
+		if !ok {
+			return nil, missingComponent("content model")
+		}
+		p, err := b.modelParticle(model, in.Occurs)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			// Nested empty groups contribute nothing; keep the particle so
+			// the occurrence bounds remain visible.
+			p = newParticle(in.Occurs)
+			p.Kind = ParticleSequence
+		}
+		return p, nil
+	default:
+		return nil, missingComponent("particle")
+	}
+}
+
+func newParticle(occurs runtime.Occurrence) *Particle {
+	p := &Particle{MinOccurs: int(occurs.Min), MaxOccurs: int(occurs.Max)}
+	if occurs.Unbounded {
+		p.MaxOccurs = Unbounded
+	}
+	return p
+}
+
+func (b *builder) wildcard(id runtime.WildcardID) (*Wildcard, error) {
+	w, ok := b.rt.WildcardComponent(id)
+	if !ok {
+		return nil, missingComponent("wildcard")
+	}
+	out := &Wildcard{
+		Process:           w.Process,
+		NotDefined:        w.NotDefined,
+		NotDefinedSibling: w.NotDefinedSibling,
+	}
+	for _, q := range w.NotQNames {
+		out.NotQNames = append(out.NotQNames, b.name(q))
+	}
+	switch w.Mode {
+	case runtime.WildcardAny:
+		out.Constraint = NamespaceAny
+	case runtime.WildcardOther:
+		// ##other excludes the target namespace and no namespace.
+		out.Constraint = NamespaceNot
+		out.Namespaces = []string{b.rt.Namespace(w.OtherThan), ""}
+	case runtime.WildcardLocal:
+		out.Constraint = NamespaceEnumeration
+		out.Namespaces = []string{""}
+	case runtime.WildcardTargetNamespace, runtime.WildcardList, runtime.WildcardNot:
+		out.Constraint = NamespaceEnumeration
+		if w.Mode == runtime.WildcardNot {
+			out.Constraint = NamespaceNot
+		}
+		for _, ns := range w.Namespaces {
+			out.Namespaces = append(out.Namespaces, b.rt.Namespace(ns))
+		}
+	default:
+		return nil, missingComponent("wildcard")
+	}
+	return out, nil
+}
+
+func (b *builder) element(id runtime.ElementID) (*Element, error) {
+	if elem, ok := b.elements[id]; ok {
+		return elem, nil
+	}
+	decl, ok := b.rt.ElementComponent(id)
+	if !ok {
+		return nil, missingComponent("element declaration")
+	}
+	_, global, _ := b.rt.ElementName(id)
+	elem := &Element{
+		Name:       b.name(decl.Name),
+		Default:    decl.Default.Lexical,
+		Fixed:      decl.Fixed.Lexical,
+		Global:     global,
+		Nillable:   decl.Nillable,
+		Abstract:   decl.Abstract,
+		HasDefault: decl.Default.Present,
+		HasFixed:   decl.Fixed.Present,
+		Annotation: b.annotation(runtime.AnnotatedElement, uint32(id)),
+	}
+	b.elements[id] = elem
+	var err error
+	if elem.Type, err = b.typ(decl.Type); err != nil {
+		return nil, err
+	}
+	if decl.SubstHead != runtime.NoElement {
+		if elem.SubstitutionGroup, err = b.element(decl.SubstHead); err != nil {
+			return nil, err
+		}
+	}
+	for _, icID := range decl.Identity {
+		ic, err := b.identity(icID)
+		if err != nil {
+			return nil, err
+		}
+		elem.IdentityConstraints = append(elem.IdentityConstraints, ic)
+	}
+	return elem, nil
+}
+
+func (b *builder) attribute(id runtime.AttributeID) (*Attribute, error) {
+	decl, ok := b.rt.AttributeComponent(id)
+	if !ok {
+		return nil, missingComponent("attribute declaration")
+	}
+	typ, err := b.simpleType(decl.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &Attribute{
+		Name:       b.name(decl.Name),
+		Type:       typ,
+		Default:    decl.Default.Lexical,
+		Fixed:      decl.Fixed.Lexical,
+		HasDefault: decl.Default.Present,
+		HasFixed:   decl.Fixed.Present,
+		Annotation: b.annotation(runtime.AnnotatedAttribute, uint32(id)),
+	}, nil
+}
+
+// attributeUses returns the attribute uses of set sorted by name, without
+// prohibited uses, and its attribute wildcard. Uses without an annotation of
+// their own take the annotation of the same use in the inherited sets.
+func (b *builder) attributeUses(id runtime.AttributeUseSetID, inherited []runtime.AttributeUseSetID) ([]*AttributeUse, *Wildcard, error) {
+	set, ok := b.rt.AttributeUseSetComponent(id)
+	if !ok {
+		return nil, nil, missingComponent("attribute-use set")
+	}
+	var uses []*AttributeUse
+	for _, use := range set.Uses {
+		if use.Prohibited {
+			continue
+		}
+		typ, err := b.simpleType(use.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		uses = append(uses, &AttributeUse{
+			Name:       b.name(use.Name),
+			Type:       typ,
+			Default:    use.Default.Lexical,
+			Fixed:      use.Fixed.Lexical,
+			Required:   use.Required,
+			HasDefault: use.Default.Present,
+			HasFixed:   use.Fixed.Present,
+			Annotation: b.attributeUseAnnotation(use.Name, append([]runtime.AttributeUseSetID{id}, inherited...)),
+		})
+	}
+	slices.SortFunc(uses, func(a, b *AttributeUse) int { return compareNames(a.Name, b.Name) })
+	if set.Wildcard == runtime.NoWildcard {
+		return uses, nil, nil
+	}
+	wildcard, err := b.wildcard(set.Wildcard)
+	if err != nil {
+		return nil, nil, err
+	}
+	return uses, wildcard, nil
+}
+
+// inheritedAttributeSets returns the attribute-use sets of the complex base
+// types of ct, nearest first.
+func (b *builder) inheritedAttributeSets(ct runtime.ComplexTypeComponent) []runtime.AttributeUseSetID {
+	var sets []runtime.AttributeUseSetID
+	for ct.Derivation != runtime.DerivationKindNone {
+		base, ok := ct.Base.Complex()
+		if !ok {
+			break
+		}
+		if ct, ok = b.rt.ComplexTypeComponent(base); !ok {
+			break
+		}
+		sets = append(sets, ct.Attrs)
+	}
+	return sets
+}
+
+func (b *builder) attributeUseAnnotation(name runtime.QName, sets []runtime.AttributeUseSetID) Annotation {
+	for _, set := range sets {
+		if a, ok := b.rt.Annotation(runtime.AnnotationKey{Kind: runtime.AnnotatedAttributeUse, ID: uint32(set), Name: name}); ok {
+			return newAnnotation(a)
+		}
+	}
+	return Annotation{}
+}
+
+func (b *builder) identity(id runtime.IdentityConstraintID) (*IdentityConstraint, error) {
+	if ic, ok := b.identities[id]; ok {
+		return ic, nil
+	}
+	in, ok := b.rt.IdentityComponent(id)
+	if !ok {
+		return nil, missingComponent("identity constraint")
+	}
+	ic := &IdentityConstraint{
+		Name:       b.name(in.Name),
+		Selector:   in.Selector,
+		Fields:     in.Fields,
+		Kind:       in.Kind,
+		Annotation: b.annotation(runtime.AnnotatedIdentity, uint32(id)),
+	}
+	b.identities[id] = ic
+	if in.Kind == runtime.IdentityKeyRef {
+		refer, err := b.identity(in.Refer)
+		if err != nil {
+			return nil, err
+		}
+		ic.Refer = refer
+	}
+	return ic, nil
+}
+
+func compareNames(a, b xml.Name) int {
+	return cmp.Or(strings.Compare(a.Space, b.Space), strings.Compare(a.Local, b.Local))
+}
+
+func missingComponent(kind string) error {
+	return xsderrors.InternalInvariant("schema introspection references missing " + kind)
+}
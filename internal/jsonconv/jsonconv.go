@@ -0,0 +1,290 @@
+// Package jsonconv converts between XML instances and JSON documents using the
+// content models and simple types of a published schema.
+//
+// A document maps to an object with one member named after its root element.
+// An element maps to a scalar when its type is simple, or a complex type with
+// simple content and no attributes, and to an object otherwise. Objects hold
+// attributes as "@name" members, simple content as "#value", mixed character
+// data as "#text" and child elements by name. Children that the content model
+// lets repeat are arrays whatever their count, so the JSON shape follows the
+// schema rather than the instance.
+package jsonconv
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+const (
+	attributePrefix = "@"
+	valueKey        = "#value"
+	textKey         = "#text"
+)
+
+// converter holds the mapping derived from a schema, shared by both
+// directions.
+type converter struct {
+	rt      *runtime.Schema
+	shapes  map[runtime.ComplexTypeID]*shape
+	globals map[xml.Name]runtime.ElementID
+	roots   keys
+}
+
+func newConverter(rt *runtime.Schema) (*converter, error) {
+	if rt == nil {
+		return nil, xsderrors.InternalInvariant("JSON conversion requires a compiled engine")
+	}
+	c := &converter{
+		rt:      rt,
+		shapes:  make(map[runtime.ComplexTypeID]*shape),
+		globals: make(map[xml.Name]runtime.ElementID),
+		roots:   make(keys),
+	}
+	for qname, id := range rt.GlobalElements() {
+		name := c.name(qname)
+		c.globals[name] = id
+		c.roots.add(name)
+	}
+	return c, nil
+}
+
+func (c *converter) name(q runtime.QName) xml.Name {
+	expanded := c.rt.ExpandedName(q)
+	return xml.Name{Space: expanded.Namespace, Local: expanded.Local}
+}
+
+// shape is the JSON mapping of the content of one complex type.
+type shape struct {
+	children   map[xml.Name]child
+	elements   keys
+	attributes keys
+	// text is the simple content type, or NoSimpleType for complex content.
+	text runtime.SimpleTypeID
+	// object is set when simple content maps to an object because the
+	// type declares attributes.
+	object bool
+	mixed  bool
+	// wildcard is set when the content model has an element wildcard;
+	// wildcardRepeated and wildcardOrder describe it as child does.
+	wildcard         bool
+	wildcardRepeated bool
+	wildcardOrder    int
+	count            int
+	// sequences counts the repeated sequences of the content model.
+	sequences int
+}
+
+// child is one element name the content model of a shape admits. Order is
+// the position of its first particle, which fixes the order of elements
+// written back to XML. Exact is set when occurs, the bounds of that
+// particle, also bounds the number of elements with the name: no enclosing
+// group repeats and no other particle matches them. Sequence numbers the
+// repeated sequence of several particles that encloses the particle, or is
+// zero: the elements of such a sequence interleave, and once grouped by name
+// their order cannot be recovered.
+type child struct {
+	occurs   runtime.Occurrence
+	elem     runtime.ElementID
+	order    int
+	sequence int
+	repeated bool
+	exact    bool
+}
+
+func (c *converter) shape(id runtime.ComplexTypeID) (*shape, error) {
+	if s, ok := c.shapes[id]; ok {
+		return s, nil
+	}
+	ct, ok := c.rt.ComplexTypeComponent(id)
+	if !ok {
+		return nil, missingComponent("complex type")
+	}
+	set, ok := c.rt.AttributeUseSetComponent(ct.Attrs)
+	if !ok {
+		return nil, missingComponent("attribute-use set")
+	}
+	s := &shape{
+		children:   make(map[xml.Name]child),
+		elements:   make(keys),
+		attributes: make(keys),
+		text:       runtime.NoSimpleType,
+	}
+	for _, use := range set.Uses {
+		if !use.Prohibited {
+			s.attributes.add(c.name(use.Name))
+		}
+	}
+	switch ct.ContentKind {
+	case runtime.ContentSimple, runtime.ContentSimpleMixed:
+		s.text = ct.TextType
+		s.object = len(s.attributes) != 0 || set.Wildcard != runtime.NoWildcard
+	default:
+		s.mixed = ct.ContentKind == runtime.ContentMixed
+		model, ok := c.rt.ContentModelComponent(ct.Content)
+		if !ok {
+			return nil, missingComponent("content model")
+		}
+		if err := c.group(s, model, false, 0); err != nil {
+			return nil, err
+		}
+		if model.Open.Present() {
+			s.addWildcard(true)
+		}
+	}
+	c.shapes[id] = s
+	return s, nil
+}
+
+// group records the particles of model. Repeated is set when an enclosing
+// particle may occur more than once, and sequence numbers the enclosing
+// repeated sequence.
+func (c *converter) group(s *shape, model runtime.ContentModel, repeated bool, sequence int) error {
+	repeated = repeated || repeats(model.Occurs)
+	if repeated && sequence == 0 && model.Kind == runtime.ModelSequence && len(model.Particles) > 1 {
+		s.sequences++
+		sequence = s.sequences
+	}
+	for _, p := range model.Particles {
+		r := repeated || repeats(p.Occurs)
+		switch p.Kind {
+		case runtime.ParticleElement:
+			members := c.rt.SubstitutionMembers(p.Element)
+			if err := c.addElement(s, p.Element, r, p.Occurs, !repeated && len(members) == 0, sequence); err != nil {
+				return err
+			}
+			for _, member := range members {
+				if err := c.addElement(s, member, r, p.Occurs, false, sequence); err != nil {
+					return err
+				}
+			}
+		case runtime.ParticleWildcard:
+			s.addWildcard(r)
+		case runtime.ParticleModel:
+			sub, ok := c.rt.ContentModelComponent(p.Model)
+			if !ok {
+				return missingComponent("content model")
+			}
+			if err := c.group(s, sub, r, sequence); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addElement records an element particle. A name matched by several
+// particles may occur once for each, so it is repeated too.
+func (c *converter) addElement(s *shape, id runtime.ElementID, repeated bool, occurs runtime.Occurrence, exact bool, sequence int) error {
+	decl, ok := c.rt.ElementComponent(id)
+	if !ok {
+		return missingComponent("element declaration")
+	}
+	name := c.name(decl.Name)
+	if existing, ok := s.children[name]; ok {
+		existing.repeated = true
+		existing.exact = false
+		s.children[name] = existing
+		return nil
+	}
+	s.children[name] = child{elem: id, occurs: occurs, order: s.count, sequence: sequence, repeated: repeated, exact: exact}
+	s.count++
+	s.elements.add(name)
+	return nil
+}
+
+func (s *shape) addWildcard(repeated bool) {
+	if s.wildcard {
+		s.wildcardRepeated = true
+		return
+	}
+	s.wildcard = true
+	s.wildcardRepeated = repeated
+	s.wildcardOrder = s.count
+	s.count++
+}
+
+// repeated reports whether the child elements named name map to an array.
+func (s *shape) repeated(name xml.Name) bool {
+	if ch, ok := s.children[name]; ok {
+		return ch.repeated
+	}
+	return s.wildcardRepeated
+}
+
+// order returns the position of the elements named name among the children
+// written back to XML.
+func (s *shape) order(name xml.Name) int {
+	if ch, ok := s.children[name]; ok {
+		return ch.order
+	}
+	if s.wildcard {
+		return s.wildcardOrder
+	}
+	return s.count
+}
+
+func repeats(occurs runtime.Occurrence) bool {
+	return occurs.Unbounded || occurs.Max > 1
+}
+
+// keys maps local names to the declared names that share them, so a member
+// can be named by its local name wherever that is unambiguous.
+type keys map[string][]xml.Name
+
+func (k keys) add(name xml.Name) {
+	for _, declared := range k[name.Local] {
+		if declared == name {
+			return
+		}
+	}
+	k[name.Local] = append(k[name.Local], name)
+}
+
+// key returns the member name of name: its local name when that identifies
+// it, and Clark notation otherwise.
+func (k keys) key(name xml.Name) string {
+	declared := k[name.Local]
+	if len(declared) == 0 && name.Space == "" || len(declared) == 1 && declared[0] == name {
+		return name.Local
+	}
+	return clark(name)
+}
+
+// name reverses key.
+func (k keys) name(key string) (xml.Name, bool) {
+	if rest, ok := strings.CutPrefix(key, "{"); ok {
+		space, local, ok := strings.Cut(rest, "}")
+		return xml.Name{Space: space, Local: local}, ok && local != ""
+	}
+	switch declared := k[key]; len(declared) {
+	case 0:
+		return xml.Name{Local: key}, key != ""
+	case 1:
+		return declared[0], true
+	default:
+		return xml.Name{}, false
+	}
+}
+
+func clark(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+func isXSIName(name xml.Name) bool {
+	return name.Space == vocab.XSINamespaceURI
+}
+
+func invalidJSON(path, msg string) error {
+	return xsderrors.Validation(xsderrors.CodeValidationJSON, 0, 0, path, msg)
+}
+
+func missingComponent(kind string) error {
+	return xsderrors.InternalInvariant("JSON conversion references missing " + kind)
+}
@@ -0,0 +1,888 @@
+package jsonconv
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/regex"
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+// dialect is the meta-schema of the documents Schema writes.
+const dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Loss is a schema construct that the JSON Schema written by Schema does not
+// express, or expresses more loosely than the XML schema does.
+type Loss struct {
+	// Component is the Clark name of the global declaration or definition,
+	// followed by the keys of nested local declarations.
+	Component string
+	Construct string
+	Detail    string
+}
+
+// Schema returns a JSON Schema 2020-12 document that describes the JSON form
+// ToJSON writes for the documents rt validates, and the constructs it leaves
+// out.
+//
+// Named types and global elements are definitions in $defs. Extensions of
+// named complex types and restrictions of named simple types reference their
+// base through allOf and add what they change. Objects are closed with
+// unevaluatedProperties where they are used, so definitions stay extensible.
+func Schema(rt *runtime.Schema) ([]byte, []Loss, error) {
+	c, err := newConverter(rt)
+	if err != nil {
+		return nil, nil, err
+	}
+	x := &exporter{
+		c:        c,
+		types:    make(map[runtime.TypeID]string),
+		elements: make(map[runtime.ElementID]string),
+		seen:     make(map[Loss]bool),
+	}
+	defs := x.allocate()
+	out := make(object, 0, len(defs))
+	for _, d := range defs {
+		var schema object
+		if d.elem != runtime.NoElement {
+			schema, err = x.element(d.elem, clark(d.name))
+		} else {
+			schema, err = x.definition(d.typ, clark(d.name))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		schema = append(object{{key: "title", value: clark(d.name)}}, schema...)
+		out = append(out, member{key: d.key, value: schema})
+	}
+	roots := x.roots()
+	doc := object{
+		{key: "$schema", value: dialect},
+		{key: "type", value: "object"},
+		{key: "properties", value: roots},
+		{key: "additionalProperties", value: literal("false")},
+		{key: "minProperties", value: literal("1")},
+		{key: "maxProperties", value: literal("1")},
+	}
+	if len(out) != 0 {
+		doc = append(doc, member{key: "$defs", value: out})
+	}
+	var compact, indented bytes.Buffer
+	writeValue(&compact, doc)
+	if err := json.Indent(&indented, compact.Bytes(), "", "  "); err != nil {
+		return nil, nil, err
+	}
+	indented.WriteByte('\n')
+	slices.SortStableFunc(x.losses, func(a, b Loss) int {
+		return cmp.Or(cmp.Compare(a.Component, b.Component), cmp.Compare(a.Construct, b.Construct))
+	})
+	return indented.Bytes(), x.losses, nil
+}
+
+// exporter builds the JSON Schema of one schema.
+type exporter struct {
+	c        *converter
+	types    map[runtime.TypeID]string
+	elements map[runtime.ElementID]string
+	seen     map[Loss]bool
+	losses   []Loss
+}
+
+// definition is one $defs entry: a named type or a global element.
+type definition struct {
+	name xml.Name
+	key  string
+	typ  runtime.TypeID
+	elem runtime.ElementID
+}
+
+// allocate names the $defs entries by local name. Elements whose local name
+// a type already takes get an "Element" suffix, and remaining clashes
+// between namespaces a numeric one.
+func (x *exporter) allocate() []definition {
+	var defs []definition
+	for qname, id := range x.c.rt.GlobalTypes() {
+		name := x.c.name(qname)
+		if name.Space == vocab.XSDNamespaceURI || strings.Contains(name.Local, "$") {
+			continue
+		}
+		defs = append(defs, definition{name: name, typ: id, elem: runtime.NoElement})
+	}
+	slices.SortFunc(defs, func(a, b definition) int { return compareNames(a.name, b.name) })
+	types := len(defs)
+	for name, id := range x.c.globals {
+		defs = append(defs, definition{name: name, elem: id})
+	}
+	slices.SortFunc(defs[types:], func(a, b definition) int { return compareNames(a.name, b.name) })
+	taken := make(map[string]bool, len(defs))
+	for i := range defs {
+		d := &defs[i]
+		base := d.name.Local
+		if taken[base] && d.elem != runtime.NoElement {
+			base += "Element"
+		}
+		d.key = base
+		for n := 2; taken[d.key]; n++ {
+			d.key = base + "-" + strconv.Itoa(n)
+		}
+		taken[d.key] = true
+		if d.elem != runtime.NoElement {
+			x.elements[d.elem] = d.key
+		} else {
+			x.types[d.typ] = d.key
+		}
+	}
+	slices.SortFunc(defs, func(a, b definition) int { return cmp.Compare(a.key, b.key) })
+	return defs
+}
+
+// roots returns the properties of the document object: one for each global
+// element that may be the document element.
+func (x *exporter) roots() object {
+	names := make([]xml.Name, 0, len(x.c.globals))
+	for name := range x.c.globals {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b xml.Name) int {
+		return cmp.Compare(x.c.roots.key(a), x.c.roots.key(b))
+	})
+	var out object
+	for _, name := range names {
+		id := x.c.globals[name]
+		if decl, ok := x.c.rt.ElementComponent(id); !ok || decl.Abstract {
+			continue
+		}
+		out = append(out, member{key: x.c.roots.key(name), value: ref(x.elements[id])})
+	}
+	if out == nil {
+		return object{}
+	}
+	return out
+}
+
+func (x *exporter) lose(component, construct, detail string) {
+	l := Loss{Component: component, Construct: construct, Detail: detail}
+	if x.seen[l] {
+		return
+	}
+	x.seen[l] = true
+	x.losses = append(x.losses, l)
+}
+
+// definition returns the $defs entry of named type t.
+func (x *exporter) definition(t runtime.TypeID, component string) (object, error) {
+	if id, ok := t.Simple(); ok {
+		return x.simple(id, component)
+	}
+	id, _ := t.Complex()
+	return x.complex(id, component)
+}
+
+// use returns the schema of a value of type t: a reference for named types,
+// closed when t maps to an object, or the inline schema of t.
+func (x *exporter) use(t runtime.TypeID, component string) (object, error) {
+	if t == x.c.rt.AnyType() {
+		return object{}, nil
+	}
+	var schema object
+	if name, ok := x.types[t]; ok {
+		schema = ref(name)
+	} else {
+		var err error
+		if schema, err = x.definition(t, component); err != nil {
+			return nil, err
+		}
+	}
+	id, ok := t.Complex()
+	if !ok {
+		return schema, nil
+	}
+	s, err := x.c.shape(id)
+	if err != nil {
+		return nil, err
+	}
+	if (s.text == runtime.NoSimpleType || s.object) && !s.wildcard {
+		schema = append(schema, member{key: "unevaluatedProperties", value: literal("false")})
+	}
+	return schema, nil
+}
+
+// element returns the schema of the elements declared by id.
+func (x *exporter) element(id runtime.ElementID, component string) (object, error) {
+	decl, ok := x.c.rt.ElementComponent(id)
+	if !ok {
+		return nil, missingComponent("element declaration")
+	}
+	for _, ic := range decl.Identity {
+		constraint, ok := x.c.rt.IdentityComponent(ic)
+		if !ok {
+			return nil, missingComponent("identity constraint")
+		}
+		x.lose(component, "identity constraint", identityKind(constraint.Kind)+" "+clark(x.c.name(constraint.Name))+" is not checked")
+	}
+	if x.c.rt.ElementTypeAlternatives(id) {
+		x.lose(component, "type alternative", "only the declared type is described")
+	}
+	schema, err := x.use(decl.Type, component)
+	if err != nil {
+		return nil, err
+	}
+	value, scalar, err := x.scalarType(decl.Type)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case decl.Fixed.Present && scalar:
+		schema = append(schema, member{key: "const", value: x.constant(value, decl.Fixed.Lexical)})
+	case decl.Default.Present && scalar:
+		schema = append(schema, member{key: "default", value: x.constant(value, decl.Default.Lexical)})
+	case decl.Fixed.Present:
+		x.lose(component, "fixed value", "the fixed value of the element's content is not checked")
+	}
+	if !decl.Nillable {
+		return schema, nil
+	}
+	if !scalar && value != runtime.NoSimpleType {
+		x.lose(component, "nillable", `nilled elements with attributes, whose "#value" is null, are not described`)
+	}
+	return object{{key: "anyOf", value: array{object{{key: "type", value: "null"}}, schema}}}, nil
+}
+
+// scalarType returns the simple type of the values of elements of type t
+// and whether they map to scalars rather than objects.
+func (x *exporter) scalarType(t runtime.TypeID) (runtime.SimpleTypeID, bool, error) {
+	if id, ok := t.Simple(); ok {
+		return id, true, nil
+	}
+	id, _ := t.Complex()
+	s, err := x.c.shape(id)
+	if err != nil {
+		return runtime.NoSimpleType, false, err
+	}
+	return s.text, s.text != runtime.NoSimpleType && !s.object, nil
+}
+
+// constant returns the JSON value of a fixed or default value.
+func (x *exporter) constant(typ runtime.SimpleTypeID, lexical string) any {
+	if v, ok := x.c.typed(typ, lexical); ok {
+		return v
+	}
+	return lexical
+}
+
+// complex returns the schema of complex type id.
+func (x *exporter) complex(id runtime.ComplexTypeID, component string) (object, error) {
+	ct, ok := x.c.rt.ComplexTypeComponent(id)
+	if !ok {
+		return nil, missingComponent("complex type")
+	}
+	s, err := x.c.shape(id)
+	if err != nil {
+		return nil, err
+	}
+	if x.c.rt.ComplexAssertions(runtime.ComplexRef(id)) {
+		x.lose(component, "assertion", "xs:assert conditions are not checked")
+	}
+	if s.text != runtime.NoSimpleType && !s.object {
+		return x.use(runtime.SimpleRef(s.text), component)
+	}
+	var all array
+	base, err := x.base(ct)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		all = append(all, ref(x.types[ct.Base]))
+	}
+	props, required, err := x.attributes(ct, s, base, component)
+	if err != nil {
+		return nil, err
+	}
+	if s.text != runtime.NoSimpleType && base == nil {
+		value, err := x.use(runtime.SimpleRef(s.text), component)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, member{key: valueKey, value: value})
+		required = append(required, valueKey)
+	}
+	if s.mixed && (base == nil || !base.mixed) {
+		props = append(props, member{key: textKey, value: object{{key: "type", value: "string"}}})
+		x.lose(component, "mixed content", `character data is one "#text" string and its position among the child elements is lost`)
+	}
+	if s.text == runtime.NoSimpleType {
+		children, err := x.children(s, base, component)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, children...)
+		model, ok := x.c.rt.ContentModelComponent(ct.Content)
+		if !ok {
+			return nil, missingComponent("content model")
+		}
+		constraint, err := x.model(s, model, false, component)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range constraint {
+			if m.key == "required" {
+				keys, _ := m.value.(array)
+				for _, key := range keys {
+					if name, _ := key.(string); base == nil || !base.hasElementKey(name) {
+						required = appendKey(required, name)
+					}
+				}
+				continue
+			}
+			all = append(all, object{m})
+		}
+		if s.wildcard {
+			x.lose(component, "element wildcard", "the object admits any member, since the elements a wildcard matches are not described")
+		}
+	}
+	var out object
+	if len(all) != 0 {
+		out = append(out, member{key: "allOf", value: all})
+	}
+	out = append(out, member{key: "type", value: "object"})
+	if props == nil {
+		props = object{}
+	}
+	out = append(out, member{key: "properties", value: props})
+	set, ok := x.c.rt.AttributeUseSetComponent(ct.Attrs)
+	if !ok {
+		return nil, missingComponent("attribute-use set")
+	}
+	if set.Wildcard != runtime.NoWildcard {
+		out = append(out, member{key: "patternProperties", value: object{{key: "^" + attributePrefix, value: literal("true")}}})
+		x.lose(component, "attribute wildcard", "the attributes the wildcard matches are not described")
+	}
+	if len(required) != 0 {
+		out = append(out, member{key: "required", value: required})
+	}
+	return out, nil
+}
+
+// base returns the shape of the base type of ct when ct extends a named
+// complex type that maps to an object, so ct's schema can reference it.
+func (x *exporter) base(ct runtime.ComplexTypeComponent) (*shape, error) {
+	if ct.Derivation != runtime.DerivationKindExtension || ct.Base == x.c.rt.AnyType() {
+		return nil, nil
+	}
+	id, ok := ct.Base.Complex()
+	if _, named := x.types[ct.Base]; !ok || !named {
+		return nil, nil
+	}
+	s, err := x.c.shape(id)
+	if err != nil || s.text != runtime.NoSimpleType && !s.object {
+		return nil, err
+	}
+	return s, nil
+}
+
+// attributes returns the attribute properties of ct that its base does not
+// declare, and the keys of those that are required.
+func (x *exporter) attributes(ct runtime.ComplexTypeComponent, s, base *shape, component string) (object, array, error) {
+	set, ok := x.c.rt.AttributeUseSetComponent(ct.Attrs)
+	if !ok {
+		return nil, nil, missingComponent("attribute-use set")
+	}
+	var props object
+	var required array
+	for _, use := range set.Uses {
+		name := x.c.name(use.Name)
+		if use.Prohibited || base != nil && slices.Contains(base.attributes[name.Local], name) {
+			continue
+		}
+		key := attributePrefix + s.attributes.key(name)
+		schema, err := x.use(runtime.SimpleRef(use.Type), component+"/"+key)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case use.Fixed.Present:
+			schema = append(schema, member{key: "const", value: x.constant(use.Type, use.Fixed.Lexical)})
+		case use.Default.Present:
+			schema = append(schema, member{key: "default", value: x.constant(use.Type, use.Default.Lexical)})
+		}
+		props = append(props, member{key: key, value: schema})
+		if use.Required {
+			required = append(required, key)
+		}
+	}
+	return props, required, nil
+}
+
+// children returns the child element properties of s that its base does
+// not declare, in particle order. Children that repeat are arrays, bounded
+// by the particle's occurrence when that alone bounds their number.
+func (x *exporter) children(s, base *shape, component string) (object, error) {
+	names := make([]xml.Name, 0, len(s.children))
+	for name := range s.children {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b xml.Name) int { return cmp.Compare(s.order(a), s.order(b)) })
+	var props object
+	for _, name := range names {
+		ch := s.children[name]
+		if base != nil {
+			if _, ok := base.children[name]; ok {
+				continue
+			}
+		}
+		decl, ok := x.c.rt.ElementComponent(ch.elem)
+		if !ok {
+			return nil, missingComponent("element declaration")
+		}
+		if decl.Abstract {
+			continue
+		}
+		key := s.elements.key(name)
+		var schema object
+		if def, ok := x.elements[ch.elem]; ok {
+			schema = ref(def)
+		} else {
+			var err error
+			if schema, err = x.element(ch.elem, component+"/"+key); err != nil {
+				return nil, err
+			}
+		}
+		if ch.repeated {
+			items := object{{key: "type", value: "array"}, {key: "items", value: schema}}
+			if ch.exact && !s.wildcard {
+				if ch.occurs.Min > 1 {
+					items = append(items, member{key: "minItems", value: literal(strconv.FormatUint(uint64(ch.occurs.Min), 10))})
+				}
+				if !ch.occurs.Unbounded {
+					items = append(items, member{key: "maxItems", value: literal(strconv.FormatUint(uint64(ch.occurs.Max), 10))})
+				}
+			}
+			schema = items
+		}
+		props = append(props, member{key: key, value: schema})
+	}
+	return props, nil
+}
+
+// hasElementKey reports whether key names a child element of s.
+func (s *shape) hasElementKey(key string) bool {
+	name, ok := s.elements.name(key)
+	if !ok {
+		return false
+	}
+	_, ok = s.children[name]
+	return ok
+}
+
+// model returns the constraints model places on the members of an object:
+// the keys its required particles need, with a choice requiring one of its
+// branches and, unless it repeats, forbidding the keys of the others.
+// Repeated is set when an enclosing particle may occur more than once.
+func (x *exporter) model(s *shape, model runtime.ContentModel, repeated bool, component string) (object, error) {
+	repeated = repeated || repeats(model.Occurs)
+	switch model.Kind {
+	case runtime.ModelSequence, runtime.ModelAll:
+		var required, all array
+		for _, p := range model.Particles {
+			if p.Occurs.Min == 0 {
+				continue
+			}
+			constraint, err := x.particle(s, p, repeated, component)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range constraint {
+				if m.key != "required" {
+					all = append(all, object{m})
+					continue
+				}
+				keys, _ := m.value.(array)
+				for _, key := range keys {
+					name, _ := key.(string)
+					required = appendKey(required, name)
+				}
+			}
+		}
+		var out object
+		if len(required) != 0 {
+			out = append(out, member{key: "required", value: required})
+		}
+		if len(all) != 0 {
+			out = append(out, member{key: "allOf", value: all})
+		}
+		return out, nil
+	case runtime.ModelChoice:
+		branches := make(array, 0, len(model.Particles))
+		keys := make([][]string, len(model.Particles))
+		for i, p := range model.Particles {
+			var constraint object
+			if p.Occurs.Min != 0 {
+				var err error
+				if constraint, err = x.particle(s, p, repeated, component); err != nil {
+					return nil, err
+				}
+			}
+			if constraint == nil {
+				constraint = object{}
+			}
+			keys[i] = x.particleKeys(s, p, nil)
+			branches = append(branches, constraint)
+		}
+		if !repeated {
+			for i := range branches {
+				var others []string
+				for j, k := range keys {
+					for _, key := range k {
+						if j != i && !slices.Contains(keys[i], key) && !slices.Contains(others, key) {
+							others = append(others, key)
+						}
+					}
+				}
+				if len(others) == 0 {
+					continue
+				}
+				forbidden := object{{key: "required", value: array{others[0]}}}
+				if len(others) > 1 {
+					variants := make(array, 0, len(others))
+					for _, key := range others {
+						variants = append(variants, object{{key: "required", value: array{key}}})
+					}
+					forbidden = object{{key: "anyOf", value: variants}}
+				}
+				branch, _ := branches[i].(object)
+				branches[i] = append(branch, member{key: "not", value: forbidden})
+			}
+		}
+		return object{{key: "anyOf", value: branches}}, nil
+	}
+	return nil, nil
+}
+
+// particle returns the constraint a particle with a minOccurs of at least
+// one places on the members of an object.
+func (x *exporter) particle(s *shape, p runtime.Particle, repeated bool, component string) (object, error) {
+	switch p.Kind {
+	case runtime.ParticleElement:
+		keys := x.particleKeys(s, p, nil)
+		switch len(keys) {
+		case 0:
+			return nil, nil
+		case 1:
+			return object{{key: "required", value: array{keys[0]}}}, nil
+		}
+		branches := make(array, 0, len(keys))
+		for _, key := range keys {
+			branches = append(branches, object{{key: "required", value: array{key}}})
+		}
+		return object{{key: "anyOf", value: branches}}, nil
+	case runtime.ParticleModel:
+		sub, ok := x.c.rt.ContentModelComponent(p.Model)
+		if !ok {
+			return nil, missingComponent("content model")
+		}
+		if repeats(p.Occurs) || repeats(sub.Occurs) {
+			x.lose(component, "group occurrence", "the occurrence bounds of a repeating group are not checked")
+		}
+		return x.model(s, sub, repeated || repeats(p.Occurs), component)
+	}
+	return nil, nil
+}
+
+// particleKeys appends the keys of the elements particle p matches to keys.
+func (x *exporter) particleKeys(s *shape, p runtime.Particle, keys []string) []string {
+	switch p.Kind {
+	case runtime.ParticleElement:
+		for _, id := range append([]runtime.ElementID{p.Element}, x.c.rt.SubstitutionMembers(p.Element)...) {
+			decl, ok := x.c.rt.ElementComponent(id)
+			if !ok || decl.Abstract {
+				continue
+			}
+			if key := s.elements.key(x.c.name(decl.Name)); !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	case runtime.ParticleModel:
+		sub, ok := x.c.rt.ContentModelComponent(p.Model)
+		if !ok {
+			return keys
+		}
+		for _, q := range sub.Particles {
+			keys = x.particleKeys(s, q, keys)
+		}
+	}
+	return keys
+}
+
+// simple returns the schema of simple type id. Restrictions of named types
+// reference their base and add the facets they change; other types carry
+// all the facets in effect.
+func (x *exporter) simple(id runtime.SimpleTypeID, component string) (object, error) {
+	st, ok := x.c.rt.SimpleTypeComponent(id)
+	if !ok {
+		return nil, missingComponent("simple type")
+	}
+	if x.c.rt.SimpleAssertions(id) {
+		x.lose(component, "assertion", "xs:assertion facets are not checked")
+	}
+	var out object
+	var all array
+	var inherited runtime.FacetComponent
+	if name, named := x.types[runtime.SimpleRef(st.Base)]; named && st.Base != runtime.NoSimpleType {
+		all = append(all, ref(name))
+		if bt, ok := x.c.rt.SimpleTypeComponent(st.Base); ok {
+			inherited = bt.Facets
+		}
+	} else {
+		switch st.Variety {
+		case runtime.SimpleVarietyList:
+			item, err := x.use(runtime.SimpleRef(st.ListItem), component)
+			if err != nil {
+				return nil, err
+			}
+			out = object{{key: "type", value: "array"}, {key: "items", value: item}}
+		case runtime.SimpleVarietyUnion:
+			members := x.c.unionMembers(id)
+			variants := make(array, 0, len(members))
+			for _, m := range members {
+				schema, err := x.use(runtime.SimpleRef(m), component)
+				if err != nil {
+					return nil, err
+				}
+				variants = append(variants, schema)
+			}
+			out = object{{key: "anyOf", value: variants}}
+		default:
+			out = atomic(st)
+		}
+	}
+	facets, extra := x.facets(id, st, inherited, all != nil, component)
+	all = append(all, extra...)
+	if len(all) != 0 {
+		out = append(object{{key: "allOf", value: all}}, out...)
+	}
+	return append(out, facets...), nil
+}
+
+// atomic returns the JSON type of the values of atomic type st.
+func atomic(st runtime.SimpleTypeComponent) object {
+	switch st.Primitive {
+	case runtime.PrimitiveBoolean:
+		return object{{key: "type", value: "boolean"}}
+	case runtime.PrimitiveDecimal:
+		if st.Facets.Present&runtime.FacetFractionDigits != 0 && st.Facets.FractionDigits == 0 {
+			return object{{key: "type", value: "integer"}}
+		}
+		return object{{key: "type", value: "number"}}
+	case runtime.PrimitiveFloat, runtime.PrimitiveDouble:
+		return object{{key: "anyOf", value: array{
+			object{{key: "type", value: "number"}},
+			object{{key: "enum", value: array{"INF", "-INF", "NaN"}}},
+		}}}
+	}
+	return object{{key: "type", value: "string"}}
+}
+
+// facets returns the keywords for the facets of st that differ from
+// inherited, and the subschemas that must be combined with allOf. Derived
+// is set when st's schema references its base rather than stating its type.
+func (x *exporter) facets(id runtime.SimpleTypeID, st runtime.SimpleTypeComponent, inherited runtime.FacetComponent, derived bool, component string) (object, array) {
+	f := st.Facets
+	changed := func(bit runtime.FacetMask, same bool) bool {
+		return f.Present&bit != 0 && (inherited.Present&bit == 0 || !same)
+	}
+	var out object
+	var all array
+	list := st.Variety == runtime.SimpleVarietyList
+	minLength, maxLength := "minLength", "maxLength"
+	if list {
+		minLength, maxLength = "minItems", "maxItems"
+	}
+	binary := !list && (st.Primitive == runtime.PrimitiveHexBinary || st.Primitive == runtime.PrimitiveBase64Binary)
+	lengths := []struct {
+		keys  []string
+		name  string
+		value uint32
+		same  bool
+		bit   runtime.FacetMask
+	}{
+		{keys: []string{minLength, maxLength}, name: "length", value: f.Length, same: f.Length == inherited.Length, bit: runtime.FacetLength},
+		{keys: []string{minLength}, name: "minLength", value: f.MinLength, same: f.MinLength == inherited.MinLength, bit: runtime.FacetMinLength},
+		{keys: []string{maxLength}, name: "maxLength", value: f.MaxLength, same: f.MaxLength == inherited.MaxLength, bit: runtime.FacetMaxLength},
+	}
+	for _, l := range lengths {
+		if !changed(l.bit, l.same) {
+			continue
+		}
+		if binary {
+			x.lose(component, l.name, "lengths of binary types count octets, not characters, and are not checked")
+			continue
+		}
+		for _, key := range l.keys {
+			out = append(out, member{key: key, value: literal(strconv.FormatUint(uint64(l.value), 10))})
+		}
+	}
+	kind := kindOf(st)
+	if len(f.Patterns) > len(inherited.Patterns) {
+		for _, group := range f.Patterns[len(inherited.Patterns):] {
+			if kind != kindString {
+				x.lose(component, "pattern", "patterns apply to the lexical form of values that are not JSON strings and are not checked")
+				break
+			}
+			variants := make(array, 0, len(group))
+			for _, source := range group {
+				re, err := regex.Compile(source)
+				if err != nil {
+					x.lose(component, "pattern", "pattern "+strconv.Quote(source)+" cannot be translated")
+					variants = nil
+					break
+				}
+				variants = append(variants, object{{key: "pattern", value: re.ECMAScript()}})
+			}
+			switch len(variants) {
+			case 0:
+			case 1:
+				all = append(all, variants[0])
+			default:
+				all = append(all, object{{key: "anyOf", value: variants}})
+			}
+		}
+		if len(all) == 1 && !derived {
+			pattern, _ := all[0].(object)
+			if len(pattern) == 1 && pattern[0].key == "pattern" {
+				out = append(out, pattern[0])
+				all = nil
+			}
+		}
+	}
+	if changed(runtime.FacetEnumeration, slices.Equal(f.Enumeration, inherited.Enumeration)) {
+		values := make(array, 0, len(f.Enumeration))
+		for _, v := range f.Enumeration {
+			values = append(values, x.constant(id, v))
+		}
+		out = append(out, member{key: "enum", value: values})
+		if st.Primitive == runtime.PrimitiveQName || st.Primitive == runtime.PrimitiveNotation {
+			x.lose(component, "enumeration", "QName values compare by expanded name, so prefixes other than the schema's are not accepted")
+		}
+	}
+	bounds := []struct {
+		key   string
+		name  string
+		value string
+		same  bool
+		bit   runtime.FacetMask
+	}{
+		{key: "minimum", name: "minInclusive", value: f.MinInclusive, same: f.MinInclusive == inherited.MinInclusive, bit: runtime.FacetMinInclusive},
+		{key: "maximum", name: "maxInclusive", value: f.MaxInclusive, same: f.MaxInclusive == inherited.MaxInclusive, bit: runtime.FacetMaxInclusive},
+		{key: "exclusiveMinimum", name: "minExclusive", value: f.MinExclusive, same: f.MinExclusive == inherited.MinExclusive, bit: runtime.FacetMinExclusive},
+		{key: "exclusiveMaximum", name: "maxExclusive", value: f.MaxExclusive, same: f.MaxExclusive == inherited.MaxExclusive, bit: runtime.FacetMaxExclusive},
+	}
+	for _, b := range bounds {
+		if !changed(b.bit, b.same) {
+			continue
+		}
+		if kind != kindNumber {
+			x.lose(component, b.name, "ranges of non-numeric values are not checked")
+			continue
+		}
+		if n, ok := jsonNumber(b.value); ok {
+			out = append(out, member{key: b.key, value: n})
+		}
+	}
+	if changed(runtime.FacetTotalDigits, f.TotalDigits == inherited.TotalDigits) {
+		x.lose(component, "totalDigits", "the number of digits is not checked")
+	}
+	if changed(runtime.FacetFractionDigits, f.FractionDigits == inherited.FractionDigits) {
+		switch {
+		case f.FractionDigits != 0:
+			out = append(out, member{key: "multipleOf", value: literal("0." + strings.Repeat("0", int(f.FractionDigits)-1) + "1")})
+		case derived:
+			out = append(out, member{key: "type", value: "integer"})
+		}
+	}
+	if changed(runtime.FacetExplicitTimezone, f.ExplicitTimezone == inherited.ExplicitTimezone) && f.ExplicitTimezone != runtime.ExplicitTimezoneOptional {
+		x.lose(component, "explicitTimezone", "whether values carry a timezone is not checked")
+	}
+	return out, all
+}
+
+// valueKind classifies the JSON values of a simple type.
+type valueKind uint8
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindOther
+)
+
+func kindOf(st runtime.SimpleTypeComponent) valueKind {
+	if st.Variety != runtime.SimpleVarietyAtomic {
+		return kindOther
+	}
+	switch st.Primitive {
+	case runtime.PrimitiveDecimal, runtime.PrimitiveFloat, runtime.PrimitiveDouble:
+		return kindNumber
+	case runtime.PrimitiveBoolean:
+		return kindOther
+	}
+	return kindString
+}
+
+// jsonNumber returns the JSON number for a decimal, float or double lexical
+// value, reporting false for the special float values.
+func jsonNumber(lexical string) (literal, bool) {
+	s := strings.TrimPrefix(lexical, "+")
+	sign := ""
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = "-", rest
+	}
+	mantissa, exponent, scientific := strings.Cut(strings.ToLower(s), "e")
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	whole = strings.TrimLeft(whole, "0")
+	if whole == "" {
+		whole = "0"
+	}
+	out := sign + whole
+	if fraction = strings.TrimRight(fraction, "0"); fraction != "" {
+		out += "." + fraction
+	}
+	if scientific {
+		exponent = strings.TrimPrefix(exponent, "+")
+		out += "e" + exponent
+	}
+	if _, err := strconv.ParseFloat(out, 64); err != nil || !json.Valid([]byte(out)) {
+		return "", false
+	}
+	return literal(out), true
+}
+
+// appendKey appends key to keys unless it is already there.
+func appendKey(keys array, key string) array {
+	if slices.Contains(keys, any(key)) {
+		return keys
+	}
+	return append(keys, key)
+}
+
+func ref(name string) object {
+	return object{{key: "$ref", value: "#/$defs/" + name}}
+}
+
+func compareNames(a, b xml.Name) int {
+	return cmp.Or(cmp.Compare(a.Local, b.Local), cmp.Compare(a.Space, b.Space))
+}
+
+func identityKind(kind runtime.IdentityKind) string {
+	switch kind {
+	case runtime.IdentityKey:
+		return "key"
+	case runtime.IdentityKeyRef:
+		return "keyref"
+	}
+	return "unique"
+}
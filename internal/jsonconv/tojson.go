@@ -0,0 +1,216 @@
+package jsonconv
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/lex"
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/validate"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// ToJSON validates the document read from r with opts and writes its JSON
+// form to w. The form is built from the validation events as the document
+// streams and held until the document is known to be valid, since a member
+// collects its repeated elements wherever they occur; its retained names and
+// values count against the instance byte limit. Documents that fail
+// validation return the validation error and write nothing.
+func ToJSON(ctx context.Context, rt *runtime.Schema, w io.Writer, r io.Reader, opts validate.Options) error {
+	c, err := newConverter(rt)
+	if err != nil {
+		return err
+	}
+	limits, err := validate.NormalizeOptions(opts)
+	if err != nil {
+		return err
+	}
+	b := &builder{c: c, maxBytes: limits.InstanceBytes}
+	opts.Events = b.event
+	opts.EventText = true
+	if err := validate.Validate(ctx, rt, r, opts); err != nil {
+		return err
+	}
+	if b.root == nil {
+		return xsderrors.InternalInvariant("JSON conversion saw no document element")
+	}
+	out := bufio.NewWriter(w)
+	writeValue(out, object{*b.root})
+	out.WriteByte('\n')
+	return out.Flush()
+}
+
+// memberBytes is the bytes charged for the punctuation of one member.
+const memberBytes = 8
+
+// builder assembles the JSON value of each element from its events.
+type builder struct {
+	c     *converter
+	root  *member
+	stack []*element
+	// bytes estimates the size of the JSON form assembled so far; it may not
+	// exceed maxBytes.
+	bytes    int64
+	maxBytes int64
+}
+
+// charge counts n bytes of the JSON form retained for the element at line
+// and column.
+func (b *builder) charge(n, line, column int) error {
+	b.bytes += int64(n)
+	if b.bytes > b.maxBytes {
+		return xsderrors.Validation(xsderrors.CodeValidationLimit, line, column, "", "JSON form exceeds MaxInstanceBytes")
+	}
+	return nil
+}
+
+// element is an open element: its attributes, then its children in the
+// order their names first occur.
+type element struct {
+	shape   *shape
+	index   map[string]int
+	text    strings.Builder
+	members object
+	attrs   int
+}
+
+func (b *builder) event(ev validate.Event) error {
+	switch ev.Kind {
+	case validate.EventStartElement:
+		return b.start(ev)
+	case validate.EventEndElement:
+		return b.end(ev)
+	case validate.EventText:
+		if n := len(b.stack); n != 0 {
+			if err := b.charge(len(ev.Text), ev.Line, ev.Column); err != nil {
+				return err
+			}
+			b.stack[n-1].text.WriteString(ev.Text)
+		}
+	}
+	return nil
+}
+
+func (b *builder) start(ev validate.Event) error {
+	e := &element{index: make(map[string]int)}
+	if id, ok := ev.TypeID.Complex(); ok && ev.Assessed {
+		s, err := b.c.shape(id)
+		if err != nil {
+			return err
+		}
+		e.shape = s
+	}
+	var names keys
+	if e.shape != nil {
+		names = e.shape.attributes
+	}
+	for _, a := range ev.Attributes {
+		if isXSIName(a.Name) {
+			continue
+		}
+		if err := b.charge(memberBytes+len(a.Name.Space)+len(a.Name.Local)+len(a.Value), ev.Line, ev.Column); err != nil {
+			return err
+		}
+		e.members = append(e.members, member{
+			key:   attributePrefix + names.key(a.Name),
+			value: b.c.scalar(a.TypeID, a.Value, a.Canonical),
+		})
+	}
+	e.attrs = len(e.members)
+	b.stack = append(b.stack, e)
+	return nil
+}
+
+func (b *builder) end(ev validate.Event) error {
+	n := len(b.stack)
+	if n == 0 {
+		return xsderrors.InternalInvariant("JSON conversion saw an end event without a start")
+	}
+	e := b.stack[n-1]
+	b.stack = b.stack[:n-1]
+	if err := b.charge(memberBytes+len(ev.Name.Space)+len(ev.Name.Local)+len(ev.Value), ev.Line, ev.Column); err != nil {
+		return err
+	}
+	v := b.value(e, ev)
+	if n == 1 {
+		b.root = &member{key: b.c.roots.key(ev.Name), value: v}
+		return nil
+	}
+	b.stack[n-2].add(ev.Name, v)
+	return nil
+}
+
+// value returns the JSON value of e once its content is complete.
+// Character data that is only whitespace is dropped.
+func (b *builder) value(e *element, ev validate.Event) any {
+	text := e.text.String()
+	if lex.TrimXMLWhitespaceString(text) == "" {
+		text = ""
+	}
+	switch {
+	case ev.Nilled:
+		if e.attrs == 0 {
+			return null
+		}
+		return e.with(valueKey, null)
+	case !ev.Assessed:
+		if len(e.members) == 0 {
+			return text
+		}
+		if text != "" {
+			return e.with(textKey, text)
+		}
+	case ev.TypeID.IsSimple():
+		return b.c.scalar(ev.ValueType, ev.Value, ev.Canonical)
+	case e.shape.text != runtime.NoSimpleType:
+		v := b.c.scalar(ev.ValueType, ev.Value, ev.Canonical)
+		if !e.shape.object {
+			return v
+		}
+		return e.with(valueKey, v)
+	case e.shape.mixed && text != "":
+		return e.with(textKey, text)
+	}
+	if e.members == nil {
+		return object{}
+	}
+	return e.members
+}
+
+// with returns the members of e with key inserted after the attributes.
+func (e *element) with(key string, v any) object {
+	out := make(object, 0, len(e.members)+1)
+	out = append(out, e.members[:e.attrs]...)
+	out = append(out, member{key: key, value: v})
+	return append(out, e.members[e.attrs:]...)
+}
+
+// add records the value of a child element. Children the content model
+// lets repeat are collected in an array from the first; elements without a
+// governing type collect an array once a name repeats.
+func (e *element) add(name xml.Name, v any) {
+	var names keys
+	repeated := false
+	if e.shape != nil {
+		names = e.shape.elements
+		repeated = e.shape.repeated(name)
+	}
+	key := names.key(name)
+	i, ok := e.index[key]
+	switch {
+	case !ok:
+		e.index[key] = len(e.members)
+		if repeated {
+			v = array{v}
+		}
+		e.members = append(e.members, member{key: key, value: v, group: repeated})
+	case e.members[i].group:
+		items, _ := e.members[i].value.(array)
+		e.members[i].value = append(items, v)
+	default:
+		e.members[i] = member{key: key, value: array{e.members[i].value, v}, group: true}
+	}
+}
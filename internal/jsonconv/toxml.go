@@ -0,0 +1,397 @@
+package jsonconv
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+// maxDepth bounds the nesting of JSON documents read by ToXML.
+const maxDepth = 10000
+
+// ToXML returns the XML document for the JSON document read from r, reversing
+// the mapping of ToJSON. Children are written in the order of the particles
+// that declare them and every namespace is declared on the document element.
+// The document is not validated.
+func ToXML(rt *runtime.Schema, r io.Reader) ([]byte, error) {
+	c, err := newConverter(rt)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	v, err := readValue(dec, 0)
+	if err != nil {
+		return nil, malformed(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, invalidJSON("", "JSON document has data after its value")
+	}
+	root, ok := v.(object)
+	if !ok || len(root) != 1 {
+		return nil, invalidJSON("", "JSON document must be an object with one member named after the document element")
+	}
+	name, ok := c.roots.name(root[0].key)
+	if !ok {
+		return nil, invalidJSON("", "member "+strconv.Quote(root[0].key)+" does not name an element")
+	}
+	elem, ok := c.globals[name]
+	if !ok {
+		elem = runtime.NoElement
+	}
+	n, err := c.node(name, elem, root[0].value, "/"+name.Local)
+	if err != nil {
+		return nil, err
+	}
+	var w writer
+	return w.write(n), nil
+}
+
+func readValue(dec *json.Decoder, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("nesting exceeds the supported depth")
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			obj := object{}
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := tok.(string)
+				if !ok {
+					return nil, errors.New("object key is not a string")
+				}
+				v, err := readValue(dec, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, member{key: key, value: v})
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			arr := array{}
+			for dec.More() {
+				v, err := readValue(dec, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+	case string:
+		return tok, nil
+	case json.Number:
+		return literal(tok), nil
+	case bool:
+		return literal(strconv.FormatBool(tok)), nil
+	case nil:
+		return null, nil
+	}
+	return nil, errors.New("unexpected token")
+}
+
+// node is one element of the document ToXML writes.
+type node struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*node
+}
+
+// pending is the value of one child member, before its elements are built.
+type pending struct {
+	value any
+	name  xml.Name
+}
+
+// node builds the element named name from v. Elem is its declaration, or
+// NoElement when it has none.
+func (c *converter) node(name xml.Name, elem runtime.ElementID, v any, path string) (*node, error) {
+	n := &node{name: name}
+	var s *shape
+	if elem != runtime.NoElement {
+		decl, ok := c.rt.ElementComponent(elem)
+		if !ok {
+			return nil, missingComponent("element declaration")
+		}
+		if id, ok := decl.Type.Complex(); ok {
+			var err error
+			if s, err = c.shape(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	obj, ok := v.(object)
+	if !ok {
+		if v == null {
+			n.attrs = append(n.attrs, nilAttr())
+			return n, nil
+		}
+		text, err := simpleText(v, path)
+		n.text = text
+		return n, err
+	}
+	var elements, attributes keys
+	if s != nil {
+		elements, attributes = s.elements, s.attributes
+	}
+	var children []pending
+	for _, m := range obj {
+		switch {
+		case strings.HasPrefix(m.key, attributePrefix):
+			attrName, ok := attributes.name(m.key[len(attributePrefix):])
+			if !ok {
+				return nil, invalidJSON(path, "member "+strconv.Quote(m.key)+" does not name an attribute")
+			}
+			text, err := simpleText(m.value, path)
+			if err != nil {
+				return nil, err
+			}
+			n.attrs = append(n.attrs, xml.Attr{Name: attrName, Value: text})
+		case m.key == valueKey:
+			if m.value == null {
+				n.attrs = append(n.attrs, nilAttr())
+				continue
+			}
+			text, err := simpleText(m.value, path)
+			if err != nil {
+				return nil, err
+			}
+			n.text = text
+		case m.key == textKey:
+			text, ok := m.value.(string)
+			if !ok {
+				return nil, invalidJSON(path, `member "#text" must be a string`)
+			}
+			n.text = text
+		default:
+			childName, ok := elements.name(m.key)
+			if !ok {
+				return nil, invalidJSON(path, "member "+strconv.Quote(m.key)+" does not name an element")
+			}
+			children = append(children, pending{name: childName, value: m.value})
+		}
+	}
+	if s != nil {
+		if names := s.interleaved(children); names != nil {
+			quoted := make([]string, len(names))
+			for i, name := range names {
+				quoted[i] = strconv.Quote(elements.key(name))
+			}
+			return nil, invalidJSON(path, "members "+strings.Join(quoted, ", ")+
+				" belong to one repeated sequence and hold several elements, whose order cannot be recovered from JSON")
+		}
+		slices.SortStableFunc(children, func(a, b pending) int {
+			return cmp.Compare(s.order(a.name), s.order(b.name))
+		})
+	}
+	for _, ch := range children {
+		items := array{ch.value}
+		if arr, ok := ch.value.(array); ok && (s == nil || s.repeated(ch.name)) {
+			items = arr
+		}
+		for _, item := range items {
+			child, err := c.node(ch.name, c.childElement(s, ch.name), item, path+"/"+ch.name.Local)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+	}
+	return n, nil
+}
+
+// interleaved returns the names of children that belong to one repeated
+// sequence when more than one of them is present and one holds several
+// elements. Writing them grouped by name would not restore the order the
+// sequence requires, as (a, b)* read back as a, a, b, b.
+func (s *shape) interleaved(children []pending) []xml.Name {
+	type members struct {
+		names   []xml.Name
+		several bool
+	}
+	sequences := make(map[int]*members)
+	for _, ch := range children {
+		sequence := s.children[ch.name].sequence
+		if sequence == 0 {
+			continue
+		}
+		count := 1
+		if arr, ok := ch.value.(array); ok && s.repeated(ch.name) {
+			count = len(arr)
+		}
+		if count == 0 {
+			continue
+		}
+		m := sequences[sequence]
+		if m == nil {
+			m = &members{}
+			sequences[sequence] = m
+		}
+		m.names = append(m.names, ch.name)
+		m.several = m.several || count > 1
+		if len(m.names) > 1 && m.several {
+			return m.names
+		}
+	}
+	return nil
+}
+
+// childElement returns the declaration of the child elements named name:
+// the particle's, or for names a wildcard admits, the global declaration.
+func (c *converter) childElement(s *shape, name xml.Name) runtime.ElementID {
+	if s != nil {
+		if ch, ok := s.children[name]; ok {
+			return ch.elem
+		}
+	}
+	if id, ok := c.globals[name]; ok {
+		return id
+	}
+	return runtime.NoElement
+}
+
+// simpleText returns the lexical form of a scalar, or of a list written as
+// an array of scalars.
+func simpleText(v any, path string) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case literal:
+		if v != null {
+			return string(v), nil
+		}
+	case array:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.(array); ok {
+				return "", invalidJSON(path, "list items must be strings, numbers or booleans")
+			}
+			text, err := simpleText(item, path)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, " "), nil
+	}
+	return "", invalidJSON(path, "value must be a string, number, boolean or array of them")
+}
+
+func nilAttr() xml.Attr {
+	return xml.Attr{Name: xml.Name{Space: vocab.XSINamespaceURI, Local: "nil"}, Value: "true"}
+}
+
+func malformed(err error) error {
+	msg := "JSON document is malformed"
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		msg += ": " + err.Error()
+	}
+	return invalidJSON("", msg)
+}
+
+// writer serializes a node tree with every namespace declared on the
+// document element, using xsi for the schema instance namespace and
+// generated ns1, ns2, ... prefixes for the others.
+type writer struct {
+	prefixes map[string]string
+	decls    []xml.Attr
+	nsCount  int
+}
+
+func (w *writer) write(n *node) []byte {
+	w.prefixes = map[string]string{"": "", vocab.XMLNamespaceURI: "xml"}
+	w.collect(n)
+	var b bytes.Buffer
+	w.element(&b, n, true)
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+func (w *writer) collect(n *node) {
+	w.declare(n.name.Space)
+	for _, a := range n.attrs {
+		w.declare(a.Name.Space)
+	}
+	for _, child := range n.children {
+		w.collect(child)
+	}
+}
+
+func (w *writer) declare(ns string) {
+	if _, ok := w.prefixes[ns]; ok {
+		return
+	}
+	prefix := "xsi"
+	if ns != vocab.XSINamespaceURI {
+		w.nsCount++
+		prefix = "ns" + strconv.Itoa(w.nsCount)
+	}
+	w.prefixes[ns] = prefix
+	w.decls = append(w.decls, xml.Attr{Name: xml.Name{Local: prefix}, Value: ns})
+}
+
+func (w *writer) qualified(name xml.Name) string {
+	if prefix := w.prefixes[name.Space]; prefix != "" {
+		return prefix + ":" + name.Local
+	}
+	return name.Local
+}
+
+func (w *writer) element(b *bytes.Buffer, n *node, root bool) {
+	b.WriteByte('<')
+	b.WriteString(w.qualified(n.name))
+	if root {
+		for _, decl := range w.decls {
+			writeAttr(b, "xmlns:"+decl.Name.Local, decl.Value)
+		}
+	}
+	for _, a := range n.attrs {
+		writeAttr(b, w.qualified(a.Name), a.Value)
+	}
+	if n.text == "" && len(n.children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteByte('>')
+	writeEscaped(b, n.text)
+	for _, child := range n.children {
+		w.element(b, child, false)
+	}
+	b.WriteString("</")
+	b.WriteString(w.qualified(n.name))
+	b.WriteByte('>')
+}
+
+func writeAttr(b *bytes.Buffer, name, value string) {
+	b.WriteByte(' ')
+	b.WriteString(name)
+	b.WriteString(`="`)
+	writeEscaped(b, value)
+	b.WriteByte('"')
+}
+
+func writeEscaped(b *bytes.Buffer, s string) {
+	// EscapeText only fails when the writer does.
+	_ = xml.EscapeText(b, []byte(s))
+}
@@ -0,0 +1,177 @@
+package jsonconv
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/lex"
+	"github.com/jacoelho/xsd/internal/runtime"
+)
+
+// A JSON value is a string, a literal, an array or an object.
+
+// literal is a JSON number, boolean or null, written as is.
+type literal string
+
+const null literal = "null"
+
+// array is a JSON array.
+type array []any
+
+// object is a JSON object with its members in order.
+type object []member
+
+// member is one object member. Group is set for arrays that collect
+// repeated elements, as opposed to arrays that hold a list value.
+type member struct {
+	value any
+	key   string
+	group bool
+}
+
+// scalar returns the JSON value of a simple value of type typ. Booleans and
+// numbers map to their canonical form, except the special float values,
+// which stay strings. Lists map to arrays of their items and unions to the
+// value of the first member type that accepts them. Other values keep their
+// normalized form.
+func (c *converter) scalar(typ runtime.SimpleTypeID, normalized, canonical string) any {
+	if typ == runtime.NoSimpleType {
+		return normalized
+	}
+	st, ok := c.rt.SimpleTypeComponent(typ)
+	if !ok {
+		return normalized
+	}
+	switch st.Variety {
+	case runtime.SimpleVarietyList:
+		var out array
+		for item := range lex.XMLFieldsSeq(normalized) {
+			v, ok := c.typed(st.ListItem, item)
+			if !ok {
+				v = item
+			}
+			out = append(out, v)
+		}
+		return out
+	case runtime.SimpleVarietyUnion:
+		for _, member := range c.unionMembers(typ) {
+			if v, ok := c.typed(member, normalized); ok {
+				return v
+			}
+		}
+		return normalized
+	}
+	switch st.Primitive {
+	case runtime.PrimitiveBoolean, runtime.PrimitiveDecimal:
+		if canonical != "" {
+			return literal(canonical)
+		}
+	case runtime.PrimitiveFloat, runtime.PrimitiveDouble:
+		switch canonical {
+		case "", "INF", "-INF", "NaN":
+		default:
+			return literal(canonical)
+		}
+	}
+	return normalized
+}
+
+// typed returns the JSON value of lexical as a value of typ, reporting false
+// when typ rejects it.
+func (c *converter) typed(typ runtime.SimpleTypeID, lexical string) (any, bool) {
+	v, err := c.rt.ValidateSimpleValue(typ, lexical, unresolvedQName, runtime.SimpleNeedCanonical)
+	if err != nil {
+		return nil, false
+	}
+	return c.scalar(typ, c.rt.NormalizeSimpleValue(typ, lexical), v.CanonicalText()), true
+}
+
+// unionMembers returns the member types of union id. Restrictions of a
+// union share the members of their base.
+func (c *converter) unionMembers(id runtime.SimpleTypeID) []runtime.SimpleTypeID {
+	for id != runtime.NoSimpleType {
+		st, ok := c.rt.SimpleTypeComponent(id)
+		if !ok {
+			return nil
+		}
+		if len(st.Members) != 0 {
+			return st.Members
+		}
+		id = st.Base
+	}
+	return nil
+}
+
+// unresolvedQName accepts QName values with any prefix, in no namespace.
+// Only the choice of a union member depends on it.
+func unresolvedQName(lexical string) (string, string, bool) {
+	_, local, ok := strings.Cut(lexical, ":")
+	if !ok {
+		local = lexical
+	}
+	return "", local, true
+}
+
+// jsonWriter is where JSON values are written: a bytes.Buffer or a
+// bufio.Writer.
+type jsonWriter interface {
+	io.ByteWriter
+	io.StringWriter
+	WriteRune(r rune) (int, error)
+}
+
+func writeValue(b jsonWriter, v any) {
+	switch v := v.(type) {
+	case string:
+		writeString(b, v)
+	case literal:
+		b.WriteString(string(v))
+	case array:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i != 0 {
+				b.WriteByte(',')
+			}
+			writeValue(b, item)
+		}
+		b.WriteByte(']')
+	case object:
+		b.WriteByte('{')
+		for i, m := range v {
+			if i != 0 {
+				b.WriteByte(',')
+			}
+			writeString(b, m.key)
+			b.WriteByte(':')
+			writeValue(b, m.value)
+		}
+		b.WriteByte('}')
+	}
+}
+
+func writeString(b jsonWriter, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			b.WriteString(`\u00`)
+			if r < 0x10 {
+				b.WriteByte('0')
+			}
+			b.WriteString(strconv.FormatInt(int64(r), 16))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
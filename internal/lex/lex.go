@@ -214,7 +214,26 @@ func IsXMLChar(r rune) bool {
 		(r >= 0x10000 && r <= 0x10FFFF)
 }
 
-// IsXMLNameStartChar reports whether r can start an XML Name.
+// IsXML11Char reports whether r is an XML 1.1 character, which admits the
+// C0 controls other than NUL.
+func IsXML11Char(r rune) bool {
+	return (r >= 0x1 && r <= 0xD7FF) ||
+		(r >= 0xE000 && r <= 0xFFFD) ||
+		(r >= 0x10000 && r <= 0x10FFFF)
+}
+
+// IsXML11RestrictedChar reports whether r is an XML 1.1 RestrictedChar, which
+// may appear in a document only as a character reference.
+func IsXML11RestrictedChar(r rune) bool {
+	return (r >= 0x1 && r <= 0x8) ||
+		r == 0xB || r == 0xC ||
+		(r >= 0xE && r <= 0x1F) ||
+		(r >= 0x7F && r <= 0x84) ||
+		(r >= 0x86 && r <= 0x9F)
+}
+
+// IsXMLNameStartChar reports whether r can start an XML Name. The ranges are
+// those of XML 1.0 fifth edition, which equal the XML 1.1 productions.
 func IsXMLNameStartChar(r rune) bool {
 	return r == ':' ||
 		r == '_' ||
@@ -0,0 +1,167 @@
+package regex
+
+import (
+	"slices"
+	"unicode"
+)
+
+const maxRune = unicode.MaxRune
+
+// runeRange is an inclusive code point range.
+type runeRange struct {
+	lo rune
+	hi rune
+}
+
+// charSet is a normalized set of code points: ranges are sorted, disjoint,
+// and non-adjacent. The ascii bitmap mirrors membership below U+0080 so the
+// common case avoids a range search.
+type charSet struct {
+	ranges []runeRange
+	ascii  [2]uint64
+}
+
+func newCharSet(ranges []runeRange) charSet {
+	s := charSet{ranges: normalizeRanges(ranges)}
+	s.fillASCII()
+	return s
+}
+
+func singletonSet(r rune) charSet {
+	return newCharSet([]runeRange{{lo: r, hi: r}})
+}
+
+func rangeTableSet(table *unicode.RangeTable) charSet {
+	ranges := make([]runeRange, 0, len(table.R16)+len(table.R32))
+	for _, r := range table.R16 {
+		ranges = appendStrideRanges(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		ranges = appendStrideRanges(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return newCharSet(ranges)
+}
+
+func appendStrideRanges(ranges []runeRange, lo, hi, stride rune) []runeRange {
+	if stride == 1 {
+		return append(ranges, runeRange{lo: lo, hi: hi})
+	}
+	for r := lo; r <= hi; r += stride {
+		ranges = append(ranges, runeRange{lo: r, hi: r})
+	}
+	return ranges
+}
+
+func normalizeRanges(ranges []runeRange) []runeRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	ranges = slices.Clone(ranges)
+	slices.SortFunc(ranges, func(a, b runeRange) int {
+		if a.lo != b.lo {
+			return int(a.lo - b.lo)
+		}
+		return int(a.hi - b.hi)
+	})
+	out := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &out[len(out)-1]
+		if r.lo <= last.hi+1 {
+			last.hi = max(last.hi, r.hi)
+			continue
+		}
+		out = append(out, r)
+	}
+	return slices.Clip(out)
+}
+
+func (s *charSet) fillASCII() {
+	s.ascii = [2]uint64{}
+	for _, r := range s.ranges {
+		if r.lo >= 0x80 {
+			break
+		}
+		for c := r.lo; c <= min(r.hi, 0x7F); c++ {
+			s.ascii[c>>6] |= 1 << (c & 63)
+		}
+	}
+}
+
+func (s charSet) contains(r rune) bool {
+	if r < 0x80 {
+		return r >= 0 && s.ascii[r>>6]&(1<<(r&63)) != 0
+	}
+	ranges := s.ranges
+	for len(ranges) > 0 {
+		mid := len(ranges) / 2
+		switch {
+		case r < ranges[mid].lo:
+			ranges = ranges[:mid]
+		case r > ranges[mid].hi:
+			ranges = ranges[mid+1:]
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func (s charSet) union(o charSet) charSet {
+	ranges := make([]runeRange, 0, len(s.ranges)+len(o.ranges))
+	ranges = append(ranges, s.ranges...)
+	ranges = append(ranges, o.ranges...)
+	return newCharSet(ranges)
+}
+
+func (s charSet) negate() charSet {
+	ranges := make([]runeRange, 0, len(s.ranges)+1)
+	next := rune(0)
+	for _, r := range s.ranges {
+		if r.lo > next {
+			ranges = append(ranges, runeRange{lo: next, hi: r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= maxRune {
+		ranges = append(ranges, runeRange{lo: next, hi: maxRune})
+	}
+	out := charSet{ranges: ranges}
+	out.fillASCII()
+	return out
+}
+
+func (s charSet) subtract(o charSet) charSet {
+	return s.intersect(o.negate())
+}
+
+func (s charSet) intersect(o charSet) charSet {
+	var ranges []runeRange
+	i, j := 0, 0
+	for i < len(s.ranges) && j < len(o.ranges) {
+		a, b := s.ranges[i], o.ranges[j]
+		lo, hi := max(a.lo, b.lo), min(a.hi, b.hi)
+		if lo <= hi {
+			ranges = append(ranges, runeRange{lo: lo, hi: hi})
+		}
+		if a.hi < b.hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	out := charSet{ranges: ranges}
+	out.fillASCII()
+	return out
+}
+
+func (s charSet) empty() bool {
+	return len(s.ranges) == 0
+}
+
+func (s charSet) equal(o charSet) bool {
+	return slices.Equal(s.ranges, o.ranges)
+}
+
+func (s charSet) clone() charSet {
+	return charSet{ranges: slices.Clone(s.ranges), ascii: s.ascii}
+}
@@ -0,0 +1,134 @@
+package regex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ECMAScript returns an ECMA-262 expression that matches the strings re
+// matches, for use where XML Schema patterns are exported, such as the
+// pattern keyword of JSON Schema. The expression is anchored with ^ and $,
+// character classes are written as code point ranges, and characters outside
+// the Basic Multilingual Plane use \u{...} escapes, which need the unicode
+// flag.
+func (re *Regex) ECMAScript() string {
+	if re == nil {
+		return ""
+	}
+	root, err := parse(re.source)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("^(?:")
+	writeECMANode(&b, root)
+	b.WriteString(")$")
+	return b.String()
+}
+
+func writeECMANode(b *strings.Builder, n *node) {
+	switch n.kind {
+	case nodeSet:
+		writeECMASet(b, n.set)
+	case nodeConcat:
+		for _, sub := range n.subs {
+			if sub.kind == nodeAlternate {
+				writeECMAGroup(b, sub)
+				continue
+			}
+			writeECMANode(b, sub)
+		}
+	case nodeAlternate:
+		for i, sub := range n.subs {
+			if i != 0 {
+				b.WriteByte('|')
+			}
+			writeECMANode(b, sub)
+		}
+	case nodeRepeat:
+		sub := n.subs[0]
+		if sub.kind == nodeSet {
+			writeECMANode(b, sub)
+		} else {
+			writeECMAGroup(b, sub)
+		}
+		writeECMAQuantifier(b, n.min, n.max)
+	}
+}
+
+func writeECMAGroup(b *strings.Builder, n *node) {
+	b.WriteString("(?:")
+	writeECMANode(b, n)
+	b.WriteByte(')')
+}
+
+func writeECMAQuantifier(b *strings.Builder, lo, hi int) {
+	switch {
+	case lo == 0 && hi == unbounded:
+		b.WriteByte('*')
+	case lo == 1 && hi == unbounded:
+		b.WriteByte('+')
+	case lo == 0 && hi == 1:
+		b.WriteByte('?')
+	case lo == hi:
+		b.WriteString("{" + strconv.Itoa(lo) + "}")
+	case hi == unbounded:
+		b.WriteString("{" + strconv.Itoa(lo) + ",}")
+	default:
+		b.WriteString("{" + strconv.Itoa(lo) + "," + strconv.Itoa(hi) + "}")
+	}
+}
+
+// writeECMASet writes s as a single character or as a class, negated when
+// that takes fewer ranges.
+func writeECMASet(b *strings.Builder, s charSet) {
+	switch {
+	case s.empty():
+		b.WriteString("(?!)")
+		return
+	case len(s.ranges) == 1 && s.ranges[0].lo == s.ranges[0].hi:
+		writeECMARune(b, s.ranges[0].lo, false)
+		return
+	}
+	ranges, negated := s.ranges, false
+	if complement := s.negate().ranges; len(complement) < len(ranges) {
+		ranges, negated = complement, true
+	}
+	if negated && len(ranges) == 0 {
+		b.WriteString(`[\s\S]`)
+		return
+	}
+	b.WriteByte('[')
+	if negated {
+		b.WriteByte('^')
+	}
+	for _, r := range ranges {
+		writeECMARune(b, r.lo, true)
+		if r.hi == r.lo {
+			continue
+		}
+		if r.hi > r.lo+1 {
+			b.WriteByte('-')
+		}
+		writeECMARune(b, r.hi, true)
+	}
+	b.WriteByte(']')
+}
+
+// writeECMARune writes printable ASCII characters as themselves, escaping
+// those with a meaning in the expression or, within a class, a hyphen, and
+// every other character as a code point escape.
+func writeECMARune(b *strings.Builder, r rune, class bool) {
+	switch {
+	case strings.ContainsRune(`^$\.*+?()[]{}|/`, r) || class && r == '-':
+		b.WriteByte('\\')
+		b.WriteRune(r)
+	case r >= ' ' && r <= '~':
+		b.WriteRune(r)
+	case r > 0xFFFF:
+		b.WriteString(`\u{` + strings.ToUpper(strconv.FormatInt(int64(r), 16)) + "}")
+	default:
+		hex := strings.ToUpper(strconv.FormatInt(int64(r), 16))
+		b.WriteString(`\u` + strings.Repeat("0", 4-len(hex)) + hex)
+	}
+}
@@ -0,0 +1,185 @@
+package regex
+
+import (
+	"math"
+	"strings"
+)
+
+// maxGenerateRunes bounds the characters Generate emits before it stops
+// taking optional repetitions and follows the shortest way to a match.
+const maxGenerateRunes = 64
+
+// Generate returns a string that re matches and false when re matches no
+// string. pick chooses among n alternatives, returning an index in [0, n),
+// whenever the expression branches or a character class offers several
+// characters. A nil pick yields the shortest match built from the first
+// preferred character of every class.
+//
+// Characters are drawn from ASCII letters and digits when a class contains
+// any, then from other printable ASCII, so generated text survives whitespace
+// normalization and XML serialization whenever the expression allows it.
+func (re *Regex) Generate(pick func(n int) int) (string, bool) {
+	if re == nil {
+		return "", false
+	}
+	dist := re.matchDistances()
+	if dist[0] == math.MaxInt {
+		return "", false
+	}
+	var b strings.Builder
+	runes := 0
+	pc := uint32(0)
+	// taken records the branch followed from each split since the last
+	// emitted character. A split reached again without emitting one closes
+	// a loop over an empty match, as in (a?)*, and takes its other branch.
+	taken := make(map[uint32]uint32)
+	for {
+		in := re.prog[pc]
+		switch in.op {
+		case opMatch:
+			return b.String(), true
+		case opSet:
+			b.WriteRune(re.sets[in.arg].sample(pick))
+			runes++
+			pc++
+			clear(taken)
+		case opJump:
+			pc = in.out
+		case opSplit:
+			next, revisit := taken[pc]
+			if revisit {
+				next = otherBranch(in.out, in.arg, next, dist)
+			} else {
+				next = chooseBranch(in.out, in.arg, dist, pick, runes < maxGenerateRunes)
+			}
+			taken[pc] = next
+			pc = next
+		}
+	}
+}
+
+// otherBranch returns the split target other than prev, unless no match is
+// reachable from it.
+func otherBranch(out, arg, prev uint32, dist []int) uint32 {
+	other := out
+	if prev == out {
+		other = arg
+	}
+	if dist[other] == math.MaxInt {
+		return prev
+	}
+	return other
+}
+
+// chooseBranch returns the split target to follow. Without pick, or once the
+// output is long enough, it takes the branch closer to a match so generation
+// always terminates.
+func chooseBranch(out, arg uint32, dist []int, pick func(int) int, free bool) uint32 {
+	switch {
+	case dist[out] == math.MaxInt:
+		return arg
+	case dist[arg] == math.MaxInt:
+		return out
+	case pick != nil && free:
+		if pick(2) == 0 {
+			return out
+		}
+		return arg
+	case dist[arg] < dist[out]:
+		return arg
+	default:
+		return out
+	}
+}
+
+// matchDistances returns, for every instruction, the fewest characters that
+// must be consumed from it to reach a match, or math.MaxInt when no match is
+// reachable.
+func (re *Regex) matchDistances() []int {
+	dist := make([]int, len(re.prog))
+	for i := range dist {
+		dist[i] = math.MaxInt
+	}
+	for changed := true; changed; {
+		changed = false
+		for pc := len(re.prog) - 1; pc >= 0; pc-- {
+			in := re.prog[pc]
+			d := math.MaxInt
+			switch in.op {
+			case opMatch:
+				d = 0
+			case opSet:
+				if next := dist[pc+1]; next != math.MaxInt && !re.sets[in.arg].empty() {
+					d = next + 1
+				}
+			case opJump:
+				d = dist[in.out]
+			case opSplit:
+				d = min(dist[in.out], dist[in.arg])
+			}
+			if d < dist[pc] {
+				dist[pc] = d
+				changed = true
+			}
+		}
+	}
+	return dist
+}
+
+// sample returns a character of s suitable for generated text. Callers only
+// sample non-empty sets.
+func (s charSet) sample(pick func(int) int) rune {
+	var preferred, printable []rune
+	for r := rune('!'); r <= '~'; r++ {
+		if !s.contains(r) {
+			continue
+		}
+		if isASCIIAlnum(r) {
+			preferred = append(preferred, r)
+		} else if r != '<' && r != '&' {
+			printable = append(printable, r)
+		}
+	}
+	candidates := preferred
+	if len(candidates) == 0 {
+		candidates = printable
+	}
+	if len(candidates) == 0 {
+		for _, rr := range s.ranges {
+			for r := rr.lo; r <= rr.hi && len(candidates) < 16; r++ {
+				if isXMLChar(r) {
+					candidates = append(candidates, r)
+				}
+			}
+			if len(candidates) == 16 {
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return s.ranges[0].lo
+	}
+	if pick == nil {
+		return candidates[0]
+	}
+	return candidates[pick(len(candidates))]
+}
+
+func isASCIIAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func isXMLChar(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return true
+	case r < 0x20:
+		return false
+	case r >= 0xD800 && r <= 0xDFFF:
+		return false
+	case r == 0xFFFE || r == 0xFFFF:
+		return false
+	default:
+		return r <= maxRune
+	}
+}
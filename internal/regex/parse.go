@@ -0,0 +1,387 @@
+package regex
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+type nodeKind uint8
+
+const (
+	nodeEmpty nodeKind = iota
+	nodeSet
+	nodeConcat
+	nodeAlternate
+	nodeRepeat
+)
+
+// unbounded marks a repeat without an upper bound.
+const unbounded = -1
+
+// node is one parsed regular-expression term. Repeat bounds are already
+// range-checked against MaxProgramSize by the parser.
+type node struct {
+	set  charSet
+	subs []*node
+	min  int
+	max  int
+	kind nodeKind
+}
+
+// SyntaxError reports an invalid XML Schema regular expression.
+type SyntaxError struct {
+	Msg    string
+	Offset int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid regular expression at offset %d: %s", e.Offset, e.Msg)
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func parse(src string) (*node, error) {
+	p := parser{src: src}
+	n, err := p.parseRegExp()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return n, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) more() bool {
+	return p.pos < len(p.src)
+}
+
+func (p *parser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *parser) nextRune() rune {
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	return r
+}
+
+// parseRegExp parses regExp ::= branch ( '|' branch )*.
+func (p *parser) parseRegExp() (*node, error) {
+	var branches []*node
+	for {
+		branch, err := p.parseBranch()
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+		if !p.more() || p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(branches) == 1 {
+		return branches[0], nil
+	}
+	return &node{kind: nodeAlternate, subs: branches}, nil
+}
+
+// parseBranch parses branch ::= piece*.
+func (p *parser) parseBranch() (*node, error) {
+	var pieces []*node
+	for p.more() && p.peek() != '|' && p.peek() != ')' {
+		piece, err := p.parsePiece()
+		if err != nil {
+			return nil, err
+		}
+		pieces = append(pieces, piece)
+	}
+	switch len(pieces) {
+	case 0:
+		return &node{kind: nodeEmpty}, nil
+	case 1:
+		return pieces[0], nil
+	default:
+		return &node{kind: nodeConcat, subs: pieces}, nil
+	}
+}
+
+// parsePiece parses piece ::= atom quantifier?.
+func (p *parser) parsePiece() (*node, error) {
+	atom, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if !p.more() {
+		return atom, nil
+	}
+	lo, hi := 0, 0
+	switch p.peek() {
+	case '?':
+		lo, hi = 0, 1
+	case '*':
+		lo, hi = 0, unbounded
+	case '+':
+		lo, hi = 1, unbounded
+	case '{':
+		return p.parseQuantity(atom)
+	default:
+		return atom, nil
+	}
+	p.pos++
+	return &node{kind: nodeRepeat, subs: []*node{atom}, min: lo, max: hi}, nil
+}
+
+func (p *parser) parseQuantity(atom *node) (*node, error) {
+	p.pos++
+	lo, ok := p.parseQuantExact()
+	if !ok {
+		return nil, p.errorf("invalid quantifier")
+	}
+	hi := lo
+	if p.more() && p.peek() == ',' {
+		p.pos++
+		hi = unbounded
+		if p.more() && p.peek() != '}' {
+			if hi, ok = p.parseQuantExact(); !ok {
+				return nil, p.errorf("invalid quantifier")
+			}
+			if hi < lo {
+				return nil, p.errorf("quantifier upper bound is below lower bound")
+			}
+		}
+	}
+	if !p.more() || p.peek() != '}' {
+		return nil, p.errorf("unclosed quantifier")
+	}
+	p.pos++
+	return &node{kind: nodeRepeat, subs: []*node{atom}, min: lo, max: hi}, nil
+}
+
+// parseQuantExact parses a decimal quantity. Values above MaxProgramSize
+// saturate: they can never compile, and the caller reports ErrTooLarge from
+// the size check instead of an integer overflow.
+func (p *parser) parseQuantExact() (int, bool) {
+	start := p.pos
+	n := 0
+	for p.more() && p.peek() >= '0' && p.peek() <= '9' {
+		if n <= MaxProgramSize {
+			n = n*10 + int(p.peek()-'0')
+		}
+		p.pos++
+	}
+	return min(n, MaxProgramSize+1), p.pos > start
+}
+
+func (p *parser) parseAtom() (*node, error) {
+	switch c := p.peek(); c {
+	case '(':
+		p.pos++
+		n, err := p.parseRegExp()
+		if err != nil {
+			return nil, err
+		}
+		if !p.more() || p.peek() != ')' {
+			return nil, p.errorf("unclosed group")
+		}
+		p.pos++
+		return n, nil
+	case '[':
+		set, err := p.parseClassExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeSet, set: set}, nil
+	case '\\':
+		set, err := p.parseEscape()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeSet, set: set}, nil
+	case '.':
+		p.pos++
+		return &node{kind: nodeSet, set: anyCharSet()}, nil
+	case '?', '*', '+', '{':
+		return nil, p.errorf("quantifier without operand")
+	case ')', ']', '}':
+		return nil, p.errorf("unexpected %q", c)
+	default:
+		return &node{kind: nodeSet, set: singletonSet(p.nextRune())}, nil
+	}
+}
+
+func anyCharSet() charSet {
+	return newCharSet([]runeRange{{'\n', '\n'}, {'\r', '\r'}}).negate()
+}
+
+// parseEscape parses one escape at p.pos. Single-character escapes yield a
+// singleton set.
+func (p *parser) parseEscape() (charSet, error) {
+	p.pos++
+	if !p.more() {
+		return charSet{}, p.errorf("trailing escape")
+	}
+	c := p.peek()
+	p.pos++
+	if r, ok := singleCharEscape(c); ok {
+		return singletonSet(r), nil
+	}
+	if set, ok := multiCharEscapeSet(c); ok {
+		return set, nil
+	}
+	if c == 'p' || c == 'P' {
+		set, err := p.parseCategory()
+		if err != nil {
+			return charSet{}, err
+		}
+		if c == 'P' {
+			return set.negate(), nil
+		}
+		return set, nil
+	}
+	return charSet{}, p.errorf("invalid escape \\%c", c)
+}
+
+func singleCharEscape(c byte) (rune, bool) {
+	switch c {
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case '\\', '|', '.', '?', '*', '+', '(', ')', '{', '}', '-', '[', ']', '^':
+		return rune(c), true
+	default:
+		return 0, false
+	}
+}
+
+func (p *parser) parseCategory() (charSet, error) {
+	if !p.more() || p.peek() != '{' {
+		return charSet{}, p.errorf("invalid category escape")
+	}
+	start := p.pos + 1
+	end := start
+	for end < len(p.src) && p.src[end] != '}' {
+		end++
+	}
+	if end == len(p.src) {
+		return charSet{}, p.errorf("unclosed category escape")
+	}
+	name := p.src[start:end]
+	set, ok := categorySet(name)
+	if !ok {
+		return charSet{}, p.errorf("unknown category %q", name)
+	}
+	p.pos = end + 1
+	return set, nil
+}
+
+// parseClassExpr parses charClassExpr ::= '[' charGroup ']', including
+// negation and class subtraction.
+func (p *parser) parseClassExpr() (charSet, error) {
+	p.pos++
+	negated := false
+	if p.more() && p.peek() == '^' {
+		negated = true
+		p.pos++
+	}
+	var set charSet
+	terms := 0
+	for {
+		if !p.more() {
+			return charSet{}, p.errorf("unclosed character class")
+		}
+		c := p.peek()
+		if c == ']' {
+			if terms == 0 {
+				return charSet{}, p.errorf("empty character class")
+			}
+			p.pos++
+			break
+		}
+		if c == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '[' {
+			if terms == 0 {
+				return charSet{}, p.errorf("class subtraction without base group")
+			}
+			p.pos++
+			sub, err := p.parseClassExpr()
+			if err != nil {
+				return charSet{}, err
+			}
+			if !p.more() || p.peek() != ']' {
+				return charSet{}, p.errorf("class subtraction must end the character class")
+			}
+			p.pos++
+			if negated {
+				set = set.negate()
+			}
+			return set.subtract(sub), nil
+		}
+		term, err := p.parseClassTerm()
+		if err != nil {
+			return charSet{}, err
+		}
+		set = set.union(term)
+		terms++
+	}
+	if negated {
+		return set.negate(), nil
+	}
+	return set, nil
+}
+
+// parseClassTerm parses one charRange or charClassEsc inside a class.
+func (p *parser) parseClassTerm() (charSet, error) {
+	lo, single, set, err := p.parseClassChar()
+	if err != nil {
+		return charSet{}, err
+	}
+	if !single {
+		return set, nil
+	}
+	// A hyphen forms a range unless it ends the group or starts a
+	// subtraction.
+	if p.pos+1 < len(p.src) && p.peek() == '-' && p.src[p.pos+1] != ']' && p.src[p.pos+1] != '[' {
+		p.pos++
+		hi, single, _, err := p.parseClassChar()
+		if err != nil {
+			return charSet{}, err
+		}
+		if !single {
+			return charSet{}, p.errorf("character range ends with a multi-character escape")
+		}
+		if hi < lo {
+			return charSet{}, p.errorf("character range is out of order")
+		}
+		return newCharSet([]runeRange{{lo: lo, hi: hi}}), nil
+	}
+	return singletonSet(lo), nil
+}
+
+func (p *parser) parseClassChar() (rune, bool, charSet, error) {
+	c := p.peek()
+	switch c {
+	case '\\':
+		if p.pos+1 < len(p.src) {
+			if r, ok := singleCharEscape(p.src[p.pos+1]); ok {
+				p.pos += 2
+				return r, true, charSet{}, nil
+			}
+		}
+		set, err := p.parseEscape()
+		return 0, false, set, err
+	case '[':
+		return 0, false, charSet{}, p.errorf("unescaped '[' in character class")
+	default:
+		return p.nextRune(), true, charSet{}, nil
+	}
+}
@@ -0,0 +1,352 @@
+// Package regex compiles and matches XML Schema 1.0 regular expressions.
+//
+// The dialect is the one defined by XML Schema Part 2, appendix F: every
+// expression is implicitly anchored at both ends, '^' and '$' are ordinary
+// characters, and character classes support \i, \c, Unicode categories,
+// \p{IsBlock} block escapes, and class subtraction. Compiled expressions are
+// Thompson NFAs matched by simulating all states in lockstep, so matching
+// time is linear in the input length for a fixed expression.
+package regex
+
+import (
+	"errors"
+	"slices"
+	"unicode/utf8"
+)
+
+// MaxProgramSize caps compiled NFA instructions. Counted repetition is
+// expanded when compiling, so the cap bounds both memory and per-character
+// matching work.
+const MaxProgramSize = 1 << 16
+
+// ErrTooLarge reports an expression whose compiled program would exceed
+// MaxProgramSize.
+var ErrTooLarge = errors.New("regular expression program is too large")
+
+type opcode uint8
+
+const (
+	opMatch opcode = iota
+	opSet
+	opJump
+	opSplit
+)
+
+// inst is one NFA instruction. opSet consumes a character from sets[arg] and
+// continues at the next instruction; opJump continues at out; opSplit
+// continues at both out and arg.
+type inst struct {
+	out uint32
+	arg uint32
+	op  opcode
+}
+
+// Regex is a compiled XML Schema regular expression. It is immutable and safe
+// for concurrent use.
+type Regex struct {
+	source string
+	prog   []inst
+	sets   []charSet
+}
+
+// Compile parses and compiles an XML Schema regular expression. Syntax errors
+// are reported as *SyntaxError; oversized expansions return ErrTooLarge.
+func Compile(source string) (*Regex, error) {
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	size, ok := programSize(root)
+	if !ok {
+		return nil, ErrTooLarge
+	}
+	c := compiler{prog: make([]inst, 0, size)}
+	c.emit(root)
+	c.prog = append(c.prog, inst{op: opMatch})
+	return &Regex{source: source, prog: c.prog, sets: c.sets}, nil
+}
+
+// MustCompile is like Compile but panics when source does not compile.
+func MustCompile(source string) *Regex {
+	re, err := Compile(source)
+	if err != nil {
+		panic("regex: Compile(" + source + "): " + err.Error())
+	}
+	return re
+}
+
+// String returns the source text used to compile re.
+func (re *Regex) String() string {
+	return re.source
+}
+
+// Clone returns a deep copy of re that shares no memory with it.
+func (re *Regex) Clone() *Regex {
+	sets := make([]charSet, len(re.sets))
+	for i := range re.sets {
+		sets[i] = re.sets[i].clone()
+	}
+	return &Regex{source: re.source, prog: slices.Clone(re.prog), sets: sets}
+}
+
+// MatchString reports whether the whole of s matches re.
+func (re *Regex) MatchString(s string) bool {
+	var m Machine
+	return m.MatchString(re, s)
+}
+
+// Match reports whether the whole of b matches re.
+func (re *Regex) Match(b []byte) bool {
+	var m Machine
+	return m.Match(re, b)
+}
+
+// programSize returns the instruction count needed for n, excluding the final
+// match instruction, and false when it would exceed MaxProgramSize.
+func programSize(n *node) (int, bool) {
+	switch n.kind {
+	case nodeEmpty:
+		return 0, true
+	case nodeSet:
+		return 1, true
+	case nodeConcat, nodeAlternate:
+		total := 0
+		if n.kind == nodeAlternate {
+			total = 2 * (len(n.subs) - 1)
+		}
+		for _, sub := range n.subs {
+			size, ok := programSize(sub)
+			if !ok {
+				return 0, false
+			}
+			total += size
+			if total >= MaxProgramSize {
+				return 0, false
+			}
+		}
+		return total, true
+	case nodeRepeat:
+		body, ok := programSize(n.subs[0])
+		if !ok {
+			return 0, false
+		}
+		total, ok := mulSize(n.min, body)
+		if !ok {
+			return 0, false
+		}
+		var tail int
+		if n.max == unbounded {
+			tail = body + 2
+		} else if tail, ok = mulSize(n.max-n.min, body+1); !ok {
+			return 0, false
+		}
+		total += tail
+		return total, total < MaxProgramSize
+	default:
+		return 0, false
+	}
+}
+
+func mulSize(count, size int) (int, bool) {
+	if count == 0 || size == 0 {
+		return 0, true
+	}
+	if count >= MaxProgramSize || size >= MaxProgramSize || count*size >= MaxProgramSize {
+		return 0, false
+	}
+	return count * size, true
+}
+
+type compiler struct {
+	prog []inst
+	sets []charSet
+}
+
+func (c *compiler) pc() uint32 {
+	return uint32(len(c.prog)) //nolint:gosec // programSize bounds len(prog) by MaxProgramSize.
+}
+
+func (c *compiler) emit(n *node) {
+	switch n.kind {
+	case nodeEmpty:
+	case nodeSet:
+		c.prog = append(c.prog, inst{op: opSet, arg: c.setIndex(n.set)})
+	case nodeConcat:
+		for _, sub := range n.subs {
+			c.emit(sub)
+		}
+	case nodeAlternate:
+		c.emitAlternate(n.subs)
+	case nodeRepeat:
+		c.emitRepeat(n.subs[0], n.min, n.max)
+	}
+}
+
+// emitAlternate lays out a|b|c as split(a, split(b, c)) with every branch
+// jumping to the common end.
+func (c *compiler) emitAlternate(subs []*node) {
+	var jumps []uint32
+	for i, sub := range subs {
+		if i == len(subs)-1 {
+			c.emit(sub)
+			break
+		}
+		split := c.pc()
+		c.prog = append(c.prog, inst{op: opSplit, out: split + 1})
+		c.emit(sub)
+		jumps = append(jumps, c.pc())
+		c.prog = append(c.prog, inst{op: opJump})
+		c.prog[split].arg = c.pc()
+	}
+	end := c.pc()
+	for _, j := range jumps {
+		c.prog[j].out = end
+	}
+}
+
+// emitRepeat expands body{lo,hi}: lo mandatory copies followed by either a
+// star loop or hi-lo nested optional copies that all exit to the same end.
+func (c *compiler) emitRepeat(body *node, lo, hi int) {
+	for range lo {
+		c.emit(body)
+	}
+	if hi == unbounded {
+		loop := c.pc()
+		c.prog = append(c.prog, inst{op: opSplit, out: loop + 1})
+		c.emit(body)
+		c.prog = append(c.prog, inst{op: opJump, out: loop})
+		c.prog[loop].arg = c.pc()
+		return
+	}
+	var splits []uint32
+	for range hi - lo {
+		splits = append(splits, c.pc())
+		c.prog = append(c.prog, inst{op: opSplit, out: c.pc() + 1})
+		c.emit(body)
+	}
+	end := c.pc()
+	for _, s := range splits {
+		c.prog[s].arg = end
+	}
+}
+
+func (c *compiler) setIndex(set charSet) uint32 {
+	for i := range c.sets {
+		if c.sets[i].equal(set) {
+			return uint32(i) //nolint:gosec // set count is bounded by program size.
+		}
+	}
+	c.sets = append(c.sets, set)
+	return uint32(len(c.sets) - 1) //nolint:gosec // set count is bounded by program size.
+}
+
+// Machine owns reusable NFA simulation buffers. A zero Machine is ready to
+// use; it must not be shared by concurrent matches.
+type Machine struct {
+	cur   threadSet
+	next  threadSet
+	stack []uint32
+}
+
+type threadSet struct {
+	dense  []uint32
+	sparse []uint32
+}
+
+func (s *threadSet) reset(size int) {
+	if cap(s.sparse) < size {
+		s.sparse = make([]uint32, size)
+		s.dense = make([]uint32, 0, size)
+	}
+	s.sparse = s.sparse[:size]
+	s.dense = s.dense[:0]
+}
+
+func (s *threadSet) has(pc uint32) bool {
+	i := s.sparse[pc]
+	return int(i) < len(s.dense) && s.dense[i] == pc
+}
+
+func (s *threadSet) add(pc uint32) {
+	s.sparse[pc] = uint32(len(s.dense)) //nolint:gosec // dense length is bounded by program size.
+	s.dense = append(s.dense, pc)
+}
+
+// MatchString reports whether the whole of s matches re.
+func (m *Machine) MatchString(re *Regex, s string) bool {
+	if !m.start(re) {
+		return false
+	}
+	for _, r := range s {
+		if !m.step(re, r) {
+			return false
+		}
+	}
+	return m.matched(re)
+}
+
+// Match reports whether the whole of b matches re.
+func (m *Machine) Match(re *Regex, b []byte) bool {
+	if !m.start(re) {
+		return false
+	}
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		if !m.step(re, r) {
+			return false
+		}
+	}
+	return m.matched(re)
+}
+
+func (m *Machine) start(re *Regex) bool {
+	if re == nil {
+		return false
+	}
+	m.cur.reset(len(re.prog))
+	m.next.reset(len(re.prog))
+	m.addClosure(re, &m.cur, 0)
+	return true
+}
+
+func (m *Machine) step(re *Regex, r rune) bool {
+	m.next.dense = m.next.dense[:0]
+	for _, pc := range m.cur.dense {
+		in := re.prog[pc]
+		if in.op == opSet && re.sets[in.arg].contains(r) {
+			m.addClosure(re, &m.next, pc+1)
+		}
+	}
+	m.cur, m.next = m.next, m.cur
+	return len(m.cur.dense) != 0
+}
+
+func (m *Machine) matched(re *Regex) bool {
+	for _, pc := range m.cur.dense {
+		if re.prog[pc].op == opMatch {
+			return true
+		}
+	}
+	return false
+}
+
+// addClosure adds pc and every instruction reachable from it without
+// consuming input. Each instruction enters a set at most once per step.
+func (m *Machine) addClosure(re *Regex, set *threadSet, pc uint32) {
+	m.stack = append(m.stack[:0], pc)
+	for len(m.stack) > 0 {
+		pc := m.stack[len(m.stack)-1]
+		m.stack = m.stack[:len(m.stack)-1]
+		if set.has(pc) {
+			continue
+		}
+		set.add(pc)
+		switch in := re.prog[pc]; in.op {
+		case opJump:
+			m.stack = append(m.stack, in.out)
+		case opSplit:
+			m.stack = append(m.stack, in.arg, in.out)
+		}
+	}
+}
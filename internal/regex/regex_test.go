@@ -0,0 +1,241 @@
+package regex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMatchString(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		reject  []string
+	}{
+		{pattern: "", match: []string{""}, reject: []string{"a"}},
+		{pattern: "abc", match: []string{"abc"}, reject: []string{"ab", "abcd", "xabc"}},
+		{pattern: "a|b|", match: []string{"a", "b", ""}, reject: []string{"ab"}},
+		{pattern: "^a$", match: []string{"^a$"}, reject: []string{"a"}},
+		{pattern: "a.c", match: []string{"abc", "a c", "aéc"}, reject: []string{"a\nc", "a\rc", "ac"}},
+		{pattern: "(ab)*c?", match: []string{"", "ab", "ababc", "c"}, reject: []string{"aba", "cc"}},
+		{pattern: "a{2,3}", match: []string{"aa", "aaa"}, reject: []string{"a", "aaaa"}},
+		{pattern: "a{0002}", match: []string{"aa"}, reject: []string{"a"}},
+		{pattern: "a{2,}", match: []string{"aa", "aaaaa"}, reject: []string{"a"}},
+		{pattern: "(a|)+", match: []string{"", "a", "aaa"}, reject: []string{"b"}},
+		{pattern: "[a-z-[aeiou]]+", match: []string{"bcd", "xyz"}, reject: []string{"bad", "A"}},
+		{pattern: "[^a-z-[xyz]]", match: []string{"A", "0"}, reject: []string{"a", "x"}},
+		{pattern: "[a-c-[b-[b]]]", match: []string{"a", "b", "c"}, reject: []string{"d"}},
+		{pattern: "[-a]+", match: []string{"-a-"}, reject: []string{"b"}},
+		{pattern: "[a-]+", match: []string{"a-"}, reject: []string{"b"}},
+		{pattern: `[\-\[\]]+`, match: []string{"-[]"}, reject: []string{"a"}},
+		{pattern: `\i\c*`, match: []string{"_a1", "x-y.z", ":a"}, reject: []string{"1a", "-a", "a b"}},
+		{pattern: `[\i-[:]][\c-[:]]*`, match: []string{"a1"}, reject: []string{"a:b", ":a"}},
+		{pattern: `\I\C`, match: []string{"1 "}, reject: []string{"a1", "1a"}},
+		{pattern: `\p{IsBasicLatin}+`, match: []string{"abc~"}, reject: []string{"é"}},
+		{pattern: `\P{IsBasicLatin}`, match: []string{"é"}, reject: []string{"a"}},
+		{pattern: `\p{IsPrivateUse}`, match: []string{"\uE000", "\U000F0000", "\U00100000"}, reject: []string{"a"}},
+		{pattern: `\p{IsSpecials}`, match: []string{"\uFEFF", "\uFFF0"}, reject: []string{"\uFFFE"}},
+		{pattern: `\p{Lu}\p{Ll}`, match: []string{"Ab"}, reject: []string{"aB"}},
+		{pattern: `\p{Cn}`, match: []string{"\U000E0FFF"}, reject: []string{"a", "\u0000"}},
+		{pattern: `\P{L}`, match: []string{"1"}, reject: []string{"a"}},
+		{pattern: `\d+`, match: []string{"123", "١٢"}, reject: []string{"a"}},
+		{pattern: `\s\S`, match: []string{" a", "\ta"}, reject: []string{"a ", " a"}},
+		{pattern: `\w+`, match: []string{"ab1", "ȿ"}, reject: []string{"a b", "a-b"}},
+		{pattern: `\W`, match: []string{" ", "-"}, reject: []string{"a", "ȿ"}},
+		{pattern: `\n\r\t\\\|\.\?\*\+\(\)\{\}\-\[\]\^`, match: []string{"\n\r\t\\|.?*+(){}-[]^"}},
+		{pattern: `[\n\t]`, match: []string{"\n", "\t"}, reject: []string{"n"}},
+	}
+	for _, tt := range tests {
+		re, err := Compile(tt.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", tt.pattern, err)
+		}
+		for _, s := range tt.match {
+			if !re.MatchString(s) || !re.Match([]byte(s)) {
+				t.Errorf("Compile(%q) does not match %q", tt.pattern, s)
+			}
+		}
+		for _, s := range tt.reject {
+			if re.MatchString(s) || re.Match([]byte(s)) {
+				t.Errorf("Compile(%q) matches %q", tt.pattern, s)
+			}
+		}
+	}
+}
+
+func TestCompileRejectsInvalidSyntax(t *testing.T) {
+	for _, pattern := range []string{
+		"(", ")", "[", "]", "[]", "a**", "*", "{1}", "a{2,1}", "a{,2}", "a{1",
+		`\`, `\q`, `\p{Foo}`, `\p{IsFoo}`, `\p{L`, `[a-\d]`, `[z-a]`, `[a-[b]`,
+	} {
+		_, err := Compile(pattern)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Errorf("Compile(%q) error = %v, want *SyntaxError", pattern, err)
+		}
+	}
+}
+
+func TestCompileLargeCountedRepeat(t *testing.T) {
+	re, err := Compile(`[0-9]{1001,2000}`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if !re.MatchString(strings.Repeat("7", 1500)) {
+		t.Fatal("large counted repeat does not match inside bounds")
+	}
+	if re.MatchString(strings.Repeat("7", 1000)) || re.MatchString(strings.Repeat("7", 2001)) {
+		t.Fatal("large counted repeat matches outside bounds")
+	}
+}
+
+func TestCompileRejectsOversizedProgram(t *testing.T) {
+	for _, pattern := range []string{
+		`a{65536}`,
+		`(a{1000}){1000}`,
+		`a{99999999999999999999999}`,
+		`(a{300}|b{300}){0,300}`,
+	} {
+		if _, err := Compile(pattern); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Compile(%q) error = %v, want ErrTooLarge", pattern, err)
+		}
+	}
+}
+
+func TestMatchIsLinearForNestedRepeats(t *testing.T) {
+	re, err := Compile(`(a*)*b`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if re.MatchString(strings.Repeat("a", 100_000)) {
+		t.Fatal("nested star matched input without terminator")
+	}
+}
+
+func TestCloneSharesNoMemory(t *testing.T) {
+	re, err := Compile(`[a-z]+\d`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	clone := re.Clone()
+	clone.sets[0].ranges[0] = runeRange{lo: '0', hi: '0'}
+	clone.prog[0].op = opMatch
+	if !re.MatchString("abc1") {
+		t.Fatal("mutating clone changed original")
+	}
+	if clone.String() != re.String() {
+		t.Fatalf("Clone().String() = %q, want %q", clone.String(), re.String())
+	}
+}
+
+func TestMachineReuseAcrossPrograms(t *testing.T) {
+	small, err := Compile(`a`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := Compile(`(ab|cd){3,5}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Machine
+	for range 3 {
+		if !m.MatchString(large, "abcdab") || m.MatchString(large, "abcd") {
+			t.Fatal("large program match result is wrong")
+		}
+		if !m.MatchString(small, "a") || m.MatchString(small, "aa") {
+			t.Fatal("small program match result is wrong")
+		}
+	}
+}
+
+func TestNamedSetLookups(t *testing.T) {
+	for _, name := range []string{"L", "Nd", "Cn", "Co"} {
+		if !IsCategory(name) {
+			t.Errorf("IsCategory(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"Greek", "Cs", "LC", ""} {
+		if IsCategory(name) {
+			t.Errorf("IsCategory(%q) = true", name)
+		}
+	}
+	for _, name := range []string{"BasicLatin", "Latin-1Supplement", "PrivateUse", "CJKUnifiedIdeographsExtensionB"} {
+		if !IsBlock(name) {
+			t.Errorf("IsBlock(%q) = false", name)
+		}
+	}
+	if IsBlock("Latin1Supplement") {
+		t.Error(`IsBlock("Latin1Supplement") = true`)
+	}
+}
+
+func TestGenerateMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		shortest string
+	}{
+		{pattern: "", shortest: ""},
+		{pattern: "abc", shortest: "abc"},
+		{pattern: "[A-Z]{2}-\\d{4}", shortest: "AA-0000"},
+		{pattern: "(ab)*c?", shortest: ""},
+		{pattern: "x+|yy|z{3}", shortest: "x"},
+		{pattern: "[^a-z-[xyz]]", shortest: "0"},
+		{pattern: `\s`, shortest: "\t"},
+		{pattern: `\p{Lu}\p{Ll}+`, shortest: "Aa"},
+		{pattern: `\P{IsBasicLatin}`, shortest: "\u0080"},
+		{pattern: `[\i-[:]][\c-[:]]*`, shortest: "A"},
+		{pattern: "(a?)*", shortest: ""},
+		{pattern: "(a|)*", shortest: ""},
+		{pattern: "((a?)*b?)*", shortest: ""},
+		{pattern: "(a*)+(b?){2,}", shortest: ""},
+		{pattern: "(|a)*x", shortest: "x"},
+	}
+	for _, tt := range tests {
+		re := MustCompile(tt.pattern)
+		got, ok := re.Generate(nil)
+		if !ok || got != tt.shortest {
+			t.Errorf("Compile(%q).Generate(nil) = %q, %v; want %q", tt.pattern, got, ok, tt.shortest)
+		}
+		seed := 0
+		for range 50 {
+			seed++
+			pick := func(n int) int { seed = seed*1103515245 + 12345; return (seed >> 8 & 0x7fff) % n }
+			got, ok := re.Generate(pick)
+			if !ok || !re.MatchString(got) {
+				t.Errorf("Compile(%q).Generate(pick) = %q, %v; does not match", tt.pattern, got, ok)
+			}
+		}
+	}
+}
+
+func TestGenerateReportsEmptyLanguage(t *testing.T) {
+	if got, ok := MustCompile("a[b-[b]]").Generate(nil); ok {
+		t.Fatalf("Generate() = %q, want no match", got)
+	}
+}
+
+func TestECMAScript(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "", want: `^(?:)$`},
+		{pattern: "abc", want: `^(?:abc)$`},
+		{pattern: "a|b|", want: `^(?:a|b|)$`},
+		{pattern: "x(a|b)y", want: `^(?:x(?:a|b)y)$`},
+		{pattern: "(ab)*c?", want: `^(?:(?:ab)*c?)$`},
+		{pattern: "[A-Z]{2}-[0-9]{4,}", want: `^(?:[A-Z]{2}-[0-9]{4,})$`},
+		{pattern: "[^a-z-[xyz]]", want: `^(?:[^a-z])$`},
+		{pattern: "a.{1,3}", want: `^(?:a[^\u000A\u000D]{1,3})$`},
+		{pattern: "[ab]+", want: `^(?:[ab]+)$`},
+		{pattern: `[\-.]\.\^/ `, want: `^(?:[\-\.]\.\^\/ )$`},
+		{pattern: "a[b-[b]]", want: `^(?:a(?!))$`},
+		{pattern: `\p{IsPrivateUse}`, want: `^(?:[\uE000-\uF8FF\u{F0000}-\u{FFFFD}\u{100000}-\u{10FFFD}])$`},
+	}
+	for _, tt := range tests {
+		got := MustCompile(tt.pattern).ECMAScript()
+		if got != tt.want {
+			t.Errorf("Compile(%q).ECMAScript() = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package regex
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// xsdCategories lists the general category names accepted by \p{...} and
+// \P{...} in XML Schema 1.0 regular expressions.
+var xsdCategories = []string{
+	"L", "Lu", "Ll", "Lt", "Lm", "Lo",
+	"M", "Mn", "Mc", "Me",
+	"N", "Nd", "Nl", "No",
+	"P", "Pc", "Pd", "Ps", "Pe", "Pi", "Pf", "Po",
+	"Z", "Zs", "Zl", "Zp",
+	"S", "Sm", "Sc", "Sk", "So",
+	"C", "Cc", "Cf", "Co", "Cn",
+}
+
+// xsdBlocks is the XML Schema 1.0 block table (Unicode 3.1 Blocks.txt with
+// spaces removed). Names that appear more than once denote the union of
+// their ranges.
+var xsdBlocks = []struct {
+	name string
+	r    runeRange
+}{
+	{"BasicLatin", runeRange{0x0000, 0x007F}},
+	{"Latin-1Supplement", runeRange{0x0080, 0x00FF}},
+	{"LatinExtended-A", runeRange{0x0100, 0x017F}},
+	{"LatinExtended-B", runeRange{0x0180, 0x024F}},
+	{"IPAExtensions", runeRange{0x0250, 0x02AF}},
+	{"SpacingModifierLetters", runeRange{0x02B0, 0x02FF}},
+	{"CombiningDiacriticalMarks", runeRange{0x0300, 0x036F}},
+	{"Greek", runeRange{0x0370, 0x03FF}},
+	{"Cyrillic", runeRange{0x0400, 0x04FF}},
+	{"Armenian", runeRange{0x0530, 0x058F}},
+	{"Hebrew", runeRange{0x0590, 0x05FF}},
+	{"Arabic", runeRange{0x0600, 0x06FF}},
+	{"Syriac", runeRange{0x0700, 0x074F}},
+	{"Thaana", runeRange{0x0780, 0x07BF}},
+	{"Devanagari", runeRange{0x0900, 0x097F}},
+	{"Bengali", runeRange{0x0980, 0x09FF}},
+	{"Gurmukhi", runeRange{0x0A00, 0x0A7F}},
+	{"Gujarati", runeRange{0x0A80, 0x0AFF}},
+	{"Oriya", runeRange{0x0B00, 0x0B7F}},
+	{"Tamil", runeRange{0x0B80, 0x0BFF}},
+	{"Telugu", runeRange{0x0C00, 0x0C7F}},
+	{"Kannada", runeRange{0x0C80, 0x0CFF}},
+	{"Malayalam", runeRange{0x0D00, 0x0D7F}},
+	{"Sinhala", runeRange{0x0D80, 0x0DFF}},
+	{"Thai", runeRange{0x0E00, 0x0E7F}},
+	{"Lao", runeRange{0x0E80, 0x0EFF}},
+	{"Tibetan", runeRange{0x0F00, 0x0FFF}},
+	{"Myanmar", runeRange{0x1000, 0x109F}},
+	{"Georgian", runeRange{0x10A0, 0x10FF}},
+	{"HangulJamo", runeRange{0x1100, 0x11FF}},
+	{"Ethiopic", runeRange{0x1200, 0x137F}},
+	{"Cherokee", runeRange{0x13A0, 0x13FF}},
+	{"UnifiedCanadianAboriginalSyllabics", runeRange{0x1400, 0x167F}},
+	{"Ogham", runeRange{0x1680, 0x169F}},
+	{"Runic", runeRange{0x16A0, 0x16FF}},
+	{"Khmer", runeRange{0x1780, 0x17FF}},
+	{"Mongolian", runeRange{0x1800, 0x18AF}},
+	{"LatinExtendedAdditional", runeRange{0x1E00, 0x1EFF}},
+	{"GreekExtended", runeRange{0x1F00, 0x1FFF}},
+	{"GeneralPunctuation", runeRange{0x2000, 0x206F}},
+	{"SuperscriptsandSubscripts", runeRange{0x2070, 0x209F}},
+	{"CurrencySymbols", runeRange{0x20A0, 0x20CF}},
+	{"CombiningMarksforSymbols", runeRange{0x20D0, 0x20FF}},
+	{"LetterlikeSymbols", runeRange{0x2100, 0x214F}},
+	{"NumberForms", runeRange{0x2150, 0x218F}},
+	{"Arrows", runeRange{0x2190, 0x21FF}},
+	{"MathematicalOperators", runeRange{0x2200, 0x22FF}},
+	{"MiscellaneousTechnical", runeRange{0x2300, 0x23FF}},
+	{"ControlPictures", runeRange{0x2400, 0x243F}},
+	{"OpticalCharacterRecognition", runeRange{0x2440, 0x245F}},
+	{"EnclosedAlphanumerics", runeRange{0x2460, 0x24FF}},
+	{"BoxDrawing", runeRange{0x2500, 0x257F}},
+	{"BlockElements", runeRange{0x2580, 0x259F}},
+	{"GeometricShapes", runeRange{0x25A0, 0x25FF}},
+	{"MiscellaneousSymbols", runeRange{0x2600, 0x26FF}},
+	{"Dingbats", runeRange{0x2700, 0x27BF}},
+	{"BraillePatterns", runeRange{0x2800, 0x28FF}},
+	{"CJKRadicalsSupplement", runeRange{0x2E80, 0x2EFF}},
+	{"KangxiRadicals", runeRange{0x2F00, 0x2FDF}},
+	{"IdeographicDescriptionCharacters", runeRange{0x2FF0, 0x2FFF}},
+	{"CJKSymbolsandPunctuation", runeRange{0x3000, 0x303F}},
+	{"Hiragana", runeRange{0x3040, 0x309F}},
+	{"Katakana", runeRange{0x30A0, 0x30FF}},
+	{"Bopomofo", runeRange{0x3100, 0x312F}},
+	{"HangulCompatibilityJamo", runeRange{0x3130, 0x318F}},
+	{"Kanbun", runeRange{0x3190, 0x319F}},
+	{"BopomofoExtended", runeRange{0x31A0, 0x31BF}},
+	{"EnclosedCJKLettersandMonths", runeRange{0x3200, 0x32FF}},
+	{"CJKCompatibility", runeRange{0x3300, 0x33FF}},
+	{"CJKUnifiedIdeographsExtensionA", runeRange{0x3400, 0x4DB5}},
+	{"CJKUnifiedIdeographs", runeRange{0x4E00, 0x9FFF}},
+	{"YiSyllables", runeRange{0xA000, 0xA48F}},
+	{"YiRadicals", runeRange{0xA490, 0xA4CF}},
+	{"HangulSyllables", runeRange{0xAC00, 0xD7A3}},
+	{"HighSurrogates", runeRange{0xD800, 0xDB7F}},
+	{"HighPrivateUseSurrogates", runeRange{0xDB80, 0xDBFF}},
+	{"LowSurrogates", runeRange{0xDC00, 0xDFFF}},
+	{"PrivateUse", runeRange{0xE000, 0xF8FF}},
+	{"CJKCompatibilityIdeographs", runeRange{0xF900, 0xFAFF}},
+	{"AlphabeticPresentationForms", runeRange{0xFB00, 0xFB4F}},
+	{"ArabicPresentationForms-A", runeRange{0xFB50, 0xFDFF}},
+	{"CombiningHalfMarks", runeRange{0xFE20, 0xFE2F}},
+	{"CJKCompatibilityForms", runeRange{0xFE30, 0xFE4F}},
+	{"SmallFormVariants", runeRange{0xFE50, 0xFE6F}},
+	{"ArabicPresentationForms-B", runeRange{0xFE70, 0xFEFE}},
+	{"Specials", runeRange{0xFEFF, 0xFEFF}},
+	{"HalfwidthandFullwidthForms", runeRange{0xFF00, 0xFFEF}},
+	{"Specials", runeRange{0xFFF0, 0xFFFD}},
+	{"OldItalic", runeRange{0x10300, 0x1032F}},
+	{"Gothic", runeRange{0x10330, 0x1034F}},
+	{"Deseret", runeRange{0x10400, 0x1044F}},
+	{"ByzantineMusicalSymbols", runeRange{0x1D000, 0x1D0FF}},
+	{"MusicalSymbols", runeRange{0x1D100, 0x1D1FF}},
+	{"MathematicalAlphanumericSymbols", runeRange{0x1D400, 0x1D7FF}},
+	{"CJKUnifiedIdeographsExtensionB", runeRange{0x20000, 0x2A6D6}},
+	{"CJKCompatibilityIdeographsSupplement", runeRange{0x2F800, 0x2FA1F}},
+	{"Tags", runeRange{0xE0000, 0xE007F}},
+	{"PrivateUse", runeRange{0xF0000, 0xFFFFD}},
+	{"PrivateUse", runeRange{0x100000, 0x10FFFD}},
+}
+
+// xsdDigitRanges is the \d class: the decimal digits of the Unicode tables
+// XML Schema 1.0 was published against.
+var xsdDigitRanges = []runeRange{
+	{0x0030, 0x0039}, {0x0660, 0x0669}, {0x06F0, 0x06F9}, {0x0966, 0x096F},
+	{0x09E6, 0x09EF}, {0x0A66, 0x0A6F}, {0x0AE6, 0x0AEF}, {0x0B66, 0x0B6F},
+	{0x0BE7, 0x0BEF}, {0x0C66, 0x0C6F}, {0x0CE6, 0x0CEF}, {0x0D66, 0x0D6F},
+	{0x0E50, 0x0E59}, {0x0ED0, 0x0ED9}, {0x0F20, 0x0F29}, {0x1040, 0x1049},
+	{0x1369, 0x1371}, {0x17E0, 0x17E9}, {0x1810, 0x1819}, {0x1D7CE, 0x1D7FF},
+	{0xFF10, 0xFF19},
+}
+
+// xmlNameStartRanges and xmlNameExtraRanges follow the XML 1.0 NameStartChar
+// and NameChar productions used by internal/lex.
+var xmlNameStartRanges = []runeRange{
+	{':', ':'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'},
+	{0xC0, 0xD6}, {0xD8, 0xF6}, {0xF8, 0x2FF}, {0x370, 0x37D},
+	{0x37F, 0x1FFF}, {0x200C, 0x200D}, {0x2070, 0x218F}, {0x2C00, 0x2FEF},
+	{0x3001, 0xD7FF}, {0xF900, 0xFDCF}, {0xFDF0, 0xFFFD}, {0x10000, 0xEFFFF},
+}
+
+var xmlNameExtraRanges = []runeRange{
+	{'-', '.'}, {'0', '9'}, {0xB7, 0xB7}, {0x0300, 0x036F}, {0x203F, 0x2040},
+}
+
+var (
+	namedSetsOnce sync.Once
+	categorySets  map[string]charSet
+	blockSets     map[string]charSet
+	escapeSets    map[byte]charSet
+)
+
+func loadNamedSets() {
+	namedSetsOnce.Do(func() {
+		// Cn and the one-letter groups are derived rather than read from
+		// unicode.Categories, whose Cn coverage depends on the Go release.
+		categorySets = make(map[string]charSet, len(xsdCategories))
+		var assigned charSet
+		for _, name := range xsdCategories {
+			if len(name) != 2 || name == "Cn" {
+				continue
+			}
+			set := rangeTableSet(unicode.Categories[name])
+			categorySets[name] = set
+			categorySets[name[:1]] = categorySets[name[:1]].union(set)
+			assigned = assigned.union(set)
+		}
+		surrogates := rangeTableSet(unicode.Cs)
+		unassigned := assigned.union(surrogates).negate()
+		categorySets["Cn"] = unassigned
+		categorySets["C"] = categorySets["C"].union(surrogates).union(unassigned)
+
+		grouped := make(map[string][]runeRange, len(xsdBlocks))
+		for _, b := range xsdBlocks {
+			grouped[b.name] = append(grouped[b.name], b.r)
+		}
+		blockSets = make(map[string]charSet, len(grouped))
+		for name, ranges := range grouped {
+			blockSets[name] = newCharSet(ranges)
+		}
+
+		digit := newCharSet(xsdDigitRanges)
+		space := newCharSet([]runeRange{{'\t', '\n'}, {'\r', '\r'}, {' ', ' '}})
+		nameStart := newCharSet(xmlNameStartRanges)
+		name := nameStart.union(newCharSet(xmlNameExtraRanges))
+		notWord := categorySets["P"].union(categorySets["Z"]).union(categorySets["C"])
+		escapeSets = map[byte]charSet{
+			'd': digit, 'D': digit.negate(),
+			's': space, 'S': space.negate(),
+			'i': nameStart, 'I': nameStart.negate(),
+			'c': name, 'C': name.negate(),
+			'w': notWord.negate(), 'W': notWord,
+		}
+	})
+}
+
+// IsCategory reports whether name is an XML Schema 1.0 general category
+// accepted by \p{name}.
+func IsCategory(name string) bool {
+	loadNamedSets()
+	_, ok := categorySets[name]
+	return ok
+}
+
+// IsBlock reports whether name is an XML Schema 1.0 block name accepted by
+// \p{Isname}.
+func IsBlock(name string) bool {
+	loadNamedSets()
+	_, ok := blockSets[name]
+	return ok
+}
+
+func categorySet(name string) (charSet, bool) {
+	loadNamedSets()
+	if block, ok := strings.CutPrefix(name, "Is"); ok {
+		set, found := blockSets[block]
+		return set, found
+	}
+	set, ok := categorySets[name]
+	return set, ok
+}
+
+func multiCharEscapeSet(c byte) (charSet, bool) {
+	loadNamedSets()
+	set, ok := escapeSets[c]
+	return set, ok
+}
@@ -0,0 +1,135 @@
+package runtime
+
+import (
+	"errors"
+	"slices"
+)
+
+// AnnotatedKind identifies the kind of component an annotation belongs to.
+type AnnotatedKind uint8
+
+const (
+	// AnnotatedSchema keys the top-level annotations of the schema documents.
+	AnnotatedSchema AnnotatedKind = iota
+	// AnnotatedElement keys an element declaration by ElementID.
+	AnnotatedElement
+	// AnnotatedAttribute keys a top-level attribute declaration by AttributeID.
+	AnnotatedAttribute
+	// AnnotatedAttributeUse keys an attribute use by AttributeUseSetID and
+	// attribute name.
+	AnnotatedAttributeUse
+	// AnnotatedSimpleType keys a simple type definition by SimpleTypeID.
+	AnnotatedSimpleType
+	// AnnotatedComplexType keys a complex type definition by ComplexTypeID.
+	AnnotatedComplexType
+	// AnnotatedAttributeGroup keys a named attribute group by the
+	// AttributeUseSetID of its attribute uses.
+	AnnotatedAttributeGroup
+	// AnnotatedIdentity keys an identity constraint by IdentityConstraintID.
+	AnnotatedIdentity
+)
+
+// AnnotationKey identifies the component an annotation belongs to.
+type AnnotationKey struct {
+	// Name is the attribute name of an AnnotatedAttributeUse key.
+	Name QName
+	ID   uint32
+	Kind AnnotatedKind
+}
+
+// Annotation is the retained xs:documentation and xs:appinfo content of one
+// schema component, in document order.
+type Annotation struct {
+	Documentation []Documentation
+	AppInfo       []AppInfo
+}
+
+// Documentation is one retained xs:documentation element.
+type Documentation struct {
+	// Source is the source attribute.
+	Source string
+	// Lang is the in-scope xml:lang value.
+	Lang string
+	// Text is the character data of the element and its descendants.
+	Text string
+	// Content is the element content serialized as XML.
+	Content string
+}
+
+// AppInfo is one retained xs:appinfo element.
+type AppInfo struct {
+	// Source is the source attribute.
+	Source string
+	// Content is the element content serialized as XML.
+	Content string
+}
+
+// Empty reports whether a retains no documentation or appinfo.
+func (a Annotation) Empty() bool {
+	return len(a.Documentation) == 0 && len(a.AppInfo) == 0
+}
+
+// Append adds the documentation and appinfo of other after those of a.
+func (a *Annotation) Append(other Annotation) {
+	a.Documentation = append(a.Documentation, other.Documentation...)
+	a.AppInfo = append(a.AppInfo, other.AppInfo...)
+}
+
+// CloneAnnotation returns a copy of a that shares no slices with it.
+func CloneAnnotation(a Annotation) Annotation {
+	return Annotation{
+		Documentation: slices.Clone(a.Documentation),
+		AppInfo:       slices.Clone(a.AppInfo),
+	}
+}
+
+func cloneAnnotations(in map[AnnotationKey]Annotation) map[AnnotationKey]Annotation {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[AnnotationKey]Annotation, len(in))
+	for key, a := range in {
+		out[key] = CloneAnnotation(a)
+	}
+	return out
+}
+
+func validateAnnotationKeys(annotations map[AnnotationKey]Annotation, build *SchemaBuild) error {
+	for key := range annotations {
+		if !validAnnotationKey(key, build) {
+			return errors.New("annotation references invalid component")
+		}
+	}
+	return nil
+}
+
+func validAnnotationKey(key AnnotationKey, build *SchemaBuild) bool {
+	switch key.Kind {
+	case AnnotatedSchema:
+		return key.ID == 0
+	case AnnotatedElement:
+		return validRuntimeID(key.ID, len(build.Elements))
+	case AnnotatedAttribute:
+		return validRuntimeID(key.ID, len(build.Attributes))
+	case AnnotatedAttributeUse, AnnotatedAttributeGroup:
+		return ValidAttributeUseSetID(AttributeUseSetID(key.ID), len(build.AttributeUseSets))
+	case AnnotatedSimpleType:
+		return validRuntimeID(key.ID, len(build.SimpleTypes))
+	case AnnotatedComplexType:
+		return validRuntimeID(key.ID, len(build.ComplexTypes))
+	case AnnotatedIdentity:
+		return validRuntimeID(key.ID, len(build.Identities))
+	}
+	return false
+}
+
+// Annotation returns a copy of the retained annotation of the component
+// identified by key. It reports false when the schema was compiled without
+// annotation retention or the component has no documentation or appinfo.
+func (rt *Schema) Annotation(key AnnotationKey) (Annotation, bool) {
+	a, ok := rt.runtime.Components.annotations[key]
+	if !ok {
+		return Annotation{}, false
+	}
+	return CloneAnnotation(a), true
+}
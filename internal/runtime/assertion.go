@@ -0,0 +1,382 @@
+package runtime
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/internal/xpath"
+)
+
+// Assertion is one compiled XSD 1.1 assertion: an xs:assert on a complex type
+// or an xs:assertion facet on a simple type.
+type Assertion struct {
+	Test *xpath.Expr
+}
+
+// AssertionError reports an assertion that does not hold. Err is the dynamic
+// evaluation error when the test could not be evaluated; such assertions are
+// not satisfied.
+type AssertionError struct {
+	Err  error
+	Test string
+}
+
+func (e *AssertionError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	msg := "assertion " + strconv.Quote(e.Test) + " is not satisfied"
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *AssertionError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// assertionReadTable is the published assertion projection. Its slices are
+// nil when the schema has no assertions.
+type assertionReadTable struct {
+	simple   [][]Assertion
+	complex  [][]Assertion
+	asserted []bool
+}
+
+func newAssertionReadTable(simpleTypes []SimpleType, complexTypes []ComplexType) assertionReadTable {
+	var table assertionReadTable
+	for i := range simpleTypes {
+		if len(simpleTypes[i].Assertions) == 0 {
+			continue
+		}
+		if table.simple == nil {
+			table.simple = make([][]Assertion, len(simpleTypes))
+		}
+		table.simple[i] = simpleTypes[i].Assertions
+	}
+	for i := range complexTypes {
+		if len(complexTypes[i].Assertions) == 0 {
+			continue
+		}
+		if table.complex == nil {
+			table.complex = make([][]Assertion, len(complexTypes))
+		}
+		table.complex[i] = complexTypes[i].Assertions
+	}
+	if table.simple != nil {
+		table.asserted = simpleAssertionReach(simpleTypes)
+	}
+	return table
+}
+
+// simpleAssertionReach marks simple types whose value validation evaluates an
+// assertion of the type itself, its list item type, or a union member.
+func simpleAssertionReach(types []SimpleType) []bool {
+	const (
+		unvisited uint8 = iota
+		visiting
+		done
+	)
+	state := make([]uint8, len(types))
+	reach := make([]bool, len(types))
+	var visit func(SimpleTypeID) bool
+	visit = func(id SimpleTypeID) bool {
+		if !ValidSimpleTypeID(id, len(types)) {
+			return false
+		}
+		switch state[id] {
+		case done:
+			return reach[id]
+		case visiting:
+			return false
+		}
+		state[id] = visiting
+		st := &types[id]
+		asserted := len(st.Assertions) != 0
+		if st.Variety == SimpleVarietyList && st.ListItem != NoSimpleType && visit(st.ListItem) {
+			asserted = true
+		}
+		for _, member := range st.Union {
+			if visit(member) {
+				asserted = true
+			}
+		}
+		state[id] = done
+		reach[id] = asserted
+		return asserted
+	}
+	for i := range types {
+		visit(SimpleTypeID(i))
+	}
+	return reach
+}
+
+func (t *assertionReadTable) simpleAssertions(id SimpleTypeID) []Assertion {
+	if int(id) >= len(t.simple) {
+		return nil
+	}
+	return t.simple[id]
+}
+
+// simpleAsserted reports whether validating a value of id evaluates any
+// assertion, in which case lexical fast paths must not be taken.
+func (t *assertionReadTable) simpleAsserted(id SimpleTypeID) bool {
+	return int(id) < len(t.asserted) && t.asserted[id]
+}
+
+func (t *assertionReadTable) complexAssertions(id ComplexTypeID) []Assertion {
+	if int(id) >= len(t.complex) {
+		return nil
+	}
+	return t.complex[id]
+}
+
+// ComplexAssertions reports whether elements governed by t carry xs:assert
+// assertions and so must buffer their subtree for evaluation.
+func (rt *Schema) ComplexAssertions(t TypeID) bool {
+	id, ok := t.Complex()
+	return ok && len(rt.runtime.Assertions.complexAssertions(id)) != 0
+}
+
+// SimpleAssertions reports whether values of simple type id are checked by
+// xs:assertion facets.
+func (rt *Schema) SimpleAssertions(id SimpleTypeID) bool {
+	return len(rt.runtime.Assertions.simpleAssertions(id)) != 0
+}
+
+// ValidateComplexAssertions evaluates the assertions of complex type t with
+// root as the context element. Nodes annotated with a non-zero Type carry the
+// simple type ID plus one of their typed value.
+func (rt *Schema) ValidateComplexAssertions(t TypeID, root *xpath.Node, maxSteps int) error {
+	id, ok := t.Complex()
+	if !ok {
+		return nil
+	}
+	host := assertionHost{runtime: &rt.runtime}
+	for _, assertion := range rt.runtime.Assertions.complexAssertions(id) {
+		if err := evaluateAssertion(assertion, xpath.Context{Node: root, Host: host, MaxSteps: maxSteps}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertionNodeType returns the xpath.Node type annotation for a node whose
+// typed value is governed by simple type id.
+func AssertionNodeType(id SimpleTypeID) uint32 {
+	if id == NoSimpleType {
+		return 0
+	}
+	return uint32(id) + 1
+}
+
+func evaluateAssertion(assertion Assertion, ctx xpath.Context) error {
+	ok, err := assertion.Test.Test(ctx)
+	if err != nil || !ok {
+		return &AssertionError{Test: assertion.Test.String(), Err: err}
+	}
+	return nil
+}
+
+func (r *schemaRuntime) validateSimpleAssertions(id SimpleTypeID, lexical string) error {
+	assertions := r.Assertions.simpleAssertions(id)
+	if len(assertions) == 0 {
+		return nil
+	}
+	host := assertionHost{runtime: r}
+	value, err := host.atomize(id, lexical)
+	if err != nil {
+		return err
+	}
+	for _, assertion := range assertions {
+		if err := evaluateAssertion(assertion, xpath.Context{Host: host, Value: value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assertionHost supplies schema datatypes to assertion evaluation.
+type assertionHost struct {
+	runtime *schemaRuntime
+}
+
+func (h assertionHost) Atomize(typ uint32, lexical string) ([]xpath.Atomic, error) {
+	if typ == 0 {
+		return []xpath.Atomic{xpath.Untyped(lexical)}, nil
+	}
+	return h.atomize(SimpleTypeID(typ-1), lexical)
+}
+
+func (h assertionHost) Cast(local, lexical string) (xpath.Atomic, error) {
+	id, ok := h.builtinSimpleType(local)
+	if !ok {
+		return xpath.Atomic{}, errors.New("cast to xs:" + local + " is not supported")
+	}
+	reader := publishedSimpleValueMetadataReader{runtime: h.runtime}
+	if _, err := validateSimpleValue(reader, id, lexical, nil, 0, nil); err != nil {
+		return xpath.Atomic{}, errors.New("cannot cast " + strconv.Quote(lexical) + " to xs:" + local + ": " + err.Error())
+	}
+	values, err := h.atomize(id, lexical)
+	if err != nil {
+		return xpath.Atomic{}, err
+	}
+	if len(values) != 1 {
+		return xpath.Atomic{}, errors.New("cast to xs:" + local + " is not atomic")
+	}
+	return values[0], nil
+}
+
+func (h assertionHost) builtinSimpleType(local string) (SimpleTypeID, bool) {
+	q, ok := h.runtime.Names.LookupQName(XSDNamespaceURI, local)
+	if !ok {
+		return NoSimpleType, false
+	}
+	t, ok := h.runtime.GlobalTypes[q]
+	if !ok {
+		return NoSimpleType, false
+	}
+	return t.Simple()
+}
+
+// atomize returns the typed value of lexical, already known to be valid for
+// simple type id.
+func (h assertionHost) atomize(id SimpleTypeID, lexical string) ([]xpath.Atomic, error) {
+	reader := publishedSimpleValueMetadataReader{runtime: h.runtime}
+	typ, ok := reader.simpleValueType(id)
+	if !ok {
+		return nil, ErrSimpleValueMetadata
+	}
+	normalized := normalizeSimpleValueLexical(lexical, typ.Whitespace)
+	switch typ.Variety {
+	case SimpleVarietyList:
+		var out []xpath.Atomic
+		var itemErr error
+		forEachSimpleValueListItem(normalized, func(item string) bool {
+			values, err := h.atomize(typ.ListItem, item)
+			if err != nil {
+				itemErr = err
+				return false
+			}
+			out = append(out, values...)
+			return true
+		})
+		return out, itemErr
+	case SimpleVarietyUnion:
+		for _, member := range typ.UnionMembers {
+			if _, err := validateSimpleValue(reader, member, normalized, nil, 0, nil); err == nil {
+				return h.atomize(member, normalized)
+			}
+		}
+		return []xpath.Atomic{xpath.Untyped(normalized)}, nil
+	default:
+		value, err := atomicAssertionValue(typ, normalized)
+		if err != nil {
+			return nil, err
+		}
+		return []xpath.Atomic{value}, nil
+	}
+}
+
+func atomicAssertionValue(typ SimpleValueType, normalized string) (xpath.Atomic, error) {
+	switch typ.Primitive {
+	case PrimitiveString:
+		return xpath.String(normalized), nil
+	case PrimitiveBoolean:
+		value, err := ParseBooleanValue(normalized)
+		if err != nil {
+			return xpath.Atomic{}, err
+		}
+		return xpath.Boolean(value), nil
+	case PrimitiveDecimal:
+		if typ.Builtin == BuiltinValidationInteger {
+			return xpath.ParseNumber(xpath.TypeInteger, normalized)
+		}
+		return xpath.ParseNumber(xpath.TypeDecimal, normalized)
+	case PrimitiveFloat:
+		return xpath.ParseNumber(xpath.TypeFloat, normalized)
+	case PrimitiveDouble:
+		return xpath.ParseNumber(xpath.TypeDouble, normalized)
+	case PrimitiveDuration, PrimitiveDateTime, PrimitiveTime, PrimitiveDate,
+		PrimitiveGYearMonth, PrimitiveGYear, PrimitiveGMonthDay, PrimitiveGDay, PrimitiveGMonth:
+		result, err := ParsePrimitiveActual(typ.Primitive, normalized, 0)
+		if err != nil {
+			return xpath.Atomic{}, err
+		}
+		return xpath.Other(primitiveLocalName(typ.Primitive), normalized, assertionActual(result.Actual)), nil
+	default:
+		return xpath.StringLike(primitiveLocalName(typ.Primitive), normalized), nil
+	}
+}
+
+// assertionActual orders primitive actual values of one datatype for XPath
+// comparisons.
+type assertionActual PrimitiveActualValue
+
+func (a assertionActual) Compare(other xpath.Comparable) (int, bool) {
+	b, ok := other.(assertionActual)
+	if !ok || a.Kind != b.Kind {
+		return 0, false
+	}
+	var relation OrderedFacetRelation
+	switch a.Kind {
+	case PrimitiveDuration:
+		relation = CompareDurationValues(a.Duration, b.Duration)
+	case PrimitiveDate, PrimitiveDateTime:
+		relation = CompareTemporalValues(a.Temporal, b.Temporal)
+	case PrimitiveTime:
+		relation = CompareTimePartial(a.Time, b.Time)
+	default:
+		relation = CompareGValues(a.G, b.G)
+	}
+	switch relation {
+	case OrderedFacetLess:
+		return -1, true
+	case OrderedFacetEqual:
+		return 0, true
+	case OrderedFacetGreater:
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+func primitiveLocalName(kind PrimitiveKind) string {
+	switch kind {
+	case PrimitiveDuration:
+		return vocab.XSDValueDuration
+	case PrimitiveDateTime:
+		return vocab.XSDValueDateTime
+	case PrimitiveTime:
+		return vocab.XSDValueTime
+	case PrimitiveDate:
+		return vocab.XSDValueDate
+	case PrimitiveGYearMonth:
+		return vocab.XSDValueGYearMonth
+	case PrimitiveGYear:
+		return vocab.XSDValueGYear
+	case PrimitiveGMonthDay:
+		return vocab.XSDValueGMonthDay
+	case PrimitiveGDay:
+		return vocab.XSDValueGDay
+	case PrimitiveGMonth:
+		return vocab.XSDValueGMonth
+	case PrimitiveHexBinary:
+		return vocab.XSDValueHexBinary
+	case PrimitiveBase64Binary:
+		return vocab.XSDValueBase64Binary
+	case PrimitiveAnyURI:
+		return vocab.XSDValueAnyURI
+	case PrimitiveQName:
+		return vocab.XSDValueQName
+	case PrimitiveNotation:
+		return vocab.XSDValueNOTATION
+	default:
+		return vocab.XSDValueString
+	}
+}
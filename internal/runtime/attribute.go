@@ -733,7 +733,7 @@ func ValidateAttributeUseSetRestriction(
 			return errors.New("complex restriction adds attribute outside base wildcard")
 		}
 		wildcard, ok := rt.Wildcard(baseWildcard.Wildcard)
-		if !ok || !WildcardAllowsNamespace(wildcard, use.Name.Namespace) {
+		if !ok || !WildcardAllowsQName(wildcard, use.Name) {
 			return errors.New("complex restriction adds attribute outside base wildcard")
 		}
 	}
@@ -144,7 +144,10 @@ func (s BuiltinAttributeSeed) TypeID(ids BuiltinIDs, internal BuiltinAttributeIn
 		BuiltinValidationNCName,
 		BuiltinValidationNMTOKEN,
 		BuiltinValidationLanguage,
-		BuiltinValidationEntity:
+		BuiltinValidationEntity,
+		BuiltinValidationDayTimeDuration,
+		BuiltinValidationYearMonthDuration,
+		BuiltinValidationError:
 	}
 	switch s.handle {
 	case builtinSimpleString:
@@ -155,6 +158,7 @@ func (s BuiltinAttributeSeed) TypeID(ids BuiltinIDs, internal BuiltinAttributeIn
 		return ids.ID, ids.ID != NoSimpleType
 	case builtinSimpleNoHandle,
 		builtinSimpleAnySimpleType,
+		builtinSimpleAnyAtomicType,
 		builtinSimpleBoolean,
 		builtinSimpleDecimal,
 		builtinSimpleInteger,
@@ -168,7 +172,8 @@ func (s BuiltinAttributeSeed) TypeID(ids BuiltinIDs, internal BuiltinAttributeIn
 		builtinSimpleNMTOKEN,
 		builtinSimpleNMTOKENS,
 		builtinSimpleENTITY,
-		builtinSimpleENTITIES:
+		builtinSimpleENTITIES,
+		builtinSimpleError:
 	}
 	return NoSimpleType, false
 }
@@ -218,6 +223,7 @@ type BuiltinSimpleFacetExpectation struct {
 	MaxInclusive      string
 	Type              SimpleTypeID
 	MinLength         uint32
+	ExplicitTimezone  ExplicitTimezone
 	HasFractionDigits bool
 	HasMinLength      bool
 }
@@ -243,19 +249,20 @@ type BuiltinDecimalFacet struct {
 // BuiltinSimpleFacetValidation is the runtime projection needed to validate
 // facets on fixed built-in simple-type declarations.
 type BuiltinSimpleFacetValidation struct {
-	MinInclusive    BuiltinDecimalFacet
-	MaxInclusive    BuiltinDecimalFacet
-	FractionDigits  BuiltinUnsignedFacet
-	MinLength       BuiltinUnsignedFacet
-	EnumerationSize int
-	PatternSize     int
-	Present         FacetMask
-	Fixed           FacetMask
-	HasLength       bool
-	HasMaxLength    bool
-	HasTotalDigits  bool
-	HasMinExclusive bool
-	HasMaxExclusive bool
+	MinInclusive     BuiltinDecimalFacet
+	MaxInclusive     BuiltinDecimalFacet
+	FractionDigits   BuiltinUnsignedFacet
+	MinLength        BuiltinUnsignedFacet
+	EnumerationSize  int
+	PatternSize      int
+	Present          FacetMask
+	Fixed            FacetMask
+	ExplicitTimezone ExplicitTimezone
+	HasLength        bool
+	HasMaxLength     bool
+	HasTotalDigits   bool
+	HasMinExclusive  bool
+	HasMaxExclusive  bool
 }
 
 type builtinSimpleExpectation struct {
@@ -271,9 +278,11 @@ type builtinSimpleExpectation struct {
 	whitespace        WhitespaceMode
 	builtin           BuiltinValidationKind
 	identity          SimpleIdentityKind
+	explicitTimezone  ExplicitTimezone
 	checkID           bool
 	hasFractionDigits bool
 	hasMinLength      bool
+	xsd11             bool
 }
 
 // BuiltinSimpleSeed is the runtime-owned construction spec for one built-in
@@ -292,11 +301,15 @@ type BuiltinSimpleSeed struct {
 	Builtin           BuiltinValidationKind
 	Identity          SimpleIdentityKind
 	Fast              SimpleFastKind
+	explicitTimezone  ExplicitTimezone
 	HasFractionDigits bool
 	HasMinLength      bool
-	hasMinInclusive   bool
-	hasMaxInclusive   bool
-	handle            builtinSimpleHandle
+	// XSD11 marks types that schema documents may reference only in XSD 1.1
+	// mode.
+	XSD11           bool
+	hasMinInclusive bool
+	hasMaxInclusive bool
+	handle          builtinSimpleHandle
 }
 
 type builtinSimpleHandle uint8
@@ -304,6 +317,7 @@ type builtinSimpleHandle uint8
 const (
 	builtinSimpleNoHandle builtinSimpleHandle = iota
 	builtinSimpleAnySimpleType
+	builtinSimpleAnyAtomicType
 	builtinSimpleString
 	builtinSimpleBoolean
 	builtinSimpleDecimal
@@ -321,6 +335,7 @@ const (
 	builtinSimpleNMTOKENS
 	builtinSimpleENTITY
 	builtinSimpleENTITIES
+	builtinSimpleError
 )
 
 // BuiltinSimpleSeedCount returns the number of topologically ordered fixed XSD
@@ -361,8 +376,10 @@ func builtinSimpleSeedForExpectation(exp builtinSimpleExpectation) BuiltinSimple
 		Builtin:           exp.builtin,
 		Identity:          exp.identity,
 		Fast:              builtinSimpleFastKind(exp),
+		explicitTimezone:  exp.explicitTimezone,
 		HasFractionDigits: exp.hasFractionDigits,
 		HasMinLength:      exp.hasMinLength,
+		XSD11:             exp.xsd11,
 		hasMinInclusive:   exp.minInclusive != "",
 		hasMaxInclusive:   exp.maxInclusive != "",
 		handle:            builtinSimpleHandleForLocal(exp.local),
@@ -407,6 +424,8 @@ func (s *BuiltinSimpleSeed) RecordID(ids *BuiltinIDs, id SimpleTypeID) {
 	switch s.handle {
 	case builtinSimpleAnySimpleType:
 		ids.AnySimpleType = id
+	case builtinSimpleAnyAtomicType:
+		ids.AnyAtomicType = id
 	case builtinSimpleString:
 		ids.String = id
 	case builtinSimpleBoolean:
@@ -441,6 +460,8 @@ func (s *BuiltinSimpleSeed) RecordID(ids *BuiltinIDs, id SimpleTypeID) {
 		ids.ENTITY = id
 	case builtinSimpleENTITIES:
 		ids.ENTITIES = id
+	case builtinSimpleError:
+		ids.Error = id
 	case builtinSimpleNoHandle:
 	}
 }
@@ -461,6 +482,9 @@ func (s *BuiltinSimpleFacetStorage) facetSet(seed *BuiltinSimpleSeed) FacetSet {
 	if seed.hasMaxInclusive {
 		SetBoundFacet(&f, FacetMaxInclusive, seed.maxInclusive, false)
 	}
+	if seed.explicitTimezone != ExplicitTimezoneOptional {
+		SetExplicitTimezoneFacet(&f, seed.explicitTimezone, false)
+	}
 	return f
 }
 
@@ -519,6 +543,8 @@ func builtinSimpleHandleForLocal(local string) builtinSimpleHandle {
 	switch local {
 	case vocab.XSDValueAnySimpleType:
 		return builtinSimpleAnySimpleType
+	case vocab.XSDValueAnyAtomicType:
+		return builtinSimpleAnyAtomicType
 	case vocab.XSDValueString:
 		return builtinSimpleString
 	case vocab.XSDValueBoolean:
@@ -553,6 +579,8 @@ func builtinSimpleHandleForLocal(local string) builtinSimpleHandle {
 		return builtinSimpleENTITY
 	case vocab.XSDValueENTITIES:
 		return builtinSimpleENTITIES
+	case vocab.XSDValueError:
+		return builtinSimpleError
 	default:
 		return builtinSimpleNoHandle
 	}
@@ -580,19 +608,20 @@ func NewBuiltinSimpleFacetValidation(f FacetSet, exp BuiltinSimpleFacetExpectati
 	minInclusive, hasMinInclusive := BoundFacet(f, FacetMinInclusive)
 	maxInclusive, hasMaxInclusive := BoundFacet(f, FacetMaxInclusive)
 	return BuiltinSimpleFacetValidation{
-		MinInclusive:    newBuiltinDecimalFacet(minInclusive, hasMinInclusive, exp.MinInclusive),
-		MaxInclusive:    newBuiltinDecimalFacet(maxInclusive, hasMaxInclusive, exp.MaxInclusive),
-		FractionDigits:  newBuiltinUnsignedFacet(f.FractionDigits, f.Present&FacetFractionDigits != 0),
-		MinLength:       newBuiltinUnsignedFacet(f.MinLength, f.Present&FacetMinLength != 0),
-		EnumerationSize: len(f.Enumeration),
-		PatternSize:     int(f.patterns.count()),
-		Present:         f.Present,
-		Fixed:           f.Fixed,
-		HasLength:       f.Present&FacetLength != 0,
-		HasMaxLength:    f.Present&FacetMaxLength != 0,
-		HasTotalDigits:  f.Present&FacetTotalDigits != 0,
-		HasMinExclusive: f.Present&FacetMinExclusive != 0,
-		HasMaxExclusive: f.Present&FacetMaxExclusive != 0,
+		MinInclusive:     newBuiltinDecimalFacet(minInclusive, hasMinInclusive, exp.MinInclusive),
+		MaxInclusive:     newBuiltinDecimalFacet(maxInclusive, hasMaxInclusive, exp.MaxInclusive),
+		FractionDigits:   newBuiltinUnsignedFacet(f.FractionDigits, f.Present&FacetFractionDigits != 0),
+		MinLength:        newBuiltinUnsignedFacet(f.MinLength, f.Present&FacetMinLength != 0),
+		EnumerationSize:  len(f.Enumeration),
+		PatternSize:      int(f.patterns.count()),
+		Present:          f.Present,
+		Fixed:            f.Fixed,
+		ExplicitTimezone: f.ExplicitTimezone,
+		HasLength:        f.Present&FacetLength != 0,
+		HasMaxLength:     f.Present&FacetMaxLength != 0,
+		HasTotalDigits:   f.Present&FacetTotalDigits != 0,
+		HasMinExclusive:  f.Present&FacetMinExclusive != 0,
+		HasMaxExclusive:  f.Present&FacetMaxExclusive != 0,
 	}
 }
 
@@ -677,6 +706,9 @@ func ValidateBuiltinSimpleFacets(shape BuiltinSimpleFacetValidation, exp Builtin
 	if exp.HasMinLength && !builtinUnsignedFacetValue(shape.MinLength, exp.MinLength) {
 		return errors.New("builtin list minLength facet does not match handle")
 	}
+	if shape.ExplicitTimezone != exp.ExplicitTimezone {
+		return errors.New("builtin explicitTimezone facet does not match handle")
+	}
 	if !builtinDecimalFacetValue(shape.MinInclusive, exp.MinInclusive, exp.Type) ||
 		!builtinDecimalFacetValue(shape.MaxInclusive, exp.MaxInclusive, exp.Type) {
 		return errors.New("builtin numeric bound facet does not match handle")
@@ -707,6 +739,9 @@ func builtinSimpleExpectedFacetMask(exp BuiltinSimpleFacetExpectation) FacetMask
 	if exp.HasMinLength {
 		present |= FacetMinLength
 	}
+	if exp.ExplicitTimezone != ExplicitTimezoneOptional {
+		present |= FacetExplicitTimezone
+	}
 	return present
 }
 
@@ -742,6 +777,7 @@ func (exp builtinSimpleExpectation) facetExpectation(compilationType SimpleTypeI
 		MaxInclusive:      exp.maxInclusive,
 		Type:              compilationType,
 		MinLength:         exp.minLength,
+		ExplicitTimezone:  exp.explicitTimezone,
 		HasFractionDigits: exp.hasFractionDigits,
 		HasMinLength:      exp.hasMinLength,
 	}
@@ -777,6 +813,7 @@ func builtinAttributeExpectationForSeed(seed BuiltinAttributeSeed, builtins Buil
 		exp.typ = builtins.ID
 	case builtinSimpleNoHandle,
 		builtinSimpleAnySimpleType,
+		builtinSimpleAnyAtomicType,
 		builtinSimpleBoolean,
 		builtinSimpleDecimal,
 		builtinSimpleInteger,
@@ -790,21 +827,23 @@ func builtinAttributeExpectationForSeed(seed BuiltinAttributeSeed, builtins Buil
 		builtinSimpleNMTOKEN,
 		builtinSimpleNMTOKENS,
 		builtinSimpleENTITY,
-		builtinSimpleENTITIES:
+		builtinSimpleENTITIES,
+		builtinSimpleError:
 	}
 	return exp
 }
 
 var builtinSimpleExpectationTable = [...]builtinSimpleExpectation{
 	{local: vocab.XSDValueAnySimpleType, checkID: true, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespacePreserve, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueString, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespacePreserve, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueAnyAtomicType, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespacePreserve, builtin: BuiltinValidationNone, identity: SimpleIdentityNone, xsd11: true},
+	{local: vocab.XSDValueString, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespacePreserve, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueNormalized, baseLocal: vocab.XSDValueString, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceReplace, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueToken, baseLocal: vocab.XSDValueNormalized, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueLanguage, baseLocal: vocab.XSDValueToken, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationLanguage, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueName, baseLocal: vocab.XSDValueToken, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationName, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueNCName, baseLocal: vocab.XSDValueName, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNCName, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueBoolean, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveBoolean, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueDecimal, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueBoolean, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveBoolean, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueDecimal, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueInteger, checkID: true, baseLocal: vocab.XSDValueDecimal, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationInteger, identity: SimpleIdentityNone, hasFractionDigits: true},
 	{local: vocab.XSDValueNonPositive, baseLocal: vocab.XSDValueInteger, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationInteger, identity: SimpleIdentityNone, hasFractionDigits: true, maxInclusive: "0"},
 	{local: vocab.XSDValueNegative, baseLocal: vocab.XSDValueNonPositive, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationInteger, identity: SimpleIdentityNone, hasFractionDigits: true, maxInclusive: "-1"},
@@ -818,22 +857,25 @@ var builtinSimpleExpectationTable = [...]builtinSimpleExpectation{
 	{local: vocab.XSDValueUnsignedInt, baseLocal: vocab.XSDValueUnsignedLong, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationInteger, identity: SimpleIdentityNone, hasFractionDigits: true, minInclusive: "0", maxInclusive: "4294967295"},
 	{local: vocab.XSDValueUnsignedShort, baseLocal: vocab.XSDValueUnsignedInt, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationInteger, identity: SimpleIdentityNone, hasFractionDigits: true, minInclusive: "0", maxInclusive: "65535"},
 	{local: vocab.XSDValueUnsignedByte, baseLocal: vocab.XSDValueUnsignedShort, variety: SimpleVarietyAtomic, primitive: PrimitiveDecimal, whitespace: WhitespaceCollapse, builtin: BuiltinValidationInteger, identity: SimpleIdentityNone, hasFractionDigits: true, minInclusive: "0", maxInclusive: "255"},
-	{local: vocab.XSDValueFloat, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveFloat, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueDouble, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveDouble, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueDuration, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveDuration, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueDate, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveDate, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueDateTime, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveDateTime, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueTime, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveTime, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueGYearMonth, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveGYearMonth, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueGYear, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveGYear, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueGMonthDay, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveGMonthDay, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueGDay, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveGDay, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueGMonth, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveGMonth, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueAnyURI, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveAnyURI, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueHexBinary, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveHexBinary, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueBase64Binary, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveBase64Binary, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueQName, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveQName, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
-	{local: vocab.XSDValueNOTATION, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveNotation, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueFloat, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveFloat, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueDouble, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveDouble, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueDuration, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveDuration, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueDayTimeDuration, baseLocal: vocab.XSDValueDuration, variety: SimpleVarietyAtomic, primitive: PrimitiveDuration, whitespace: WhitespaceCollapse, builtin: BuiltinValidationDayTimeDuration, identity: SimpleIdentityNone, xsd11: true},
+	{local: vocab.XSDValueYearMonthDuration, baseLocal: vocab.XSDValueDuration, variety: SimpleVarietyAtomic, primitive: PrimitiveDuration, whitespace: WhitespaceCollapse, builtin: BuiltinValidationYearMonthDuration, identity: SimpleIdentityNone, xsd11: true},
+	{local: vocab.XSDValueDate, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveDate, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueDateTime, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveDateTime, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueDateTimeStamp, baseLocal: vocab.XSDValueDateTime, variety: SimpleVarietyAtomic, primitive: PrimitiveDateTime, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone, explicitTimezone: ExplicitTimezoneRequired, xsd11: true},
+	{local: vocab.XSDValueTime, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveTime, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueGYearMonth, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveGYearMonth, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueGYear, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveGYear, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueGMonthDay, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveGMonthDay, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueGDay, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveGDay, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueGMonth, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveGMonth, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueAnyURI, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveAnyURI, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueHexBinary, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveHexBinary, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueBase64Binary, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveBase64Binary, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueQName, checkID: true, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveQName, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
+	{local: vocab.XSDValueNOTATION, baseLocal: vocab.XSDValueAnyAtomicType, variety: SimpleVarietyAtomic, primitive: PrimitiveNotation, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueID, checkID: true, baseLocal: vocab.XSDValueNCName, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNCName, identity: SimpleIdentityID},
 	{local: vocab.XSDValueIDREF, checkID: true, baseLocal: vocab.XSDValueNCName, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNCName, identity: SimpleIdentityIDREF},
 	{local: vocab.XSDValueIDREFS, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, listItemLocal: vocab.XSDValueIDREF, variety: SimpleVarietyList, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityIDREFList, hasMinLength: true, minLength: 1},
@@ -841,6 +883,7 @@ var builtinSimpleExpectationTable = [...]builtinSimpleExpectation{
 	{local: vocab.XSDValueNMTOKENS, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, listItemLocal: vocab.XSDValueNMTOKEN, variety: SimpleVarietyList, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone, hasMinLength: true, minLength: 1},
 	{local: vocab.XSDValueENTITY, checkID: true, baseLocal: vocab.XSDValueNCName, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationEntity, identity: SimpleIdentityNone},
 	{local: vocab.XSDValueENTITIES, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, listItemLocal: vocab.XSDValueENTITY, variety: SimpleVarietyList, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationNone, identity: SimpleIdentityNone, hasMinLength: true, minLength: 1},
+	{local: vocab.XSDValueError, checkID: true, baseLocal: vocab.XSDValueAnySimpleType, variety: SimpleVarietyAtomic, primitive: PrimitiveString, whitespace: WhitespaceCollapse, builtin: BuiltinValidationError, identity: SimpleIdentityNone, xsd11: true},
 }
 
 func builtinSimpleExpectationWithBuiltins(exp builtinSimpleExpectation, builtins BuiltinIDs) builtinSimpleExpectation {
@@ -850,6 +893,8 @@ func builtinSimpleExpectationWithBuiltins(exp builtinSimpleExpectation, builtins
 	switch builtinSimpleHandleForLocal(exp.local) {
 	case builtinSimpleAnySimpleType:
 		exp.id = builtins.AnySimpleType
+	case builtinSimpleAnyAtomicType:
+		exp.id = builtins.AnyAtomicType
 	case builtinSimpleString:
 		exp.id = builtins.String
 	case builtinSimpleBoolean:
@@ -884,6 +929,8 @@ func builtinSimpleExpectationWithBuiltins(exp builtinSimpleExpectation, builtins
 		exp.id = builtins.ENTITY
 	case builtinSimpleENTITIES:
 		exp.id = builtins.ENTITIES
+	case builtinSimpleError:
+		exp.id = builtins.Error
 	case builtinSimpleNoHandle:
 	}
 	return exp
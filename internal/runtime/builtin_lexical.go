@@ -137,6 +137,12 @@ func ValidateBuiltinDerived(in BuiltinDerivedInput) error {
 		if in.Norm != vocab.XMLValueDefault && in.Norm != vocab.XMLValuePreserve {
 			return fmt.Errorf("invalid xml:space")
 		}
+	case BuiltinValidationDayTimeDuration:
+		return validateDayTimeDurationLexical(in.Norm)
+	case BuiltinValidationYearMonthDuration:
+		return validateYearMonthDurationLexical(in.Norm)
+	case BuiltinValidationError:
+		return fmt.Errorf("xs:error has no valid values")
 	}
 	return nil
 }
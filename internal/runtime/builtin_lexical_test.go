@@ -109,6 +109,43 @@ func TestValidateBuiltinDerived(t *testing.T) {
 			},
 			wantErr: "invalid xml:space",
 		},
+		{
+			name: "dayTimeDuration",
+			in: BuiltinDerivedInput{
+				Kind: BuiltinValidationDayTimeDuration,
+				Norm: "-P1DT2H3M",
+			},
+		},
+		{
+			name: "dayTimeDuration rejects months",
+			in: BuiltinDerivedInput{
+				Kind: BuiltinValidationDayTimeDuration,
+				Norm: "P1M",
+			},
+			wantErr: "invalid dayTimeDuration",
+		},
+		{
+			name: "yearMonthDuration",
+			in: BuiltinDerivedInput{
+				Kind: BuiltinValidationYearMonthDuration,
+				Norm: "P1Y2M",
+			},
+		},
+		{
+			name: "yearMonthDuration rejects time",
+			in: BuiltinDerivedInput{
+				Kind: BuiltinValidationYearMonthDuration,
+				Norm: "P1YT1M",
+			},
+			wantErr: "invalid yearMonthDuration",
+		},
+		{
+			name: "error rejects every value",
+			in: BuiltinDerivedInput{
+				Kind: BuiltinValidationError,
+			},
+			wantErr: "xs:error has no valid values",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
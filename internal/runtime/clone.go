@@ -5,6 +5,7 @@ import "slices"
 // CloneWildcard deep-clones wildcard metadata.
 func CloneWildcard(in Wildcard) Wildcard {
 	in.Namespaces = slices.Clone(in.Namespaces)
+	in.NotQNames = slices.Clone(in.NotQNames)
 	return in
 }
 
@@ -41,6 +41,9 @@ type CompiledModel struct {
 	Kind      CompiledModelKind
 	Mixed     bool
 	Empty     bool
+	// Open is the open content of the source model, matched when the
+	// compiled model cannot accept an element.
+	Open OpenContent
 }
 
 // DFARowIndex stores the optional name index for a compiled DFA row.
@@ -210,6 +213,9 @@ func validateCompiledModelRuntime(
 	if model.Mixed != source.Mixed {
 		return errors.New("compiled content model mixed flag does not match source model")
 	}
+	if model.Open != source.Open {
+		return errors.New("compiled content model open content does not match source model")
+	}
 	if !ValidCompiledModelKind(model.Kind) {
 		return errors.New("compiled content model has invalid kind")
 	}
@@ -91,6 +91,9 @@ type ComplexType struct {
 	Block              DerivationMask
 	Final              DerivationMask
 	Scope              DeclarationScope
+	// Assertions are the xs:assert assertions of the type, including those
+	// inherited from its base type.
+	Assertions []Assertion
 }
 
 // ComplexTypeByID resolves a complex type ID against a complex-type table.
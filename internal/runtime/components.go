@@ -0,0 +1,403 @@
+package runtime
+
+import (
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// componentTable retains the schema component properties that validation
+// read projections drop. It backs read-only schema introspection and shares
+// no storage with the compiler-owned build.
+type componentTable struct {
+	attributeGroups  map[QName]AttributeUseSetID
+	globalIdentities map[QName]IdentityConstraintID
+	annotations      map[AnnotationKey]Annotation
+	locations        map[LocationKey]SourceLocation
+	simpleTypes      []SimpleTypeComponent
+	complexTypes     []ComplexTypeComponent
+	elements         []ElementComponent
+	attributes       []AttributeComponent
+	attributeUseSets []AttributeUseSetComponent
+	identities       []IdentityComponent
+	models           []ContentModel
+	wildcards        []Wildcard
+}
+
+// SimpleTypeComponent is the introspection view of a simple type definition.
+type SimpleTypeComponent struct {
+	// Members are the direct member types of a union defined by xs:union.
+	// They are nil for restrictions of union types.
+	Members    []SimpleTypeID
+	Facets     FacetComponent
+	Base       SimpleTypeID
+	ListItem   SimpleTypeID
+	Variety    SimpleVariety
+	Primitive  PrimitiveKind
+	Whitespace WhitespaceMode
+	Final      DerivationMask
+}
+
+// FacetComponent holds the lexical values of the facets in effect for a
+// simple type, including those inherited from its base types.
+type FacetComponent struct {
+	Enumeration []string
+	// EnumerationNames holds the QName resolutions of each enumeration value
+	// of QName and NOTATION types. It is nil for other types.
+	EnumerationNames [][]ResolvedValueName
+	// Patterns holds one group per derivation step, base type steps first.
+	// A value must match one pattern of every group.
+	Patterns         [][]string
+	MinInclusive     string
+	MaxInclusive     string
+	MinExclusive     string
+	MaxExclusive     string
+	Length           uint32
+	MinLength        uint32
+	MaxLength        uint32
+	TotalDigits      uint32
+	FractionDigits   uint32
+	Present          FacetMask
+	Fixed            FacetMask
+	ExplicitTimezone ExplicitTimezone
+}
+
+// ComplexTypeComponent is the introspection view of a complex type definition.
+type ComplexTypeComponent struct {
+	Base        TypeID
+	Content     ContentModelID
+	Attrs       AttributeUseSetID
+	TextType    SimpleTypeID
+	ContentKind ContentKind
+	Derivation  DerivationKind
+	Abstract    bool
+	Block       DerivationMask
+	Final       DerivationMask
+}
+
+// ValueConstraintComponent is the lexical form of a default or fixed value.
+type ValueConstraintComponent struct {
+	Lexical string
+	Present bool
+}
+
+// ElementComponent is the introspection view of an element declaration.
+type ElementComponent struct {
+	Identity  []IdentityConstraintID
+	Default   ValueConstraintComponent
+	Fixed     ValueConstraintComponent
+	Type      TypeID
+	Name      QName
+	SubstHead ElementID
+	Nillable  bool
+	Abstract  bool
+	Block     DerivationMask
+	Final     DerivationMask
+}
+
+// AttributeComponent is the introspection view of an attribute declaration.
+type AttributeComponent struct {
+	Default ValueConstraintComponent
+	Fixed   ValueConstraintComponent
+	Name    QName
+	Type    SimpleTypeID
+}
+
+// AttributeUseSetComponent is the introspection view of an attribute-use set.
+type AttributeUseSetComponent struct {
+	Uses     []AttributeUseComponent
+	Wildcard WildcardID
+}
+
+// AttributeUseComponent is the introspection view of an attribute use.
+type AttributeUseComponent struct {
+	Default    ValueConstraintComponent
+	Fixed      ValueConstraintComponent
+	Name       QName
+	Type       SimpleTypeID
+	Required   bool
+	Prohibited bool
+}
+
+// IdentityComponent is the introspection view of an identity constraint.
+type IdentityComponent struct {
+	Fields   []string
+	Selector string
+	Name     QName
+	Refer    IdentityConstraintID
+	Kind     IdentityKind
+}
+
+func newComponentTable(build *SchemaBuild) componentTable {
+	table := componentTable{
+		attributeGroups:  maps.Clone(build.AttributeGroups),
+		globalIdentities: maps.Clone(build.GlobalIdentities),
+		annotations:      cloneAnnotations(build.Annotations),
+		locations:        cloneLocations(build.Locations),
+		simpleTypes:      make([]SimpleTypeComponent, len(build.SimpleTypes)),
+		complexTypes:     make([]ComplexTypeComponent, len(build.ComplexTypes)),
+		elements:         make([]ElementComponent, len(build.Elements)),
+		attributes:       make([]AttributeComponent, len(build.Attributes)),
+		attributeUseSets: make([]AttributeUseSetComponent, len(build.AttributeUseSets)),
+		identities:       make([]IdentityComponent, len(build.Identities)),
+		models:           make([]ContentModel, len(build.Models)),
+		wildcards:        make([]Wildcard, len(build.Wildcards)),
+	}
+	for i := range build.SimpleTypes {
+		table.simpleTypes[i] = newSimpleTypeComponent(&build.SimpleTypes[i])
+	}
+	for i, ct := range build.ComplexTypes {
+		table.complexTypes[i] = ComplexTypeComponent{
+			Base:        ct.Base,
+			Content:     ct.Content,
+			Attrs:       ct.Attrs,
+			TextType:    ct.TextType,
+			ContentKind: ct.ContentKind,
+			Derivation:  ct.Derivation,
+			Abstract:    ct.Abstract,
+			Block:       ct.Block,
+			Final:       ct.Final,
+		}
+	}
+	for i, decl := range build.Elements {
+		table.elements[i] = ElementComponent{
+			Identity:  slices.Clone(decl.Identity),
+			Default:   newValueConstraintComponent(decl.Default),
+			Fixed:     newValueConstraintComponent(decl.Fixed),
+			Type:      decl.Type,
+			Name:      decl.Name,
+			SubstHead: decl.SubstHead,
+			Nillable:  decl.Nillable,
+			Abstract:  decl.Abstract,
+			Block:     decl.Block,
+			Final:     decl.Final,
+		}
+	}
+	for i, decl := range build.Attributes {
+		table.attributes[i] = AttributeComponent{
+			Default: newValueConstraintComponent(decl.Default),
+			Fixed:   newValueConstraintComponent(decl.Fixed),
+			Name:    decl.Name,
+			Type:    decl.Type,
+		}
+	}
+	for i, set := range build.AttributeUseSets {
+		uses := make([]AttributeUseComponent, len(set.Uses))
+		for j, use := range set.Uses {
+			uses[j] = AttributeUseComponent{
+				Default:    newValueConstraintComponent(use.Default),
+				Fixed:      newValueConstraintComponent(use.Fixed),
+				Name:       use.Name,
+				Type:       use.Type,
+				Required:   use.Required,
+				Prohibited: use.Prohibited,
+			}
+		}
+		table.attributeUseSets[i] = AttributeUseSetComponent{Uses: uses, Wildcard: set.Wildcard}
+	}
+	for i, ic := range build.Identities {
+		fields := make([]string, len(ic.Fields))
+		for j, field := range ic.Fields {
+			fields[j] = field.XPath
+		}
+		table.identities[i] = IdentityComponent{
+			Fields:   fields,
+			Selector: ic.SelectorXPath,
+			Name:     ic.Name,
+			Refer:    ic.Refer,
+			Kind:     ic.Kind,
+		}
+	}
+	for i := range build.Models {
+		table.models[i] = CloneContentModel(build.Models[i])
+	}
+	for i := range build.Wildcards {
+		table.wildcards[i] = CloneWildcard(build.Wildcards[i])
+	}
+	return table
+}
+
+func newSimpleTypeComponent(st *SimpleType) SimpleTypeComponent {
+	f := &st.Facets
+	facets := FacetComponent{
+		Patterns:         f.patterns.sources(),
+		Length:           f.Length,
+		MinLength:        f.MinLength,
+		MaxLength:        f.MaxLength,
+		TotalDigits:      f.TotalDigits,
+		FractionDigits:   f.FractionDigits,
+		Present:          f.Present,
+		Fixed:            f.Fixed,
+		ExplicitTimezone: f.ExplicitTimezone,
+	}
+	if len(f.Enumeration) != 0 {
+		facets.Enumeration = make([]string, len(f.Enumeration))
+		for i, lit := range f.Enumeration {
+			facets.Enumeration[i] = lit.Lexical
+			if len(lit.ResolvedNames) != 0 {
+				if facets.EnumerationNames == nil {
+					facets.EnumerationNames = make([][]ResolvedValueName, len(f.Enumeration))
+				}
+				facets.EnumerationNames[i] = slices.Clone(lit.ResolvedNames)
+			}
+		}
+	}
+	facets.MinInclusive = boundFacetLexical(*f, FacetMinInclusive)
+	facets.MaxInclusive = boundFacetLexical(*f, FacetMaxInclusive)
+	facets.MinExclusive = boundFacetLexical(*f, FacetMinExclusive)
+	facets.MaxExclusive = boundFacetLexical(*f, FacetMaxExclusive)
+	return SimpleTypeComponent{
+		Members:    slices.Clone(st.UnionSources),
+		Facets:     facets,
+		Base:       st.Base,
+		ListItem:   st.ListItem,
+		Variety:    st.Variety,
+		Primitive:  st.Primitive,
+		Whitespace: st.Whitespace,
+		Final:      st.Final,
+	}
+}
+
+func boundFacetLexical(f FacetSet, flag FacetMask) string {
+	lit, _ := BoundFacet(f, flag)
+	return lit.Lexical
+}
+
+func newValueConstraintComponent(vc *ValueConstraint) ValueConstraintComponent {
+	if vc == nil {
+		return ValueConstraintComponent{}
+	}
+	return ValueConstraintComponent{Lexical: vc.Lexical, Present: true}
+}
+
+func validateComponentTable(table componentTable, build *SchemaBuild) error {
+	switch {
+	case len(table.simpleTypes) != len(build.SimpleTypes),
+		len(table.complexTypes) != len(build.ComplexTypes),
+		len(table.elements) != len(build.Elements),
+		len(table.attributes) != len(build.Attributes),
+		len(table.attributeUseSets) != len(build.AttributeUseSets),
+		len(table.identities) != len(build.Identities),
+		len(table.models) != len(build.Models),
+		len(table.wildcards) != len(build.Wildcards):
+		return errors.New("component table counts do not match build")
+	}
+	if !maps.Equal(table.globalIdentities, build.GlobalIdentities) {
+		return errors.New("component identity names do not match build")
+	}
+	for _, id := range table.attributeGroups {
+		if !ValidAttributeUseSetID(id, len(build.AttributeUseSets)) {
+			return errors.New("attribute group references invalid attribute-use set")
+		}
+	}
+	if err := validateAnnotationKeys(table.annotations, build); err != nil {
+		return err
+	}
+	return validateLocationKeys(table.locations, build)
+}
+
+// GlobalElements returns the names and IDs of the top-level element
+// declarations in no particular order.
+func (rt *Schema) GlobalElements() iter.Seq2[QName, ElementID] {
+	return maps.All(rt.runtime.GlobalElements)
+}
+
+// GlobalAttributes returns the names and IDs of the top-level attribute
+// declarations in no particular order.
+func (rt *Schema) GlobalAttributes() iter.Seq2[QName, AttributeID] {
+	return maps.All(rt.runtime.GlobalAttributes)
+}
+
+// GlobalTypes returns the names and IDs of the named type definitions in no
+// particular order.
+func (rt *Schema) GlobalTypes() iter.Seq2[QName, TypeID] {
+	return maps.All(rt.runtime.GlobalTypes)
+}
+
+// AttributeGroups returns the names and attribute-use sets of the named
+// attribute groups in no particular order.
+func (rt *Schema) AttributeGroups() iter.Seq2[QName, AttributeUseSetID] {
+	return maps.All(rt.runtime.Components.attributeGroups)
+}
+
+// IdentityConstraints returns the names and IDs of the identity constraints
+// in no particular order.
+func (rt *Schema) IdentityConstraints() iter.Seq2[QName, IdentityConstraintID] {
+	return maps.All(rt.runtime.Components.globalIdentities)
+}
+
+// SimpleTypeComponent returns the introspection view of simple type id.
+func (rt *Schema) SimpleTypeComponent(id SimpleTypeID) (SimpleTypeComponent, bool) {
+	st, ok := componentByID(rt.runtime.Components.simpleTypes, uint32(id))
+	st.Members = slices.Clone(st.Members)
+	st.Facets.Enumeration = slices.Clone(st.Facets.Enumeration)
+	st.Facets.EnumerationNames = slices.Clone(st.Facets.EnumerationNames)
+	st.Facets.Patterns = slices.Clone(st.Facets.Patterns)
+	for i := range st.Facets.Patterns {
+		st.Facets.Patterns[i] = slices.Clone(st.Facets.Patterns[i])
+	}
+	return st, ok
+}
+
+// ComplexTypeComponent returns the introspection view of complex type id.
+func (rt *Schema) ComplexTypeComponent(id ComplexTypeID) (ComplexTypeComponent, bool) {
+	return componentByID(rt.runtime.Components.complexTypes, uint32(id))
+}
+
+// ElementComponent returns the introspection view of element declaration id.
+func (rt *Schema) ElementComponent(id ElementID) (ElementComponent, bool) {
+	decl, ok := componentByID(rt.runtime.Components.elements, uint32(id))
+	decl.Identity = slices.Clone(decl.Identity)
+	return decl, ok
+}
+
+// AttributeComponent returns the introspection view of attribute declaration
+// id.
+func (rt *Schema) AttributeComponent(id AttributeID) (AttributeComponent, bool) {
+	return componentByID(rt.runtime.Components.attributes, uint32(id))
+}
+
+// AttributeUseSetComponent returns the introspection view of attribute-use
+// set id.
+func (rt *Schema) AttributeUseSetComponent(id AttributeUseSetID) (AttributeUseSetComponent, bool) {
+	set, ok := componentByID(rt.runtime.Components.attributeUseSets, uint32(id))
+	set.Uses = slices.Clone(set.Uses)
+	return set, ok
+}
+
+// IdentityComponent returns the introspection view of identity constraint id.
+func (rt *Schema) IdentityComponent(id IdentityConstraintID) (IdentityComponent, bool) {
+	ic, ok := componentByID(rt.runtime.Components.identities, uint32(id))
+	ic.Fields = slices.Clone(ic.Fields)
+	return ic, ok
+}
+
+// ContentModelComponent returns a copy of content model id.
+func (rt *Schema) ContentModelComponent(id ContentModelID) (ContentModel, bool) {
+	return ContentModelByID(rt.runtime.Components.models, id)
+}
+
+// WildcardComponent returns a copy of wildcard id.
+func (rt *Schema) WildcardComponent(id WildcardID) (Wildcard, bool) {
+	return WildcardByID(rt.runtime.Components.wildcards, id)
+}
+
+// SubstitutionMembers returns the element declarations that may substitute
+// for head, directly or transitively, including abstract and blocked ones.
+func (rt *Schema) SubstitutionMembers(head ElementID) []ElementID {
+	var members []ElementID
+	rt.runtime.Substitutions.ForEachMember(head, func(id ElementID) bool {
+		members = append(members, id)
+		return true
+	})
+	return members
+}
+
+func componentByID[T any](table []T, id uint32) (T, bool) {
+	if !validRuntimeID(id, len(table)) {
+		var zero T
+		return zero, false
+	}
+	return table[id], true
+}
@@ -13,6 +13,9 @@ type ContentState struct {
 	state   uint32
 	count   uint32
 	present bool
+	// suffix records that suffix open content has matched, after which only
+	// the open content wildcard may match.
+	suffix bool
 }
 
 // HasModel reports whether state references a compiled content model.
@@ -108,10 +111,14 @@ type ContentFrame struct {
 }
 
 type compiledModelRead struct {
-	Rows      []compiledModelRowRead
-	All       []compiledAllTermRead
+	Rows []compiledModelRowRead
+	All  []compiledAllTermRead
+	// Siblings are the sorted element names of the model, kept only when a
+	// wildcard of the model excludes ##definedSibling names.
+	Siblings  []QName
 	Start     uint32
 	AllBitLen uint32
+	Open      OpenContent
 	Kind      CompiledModelKind
 	Empty     bool
 }
@@ -200,6 +207,7 @@ func newCompiledModelReads(models []CompiledModel) []compiledModelRead {
 			All:       modelAll,
 			Start:     model.Start,
 			AllBitLen: model.AllBitLen,
+			Open:      model.Open,
 			Kind:      model.Kind,
 			Empty:     model.Empty,
 		}
@@ -207,6 +215,56 @@ func newCompiledModelReads(models []CompiledModel) []compiledModelRead {
 	return reads
 }
 
+// setCompiledModelSiblings records the sibling element names of every
+// compiled model that matches a wildcard excluding ##definedSibling names.
+func setCompiledModelSiblings(reads []compiledModelRead, models []CompiledModel, wildcards []Wildcard, elements []ElementDecl) {
+	for i := range min(len(reads), len(models)) {
+		reads[i].Siblings = compiledModelSiblings(models[i], wildcards, elements)
+	}
+}
+
+func validateCompiledModelSiblings(reads []compiledModelRead, models []CompiledModel, wildcards []Wildcard, elements []ElementDecl) error {
+	if len(reads) != len(models) {
+		return errors.New("compiled model read projection count does not match compiled models")
+	}
+	for i := range reads {
+		if !slices.Equal(reads[i].Siblings, compiledModelSiblings(models[i], wildcards, elements)) {
+			return errors.New("compiled model sibling names do not match compiled model")
+		}
+	}
+	return nil
+}
+
+func compiledModelSiblings(model CompiledModel, wildcards []Wildcard, elements []ElementDecl) []QName {
+	excludesSiblings := func(id WildcardID) bool {
+		return ValidWildcardID(id, len(wildcards)) && wildcards[id].NotDefinedSibling
+	}
+	needed := model.Open.Present() && excludesSiblings(model.Open.Wildcard)
+	var names []QName
+	add := func(p Particle) {
+		switch p.Kind {
+		case ParticleElement:
+			if ValidElementID(p.Element, len(elements)) {
+				names = append(names, elements[p.Element].Name)
+			}
+		case ParticleWildcard:
+			needed = needed || excludesSiblings(p.Wildcard)
+		}
+	}
+	for _, row := range model.Rows {
+		for _, edge := range row.Edges {
+			add(edge.Particle)
+		}
+	}
+	for _, term := range model.All {
+		add(term.Particle)
+	}
+	if !needed {
+		return nil
+	}
+	return NormalizeQNameList(names)
+}
+
 func compiledModelReadCounts(models []CompiledModel) (rows, edges, all int) {
 	for i := range models {
 		rows = addCompiledModelReadCount(rows, len(models[i].Rows))
@@ -232,7 +290,7 @@ func validateCompiledModelReadProjectionTable(reads []compiledModelRead, models
 	for i := range reads {
 		read, model := reads[i], models[i]
 		if read.Start != model.Start || read.AllBitLen != model.AllBitLen ||
-			read.Kind != model.Kind || read.Empty != model.Empty ||
+			read.Kind != model.Kind || read.Empty != model.Empty || read.Open != model.Open ||
 			!equalCompiledModelRowReadsForSource(read.Rows, model.Rows) ||
 			!equalCompiledAllTermReadsForSource(read.All, model.All) {
 			return errors.New("compiled model read projection does not match compiled model")
@@ -321,16 +379,39 @@ func (rt *Schema) AdvanceContent(st *ContentState, in ContentInput, scratch *Con
 		return NoContentMatch(), ContentAdvanceInvalid
 	}
 	model := &rt.runtime.CompiledModels[st.model]
+	if st.suffix {
+		return rt.advancePublishedOpenContent(model, in)
+	}
+	var match ContentMatch
+	var status ContentAdvanceStatus
 	switch model.Kind {
 	case CompiledModelAny:
 		return rt.matchPublishedAnyContent(in), ContentAdvanceMatched
+	case CompiledModelEmpty:
+		match, status = NoContentMatch(), ContentAdvanceNoMatch
 	case CompiledModelAll:
-		return rt.advancePublishedAllContent(model, in, scratch)
+		match, status = rt.advancePublishedAllContent(model, in, scratch)
 	case CompiledModelDFA:
-		return rt.advancePublishedDFAContent(st, model, in)
+		match, status = rt.advancePublishedDFAContent(st, model, in)
 	default:
 		return NoContentMatch(), ContentAdvanceInvalid
 	}
+	if status != ContentAdvanceNoMatch || !model.Open.Present() {
+		return match, status
+	}
+	if model.Open.Mode == OpenContentSuffix {
+		switch completePublishedContent(*st, model, scratch) {
+		case ContentCompletionInvalid:
+			return NoContentMatch(), ContentAdvanceInvalid
+		case ContentCompletionIncomplete:
+			return NoContentMatch(), ContentAdvanceNoMatch
+		}
+	}
+	match, status = rt.advancePublishedOpenContent(model, in)
+	if status == ContentAdvanceMatched && model.Open.Mode == OpenContentSuffix {
+		st.suffix = true
+	}
+	return match, status
 }
 
 // CompleteContent reports whether a freeze-validated content state may end.
@@ -342,6 +423,13 @@ func (rt *Schema) CompleteContent(st ContentState, scratch *ContentScratch) Cont
 		return ContentCompletionInvalid
 	}
 	model := &rt.runtime.CompiledModels[st.model]
+	if st.suffix {
+		return ContentCompletionComplete
+	}
+	return completePublishedContent(st, model, scratch)
+}
+
+func completePublishedContent(st ContentState, model *compiledModelRead, scratch *ContentScratch) ContentCompletionStatus {
 	switch model.Kind {
 	case CompiledModelEmpty, CompiledModelAny:
 		return ContentCompletionComplete
@@ -354,6 +442,19 @@ func (rt *Schema) CompleteContent(st ContentState, scratch *ContentScratch) Cont
 	}
 }
 
+// advancePublishedOpenContent matches in against the open content wildcard of
+// model.
+func (rt *Schema) advancePublishedOpenContent(model *compiledModelRead, in ContentInput) (ContentMatch, ContentAdvanceStatus) {
+	if !model.Open.Present() || !ValidWildcardID(model.Open.Wildcard, len(rt.runtime.Wildcards)) {
+		return NoContentMatch(), ContentAdvanceInvalid
+	}
+	match, matched := rt.matchPublishedWildcardParticle(rt.runtime.Wildcards[model.Open.Wildcard], model, in)
+	if !matched {
+		return NoContentMatch(), ContentAdvanceNoMatch
+	}
+	return match, ContentAdvanceMatched
+}
+
 func (rt *Schema) matchPublishedAnyContent(in ContentInput) ContentMatch {
 	if in.Name.Known {
 		if id, ok := rt.runtime.GlobalElements[in.Name.Name]; ok {
@@ -372,7 +473,7 @@ func (rt *Schema) advancePublishedAllContent(model *compiledModelRead, in Conten
 		if seen {
 			continue
 		}
-		match, matched, valid := rt.matchPublishedDirectParticle(term.Particle, in)
+		match, matched, valid := rt.matchPublishedDirectParticle(model, term.Particle, in)
 		if !valid {
 			return NoContentMatch(), ContentAdvanceInvalid
 		}
@@ -421,7 +522,7 @@ func (rt *Schema) advancePublishedDFAContent(st *ContentState, model *compiledMo
 		return rt.advancePublishedIndexedDFAContent(st, model, row, row.Index, in)
 	}
 	for _, edge := range row.Edges {
-		match, matched, valid := rt.matchPublishedDirectParticle(edge.Particle, in)
+		match, matched, valid := rt.matchPublishedDirectParticle(model, edge.Particle, in)
 		if !valid {
 			return NoContentMatch(), ContentAdvanceInvalid
 		}
@@ -468,7 +569,7 @@ func (rt *Schema) advancePublishedIndexedDFAContent(st *ContentState, model *com
 			return NoContentMatch(), ContentAdvanceNoMatch
 		}
 		edge := row.Edges[pos]
-		match, matched, valid := rt.matchPublishedDirectParticle(edge.Particle, in)
+		match, matched, valid := rt.matchPublishedDirectParticle(model, edge.Particle, in)
 		if !valid {
 			return NoContentMatch(), ContentAdvanceInvalid
 		}
@@ -482,7 +583,7 @@ func (rt *Schema) advancePublishedIndexedDFAContent(st *ContentState, model *com
 	}
 }
 
-func (rt *Schema) matchPublishedDirectParticle(p compiledParticleRead, in ContentInput) (ContentMatch, bool, bool) {
+func (rt *Schema) matchPublishedDirectParticle(model *compiledModelRead, p compiledParticleRead, in ContentInput) (ContentMatch, bool, bool) {
 	switch p.Kind {
 	case ParticleElement:
 		name, ok := rt.runtime.Elements.name(p.Element)
@@ -501,7 +602,7 @@ func (rt *Schema) matchPublishedDirectParticle(p compiledParticleRead, in Conten
 		if !ValidWildcardID(p.Wildcard, len(rt.runtime.Wildcards)) {
 			return NoContentMatch(), false, false
 		}
-		match, matched := rt.matchPublishedWildcardParticle(rt.runtime.Wildcards[p.Wildcard], in)
+		match, matched := rt.matchPublishedWildcardParticle(rt.runtime.Wildcards[p.Wildcard], model, in)
 		return match, matched, true
 	default:
 		return NoContentMatch(), false, false
@@ -509,10 +610,22 @@ func (rt *Schema) matchPublishedDirectParticle(p compiledParticleRead, in Conten
 	return NoContentMatch(), false, true
 }
 
-func (rt *Schema) matchPublishedWildcardParticle(w WildcardView, in ContentInput) (ContentMatch, bool) {
-	if !w.AllowsURI(in.Name.NS) {
+func (rt *Schema) matchPublishedWildcardParticle(w WildcardView, model *compiledModelRead, in ContentInput) (ContentMatch, bool) {
+	if !w.AllowsName(in.Name) {
 		return NoContentMatch(), false
 	}
+	if in.Name.Known {
+		if w.ExcludesDefinedSibling() {
+			if _, sibling := slices.BinarySearchFunc(model.Siblings, in.Name.Name, compareQName); sibling {
+				return NoContentMatch(), false
+			}
+		}
+		if w.ExcludesDefined() {
+			if _, defined := rt.runtime.GlobalElements[in.Name.Name]; defined {
+				return NoContentMatch(), false
+			}
+		}
+	}
 	switch w.Process() {
 	case ProcessStrict:
 		if in.Name.Known {
@@ -561,3 +674,78 @@ func advancePublishedDFAState(st *ContentState, model *compiledModelRead, edge c
 	st.count = count
 	return true
 }
+
+// ContentExpected lists what a content-model state accepts as the next
+// child element.
+type ContentExpected struct {
+	// Elements are the names of the element declarations that may match,
+	// including substitution group members, in particle order.
+	Elements []QName
+	// Wildcards are the element wildcards that may match, including open
+	// content.
+	Wildcards []WildcardID
+	// Any is set for the content of xs:anyType, which accepts every element.
+	Any bool
+}
+
+// ExpectedContent returns what st accepts as the next child element. It reads
+// scratch without changing it and returns nothing for invalid states.
+func (rt *Schema) ExpectedContent(st ContentState, scratch *ContentScratch) ContentExpected {
+	var out ContentExpected
+	if !st.HasModel() || !ValidContentModelID(st.model, len(rt.runtime.CompiledModels)) {
+		return out
+	}
+	model := &rt.runtime.CompiledModels[st.model]
+	if !st.suffix {
+		switch model.Kind {
+		case CompiledModelAny:
+			out.Any = true
+			return out
+		case CompiledModelAll:
+			for i, term := range model.All {
+				if seen, valid := scratch.AllSeen(i); valid && !seen {
+					rt.expectParticle(&out, term.Particle)
+				}
+			}
+		case CompiledModelDFA:
+			if !ValidUint32Index(st.state, len(model.Rows)) {
+				return out
+			}
+			for _, edge := range model.Rows[st.state].Edges {
+				next := st
+				if advancePublishedDFAState(&next, model, edge) {
+					rt.expectParticle(&out, edge.Particle)
+				}
+			}
+		}
+	}
+	if model.Open.Present() && (st.suffix || model.Open.Mode != OpenContentSuffix || completePublishedContent(st, model, scratch) == ContentCompletionComplete) {
+		out.Wildcards = append(out.Wildcards, model.Open.Wildcard)
+	}
+	return out
+}
+
+func (rt *Schema) expectParticle(out *ContentExpected, p compiledParticleRead) {
+	switch p.Kind {
+	case ParticleElement:
+		rt.expectElement(out, p.Element)
+		rt.runtime.Substitutions.ForEachMember(p.Element, func(id ElementID) bool {
+			rt.expectElement(out, id)
+			return true
+		})
+	case ParticleWildcard:
+		if !slices.Contains(out.Wildcards, p.Wildcard) {
+			out.Wildcards = append(out.Wildcards, p.Wildcard)
+		}
+	}
+}
+
+func (rt *Schema) expectElement(out *ContentExpected, id ElementID) {
+	info, ok := rt.runtime.Elements.start(id)
+	if !ok || info.Abstract {
+		return
+	}
+	if name, ok := rt.runtime.Elements.name(id); ok && !slices.Contains(out.Elements, name) {
+		out.Elements = append(out.Elements, name)
+	}
+}
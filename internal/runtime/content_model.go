@@ -52,6 +52,51 @@ type ContentModel struct {
 	Occurs       Occurrence
 	Kind         ModelKind
 	Mixed        bool
+	// Open is the XSD 1.1 open content of a complex type's content model.
+	Open OpenContent
+}
+
+// OpenContentMode identifies how XSD 1.1 open content admits elements.
+type OpenContentMode uint8
+
+const (
+	// OpenContentNone is the absent open content.
+	OpenContentNone OpenContentMode = iota
+	// OpenContentInterleave admits wildcard elements anywhere among the
+	// elements of the content model.
+	OpenContentInterleave
+	// OpenContentSuffix admits wildcard elements after the content model is
+	// satisfied.
+	OpenContentSuffix
+)
+
+// OpenContent is the XSD 1.1 {open content} of a complex type. Its zero value
+// is the absent open content. Elements that the content model cannot accept
+// are matched against Wildcard instead; the content model keeps priority.
+type OpenContent struct {
+	Wildcard WildcardID
+	Mode     OpenContentMode
+}
+
+// Present reports whether open content applies.
+func (o OpenContent) Present() bool {
+	return o.Mode != OpenContentNone
+}
+
+func validateOpenContent(open OpenContent, wildcardCount int) error {
+	switch open.Mode {
+	case OpenContentNone:
+		if open != (OpenContent{}) {
+			return errors.New("absent open content stores wildcard")
+		}
+	case OpenContentInterleave, OpenContentSuffix:
+		if !ValidWildcardID(open.Wildcard, wildcardCount) {
+			return errors.New("open content references invalid wildcard")
+		}
+	default:
+		return errors.New("open content has invalid mode")
+	}
+	return nil
 }
 
 // ContentModelByID resolves and clones a content model from a content-model
@@ -123,7 +168,7 @@ func ValidateContentModelShape(model ContentModel) error {
 			return errors.New("empty content model stores inactive fields")
 		}
 	case ModelAny:
-		if len(model.Particles) != 0 || len(model.ChoiceLimits) != 0 || model.Occurs != (Occurrence{}) || !model.Mixed {
+		if len(model.Particles) != 0 || len(model.ChoiceLimits) != 0 || model.Occurs != (Occurrence{}) || !model.Mixed || model.Open.Present() {
 			return errors.New("any content model has invalid shape")
 		}
 	case ModelSequence, ModelChoice:
@@ -160,6 +205,9 @@ func ValidateContentModelRuntime(model ContentModel, limits ContentModelRefLimit
 	if err := ValidateContentModelShape(model); err != nil {
 		return err
 	}
+	if err := validateOpenContent(model.Open, limits.WildcardCount); err != nil {
+		return err
+	}
 	for _, p := range model.Particles {
 		switch p.Kind {
 		case ParticleElement:
@@ -148,6 +148,9 @@ func (v contentRestrictionValidator) validateKnownGroupRestriction(base, derived
 	if base.Kind == ModelAll && derived.Kind == ModelSequence {
 		return true, v.validateSequenceRestrictsAll(base, derived)
 	}
+	if base.Kind == ModelAll && derived.Kind == ModelChoice {
+		return true, v.validatePointlessChoiceRestrictsAll(base, derived)
+	}
 	if base.Kind == ModelChoice && derived.Kind == ModelSequence {
 		return true, v.validateSequenceRestrictsChoice(base, derived)
 	}
@@ -384,6 +387,13 @@ func (v contentRestrictionValidator) validatePointlessChoiceRestrictsSequence(ba
 	return v.validateChoiceBranchRestrictsSequence(base, derived.Particles[0])
 }
 
+func (v contentRestrictionValidator) validatePointlessChoiceRestrictsAll(base, derived ContentModel) error {
+	if !derived.Occurs.IsExactlyOne() || len(derived.Particles) != 1 {
+		return xsderrors.SchemaCompile(xsderrors.CodeSchemaContentModel, "choice restriction of all is forbidden")
+	}
+	return v.validateMappedGroupRestriction(base, derived, "choice restriction particle is not subset of all", "choice restriction omits required all particle")
+}
+
 func (v contentRestrictionValidator) validateChoiceBranchRestrictsSequence(base ContentModel, derived Particle) error {
 	var unsupportedErr error
 	for i, baseParticle := range base.Particles {
@@ -608,7 +618,7 @@ func (v contentRestrictionValidator) validateParticleRestrictsWildcard(base, der
 		if err != nil {
 			return err
 		}
-		if !WildcardAllowsNamespace(baseWildcard, derivedName.Namespace) {
+		if !WildcardAllowsQName(baseWildcard, derivedName) {
 			return xsderrors.SchemaCompile(xsderrors.CodeSchemaContentModel, "element restriction is not allowed by wildcard")
 		}
 	case ParticleWildcard:
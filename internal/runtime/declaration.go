@@ -47,6 +47,9 @@ type ElementDecl struct {
 	Block     DerivationMask
 	Final     DerivationMask
 	Scope     DeclarationScope
+	// Alternatives are the XSD 1.1 type alternatives of the declaration in
+	// document order. Only the last alternative may omit its test.
+	Alternatives []TypeAlternative
 }
 
 // ElementDeclByID resolves an element declaration ID against an element table.
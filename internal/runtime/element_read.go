@@ -10,6 +10,7 @@ type elementReadFlags uint8
 const (
 	elementReadAbstract elementReadFlags = 1 << iota
 	elementReadNillable
+	elementReadAlternatives
 )
 
 type elementReadMeta struct {
@@ -34,6 +35,8 @@ type elementReadTable struct {
 	meta        []elementReadMeta
 	identities  []IdentityConstraintID
 	constraints []elementConstraintRead
+	// alternatives is nil when no declaration has type alternatives.
+	alternatives [][]TypeAlternative
 }
 
 func newElementReadTable(decls []ElementDecl, complexTypes []ComplexType) elementReadTable {
@@ -67,6 +70,13 @@ func newElementReadTable(decls []ElementDecl, complexTypes []ComplexType) elemen
 		if decl.Nillable {
 			meta.flags |= elementReadNillable
 		}
+		if len(decl.Alternatives) != 0 {
+			meta.flags |= elementReadAlternatives
+			if table.alternatives == nil {
+				table.alternatives = make([][]TypeAlternative, len(decls))
+			}
+			table.alternatives[i] = decl.Alternatives
+		}
 		table.identities = append(table.identities, decl.Identity...)
 		if decl.Fixed != nil {
 			value, _ := NewValueConstraintReadFromConstraint(decl.Fixed)
@@ -107,15 +117,23 @@ func (t elementReadTable) start(id ElementID) (ElementStartInfo, bool) {
 		def = !fixed
 	}
 	return ElementStartInfo{
-		Type:     meta.typ,
-		Block:    meta.block,
-		Abstract: meta.flags&elementReadAbstract != 0,
-		Nillable: meta.flags&elementReadNillable != 0,
-		Fixed:    fixed,
-		Default:  def,
+		Type:         meta.typ,
+		Block:        meta.block,
+		Abstract:     meta.flags&elementReadAbstract != 0,
+		Nillable:     meta.flags&elementReadNillable != 0,
+		Fixed:        fixed,
+		Default:      def,
+		Alternatives: meta.flags&elementReadAlternatives != 0,
 	}, true
 }
 
+func (t elementReadTable) typeAlternatives(id ElementID) []TypeAlternative {
+	if !ValidElementID(id, len(t.alternatives)) {
+		return nil
+	}
+	return t.alternatives[id]
+}
+
 func (t elementReadTable) identityConstraints(id ElementID) (IdentityConstraintIDs, bool) {
 	if !ValidElementID(id, len(t.meta)) {
 		return IdentityConstraintIDs{}, false
@@ -169,9 +187,13 @@ func validateElementReadTableProjection(table elementReadTable, decls []ElementD
 		if table.names[i] != decl.Name || meta.typ != decl.Type || meta.block != effectiveElementBlock(*decl, complexTypes) ||
 			(meta.flags&elementReadAbstract != 0) != decl.Abstract ||
 			(meta.flags&elementReadNillable != 0) != decl.Nillable ||
-			meta.flags & ^(elementReadAbstract|elementReadNillable) != 0 {
+			(meta.flags&elementReadAlternatives != 0) != (len(decl.Alternatives) != 0) ||
+			meta.flags & ^(elementReadAbstract|elementReadNillable|elementReadAlternatives) != 0 {
 			return errors.New("element read table metadata does not match declaration")
 		}
+		if !slices.Equal(table.typeAlternatives(ElementID(i)), decl.Alternatives) {
+			return errors.New("element read table type alternatives do not match declaration")
+		}
 		if meta.identityStart != identityOffset || meta.identityCount != len(decl.Identity) {
 			return errors.New("element read table identity span does not match declaration")
 		}
@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"errors"
+	"maps"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+// SourceLocation is where a schema component is declared.
+type SourceLocation struct {
+	// Source is the name of the schema document.
+	Source string
+	// Component is the local name of the declaring schema element.
+	Component string
+	Line      int
+	Column    int
+}
+
+// LocationKey identifies the component a source location belongs to. It
+// keys components the way annotations do; Facet additionally selects one
+// facet declared by an AnnotatedSimpleType.
+type LocationKey struct {
+	AnnotationKey
+	Facet FacetMask
+}
+
+func validateLocationKeys(locations map[LocationKey]SourceLocation, build *SchemaBuild) error {
+	for key := range locations {
+		if !validAnnotationKey(key.AnnotationKey, build) ||
+			key.Kind == AnnotatedSchema ||
+			key.Facet != 0 && key.Kind != AnnotatedSimpleType {
+			return errors.New("source location references invalid component")
+		}
+	}
+	return nil
+}
+
+// HasSourceLocations reports whether rt retains the source locations of its
+// components.
+func (rt *Schema) HasSourceLocations() bool {
+	return rt != nil && len(rt.runtime.Components.locations) != 0
+}
+
+// SourceLocation returns the source location of the component identified by
+// key. It reports false when the schema was compiled without source
+// locations or the component is built in.
+func (rt *Schema) SourceLocation(key LocationKey) (SourceLocation, bool) {
+	if rt == nil {
+		return SourceLocation{}, false
+	}
+	loc, ok := rt.runtime.Components.locations[key]
+	return loc, ok
+}
+
+// FacetSourceLocation returns the source location of the facet declaration
+// that applies facet to values of simple type id: the nearest one along the
+// base type chain.
+func (rt *Schema) FacetSourceLocation(id SimpleTypeID, facet FacetMask) (SourceLocation, bool) {
+	if !rt.HasSourceLocations() {
+		return SourceLocation{}, false
+	}
+	types := rt.runtime.Components.simpleTypes
+	for range types {
+		key := LocationKey{AnnotationKey: AnnotationKey{Kind: AnnotatedSimpleType, ID: uint32(id)}, Facet: facet}
+		if loc, ok := rt.runtime.Components.locations[key]; ok {
+			return loc, true
+		}
+		st, ok := componentByID(types, uint32(id))
+		if !ok || st.Base == id {
+			break
+		}
+		id = st.Base
+	}
+	return SourceLocation{}, false
+}
+
+// FacetMaskForLocal returns the facet declared by the schema element with
+// local name local.
+func FacetMaskForLocal(local string) (FacetMask, bool) {
+	switch local {
+	case vocab.XSDFacetLength:
+		return FacetLength, true
+	case vocab.XSDFacetMinLength:
+		return FacetMinLength, true
+	case vocab.XSDFacetMaxLength:
+		return FacetMaxLength, true
+	case vocab.XSDFacetTotalDigits:
+		return FacetTotalDigits, true
+	case vocab.XSDFacetFractionDigits:
+		return FacetFractionDigits, true
+	case vocab.XSDFacetMinInclusive:
+		return FacetMinInclusive, true
+	case vocab.XSDFacetMaxInclusive:
+		return FacetMaxInclusive, true
+	case vocab.XSDFacetMinExclusive:
+		return FacetMinExclusive, true
+	case vocab.XSDFacetMaxExclusive:
+		return FacetMaxExclusive, true
+	case vocab.XSDFacetEnumeration:
+		return FacetEnumeration, true
+	case vocab.XSDFacetPattern:
+		return FacetPattern, true
+	case vocab.XSDFacetWhiteSpace:
+		return FacetWhiteSpace, true
+	case vocab.XSDFacetExplicitTimezone:
+		return FacetExplicitTimezone, true
+	default:
+		return 0, false
+	}
+}
+
+// FailedFacet returns the facet a simple value validation error reports as
+// failed. Facet failures read "<facet> facet failed".
+func FailedFacet(err error) (FacetMask, bool) {
+	if err == nil {
+		return 0, false
+	}
+	msg := err.Error()
+	end := strings.Index(msg, " facet failed")
+	if end < 0 {
+		return 0, false
+	}
+	name := msg[strings.LastIndexAny(msg[:end], " :")+1 : end]
+	return FacetMaskForLocal(name)
+}
+
+func cloneLocations(in map[LocationKey]SourceLocation) map[LocationKey]SourceLocation {
+	if len(in) == 0 {
+		return nil
+	}
+	return maps.Clone(in)
+}
@@ -37,6 +37,7 @@ type NameReadView struct {
 	nsIndex    map[string]NamespaceID
 	localIndex map[string]LocalNameID
 	namespaces []string
+	locals     []string
 }
 
 // NewNameReadView returns an owned immutable read view of names.
@@ -48,6 +49,7 @@ func NewNameReadView(names *NameTable) NameReadView {
 		nsIndex:    maps.Clone(names.nsIndex),
 		localIndex: maps.Clone(names.localIndex),
 		namespaces: slices.Clone(names.namespaces),
+		locals:     slices.Clone(names.locals),
 	}
 }
 
@@ -67,6 +69,15 @@ func (v NameReadView) LookupQName(ns, local string) (QName, bool) {
 	return QName{Namespace: nsID, Local: localID}, true
 }
 
+// LookupNamespace returns the interned ID for ns.
+func (v NameReadView) LookupNamespace(ns string) (NamespaceID, bool) {
+	if ns == "" {
+		return EmptyNamespaceID, true
+	}
+	id, ok := v.nsIndex[ns]
+	return id, ok
+}
+
 // Namespace returns the URI for id, or "" when id is not valid.
 func (v NameReadView) Namespace(id NamespaceID) string {
 	if !validRuntimeID(uint32(id), len(v.namespaces)) {
@@ -75,13 +86,22 @@ func (v NameReadView) Namespace(id NamespaceID) string {
 	return v.namespaces[id]
 }
 
+// Local returns the local name for id, or "" when id is not valid.
+func (v NameReadView) Local(id LocalNameID) string {
+	if !validRuntimeID(uint32(id), len(v.locals)) {
+		return ""
+	}
+	return v.locals[id]
+}
+
 // ValidateNameReadProjection validates a name read view against a frozen name
 // table.
 func ValidateNameReadProjection(read NameReadView, names *NameTable) error {
 	if names == nil ||
 		!maps.Equal(read.nsIndex, names.nsIndex) ||
 		!maps.Equal(read.localIndex, names.localIndex) ||
-		!slices.Equal(read.namespaces, names.namespaces) {
+		!slices.Equal(read.namespaces, names.namespaces) ||
+		!slices.Equal(read.locals, names.locals) {
 		return errors.New("name read projection does not match name table")
 	}
 	return nil
@@ -264,7 +264,7 @@ func ParticleMatchesName(rt ParticleRuntime, p Particle, name QName) bool {
 		return elementParticleMatchesName(rt, p.Element, name)
 	case ParticleWildcard:
 		w, ok := rt.Wildcard(p.Wildcard)
-		return ok && WildcardAllowsNamespace(w, name.Namespace)
+		return ok && WildcardAllowsQName(w, name)
 	case ParticleModel:
 		model, ok := rt.ContentModel(p.Model)
 		return ok && modelStartMatchesName(rt, model, name)
@@ -281,9 +281,21 @@ func firstParticleElementNameMatchedBy(rt ParticleRuntime, src, dst Particle) (Q
 	if !ok {
 		return QName{}, false
 	}
-	if ParticleMatchesName(rt, dst, name) {
+	// A wildcard excluding ##definedSibling names never admits the name of an
+	// element particle it competes with, and one excluding ##defined names
+	// never admits a substitution group member, which is always global.
+	var excludesSibling, excludesDefined bool
+	if dst.Kind == ParticleWildcard {
+		if w, ok := rt.Wildcard(dst.Wildcard); ok {
+			excludesSibling, excludesDefined = w.NotDefinedSibling, w.NotDefined
+		}
+	}
+	if !excludesSibling && ParticleMatchesName(rt, dst, name) {
 		return name, true
 	}
+	if excludesDefined {
+		return QName{}, false
+	}
 	var found QName
 	var matched bool
 	rt.ForEachSubstitutionMember(src.Element, func(member ElementID) bool {
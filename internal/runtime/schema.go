@@ -9,6 +9,16 @@ type SchemaBuild struct {
 	Notations        map[QName]bool
 	GlobalIdentities map[QName]IdentityConstraintID
 	GlobalTypes      map[QName]TypeID
+	// AttributeGroups maps named attribute groups to their attribute-use
+	// sets. Validation never reads it; it backs schema introspection.
+	AttributeGroups map[QName]AttributeUseSetID
+	// Annotations holds the documentation and appinfo retained when
+	// annotation retention is enabled. Validation never reads it.
+	Annotations map[AnnotationKey]Annotation
+	// Locations holds the component source locations retained when source
+	// location retention is enabled. Validation reads it only to describe
+	// errors.
+	Locations        map[LocationKey]SourceLocation
 	Identities       []IdentityConstraint
 	ComplexTypes     []ComplexType
 	Wildcards        []Wildcard
@@ -26,6 +36,7 @@ type schemaRuntime struct {
 	GlobalAttributes      map[QName]AttributeID
 	GlobalElements        map[QName]ElementID
 	GlobalTypes           map[QName]TypeID
+	TypeNames             map[TypeID]QName
 	Substitutions         SubstitutionTable
 	Notations             map[ExpandedName]bool
 	Names                 NameReadView
@@ -40,6 +51,8 @@ type schemaRuntime struct {
 	CompiledModels        []compiledModelRead
 	Attributes            []AttributeDeclRead
 	Elements              elementReadTable
+	Assertions            assertionReadTable
+	Components            componentTable
 }
 
 type complexTypeRead struct {
@@ -119,6 +132,9 @@ func (r complexTypeRead) textContent(fixed bool) ElementTextContent {
 // Schema is sealed validation-ready schema state.
 type Schema struct {
 	runtime schemaRuntime
+	// tables are the published schema tables. Validation reads runtime; the
+	// tables back EncodeSchema only.
+	tables SchemaBuild
 }
 
 // TypeName returns a compiler-owned type name.
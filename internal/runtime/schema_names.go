@@ -0,0 +1,57 @@
+package runtime
+
+// newTypeNameReads inverts the global type registry. A type bound to more
+// than one name keeps the smallest name so publication is deterministic.
+func newTypeNameReads(globals map[QName]TypeID) map[TypeID]QName {
+	names := make(map[TypeID]QName, len(globals))
+	for name, id := range globals {
+		if prev, ok := names[id]; ok && !qnameLess(name, prev) {
+			continue
+		}
+		names[id] = name
+	}
+	return names
+}
+
+func qnameLess(a, b QName) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Local < b.Local
+}
+
+// ExpandedName returns the namespace URI and local name of q.
+func (rt *Schema) ExpandedName(q QName) ExpandedName {
+	return ExpandedName{
+		Namespace: rt.runtime.Names.Namespace(q.Namespace),
+		Local:     rt.runtime.Names.Local(q.Local),
+	}
+}
+
+// TypeName returns the name of a global type definition. ok is false for
+// anonymous types.
+func (rt *Schema) TypeName(id TypeID) (QName, bool) {
+	name, ok := rt.runtime.TypeNames[id]
+	return name, ok
+}
+
+// ElementName returns the name of an element declaration and whether it is a
+// top-level declaration. ok is false for invalid IDs.
+func (rt *Schema) ElementName(id ElementID) (name QName, global, ok bool) {
+	name, ok = rt.runtime.Elements.name(id)
+	if !ok {
+		return QName{}, false, false
+	}
+	globalID, declared := rt.runtime.GlobalElements[name]
+	return name, declared && globalID == id, true
+}
+
+// NormalizeSimpleValue applies the whiteSpace facet of simple type id to
+// lexical, giving the value's schema-normalized form.
+func (rt *Schema) NormalizeSimpleValue(id SimpleTypeID, lexical string) string {
+	read, ok := simpleValueRouteSlotByID(rt.runtime.SimpleValueRoutes, id)
+	if !ok {
+		return lexical
+	}
+	return normalizeSimpleValueLexical(lexical, read.whitespace)
+}
@@ -29,6 +29,7 @@ func PublishSchema(ctx context.Context, build *SchemaBuild) (*Schema, error) {
 	if err := publishContextError(ctx); err != nil {
 		return nil, err
 	}
+	candidate.tables = *build
 	*build = SchemaBuild{}
 	return candidate, nil
 }
@@ -121,6 +122,7 @@ func newSchemaRuntime(build *SchemaBuild) (schemaRuntime, error) {
 		GlobalAttributes:  maps.Clone(build.GlobalAttributes),
 		GlobalElements:    maps.Clone(build.GlobalElements),
 		GlobalTypes:       maps.Clone(build.GlobalTypes),
+		TypeNames:         newTypeNameReads(build.GlobalTypes),
 		Substitutions:     build.Substitutions,
 		Names:             NewNameReadView(&build.Names),
 		Notations:         NewNotationReadMap(&build.Names, build.Notations),
@@ -133,7 +135,10 @@ func newSchemaRuntime(build *SchemaBuild) (schemaRuntime, error) {
 		CompiledModels:    newCompiledModelReads(build.CompiledModels),
 		Elements:          newElementReadTable(build.Elements, build.ComplexTypes),
 		Identities:        newIdentityConstraintReads(build.Identities),
+		Assertions:        newAssertionReadTable(build.SimpleTypes, build.ComplexTypes),
+		Components:        newComponentTable(build),
 	}
+	setCompiledModelSiblings(reads.CompiledModels, build.CompiledModels, build.Wildcards, build.Elements)
 	reads.SimpleValueQNameNeeds = newSimpleValueQNameResolverNeedsForSimpleTypes(build.SimpleTypes)
 	reads.AttributeUseSets = newAttributeUseSetReads(&build.Names, build.AttributeUseSets, build.SimpleTypes)
 	return reads, nil
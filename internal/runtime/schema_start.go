@@ -38,6 +38,31 @@ func (rt *Schema) LookupQName(ns, local string) (QName, bool) {
 	return rt.runtime.Names.LookupQName(ns, local)
 }
 
+// DeclaresNamespace reports whether any global element, attribute, or type
+// is declared in ns.
+func (rt *Schema) DeclaresNamespace(ns string) bool {
+	id, ok := rt.runtime.Names.LookupNamespace(ns)
+	if !ok {
+		return false
+	}
+	for name := range rt.runtime.GlobalElements {
+		if name.Namespace == id {
+			return true
+		}
+	}
+	for name := range rt.runtime.GlobalAttributes {
+		if name.Namespace == id {
+			return true
+		}
+	}
+	for name := range rt.runtime.GlobalTypes {
+		if name.Namespace == id {
+			return true
+		}
+	}
+	return false
+}
+
 // Namespace returns the namespace URI for id.
 func (rt *Schema) Namespace(id NamespaceID) string {
 	return rt.runtime.Names.Namespace(id)
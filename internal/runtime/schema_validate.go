@@ -321,6 +321,9 @@ func validateRuntimeReadProjections(rt *schemaAudit) error {
 	if err := validateWildcardReads(rt); err != nil {
 		return err
 	}
+	if err := validateComponentReads(rt); err != nil {
+		return err
+	}
 	return validateCompiledModelReads(rt)
 }
 
@@ -334,6 +337,9 @@ func validateGlobalReadProjections(rt *schemaAudit) error {
 	if !maps.Equal(rt.runtime.GlobalTypes, rt.build.GlobalTypes) {
 		return xsderrors.InternalInvariant("global type read projection does not match build")
 	}
+	if !maps.Equal(rt.runtime.TypeNames, newTypeNameReads(rt.build.GlobalTypes)) {
+		return xsderrors.InternalInvariant("type name read projection does not match build")
+	}
 	return nil
 }
 
@@ -422,10 +428,20 @@ func validateWildcardReads(rt *schemaAudit) error {
 	return nil
 }
 
+func validateComponentReads(rt *schemaAudit) error {
+	if err := validateComponentTable(rt.runtime.Components, &rt.build); err != nil {
+		return xsderrors.InternalInvariant(err.Error())
+	}
+	return nil
+}
+
 func validateCompiledModelReads(rt *schemaAudit) error {
 	if err := validateCompiledModelReadProjectionTable(rt.runtime.CompiledModels, rt.build.CompiledModels); err != nil {
 		return xsderrors.InternalInvariant(err.Error())
 	}
+	if err := validateCompiledModelSiblings(rt.runtime.CompiledModels, rt.build.CompiledModels, rt.build.Wildcards, rt.build.Elements); err != nil {
+		return xsderrors.InternalInvariant(err.Error())
+	}
 	return nil
 }
 
@@ -633,6 +649,9 @@ func validateElementDeclShape(rt *schemaAudit, decl ElementDecl) error {
 	}); err != nil {
 		return xsderrors.InternalInvariant(err.Error())
 	}
+	if err := validateTypeAlternatives(decl.Alternatives, len(rt.build.SimpleTypes), len(rt.build.ComplexTypes)); err != nil {
+		return xsderrors.InternalInvariant(err.Error())
+	}
 	return nil
 }
 
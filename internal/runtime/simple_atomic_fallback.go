@@ -149,6 +149,11 @@ func applyAtomicFacets(primitive PrimitiveKind, builtin BuiltinValidationKind, f
 			return err
 		}
 	}
+	if f.Facets&FacetExplicitTimezone != 0 {
+		if err := validateExplicitTimezone(f.ExplicitTimezone, normalized); err != nil {
+			return err
+		}
+	}
 	if primitive == PrimitiveDecimal {
 		dec := actual.Decimal
 		if !actual.Valid || actual.Kind != PrimitiveDecimal {
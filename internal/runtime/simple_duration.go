@@ -88,6 +88,25 @@ func parseDurationValue[T byteText](raw T) (DurationValue, error) {
 	}, nil
 }
 
+// validateDayTimeDurationLexical checks the xs:dayTimeDuration restriction of
+// the duration lexical space: no year or month components.
+func validateDayTimeDurationLexical(s string) error {
+	date, _, _ := strings.Cut(s, "T")
+	if strings.ContainsAny(date, "YM") {
+		return errors.New("invalid dayTimeDuration")
+	}
+	return nil
+}
+
+// validateYearMonthDurationLexical checks the xs:yearMonthDuration
+// restriction of the duration lexical space: no day or time components.
+func validateYearMonthDurationLexical(s string) error {
+	if strings.ContainsAny(s, "DT") {
+		return errors.New("invalid yearMonthDuration")
+	}
+	return nil
+}
+
 // EqualDurationValues reports XML Schema equality for xs:duration values.
 func EqualDurationValues(a, b DurationValue) bool {
 	return a.months == b.months &&
@@ -96,6 +115,24 @@ func EqualDurationValues(a, b DurationValue) bool {
 		compareFraction(a.frac, b.frac) == 0
 }
 
+// Months returns the year and month components of d as a signed month count.
+func (d DurationValue) Months() int64 {
+	return d.months
+}
+
+// SecondsText returns the day and time components of d as a signed decimal
+// count of seconds.
+func (d DurationValue) SecondsText() string {
+	switch {
+	case d.frac == "":
+		return strconv.FormatInt(d.seconds, 10)
+	case d.negativeFrac:
+		return "-" + strconv.FormatInt(-d.seconds, 10) + "." + d.frac
+	default:
+		return strconv.FormatInt(d.seconds, 10) + "." + d.frac
+	}
+}
+
 func durationIdentityCanonical(value DurationValue) string {
 	buf := make([]byte, 0, 44+len(value.frac))
 	buf = strconv.AppendInt(buf, value.months, 10)
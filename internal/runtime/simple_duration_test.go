@@ -72,6 +72,35 @@ func TestDurationValueEquality(t *testing.T) {
 	}
 }
 
+func TestDurationValueComponents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		seconds string
+		months  int64
+	}{
+		{input: "P1Y2M3DT4H5M6.7S", months: 14, seconds: "273906.7"},
+		{input: "-P1Y2M", months: -14, seconds: "0"},
+		{input: "-PT1.5S", seconds: "-1.5"},
+		{input: "-PT0.25S", seconds: "-0.25"},
+		{input: "PT1.500S", seconds: "1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			value := mustParseDurationValue(t, tt.input)
+			if got := value.Months(); got != tt.months {
+				t.Fatalf("Months() = %d, want %d", got, tt.months)
+			}
+			if got := value.SecondsText(); got != tt.seconds {
+				t.Fatalf("SecondsText() = %q, want %q", got, tt.seconds)
+			}
+		})
+	}
+}
+
 func TestDurationIdentityCanonicalMatchesValueEquality(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,84 @@
+package runtime
+
+import "errors"
+
+// TemporalFields are the components of a date/time or g* lexical value as
+// written, before timezone normalization. Components the primitive does not
+// carry take their values from 2000-01-01T00:00:00, the reference the
+// partial order uses for them.
+type TemporalFields struct {
+	// Fraction holds the fractional second digits without trailing zeros.
+	Fraction string
+	// Year is the astronomical year: the lexical year -0001 is year 0.
+	Year            int64
+	Month           int
+	Day             int
+	Hour            int
+	Minute          int
+	Second          int
+	TimezoneMinutes int
+	HasTimezone     bool
+}
+
+// ParseTemporalFields parses normalized as a date/time or g* primitive value
+// of kind and returns its components. Years beyond the int64 range fail.
+func ParseTemporalFields(kind PrimitiveKind, normalized string) (TemporalFields, error) {
+	year := xsdYear{digits: "2000"}
+	fields := TemporalFields{Month: 1, Day: 1}
+	var tz xsdTimezone
+	switch kind {
+	case PrimitiveDateTime:
+		date, next, err := parseXSDDatePart(normalized)
+		if err != nil {
+			return TemporalFields{}, err
+		}
+		if next >= len(normalized) || normalized[next] != 'T' {
+			return TemporalFields{}, errors.New("invalid dateTime")
+		}
+		tm, err := parseXSDTimeParts(normalized[next+1:])
+		if err != nil {
+			return TemporalFields{}, errors.New("invalid dateTime")
+		}
+		year, fields.Month, fields.Day = date.year, date.month, date.day
+		fields.setTime(tm)
+		tz = tm.tz
+	case PrimitiveDate:
+		date, next, err := parseXSDDatePart(normalized)
+		if err != nil {
+			return TemporalFields{}, err
+		}
+		tz, err = parseXSDTimezoneToEnd(normalized, next, "date")
+		if err != nil {
+			return TemporalFields{}, err
+		}
+		year, fields.Month, fields.Day = date.year, date.month, date.day
+	case PrimitiveTime:
+		tm, err := parseXSDTimeParts(normalized)
+		if err != nil {
+			return TemporalFields{}, err
+		}
+		fields.setTime(tm)
+		tz = tm.tz
+	case PrimitiveGYearMonth, PrimitiveGYear, PrimitiveGMonthDay, PrimitiveGDay, PrimitiveGMonth:
+		value, err := ParseGValue(kind, normalized)
+		if err != nil {
+			return TemporalFields{}, err
+		}
+		year, fields.Month, fields.Day = value.year, value.month, value.day
+		tz = value.tz
+	default:
+		return TemporalFields{}, errors.New("invalid temporal primitive")
+	}
+	astronomical, ok := xsdYearToAstronomicalInt64(year)
+	if !ok {
+		return TemporalFields{}, errors.New("year out of range")
+	}
+	fields.Year = astronomical
+	fields.TimezoneMinutes = tz.minutes
+	fields.HasTimezone = tz.present
+	return fields, nil
+}
+
+func (f *TemporalFields) setTime(tm xsdTimeParts) {
+	f.Hour, f.Minute, f.Second, f.Fraction = tm.hour, tm.minute, tm.second, tm.frac
+}
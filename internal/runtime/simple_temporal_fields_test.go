@@ -0,0 +1,71 @@
+package runtime
+
+import "testing"
+
+func TestParseTemporalFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  TemporalFields
+		kind  PrimitiveKind
+	}{
+		{
+			name:  "dateTime keeps written timezone",
+			kind:  PrimitiveDateTime,
+			input: "2026-05-18T23:30:15.250-05:30",
+			want:  TemporalFields{Year: 2026, Month: 5, Day: 18, Hour: 23, Minute: 30, Second: 15, Fraction: "25", TimezoneMinutes: -330, HasTimezone: true},
+		},
+		{
+			name:  "date negative year is astronomical",
+			kind:  PrimitiveDate,
+			input: "-0001-12-31",
+			want:  TemporalFields{Year: 0, Month: 12, Day: 31},
+		},
+		{
+			name:  "time uses reference date",
+			kind:  PrimitiveTime,
+			input: "24:00:00Z",
+			want:  TemporalFields{Year: 2000, Month: 1, Day: 1, Hour: 24, HasTimezone: true},
+		},
+		{
+			name:  "gMonthDay uses reference year",
+			kind:  PrimitiveGMonthDay,
+			input: "--02-29",
+			want:  TemporalFields{Year: 2000, Month: 2, Day: 29},
+		},
+		{
+			name:  "gYear",
+			kind:  PrimitiveGYear,
+			input: "10000+14:00",
+			want:  TemporalFields{Year: 10000, Month: 1, Day: 1, TimezoneMinutes: 840, HasTimezone: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseTemporalFields(tt.kind, tt.input)
+			if err != nil {
+				t.Fatalf("ParseTemporalFields(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseTemporalFields(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemporalFieldsRejectsUnrepresentableYear(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseTemporalFields(PrimitiveGYear, "99999999999999999999")
+	if got := errorMessage(err); got != "year out of range" {
+		t.Fatalf("ParseTemporalFields() error = %q, want year out of range", got)
+	}
+	_, err = ParseTemporalFields(PrimitiveDuration, "P1D")
+	if got := errorMessage(err); got != "invalid temporal primitive" {
+		t.Fatalf("ParseTemporalFields(duration) error = %q, want invalid temporal primitive", got)
+	}
+}
@@ -0,0 +1,86 @@
+package runtime
+
+import "errors"
+
+// ExplicitTimezone is the value of the XSD 1.1 explicitTimezone facet.
+type ExplicitTimezone uint8
+
+const (
+	// ExplicitTimezoneOptional admits values with or without a timezone.
+	ExplicitTimezoneOptional ExplicitTimezone = iota
+	// ExplicitTimezoneRequired admits only values with a timezone.
+	ExplicitTimezoneRequired
+	// ExplicitTimezoneProhibited admits only values without a timezone.
+	ExplicitTimezoneProhibited
+)
+
+// ValidExplicitTimezone reports whether tz is a known explicitTimezone value.
+func ValidExplicitTimezone(tz ExplicitTimezone) bool {
+	switch tz {
+	case ExplicitTimezoneOptional, ExplicitTimezoneRequired, ExplicitTimezoneProhibited:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetExplicitTimezoneFacet records the explicitTimezone facet value.
+func SetExplicitTimezoneFacet(f *FacetSet, tz ExplicitTimezone, fixed bool) {
+	f.ExplicitTimezone = tz
+	SetFacet(f, FacetExplicitTimezone, fixed)
+}
+
+// ValidateExplicitTimezoneRestriction validates that a restriction keeps a
+// base explicitTimezone of required or prohibited. Only an optional base
+// value may be narrowed.
+func ValidateExplicitTimezoneRestriction(derived, base FacetSet) error {
+	if base.Present&FacetExplicitTimezone == 0 || base.ExplicitTimezone == ExplicitTimezoneOptional {
+		return nil
+	}
+	if derived.ExplicitTimezone != base.ExplicitTimezone {
+		return errors.New("explicitTimezone cannot change a required or prohibited base value")
+	}
+	return nil
+}
+
+func validateExplicitTimezone(tz ExplicitTimezone, normalized string) error {
+	switch tz {
+	case ExplicitTimezoneRequired:
+		if !temporalLexicalHasTimezone(normalized) {
+			return errors.New("explicitTimezone facet failed: timezone is required")
+		}
+	case ExplicitTimezoneProhibited:
+		if temporalLexicalHasTimezone(normalized) {
+			return errors.New("explicitTimezone facet failed: timezone is prohibited")
+		}
+	case ExplicitTimezoneOptional:
+	}
+	return nil
+}
+
+// temporalLexicalHasTimezone reports whether a lexically valid date/time or
+// g* value ends with a timezone. No temporal lexical form other than a
+// timezone ends with a sign followed by hh:mm.
+func temporalLexicalHasTimezone(s string) bool {
+	if s == "" {
+		return false
+	}
+	if s[len(s)-1] == 'Z' {
+		return true
+	}
+	if len(s) < 6 {
+		return false
+	}
+	tz := s[len(s)-6:]
+	return (tz[0] == '+' || tz[0] == '-') && tz[3] == ':'
+}
+
+func primitiveHasTimezone(kind PrimitiveKind) bool {
+	switch kind {
+	case PrimitiveDateTime, PrimitiveTime, PrimitiveDate,
+		PrimitiveGYearMonth, PrimitiveGYear, PrimitiveGMonthDay, PrimitiveGDay, PrimitiveGMonth:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package runtime
+
+import "testing"
+
+func TestValidateExplicitTimezone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tz      ExplicitTimezone
+		input   string
+		wantErr string
+	}{
+		{name: "optional without timezone", tz: ExplicitTimezoneOptional, input: "2024-01-02"},
+		{name: "optional with timezone", tz: ExplicitTimezoneOptional, input: "2024-01-02Z"},
+		{name: "required utc", tz: ExplicitTimezoneRequired, input: "2024-01-02T03:04:05Z"},
+		{name: "required offset", tz: ExplicitTimezoneRequired, input: "2024-01-02T03:04:05-05:00"},
+		{name: "required gDay offset", tz: ExplicitTimezoneRequired, input: "---05+14:00"},
+		{name: "required rejects local", tz: ExplicitTimezoneRequired, input: "03:04:05", wantErr: "explicitTimezone facet failed: timezone is required"},
+		{name: "required rejects date", tz: ExplicitTimezoneRequired, input: "2024-01-02", wantErr: "explicitTimezone facet failed: timezone is required"},
+		{name: "prohibited local", tz: ExplicitTimezoneProhibited, input: "2024-01"},
+		{name: "prohibited negative year", tz: ExplicitTimezoneProhibited, input: "-2024"},
+		{name: "prohibited rejects offset", tz: ExplicitTimezoneProhibited, input: "2024-01+01:00", wantErr: "explicitTimezone facet failed: timezone is prohibited"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateExplicitTimezone(tt.tz, tt.input)
+			if got := errorMessage(err); got != tt.wantErr {
+				t.Fatalf("validateExplicitTimezone() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateExplicitTimezoneRestriction(t *testing.T) {
+	t.Parallel()
+
+	facets := func(tz ExplicitTimezone) FacetSet {
+		var f FacetSet
+		SetExplicitTimezoneFacet(&f, tz, false)
+		return f
+	}
+	tests := []struct {
+		name    string
+		derived FacetSet
+		base    FacetSet
+		wantErr bool
+	}{
+		{name: "base absent", derived: facets(ExplicitTimezoneProhibited)},
+		{name: "optional narrowed", derived: facets(ExplicitTimezoneRequired), base: facets(ExplicitTimezoneOptional)},
+		{name: "required kept", derived: facets(ExplicitTimezoneRequired), base: facets(ExplicitTimezoneRequired)},
+		{name: "required loosened", derived: facets(ExplicitTimezoneOptional), base: facets(ExplicitTimezoneRequired), wantErr: true},
+		{name: "prohibited changed", derived: facets(ExplicitTimezoneRequired), base: facets(ExplicitTimezoneProhibited), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateExplicitTimezoneRestriction(tt.derived, tt.base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateExplicitTimezoneRestriction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
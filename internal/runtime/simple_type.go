@@ -108,8 +108,11 @@ type SimpleType struct {
 	Builtin      BuiltinValidationKind
 	Identity     SimpleIdentityKind
 	Fast         SimpleFastKind
-	Missing      bool
-	Scope        DeclarationScope
+	// Assertions are the xs:assertion facets of the type and its restriction
+	// ancestors.
+	Assertions []Assertion
+	Missing    bool
+	Scope      DeclarationScope
 }
 
 // SimpleTypeByID resolves a simple type ID against a simple-type table.
@@ -228,20 +231,23 @@ const (
 	// FacetWhiteSpace is valid only in the fixed mask; whiteSpace itself is
 	// always represented by the simple type's whitespace mode.
 	FacetWhiteSpace
+	// FacetExplicitTimezone records the XSD 1.1 explicitTimezone facet.
+	FacetExplicitTimezone
 )
 
 // FacetSet stores compiled facet values attached to a simple type.
 type FacetSet struct {
-	bounds         facetBounds
-	patterns       stringPatternSteps
-	Enumeration    []CompiledLiteral
-	Length         uint32
-	MinLength      uint32
-	MaxLength      uint32
-	TotalDigits    uint32
-	FractionDigits uint32
-	Present        FacetMask
-	Fixed          FacetMask
+	bounds           facetBounds
+	patterns         stringPatternSteps
+	Enumeration      []CompiledLiteral
+	Length           uint32
+	MinLength        uint32
+	MaxLength        uint32
+	TotalDigits      uint32
+	FractionDigits   uint32
+	Present          FacetMask
+	Fixed            FacetMask
+	ExplicitTimezone ExplicitTimezone
 }
 
 type facetBounds [4]*CompiledLiteral
@@ -346,6 +352,8 @@ func ClearFacet(f *FacetSet, flag FacetMask) {
 		f.Enumeration = nil
 	case FacetPattern:
 		f.patterns = stringPatternSteps{}
+	case FacetExplicitTimezone:
+		f.ExplicitTimezone = ExplicitTimezoneOptional
 	default:
 	}
 }
@@ -417,6 +425,9 @@ func actualFacetMask(f FacetSet) FacetMask {
 	if f.patterns.count() != 0 {
 		actual |= FacetPattern
 	}
+	if f.Present&FacetExplicitTimezone != 0 || f.ExplicitTimezone != ExplicitTimezoneOptional {
+		actual |= FacetExplicitTimezone
+	}
 	return actual
 }
 
@@ -485,6 +496,9 @@ func ValidateSimpleTypeFacetMaskShape(variety SimpleVariety, primitive Primitive
 // ValidateSimpleTypeFacetMaskForSimpleType validates stored facet masks and
 // facet-family applicability for st.
 func ValidateSimpleTypeFacetMaskForSimpleType(st SimpleType) error {
+	if !ValidExplicitTimezone(st.Facets.ExplicitTimezone) {
+		return errors.New("simple type explicitTimezone facet is invalid")
+	}
 	return ValidateSimpleTypeFacetMaskShape(st.Variety, st.Primitive, FacetMaskShapeForFacetSet(st.Facets))
 }
 
@@ -645,12 +659,13 @@ func ValidateFacetCardinalityRestriction(derived, base FacetCardinalityShape) er
 // FixedFacetValues is the value-independent projection of simple facets whose
 // fixedness can be validated without schema-private literal representations.
 type FixedFacetValues struct {
-	Length         FacetCardinalityValue
-	MinLength      FacetCardinalityValue
-	MaxLength      FacetCardinalityValue
-	TotalDigits    FacetCardinalityValue
-	FractionDigits FacetCardinalityValue
-	Whitespace     WhitespaceMode
+	Length           FacetCardinalityValue
+	MinLength        FacetCardinalityValue
+	MaxLength        FacetCardinalityValue
+	TotalDigits      FacetCardinalityValue
+	FractionDigits   FacetCardinalityValue
+	Whitespace       WhitespaceMode
+	ExplicitTimezone ExplicitTimezone
 }
 
 // FixedLiteralFacetPreservation is the caller-supplied preservation fact for a
@@ -698,12 +713,13 @@ func FixedFacetPreservationForSimpleTypes(derived, base SimpleType) FixedFacetPr
 func fixedFacetValuesForSimpleType(st SimpleType) FixedFacetValues {
 	shape := FacetCardinalityShapeForSimpleType(st)
 	return FixedFacetValues{
-		Length:         shape.Length,
-		MinLength:      shape.MinLength,
-		MaxLength:      shape.MaxLength,
-		TotalDigits:    shape.TotalDigits,
-		FractionDigits: shape.FractionDigits,
-		Whitespace:     st.Whitespace,
+		Length:           shape.Length,
+		MinLength:        shape.MinLength,
+		MaxLength:        shape.MaxLength,
+		TotalDigits:      shape.TotalDigits,
+		FractionDigits:   shape.FractionDigits,
+		Whitespace:       st.Whitespace,
+		ExplicitTimezone: st.Facets.ExplicitTimezone,
 	}
 }
 
@@ -741,6 +757,9 @@ func ValidateFixedFacetPreservation(shape FixedFacetPreservation) error {
 	if fixed&FacetWhiteSpace != 0 && shape.Derived.Whitespace != shape.Base.Whitespace {
 		return errors.New("fixed whiteSpace facet cannot change")
 	}
+	if fixed&FacetExplicitTimezone != 0 && shape.Derived.ExplicitTimezone != shape.Base.ExplicitTimezone {
+		return errors.New("fixed explicitTimezone facet cannot change")
+	}
 	if fixed&FacetMinInclusive != 0 && !shape.MinInclusive.preserved() {
 		return errors.New("fixed minInclusive facet cannot change")
 	}
@@ -765,7 +784,8 @@ const fixedFacetPreservationMask = FacetLength |
 	FacetMaxInclusive |
 	FacetMinExclusive |
 	FacetMaxExclusive |
-	FacetWhiteSpace
+	FacetWhiteSpace |
+	FacetExplicitTimezone
 
 func (p FixedLiteralFacetPreservation) preserved() bool {
 	return p.BasePresent && p.DerivedPresent && p.Equal
@@ -1123,6 +1143,9 @@ func ValidateFacetRestrictionForSimpleTypes(derived, base SimpleType) error {
 	if !OrderedFacetSetRestricts(derived.Variety, derived.Primitive, derived.Facets, base.Facets) {
 		return errors.New("simple type ordered facets loosen base")
 	}
+	if err := ValidateExplicitTimezoneRestriction(derived.Facets, base.Facets); err != nil {
+		return err
+	}
 	if err := validateEnumerationFacetRestriction(derived.Facets.Enumeration, base.Facets.Enumeration, derived.Base); err != nil {
 		return err
 	}
@@ -1172,7 +1195,7 @@ func FacetAllowedForSimpleType(variety SimpleVariety, primitive PrimitiveKind, f
 // FacetMaskAllowedForSimpleType reports whether every facet family in mask is
 // legal for a simple type's variety and primitive datatype family.
 func FacetMaskAllowedForSimpleType(variety SimpleVariety, primitive PrimitiveKind, mask FacetMask) bool {
-	for facet := FacetLength; facet <= FacetWhiteSpace; facet <<= 1 {
+	for facet := FacetLength; facet <= FacetExplicitTimezone; facet <<= 1 {
 		if mask&facet != 0 && !FacetAllowedForSimpleType(variety, primitive, facet) {
 			return false
 		}
@@ -1191,6 +1214,8 @@ func atomicFacetAllowed(kind PrimitiveKind, facet FacetMask) bool {
 		return primitiveHasOrderFacet(kind)
 	case FacetTotalDigits, FacetFractionDigits:
 		return kind == PrimitiveDecimal
+	case FacetExplicitTimezone:
+		return primitiveHasTimezone(kind)
 	default:
 		return false
 	}
@@ -1501,6 +1526,12 @@ const (
 	BuiltinValidationXMLLang
 	// BuiltinValidationXMLSpace validates xml:space.
 	BuiltinValidationXMLSpace
+	// BuiltinValidationDayTimeDuration validates xs:dayTimeDuration.
+	BuiltinValidationDayTimeDuration
+	// BuiltinValidationYearMonthDuration validates xs:yearMonthDuration.
+	BuiltinValidationYearMonthDuration
+	// BuiltinValidationError rejects every value of xs:error.
+	BuiltinValidationError
 )
 
 // ValidBuiltinValidationKind reports whether kind is a known built-in validator.
@@ -1514,7 +1545,10 @@ func ValidBuiltinValidationKind(kind BuiltinValidationKind) bool {
 		BuiltinValidationLanguage,
 		BuiltinValidationEntity,
 		BuiltinValidationXMLLang,
-		BuiltinValidationXMLSpace:
+		BuiltinValidationXMLSpace,
+		BuiltinValidationDayTimeDuration,
+		BuiltinValidationYearMonthDuration,
+		BuiltinValidationError:
 		return true
 	default:
 		return false
@@ -1533,7 +1567,10 @@ func SimpleValueBuiltinDerivedRuntimeOwned(kind BuiltinValidationKind) bool {
 		BuiltinValidationLanguage,
 		BuiltinValidationEntity,
 		BuiltinValidationXMLLang,
-		BuiltinValidationXMLSpace:
+		BuiltinValidationXMLSpace,
+		BuiltinValidationDayTimeDuration,
+		BuiltinValidationYearMonthDuration,
+		BuiltinValidationError:
 		return true
 	default:
 		return false
@@ -1,9 +1,10 @@
 package runtime
 
 import (
-	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/jacoelho/xsd/internal/regex"
 )
 
 func TestPrimitiveKindValidity(t *testing.T) {
@@ -1415,8 +1416,8 @@ func TestValidatePatternFacetShape(t *testing.T) {
 			groups: [][]StringPattern{{NewFastStringPattern(CompileSimpleStringPattern("[A-Z]"))}},
 		},
 		{
-			name:   "regexp matcher",
-			groups: [][]StringPattern{{NewRegexpStringPattern(regexp.MustCompile("^[A-Z]$"))}},
+			name:   "regex matcher",
+			groups: [][]StringPattern{{NewRegexStringPattern(regex.MustCompile("^[A-Z]$"))}},
 		},
 		{
 			name:    "empty group",
@@ -1430,7 +1431,7 @@ func TestValidatePatternFacetShape(t *testing.T) {
 		},
 		{
 			name:    "pattern with both matchers",
-			groups:  [][]StringPattern{{{fast: &SimplePattern{}, re: regexp.MustCompile("[A-Z]")}}},
+			groups:  [][]StringPattern{{{fast: &SimplePattern{}, re: regex.MustCompile("[A-Z]")}}},
 			wantErr: "simple type pattern facet has invalid matcher",
 		},
 	}
@@ -1459,7 +1460,7 @@ func TestValidatePatternFacetRestriction(t *testing.T) {
 
 	a := NewFastStringPattern(CompileSimpleStringPattern("[A-Z]"))
 	b := NewFastStringPattern(CompileSimpleStringPattern("[0-9]"))
-	c := NewRegexpStringPattern(regexp.MustCompile("^[a-z]$"))
+	c := NewRegexStringPattern(regex.MustCompile("^[a-z]$"))
 	base := newTestStringPatternSteps([][]StringPattern{{a}, {b}})
 	derived := appendStringPatternStep(base, []StringPattern{c})
 	for name, steps := range map[string]stringPatternSteps{"inherited": base, "appended": derived} {
@@ -51,6 +51,7 @@ type simpleValueMetadataReader interface {
 	simpleValueStringEnumeration(id SimpleTypeID, canonical string) (bool, bool)
 	simpleValueNotation(ns, local string) (bool, bool)
 	simpleValueUnsupported(err error) bool
+	simpleValueAssertions(id SimpleTypeID, lexical string) error
 }
 
 type callbackSimpleValueMetadataReader struct {
@@ -89,6 +90,12 @@ func (r callbackSimpleValueMetadataReader) simpleValueUnsupported(err error) boo
 	return r.callbacks.Unsupported != nil && r.callbacks.Unsupported(err)
 }
 
+// simpleValueAssertions accepts every value: compile-time literals are not
+// checked against xs:assertion facets.
+func (callbackSimpleValueMetadataReader) simpleValueAssertions(SimpleTypeID, string) error {
+	return nil
+}
+
 // LengthFacetValues is the runtime projection of length/minLength/maxLength
 // facet values.
 type LengthFacetValues struct {
@@ -129,16 +136,17 @@ type SimpleValueFacetLiteral struct {
 // SimpleValueFacets is the runtime-owned read projection of simple-type facets
 // needed by schema atomic fallback validation.
 type SimpleValueFacets struct {
-	Enumeration   []SimpleValueFacetLiteral
-	StringFacets  StringFacetValues
-	enumeration   []simpleValueLiteralRead
-	MinInclusive  SimpleValueFacetLiteral
-	MaxInclusive  SimpleValueFacetLiteral
-	MinExclusive  SimpleValueFacetLiteral
-	MaxExclusive  SimpleValueFacetLiteral
-	DecimalFacets DecimalFacetValues
-	LengthFacets  LengthFacetValues
-	Facets        FacetMask
+	Enumeration      []SimpleValueFacetLiteral
+	StringFacets     StringFacetValues
+	enumeration      []simpleValueLiteralRead
+	MinInclusive     SimpleValueFacetLiteral
+	MaxInclusive     SimpleValueFacetLiteral
+	MinExclusive     SimpleValueFacetLiteral
+	MaxExclusive     SimpleValueFacetLiteral
+	DecimalFacets    DecimalFacetValues
+	LengthFacets     LengthFacetValues
+	Facets           FacetMask
+	ExplicitTimezone ExplicitTimezone
 }
 
 // SimpleValueFacetProjector projects immutable facet storage while pooling
@@ -200,14 +208,15 @@ type simpleValueBoundReads [4]*simpleValueLiteralRead
 // validation. Enumeration literals use a separate compact projection and do
 // not retain the compiler's literal table.
 type simpleValueFacetRead struct {
-	bounds         simpleValueBoundReads
-	patterns       *stringPatternStepRead
-	length         uint32
-	minLength      uint32
-	maxLength      uint32
-	totalDigits    uint32
-	fractionDigits uint32
-	present        FacetMask
+	bounds           simpleValueBoundReads
+	patterns         *stringPatternStepRead
+	length           uint32
+	minLength        uint32
+	maxLength        uint32
+	totalDigits      uint32
+	fractionDigits   uint32
+	present          FacetMask
+	explicitTimezone ExplicitTimezone
 }
 
 func newSimpleValueFacetRead(
@@ -228,14 +237,15 @@ func newSimpleValueFacetRead(
 		bounds[i] = &boundPool[index]
 	}
 	return simpleValueFacetRead{
-		bounds:         bounds,
-		patterns:       patterns,
-		length:         f.Length,
-		minLength:      f.MinLength,
-		maxLength:      f.MaxLength,
-		totalDigits:    f.TotalDigits,
-		fractionDigits: f.FractionDigits,
-		present:        f.Present,
+		bounds:           bounds,
+		patterns:         patterns,
+		length:           f.Length,
+		minLength:        f.MinLength,
+		maxLength:        f.MaxLength,
+		totalDigits:      f.TotalDigits,
+		fractionDigits:   f.FractionDigits,
+		present:          f.Present,
+		explicitTimezone: f.ExplicitTimezone,
 	}
 }
 
@@ -636,15 +646,16 @@ func simpleValueFacetsForColdRead(cold *simpleValueColdRead) SimpleValueFacets {
 	}
 	f := cold.facets
 	return SimpleValueFacets{
-		MinInclusive:  f.literal(FacetMinInclusive),
-		MaxInclusive:  f.literal(FacetMaxInclusive),
-		MinExclusive:  f.literal(FacetMinExclusive),
-		MaxExclusive:  f.literal(FacetMaxExclusive),
-		StringFacets:  StringFacetValues{patternReads: f.patterns, HasEnumeration: len(cold.enumeration) != 0},
-		DecimalFacets: f.decimalValues(),
-		LengthFacets:  f.lengthValues(),
-		Facets:        f.present,
-		enumeration:   cold.enumeration,
+		MinInclusive:     f.literal(FacetMinInclusive),
+		MaxInclusive:     f.literal(FacetMaxInclusive),
+		MinExclusive:     f.literal(FacetMinExclusive),
+		MaxExclusive:     f.literal(FacetMaxExclusive),
+		StringFacets:     StringFacetValues{patternReads: f.patterns, HasEnumeration: len(cold.enumeration) != 0},
+		DecimalFacets:    f.decimalValues(),
+		LengthFacets:     f.lengthValues(),
+		Facets:           f.present,
+		ExplicitTimezone: f.explicitTimezone,
+		enumeration:      cold.enumeration,
 	}
 }
 
@@ -811,9 +822,10 @@ func simpleValueFacetsForFacetSet(f FacetSet, enumeration []SimpleValueFacetLite
 		StringFacets: StringFacetValues{
 			patternSource: f.patterns,
 		},
-		DecimalFacets: decimalFacetValues(f),
-		LengthFacets:  lengthFacetValues(f),
-		Facets:        f.Present,
+		DecimalFacets:    decimalFacetValues(f),
+		LengthFacets:     lengthFacetValues(f),
+		Facets:           f.Present,
+		ExplicitTimezone: f.ExplicitTimezone,
 	}
 }
 
@@ -911,19 +923,27 @@ func validateSimpleValue[R simpleValueMetadataReader](reader R, id SimpleTypeID,
 	if id != NoSimpleType {
 		typ, known = reader.simpleValueType(id)
 	}
+	var value SimpleValue
+	var err error
 	switch SimpleValueRoute(SimpleValueRouteShape{Type: id, Variety: typ.Variety, Known: known}) {
 	case SimpleValueRouteUntyped:
 		return SimpleValue{Canonical: lexical, Type: NoSimpleType}, nil
 	case SimpleValueRouteAtomic:
-		return validateAtomicSimpleValue(reader, id, typ, lexical, resolve, needs, scratch)
+		value, err = validateAtomicSimpleValue(reader, id, typ, lexical, resolve, needs, scratch)
 	case SimpleValueRouteList:
-		return validateListSimpleValue(reader, id, typ, lexical, resolve, needs, scratch)
+		value, err = validateListSimpleValue(reader, id, typ, lexical, resolve, needs, scratch)
 	case SimpleValueRouteUnion:
-		return validateUnionSimpleValue(reader, id, typ, lexical, resolve, needs, scratch)
-	case SimpleValueRouteMissing, SimpleValueRouteInvalid:
+		value, err = validateUnionSimpleValue(reader, id, typ, lexical, resolve, needs, scratch)
+	default:
 		return SimpleValue{}, ErrSimpleValueMetadata
 	}
-	return SimpleValue{}, ErrSimpleValueMetadata
+	if err != nil {
+		return SimpleValue{}, err
+	}
+	if err := reader.simpleValueAssertions(id, lexical); err != nil {
+		return SimpleValue{}, err
+	}
+	return value, nil
 }
 
 func validateSimpleValueRouteReadFast(reads []simpleValueRouteRead, notations map[ExpandedName]bool, id SimpleTypeID, lexical string, resolve ResolveQNameParts, needs SimpleValueNeed) (SimpleValue, bool, error) {
@@ -161,7 +161,7 @@ func equalSimpleValueEnumerationReadForSource(read []simpleValueLiteralRead, sou
 func equalColdFacetProjection(read simpleValueFacetRead, facets FacetSet) bool {
 	if read.length != facets.Length || read.minLength != facets.MinLength || read.maxLength != facets.MaxLength ||
 		read.totalDigits != facets.TotalDigits || read.fractionDigits != facets.FractionDigits ||
-		read.present != facets.Present {
+		read.present != facets.Present || read.explicitTimezone != facets.ExplicitTimezone {
 		return false
 	}
 	for i := range read.bounds {
@@ -14,8 +14,10 @@ func (rt *Schema) validatePublishedSimpleValue(id SimpleTypeID, lexical string,
 }
 
 func (rt *Schema) validatePublishedSimpleValueWithScratch(id SimpleTypeID, lexical string, resolve ResolveQNameParts, needs SimpleValueNeed, scratch *StringPatternScratch) (SimpleValue, error) {
-	if value, handled, err := validateSimpleValueRouteReadFast(rt.runtime.SimpleValueRoutes, rt.runtime.Notations, id, lexical, resolve, needs); handled {
-		return value, err
+	if !rt.runtime.Assertions.simpleAsserted(id) {
+		if value, handled, err := validateSimpleValueRouteReadFast(rt.runtime.SimpleValueRoutes, rt.runtime.Notations, id, lexical, resolve, needs); handled {
+			return value, err
+		}
 	}
 	return validateSimpleValue(publishedSimpleValueMetadataReader{runtime: &rt.runtime}, id, lexical, resolve, needs, scratch)
 }
@@ -74,7 +76,14 @@ func (publishedSimpleValueMetadataReader) simpleValueUnsupported(err error) bool
 	return xsderrors.IsUnsupported(err)
 }
 
+func (r publishedSimpleValueMetadataReader) simpleValueAssertions(id SimpleTypeID, lexical string) error {
+	return r.runtime.validateSimpleAssertions(id, lexical)
+}
+
 func (rt *Schema) validatePublishedRawSimpleValueWithScratch(id SimpleTypeID, raw []byte, scratch *StringPatternScratch) (bool, error) {
+	if rt.runtime.Assertions.simpleAsserted(id) {
+		return false, nil
+	}
 	return validateResolvedRawSimpleValue(rawSimpleValueResolver{runtime: &rt.runtime, scratch: scratch}, id, raw)
 }
 
@@ -0,0 +1,1183 @@
+package runtime
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/jacoelho/xsd/internal/regex"
+	"github.com/jacoelho/xsd/internal/xpath"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// SnapshotVersion identifies the snapshot encoding. It changes whenever the
+// shape of the encoded schema tables changes; snapshots of other versions are
+// rejected. ExportTables records it as the version of the plain tables, which
+// carry the same fields.
+const SnapshotVersion uint32 = 2
+
+const (
+	snapshotMagic = "XSDSNAP\x00"
+	// snapshotHeaderSize covers the magic, the version, the payload length and
+	// the CRC-32C payload checksum.
+	snapshotHeaderSize = len(snapshotMagic) + 4 + 8 + 4
+)
+
+var snapshotChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// EncodeSchema encodes the tables of rt as a versioned, checksummed snapshot.
+// Compiled regular expressions, XPath expressions and parsed facet literal
+// values are stored as source and rebuilt by DecodeSchema. It reads only the
+// tables rt was published from.
+func EncodeSchema(rt *Schema) ([]byte, error) {
+	if rt == nil {
+		return nil, xsderrors.InternalInvariant("nil schema")
+	}
+	e := snapshotEncoder{buf: make([]byte, snapshotHeaderSize, 64<<10)}
+	e.schemaBuild(&rt.tables)
+	payload := e.buf[snapshotHeaderSize:]
+	header := e.buf[:0]
+	header = append(header, snapshotMagic...)
+	header = binary.BigEndian.AppendUint32(header, SnapshotVersion)
+	header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	binary.BigEndian.AppendUint32(header, crc32.Checksum(payload, snapshotChecksumTable))
+	return e.buf, nil
+}
+
+// DecodeSchema decodes a snapshot written by EncodeSchema and publishes
+// the decoded tables through PublishSchema, so a loaded schema satisfies the
+// same invariants as a compiled one. The checksum detects corruption, not
+// tampering: snapshots must come from a trusted source.
+func DecodeSchema(ctx context.Context, data []byte) (*Schema, error) {
+	if len(data) < snapshotHeaderSize || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, snapshotError("data is not a schema snapshot")
+	}
+	header := data[len(snapshotMagic):snapshotHeaderSize]
+	if version := binary.BigEndian.Uint32(header); version != SnapshotVersion {
+		return nil, snapshotError("unsupported schema snapshot version " + strconv.FormatUint(uint64(version), 10))
+	}
+	payload := data[snapshotHeaderSize:]
+	if binary.BigEndian.Uint64(header[4:]) != uint64(len(payload)) {
+		return nil, snapshotError("schema snapshot length does not match its header")
+	}
+	if binary.BigEndian.Uint32(header[12:]) != crc32.Checksum(payload, snapshotChecksumTable) {
+		return nil, snapshotError("schema snapshot checksum mismatch")
+	}
+	d := snapshotDecoder{data: payload}
+	build := d.schemaBuild()
+	if d.err == "" && len(d.data) != 0 {
+		d.fail("trailing data")
+	}
+	if d.err != "" {
+		return nil, snapshotError("malformed schema snapshot: " + d.err)
+	}
+	return PublishSchema(ctx, &build)
+}
+
+func snapshotError(msg string) error {
+	return xsderrors.SchemaCompile(xsderrors.CodeSchemaSnapshot, msg)
+}
+
+// snapshotEncoder appends the snapshot payload. Compiled literals,
+// enumerations, pattern steps and expressions shared between components are
+// written once and referenced afterwards, so decoding restores the sharing
+// publication relies on.
+type snapshotEncoder struct {
+	literals     map[*CompiledLiteral]uint64
+	enumerations map[simpleValueEnumerationSource]uint64
+	patterns     map[*stringPatternStep]uint64
+	exprs        map[*xpath.Expr]uint64
+	buf          []byte
+}
+
+func (e *snapshotEncoder) uint(v uint64) { e.buf = binary.AppendUvarint(e.buf, v) }
+
+func (e *snapshotEncoder) int(v int) { e.uint(uint64(max(v, 0))) }
+
+func (e *snapshotEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *snapshotEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func encodeUint[T ~uint8 | ~uint16 | ~uint32](e *snapshotEncoder, v T) { e.uint(uint64(v)) }
+
+func encodeSlice[T any](e *snapshotEncoder, items []T, item func(*snapshotEncoder, T)) {
+	e.uint(uint64(len(items)))
+	for _, v := range items {
+		item(e, v)
+	}
+}
+
+// encodeQNameMap writes zero for a nil map, else the entry count plus one,
+// followed by the entries in name order.
+func encodeQNameMap[V any](e *snapshotEncoder, m map[QName]V, value func(*snapshotEncoder, V)) {
+	if m == nil {
+		e.uint(0)
+		return
+	}
+	e.uint(uint64(len(m)) + 1)
+	for _, q := range slices.SortedFunc(maps.Keys(m), compareQName) {
+		e.qname(q)
+		value(e, m[q])
+	}
+}
+
+func (e *snapshotEncoder) schemaBuild(b *SchemaBuild) {
+	e.literals = make(map[*CompiledLiteral]uint64)
+	e.enumerations = make(map[simpleValueEnumerationSource]uint64)
+	e.patterns = make(map[*stringPatternStep]uint64)
+	e.exprs = make(map[*xpath.Expr]uint64)
+
+	encodeSlice(e, b.Names.namespaces, (*snapshotEncoder).string)
+	encodeSlice(e, b.Names.locals, (*snapshotEncoder).string)
+	e.int(b.Names.maxNames)
+	e.builtinIDs(b.Builtin)
+	encodeSlice(e, b.SimpleTypes, (*snapshotEncoder).simpleType)
+	encodeSlice(e, b.ComplexTypes, (*snapshotEncoder).complexType)
+	encodeSlice(e, b.Elements, (*snapshotEncoder).elementDecl)
+	encodeSlice(e, b.Attributes, (*snapshotEncoder).attributeDecl)
+	encodeSlice(e, b.AttributeUseSets, (*snapshotEncoder).attributeUseSet)
+	encodeSlice(e, b.Models, (*snapshotEncoder).contentModel)
+	encodeSlice(e, b.CompiledModels, (*snapshotEncoder).compiledModel)
+	encodeSlice(e, b.Wildcards, (*snapshotEncoder).wildcard)
+	encodeSlice(e, b.Identities, (*snapshotEncoder).identity)
+	encodeSlice(e, b.Substitutions.spans, func(e *snapshotEncoder, span substitutionSpan) {
+		e.int(span.start)
+		e.int(span.count)
+	})
+	encodeSlice(e, b.Substitutions.entries, func(e *snapshotEncoder, entry substitutionEntry) {
+		e.qname(entry.name)
+		encodeUint(e, entry.member)
+		e.bool(entry.effective)
+	})
+	encodeQNameMap(e, b.GlobalAttributes, encodeUint[AttributeID])
+	encodeQNameMap(e, b.GlobalElements, encodeUint[ElementID])
+	encodeQNameMap(e, b.GlobalTypes, (*snapshotEncoder).typeID)
+	encodeQNameMap(e, b.GlobalIdentities, encodeUint[IdentityConstraintID])
+	encodeQNameMap(e, b.AttributeGroups, encodeUint[AttributeUseSetID])
+	encodeQNameMap(e, b.Notations, (*snapshotEncoder).bool)
+	e.annotations(b.Annotations)
+	e.locations(b.Locations)
+}
+
+func (e *snapshotEncoder) annotations(annotations map[AnnotationKey]Annotation) {
+	if annotations == nil {
+		e.uint(0)
+		return
+	}
+	keys := slices.SortedFunc(maps.Keys(annotations), compareAnnotationKey)
+	e.uint(uint64(len(keys)) + 1)
+	for _, key := range keys {
+		e.qname(key.Name)
+		encodeUint(e, key.ID)
+		encodeUint(e, key.Kind)
+		e.annotation(annotations[key])
+	}
+}
+
+func (e *snapshotEncoder) locations(locations map[LocationKey]SourceLocation) {
+	if locations == nil {
+		e.uint(0)
+		return
+	}
+	keys := slices.SortedFunc(maps.Keys(locations), compareLocationKey)
+	e.uint(uint64(len(keys)) + 1)
+	for _, key := range keys {
+		e.qname(key.Name)
+		encodeUint(e, key.ID)
+		encodeUint(e, key.Kind)
+		encodeUint(e, key.Facet)
+		loc := locations[key]
+		e.string(loc.Source)
+		e.string(loc.Component)
+		e.int(loc.Line)
+		e.int(loc.Column)
+	}
+}
+
+func compareAnnotationKey(a, b AnnotationKey) int {
+	return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.ID, b.ID), compareQName(a.Name, b.Name))
+}
+
+func compareLocationKey(a, b LocationKey) int {
+	return cmp.Or(compareAnnotationKey(a.AnnotationKey, b.AnnotationKey), cmp.Compare(a.Facet, b.Facet))
+}
+
+func (e *snapshotEncoder) qname(q QName) {
+	encodeUint(e, q.Namespace)
+	encodeUint(e, q.Local)
+}
+
+func (e *snapshotEncoder) typeID(t TypeID) {
+	encodeUint(e, t.kind)
+	encodeUint(e, t.id)
+}
+
+func (e *snapshotEncoder) builtinIDs(ids BuiltinIDs) {
+	encodeUint(e, ids.AnyType)
+	for _, id := range [...]SimpleTypeID{
+		ids.AnySimpleType, ids.AnyAtomicType, ids.String, ids.Boolean, ids.Decimal,
+		ids.Integer, ids.Int, ids.Date, ids.DateTime, ids.Time, ids.AnyURI, ids.QName,
+		ids.ID, ids.IDREF, ids.IDREFS, ids.NMTOKEN, ids.NMTOKENS, ids.ENTITY,
+		ids.ENTITIES, ids.Error,
+	} {
+		encodeUint(e, id)
+	}
+}
+
+func (e *snapshotEncoder) simpleType(st SimpleType) {
+	encodeSlice(e, st.Union, encodeUint[SimpleTypeID])
+	encodeSlice(e, st.UnionSources, encodeUint[SimpleTypeID])
+	e.facetSet(&st.Facets)
+	e.qname(st.Name)
+	encodeUint(e, st.Base)
+	encodeUint(e, st.ListItem)
+	encodeUint(e, st.Variety)
+	encodeUint(e, st.Primitive)
+	encodeUint(e, st.Final)
+	encodeUint(e, st.Whitespace)
+	encodeUint(e, st.Builtin)
+	encodeUint(e, st.Identity)
+	encodeUint(e, st.Fast)
+	encodeSlice(e, st.Assertions, (*snapshotEncoder).assertion)
+	e.bool(st.Missing)
+	encodeUint(e, st.Scope)
+}
+
+func (e *snapshotEncoder) facetSet(f *FacetSet) {
+	for _, literal := range f.bounds {
+		e.literalRef(literal)
+	}
+	e.patternStep(f.patterns.tail)
+	e.enumeration(f.Enumeration)
+	encodeUint(e, f.Length)
+	encodeUint(e, f.MinLength)
+	encodeUint(e, f.MaxLength)
+	encodeUint(e, f.TotalDigits)
+	encodeUint(e, f.FractionDigits)
+	encodeUint(e, f.Present)
+	encodeUint(e, f.Fixed)
+	encodeUint(e, f.ExplicitTimezone)
+}
+
+func (e *snapshotEncoder) literalRef(literal *CompiledLiteral) {
+	if literal == nil {
+		e.uint(0)
+		return
+	}
+	if n, ok := e.literals[literal]; ok {
+		e.uint(n)
+		return
+	}
+	n := uint64(len(e.literals) + 1)
+	e.literals[literal] = n
+	e.uint(n)
+	e.compiledLiteral(*literal)
+}
+
+func (e *snapshotEncoder) enumeration(literals []CompiledLiteral) {
+	source, ok := simpleValueEnumerationSourceForLiterals(literals)
+	if !ok {
+		e.uint(0)
+		return
+	}
+	if n, ok := e.enumerations[source]; ok {
+		e.uint(n)
+		return
+	}
+	n := uint64(len(e.enumerations) + 1)
+	e.enumerations[source] = n
+	e.uint(n)
+	encodeSlice(e, literals, (*snapshotEncoder).compiledLiteral)
+}
+
+func (e *snapshotEncoder) patternStep(step *stringPatternStep) {
+	if step == nil {
+		e.uint(0)
+		return
+	}
+	if n, ok := e.patterns[step]; ok {
+		e.uint(n)
+		return
+	}
+	n := uint64(len(e.patterns) + 1)
+	e.patterns[step] = n
+	e.uint(n)
+	e.patternStep(step.parent)
+	encodeUint(e, step.count)
+	encodeSlice(e, step.patterns, func(e *snapshotEncoder, p StringPattern) { e.string(p.Source()) })
+}
+
+func (e *snapshotEncoder) expr(x *xpath.Expr) {
+	if x == nil {
+		e.uint(0)
+		return
+	}
+	if n, ok := e.exprs[x]; ok {
+		e.uint(n)
+		return
+	}
+	n := uint64(len(e.exprs) + 1)
+	e.exprs[x] = n
+	e.uint(n)
+	e.string(x.String())
+	e.string(x.DefaultElementNamespace())
+	namespaces := x.Namespaces()
+	e.uint(uint64(len(namespaces)))
+	for _, prefix := range slices.Sorted(maps.Keys(namespaces)) {
+		e.string(prefix)
+		e.string(namespaces[prefix])
+	}
+}
+
+func (e *snapshotEncoder) assertion(a Assertion) { e.expr(a.Test) }
+
+func (e *snapshotEncoder) compiledLiteral(literal CompiledLiteral) {
+	e.string(literal.Lexical)
+	e.string(literal.Canonical)
+	encodeSlice(e, literal.ResolvedNames, (*snapshotEncoder).resolvedName)
+	encodeUint(e, literal.Type)
+}
+
+func (e *snapshotEncoder) resolvedName(name ResolvedValueName) {
+	e.string(name.Lexical)
+	e.string(name.NS)
+	e.string(name.Local)
+}
+
+func (e *snapshotEncoder) valueConstraint(vc *ValueConstraint) {
+	e.bool(vc != nil)
+	if vc == nil {
+		return
+	}
+	encodeSlice(e, vc.ResolvedNames, (*snapshotEncoder).resolvedName)
+	e.string(vc.Lexical)
+	e.string(vc.Canonical)
+	e.string(vc.Value.Canonical)
+	e.string(vc.Value.IDs)
+	e.string(vc.Value.IDRefs)
+	e.string(vc.Value.Identity)
+	encodeUint(e, vc.Value.Type)
+}
+
+func (e *snapshotEncoder) complexType(ct ComplexType) {
+	e.qname(ct.Name)
+	e.typeID(ct.Base)
+	encodeUint(e, ct.Content)
+	encodeUint(e, ct.Attrs)
+	encodeUint(e, ct.TextType)
+	encodeUint(e, ct.ContentKind)
+	e.bool(ct.Abstract)
+	encodeUint(e, ct.Derivation)
+	e.bool(ct.ExplicitDerivation)
+	encodeUint(e, ct.Block)
+	encodeUint(e, ct.Final)
+	encodeUint(e, ct.Scope)
+	encodeSlice(e, ct.Assertions, (*snapshotEncoder).assertion)
+}
+
+func (e *snapshotEncoder) elementDecl(decl ElementDecl) {
+	e.valueConstraint(decl.Default)
+	e.valueConstraint(decl.Fixed)
+	encodeSlice(e, decl.Identity, encodeUint[IdentityConstraintID])
+	e.typeID(decl.Type)
+	e.qname(decl.Name)
+	encodeUint(e, decl.SubstHead)
+	e.bool(decl.Nillable)
+	e.bool(decl.Abstract)
+	encodeUint(e, decl.Block)
+	encodeUint(e, decl.Final)
+	encodeUint(e, decl.Scope)
+	encodeSlice(e, decl.Alternatives, func(e *snapshotEncoder, alt TypeAlternative) {
+		e.expr(alt.Test)
+		e.typeID(alt.Type)
+	})
+}
+
+func (e *snapshotEncoder) attributeDecl(decl AttributeDecl) {
+	e.valueConstraint(decl.Default)
+	e.valueConstraint(decl.Fixed)
+	e.qname(decl.Name)
+	encodeUint(e, decl.Type)
+}
+
+func (e *snapshotEncoder) attributeUseSet(set AttributeUseSet) {
+	encodeQNameMap(e, set.Index, encodeUint[uint32])
+	encodeSlice(e, set.Uses, func(e *snapshotEncoder, use AttributeUse) {
+		e.valueConstraint(use.Default)
+		e.valueConstraint(use.Fixed)
+		e.qname(use.Name)
+		encodeUint(e, use.Type)
+		e.bool(use.Required)
+		e.bool(use.Prohibited)
+		e.bool(use.FixedFromDeclaration)
+	})
+	encodeSlice(e, set.Required, encodeUint[uint32])
+	encodeSlice(e, set.ValueConstraints, encodeUint[uint32])
+	encodeUint(e, set.Wildcard)
+	encodeUint(e, set.WildcardBase)
+	encodeUint(e, set.WildcardDeclared)
+	encodeUint(e, set.WildcardDerive)
+}
+
+func (e *snapshotEncoder) occurrence(o Occurrence) {
+	encodeUint(e, o.Min)
+	encodeUint(e, o.Max)
+	e.bool(o.Unbounded)
+}
+
+func (e *snapshotEncoder) particle(p Particle) {
+	encodeUint(e, p.Kind)
+	e.occurrence(p.Occurs)
+	encodeUint(e, p.Element)
+	encodeUint(e, p.Model)
+	encodeUint(e, p.Wildcard)
+}
+
+func (e *snapshotEncoder) openContent(open OpenContent) {
+	encodeUint(e, open.Wildcard)
+	encodeUint(e, open.Mode)
+}
+
+func (e *snapshotEncoder) contentModel(m ContentModel) {
+	encodeSlice(e, m.Particles, (*snapshotEncoder).particle)
+	encodeSlice(e, m.ChoiceLimits, encodeUint[uint32])
+	e.occurrence(m.Occurs)
+	encodeUint(e, m.Kind)
+	e.bool(m.Mixed)
+	e.openContent(m.Open)
+}
+
+func (e *snapshotEncoder) compiledModel(m CompiledModel) {
+	encodeSlice(e, m.Rows, func(e *snapshotEncoder, row CompiledModelRow) {
+		encodeSlice(e, row.Edges, func(e *snapshotEncoder, edge CompiledModelEdge) {
+			e.particle(edge.Particle)
+			encodeUint(e, edge.To)
+		})
+		encodeQNameMap(e, row.Index.NameToEdge, encodeUint[uint32])
+		encodeSlice(e, row.Index.WildcardEdges, encodeUint[uint32])
+		e.bool(row.Index.Enabled)
+		e.particle(row.CountParticle)
+		encodeUint(e, row.Min)
+		encodeUint(e, row.Max)
+		e.bool(row.Accept)
+		e.bool(row.Counted)
+		e.bool(row.Unbounded)
+	})
+	encodeSlice(e, m.All, func(e *snapshotEncoder, term CompiledAllTerm) {
+		e.particle(term.Particle)
+		e.bool(term.Required)
+	})
+	encodeUint(e, m.Source)
+	encodeUint(e, m.Start)
+	encodeUint(e, m.AllBitLen)
+	encodeUint(e, m.Kind)
+	e.bool(m.Mixed)
+	e.bool(m.Empty)
+	e.openContent(m.Open)
+}
+
+func (e *snapshotEncoder) wildcard(w Wildcard) {
+	encodeSlice(e, w.Namespaces, encodeUint[NamespaceID])
+	encodeSlice(e, w.NotQNames, (*snapshotEncoder).qname)
+	encodeUint(e, w.OtherThan)
+	encodeUint(e, w.Mode)
+	encodeUint(e, w.Process)
+	e.bool(w.NotDefined)
+	e.bool(w.NotDefinedSibling)
+}
+
+func (e *snapshotEncoder) identityStep(step IdentityStep) {
+	e.qname(step.Name)
+	e.bool(step.Wildcard)
+	e.bool(step.NamespaceSet)
+	encodeUint(e, step.Namespace)
+}
+
+// identity writes the parsed selector and fields; the field lookup tables
+// are rebuilt from them on decode.
+func (e *snapshotEncoder) identity(ic IdentityConstraint) {
+	encodeSlice(e, ic.Selector, func(e *snapshotEncoder, path IdentityPath) {
+		encodeSlice(e, path.Steps, (*snapshotEncoder).identityStep)
+		e.bool(path.Descendant)
+		e.bool(path.Self)
+	})
+	encodeSlice(e, ic.Fields, func(e *snapshotEncoder, field IdentityField) {
+		encodeSlice(e, field.Paths, func(e *snapshotEncoder, path IdentityFieldPath) {
+			encodeSlice(e, path.Steps, (*snapshotEncoder).identityStep)
+			e.qname(path.Attribute)
+			encodeUint(e, path.AttrNamespace)
+			e.bool(path.Descendant)
+			e.bool(path.Self)
+			e.bool(path.Attr)
+			e.bool(path.AttrWildcard)
+			e.bool(path.AttrNamespaceSet)
+		})
+		e.string(field.XPath)
+	})
+	e.string(ic.SelectorXPath)
+	e.qname(ic.Name)
+	encodeUint(e, ic.Refer)
+	encodeUint(e, ic.Kind)
+}
+
+func (e *snapshotEncoder) annotation(a Annotation) {
+	encodeSlice(e, a.Documentation, func(e *snapshotEncoder, doc Documentation) {
+		e.string(doc.Source)
+		e.string(doc.Lang)
+		e.string(doc.Text)
+		e.string(doc.Content)
+	})
+	encodeSlice(e, a.AppInfo, func(e *snapshotEncoder, info AppInfo) {
+		e.string(info.Source)
+		e.string(info.Content)
+	})
+}
+
+// snapshotDecoder reads the snapshot payload. The first malformed read
+// records err and stops consuming data, so later reads return zero values.
+type snapshotDecoder struct {
+	literals     []*CompiledLiteral
+	enumerations [][]CompiledLiteral
+	patterns     []*stringPatternStep
+	exprs        []*xpath.Expr
+	err          string
+	data         []byte
+}
+
+func (d *snapshotDecoder) fail(msg string) {
+	if d.err == "" {
+		d.err = msg
+	}
+	d.data = nil
+}
+
+func (d *snapshotDecoder) uint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail("truncated or invalid integer")
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// int reads a non-negative int written by snapshotEncoder.int.
+func (d *snapshotDecoder) int() int {
+	v := d.uint()
+	if v > math.MaxInt32 {
+		d.fail("integer out of range")
+		return 0
+	}
+	return int(v)
+}
+
+func (d *snapshotDecoder) bool() bool {
+	switch d.uint() {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		d.fail("invalid boolean")
+		return false
+	}
+}
+
+// length reads a count of items that each take at least one byte, so a
+// corrupt count cannot drive an allocation past the remaining data.
+func (d *snapshotDecoder) length() int {
+	n := d.uint()
+	if n > uint64(len(d.data)) {
+		d.fail("length exceeds remaining data")
+		return 0
+	}
+	return int(n)
+}
+
+// mapLength reads a map entry count written by encodeQNameMap. It returns -1
+// for a nil map.
+func (d *snapshotDecoder) mapLength() int {
+	n := d.uint()
+	if n == 0 {
+		return -1
+	}
+	if n-1 > uint64(len(d.data)) {
+		d.fail("length exceeds remaining data")
+		return -1
+	}
+	return int(n - 1)
+}
+
+func (d *snapshotDecoder) string() string {
+	n := d.length()
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func decodeUint[T ~uint8 | ~uint16 | ~uint32](d *snapshotDecoder) T {
+	v := d.uint()
+	if v > uint64(^T(0)) {
+		d.fail("integer out of range")
+		return 0
+	}
+	return T(v)
+}
+
+func decodeSlice[T any](d *snapshotDecoder, item func(*snapshotDecoder) T) []T {
+	n := d.length()
+	if n == 0 {
+		return nil
+	}
+	out := make([]T, n)
+	for i := range out {
+		out[i] = item(d)
+	}
+	return out
+}
+
+func decodeQNameMap[V any](d *snapshotDecoder, value func(*snapshotDecoder) V) map[QName]V {
+	n := d.mapLength()
+	if n < 0 {
+		return nil
+	}
+	out := make(map[QName]V, n)
+	for range n {
+		q := d.qname()
+		if _, ok := out[q]; ok {
+			d.fail("duplicate name")
+		}
+		out[q] = value(d)
+	}
+	return out
+}
+
+// ref reads a shared-value reference. It returns the index of a value already
+// decoded, or fresh when the value follows.
+func (d *snapshotDecoder) ref(decoded int) (index int, fresh, ok bool) {
+	n := d.uint()
+	switch {
+	case n == 0:
+		return 0, false, false
+	case n <= uint64(decoded):
+		return int(n - 1), false, true
+	case n == uint64(decoded)+1:
+		return decoded, true, true
+	default:
+		d.fail("invalid shared value reference")
+		return 0, false, false
+	}
+}
+
+func (d *snapshotDecoder) schemaBuild() SchemaBuild {
+	var b SchemaBuild
+	namespaces := decodeSlice(d, (*snapshotDecoder).string)
+	locals := decodeSlice(d, (*snapshotDecoder).string)
+	b.Names = NameTable{
+		nsIndex:    make(map[string]NamespaceID, len(namespaces)),
+		localIndex: make(map[string]LocalNameID, len(locals)),
+		namespaces: namespaces,
+		locals:     locals,
+		maxNames:   d.int(),
+	}
+	for i, uri := range namespaces {
+		b.Names.nsIndex[uri] = NamespaceID(i) //nolint:gosec // bounded by the publication ID-domain audit.
+	}
+	for i, local := range locals {
+		b.Names.localIndex[local] = LocalNameID(i) //nolint:gosec // bounded by the publication ID-domain audit.
+	}
+	b.Builtin = d.builtinIDs()
+	b.SimpleTypes = decodeSlice(d, (*snapshotDecoder).simpleType)
+	replayLiteralActuals(b.SimpleTypes, d.literals, d.enumerations)
+	b.ComplexTypes = decodeSlice(d, (*snapshotDecoder).complexType)
+	b.Elements = decodeSlice(d, (*snapshotDecoder).elementDecl)
+	b.Attributes = decodeSlice(d, (*snapshotDecoder).attributeDecl)
+	b.AttributeUseSets = decodeSlice(d, (*snapshotDecoder).attributeUseSet)
+	b.Models = decodeSlice(d, (*snapshotDecoder).contentModel)
+	b.CompiledModels = decodeSlice(d, (*snapshotDecoder).compiledModel)
+	b.Wildcards = decodeSlice(d, (*snapshotDecoder).wildcard)
+	b.Identities = decodeSlice(d, (*snapshotDecoder).identity)
+	b.Substitutions.spans = decodeSlice(d, func(d *snapshotDecoder) substitutionSpan {
+		return substitutionSpan{start: d.int(), count: d.int()}
+	})
+	b.Substitutions.entries = decodeSlice(d, func(d *snapshotDecoder) substitutionEntry {
+		return substitutionEntry{name: d.qname(), member: decodeUint[ElementID](d), effective: d.bool()}
+	})
+	b.GlobalAttributes = decodeQNameMap(d, decodeUint[AttributeID])
+	b.GlobalElements = decodeQNameMap(d, decodeUint[ElementID])
+	b.GlobalTypes = decodeQNameMap(d, (*snapshotDecoder).typeID)
+	b.GlobalIdentities = decodeQNameMap(d, decodeUint[IdentityConstraintID])
+	b.AttributeGroups = decodeQNameMap(d, decodeUint[AttributeUseSetID])
+	b.Notations = decodeQNameMap(d, (*snapshotDecoder).bool)
+	if n := d.mapLength(); n >= 0 {
+		b.Annotations = make(map[AnnotationKey]Annotation, n)
+		for range n {
+			key := AnnotationKey{Name: d.qname(), ID: decodeUint[uint32](d), Kind: decodeUint[AnnotatedKind](d)}
+			if _, ok := b.Annotations[key]; ok {
+				d.fail("duplicate annotation")
+			}
+			b.Annotations[key] = d.annotation()
+		}
+	}
+	if n := d.mapLength(); n >= 0 {
+		b.Locations = make(map[LocationKey]SourceLocation, n)
+		for range n {
+			key := LocationKey{
+				AnnotationKey: AnnotationKey{Name: d.qname(), ID: decodeUint[uint32](d), Kind: decodeUint[AnnotatedKind](d)},
+				Facet:         decodeUint[FacetMask](d),
+			}
+			if _, ok := b.Locations[key]; ok {
+				d.fail("duplicate source location")
+			}
+			b.Locations[key] = SourceLocation{Source: d.string(), Component: d.string(), Line: d.int(), Column: d.int()}
+		}
+	}
+	return b
+}
+
+func (d *snapshotDecoder) qname() QName {
+	return QName{Namespace: decodeUint[NamespaceID](d), Local: decodeUint[LocalNameID](d)}
+}
+
+func (d *snapshotDecoder) typeID() TypeID {
+	t := TypeID{kind: decodeUint[typeKind](d), id: decodeUint[uint32](d)}
+	if t.kind != typeNone && t.kind != typeSimple && t.kind != typeComplex {
+		d.fail("invalid type reference")
+	}
+	return t
+}
+
+func (d *snapshotDecoder) builtinIDs() BuiltinIDs {
+	ids := BuiltinIDs{AnyType: decodeUint[ComplexTypeID](d)}
+	for _, id := range [...]*SimpleTypeID{
+		&ids.AnySimpleType, &ids.AnyAtomicType, &ids.String, &ids.Boolean, &ids.Decimal,
+		&ids.Integer, &ids.Int, &ids.Date, &ids.DateTime, &ids.Time, &ids.AnyURI, &ids.QName,
+		&ids.ID, &ids.IDREF, &ids.IDREFS, &ids.NMTOKEN, &ids.NMTOKENS, &ids.ENTITY,
+		&ids.ENTITIES, &ids.Error,
+	} {
+		*id = decodeUint[SimpleTypeID](d)
+	}
+	return ids
+}
+
+func (d *snapshotDecoder) simpleType() SimpleType {
+	var st SimpleType
+	st.Union = decodeSlice(d, decodeUint[SimpleTypeID])
+	st.UnionSources = decodeSlice(d, decodeUint[SimpleTypeID])
+	st.Facets = d.facetSet()
+	st.Name = d.qname()
+	st.Base = decodeUint[SimpleTypeID](d)
+	st.ListItem = decodeUint[SimpleTypeID](d)
+	st.Variety = decodeUint[SimpleVariety](d)
+	st.Primitive = decodeUint[PrimitiveKind](d)
+	st.Final = decodeUint[DerivationMask](d)
+	st.Whitespace = decodeUint[WhitespaceMode](d)
+	st.Builtin = decodeUint[BuiltinValidationKind](d)
+	st.Identity = decodeUint[SimpleIdentityKind](d)
+	st.Fast = decodeUint[SimpleFastKind](d)
+	st.Assertions = decodeSlice(d, (*snapshotDecoder).assertion)
+	st.Missing = d.bool()
+	st.Scope = decodeUint[DeclarationScope](d)
+	return st
+}
+
+func (d *snapshotDecoder) facetSet() FacetSet {
+	var f FacetSet
+	for i := range f.bounds {
+		f.bounds[i] = d.literalRef()
+	}
+	f.patterns.tail = d.patternStep()
+	f.Enumeration = d.enumeration()
+	f.Length = decodeUint[uint32](d)
+	f.MinLength = decodeUint[uint32](d)
+	f.MaxLength = decodeUint[uint32](d)
+	f.TotalDigits = decodeUint[uint32](d)
+	f.FractionDigits = decodeUint[uint32](d)
+	f.Present = decodeUint[FacetMask](d)
+	f.Fixed = decodeUint[FacetMask](d)
+	f.ExplicitTimezone = decodeUint[ExplicitTimezone](d)
+	return f
+}
+
+func (d *snapshotDecoder) literalRef() *CompiledLiteral {
+	i, fresh, ok := d.ref(len(d.literals))
+	if !ok {
+		return nil
+	}
+	if !fresh {
+		return d.literals[i]
+	}
+	literal := new(CompiledLiteral)
+	d.literals = append(d.literals, literal)
+	*literal = d.compiledLiteral()
+	return literal
+}
+
+// replayLiteralActuals restores the parsed actual values of decoded facet
+// literals from their lexical forms, as NewCompiledLiteralForSimpleType
+// computes them.
+func replayLiteralActuals(types []SimpleType, literals []*CompiledLiteral, enumerations [][]CompiledLiteral) {
+	replay := func(literal *CompiledLiteral) {
+		if ValidSimpleTypeID(literal.Type, len(types)) {
+			literal.Actual = compiledLiteralActualValue(types[literal.Type], literal.Lexical)
+		}
+	}
+	for _, literal := range literals {
+		replay(literal)
+	}
+	for _, enumeration := range enumerations {
+		for i := range enumeration {
+			replay(&enumeration[i])
+		}
+	}
+}
+
+func (d *snapshotDecoder) enumeration() []CompiledLiteral {
+	i, fresh, ok := d.ref(len(d.enumerations))
+	if !ok {
+		return nil
+	}
+	if !fresh {
+		return d.enumerations[i]
+	}
+	literals := decodeSlice(d, (*snapshotDecoder).compiledLiteral)
+	if literals == nil {
+		d.fail("empty enumeration")
+	}
+	d.enumerations = append(d.enumerations, literals)
+	return literals
+}
+
+func (d *snapshotDecoder) patternStep() *stringPatternStep {
+	i, fresh, ok := d.ref(len(d.patterns))
+	if !ok {
+		return nil
+	}
+	if !fresh {
+		return d.patterns[i]
+	}
+	step := new(stringPatternStep)
+	d.patterns = append(d.patterns, step)
+	step.parent = d.patternStep()
+	step.count = decodeUint[uint32](d)
+	step.patterns = decodeSlice(d, (*snapshotDecoder).stringPattern)
+	return step
+}
+
+func (d *snapshotDecoder) stringPattern() StringPattern {
+	source := d.string()
+	if d.err != "" {
+		return StringPattern{}
+	}
+	p, err := recompileStringPattern(source)
+	if err != nil {
+		d.fail(err.Error())
+	}
+	return p
+}
+
+// recompileStringPattern compiles a pattern facet the way the schema compiler
+// does: with the fast matcher when the pattern allows it, else the regex
+// matcher.
+func recompileStringPattern(source string) (StringPattern, error) {
+	if fast := CompileSimpleStringPattern(source); fast != nil {
+		return NewFastStringPattern(fast), nil
+	}
+	re, err := regex.Compile(source)
+	if err != nil {
+		return StringPattern{}, errors.New("invalid pattern " + strconv.Quote(source) + ": " + err.Error())
+	}
+	return NewRegexStringPattern(re), nil
+}
+
+func (d *snapshotDecoder) expr() *xpath.Expr {
+	i, fresh, ok := d.ref(len(d.exprs))
+	if !ok {
+		return nil
+	}
+	if !fresh {
+		return d.exprs[i]
+	}
+	source := d.string()
+	defaultNS := d.string()
+	var namespaces map[string]string
+	if n := d.length(); n != 0 {
+		namespaces = make(map[string]string, n)
+		for range n {
+			prefix := d.string()
+			namespaces[prefix] = d.string()
+		}
+	}
+	if d.err != "" {
+		return nil
+	}
+	x, err := recompileExpr(source, defaultNS, namespaces)
+	if err != nil {
+		d.fail(err.Error())
+		return nil
+	}
+	d.exprs = append(d.exprs, x)
+	return x
+}
+
+// recompileExpr compiles an XPath expression in the static context it was
+// compiled in by the schema compiler.
+func recompileExpr(source, defaultNS string, namespaces map[string]string) (*xpath.Expr, error) {
+	x, err := xpath.Compile(source, xpath.StaticContext{
+		Namespace: func(prefix string) (string, bool) {
+			uri, ok := namespaces[prefix]
+			return uri, ok
+		},
+		DefaultElementNamespace: defaultNS,
+	})
+	if err != nil {
+		return nil, errors.New("invalid XPath expression: " + err.Error())
+	}
+	return x, nil
+}
+
+func (d *snapshotDecoder) assertion() Assertion { return Assertion{Test: d.expr()} }
+
+func (d *snapshotDecoder) compiledLiteral() CompiledLiteral {
+	return CompiledLiteral{
+		Lexical:       d.string(),
+		Canonical:     d.string(),
+		ResolvedNames: decodeSlice(d, (*snapshotDecoder).resolvedName),
+		Type:          decodeUint[SimpleTypeID](d),
+	}
+}
+
+func (d *snapshotDecoder) resolvedName() ResolvedValueName {
+	return ResolvedValueName{Lexical: d.string(), NS: d.string(), Local: d.string()}
+}
+
+func (d *snapshotDecoder) valueConstraint() *ValueConstraint {
+	if !d.bool() {
+		return nil
+	}
+	return &ValueConstraint{
+		ResolvedNames: decodeSlice(d, (*snapshotDecoder).resolvedName),
+		Lexical:       d.string(),
+		Canonical:     d.string(),
+		Value: SimpleValue{
+			Canonical: d.string(),
+			IDs:       d.string(),
+			IDRefs:    d.string(),
+			Identity:  d.string(),
+			Type:      decodeUint[SimpleTypeID](d),
+		},
+	}
+}
+
+func (d *snapshotDecoder) complexType() ComplexType {
+	return ComplexType{
+		Name:               d.qname(),
+		Base:               d.typeID(),
+		Content:            decodeUint[ContentModelID](d),
+		Attrs:              decodeUint[AttributeUseSetID](d),
+		TextType:           decodeUint[SimpleTypeID](d),
+		ContentKind:        decodeUint[ContentKind](d),
+		Abstract:           d.bool(),
+		Derivation:         decodeUint[DerivationKind](d),
+		ExplicitDerivation: d.bool(),
+		Block:              decodeUint[DerivationMask](d),
+		Final:              decodeUint[DerivationMask](d),
+		Scope:              decodeUint[DeclarationScope](d),
+		Assertions:         decodeSlice(d, (*snapshotDecoder).assertion),
+	}
+}
+
+func (d *snapshotDecoder) elementDecl() ElementDecl {
+	return ElementDecl{
+		Default:   d.valueConstraint(),
+		Fixed:     d.valueConstraint(),
+		Identity:  decodeSlice(d, decodeUint[IdentityConstraintID]),
+		Type:      d.typeID(),
+		Name:      d.qname(),
+		SubstHead: decodeUint[ElementID](d),
+		Nillable:  d.bool(),
+		Abstract:  d.bool(),
+		Block:     decodeUint[DerivationMask](d),
+		Final:     decodeUint[DerivationMask](d),
+		Scope:     decodeUint[DeclarationScope](d),
+		Alternatives: decodeSlice(d, func(d *snapshotDecoder) TypeAlternative {
+			return TypeAlternative{Test: d.expr(), Type: d.typeID()}
+		}),
+	}
+}
+
+func (d *snapshotDecoder) attributeDecl() AttributeDecl {
+	return AttributeDecl{
+		Default: d.valueConstraint(),
+		Fixed:   d.valueConstraint(),
+		Name:    d.qname(),
+		Type:    decodeUint[SimpleTypeID](d),
+	}
+}
+
+func (d *snapshotDecoder) attributeUseSet() AttributeUseSet {
+	return AttributeUseSet{
+		Index: decodeQNameMap(d, decodeUint[uint32]),
+		Uses: decodeSlice(d, func(d *snapshotDecoder) AttributeUse {
+			return AttributeUse{
+				Default:              d.valueConstraint(),
+				Fixed:                d.valueConstraint(),
+				Name:                 d.qname(),
+				Type:                 decodeUint[SimpleTypeID](d),
+				Required:             d.bool(),
+				Prohibited:           d.bool(),
+				FixedFromDeclaration: d.bool(),
+			}
+		}),
+		Required:         decodeSlice(d, decodeUint[uint32]),
+		ValueConstraints: decodeSlice(d, decodeUint[uint32]),
+		Wildcard:         decodeUint[WildcardID](d),
+		WildcardBase:     decodeUint[WildcardID](d),
+		WildcardDeclared: decodeUint[WildcardID](d),
+		WildcardDerive:   decodeUint[AttributeWildcardDerivation](d),
+	}
+}
+
+func (d *snapshotDecoder) occurrence() Occurrence {
+	return Occurrence{Min: decodeUint[uint32](d), Max: decodeUint[uint32](d), Unbounded: d.bool()}
+}
+
+func (d *snapshotDecoder) particle() Particle {
+	return Particle{
+		Kind:     decodeUint[ParticleKind](d),
+		Occurs:   d.occurrence(),
+		Element:  decodeUint[ElementID](d),
+		Model:    decodeUint[ContentModelID](d),
+		Wildcard: decodeUint[WildcardID](d),
+	}
+}
+
+func (d *snapshotDecoder) openContent() OpenContent {
+	return OpenContent{Wildcard: decodeUint[WildcardID](d), Mode: decodeUint[OpenContentMode](d)}
+}
+
+func (d *snapshotDecoder) contentModel() ContentModel {
+	return ContentModel{
+		Particles:    decodeSlice(d, (*snapshotDecoder).particle),
+		ChoiceLimits: decodeSlice(d, decodeUint[uint32]),
+		Occurs:       d.occurrence(),
+		Kind:         decodeUint[ModelKind](d),
+		Mixed:        d.bool(),
+		Open:         d.openContent(),
+	}
+}
+
+func (d *snapshotDecoder) compiledModel() CompiledModel {
+	return CompiledModel{
+		Rows: decodeSlice(d, func(d *snapshotDecoder) CompiledModelRow {
+			return CompiledModelRow{
+				Edges: decodeSlice(d, func(d *snapshotDecoder) CompiledModelEdge {
+					return CompiledModelEdge{Particle: d.particle(), To: decodeUint[uint32](d)}
+				}),
+				Index: DFARowIndex{
+					NameToEdge:    decodeQNameMap(d, decodeUint[uint32]),
+					WildcardEdges: decodeSlice(d, decodeUint[uint32]),
+					Enabled:       d.bool(),
+				},
+				CountParticle: d.particle(),
+				Min:           decodeUint[uint32](d),
+				Max:           decodeUint[uint32](d),
+				Accept:        d.bool(),
+				Counted:       d.bool(),
+				Unbounded:     d.bool(),
+			}
+		}),
+		All: decodeSlice(d, func(d *snapshotDecoder) CompiledAllTerm {
+			return CompiledAllTerm{Particle: d.particle(), Required: d.bool()}
+		}),
+		Source:    decodeUint[ContentModelID](d),
+		Start:     decodeUint[uint32](d),
+		AllBitLen: decodeUint[uint32](d),
+		Kind:      decodeUint[CompiledModelKind](d),
+		Mixed:     d.bool(),
+		Empty:     d.bool(),
+		Open:      d.openContent(),
+	}
+}
+
+func (d *snapshotDecoder) wildcard() Wildcard {
+	return Wildcard{
+		Namespaces:        decodeSlice(d, decodeUint[NamespaceID]),
+		NotQNames:         decodeSlice(d, (*snapshotDecoder).qname),
+		OtherThan:         decodeUint[NamespaceID](d),
+		Mode:              decodeUint[WildcardMode](d),
+		Process:           decodeUint[ProcessContents](d),
+		NotDefined:        d.bool(),
+		NotDefinedSibling: d.bool(),
+	}
+}
+
+func (d *snapshotDecoder) identityStep() IdentityStep {
+	return IdentityStep{
+		Name:         d.qname(),
+		Wildcard:     d.bool(),
+		NamespaceSet: d.bool(),
+		Namespace:    decodeUint[NamespaceID](d),
+	}
+}
+
+func (d *snapshotDecoder) identity() IdentityConstraint {
+	ic := IdentityConstraint{
+		Selector: decodeSlice(d, func(d *snapshotDecoder) IdentityPath {
+			return IdentityPath{
+				Steps:      decodeSlice(d, (*snapshotDecoder).identityStep),
+				Descendant: d.bool(),
+				Self:       d.bool(),
+			}
+		}),
+		Fields: decodeSlice(d, func(d *snapshotDecoder) IdentityField {
+			return IdentityField{
+				Paths: decodeSlice(d, func(d *snapshotDecoder) IdentityFieldPath {
+					return IdentityFieldPath{
+						Steps:            decodeSlice(d, (*snapshotDecoder).identityStep),
+						Attribute:        d.qname(),
+						AttrNamespace:    decodeUint[NamespaceID](d),
+						Descendant:       d.bool(),
+						Self:             d.bool(),
+						Attr:             d.bool(),
+						AttrWildcard:     d.bool(),
+						AttrNamespaceSet: d.bool(),
+					}
+				}),
+				XPath: d.string(),
+			}
+		}),
+		SelectorXPath: d.string(),
+		Name:          d.qname(),
+		Refer:         decodeUint[IdentityConstraintID](d),
+		Kind:          decodeUint[IdentityKind](d),
+	}
+	ic.ElementFields, ic.AttributeFields, ic.AttributeWildcardFields = BuildIdentityFieldLookup(ic.Fields)
+	return ic
+}
+
+func (d *snapshotDecoder) annotation() Annotation {
+	return Annotation{
+		Documentation: decodeSlice(d, func(d *snapshotDecoder) Documentation {
+			return Documentation{Source: d.string(), Lang: d.string(), Text: d.string(), Content: d.string()}
+		}),
+		AppInfo: decodeSlice(d, func(d *snapshotDecoder) AppInfo {
+			return AppInfo{Source: d.string(), Content: d.string()}
+		}),
+	}
+}
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSnapshotEncodesEveryBuildField fails when a schema table gains or loses
+// a field, so the snapshot codec, the table conversion in tables.go and
+// SnapshotVersion are revisited with it.
+func TestSnapshotEncodesEveryBuildField(t *testing.T) {
+	t.Parallel()
+
+	fields := map[reflect.Type]int{
+		reflect.TypeFor[SchemaBuild]():        20,
+		reflect.TypeFor[NameTable]():          5,
+		reflect.TypeFor[BuiltinIDs]():         21,
+		reflect.TypeFor[SimpleType]():         16,
+		reflect.TypeFor[FacetSet]():           11,
+		reflect.TypeFor[stringPatternStep]():  3,
+		reflect.TypeFor[CompiledLiteral]():    5,
+		reflect.TypeFor[ResolvedValueName]():  3,
+		reflect.TypeFor[Assertion]():          1,
+		reflect.TypeFor[ValueConstraint]():    4,
+		reflect.TypeFor[SimpleValue]():        5,
+		reflect.TypeFor[ComplexType]():        13,
+		reflect.TypeFor[ElementDecl]():        12,
+		reflect.TypeFor[TypeAlternative]():    2,
+		reflect.TypeFor[AttributeDecl]():      4,
+		reflect.TypeFor[AttributeUseSet]():    8,
+		reflect.TypeFor[AttributeUse]():       7,
+		reflect.TypeFor[ContentModel]():       6,
+		reflect.TypeFor[Particle]():           5,
+		reflect.TypeFor[Occurrence]():         3,
+		reflect.TypeFor[OpenContent]():        2,
+		reflect.TypeFor[CompiledModel]():      9,
+		reflect.TypeFor[CompiledModelRow]():   8,
+		reflect.TypeFor[CompiledModelEdge]():  2,
+		reflect.TypeFor[DFARowIndex]():        3,
+		reflect.TypeFor[CompiledAllTerm]():    2,
+		reflect.TypeFor[Wildcard]():           7,
+		reflect.TypeFor[IdentityConstraint](): 9,
+		reflect.TypeFor[IdentityPath]():       3,
+		reflect.TypeFor[IdentityStep]():       4,
+		reflect.TypeFor[IdentityField]():      2,
+		reflect.TypeFor[IdentityFieldPath]():  8,
+		reflect.TypeFor[SubstitutionTable]():  2,
+		reflect.TypeFor[substitutionSpan]():   2,
+		reflect.TypeFor[substitutionEntry]():  3,
+		reflect.TypeFor[AnnotationKey]():      3,
+		reflect.TypeFor[Annotation]():         2,
+		reflect.TypeFor[Documentation]():      4,
+		reflect.TypeFor[AppInfo]():            2,
+		reflect.TypeFor[LocationKey]():        2,
+		reflect.TypeFor[SourceLocation]():     4,
+		reflect.TypeFor[TypeID]():             2,
+		reflect.TypeFor[QName]():              2,
+	}
+	for typ, want := range fields {
+		if got := typ.NumField(); got != want {
+			t.Errorf("%s has %d fields, snapshot codec encodes %d; update the codec, the table conversion and SnapshotVersion", typ, got, want)
+		}
+	}
+}
@@ -9,6 +9,9 @@ type ElementStartInfo struct {
 	Nillable bool
 	Fixed    bool
 	Default  bool
+	// Alternatives reports that the governing type is selected from the
+	// declaration's XSD 1.1 type alternatives.
+	Alternatives bool
 }
 
 // ElementStartInfoShape is the schema-independent projection used to publish
@@ -20,6 +23,9 @@ type ElementStartInfoShape struct {
 	Nillable bool
 	Fixed    bool
 	Default  bool
+	// Alternatives reports that the governing type is selected from the
+	// declaration's XSD 1.1 type alternatives.
+	Alternatives bool
 }
 
 // NewElementStartInfo returns the start projection for one element
@@ -3,16 +3,17 @@ package runtime
 import (
 	"errors"
 	"math"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/jacoelho/xsd/internal/regex"
 )
 
 // StringPattern is a compiled string pattern matcher used during validation.
 type StringPattern struct {
-	re   *regexp.Regexp
+	re   *regex.Regex
 	fast *SimplePattern
 }
 
@@ -33,7 +34,7 @@ type stringPatternStepRead struct {
 }
 
 type stringPatternRead struct {
-	re   *regexp.Regexp
+	re   *regex.Regex
 	fast *SimplePattern
 }
 
@@ -90,7 +91,7 @@ func newStringPatternReadPoolForSimpleTypes(types []SimpleType) map[*stringPatte
 	reads := make([]stringPatternStepRead, len(sources))
 	patterns := make([]stringPatternRead, patternCount)
 	fastCopies := make(map[*SimplePattern]*SimplePattern)
-	regexpCopies := make(map[*regexp.Regexp]*regexp.Regexp)
+	regexCopies := make(map[*regex.Regex]*regex.Regex)
 	patternOffset := 0
 	for i, source := range sources {
 		var stepPatterns []stringPatternRead
@@ -99,7 +100,7 @@ func newStringPatternReadPoolForSimpleTypes(types []SimpleType) map[*stringPatte
 			stepPatterns = patterns[patternOffset:end:end]
 			patternOffset = end
 			for j, pattern := range source.patterns {
-				stepPatterns[j] = newStringPatternRead(pattern, fastCopies, regexpCopies)
+				stepPatterns[j] = newStringPatternRead(pattern, fastCopies, regexCopies)
 			}
 		}
 		reads[i] = stringPatternStepRead{
@@ -115,13 +116,14 @@ func newStringPatternReadPoolForSimpleTypes(types []SimpleType) map[*stringPatte
 func newStringPatternRead(
 	pattern StringPattern,
 	fastCopies map[*SimplePattern]*SimplePattern,
-	regexpCopies map[*regexp.Regexp]*regexp.Regexp,
+	regexCopies map[*regex.Regex]*regex.Regex,
 ) stringPatternRead {
 	if pattern.fast != nil {
 		if fast := fastCopies[pattern.fast]; fast != nil {
 			return stringPatternRead{fast: fast}
 		}
 		fast := &SimplePattern{
+			source:   pattern.fast.source,
 			atoms:    slices.Clone(pattern.fast.atoms),
 			variable: pattern.fast.variable,
 		}
@@ -131,13 +133,11 @@ func newStringPatternRead(
 		fastCopies[pattern.fast] = fast
 		return stringPatternRead{fast: fast}
 	}
-	if re := regexpCopies[pattern.re]; re != nil {
+	if re := regexCopies[pattern.re]; re != nil {
 		return stringPatternRead{re: re}
 	}
-	// Validation only asks whether the expression matches, so recompiling the
-	// same source preserves its language without sharing Longest's mutable flag.
-	re := regexp.MustCompile(pattern.re.String())
-	regexpCopies[pattern.re] = re
+	re := pattern.re.Clone()
+	regexCopies[pattern.re] = re
 	return stringPatternRead{re: re}
 }
 
@@ -148,6 +148,21 @@ func addStringPatternReadCount(total, count int) int {
 	return total + count
 }
 
+// sources returns the pattern facet values of each derivation step, base
+// type steps first.
+func (s stringPatternSteps) sources() [][]string {
+	var out [][]string
+	for step := s.tail; step != nil; step = step.parent {
+		group := make([]string, len(step.patterns))
+		for i, pattern := range step.patterns {
+			group[i] = pattern.Source()
+		}
+		out = append(out, group)
+	}
+	slices.Reverse(out)
+	return out
+}
+
 func (s stringPatternSteps) count() uint32 {
 	if s.tail == nil {
 		return 0
@@ -214,7 +229,7 @@ func (p stringPatternRead) matchStringWithScratch(s string, input *simplePattern
 		}
 		return p.fast.MatchString(s)
 	}
-	return p.re.MatchString(s)
+	return scratch.regex.MatchString(p.re, s)
 }
 
 func (p stringPatternRead) matchBytes(s []byte) bool {
@@ -234,7 +249,7 @@ func (p stringPatternRead) matchBytesWithScratch(s []byte, input *simplePatternI
 		}
 		return p.fast.MatchBytes(s)
 	}
-	return p.re.Match(s)
+	return scratch.regex.Match(p.re, s)
 }
 
 // NewFastStringPattern returns a pattern backed by the runtime fast matcher.
@@ -242,11 +257,20 @@ func NewFastStringPattern(fast *SimplePattern) StringPattern {
 	return StringPattern{fast: fast}
 }
 
-// NewRegexpStringPattern returns a pattern backed by a Go regexp.
-func NewRegexpStringPattern(re *regexp.Regexp) StringPattern {
+// NewRegexStringPattern returns a pattern backed by the native XSD regular
+// expression matcher.
+func NewRegexStringPattern(re *regex.Regex) StringPattern {
 	return StringPattern{re: re}
 }
 
+// Source returns the pattern facet value p was compiled from.
+func (p StringPattern) Source() string {
+	if p.fast != nil {
+		return p.fast.source
+	}
+	return p.re.String()
+}
+
 // MatchString reports whether s matches p.
 func (p StringPattern) MatchString(s string) bool {
 	if p.fast != nil {
@@ -274,6 +298,7 @@ func equalSimplePattern(a, b *SimplePattern) bool {
 
 // SimplePattern is a small compiled subset of XSD regex syntax.
 type SimplePattern struct {
+	source   string
 	atoms    []simplePatternAtom
 	variable bool
 }
@@ -281,15 +306,21 @@ type SimplePattern struct {
 // StringPatternScratch owns reusable scalar buffers for published string
 // pattern validation. It must not be shared by concurrent validations.
 type StringPatternScratch struct {
+	regex  regex.Machine
 	runes  []rune
 	states []bool
 }
 
 // Reset clears scratch state and drops buffers larger than maxRetainedRunes.
+// Regex simulation buffers are sized by compiled programs, not input, and are
+// retained unless maxRetainedRunes is negative.
 func (s *StringPatternScratch) Reset(maxRetainedRunes int) {
 	if s == nil {
 		return
 	}
+	if maxRetainedRunes < 0 {
+		s.regex = regex.Machine{}
+	}
 	if maxRetainedRunes < 0 || cap(s.runes) > maxRetainedRunes {
 		s.runes = nil
 	} else {
@@ -401,9 +432,9 @@ type runeRange struct {
 }
 
 // CompileSimpleStringPattern compiles the fast runtime subset of XSD regex
-// syntax. It returns nil when source requires the general regexp path.
+// syntax. It returns nil when source requires the general regex matcher.
 func CompileSimpleStringPattern(source string) *SimplePattern {
-	var out SimplePattern
+	out := SimplePattern{source: source}
 	for i := 0; i < len(source); {
 		class, next, ok := parseSimplePatternAtom(source, i)
 		if !ok {
@@ -0,0 +1,969 @@
+package runtime
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/jacoelho/xsd/internal/xpath"
+	"github.com/jacoelho/xsd/schematables"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// ExportTables copies the tables of rt into plain values. Like EncodeSchema it
+// stores compiled regular expressions, XPath expressions and parsed facet
+// literal values as source, writes shared values once and reads only the
+// tables rt was published from.
+func ExportTables(rt *Schema) (*schematables.Schema, error) {
+	if rt == nil {
+		return nil, xsderrors.InternalInvariant("nil schema")
+	}
+	e := tablesExporter{
+		out:          &schematables.Schema{Version: SnapshotVersion},
+		literals:     make(map[*CompiledLiteral]uint32),
+		enumerations: make(map[simpleValueEnumerationSource]uint32),
+		patterns:     make(map[*stringPatternStep]uint32),
+		exprs:        make(map[*xpath.Expr]uint32),
+	}
+	e.schemaBuild(&rt.tables)
+	return e.out, nil
+}
+
+// ImportTables rebuilds the tables exported by ExportTables and publishes them
+// through PublishSchema, so imported tables satisfy the same invariants as a
+// compiled schema.
+func ImportTables(ctx context.Context, tables *schematables.Schema) (*Schema, error) {
+	if tables == nil {
+		return nil, snapshotError("nil schema tables")
+	}
+	if tables.Version != SnapshotVersion {
+		return nil, snapshotError("unsupported schema tables version " + strconv.FormatUint(uint64(tables.Version), 10))
+	}
+	im := tablesImporter{in: tables}
+	build := im.schemaBuild()
+	if im.err != "" {
+		return nil, snapshotError("malformed schema tables: " + im.err)
+	}
+	return PublishSchema(ctx, &build)
+}
+
+// tablesExporter maps shared values to their one-based positions in the
+// output pools.
+type tablesExporter struct {
+	out          *schematables.Schema
+	literals     map[*CompiledLiteral]uint32
+	enumerations map[simpleValueEnumerationSource]uint32
+	patterns     map[*stringPatternStep]uint32
+	exprs        map[*xpath.Expr]uint32
+}
+
+// convertSlice converts each item, keeping a nil slice nil.
+func convertSlice[T, U any](items []T, item func(T) U) []U {
+	if items == nil {
+		return nil
+	}
+	out := make([]U, len(items))
+	for i, v := range items {
+		out[i] = item(v)
+	}
+	return out
+}
+
+func exportUint32[T ~uint32](v T) uint32 { return uint32(v) }
+
+func exportNameIDs[T ~uint32](m map[QName]T) []schematables.NameID {
+	if m == nil {
+		return nil
+	}
+	out := make([]schematables.NameID, 0, len(m))
+	for _, q := range slices.SortedFunc(maps.Keys(m), compareQName) {
+		out = append(out, schematables.NameID{Name: exportQName(q), ID: uint32(m[q])})
+	}
+	return out
+}
+
+func exportQName(q QName) schematables.QName {
+	return schematables.QName{Namespace: uint32(q.Namespace), Local: uint32(q.Local)}
+}
+
+func exportTypeID(t TypeID) schematables.TypeRef {
+	return schematables.TypeRef{Kind: uint8(t.kind), ID: t.id}
+}
+
+func exportAnnotationKey(key AnnotationKey) schematables.AnnotationKey {
+	return schematables.AnnotationKey{Name: exportQName(key.Name), ID: key.ID, Kind: uint8(key.Kind)}
+}
+
+func (e *tablesExporter) schemaBuild(b *SchemaBuild) {
+	out := e.out
+	out.Namespaces = slices.Clone(b.Names.namespaces)
+	out.Locals = slices.Clone(b.Names.locals)
+	out.MaxNames = b.Names.maxNames
+	ids := b.Builtin
+	out.Builtin = schematables.Builtin{
+		AnyType: uint32(ids.AnyType), AnySimpleType: uint32(ids.AnySimpleType), AnyAtomicType: uint32(ids.AnyAtomicType),
+		String: uint32(ids.String), Boolean: uint32(ids.Boolean), Decimal: uint32(ids.Decimal),
+		Integer: uint32(ids.Integer), Int: uint32(ids.Int), Date: uint32(ids.Date),
+		DateTime: uint32(ids.DateTime), Time: uint32(ids.Time), AnyURI: uint32(ids.AnyURI),
+		QName: uint32(ids.QName), ID: uint32(ids.ID), IDREF: uint32(ids.IDREF),
+		IDREFS: uint32(ids.IDREFS), NMTOKEN: uint32(ids.NMTOKEN), NMTOKENS: uint32(ids.NMTOKENS),
+		ENTITY: uint32(ids.ENTITY), ENTITIES: uint32(ids.ENTITIES), Error: uint32(ids.Error),
+	}
+	out.SimpleTypes = convertSlice(b.SimpleTypes, e.simpleType)
+	out.ComplexTypes = convertSlice(b.ComplexTypes, e.complexType)
+	out.Elements = convertSlice(b.Elements, e.elementDecl)
+	out.Attributes = convertSlice(b.Attributes, func(decl AttributeDecl) schematables.Attribute {
+		return schematables.Attribute{
+			Default: exportValueConstraint(decl.Default),
+			Fixed:   exportValueConstraint(decl.Fixed),
+			Name:    exportQName(decl.Name),
+			Type:    uint32(decl.Type),
+		}
+	})
+	out.AttributeUseSets = convertSlice(b.AttributeUseSets, exportAttributeUseSet)
+	out.Models = convertSlice(b.Models, func(m ContentModel) schematables.ContentModel {
+		return schematables.ContentModel{
+			Particles:    convertSlice(m.Particles, exportParticle),
+			ChoiceLimits: slices.Clone(m.ChoiceLimits),
+			Occurs:       exportOccurrence(m.Occurs),
+			Kind:         uint8(m.Kind),
+			Mixed:        m.Mixed,
+			Open:         exportOpenContent(m.Open),
+		}
+	})
+	out.CompiledModels = convertSlice(b.CompiledModels, exportCompiledModel)
+	out.Wildcards = convertSlice(b.Wildcards, func(w Wildcard) schematables.Wildcard {
+		return schematables.Wildcard{
+			Namespaces:        convertSlice(w.Namespaces, exportUint32[NamespaceID]),
+			NotQNames:         convertSlice(w.NotQNames, exportQName),
+			OtherThan:         uint32(w.OtherThan),
+			Mode:              uint8(w.Mode),
+			Process:           uint8(w.Process),
+			NotDefined:        w.NotDefined,
+			NotDefinedSibling: w.NotDefinedSibling,
+		}
+	})
+	out.Identities = convertSlice(b.Identities, exportIdentity)
+	out.SubstitutionSpans = convertSlice(b.Substitutions.spans, func(span substitutionSpan) schematables.SubstitutionSpan {
+		return schematables.SubstitutionSpan{Start: span.start, Count: span.count}
+	})
+	out.Substitutions = convertSlice(b.Substitutions.entries, func(entry substitutionEntry) schematables.Substitution {
+		return schematables.Substitution{Name: exportQName(entry.name), Member: uint32(entry.member), Effective: entry.effective}
+	})
+	out.GlobalAttributes = exportNameIDs(b.GlobalAttributes)
+	out.GlobalElements = exportNameIDs(b.GlobalElements)
+	if b.GlobalTypes != nil {
+		out.GlobalTypes = make([]schematables.NameType, 0, len(b.GlobalTypes))
+		for _, q := range slices.SortedFunc(maps.Keys(b.GlobalTypes), compareQName) {
+			out.GlobalTypes = append(out.GlobalTypes, schematables.NameType{Name: exportQName(q), Type: exportTypeID(b.GlobalTypes[q])})
+		}
+	}
+	out.GlobalIdentities = exportNameIDs(b.GlobalIdentities)
+	out.AttributeGroups = exportNameIDs(b.AttributeGroups)
+	if b.Notations != nil {
+		out.Notations = make([]schematables.Notation, 0, len(b.Notations))
+		for _, q := range slices.SortedFunc(maps.Keys(b.Notations), compareQName) {
+			out.Notations = append(out.Notations, schematables.Notation{Name: exportQName(q), Value: b.Notations[q]})
+		}
+	}
+	if b.Annotations != nil {
+		out.Annotations = make([]schematables.Annotation, 0, len(b.Annotations))
+		for _, key := range slices.SortedFunc(maps.Keys(b.Annotations), compareAnnotationKey) {
+			a := b.Annotations[key]
+			out.Annotations = append(out.Annotations, schematables.Annotation{
+				Key: exportAnnotationKey(key),
+				Documentation: convertSlice(a.Documentation, func(doc Documentation) schematables.Documentation {
+					return schematables.Documentation{Source: doc.Source, Lang: doc.Lang, Text: doc.Text, Content: doc.Content}
+				}),
+				AppInfo: convertSlice(a.AppInfo, func(info AppInfo) schematables.AppInfo {
+					return schematables.AppInfo{Source: info.Source, Content: info.Content}
+				}),
+			})
+		}
+	}
+	if b.Locations != nil {
+		out.Locations = make([]schematables.Location, 0, len(b.Locations))
+		for _, key := range slices.SortedFunc(maps.Keys(b.Locations), compareLocationKey) {
+			loc := b.Locations[key]
+			out.Locations = append(out.Locations, schematables.Location{
+				Key:       exportAnnotationKey(key.AnnotationKey),
+				Facet:     uint16(key.Facet),
+				Source:    loc.Source,
+				Component: loc.Component,
+				Line:      loc.Line,
+				Column:    loc.Column,
+			})
+		}
+	}
+}
+
+func (e *tablesExporter) simpleType(st SimpleType) schematables.SimpleType {
+	return schematables.SimpleType{
+		Union:        convertSlice(st.Union, exportUint32[SimpleTypeID]),
+		UnionSources: convertSlice(st.UnionSources, exportUint32[SimpleTypeID]),
+		Facets:       e.facetSet(&st.Facets),
+		Name:         exportQName(st.Name),
+		Base:         uint32(st.Base),
+		ListItem:     uint32(st.ListItem),
+		Variety:      uint8(st.Variety),
+		Primitive:    uint8(st.Primitive),
+		Final:        uint8(st.Final),
+		Whitespace:   uint8(st.Whitespace),
+		Builtin:      uint8(st.Builtin),
+		Identity:     uint8(st.Identity),
+		Fast:         uint8(st.Fast),
+		Assertions:   convertSlice(st.Assertions, e.assertion),
+		Missing:      st.Missing,
+		Scope:        uint8(st.Scope),
+	}
+}
+
+func (e *tablesExporter) facetSet(f *FacetSet) schematables.Facets {
+	var out schematables.Facets
+	for i, literal := range f.bounds {
+		out.Bounds[i] = e.literalRef(literal)
+	}
+	out.Patterns = e.patternStep(f.patterns.tail)
+	out.Enumeration = e.enumeration(f.Enumeration)
+	out.Length = f.Length
+	out.MinLength = f.MinLength
+	out.MaxLength = f.MaxLength
+	out.TotalDigits = f.TotalDigits
+	out.FractionDigits = f.FractionDigits
+	out.Present = uint16(f.Present)
+	out.Fixed = uint16(f.Fixed)
+	out.ExplicitTimezone = uint8(f.ExplicitTimezone)
+	return out
+}
+
+func (e *tablesExporter) literalRef(literal *CompiledLiteral) uint32 {
+	if literal == nil {
+		return 0
+	}
+	if n, ok := e.literals[literal]; ok {
+		return n
+	}
+	e.out.Literals = append(e.out.Literals, exportLiteral(*literal))
+	n := uint32(len(e.out.Literals)) //nolint:gosec // bounded by the publication ID-domain audit.
+	e.literals[literal] = n
+	return n
+}
+
+func (e *tablesExporter) enumeration(literals []CompiledLiteral) uint32 {
+	source, ok := simpleValueEnumerationSourceForLiterals(literals)
+	if !ok {
+		return 0
+	}
+	if n, ok := e.enumerations[source]; ok {
+		return n
+	}
+	e.out.Enumerations = append(e.out.Enumerations, convertSlice(literals, exportLiteral))
+	n := uint32(len(e.out.Enumerations)) //nolint:gosec // bounded by the publication ID-domain audit.
+	e.enumerations[source] = n
+	return n
+}
+
+// patternStep exports the parent of step first, so every step follows the
+// step it refers to.
+func (e *tablesExporter) patternStep(step *stringPatternStep) uint32 {
+	if step == nil {
+		return 0
+	}
+	if n, ok := e.patterns[step]; ok {
+		return n
+	}
+	parent := e.patternStep(step.parent)
+	e.out.PatternSteps = append(e.out.PatternSteps, schematables.PatternStep{
+		Parent:   parent,
+		Count:    step.count,
+		Patterns: convertSlice(step.patterns, StringPattern.Source),
+	})
+	n := uint32(len(e.out.PatternSteps)) //nolint:gosec // bounded by the publication ID-domain audit.
+	e.patterns[step] = n
+	return n
+}
+
+func (e *tablesExporter) expr(x *xpath.Expr) uint32 {
+	if x == nil {
+		return 0
+	}
+	if n, ok := e.exprs[x]; ok {
+		return n
+	}
+	namespaces := x.Namespaces()
+	bindings := make([]schematables.NamespaceBinding, 0, len(namespaces))
+	for _, prefix := range slices.Sorted(maps.Keys(namespaces)) {
+		bindings = append(bindings, schematables.NamespaceBinding{Prefix: prefix, URI: namespaces[prefix]})
+	}
+	e.out.Exprs = append(e.out.Exprs, schematables.Expr{
+		Source:                  x.String(),
+		DefaultElementNamespace: x.DefaultElementNamespace(),
+		Namespaces:              bindings,
+	})
+	n := uint32(len(e.out.Exprs)) //nolint:gosec // bounded by the publication ID-domain audit.
+	e.exprs[x] = n
+	return n
+}
+
+func (e *tablesExporter) assertion(a Assertion) uint32 { return e.expr(a.Test) }
+
+func exportLiteral(literal CompiledLiteral) schematables.Literal {
+	return schematables.Literal{
+		Lexical:       literal.Lexical,
+		Canonical:     literal.Canonical,
+		ResolvedNames: convertSlice(literal.ResolvedNames, exportResolvedName),
+		Type:          uint32(literal.Type),
+	}
+}
+
+func exportResolvedName(name ResolvedValueName) schematables.ResolvedName {
+	return schematables.ResolvedName{Lexical: name.Lexical, Namespace: name.NS, Local: name.Local}
+}
+
+func exportValueConstraint(vc *ValueConstraint) *schematables.ValueConstraint {
+	if vc == nil {
+		return nil
+	}
+	return &schematables.ValueConstraint{
+		ResolvedNames: convertSlice(vc.ResolvedNames, exportResolvedName),
+		Lexical:       vc.Lexical,
+		Canonical:     vc.Canonical,
+		Value: schematables.SimpleValue{
+			Canonical: vc.Value.Canonical,
+			IDs:       vc.Value.IDs,
+			IDRefs:    vc.Value.IDRefs,
+			Identity:  vc.Value.Identity,
+			Type:      uint32(vc.Value.Type),
+		},
+	}
+}
+
+func (e *tablesExporter) complexType(ct ComplexType) schematables.ComplexType {
+	return schematables.ComplexType{
+		Name:               exportQName(ct.Name),
+		Base:               exportTypeID(ct.Base),
+		Content:            uint32(ct.Content),
+		Attrs:              uint32(ct.Attrs),
+		TextType:           uint32(ct.TextType),
+		ContentKind:        uint8(ct.ContentKind),
+		Abstract:           ct.Abstract,
+		Derivation:         uint8(ct.Derivation),
+		ExplicitDerivation: ct.ExplicitDerivation,
+		Block:              uint8(ct.Block),
+		Final:              uint8(ct.Final),
+		Scope:              uint8(ct.Scope),
+		Assertions:         convertSlice(ct.Assertions, e.assertion),
+	}
+}
+
+func (e *tablesExporter) elementDecl(decl ElementDecl) schematables.Element {
+	return schematables.Element{
+		Default:   exportValueConstraint(decl.Default),
+		Fixed:     exportValueConstraint(decl.Fixed),
+		Identity:  convertSlice(decl.Identity, exportUint32[IdentityConstraintID]),
+		Type:      exportTypeID(decl.Type),
+		Name:      exportQName(decl.Name),
+		SubstHead: uint32(decl.SubstHead),
+		Nillable:  decl.Nillable,
+		Abstract:  decl.Abstract,
+		Block:     uint8(decl.Block),
+		Final:     uint8(decl.Final),
+		Scope:     uint8(decl.Scope),
+		Alternatives: convertSlice(decl.Alternatives, func(alt TypeAlternative) schematables.TypeAlternative {
+			return schematables.TypeAlternative{Test: e.expr(alt.Test), Type: exportTypeID(alt.Type)}
+		}),
+	}
+}
+
+func exportAttributeUseSet(set AttributeUseSet) schematables.AttributeUseSet {
+	return schematables.AttributeUseSet{
+		Index: exportNameIDs(set.Index),
+		Uses: convertSlice(set.Uses, func(use AttributeUse) schematables.AttributeUse {
+			return schematables.AttributeUse{
+				Default:              exportValueConstraint(use.Default),
+				Fixed:                exportValueConstraint(use.Fixed),
+				Name:                 exportQName(use.Name),
+				Type:                 uint32(use.Type),
+				Required:             use.Required,
+				Prohibited:           use.Prohibited,
+				FixedFromDeclaration: use.FixedFromDeclaration,
+			}
+		}),
+		Required:         slices.Clone(set.Required),
+		ValueConstraints: slices.Clone(set.ValueConstraints),
+		Wildcard:         uint32(set.Wildcard),
+		WildcardBase:     uint32(set.WildcardBase),
+		WildcardDeclared: uint32(set.WildcardDeclared),
+		WildcardDerive:   uint8(set.WildcardDerive),
+	}
+}
+
+func exportOccurrence(o Occurrence) schematables.Occurrence {
+	return schematables.Occurrence{Min: o.Min, Max: o.Max, Unbounded: o.Unbounded}
+}
+
+func exportParticle(p Particle) schematables.Particle {
+	return schematables.Particle{
+		Kind:     uint8(p.Kind),
+		Occurs:   exportOccurrence(p.Occurs),
+		Element:  uint32(p.Element),
+		Model:    uint32(p.Model),
+		Wildcard: uint32(p.Wildcard),
+	}
+}
+
+func exportOpenContent(open OpenContent) schematables.OpenContent {
+	return schematables.OpenContent{Wildcard: uint32(open.Wildcard), Mode: uint8(open.Mode)}
+}
+
+func exportCompiledModel(m CompiledModel) schematables.CompiledModel {
+	return schematables.CompiledModel{
+		Rows: convertSlice(m.Rows, func(row CompiledModelRow) schematables.CompiledModelRow {
+			return schematables.CompiledModelRow{
+				Edges: convertSlice(row.Edges, func(edge CompiledModelEdge) schematables.Edge {
+					return schematables.Edge{Particle: exportParticle(edge.Particle), To: edge.To}
+				}),
+				NameToEdge:    exportNameIDs(row.Index.NameToEdge),
+				WildcardEdges: slices.Clone(row.Index.WildcardEdges),
+				Indexed:       row.Index.Enabled,
+				CountParticle: exportParticle(row.CountParticle),
+				Min:           row.Min,
+				Max:           row.Max,
+				Accept:        row.Accept,
+				Counted:       row.Counted,
+				Unbounded:     row.Unbounded,
+			}
+		}),
+		All: convertSlice(m.All, func(term CompiledAllTerm) schematables.AllTerm {
+			return schematables.AllTerm{Particle: exportParticle(term.Particle), Required: term.Required}
+		}),
+		Source:    uint32(m.Source),
+		Start:     m.Start,
+		AllBitLen: m.AllBitLen,
+		Kind:      uint8(m.Kind),
+		Mixed:     m.Mixed,
+		Empty:     m.Empty,
+		Open:      exportOpenContent(m.Open),
+	}
+}
+
+func exportIdentityStep(step IdentityStep) schematables.IdentityStep {
+	return schematables.IdentityStep{
+		Name:         exportQName(step.Name),
+		Wildcard:     step.Wildcard,
+		NamespaceSet: step.NamespaceSet,
+		Namespace:    uint32(step.Namespace),
+	}
+}
+
+func exportIdentity(ic IdentityConstraint) schematables.IdentityConstraint {
+	return schematables.IdentityConstraint{
+		Selector: convertSlice(ic.Selector, func(path IdentityPath) schematables.IdentityPath {
+			return schematables.IdentityPath{
+				Steps:      convertSlice(path.Steps, exportIdentityStep),
+				Descendant: path.Descendant,
+				Self:       path.Self,
+			}
+		}),
+		Fields: convertSlice(ic.Fields, func(field IdentityField) schematables.IdentityField {
+			return schematables.IdentityField{
+				Paths: convertSlice(field.Paths, func(path IdentityFieldPath) schematables.IdentityFieldPath {
+					return schematables.IdentityFieldPath{
+						Steps:            convertSlice(path.Steps, exportIdentityStep),
+						Attribute:        exportQName(path.Attribute),
+						AttrNamespace:    uint32(path.AttrNamespace),
+						Descendant:       path.Descendant,
+						Self:             path.Self,
+						Attr:             path.Attr,
+						AttrWildcard:     path.AttrWildcard,
+						AttrNamespaceSet: path.AttrNamespaceSet,
+					}
+				}),
+				XPath: field.XPath,
+			}
+		}),
+		SelectorXPath: ic.SelectorXPath,
+		Name:          exportQName(ic.Name),
+		Refer:         uint32(ic.Refer),
+		Kind:          uint8(ic.Kind),
+	}
+}
+
+// tablesImporter rebuilds schema tables from plain values. The first
+// malformed value records err; later values are still converted, and the
+// caller discards the result.
+type tablesImporter struct {
+	in           *schematables.Schema
+	literals     []*CompiledLiteral
+	enumerations [][]CompiledLiteral
+	patterns     []*stringPatternStep
+	exprs        []*xpath.Expr
+	err          string
+}
+
+func (im *tablesImporter) fail(msg string) {
+	if im.err == "" {
+		im.err = msg
+	}
+}
+
+func importUint32[T ~uint32](v uint32) T { return T(v) }
+
+func importQName(q schematables.QName) QName {
+	return QName{Namespace: NamespaceID(q.Namespace), Local: LocalNameID(q.Local)}
+}
+
+func importAnnotationKey(key schematables.AnnotationKey) AnnotationKey {
+	return AnnotationKey{Name: importQName(key.Name), ID: key.ID, Kind: AnnotatedKind(key.Kind)}
+}
+
+func importNameIDs[T ~uint32](im *tablesImporter, entries []schematables.NameID) map[QName]T {
+	if entries == nil {
+		return nil
+	}
+	out := make(map[QName]T, len(entries))
+	for _, entry := range entries {
+		q := importQName(entry.Name)
+		if _, ok := out[q]; ok {
+			im.fail("duplicate name")
+		}
+		out[q] = T(entry.ID)
+	}
+	return out
+}
+
+// ref resolves a one-based reference into pool, which holds n values.
+func ref[T any](im *tablesImporter, pool []T, n uint32) (T, bool) {
+	var zero T
+	if n == 0 {
+		return zero, false
+	}
+	if uint64(n) > uint64(len(pool)) {
+		im.fail("invalid shared value reference")
+		return zero, false
+	}
+	return pool[n-1], true
+}
+
+func (im *tablesImporter) typeID(t schematables.TypeRef) TypeID {
+	id := TypeID{kind: typeKind(t.Kind), id: t.ID}
+	if id.kind != typeNone && id.kind != typeSimple && id.kind != typeComplex {
+		im.fail("invalid type reference")
+	}
+	return id
+}
+
+func (im *tablesImporter) schemaBuild() SchemaBuild {
+	in := im.in
+	var b SchemaBuild
+	b.Names = NameTable{
+		nsIndex:    make(map[string]NamespaceID, len(in.Namespaces)),
+		localIndex: make(map[string]LocalNameID, len(in.Locals)),
+		namespaces: slices.Clone(in.Namespaces),
+		locals:     slices.Clone(in.Locals),
+		maxNames:   in.MaxNames,
+	}
+	for i, uri := range b.Names.namespaces {
+		b.Names.nsIndex[uri] = NamespaceID(i) //nolint:gosec // bounded by the publication ID-domain audit.
+	}
+	for i, local := range b.Names.locals {
+		b.Names.localIndex[local] = LocalNameID(i) //nolint:gosec // bounded by the publication ID-domain audit.
+	}
+	ids := in.Builtin
+	b.Builtin = BuiltinIDs{
+		AnyType: ComplexTypeID(ids.AnyType), AnySimpleType: SimpleTypeID(ids.AnySimpleType), AnyAtomicType: SimpleTypeID(ids.AnyAtomicType),
+		String: SimpleTypeID(ids.String), Boolean: SimpleTypeID(ids.Boolean), Decimal: SimpleTypeID(ids.Decimal),
+		Integer: SimpleTypeID(ids.Integer), Int: SimpleTypeID(ids.Int), Date: SimpleTypeID(ids.Date),
+		DateTime: SimpleTypeID(ids.DateTime), Time: SimpleTypeID(ids.Time), AnyURI: SimpleTypeID(ids.AnyURI),
+		QName: SimpleTypeID(ids.QName), ID: SimpleTypeID(ids.ID), IDREF: SimpleTypeID(ids.IDREF),
+		IDREFS: SimpleTypeID(ids.IDREFS), NMTOKEN: SimpleTypeID(ids.NMTOKEN), NMTOKENS: SimpleTypeID(ids.NMTOKENS),
+		ENTITY: SimpleTypeID(ids.ENTITY), ENTITIES: SimpleTypeID(ids.ENTITIES), Error: SimpleTypeID(ids.Error),
+	}
+	im.pools()
+	b.SimpleTypes = convertSlice(in.SimpleTypes, im.simpleType)
+	replayLiteralActuals(b.SimpleTypes, im.literals, im.enumerations)
+	b.ComplexTypes = convertSlice(in.ComplexTypes, im.complexType)
+	b.Elements = convertSlice(in.Elements, im.elementDecl)
+	b.Attributes = convertSlice(in.Attributes, func(decl schematables.Attribute) AttributeDecl {
+		return AttributeDecl{
+			Default: importValueConstraint(decl.Default),
+			Fixed:   importValueConstraint(decl.Fixed),
+			Name:    importQName(decl.Name),
+			Type:    SimpleTypeID(decl.Type),
+		}
+	})
+	b.AttributeUseSets = convertSlice(in.AttributeUseSets, im.attributeUseSet)
+	b.Models = convertSlice(in.Models, func(m schematables.ContentModel) ContentModel {
+		return ContentModel{
+			Particles:    convertSlice(m.Particles, importParticle),
+			ChoiceLimits: slices.Clone(m.ChoiceLimits),
+			Occurs:       importOccurrence(m.Occurs),
+			Kind:         ModelKind(m.Kind),
+			Mixed:        m.Mixed,
+			Open:         importOpenContent(m.Open),
+		}
+	})
+	b.CompiledModels = convertSlice(in.CompiledModels, im.compiledModel)
+	b.Wildcards = convertSlice(in.Wildcards, func(w schematables.Wildcard) Wildcard {
+		return Wildcard{
+			Namespaces:        convertSlice(w.Namespaces, importUint32[NamespaceID]),
+			NotQNames:         convertSlice(w.NotQNames, importQName),
+			OtherThan:         NamespaceID(w.OtherThan),
+			Mode:              WildcardMode(w.Mode),
+			Process:           ProcessContents(w.Process),
+			NotDefined:        w.NotDefined,
+			NotDefinedSibling: w.NotDefinedSibling,
+		}
+	})
+	b.Identities = convertSlice(in.Identities, importIdentity)
+	b.Substitutions.spans = convertSlice(in.SubstitutionSpans, func(span schematables.SubstitutionSpan) substitutionSpan {
+		if span.Start < 0 || span.Count < 0 {
+			im.fail("invalid substitution span")
+		}
+		return substitutionSpan{start: span.Start, count: span.Count}
+	})
+	b.Substitutions.entries = convertSlice(in.Substitutions, func(entry schematables.Substitution) substitutionEntry {
+		return substitutionEntry{name: importQName(entry.Name), member: ElementID(entry.Member), effective: entry.Effective}
+	})
+	b.GlobalAttributes = importNameIDs[AttributeID](im, in.GlobalAttributes)
+	b.GlobalElements = importNameIDs[ElementID](im, in.GlobalElements)
+	if in.GlobalTypes != nil {
+		b.GlobalTypes = make(map[QName]TypeID, len(in.GlobalTypes))
+		for _, entry := range in.GlobalTypes {
+			q := importQName(entry.Name)
+			if _, ok := b.GlobalTypes[q]; ok {
+				im.fail("duplicate name")
+			}
+			b.GlobalTypes[q] = im.typeID(entry.Type)
+		}
+	}
+	b.GlobalIdentities = importNameIDs[IdentityConstraintID](im, in.GlobalIdentities)
+	b.AttributeGroups = importNameIDs[AttributeUseSetID](im, in.AttributeGroups)
+	if in.Notations != nil {
+		b.Notations = make(map[QName]bool, len(in.Notations))
+		for _, entry := range in.Notations {
+			q := importQName(entry.Name)
+			if _, ok := b.Notations[q]; ok {
+				im.fail("duplicate name")
+			}
+			b.Notations[q] = entry.Value
+		}
+	}
+	if in.Annotations != nil {
+		b.Annotations = make(map[AnnotationKey]Annotation, len(in.Annotations))
+		for _, a := range in.Annotations {
+			key := importAnnotationKey(a.Key)
+			if _, ok := b.Annotations[key]; ok {
+				im.fail("duplicate annotation")
+			}
+			b.Annotations[key] = Annotation{
+				Documentation: convertSlice(a.Documentation, func(doc schematables.Documentation) Documentation {
+					return Documentation{Source: doc.Source, Lang: doc.Lang, Text: doc.Text, Content: doc.Content}
+				}),
+				AppInfo: convertSlice(a.AppInfo, func(info schematables.AppInfo) AppInfo {
+					return AppInfo{Source: info.Source, Content: info.Content}
+				}),
+			}
+		}
+	}
+	if in.Locations != nil {
+		b.Locations = make(map[LocationKey]SourceLocation, len(in.Locations))
+		for _, loc := range in.Locations {
+			key := LocationKey{AnnotationKey: importAnnotationKey(loc.Key), Facet: FacetMask(loc.Facet)}
+			if _, ok := b.Locations[key]; ok {
+				im.fail("duplicate source location")
+			}
+			b.Locations[key] = SourceLocation{Source: loc.Source, Component: loc.Component, Line: loc.Line, Column: loc.Column}
+		}
+	}
+	return b
+}
+
+// pools rebuilds the shared values. A pattern step may refer only to an
+// earlier step, so the steps form chains rather than cycles.
+func (im *tablesImporter) pools() {
+	in := im.in
+	im.literals = make([]*CompiledLiteral, len(in.Literals))
+	for i := range in.Literals {
+		literal := importLiteral(in.Literals[i])
+		im.literals[i] = &literal
+	}
+	im.enumerations = make([][]CompiledLiteral, len(in.Enumerations))
+	for i, literals := range in.Enumerations {
+		if len(literals) == 0 {
+			im.fail("empty enumeration")
+		}
+		im.enumerations[i] = convertSlice(literals, importLiteral)
+	}
+	im.patterns = make([]*stringPatternStep, len(in.PatternSteps))
+	for i, step := range in.PatternSteps {
+		if uint64(step.Parent) > uint64(i) {
+			im.fail("invalid shared value reference")
+			continue
+		}
+		parent, _ := ref(im, im.patterns[:i], step.Parent)
+		im.patterns[i] = &stringPatternStep{
+			parent:   parent,
+			count:    step.Count,
+			patterns: convertSlice(step.Patterns, im.stringPattern),
+		}
+	}
+	im.exprs = make([]*xpath.Expr, len(in.Exprs))
+	for i, expr := range in.Exprs {
+		var namespaces map[string]string
+		if len(expr.Namespaces) != 0 {
+			namespaces = make(map[string]string, len(expr.Namespaces))
+			for _, binding := range expr.Namespaces {
+				namespaces[binding.Prefix] = binding.URI
+			}
+		}
+		x, err := recompileExpr(expr.Source, expr.DefaultElementNamespace, namespaces)
+		if err != nil {
+			im.fail(err.Error())
+		}
+		im.exprs[i] = x
+	}
+}
+
+func (im *tablesImporter) stringPattern(source string) StringPattern {
+	p, err := recompileStringPattern(source)
+	if err != nil {
+		im.fail(err.Error())
+	}
+	return p
+}
+
+func (im *tablesImporter) expr(n uint32) *xpath.Expr {
+	x, _ := ref(im, im.exprs, n)
+	return x
+}
+
+func (im *tablesImporter) assertion(n uint32) Assertion { return Assertion{Test: im.expr(n)} }
+
+func importLiteral(literal schematables.Literal) CompiledLiteral {
+	return CompiledLiteral{
+		Lexical:       literal.Lexical,
+		Canonical:     literal.Canonical,
+		ResolvedNames: convertSlice(literal.ResolvedNames, importResolvedName),
+		Type:          SimpleTypeID(literal.Type),
+	}
+}
+
+func importResolvedName(name schematables.ResolvedName) ResolvedValueName {
+	return ResolvedValueName{Lexical: name.Lexical, NS: name.Namespace, Local: name.Local}
+}
+
+func importValueConstraint(vc *schematables.ValueConstraint) *ValueConstraint {
+	if vc == nil {
+		return nil
+	}
+	return &ValueConstraint{
+		ResolvedNames: convertSlice(vc.ResolvedNames, importResolvedName),
+		Lexical:       vc.Lexical,
+		Canonical:     vc.Canonical,
+		Value: SimpleValue{
+			Canonical: vc.Value.Canonical,
+			IDs:       vc.Value.IDs,
+			IDRefs:    vc.Value.IDRefs,
+			Identity:  vc.Value.Identity,
+			Type:      SimpleTypeID(vc.Value.Type),
+		},
+	}
+}
+
+func (im *tablesImporter) simpleType(st schematables.SimpleType) SimpleType {
+	return SimpleType{
+		Union:        convertSlice(st.Union, importUint32[SimpleTypeID]),
+		UnionSources: convertSlice(st.UnionSources, importUint32[SimpleTypeID]),
+		Facets:       im.facetSet(st.Facets),
+		Name:         importQName(st.Name),
+		Base:         SimpleTypeID(st.Base),
+		ListItem:     SimpleTypeID(st.ListItem),
+		Variety:      SimpleVariety(st.Variety),
+		Primitive:    PrimitiveKind(st.Primitive),
+		Final:        DerivationMask(st.Final),
+		Whitespace:   WhitespaceMode(st.Whitespace),
+		Builtin:      BuiltinValidationKind(st.Builtin),
+		Identity:     SimpleIdentityKind(st.Identity),
+		Fast:         SimpleFastKind(st.Fast),
+		Assertions:   convertSlice(st.Assertions, im.assertion),
+		Missing:      st.Missing,
+		Scope:        DeclarationScope(st.Scope),
+	}
+}
+
+func (im *tablesImporter) facetSet(f schematables.Facets) FacetSet {
+	var out FacetSet
+	for i, n := range f.Bounds {
+		out.bounds[i], _ = ref(im, im.literals, n)
+	}
+	out.patterns.tail, _ = ref(im, im.patterns, f.Patterns)
+	out.Enumeration, _ = ref(im, im.enumerations, f.Enumeration)
+	out.Length = f.Length
+	out.MinLength = f.MinLength
+	out.MaxLength = f.MaxLength
+	out.TotalDigits = f.TotalDigits
+	out.FractionDigits = f.FractionDigits
+	out.Present = FacetMask(f.Present)
+	out.Fixed = FacetMask(f.Fixed)
+	out.ExplicitTimezone = ExplicitTimezone(f.ExplicitTimezone)
+	return out
+}
+
+func (im *tablesImporter) complexType(ct schematables.ComplexType) ComplexType {
+	return ComplexType{
+		Name:               importQName(ct.Name),
+		Base:               im.typeID(ct.Base),
+		Content:            ContentModelID(ct.Content),
+		Attrs:              AttributeUseSetID(ct.Attrs),
+		TextType:           SimpleTypeID(ct.TextType),
+		ContentKind:        ContentKind(ct.ContentKind),
+		Abstract:           ct.Abstract,
+		Derivation:         DerivationKind(ct.Derivation),
+		ExplicitDerivation: ct.ExplicitDerivation,
+		Block:              DerivationMask(ct.Block),
+		Final:              DerivationMask(ct.Final),
+		Scope:              DeclarationScope(ct.Scope),
+		Assertions:         convertSlice(ct.Assertions, im.assertion),
+	}
+}
+
+func (im *tablesImporter) elementDecl(decl schematables.Element) ElementDecl {
+	return ElementDecl{
+		Default:   importValueConstraint(decl.Default),
+		Fixed:     importValueConstraint(decl.Fixed),
+		Identity:  convertSlice(decl.Identity, importUint32[IdentityConstraintID]),
+		Type:      im.typeID(decl.Type),
+		Name:      importQName(decl.Name),
+		SubstHead: ElementID(decl.SubstHead),
+		Nillable:  decl.Nillable,
+		Abstract:  decl.Abstract,
+		Block:     DerivationMask(decl.Block),
+		Final:     DerivationMask(decl.Final),
+		Scope:     DeclarationScope(decl.Scope),
+		Alternatives: convertSlice(decl.Alternatives, func(alt schematables.TypeAlternative) TypeAlternative {
+			return TypeAlternative{Test: im.expr(alt.Test), Type: im.typeID(alt.Type)}
+		}),
+	}
+}
+
+func (im *tablesImporter) attributeUseSet(set schematables.AttributeUseSet) AttributeUseSet {
+	return AttributeUseSet{
+		Index: importNameIDs[uint32](im, set.Index),
+		Uses: convertSlice(set.Uses, func(use schematables.AttributeUse) AttributeUse {
+			return AttributeUse{
+				Default:              importValueConstraint(use.Default),
+				Fixed:                importValueConstraint(use.Fixed),
+				Name:                 importQName(use.Name),
+				Type:                 SimpleTypeID(use.Type),
+				Required:             use.Required,
+				Prohibited:           use.Prohibited,
+				FixedFromDeclaration: use.FixedFromDeclaration,
+			}
+		}),
+		Required:         slices.Clone(set.Required),
+		ValueConstraints: slices.Clone(set.ValueConstraints),
+		Wildcard:         WildcardID(set.Wildcard),
+		WildcardBase:     WildcardID(set.WildcardBase),
+		WildcardDeclared: WildcardID(set.WildcardDeclared),
+		WildcardDerive:   AttributeWildcardDerivation(set.WildcardDerive),
+	}
+}
+
+func importOccurrence(o schematables.Occurrence) Occurrence {
+	return Occurrence{Min: o.Min, Max: o.Max, Unbounded: o.Unbounded}
+}
+
+func importParticle(p schematables.Particle) Particle {
+	return Particle{
+		Kind:     ParticleKind(p.Kind),
+		Occurs:   importOccurrence(p.Occurs),
+		Element:  ElementID(p.Element),
+		Model:    ContentModelID(p.Model),
+		Wildcard: WildcardID(p.Wildcard),
+	}
+}
+
+func importOpenContent(open schematables.OpenContent) OpenContent {
+	return OpenContent{Wildcard: WildcardID(open.Wildcard), Mode: OpenContentMode(open.Mode)}
+}
+
+func (im *tablesImporter) compiledModel(m schematables.CompiledModel) CompiledModel {
+	return CompiledModel{
+		Rows: convertSlice(m.Rows, func(row schematables.CompiledModelRow) CompiledModelRow {
+			return CompiledModelRow{
+				Edges: convertSlice(row.Edges, func(edge schematables.Edge) CompiledModelEdge {
+					return CompiledModelEdge{Particle: importParticle(edge.Particle), To: edge.To}
+				}),
+				Index: DFARowIndex{
+					NameToEdge:    importNameIDs[uint32](im, row.NameToEdge),
+					WildcardEdges: slices.Clone(row.WildcardEdges),
+					Enabled:       row.Indexed,
+				},
+				CountParticle: importParticle(row.CountParticle),
+				Min:           row.Min,
+				Max:           row.Max,
+				Accept:        row.Accept,
+				Counted:       row.Counted,
+				Unbounded:     row.Unbounded,
+			}
+		}),
+		All: convertSlice(m.All, func(term schematables.AllTerm) CompiledAllTerm {
+			return CompiledAllTerm{Particle: importParticle(term.Particle), Required: term.Required}
+		}),
+		Source:    ContentModelID(m.Source),
+		Start:     m.Start,
+		AllBitLen: m.AllBitLen,
+		Kind:      CompiledModelKind(m.Kind),
+		Mixed:     m.Mixed,
+		Empty:     m.Empty,
+		Open:      importOpenContent(m.Open),
+	}
+}
+
+func importIdentityStep(step schematables.IdentityStep) IdentityStep {
+	return IdentityStep{
+		Name:         importQName(step.Name),
+		Wildcard:     step.Wildcard,
+		NamespaceSet: step.NamespaceSet,
+		Namespace:    NamespaceID(step.Namespace),
+	}
+}
+
+// importIdentity rebuilds the field lookup tables from the selector and
+// fields, as snapshot decoding does.
+func importIdentity(ic schematables.IdentityConstraint) IdentityConstraint {
+	out := IdentityConstraint{
+		Selector: convertSlice(ic.Selector, func(path schematables.IdentityPath) IdentityPath {
+			return IdentityPath{
+				Steps:      convertSlice(path.Steps, importIdentityStep),
+				Descendant: path.Descendant,
+				Self:       path.Self,
+			}
+		}),
+		Fields: convertSlice(ic.Fields, func(field schematables.IdentityField) IdentityField {
+			return IdentityField{
+				Paths: convertSlice(field.Paths, func(path schematables.IdentityFieldPath) IdentityFieldPath {
+					return IdentityFieldPath{
+						Steps:            convertSlice(path.Steps, importIdentityStep),
+						Attribute:        importQName(path.Attribute),
+						AttrNamespace:    NamespaceID(path.AttrNamespace),
+						Descendant:       path.Descendant,
+						Self:             path.Self,
+						Attr:             path.Attr,
+						AttrWildcard:     path.AttrWildcard,
+						AttrNamespaceSet: path.AttrNamespaceSet,
+					}
+				}),
+				XPath: field.XPath,
+			}
+		}),
+		SelectorXPath: ic.SelectorXPath,
+		Name:          importQName(ic.Name),
+		Refer:         IdentityConstraintID(ic.Refer),
+		Kind:          IdentityKind(ic.Kind),
+	}
+	out.ElementFields, out.AttributeFields, out.AttributeWildcardFields = BuildIdentityFieldLookup(out.Fields)
+	return out
+}
@@ -0,0 +1,54 @@
+package runtime
+
+import (
+	"errors"
+
+	"github.com/jacoelho/xsd/internal/xpath"
+)
+
+// TypeAlternative is one compiled XSD 1.1 xs:alternative of an element
+// declaration. A nil Test marks the default alternative, which always applies.
+type TypeAlternative struct {
+	Test *xpath.Expr
+	Type TypeID
+}
+
+// ElementTypeAlternatives reports whether element declaration id selects
+// its type with XSD 1.1 type alternatives.
+func (rt *Schema) ElementTypeAlternatives(id ElementID) bool {
+	return len(rt.runtime.Elements.typeAlternatives(id)) != 0
+}
+
+// SelectTypeAlternative returns the type selected for element declaration id
+// by its type alternatives, evaluated against start: the element with its
+// untyped attributes and no children. The first alternative whose test holds
+// wins; a test that raises a dynamic error does not hold. When no alternative
+// applies the declared type is returned. ok is false for an invalid id.
+func (rt *Schema) SelectTypeAlternative(id ElementID, start *xpath.Node, maxSteps int) (TypeID, bool) {
+	info, ok := rt.runtime.Elements.start(id)
+	if !ok {
+		return TypeID{}, false
+	}
+	host := assertionHost{runtime: &rt.runtime}
+	for _, alt := range rt.runtime.Elements.typeAlternatives(id) {
+		if alt.Test == nil {
+			return alt.Type, true
+		}
+		if holds, err := alt.Test.Test(xpath.Context{Node: start, Host: host, MaxSteps: maxSteps}); err == nil && holds {
+			return alt.Type, true
+		}
+	}
+	return info.Type, true
+}
+
+func validateTypeAlternatives(alternatives []TypeAlternative, simpleCount, complexCount int) error {
+	for i, alt := range alternatives {
+		if !validTypeID(alt.Type, simpleCount, complexCount) {
+			return errors.New("element type alternative references invalid type")
+		}
+		if alt.Test == nil && i != len(alternatives)-1 {
+			return errors.New("element default type alternative is not last")
+		}
+	}
+	return nil
+}
@@ -53,6 +53,7 @@ type ComplexTypeID uint32
 type BuiltinIDs struct {
 	AnyType       ComplexTypeID
 	AnySimpleType SimpleTypeID
+	AnyAtomicType SimpleTypeID
 	String        SimpleTypeID
 	Boolean       SimpleTypeID
 	Decimal       SimpleTypeID
@@ -70,6 +71,7 @@ type BuiltinIDs struct {
 	NMTOKENS      SimpleTypeID
 	ENTITY        SimpleTypeID
 	ENTITIES      SimpleTypeID
+	Error         SimpleTypeID
 }
 
 // ElementID indexes an element declaration in a runtime schema.
@@ -481,9 +483,11 @@ type IdentityConstraint struct {
 	ElementFields           []CompiledIdentityField
 	AttributeFields         map[QName][]CompiledIdentityField
 	AttributeWildcardFields []CompiledIdentityField
-	Name                    QName
-	Refer                   IdentityConstraintID
-	Kind                    IdentityKind
+	// SelectorXPath is the selector's xpath attribute as written.
+	SelectorXPath string
+	Name          QName
+	Refer         IdentityConstraintID
+	Kind          IdentityKind
 }
 
 // CompiledIdentityField groups paths for one field after lookup compilation.
@@ -510,6 +514,8 @@ type IdentityStep struct {
 // IdentityField is one identity field with all parsed XPath alternatives.
 type IdentityField struct {
 	Paths []IdentityFieldPath
+	// XPath is the field's xpath attribute as written.
+	XPath string
 }
 
 // IdentityFieldPath is one parsed field XPath branch.
@@ -19,6 +19,9 @@ const (
 	WildcardTargetNamespace
 	// WildcardList allows the sorted namespace IDs in Namespaces.
 	WildcardList
+	// WildcardNot allows every namespace except the sorted namespace IDs in
+	// Namespaces, as declared by an XSD 1.1 notNamespace attribute.
+	WildcardNot
 )
 
 // ProcessContents identifies how wildcard matches are validated.
@@ -36,9 +39,18 @@ const (
 // Wildcard is the runtime representation of an element or attribute wildcard.
 type Wildcard struct {
 	Namespaces []NamespaceID
-	OtherThan  NamespaceID
-	Mode       WildcardMode
-	Process    ProcessContents
+	// NotQNames are the sorted names excluded by an XSD 1.1 notQName
+	// attribute.
+	NotQNames []QName
+	OtherThan NamespaceID
+	Mode      WildcardMode
+	Process   ProcessContents
+	// NotDefined excludes names with a global declaration of the wildcard's
+	// kind (notQName="##defined").
+	NotDefined bool
+	// NotDefinedSibling excludes names of element declarations in the content
+	// model that holds the wildcard (notQName="##definedSibling").
+	NotDefinedSibling bool
 }
 
 // WildcardByID resolves and clones a wildcard from a wildcard table.
@@ -51,11 +63,14 @@ func WildcardByID(wildcards []Wildcard, id WildcardID) (Wildcard, bool) {
 
 // WildcardView is a read-only validation view over a frozen wildcard.
 type WildcardView struct {
-	otherThan  string
-	namespaces []string
-	mode       WildcardMode
-	process    ProcessContents
-	valid      bool
+	otherThan         string
+	namespaces        []string
+	notQNames         []QName
+	mode              WildcardMode
+	process           ProcessContents
+	notDefined        bool
+	notDefinedSibling bool
+	valid             bool
 }
 
 // NewWildcardView returns a read-only validation view over wildcard.
@@ -64,9 +79,12 @@ func NewWildcardView(names *NameTable, wildcard *Wildcard) WildcardView {
 		return WildcardView{}
 	}
 	view := WildcardView{
-		mode:    wildcard.Mode,
-		process: wildcard.Process,
-		valid:   true,
+		notQNames:         slices.Clone(wildcard.NotQNames),
+		mode:              wildcard.Mode,
+		process:           wildcard.Process,
+		notDefined:        wildcard.NotDefined,
+		notDefinedSibling: wildcard.NotDefinedSibling,
+		valid:             true,
 	}
 	switch wildcard.Mode {
 	case WildcardAny, WildcardLocal:
@@ -78,7 +96,7 @@ func NewWildcardView(names *NameTable, wildcard *Wildcard) WildcardView {
 		}
 		view.otherThan = names.Namespace(wildcard.OtherThan)
 		return view
-	case WildcardTargetNamespace, WildcardList:
+	case WildcardTargetNamespace, WildcardList, WildcardNot:
 		if names == nil {
 			view.valid = false
 			return view
@@ -131,19 +149,47 @@ func (v WildcardView) AllowsURI(uri string) bool {
 		return len(v.namespaces) != 0 && v.namespaces[0] == uri
 	case WildcardList:
 		return slices.Contains(v.namespaces, uri)
+	case WildcardNot:
+		return !slices.Contains(v.namespaces, uri)
 	default:
 		return false
 	}
 }
 
+// AllowsName reports whether the wildcard admits name by namespace and is not
+// excluded by notQName. Exclusions of defined names depend on the caller's
+// declarations; see [WildcardView.ExcludesDefined] and
+// [WildcardView.ExcludesDefinedSibling].
+func (v WildcardView) AllowsName(name RuntimeName) bool {
+	if !v.AllowsURI(name.NS) {
+		return false
+	}
+	return !name.Known || !slices.Contains(v.notQNames, name.Name)
+}
+
+// ExcludesDefined reports whether names with a global declaration are
+// excluded (notQName="##defined").
+func (v WildcardView) ExcludesDefined() bool {
+	return v.valid && v.notDefined
+}
+
+// ExcludesDefinedSibling reports whether names of element declarations in
+// the enclosing content model are excluded (notQName="##definedSibling").
+func (v WildcardView) ExcludesDefinedSibling() bool {
+	return v.valid && v.notDefinedSibling
+}
+
 // EqualWildcardViews reports whether two validation wildcard views expose the
 // same wildcard process and namespace set.
 func EqualWildcardViews(a, b WildcardView) bool {
 	return a.otherThan == b.otherThan &&
 		a.mode == b.mode &&
 		a.process == b.process &&
+		a.notDefined == b.notDefined &&
+		a.notDefinedSibling == b.notDefinedSibling &&
 		a.valid == b.valid &&
-		slices.Equal(a.namespaces, b.namespaces)
+		slices.Equal(a.namespaces, b.namespaces) &&
+		slices.Equal(a.notQNames, b.notQNames)
 }
 
 // EqualWildcardViewProjection reports whether view matches the validation view
@@ -217,9 +263,21 @@ func ValidateWildcard(names *NameTable, w Wildcard) error {
 		if !validWildcardNamespaceList(names, w.Namespaces) {
 			return errors.New("namespace list wildcard is invalid")
 		}
+	case WildcardNot:
+		if w.OtherThan != EmptyNamespaceID {
+			return errors.New("notNamespace wildcard stores inactive other namespace")
+		}
+		if len(w.Namespaces) == 0 || !validWildcardNamespaceList(names, w.Namespaces) {
+			return errors.New("notNamespace wildcard is invalid")
+		}
 	default:
 		return errors.New("wildcard has invalid mode")
 	}
+	for i, q := range w.NotQNames {
+		if !names.ValidQName(q) || (i != 0 && compareQName(w.NotQNames[i-1], q) >= 0) {
+			return errors.New("wildcard notQName list is invalid")
+		}
+	}
 	return nil
 }
 
@@ -243,6 +301,12 @@ func NormalizeNamespaceList(namespaces []NamespaceID) []NamespaceID {
 	return slices.Compact(namespaces)
 }
 
+// NormalizeQNameList sorts names and removes duplicates.
+func NormalizeQNameList(names []QName) []QName {
+	slices.SortFunc(names, compareQName)
+	return slices.Compact(names)
+}
+
 // WildcardNamespaceEqual reports whether two wildcards represent the same
 // namespace set, ignoring process contents.
 func WildcardNamespaceEqual(a, b Wildcard) bool {
@@ -265,17 +329,36 @@ func WildcardAllowsNamespace(w Wildcard, ns NamespaceID) bool {
 		return len(w.Namespaces) != 0 && w.Namespaces[0] == ns
 	case WildcardList:
 		return slices.Contains(w.Namespaces, ns)
+	case WildcardNot:
+		return !slices.Contains(w.Namespaces, ns)
 	default:
 		return false
 	}
 }
 
+// WildcardAllowsQName reports whether w admits name by namespace and
+// notQName. Exclusions of defined names are not considered.
+func WildcardAllowsQName(w Wildcard, name QName) bool {
+	return WildcardAllowsNamespace(w, name.Namespace) && !slices.Contains(w.NotQNames, name)
+}
+
 // WildcardSubset reports whether derived admits only namespaces admitted by
 // base and is no less strict in processContents.
 func WildcardSubset(derived, base Wildcard) bool {
 	if derived.Process > base.Process {
 		return false
 	}
+	if (base.NotDefined && !derived.NotDefined) || (base.NotDefinedSibling && !derived.NotDefinedSibling) {
+		return false
+	}
+	for _, name := range base.NotQNames {
+		if WildcardAllowsQName(derived, name) {
+			return false
+		}
+	}
+	if derived.Mode == WildcardNot || base.Mode == WildcardNot {
+		return wildcardNamespaceSetOf(derived).subset(wildcardNamespaceSetOf(base))
+	}
 	switch derived.Mode {
 	case WildcardAny:
 		return base.Mode == WildcardAny
@@ -299,6 +382,9 @@ func WildcardSubset(derived, base Wildcard) bool {
 
 // WildcardsOverlap reports whether two wildcards can admit the same namespace.
 func WildcardsOverlap(a, b Wildcard) bool {
+	if a.Mode == WildcardNot || b.Mode == WildcardNot {
+		return wildcardNamespaceSetOf(a).overlaps(wildcardNamespaceSetOf(b))
+	}
 	if a.Mode == WildcardAny || b.Mode == WildcardAny {
 		return true
 	}
@@ -328,12 +414,55 @@ func WildcardsOverlap(a, b Wildcard) bool {
 	return false
 }
 
-// UnionWildcard returns the union of two wildcard namespace sets.
+// UnionWildcard returns the union of two wildcards. A name stays excluded only
+// when neither wildcard admits it.
 func UnionWildcard(wa, wb Wildcard, process ProcessContents) (Wildcard, error) {
+	out, err := unionWildcardNamespaces(wa, wb, process)
+	if err != nil {
+		return Wildcard{}, err
+	}
+	var notQNames []QName
+	for _, name := range wa.NotQNames {
+		if !WildcardAllowsQName(wb, name) {
+			notQNames = append(notQNames, name)
+		}
+	}
+	for _, name := range wb.NotQNames {
+		if !WildcardAllowsQName(wa, name) {
+			notQNames = append(notQNames, name)
+		}
+	}
+	out.NotQNames = NormalizeQNameList(notQNames)
+	out.NotDefined = wa.NotDefined && wb.NotDefined
+	out.NotDefinedSibling = wa.NotDefinedSibling && wb.NotDefinedSibling
+	return out, nil
+}
+
+// IntersectWildcard returns the intersection of two wildcards. A name
+// excluded by either wildcard stays excluded.
+func IntersectWildcard(wa, wb Wildcard, process ProcessContents) (Wildcard, error) {
+	out, err := intersectWildcardNamespaces(wa, wb, process)
+	if err != nil {
+		return Wildcard{}, err
+	}
+	var notQNames []QName
+	for _, name := range append(slices.Clone(wa.NotQNames), wb.NotQNames...) {
+		if WildcardAllowsNamespace(out, name.Namespace) {
+			notQNames = append(notQNames, name)
+		}
+	}
+	out.NotQNames = NormalizeQNameList(notQNames)
+	out.NotDefined = wa.NotDefined || wb.NotDefined
+	out.NotDefinedSibling = wa.NotDefinedSibling || wb.NotDefinedSibling
+	return out, nil
+}
+
+func unionWildcardNamespaces(wa, wb Wildcard, process ProcessContents) (Wildcard, error) {
+	if wa.Mode == WildcardNot || wb.Mode == WildcardNot {
+		return wildcardNamespaceSetOf(wa).union(wildcardNamespaceSetOf(wb)).wildcard(process), nil
+	}
 	if WildcardNamespaceEqual(wa, wb) {
-		out := CloneWildcard(wa)
-		out.Process = process
-		return out, nil
+		return Wildcard{Mode: wa.Mode, Namespaces: slices.Clone(wa.Namespaces), OtherThan: wa.OtherThan, Process: process}, nil
 	}
 	if wa.Mode == WildcardAny || wb.Mode == WildcardAny {
 		return Wildcard{Mode: WildcardAny, Process: process}, nil
@@ -352,33 +481,25 @@ func UnionWildcard(wa, wb Wildcard, process ProcessContents) (Wildcard, error) {
 	return Wildcard{Mode: WildcardList, Namespaces: namespaces, Process: process}, nil
 }
 
-// IntersectWildcard returns the intersection of two wildcard namespace sets.
-func IntersectWildcard(wa, wb Wildcard, process ProcessContents) (Wildcard, error) {
+func intersectWildcardNamespaces(wa, wb Wildcard, process ProcessContents) (Wildcard, error) {
+	if wa.Mode == WildcardNot || wb.Mode == WildcardNot {
+		return wildcardNamespaceSetOf(wa).intersect(wildcardNamespaceSetOf(wb)).wildcard(process), nil
+	}
 	if WildcardNamespaceEqual(wa, wb) {
-		out := CloneWildcard(wa)
-		out.Process = process
-		return out, nil
+		return Wildcard{Mode: wa.Mode, Namespaces: slices.Clone(wa.Namespaces), OtherThan: wa.OtherThan, Process: process}, nil
 	}
 	if wa.Mode == WildcardAny {
-		out := CloneWildcard(wb)
-		out.Process = process
-		return out, nil
+		return Wildcard{Mode: wb.Mode, Namespaces: slices.Clone(wb.Namespaces), OtherThan: wb.OtherThan, Process: process}, nil
 	}
 	if wb.Mode == WildcardAny {
-		out := CloneWildcard(wa)
-		out.Process = process
-		return out, nil
+		return Wildcard{Mode: wa.Mode, Namespaces: slices.Clone(wa.Namespaces), OtherThan: wa.OtherThan, Process: process}, nil
 	}
 	if wa.Mode == WildcardOther && wb.Mode == WildcardOther {
 		if wa.OtherThan == EmptyNamespaceID {
-			out := CloneWildcard(wb)
-			out.Process = process
-			return out, nil
+			return Wildcard{Mode: wb.Mode, OtherThan: wb.OtherThan, Process: process}, nil
 		}
 		if wb.OtherThan == EmptyNamespaceID {
-			out := CloneWildcard(wa)
-			out.Process = process
-			return out, nil
+			return Wildcard{Mode: wa.Mode, OtherThan: wa.OtherThan, Process: process}, nil
 		}
 		return Wildcard{}, errors.New("attribute wildcard intersection is not expressible")
 	}
@@ -448,3 +569,105 @@ func wildcardNamespaces(w Wildcard) []NamespaceID {
 	}
 	return nil
 }
+
+// wildcardNamespaceSet is a wildcard namespace set in normal form: the sorted
+// namespaces are either the whole set or, when negated, its complement.
+type wildcardNamespaceSet struct {
+	namespaces []NamespaceID
+	negated    bool
+}
+
+func wildcardNamespaceSetOf(w Wildcard) wildcardNamespaceSet {
+	switch w.Mode {
+	case WildcardAny:
+		return wildcardNamespaceSet{negated: true}
+	case WildcardOther:
+		return wildcardNamespaceSet{namespaces: NormalizeNamespaceList([]NamespaceID{EmptyNamespaceID, w.OtherThan}), negated: true}
+	case WildcardNot:
+		return wildcardNamespaceSet{namespaces: slices.Clone(w.Namespaces), negated: true}
+	default:
+		return wildcardNamespaceSet{namespaces: wildcardFiniteNamespaces(w)}
+	}
+}
+
+func (s wildcardNamespaceSet) union(o wildcardNamespaceSet) wildcardNamespaceSet {
+	switch {
+	case s.negated && o.negated:
+		return wildcardNamespaceSet{namespaces: namespaceListFilter(s.namespaces, o.namespaces, true), negated: true}
+	case s.negated:
+		return wildcardNamespaceSet{namespaces: namespaceListFilter(s.namespaces, o.namespaces, false), negated: true}
+	case o.negated:
+		return wildcardNamespaceSet{namespaces: namespaceListFilter(o.namespaces, s.namespaces, false), negated: true}
+	default:
+		return wildcardNamespaceSet{namespaces: NormalizeNamespaceList(append(slices.Clone(s.namespaces), o.namespaces...))}
+	}
+}
+
+func (s wildcardNamespaceSet) intersect(o wildcardNamespaceSet) wildcardNamespaceSet {
+	switch {
+	case s.negated && o.negated:
+		return wildcardNamespaceSet{namespaces: NormalizeNamespaceList(append(slices.Clone(s.namespaces), o.namespaces...)), negated: true}
+	case s.negated:
+		return wildcardNamespaceSet{namespaces: namespaceListFilter(o.namespaces, s.namespaces, false)}
+	case o.negated:
+		return wildcardNamespaceSet{namespaces: namespaceListFilter(s.namespaces, o.namespaces, false)}
+	default:
+		return wildcardNamespaceSet{namespaces: namespaceListFilter(s.namespaces, o.namespaces, true)}
+	}
+}
+
+func (s wildcardNamespaceSet) subset(o wildcardNamespaceSet) bool {
+	switch {
+	case s.negated && o.negated:
+		return len(namespaceListFilter(o.namespaces, s.namespaces, false)) == 0
+	case s.negated:
+		return false
+	case o.negated:
+		return len(namespaceListFilter(s.namespaces, o.namespaces, true)) == 0
+	default:
+		return len(namespaceListFilter(s.namespaces, o.namespaces, false)) == 0
+	}
+}
+
+func (s wildcardNamespaceSet) overlaps(o wildcardNamespaceSet) bool {
+	switch {
+	case s.negated && o.negated:
+		return true
+	case s.negated:
+		return len(namespaceListFilter(o.namespaces, s.namespaces, false)) != 0
+	case o.negated:
+		return len(namespaceListFilter(s.namespaces, o.namespaces, false)) != 0
+	default:
+		return len(namespaceListFilter(s.namespaces, o.namespaces, true)) != 0
+	}
+}
+
+// wildcard returns the canonical wildcard for s, preferring the XSD 1.0
+// modes where they can express the set.
+func (s wildcardNamespaceSet) wildcard(process ProcessContents) Wildcard {
+	if !s.negated {
+		return Wildcard{Mode: WildcardList, Namespaces: s.namespaces, Process: process}
+	}
+	switch {
+	case len(s.namespaces) == 0:
+		return Wildcard{Mode: WildcardAny, Process: process}
+	case len(s.namespaces) == 1 && s.namespaces[0] == EmptyNamespaceID:
+		return Wildcard{Mode: WildcardOther, OtherThan: EmptyNamespaceID, Process: process}
+	case len(s.namespaces) == 2 && s.namespaces[0] == EmptyNamespaceID:
+		return Wildcard{Mode: WildcardOther, OtherThan: s.namespaces[1], Process: process}
+	default:
+		return Wildcard{Mode: WildcardNot, Namespaces: s.namespaces, Process: process}
+	}
+}
+
+// namespaceListFilter returns the namespaces of list that are (keep) or are
+// not (!keep) in other.
+func namespaceListFilter(list, other []NamespaceID, keep bool) []NamespaceID {
+	var out []NamespaceID
+	for _, ns := range list {
+		if slices.Contains(other, ns) == keep {
+			out = append(out, ns)
+		}
+	}
+	return out
+}
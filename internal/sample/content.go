@@ -0,0 +1,482 @@
+package sample
+
+import (
+	"cmp"
+	"encoding/xml"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+// wildcardLocal is the local name of elements generated for skip and lax
+// wildcards that admit no declared element.
+const wildcardLocal = "sample"
+
+func (g *generator) element(id runtime.ElementID, depth int) (*node, error) {
+	if err := g.canceled(); err != nil {
+		return nil, err
+	}
+	if depth > maxDepth {
+		return nil, unsupported("content model requires elements nested deeper than the sample generator supports")
+	}
+	decl, ok := g.rt.ElementComponent(id)
+	if !ok {
+		return nil, missingComponent("element declaration")
+	}
+	if decl.Abstract {
+		member, ok := g.substitute(id)
+		if !ok {
+			return nil, unsupported("abstract element " + clark(g.name(decl.Name)) + " has no substitution group member")
+		}
+		if decl, ok = g.rt.ElementComponent(member); !ok {
+			return nil, missingComponent("element declaration")
+		}
+		id = member
+	}
+	n := &node{name: g.name(decl.Name), decl: id, textType: runtime.NoSimpleType}
+	typ := decl.Type
+	if concrete, name, ok := g.concreteType(typ); ok {
+		typ = concrete
+		n.attrs = append(n.attrs, attr{name: xml.Name{Space: vocab.XSINamespaceURI, Local: "type"}, qname: name, typ: runtime.NoSimpleType})
+	}
+	g.active[typ]++
+	defer func() { g.active[typ]-- }()
+	key := valueKey{kind: keyElement, id: uint32(id)}
+	if simple, ok := typ.Simple(); ok {
+		return n, g.elementText(n, decl, simple, key)
+	}
+	complexID, ok := typ.Complex()
+	if !ok {
+		return nil, missingComponent("type")
+	}
+	ct, ok := g.rt.ComplexTypeComponent(complexID)
+	if !ok {
+		return nil, missingComponent("complex type")
+	}
+	if ct.Abstract {
+		return nil, unsupported("abstract type of element " + clark(n.name) + " has no named derived type")
+	}
+	if err := g.attributes(n, ct.Attrs); err != nil {
+		return nil, err
+	}
+	if ct.ContentKind.Simple() {
+		return n, g.elementText(n, decl, ct.TextType, key)
+	}
+	if decl.Fixed.Present {
+		n.text = decl.Fixed.Lexical
+		return n, nil
+	}
+	model, ok := g.rt.ContentModelComponent(ct.Content)
+	if !ok {
+		return nil, missingComponent("content model")
+	}
+	outer := g.scope
+	g.scope = scope{model: ct.Content, repeated: repeats(model.Occurs), optional: model.Occurs.Min == 0}
+	defer func() { g.scope = outer }()
+	for range g.occurrences(model.Occurs, depth, true) {
+		if err := g.group(n, model, depth); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (g *generator) elementText(n *node, decl runtime.ElementComponent, typ runtime.SimpleTypeID, key valueKey) error {
+	switch {
+	case decl.Fixed.Present:
+		n.text = decl.Fixed.Lexical
+		return nil
+	case decl.Default.Present:
+		n.text = decl.Default.Lexical
+	default:
+		text, err := g.value(typ, key)
+		if err != nil {
+			return err
+		}
+		n.text = text
+	}
+	n.textType = typ
+	return nil
+}
+
+// substitute returns a non-abstract member of the substitution group of head.
+func (g *generator) substitute(head runtime.ElementID) (runtime.ElementID, bool) {
+	var members []runtime.ElementID
+	for _, member := range g.rt.SubstitutionMembers(head) {
+		if decl, ok := g.rt.ElementComponent(member); ok && !decl.Abstract {
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		return runtime.NoElement, false
+	}
+	g.sortElements(members)
+	if g.rand != nil {
+		return members[g.pick(len(members))], true
+	}
+	// Prefer the member with the smallest content.
+	best := members[0]
+	for _, member := range members[1:] {
+		if g.elementSize(member) < g.elementSize(best) {
+			best = member
+		}
+	}
+	return best, true
+}
+
+// concreteType returns a named non-abstract complex type derived from typ
+// when typ is an abstract complex type, for use as the xsi:type of an element.
+func (g *generator) concreteType(typ runtime.TypeID) (runtime.TypeID, xml.Name, bool) {
+	base, ok := typ.Complex()
+	if !ok {
+		return typ, xml.Name{}, false
+	}
+	if ct, ok := g.rt.ComplexTypeComponent(base); !ok || !ct.Abstract {
+		return typ, xml.Name{}, false
+	}
+	type candidate struct {
+		name xml.Name
+		id   runtime.TypeID
+	}
+	var candidates []candidate
+	for qname, id := range g.rt.GlobalTypes() {
+		complexID, ok := id.Complex()
+		if !ok || id == typ {
+			continue
+		}
+		ct, ok := g.rt.ComplexTypeComponent(complexID)
+		if !ok || ct.Abstract {
+			continue
+		}
+		if _, ok := g.rt.TypeDerivation(id, typ); !ok {
+			continue
+		}
+		name := g.name(qname)
+		if strings.Contains(name.Local, "$") {
+			continue
+		}
+		candidates = append(candidates, candidate{name: name, id: id})
+	}
+	if len(candidates) == 0 {
+		return typ, xml.Name{}, false
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int { return compareNames(a.name, b.name) })
+	c := candidates[g.pick(len(candidates))]
+	return c.id, c.name, true
+}
+
+func (g *generator) attributes(n *node, id runtime.AttributeUseSetID) error {
+	set, ok := g.rt.AttributeUseSetComponent(id)
+	if !ok {
+		return missingComponent("attribute-use set")
+	}
+	for _, use := range set.Uses {
+		if use.Prohibited || !use.Required && !g.opts.Optional {
+			continue
+		}
+		a := attr{name: g.name(use.Name), typ: use.Type}
+		switch {
+		case use.Fixed.Present:
+			a.value, a.typ = use.Fixed.Lexical, runtime.NoSimpleType
+		case use.Default.Present:
+			a.value = use.Default.Lexical
+		default:
+			var err error
+			if a.value, err = g.value(use.Type, valueKey{kind: keyAttribute, id: uint32(id), name: use.Name}); err != nil {
+				return err
+			}
+		}
+		n.attrs = append(n.attrs, a)
+	}
+	return nil
+}
+
+// occurrences returns how many times to generate a particle. Optional and
+// repeated occurrences are generated only when extra is set.
+func (g *generator) occurrences(occurs runtime.Occurrence, depth int, extra bool) int {
+	n := int(occurs.Min)
+	if extra && depth < optionalDepth {
+		if n == 0 && g.opts.Optional {
+			n = 1
+		}
+		if n > 0 && g.opts.Repeat > n {
+			n = g.opts.Repeat
+		}
+	}
+	if !occurs.Unbounded {
+		n = min(n, int(occurs.Max))
+	}
+	return n
+}
+
+func (g *generator) group(n *node, model runtime.ContentModel, depth int) error {
+	switch model.Kind {
+	case runtime.ModelSequence, runtime.ModelAll:
+		for _, p := range model.Particles {
+			if err := g.particle(n, p, depth); err != nil {
+				return err
+			}
+		}
+	case runtime.ModelChoice:
+		i, ok := g.choose(model.Particles)
+		if !ok {
+			return unsupported("no branch of a choice in " + clark(n.name) + " can be completed")
+		}
+		outer := g.scope
+		g.scope.optional = g.scope.optional || len(model.Particles) > 1
+		defer func() { g.scope = outer }()
+		return g.particle(n, model.Particles[i], depth)
+	}
+	return nil
+}
+
+func (g *generator) particle(n *node, p runtime.Particle, depth int) error {
+	switch p.Kind {
+	case runtime.ParticleElement:
+		count := g.occurrences(p.Occurs, depth, !g.recursive(p.Element))
+		for range count {
+			child, err := g.element(p.Element, depth+1)
+			if err != nil {
+				return err
+			}
+			n.children = append(n.children, child)
+		}
+		if count > 0 {
+			g.runs = append(g.runs, run{parent: n, end: len(n.children), count: count, occurs: p.Occurs, scope: g.scope})
+		}
+	case runtime.ParticleWildcard:
+		for range g.occurrences(p.Occurs, depth, true) {
+			child, err := g.wildcard(p.Wildcard, depth+1)
+			if err != nil {
+				return err
+			}
+			n.children = append(n.children, child)
+		}
+	case runtime.ParticleModel:
+		model, ok := g.rt.ContentModelComponent(p.Model)
+		if !ok {
+			return missingComponent("content model")
+		}
+		outer := g.scope
+		g.scope.repeated = g.scope.repeated || repeats(p.Occurs)
+		g.scope.optional = g.scope.optional || p.Occurs.Min == 0
+		defer func() { g.scope = outer }()
+		for range g.occurrences(p.Occurs, depth, true) {
+			if err := g.group(n, model, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recursive reports whether the type of element id is already being
+// generated, so optional occurrences would nest the same content again.
+func (g *generator) recursive(id runtime.ElementID) bool {
+	decl, ok := g.rt.ElementComponent(id)
+	return ok && g.active[decl.Type] > 0
+}
+
+// choose returns the index of the choice branch to generate.
+func (g *generator) choose(particles []runtime.Particle) (int, bool) {
+	var finite []int
+	for i, p := range particles {
+		if g.particleSize(p) != math.MaxInt {
+			finite = append(finite, i)
+		}
+	}
+	if len(finite) == 0 {
+		return 0, false
+	}
+	switch g.opts.Choice {
+	case ChoiceFirst:
+		return finite[0], true
+	case ChoiceRandom:
+		return finite[g.pick(len(finite))], true
+	default:
+		best := finite[0]
+		for _, i := range finite[1:] {
+			if g.particleSize(particles[i]) < g.particleSize(particles[best]) {
+				best = i
+			}
+		}
+		return best, true
+	}
+}
+
+func (g *generator) wildcard(id runtime.WildcardID, depth int) (*node, error) {
+	view, ok := g.rt.WildcardView(id)
+	if !ok {
+		return nil, missingComponent("wildcard")
+	}
+	w, ok := g.rt.WildcardComponent(id)
+	if !ok {
+		return nil, missingComponent("wildcard")
+	}
+	if view.Process() != runtime.ProcessSkip && !w.NotDefined {
+		var candidates []runtime.ElementID
+		for name, elem := range g.rt.GlobalElements() {
+			decl, ok := g.rt.ElementComponent(elem)
+			if !ok || decl.Abstract || slices.Contains(w.NotQNames, name) || strings.Contains(g.name(name).Local, "$") {
+				continue
+			}
+			if view.AllowsURI(g.name(name).Space) && g.elementSize(elem) != math.MaxInt {
+				candidates = append(candidates, elem)
+			}
+		}
+		if len(candidates) != 0 {
+			g.sortElements(candidates)
+			slices.SortStableFunc(candidates, func(a, b runtime.ElementID) int { return cmp.Compare(g.elementSize(a), g.elementSize(b)) })
+			return g.element(candidates[g.pick(len(candidates))], depth)
+		}
+		if view.Process() == runtime.ProcessStrict {
+			return nil, unsupported("strict wildcard admits no global element declaration")
+		}
+	}
+	spaces := []string{vocab.EmptyNamespaceURI}
+	for _, ns := range w.Namespaces {
+		spaces = append(spaces, g.rt.Namespace(ns))
+	}
+	spaces = append(spaces, "urn:xsd:sample")
+	for _, ns := range spaces {
+		if view.AllowsURI(ns) {
+			return &node{name: xml.Name{Space: ns, Local: wildcardLocal}, decl: runtime.NoElement, textType: runtime.NoSimpleType}, nil
+		}
+	}
+	return nil, unsupported("wildcard admits no namespace")
+}
+
+func (g *generator) sortElements(ids []runtime.ElementID) {
+	slices.SortFunc(ids, func(a, b runtime.ElementID) int {
+		da, _ := g.rt.ElementComponent(a)
+		db, _ := g.rt.ElementComponent(b)
+		return compareNames(g.name(da.Name), g.name(db.Name))
+	})
+}
+
+// elementSize returns the fewest elements an instance of element id holds,
+// itself included, or math.MaxInt when no finite instance exists.
+func (g *generator) elementSize(id runtime.ElementID) int {
+	if g.sizes == nil {
+		g.computeSizes()
+	}
+	if int(id) >= len(g.sizes) {
+		return math.MaxInt
+	}
+	return g.sizes[id]
+}
+
+// computeSizes solves the element sizes of the schema as a fixed point:
+// sizes start unbounded and shrink until no declaration changes, so
+// recursive declarations converge on their shortest terminating instance.
+func (g *generator) computeSizes() {
+	for id := runtime.ElementID(0); ; id++ {
+		if _, ok := g.rt.ElementComponent(id); !ok {
+			break
+		}
+		g.sizes = append(g.sizes, math.MaxInt)
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := range g.sizes {
+			if size := g.declSize(runtime.ElementID(i)); size < g.sizes[i] {
+				g.sizes[i] = size
+				changed = true
+			}
+		}
+	}
+}
+
+func (g *generator) declSize(id runtime.ElementID) int {
+	decl, ok := g.rt.ElementComponent(id)
+	if !ok {
+		return math.MaxInt
+	}
+	if decl.Abstract {
+		size := math.MaxInt
+		for _, member := range g.rt.SubstitutionMembers(id) {
+			if m, ok := g.rt.ElementComponent(member); ok && !m.Abstract && int(member) < len(g.sizes) {
+				size = min(size, g.sizes[member])
+			}
+		}
+		return size
+	}
+	complexID, ok := decl.Type.Complex()
+	if !ok {
+		return 1
+	}
+	ct, ok := g.rt.ComplexTypeComponent(complexID)
+	if !ok || ct.ContentKind.Simple() {
+		return 1
+	}
+	model, ok := g.rt.ContentModelComponent(ct.Content)
+	if !ok {
+		return math.MaxInt
+	}
+	return addSize(1, mulSize(g.groupSize(model), model.Occurs.Min))
+}
+
+func (g *generator) groupSize(model runtime.ContentModel) int {
+	switch model.Kind {
+	case runtime.ModelSequence, runtime.ModelAll:
+		size := 0
+		for _, p := range model.Particles {
+			size = addSize(size, g.particleSize(p))
+		}
+		return size
+	case runtime.ModelChoice:
+		size := math.MaxInt
+		for _, p := range model.Particles {
+			size = min(size, g.particleSize(p))
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// particleSize returns the fewest elements the required occurrences of p
+// generate.
+func (g *generator) particleSize(p runtime.Particle) int {
+	if p.Occurs.Min == 0 {
+		return 0
+	}
+	switch p.Kind {
+	case runtime.ParticleElement:
+		return mulSize(g.elementSize(p.Element), p.Occurs.Min)
+	case runtime.ParticleWildcard:
+		return int(p.Occurs.Min)
+	case runtime.ParticleModel:
+		model, ok := g.rt.ContentModelComponent(p.Model)
+		if !ok {
+			return math.MaxInt
+		}
+		return mulSize(g.groupSize(model), p.Occurs.Min)
+	default:
+		return math.MaxInt
+	}
+}
+
+func addSize(a, b int) int {
+	if a == math.MaxInt || b == math.MaxInt || a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func mulSize(size int, count uint32) int {
+	if count == 0 {
+		return 0
+	}
+	if size == math.MaxInt || size > math.MaxInt/int(count) {
+		return math.MaxInt
+	}
+	return size * int(count)
+}
+
+func compareNames(a, b xml.Name) int {
+	return cmp.Or(strings.Compare(a.Space, b.Space), strings.Compare(a.Local, b.Local))
+}
@@ -0,0 +1,587 @@
+package sample
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// Mutation names the kind of constraint a mutated instance violates.
+type Mutation uint8
+
+const (
+	// MutationFacet replaces a generated value with one its type rejects.
+	MutationFacet Mutation = iota + 1
+	// MutationOccurrence removes an element its particle requires or adds
+	// one past its maxOccurs.
+	MutationOccurrence
+	// MutationIdentity copies the key or unique field values of one
+	// selected element to another, so both share a key.
+	MutationIdentity
+	// MutationNil nils an element that is not nillable or has content.
+	MutationNil
+)
+
+// Mutant is a generated instance changed to violate one constraint.
+type Mutant struct {
+	// Original is the valid instance the mutation was applied to.
+	Original []byte
+	// Document is the mutated instance.
+	Document []byte
+	// Path is the path of the changed element.
+	Path string
+	// Code is the error code validation reports for the violation.
+	Code xsderrors.Code
+	// Mutation is the kind of mutation applied.
+	Mutation Mutation
+}
+
+// scope describes where in its parent's content model an element particle
+// is generated: repeated when an enclosing group may occur more than once,
+// optional when an enclosing group may be absent or is a choice branch.
+type scope struct {
+	model    runtime.ContentModelID
+	repeated bool
+	optional bool
+}
+
+func repeats(occurs runtime.Occurrence) bool {
+	return occurs.Unbounded || occurs.Max > 1
+}
+
+// run is the occurrences generated for one element particle: the count
+// elements of parent.children ending before end.
+type run struct {
+	parent *node
+	end    int
+	count  int
+	occurs runtime.Occurrence
+	scope  scope
+}
+
+// site is one mutation the generated instance admits.
+type site struct {
+	apply    func()
+	path     string
+	code     xsderrors.Code
+	mutation Mutation
+}
+
+// tree indexes the generated element tree for site collection.
+type tree struct {
+	parents map[*node]*node
+	paths   map[*node]string
+	order   []*node
+}
+
+// Mutate generates an instance as Generate does, then applies one mutation
+// of the given kinds, drawn with opts.Seed among those the instance admits.
+func Mutate(ctx context.Context, rt *runtime.Schema, root xml.Name, opts Options, kinds []Mutation) (Mutant, error) {
+	g, n, err := generate(ctx, rt, root, opts)
+	if err != nil {
+		return Mutant{}, err
+	}
+	original := g.write(n)
+	t := index(n)
+	var sites []site
+	for _, kind := range kinds {
+		switch kind {
+		case MutationFacet:
+			sites = append(sites, g.facetSites(t)...)
+		case MutationOccurrence:
+			sites = append(sites, g.occurrenceSites(t)...)
+		case MutationIdentity:
+			sites = append(sites, g.identitySites(t)...)
+		case MutationNil:
+			sites = append(sites, g.nilSites(t)...)
+		}
+	}
+	if len(sites) == 0 {
+		return Mutant{}, unsupported("generated instance admits none of the requested mutations")
+	}
+	r := rand.New(rand.NewPCG(opts.Seed^0x632be59bd9b4e019, opts.Seed)) //nolint:gosec // Samples need reproducible, not secure, randomness.
+	s := sites[r.IntN(len(sites))]
+	s.apply()
+	return Mutant{
+		Original: original,
+		Document: g.write(n),
+		Path:     s.path,
+		Code:     s.code,
+		Mutation: s.mutation,
+	}, nil
+}
+
+func index(root *node) tree {
+	t := tree{parents: make(map[*node]*node), paths: make(map[*node]string)}
+	var walk func(n *node, path string)
+	walk = func(n *node, path string) {
+		path += "/" + label(n)
+		t.paths[n] = path
+		t.order = append(t.order, n)
+		for _, child := range n.children {
+			t.parents[child] = n
+			walk(child, path)
+		}
+	}
+	walk(root, "")
+	return t
+}
+
+// label spells n in a path the way validation errors do: declared elements
+// by local name, others in Clark notation.
+func label(n *node) string {
+	if n.decl == runtime.NoElement {
+		return clark(n.name)
+	}
+	return n.name.Local
+}
+
+func (g *generator) facetSites(t tree) []site {
+	var sites []site
+	for _, n := range t.order {
+		if n.textType != runtime.NoSimpleType {
+			if value, ok := g.violation(n.textType); ok {
+				sites = append(sites, site{
+					apply:    func() { n.text = value },
+					path:     t.paths[n],
+					code:     xsderrors.CodeValidationFacet,
+					mutation: MutationFacet,
+				})
+			}
+		}
+		for i, a := range n.attrs {
+			if a.typ == runtime.NoSimpleType {
+				continue
+			}
+			if value, ok := g.violation(a.typ); ok {
+				sites = append(sites, site{
+					apply:    func() { n.attrs[i].value = value },
+					path:     t.paths[n],
+					code:     xsderrors.CodeValidationFacet,
+					mutation: MutationFacet,
+				})
+			}
+		}
+	}
+	return sites
+}
+
+// violation returns a value that a base type of id accepts and id rejects
+// with a facet, so the value fails only the restrictions id adds.
+func (g *generator) violation(id runtime.SimpleTypeID) (string, bool) {
+	st, ok := g.rt.SimpleTypeComponent(id)
+	if !ok {
+		return "", false
+	}
+	extra := boundaryCandidates(st.Facets)
+	for base := st.Base; base != runtime.NoSimpleType; {
+		for _, v := range append(g.validValues(base, firstPoolSize), extra...) {
+			if g.valid(base, v) && g.facetRejects(id, v) {
+				return v, true
+			}
+		}
+		next, ok := g.rt.SimpleTypeComponent(base)
+		if !ok {
+			break
+		}
+		base = next.Base
+	}
+	if st.Variety == runtime.SimpleVarietyList {
+		items := g.validValues(st.ListItem, 1)
+		if len(items) == 0 {
+			return "", false
+		}
+		for count := range 17 {
+			v := strings.TrimSpace(strings.Repeat(items[0]+" ", count))
+			if g.facetRejects(id, v) {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+// facetRejects reports whether id rejects lexical for a reason other than a
+// failed assertion.
+func (g *generator) facetRejects(id runtime.SimpleTypeID, lexical string) bool {
+	_, err := g.rt.ValidateSimpleValue(id, lexical, g.resolveQName, 0)
+	if err == nil {
+		return false
+	}
+	_, assertion := errors.AsType[*runtime.AssertionError](err)
+	return !assertion
+}
+
+// boundaryCandidates returns values just past the bounds, lengths and digit
+// counts of f.
+func boundaryCandidates(f runtime.FacetComponent) []string {
+	var out []string
+	if lo, _ := parseBound(f.MinInclusive, f.MinExclusive, true); lo != nil {
+		out = append(out, new(big.Int).Sub(floor(lo), big.NewInt(1)).String(), decimalString(lo, 6))
+	}
+	if hi, _ := parseBound(f.MaxInclusive, f.MaxExclusive, false); hi != nil {
+		out = append(out, new(big.Int).Add(ceil(hi), big.NewInt(1)).String(), decimalString(hi, 6))
+	}
+	if f.Present&runtime.FacetTotalDigits != 0 {
+		out = append(out, strings.Repeat("1", int(f.TotalDigits)+1))
+	}
+	if f.Present&runtime.FacetFractionDigits != 0 {
+		out = append(out, "0."+strings.Repeat("1", int(f.FractionDigits)+1))
+	}
+	if lo, hi := lengthBounds(f, 0); hi >= 0 && hi < 1<<12 {
+		out = append(out, strings.Repeat("x", hi+1))
+	} else if lo > 0 {
+		out = append(out, strings.Repeat("x", lo-1))
+	}
+	return out
+}
+
+func (g *generator) occurrenceSites(t tree) []site {
+	var sites []site
+	for _, r := range g.runs {
+		last := r.parent.children[r.end-1]
+		if r.scope.repeated || !g.soleParticle(r.scope.model, last) {
+			continue
+		}
+		if !r.occurs.Unbounded && r.count == int(r.occurs.Max) {
+			sites = append(sites, site{
+				apply: func() {
+					r.parent.children = slices.Insert(r.parent.children, r.end, clone(last))
+				},
+				path:     t.paths[last],
+				code:     xsderrors.CodeValidationElement,
+				mutation: MutationOccurrence,
+			})
+		}
+		if !r.scope.optional && r.count == int(r.occurs.Min) {
+			sites = append(sites, site{
+				apply: func() {
+					r.parent.children = slices.Delete(r.parent.children, r.end-1, r.end)
+				},
+				path:     t.paths[r.parent],
+				code:     xsderrors.CodeValidationContent,
+				mutation: MutationOccurrence,
+			})
+		}
+	}
+	return sites
+}
+
+// soleParticle reports whether n can only match one element particle of
+// model, so the number of elements named like n is the number of times that
+// particle occurs.
+func (g *generator) soleParticle(id runtime.ContentModelID, n *node) bool {
+	decl, ok := g.rt.ElementComponent(n.decl)
+	if !ok {
+		return false
+	}
+	matches := 0
+	var walk func(id runtime.ContentModelID) bool
+	walk = func(id runtime.ContentModelID) bool {
+		model, ok := g.rt.ContentModelComponent(id)
+		if !ok || model.Open.Present() && g.wildcardAllows(model.Open.Wildcard, n.name.Space) {
+			return false
+		}
+		for _, p := range model.Particles {
+			switch p.Kind {
+			case runtime.ParticleElement:
+				if g.particleNames(p.Element, decl.Name) {
+					matches++
+				}
+			case runtime.ParticleWildcard:
+				if g.wildcardAllows(p.Wildcard, n.name.Space) {
+					return false
+				}
+			case runtime.ParticleModel:
+				if !walk(p.Model) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return walk(id) && matches == 1
+}
+
+// particleNames reports whether an element particle for id admits name,
+// directly or through its substitution group.
+func (g *generator) particleNames(id runtime.ElementID, name runtime.QName) bool {
+	if decl, ok := g.rt.ElementComponent(id); ok && decl.Name == name {
+		return true
+	}
+	for _, member := range g.rt.SubstitutionMembers(id) {
+		if decl, ok := g.rt.ElementComponent(member); ok && decl.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) wildcardAllows(id runtime.WildcardID, ns string) bool {
+	view, ok := g.rt.WildcardView(id)
+	return !ok || view.AllowsURI(ns)
+}
+
+func (g *generator) identitySites(t tree) []site {
+	var sites []site
+	for _, scopeNode := range t.order {
+		decl, ok := g.rt.ElementComponent(scopeNode.decl)
+		if !ok {
+			continue
+		}
+		for _, id := range decl.Identity {
+			info, ok := g.rt.IdentityConstraintInfo(id)
+			if !ok || info.Kind == runtime.IdentityKeyRef {
+				continue
+			}
+			if s, ok := g.duplicateKey(id, g.selected(id, scopeNode), t); ok {
+				sites = append(sites, s)
+			}
+		}
+	}
+	return sites
+}
+
+// duplicateKey returns a site that copies the field values of the first
+// selected element to another selected element of the same declaration
+// whose fields have the same types.
+func (g *generator) duplicateKey(id runtime.IdentityConstraintID, selected []*node, t tree) (site, bool) {
+	if len(selected) < 2 {
+		return site{}, false
+	}
+	first := selected[0]
+	from, ok := g.fields(id, first)
+	if !ok {
+		return site{}, false
+	}
+	for _, other := range selected[1:] {
+		if other.decl != first.decl || contains(first, other) || contains(other, first) {
+			continue
+		}
+		to, ok := g.fields(id, other)
+		if !ok || !slices.EqualFunc(from, to, func(a, b fieldRef) bool { return a.typ() == b.typ() }) {
+			continue
+		}
+		return site{
+			apply: func() {
+				for i, ref := range to {
+					ref.set(from[i].value())
+				}
+			},
+			path:     t.paths[other],
+			code:     xsderrors.CodeValidationIdentity,
+			mutation: MutationIdentity,
+		}, true
+	}
+	return site{}, false
+}
+
+// selected returns the elements the selector of constraint id selects in
+// the scope of element scopeNode, in document order.
+func (g *generator) selected(id runtime.IdentityConstraintID, scopeNode *node) []*node {
+	paths, ok := g.rt.IdentitySelectorPaths(id)
+	if !ok {
+		return nil
+	}
+	var out []*node
+	g.walkRelative(scopeNode, func(n *node, rel []*node) {
+		for i := range paths.Len() {
+			if path, ok := paths.At(i); ok && g.pathMatches(path, rel) {
+				out = append(out, n)
+				return
+			}
+		}
+	})
+	return out
+}
+
+// fieldRef is the element text, when attr is negative, or the attribute a
+// field of an identity constraint selects.
+type fieldRef struct {
+	n    *node
+	attr int
+}
+
+func (r fieldRef) typ() runtime.SimpleTypeID {
+	if r.attr < 0 {
+		return r.n.textType
+	}
+	return r.n.attrs[r.attr].typ
+}
+
+func (r fieldRef) value() string {
+	if r.attr < 0 {
+		return r.n.text
+	}
+	return r.n.attrs[r.attr].value
+}
+
+func (r fieldRef) set(value string) {
+	if r.attr < 0 {
+		r.n.text = value
+	} else {
+		r.n.attrs[r.attr].value = value
+	}
+}
+
+// fields returns the node each field of constraint id selects below the
+// selected element s, reporting false unless every field selects exactly
+// one node.
+func (g *generator) fields(id runtime.IdentityConstraintID, s *node) ([]fieldRef, bool) {
+	count, ok := g.rt.IdentityFieldCount(id)
+	if !ok {
+		return nil, false
+	}
+	refs := make([][]fieldRef, count)
+	mark := func(fields runtime.CompiledIdentityFieldReads, ok bool, rel []*node, ref fieldRef) {
+		if !ok {
+			return
+		}
+		for i := range fields.Len() {
+			field, ok := fields.At(i)
+			if !ok || field.Field() < 0 || field.Field() >= count {
+				continue
+			}
+			for j := range field.PathCount() {
+				path, ok := field.Path(j)
+				if ok && path.IsAttribute() == (ref.attr >= 0) && g.pathMatches(path, rel) &&
+					(ref.attr < 0 || g.attributeMatches(path, ref.n.attrs[ref.attr])) {
+					refs[field.Field()] = append(refs[field.Field()], ref)
+					break
+				}
+			}
+		}
+	}
+	g.walkRelative(s, func(n *node, rel []*node) {
+		elementFields, ok := g.rt.IdentityElementFields(id)
+		mark(elementFields, ok, rel, fieldRef{n: n, attr: -1})
+		for i, a := range n.attrs {
+			if q, ok := g.rt.LookupQName(a.name.Space, a.name.Local); ok {
+				fields, ok := g.rt.IdentityAttributeFields(id, q)
+				mark(fields, ok, rel, fieldRef{n: n, attr: i})
+			}
+			fields, ok := g.rt.IdentityAttributeWildcardFields(id)
+			mark(fields, ok, rel, fieldRef{n: n, attr: i})
+		}
+	})
+	out := make([]fieldRef, count)
+	for i, r := range refs {
+		if len(r) != 1 {
+			return nil, false
+		}
+		out[i] = r[0]
+	}
+	return out, true
+}
+
+// walkRelative calls visit for base and every element below it with the
+// elements between base, exclusive, and the visited element, inclusive.
+func (g *generator) walkRelative(base *node, visit func(n *node, rel []*node)) {
+	var walk func(n *node, rel []*node)
+	walk = func(n *node, rel []*node) {
+		visit(n, rel)
+		for _, child := range n.children {
+			walk(child, append(rel, child))
+		}
+	}
+	walk(base, nil)
+}
+
+type stepPath interface {
+	StepCount() int
+	Step(index int) (runtime.IdentityStep, bool)
+	Descendant() bool
+	Self() bool
+}
+
+// pathMatches mirrors the identity path matching of validation over the
+// generated tree.
+func (g *generator) pathMatches(path stepPath, rel []*node) bool {
+	if path.Self() {
+		return len(rel) == 0
+	}
+	steps := path.StepCount()
+	if path.Descendant() {
+		if len(rel) < steps {
+			return false
+		}
+		rel = rel[len(rel)-steps:]
+	} else if len(rel) != steps {
+		return false
+	}
+	for i, n := range rel {
+		step, ok := path.Step(i)
+		if !ok || !g.stepMatches(step, n.name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *generator) stepMatches(step runtime.IdentityStep, name xml.Name) bool {
+	if !step.Wildcard {
+		q, ok := g.rt.LookupQName(name.Space, name.Local)
+		return ok && q == step.Name
+	}
+	return !step.NamespaceSet || g.rt.Namespace(step.Namespace) == name.Space
+}
+
+func (g *generator) attributeMatches(path runtime.IdentityFieldPathRead, a attr) bool {
+	if !path.AttributeWildcard() {
+		q, ok := g.rt.LookupQName(a.name.Space, a.name.Local)
+		return ok && q == path.Attribute()
+	}
+	if a.name.Space == vocab.XSINamespaceURI {
+		return false
+	}
+	return !path.AttributeNamespaceSet() || g.rt.Namespace(path.AttributeNamespace()) == a.name.Space
+}
+
+func (g *generator) nilSites(t tree) []site {
+	var sites []site
+	for _, n := range t.order {
+		decl, ok := g.rt.ElementComponent(n.decl)
+		if !ok || decl.Nillable && n.text == "" && len(n.children) == 0 {
+			continue
+		}
+		sites = append(sites, site{
+			apply: func() {
+				n.attrs = append(n.attrs, attr{name: xml.Name{Space: vocab.XSINamespaceURI, Local: "nil"}, value: "true", typ: runtime.NoSimpleType})
+			},
+			path:     t.paths[n],
+			code:     xsderrors.CodeValidationNil,
+			mutation: MutationNil,
+		})
+	}
+	return sites
+}
+
+func clone(n *node) *node {
+	c := *n
+	c.attrs = slices.Clone(n.attrs)
+	c.children = make([]*node, len(n.children))
+	for i, child := range n.children {
+		c.children[i] = clone(child)
+	}
+	return &c
+}
+
+func contains(ancestor, n *node) bool {
+	if ancestor == n {
+		return true
+	}
+	for _, child := range ancestor.children {
+		if contains(child, n) {
+			return true
+		}
+	}
+	return false
+}
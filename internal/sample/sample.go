@@ -0,0 +1,295 @@
+// Package sample generates XML instances from the compiled content models and
+// simple-type facets of a published schema.
+package sample
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// ChoicePolicy selects the branch generated for each xs:choice.
+type ChoicePolicy uint8
+
+const (
+	// ChoiceShortest takes the branch that needs the fewest elements.
+	ChoiceShortest ChoicePolicy = iota
+	// ChoiceFirst takes the first branch that can be completed.
+	ChoiceFirst
+	// ChoiceRandom takes a branch drawn from the seeded generator.
+	ChoiceRandom
+)
+
+// Options configures instance generation.
+type Options struct {
+	// Seed seeds the generator used by ChoiceRandom.
+	Seed uint64
+	// Repeat is the occurrence count of particles that may repeat, clamped
+	// to their bounds. Values below minOccurs are ignored.
+	Repeat int
+	// Choice selects the branch of each xs:choice.
+	Choice ChoicePolicy
+	// Optional includes optional particles and attributes once.
+	Optional bool
+}
+
+const (
+	// optionalDepth is the element depth past which only required particles
+	// are generated, so recursive content models terminate.
+	optionalDepth = 8
+	// maxDepth bounds element nesting for content models that require
+	// deeper recursion than any instance can provide.
+	maxDepth = 256
+)
+
+// node is one generated element. decl is NoElement for elements generated
+// for wildcards without a declaration, and textType is NoSimpleType unless
+// text holds a value drawn for that type.
+type node struct {
+	name     xml.Name
+	attrs    []attr
+	children []*node
+	text     string
+	decl     runtime.ElementID
+	textType runtime.SimpleTypeID
+}
+
+// attr is one generated attribute. Attributes with a qname value hold an
+// xsi:type reference whose prefix is chosen when the instance is written.
+// typ is NoSimpleType unless value was drawn for that type.
+type attr struct {
+	name  xml.Name
+	qname xml.Name
+	value string
+	typ   runtime.SimpleTypeID
+}
+
+type generator struct {
+	ctx    context.Context
+	rt     *runtime.Schema
+	rand   *rand.Rand
+	counts map[valueKey]int
+	pools  map[runtime.SimpleTypeID]*valuePool
+	sizes  []int
+	active map[runtime.TypeID]int
+	// bound maps the prefixes of generated QName values to namespaces.
+	bound    map[string]string
+	prefixes map[string]string
+	decls    []xml.Attr
+	nsCount  int
+	opts     Options
+	// scope describes the content model being generated, and runs records
+	// the element particles generated so far, for mutations.
+	scope scope
+	runs  []run
+}
+
+// Generate returns an instance rooted at the global element declaration
+// named root. Generation stops when ctx is done.
+func Generate(ctx context.Context, rt *runtime.Schema, root xml.Name, opts Options) ([]byte, error) {
+	g, n, err := generate(ctx, rt, root, opts)
+	if err != nil {
+		return nil, err
+	}
+	return g.write(n), nil
+}
+
+func generate(ctx context.Context, rt *runtime.Schema, root xml.Name, opts Options) (*generator, *node, error) {
+	if rt == nil {
+		return nil, nil, xsderrors.InternalInvariant("sample generation requires a compiled engine")
+	}
+	if ctx == nil {
+		return nil, nil, xsderrors.Validation(xsderrors.CodeValidationOption, 0, 0, "", "context is nil")
+	}
+	id, ok := globalElement(rt, root)
+	if !ok {
+		return nil, nil, xsderrors.Validation(xsderrors.CodeValidationRoot, 0, 0, "", "no global element declaration for "+clark(root))
+	}
+	g := &generator{
+		ctx:    ctx,
+		rt:     rt,
+		opts:   opts,
+		counts: make(map[valueKey]int),
+		pools:  make(map[runtime.SimpleTypeID]*valuePool),
+		active: make(map[runtime.TypeID]int),
+		bound:  map[string]string{"xml": vocab.XMLNamespaceURI},
+	}
+	if opts.Choice == ChoiceRandom {
+		g.rand = rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)) //nolint:gosec // Samples need reproducible, not secure, randomness.
+	}
+	n, err := g.element(id, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, n, nil
+}
+
+// canceled reports a done context as a cancellation diagnostic.
+func (g *generator) canceled() error {
+	if cause := context.Cause(g.ctx); cause != nil {
+		return xsderrors.Canceled(xsderrors.CodeValidationCanceled, "sample generation canceled", cause)
+	}
+	return nil
+}
+
+func globalElement(rt *runtime.Schema, name xml.Name) (runtime.ElementID, bool) {
+	q, ok := rt.LookupQName(name.Space, name.Local)
+	if !ok {
+		return runtime.NoElement, false
+	}
+	for global, id := range rt.GlobalElements() {
+		if global == q {
+			return id, true
+		}
+	}
+	return runtime.NoElement, false
+}
+
+func (g *generator) name(q runtime.QName) xml.Name {
+	expanded := g.rt.ExpandedName(q)
+	return xml.Name{Space: expanded.Namespace, Local: expanded.Local}
+}
+
+// pick returns an index in [0, n) drawn from the seeded generator, or 0 when
+// generation is deterministic.
+func (g *generator) pick(n int) int {
+	if g.rand == nil || n <= 1 {
+		return 0
+	}
+	return g.rand.IntN(n)
+}
+
+// write serializes n with every namespace declared on the root element.
+// Prefixes bound for QName values are declared as the schema spelled them;
+// other namespaces get xsi or generated ns1, ns2, ... prefixes.
+func (g *generator) write(n *node) []byte {
+	g.prefixes = map[string]string{"": "", vocab.XMLNamespaceURI: "xml"}
+	g.decls, g.nsCount = g.decls[:0], 0
+	for _, prefix := range slices.Sorted(maps.Keys(g.bound)) {
+		ns := g.bound[prefix]
+		if prefix == "xml" {
+			continue
+		}
+		if _, ok := g.prefixes[ns]; !ok {
+			g.prefixes[ns] = prefix
+		}
+		g.decls = append(g.decls, xml.Attr{Name: xml.Name{Local: prefix}, Value: ns})
+	}
+	g.collectNamespaces(n)
+	var b bytes.Buffer
+	g.writeNode(&b, n, 0)
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+func (g *generator) collectNamespaces(n *node) {
+	g.declare(n.name.Space)
+	for _, a := range n.attrs {
+		g.declare(a.name.Space)
+		if a.qname.Local != "" {
+			g.declare(a.qname.Space)
+		}
+	}
+	for _, child := range n.children {
+		g.collectNamespaces(child)
+	}
+}
+
+func (g *generator) declare(ns string) {
+	if _, ok := g.prefixes[ns]; ok {
+		return
+	}
+	prefix := "xsi"
+	if _, taken := g.bound[prefix]; taken || ns != vocab.XSINamespaceURI {
+		for {
+			g.nsCount++
+			prefix = "ns" + strconv.Itoa(g.nsCount)
+			if _, taken := g.bound[prefix]; !taken {
+				break
+			}
+		}
+	}
+	g.prefixes[ns] = prefix
+	g.decls = append(g.decls, xml.Attr{Name: xml.Name{Local: prefix}, Value: ns})
+}
+
+func (g *generator) qualified(name xml.Name) string {
+	if prefix := g.prefixes[name.Space]; prefix != "" {
+		return prefix + ":" + name.Local
+	}
+	return name.Local
+}
+
+func (g *generator) writeNode(b *bytes.Buffer, n *node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	b.WriteString(indent)
+	b.WriteByte('<')
+	b.WriteString(g.qualified(n.name))
+	if depth == 0 {
+		for _, decl := range g.decls {
+			b.WriteString(" xmlns:")
+			b.WriteString(decl.Name.Local)
+			b.WriteString(`="`)
+			writeEscaped(b, decl.Value)
+			b.WriteByte('"')
+		}
+	}
+	for _, a := range n.attrs {
+		value := a.value
+		if a.qname.Local != "" {
+			value = g.qualified(a.qname)
+		}
+		b.WriteByte(' ')
+		b.WriteString(g.qualified(a.name))
+		b.WriteString(`="`)
+		writeEscaped(b, value)
+		b.WriteByte('"')
+	}
+	switch {
+	case len(n.children) != 0:
+		b.WriteString(">\n")
+		for _, child := range n.children {
+			g.writeNode(b, child, depth+1)
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent)
+	case n.text != "":
+		b.WriteByte('>')
+		writeEscaped(b, n.text)
+	default:
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString("</")
+	b.WriteString(g.qualified(n.name))
+	b.WriteByte('>')
+}
+
+func writeEscaped(b *bytes.Buffer, s string) {
+	// EscapeText only fails when the writer does.
+	_ = xml.EscapeText(b, []byte(s))
+}
+
+func clark(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+func unsupported(msg string) error {
+	return xsderrors.Unsupported(xsderrors.CodeUnsupportedSample, msg)
+}
+
+func missingComponent(kind string) error {
+	return xsderrors.InternalInvariant("sample generation references missing " + kind)
+}
@@ -0,0 +1,531 @@
+package sample
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/regex"
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/vocab"
+)
+
+type valueKind uint8
+
+const (
+	keyElement valueKind = iota
+	keyAttribute
+	keyID
+)
+
+// valueKey identifies the declaration a value is generated for. Successive
+// values for the same key differ where the type allows, so repeated
+// identity-constraint fields stay distinct, while keyrefs generated for
+// another declaration of the same type start from the same values as the
+// keys they refer to.
+type valueKey struct {
+	name runtime.QName
+	id   uint32
+	kind valueKind
+}
+
+// valuePool caches the valid values found for a simple type, in the order
+// they were first found.
+type valuePool struct {
+	values    []string
+	exhausted bool
+}
+
+// firstPoolSize is the number of valid values sought for a type before more
+// are needed.
+const firstPoolSize = 4
+
+func (g *generator) value(id runtime.SimpleTypeID, key valueKey) (string, error) {
+	if g.builtinName(id) == "ID" {
+		// ID values are unique across the whole document.
+		key = valueKey{kind: keyID}
+	}
+	k := g.counts[key]
+	g.counts[key]++
+	values := g.validValues(id, k+1)
+	if len(values) == 0 {
+		return "", unsupported("no generated value satisfies the facets of simple type " + g.typeLabel(id))
+	}
+	return values[k%len(values)], nil
+}
+
+// validValues returns up to want distinct values of type id. Values keep
+// their positions as the pool grows, so the n-th value of a type is stable.
+func (g *generator) validValues(id runtime.SimpleTypeID, want int) []string {
+	pool := g.pools[id]
+	if pool == nil {
+		pool = &valuePool{}
+		g.pools[id] = pool
+	}
+	for len(pool.values) < want && !pool.exhausted {
+		target := max(want, firstPoolSize, 2*len(pool.values))
+		seen := make(map[string]bool, target)
+		for _, v := range pool.values {
+			seen[v] = true
+		}
+		for _, c := range g.candidates(id, target) {
+			if len(pool.values) == target {
+				break
+			}
+			if !seen[c] && g.valid(id, c) {
+				seen[c] = true
+				pool.values = append(pool.values, c)
+			}
+		}
+		pool.exhausted = len(pool.values) < target
+	}
+	return pool.values
+}
+
+func (g *generator) valid(id runtime.SimpleTypeID, lexical string) bool {
+	_, err := g.rt.ValidateSimpleValue(id, lexical, g.resolveQName, 0)
+	return err == nil
+}
+
+// resolveQName resolves the QName values the generator writes: unprefixed
+// names, which are in no namespace because no default namespace is
+// declared, and names whose prefix is bound on the root element.
+func (g *generator) resolveQName(lexical string) (string, string, bool) {
+	prefix, local, ok := strings.Cut(lexical, ":")
+	if !ok {
+		return vocab.EmptyNamespaceURI, lexical, true
+	}
+	ns, ok := g.bound[prefix]
+	return ns, local, ok
+}
+
+// bind binds the prefixes of QName enumeration values to the namespaces the
+// schema resolved them to, reporting false when a prefix is already bound to
+// another namespace.
+func (g *generator) bind(names []runtime.ResolvedValueName) bool {
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name.Lexical, ":")
+		if !ok {
+			continue
+		}
+		if ns, bound := g.bound[prefix]; bound && ns != name.NS {
+			return false
+		}
+	}
+	for _, name := range names {
+		if prefix, _, ok := strings.Cut(name.Lexical, ":"); ok {
+			g.bound[prefix] = name.NS
+		}
+	}
+	return true
+}
+
+// candidates returns lexical forms that may be values of type id, the most
+// typical first. Callers keep those the type accepts.
+func (g *generator) candidates(id runtime.SimpleTypeID, want int) []string {
+	st, ok := g.rt.SimpleTypeComponent(id)
+	if !ok {
+		return nil
+	}
+	if values := st.Facets.Enumeration; len(values) != 0 {
+		names := st.Facets.EnumerationNames
+		if g.rand != nil {
+			g.rand.Shuffle(len(values), func(i, j int) {
+				values[i], values[j] = values[j], values[i]
+				if names != nil {
+					names[i], names[j] = names[j], names[i]
+				}
+			})
+		}
+		if names == nil {
+			return values
+		}
+		out := make([]string, 0, len(values))
+		for i, v := range values {
+			if g.bind(names[i]) {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	out := g.patternCandidates(st.Facets, want)
+	switch st.Variety {
+	case runtime.SimpleVarietyList:
+		return append(out, g.listCandidates(st, want)...)
+	case runtime.SimpleVarietyUnion:
+		for _, member := range g.unionMembers(id) {
+			out = append(out, g.validValues(member, want)...)
+		}
+		return out
+	}
+	lo, hi := lengthBounds(st.Facets, 1)
+	switch st.Primitive {
+	case runtime.PrimitiveBoolean:
+		out = append(out, "true", "false", "1", "0")
+	case runtime.PrimitiveDecimal, runtime.PrimitiveFloat, runtime.PrimitiveDouble:
+		out = append(out, numberCandidates(st.Facets, want)...)
+	case runtime.PrimitiveDuration:
+		out = append(out, inclusiveBounds(st.Facets)...)
+		years := 0
+		for _, bound := range []string{st.Facets.MinInclusive, st.Facets.MinExclusive} {
+			if y, ok := durationYears(bound); ok {
+				years = max(years, y)
+			}
+		}
+		for i := 1; i <= want; i++ {
+			n := strconv.Itoa(i)
+			out = append(out, "P"+n+"D", "PT"+n+"H", "P"+n+"M", "P"+n+"Y", "-P"+n+"D", "-P"+n+"M")
+			if years > 0 {
+				out = append(out, "P"+strconv.Itoa(years+i)+"Y")
+			}
+		}
+	case runtime.PrimitiveDateTime, runtime.PrimitiveTime, runtime.PrimitiveDate,
+		runtime.PrimitiveGYearMonth, runtime.PrimitiveGYear, runtime.PrimitiveGMonthDay,
+		runtime.PrimitiveGDay, runtime.PrimitiveGMonth:
+		out = append(out, inclusiveBounds(st.Facets)...)
+		out = append(out, temporalCandidates(st.Primitive, st.Facets, want)...)
+	case runtime.PrimitiveHexBinary, runtime.PrimitiveBase64Binary:
+		for i := range want {
+			octets := make([]byte, lo)
+			if len(octets) != 0 {
+				octets[len(octets)-1] = byte(i)
+			} else if i > 0 {
+				break
+			}
+			if st.Primitive == runtime.PrimitiveHexBinary {
+				out = append(out, strings.ToUpper(hex.EncodeToString(octets)))
+			} else {
+				out = append(out, base64.StdEncoding.EncodeToString(octets))
+			}
+		}
+	case runtime.PrimitiveAnyURI:
+		for i := range want {
+			out = append(out, fitLength("urn:sample"+suffix(i), lo, hi))
+		}
+	default:
+		for i := range want {
+			out = append(out, fitLength("sample"+suffix(i), lo, hi), fitLength("sample"+letters(i), lo, hi))
+			if lo > 8 {
+				// Tokens such as xs:language limit subtags to eight letters.
+				out = append(out, fitLength(strings.Repeat("sampling-", lo/9+1)+letters(i+1), lo, hi))
+			}
+		}
+	}
+	return out
+}
+
+// durationYears returns the years of a positive duration bound.
+func durationYears(lexical string) (int, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(lexical), "P")
+	if !ok {
+		return 0, false
+	}
+	digits, _, ok := strings.Cut(rest, "Y")
+	if !ok {
+		return 0, false
+	}
+	y, err := strconv.Atoi(digits)
+	return y, err == nil
+}
+
+func (g *generator) listCandidates(st runtime.SimpleTypeComponent, want int) []string {
+	lo, _ := lengthBounds(st.Facets, 1)
+	items := g.validValues(st.ListItem, want+lo)
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]string, 0, want)
+	for i := range want {
+		list := make([]string, lo)
+		for j := range list {
+			list[j] = items[(i+j)%len(items)]
+		}
+		out = append(out, strings.Join(list, " "))
+	}
+	return out
+}
+
+// unionMembers returns the member types of union id. Restrictions of a
+// union share the members of their base.
+func (g *generator) unionMembers(id runtime.SimpleTypeID) []runtime.SimpleTypeID {
+	for id != runtime.NoSimpleType {
+		st, ok := g.rt.SimpleTypeComponent(id)
+		if !ok {
+			return nil
+		}
+		if len(st.Members) != 0 {
+			return st.Members
+		}
+		id = st.Base
+	}
+	return nil
+}
+
+// patternTries is the number of strings drawn from a pattern per wanted
+// value.
+const patternTries = 4
+
+// patternCandidates returns strings generated from the patterns of the most
+// derived pattern step, which every value must also match.
+func (g *generator) patternCandidates(facets runtime.FacetComponent, want int) []string {
+	if len(facets.Patterns) == 0 {
+		return nil
+	}
+	var out []string
+	for _, source := range facets.Patterns[len(facets.Patterns)-1] {
+		re, err := regex.Compile(source)
+		if err != nil {
+			continue
+		}
+		// Patterns often admit strings their primitive rejects, such as
+		// month 00, so several strings are drawn per wanted value.
+		for i := range patternTries * want {
+			var pick func(int) int
+			switch {
+			case g.rand != nil:
+				pick = g.pick
+			case i > 0:
+				pick = lcgPick(uint64(i))
+			}
+			if s, ok := re.Generate(pick); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// lcgPick returns a deterministic pick function seeded by seed.
+func lcgPick(seed uint64) func(int) int {
+	state := seed
+	return func(n int) int {
+		state = state*6364136223846793005 + 1442695040888963407
+		return int((state >> 33) % uint64(n)) //nolint:gosec // n is a small positive count.
+	}
+}
+
+// lengthBounds returns the length bounds of a type; hi is -1 when unbounded
+// and lo is at least least unless the type caps the length below it.
+func lengthBounds(f runtime.FacetComponent, least int) (int, int) {
+	lo, hi := least, -1
+	if f.Present&runtime.FacetMinLength != 0 {
+		lo = max(lo, int(f.MinLength))
+	}
+	if f.Present&runtime.FacetMaxLength != 0 {
+		hi = int(f.MaxLength)
+		lo = min(lo, hi)
+	}
+	if f.Present&runtime.FacetLength != 0 {
+		lo, hi = int(f.Length), int(f.Length)
+	}
+	return lo, hi
+}
+
+// fitLength pads or trims s to a length within [lo, hi], keeping its end so
+// distinguishing suffixes survive.
+func fitLength(s string, lo, hi int) string {
+	if n := len(s); n < lo {
+		s = strings.Repeat("x", lo-n) + s
+	}
+	if hi >= 0 && len(s) > hi {
+		s = s[len(s)-hi:]
+	}
+	return s
+}
+
+func suffix(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+// letters encodes i with lowercase letters, for types that reject digits.
+func letters(i int) string {
+	if i == 0 {
+		return ""
+	}
+	var b []byte
+	for ; i > 0; i /= 26 {
+		b = append([]byte{byte('a' + i%26)}, b...)
+	}
+	return string(b)
+}
+
+func inclusiveBounds(f runtime.FacetComponent) []string {
+	var out []string
+	for _, bound := range []string{f.MinInclusive, f.MaxInclusive} {
+		if bound != "" {
+			out = append(out, bound)
+		}
+	}
+	return out
+}
+
+// numberCandidates returns small integers of both signs, then integers
+// counting up from the lower bound and down from the upper bound, then
+// fractions between the bounds.
+func numberCandidates(f runtime.FacetComponent, want int) []string {
+	lo, loExclusive := parseBound(f.MinInclusive, f.MinExclusive, true)
+	hi, hiExclusive := parseBound(f.MaxInclusive, f.MaxExclusive, false)
+	out := make([]string, 0, 4*want)
+	for i := range want {
+		out = append(out, strconv.Itoa(i), strconv.Itoa(i+1), strconv.Itoa(-i-1))
+	}
+	if lo != nil {
+		start := ceil(lo)
+		if loExclusive && new(big.Rat).SetInt(start).Cmp(lo) == 0 {
+			start.Add(start, big.NewInt(1))
+		}
+		for i := range want {
+			out = append(out, new(big.Int).Add(start, big.NewInt(int64(i))).String())
+		}
+	}
+	if hi != nil {
+		start := floor(hi)
+		if hiExclusive && new(big.Rat).SetInt(start).Cmp(hi) == 0 {
+			start.Sub(start, big.NewInt(1))
+		}
+		for i := range want {
+			out = append(out, new(big.Int).Sub(start, big.NewInt(int64(i))).String())
+		}
+	}
+	if lo != nil && hi != nil {
+		digits := 6
+		if f.Present&runtime.FacetFractionDigits != 0 {
+			digits = min(digits, int(f.FractionDigits))
+		}
+		span := new(big.Rat).Sub(hi, lo)
+		for i := 1; i <= want; i++ {
+			step := new(big.Rat).Mul(span, big.NewRat(int64(i), int64(want+1)))
+			out = append(out, decimalString(new(big.Rat).Add(lo, step), digits))
+		}
+	}
+	return out
+}
+
+// parseBound returns the tighter of an inclusive and an exclusive bound as
+// a rational, reporting whether it is exclusive. lower selects which way is
+// tighter. Missing or special float bounds yield nil.
+func parseBound(inclusive, exclusive string, lower bool) (*big.Rat, bool) {
+	in, inOK := new(big.Rat).SetString(strings.TrimSpace(inclusive))
+	ex, exOK := new(big.Rat).SetString(strings.TrimSpace(exclusive))
+	switch {
+	case inOK && exOK:
+		c := in.Cmp(ex)
+		if !lower {
+			c = -c
+		}
+		if c > 0 {
+			return in, false
+		}
+		return ex, true
+	case inOK:
+		return in, false
+	case exOK:
+		return ex, true
+	default:
+		return nil, false
+	}
+}
+
+func floor(r *big.Rat) *big.Int {
+	// Euclidean division by the positive denominator rounds down.
+	q, _ := new(big.Int).DivMod(r.Num(), r.Denom(), new(big.Int))
+	return q
+}
+
+func ceil(r *big.Rat) *big.Int {
+	q := floor(r)
+	if !r.IsInt() {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+func decimalString(r *big.Rat, digits int) string {
+	s := r.FloatString(digits)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// temporalCandidates returns date and time values around the year of the
+// bounds, or 2000, each without and with a UTC timezone.
+func temporalCandidates(kind runtime.PrimitiveKind, f runtime.FacetComponent, want int) []string {
+	year := 2000
+	for _, bound := range []string{f.MinInclusive, f.MinExclusive, f.MaxInclusive, f.MaxExclusive} {
+		if y, ok := boundYear(bound); ok {
+			year = y
+			break
+		}
+	}
+	var out []string
+	for _, offset := range []int{0, 1, -1, 10, -10} {
+		y := year + offset
+		for i := range want {
+			month, day, hour := 1+i/28%12, 1+i%28, i%24
+			var s string
+			switch kind {
+			case runtime.PrimitiveDateTime:
+				s = fmt.Sprintf("%04d-%02d-%02dT%02d:00:00", y, month, day, hour)
+			case runtime.PrimitiveTime:
+				s = fmt.Sprintf("%02d:%02d:00", hour, i/24%60)
+			case runtime.PrimitiveDate:
+				s = fmt.Sprintf("%04d-%02d-%02d", y, month, day)
+			case runtime.PrimitiveGYearMonth:
+				s = fmt.Sprintf("%04d-%02d", y+i/12, 1+i%12)
+			case runtime.PrimitiveGYear:
+				s = fmt.Sprintf("%04d", y+i)
+			case runtime.PrimitiveGMonthDay:
+				s = fmt.Sprintf("--%02d-%02d", month, day)
+			case runtime.PrimitiveGDay:
+				s = fmt.Sprintf("---%02d", 1+i%28)
+			case runtime.PrimitiveGMonth:
+				s = fmt.Sprintf("--%02d", 1+i%12)
+			}
+			out = append(out, s, s+"Z")
+		}
+	}
+	return out
+}
+
+// boundYear returns the year of a date or time bound lexical form.
+func boundYear(lexical string) (int, bool) {
+	lexical = strings.TrimPrefix(strings.TrimSpace(lexical), "-")
+	digits := len(lexical) - len(strings.TrimLeft(lexical, "0123456789"))
+	if digits != 4 {
+		return 0, false
+	}
+	y, err := strconv.Atoi(lexical[:digits])
+	return y, err == nil
+}
+
+// builtinName returns the local name of the nearest built-in ancestor of id,
+// id included.
+func (g *generator) builtinName(id runtime.SimpleTypeID) string {
+	for id != runtime.NoSimpleType {
+		if name, ok := g.rt.TypeName(runtime.SimpleRef(id)); ok {
+			if expanded := g.name(name); expanded.Space == vocab.XSDNamespaceURI {
+				return expanded.Local
+			}
+		}
+		st, ok := g.rt.SimpleTypeComponent(id)
+		if !ok {
+			return ""
+		}
+		id = st.Base
+	}
+	return ""
+}
+
+func (g *generator) typeLabel(id runtime.SimpleTypeID) string {
+	if name, ok := g.rt.TypeName(runtime.SimpleRef(id)); ok {
+		return clark(g.name(name))
+	}
+	return "(anonymous)"
+}
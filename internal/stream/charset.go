@@ -0,0 +1,189 @@
+package stream
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset decodes characters of one non-UTF-8 document encoding.
+type Charset interface {
+	// DecodeRune decodes the first character in p and returns it with its
+	// encoded width in bytes. It returns width 0 when p ends inside a
+	// character and a negative rune when the bytes are not a valid character.
+	DecodeRune(p []byte) (r rune, width int)
+}
+
+// CharsetLookup resolves a declared encoding name to a charset. It is
+// consulted before the built-in charsets.
+type CharsetLookup func(name string) (Charset, bool)
+
+// UnsupportedEncodingError reports a declared encoding without a charset.
+type UnsupportedEncodingError struct {
+	Encoding string
+}
+
+func (e UnsupportedEncodingError) Error() string {
+	return "XML encoding " + e.Encoding + " is not supported"
+}
+
+const (
+	utf16Label        = "UTF-16"
+	utf16BigEndian    = "UTF-16BE"
+	utf16LittleEndian = "UTF-16LE"
+)
+
+var (
+	utf8Labels          = []string{"UTF-8", "UTF8"}
+	latin1Labels        = []string{"ISO-8859-1", "ISO_8859-1", "ISO8859-1", "latin1", "l1", "IBM819", "CP819", "ISO-IR-100", "csISOLatin1"}
+	windows1252Labels   = []string{"windows-1252", "cp1252", "x-cp1252"}
+	asciiLabels         = []string{"US-ASCII", "ASCII", "ANSI_X3.4-1968", "ISO646-US", "csASCII"}
+	windows1252Specials = [32]rune{
+		0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+		0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+		0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+		0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+	}
+)
+
+func hasLabel(labels []string, name string) bool {
+	return slices.ContainsFunc(labels, func(label string) bool {
+		return strings.EqualFold(label, name)
+	})
+}
+
+// IsUTF8Encoding reports whether name is a UTF-8 encoding label.
+func IsUTF8Encoding(name string) bool {
+	return hasLabel(utf8Labels, name)
+}
+
+// lookupCharset resolves name through custom and then the built-in
+// single-byte charsets. UTF-16 is selected only from byte order detection.
+func lookupCharset(name string, custom CharsetLookup) (Charset, bool) {
+	if custom != nil {
+		if charset, ok := custom(name); ok && charset != nil {
+			return charset, true
+		}
+	}
+	switch {
+	case hasLabel(latin1Labels, name):
+		return latin1Charset{}, true
+	case hasLabel(windows1252Labels, name):
+		return windows1252Charset{}, true
+	case hasLabel(asciiLabels, name):
+		return asciiCharset{}, true
+	default:
+		return nil, false
+	}
+}
+
+// utf16EncodingMatches reports whether a declaration inside a UTF-16
+// document names a UTF-16 encoding consistent with the detected byte order.
+func utf16EncodingMatches(name string, bigEndian bool) bool {
+	switch {
+	case name == "", strings.EqualFold(name, utf16Label):
+		return true
+	case strings.EqualFold(name, utf16BigEndian):
+		return bigEndian
+	case strings.EqualFold(name, utf16LittleEndian):
+		return !bigEndian
+	default:
+		return false
+	}
+}
+
+// detectUTF16 recognizes a UTF-16 byte order mark or a UTF-16 encoded "<?"
+// at the start of a document. bom is the number of mark bytes to skip.
+func detectUTF16(peek []byte) (bigEndian bool, bom int, ok bool) {
+	switch {
+	case len(peek) >= 2 && peek[0] == 0xFE && peek[1] == 0xFF:
+		return true, 2, true
+	case len(peek) >= 2 && peek[0] == 0xFF && peek[1] == 0xFE:
+		return false, 2, true
+	case len(peek) >= 4 && peek[0] == 0 && peek[1] == '<' && peek[2] == 0 && peek[3] == '?':
+		return true, 0, true
+	case len(peek) >= 4 && peek[0] == '<' && peek[1] == 0 && peek[2] == '?' && peek[3] == 0:
+		return false, 0, true
+	default:
+		return false, 0, false
+	}
+}
+
+// utf8Charset decodes UTF-8 input for XML 1.1 documents, whose line ends and
+// restricted characters the transcoder filters.
+type utf8Charset struct{}
+
+func (utf8Charset) DecodeRune(p []byte) (rune, int) {
+	if !utf8.FullRune(p) {
+		return 0, 0
+	}
+	r, width := utf8.DecodeRune(p)
+	if r == utf8.RuneError && width == 1 {
+		return -1, 1
+	}
+	return r, width
+}
+
+type latin1Charset struct{}
+
+func (latin1Charset) DecodeRune(p []byte) (rune, int) {
+	if len(p) == 0 {
+		return 0, 0
+	}
+	return rune(p[0]), 1
+}
+
+type windows1252Charset struct{}
+
+func (windows1252Charset) DecodeRune(p []byte) (rune, int) {
+	if len(p) == 0 {
+		return 0, 0
+	}
+	if c := p[0]; c >= 0x80 && c < 0xA0 {
+		return windows1252Specials[c-0x80], 1
+	}
+	return rune(p[0]), 1
+}
+
+type asciiCharset struct{}
+
+func (asciiCharset) DecodeRune(p []byte) (rune, int) {
+	if len(p) == 0 {
+		return 0, 0
+	}
+	if p[0] >= 0x80 {
+		return -1, 1
+	}
+	return rune(p[0]), 1
+}
+
+type utf16Charset struct {
+	bigEndian bool
+}
+
+func (c utf16Charset) unit(p []byte) rune {
+	if c.bigEndian {
+		return rune(p[0])<<8 | rune(p[1])
+	}
+	return rune(p[1])<<8 | rune(p[0])
+}
+
+func (c utf16Charset) DecodeRune(p []byte) (rune, int) {
+	if len(p) < 2 {
+		return 0, 0
+	}
+	u := c.unit(p)
+	switch {
+	case u < 0xD800 || u > 0xDFFF:
+		return u, 2
+	case u > 0xDBFF:
+		return -1, 2
+	case len(p) < 4:
+		return 0, 0
+	}
+	low := c.unit(p[2:])
+	if low < 0xDC00 || low > 0xDFFF {
+		return -1, 2
+	}
+	return 0x10000 + (u-0xD800)<<10 + (low - 0xDC00), 4
+}
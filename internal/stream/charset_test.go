@@ -0,0 +1,187 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// transcodedSummary returns the first attribute value, the concatenated
+// character data, and the position of the element named c.
+func transcodedSummary(t *testing.T, input []byte, limits Limits) (string, string, [2]int) {
+	t.Helper()
+	var parser Parser
+	names, values := NewCache(), NewCache()
+	if err := parser.ResetWithLimits(strings.NewReader(string(input)), &names, &values, limits); err != nil {
+		t.Fatalf("Parser.Reset() error = %v", err)
+	}
+	defer parser.Detach()
+	var attr string
+	var text strings.Builder
+	var pos [2]int
+	for {
+		tok, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			return attr, text.String(), pos
+		}
+		if err != nil {
+			t.Fatalf("Parser.Next() error = %v", err)
+		}
+		switch tok.Kind {
+		case KindStart:
+			start := tok.Start.XMLStartElement()
+			if attr == "" && len(start.Attr) != 0 {
+				attr = start.Attr[0].Value
+			}
+			if start.Name.Local == "c" {
+				pos = [2]int{tok.Line, tok.Column}
+			}
+		case KindCharData:
+			text.Write(tok.Data)
+		}
+	}
+}
+
+func encodeUTF16(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	out := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+type euroCharset struct{}
+
+// DecodeRune decodes ISO-8859-15 restricted to its euro sign difference.
+func (euroCharset) DecodeRune(p []byte) (rune, int) {
+	if len(p) == 0 {
+		return 0, 0
+	}
+	if p[0] == 0xA4 {
+		return '€', 1
+	}
+	return rune(p[0]), 1
+}
+
+func TestParserTranscodesDeclaredEncodings(t *testing.T) {
+	latin1 := `<?xml version="1.0" encoding="ISO-8859-1"?>` + "<r a=\"\xe9\">\n<c/>caf\xe9</r>"
+	cp1252 := `<?xml version="1.0" encoding="windows-1252"?>` + "<r a=\"\x80\">\n<c/>\x93q\x94</r>"
+	unicode := `<?xml version="1.0" encoding="UTF-16"?>` + "<r a=\"\U0001F600\">\n<c/>café</r>"
+	tests := []struct {
+		name     string
+		input    []byte
+		charsets CharsetLookup
+		attr     string
+		text     string
+	}{
+		{name: "ISO-8859-1", input: []byte(latin1), attr: "é", text: "\ncafé"},
+		{name: "windows-1252", input: []byte(cp1252), attr: "€", text: "\n“q”"},
+		{name: "UTF-16LE BOM", input: encodeUTF16(unicode, false, true), attr: "\U0001F600", text: "\ncafé"},
+		{name: "UTF-16BE BOM", input: encodeUTF16(unicode, true, true), attr: "\U0001F600", text: "\ncafé"},
+		{name: "UTF-16BE declaration", input: encodeUTF16(unicode, true, false), attr: "\U0001F600", text: "\ncafé"},
+		{
+			name:  "registered charset",
+			input: []byte(`<?xml version="1.0" encoding="ISO-8859-15"?>` + "<r a=\"\xa4\">\n<c/>\xe9</r>"),
+			charsets: func(name string) (Charset, bool) {
+				return euroCharset{}, strings.EqualFold(name, "iso-8859-15")
+			},
+			attr: "€",
+			text: "\né",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attr, text, _ := transcodedSummary(t, test.input, Limits{Charsets: test.charsets})
+			if attr != test.attr || text != test.text {
+				t.Fatalf("decoded attr/text = %q/%q, want %q/%q", attr, text, test.attr, test.text)
+			}
+		})
+	}
+}
+
+func TestParserTranscodedPositionsReferToOriginalBytes(t *testing.T) {
+	const body = "<r>\n  %s<c/></r>"
+	_, _, ascii := transcodedSummary(t, []byte(`<?xml version="1.0"?>`+strings.Replace(body, "%s", "ab", 1)), Limits{})
+	_, _, latin1 := transcodedSummary(t, []byte(`<?xml version="1.0" encoding="latin1"?>`+strings.Replace(body, "%s", "\xe9\xe9", 1)), Limits{})
+	if latin1 != ascii {
+		t.Fatalf("ISO-8859-1 position = %v, want %v", latin1, ascii)
+	}
+	utf16Doc := encodeUTF16(`<?xml version="1.0" encoding="UTF-16"?>`+strings.Replace(body, "%s", "é\U0001F600", 1), false, true)
+	_, _, wide := transcodedSummary(t, utf16Doc, Limits{})
+	// "é" takes two bytes and the surrogate pair four, against two ASCII bytes.
+	if want := [2]int{ascii[0], 2*ascii[1] + 2}; wide != want {
+		t.Fatalf("UTF-16 position = %v, want %v", wide, want)
+	}
+}
+
+func TestParserRejectsInvalidTranscodedInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "ASCII high byte", input: []byte(`<?xml version="1.0" encoding="US-ASCII"?><r>` + "\xe9</r>")},
+		{name: "unpaired surrogate", input: append(encodeUTF16("<r>", false, true), 0x00, 0xD8, '<', 0)},
+		{name: "truncated UTF-16", input: append(encodeUTF16("<r></r>", true, true), 0)},
+		{name: "UTF-16 declares other encoding", input: encodeUTF16(`<?xml version="1.0" encoding="ISO-8859-1"?><r/>`, false, true)},
+		{name: "UTF-8 BOM declares other encoding", input: []byte("\ufeff" + `<?xml version="1.0" encoding="ISO-8859-1"?><r/>`)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var parser Parser
+			names, values := NewCache(), NewCache()
+			err := parser.Reset(strings.NewReader(string(test.input)), &names, &values)
+			for err == nil {
+				_, err = parser.Next()
+			}
+			if errors.Is(err, io.EOF) {
+				t.Fatal("parser accepted invalid encoded input")
+			}
+		})
+	}
+}
+
+func TestParserInputByteLimitCountsRawTranscodedBytes(t *testing.T) {
+	doc := encodeUTF16(`<root>text</root>`, false, true)
+	if err := consumeWithInputLimit(strings.NewReader(string(doc)), int64(len(doc))); !errors.Is(err, io.EOF) {
+		t.Fatalf("consumeWithInputLimit(exact) error = %v, want EOF", err)
+	}
+	if err := consumeWithInputLimit(strings.NewReader(string(doc)), int64(len(doc)-1)); !IsInputLimit(err) {
+		t.Fatalf("consumeWithInputLimit(over) error = %v, want input limit", err)
+	}
+}
+
+func TestParserTranscodesAcrossReadChunks(t *testing.T) {
+	text := strings.Repeat("café \U0001F600 ", 20000)
+	doc := encodeUTF16("<r>"+text+"</r>", true, true)
+	var parser Parser
+	names, values := NewCache(), NewCache()
+	if err := parser.Reset(chunkReader{r: strings.NewReader(string(doc)), n: 7}, &names, &values); err != nil {
+		t.Fatal(err)
+	}
+	var got strings.Builder
+	for {
+		tok, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Parser.Next() error = %v", err)
+		}
+		if tok.Kind == KindCharData {
+			got.Write(tok.Data)
+		}
+	}
+	if got.String() != text {
+		t.Fatalf("decoded text length = %d, want %d", got.Len(), len(text))
+	}
+}
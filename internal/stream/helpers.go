@@ -12,6 +12,7 @@ const (
 	xmlPrefix      = vocab.XMLPrefix
 	xsdAttrVersion = vocab.XSDAttrVersion
 	xmlVersion10   = vocab.XMLVersion10
+	xmlVersion11   = vocab.XMLVersion11
 )
 
 func resetRetainedSlice[T any](s []T) []T {
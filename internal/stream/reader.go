@@ -3,7 +3,7 @@ package stream
 import (
 	"bytes"
 	"errors"
-	"strings"
+	"fmt"
 )
 
 const maxXMLDeclarationPreviewBytes = xmlInputBufferSize
@@ -11,9 +11,6 @@ const maxXMLDeclarationPreviewBytes = xmlInputBufferSize
 // ErrXMLInputNilReader reports a nil XML input reader.
 var ErrXMLInputNilReader = errors.New("xml input reader is nil")
 
-// ErrUnsupportedNonUTF8 reports XML input that declares or uses a non-UTF-8 encoding.
-var ErrUnsupportedNonUTF8 = errors.New("xml input must be UTF-8")
-
 // UnsupportedXMLVersionError reports an XML version this tokenizer does not support.
 type UnsupportedXMLVersionError struct {
 	Version string
@@ -23,33 +20,75 @@ func (e UnsupportedXMLVersionError) Error() string {
 	return "XML version " + e.Version + " is not supported"
 }
 
-func (p *Parser) prepareXMLProlog() error {
+// prepareXMLProlog selects the input encoding from a byte order mark or the
+// XML declaration and rejects unsupported XML versions. Non-UTF-8 input is
+// transcoded from here on; p.encoding records the declared encoding honored.
+func (p *Parser) prepareXMLProlog(limits Limits) error {
+	p.encoding = ""
+	p.xml11 = false
 	peek, err := p.br.ensure(XMLDeclarationPrefixLen)
 	if err != nil && !IsOnlyEOF(err) {
 		return err
 	}
-	if HasUTF8BOM(peek) {
+	utf8BOM := HasUTF8BOM(peek)
+	if utf8BOM {
 		p.br.discardUTF8BOM()
 		peek, err = p.br.ensure(XMLDeclarationPrefixLen)
 		if err != nil && !IsOnlyEOF(err) {
 			return err
 		}
 	}
-	if len(peek) >= 2 {
-		if (peek[0] == 0xFE && peek[1] == 0xFF) || (peek[0] == 0xFF && peek[1] == 0xFE) {
-			return ErrUnsupportedNonUTF8
+	if bigEndian, bom, ok := detectUTF16(peek); ok && !utf8BOM {
+		p.br.decodeWith(utf16Charset{bigEndian: bigEndian}, utf16Label, bom)
+		peek, err = p.br.ensure(XMLDeclarationPrefixLen)
+		if err != nil && !IsOnlyEOF(err) {
+			return err
+		}
+		if StartsXMLDeclaration(peek) {
+			peek = p.peekXMLDeclaration()
+		}
+		enc := DeclaredEncoding(peek)
+		if !utf16EncodingMatches(enc, bigEndian) {
+			return fmt.Errorf("XML encoding %s does not match the UTF-16 byte order", enc)
 		}
+		p.encoding = enc
+		return p.selectXMLVersion(peek, limits.XML11)
 	}
 	if StartsXMLDeclaration(peek) {
 		peek = p.peekXMLDeclaration()
 	}
-	if enc := DeclaredEncoding(peek); enc != "" && !strings.EqualFold(enc, "UTF-8") && !strings.EqualFold(enc, "UTF8") {
-		return ErrUnsupportedNonUTF8
+	if enc := DeclaredEncoding(peek); enc != "" && !IsUTF8Encoding(enc) {
+		if utf8BOM {
+			return fmt.Errorf("XML encoding %s does not match the UTF-8 byte order mark", enc)
+		}
+		charset, ok := lookupCharset(enc, limits.Charsets)
+		if !ok {
+			return UnsupportedEncodingError{Encoding: enc}
+		}
+		p.br.decodeWith(charset, enc, 0)
+		p.encoding = enc
+		peek = p.peekXMLDeclaration()
 	}
-	if version := DeclaredXMLVersion(peek); version != "" && version != xmlVersion10 {
+	return p.selectXMLVersion(peek, limits.XML11)
+}
+
+// selectXMLVersion rejects unsupported declared versions. An allowed XML 1.1
+// declaration routes the remaining input through the decoder, which
+// normalizes NEL and LS line ends and rejects literal RestrictedChars.
+func (p *Parser) selectXMLVersion(peek []byte, allowXML11 bool) error {
+	switch version := DeclaredXMLVersion(peek); {
+	case version == "", version == xmlVersion10:
+		return nil
+	case version == xmlVersion11 && allowXML11:
+		if p.br.dec == nil {
+			p.br.decodeWith(utf8Charset{}, utf8Labels[0], 0)
+		}
+		p.br.filterXML11()
+		p.xml11 = true
+		return nil
+	default:
 		return UnsupportedXMLVersionError{Version: version}
 	}
-	return nil
 }
 
 func (p *Parser) peekXMLDeclaration() []byte {
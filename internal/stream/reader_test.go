@@ -16,9 +16,8 @@ func TestParserResetClassifiesXMLProlog(t *testing.T) {
 		xml  string
 		want error
 	}{
-		{name: "UTF-16 BE", xml: string([]byte{0xFE, 0xFF}) + `<r/>`, want: ErrUnsupportedNonUTF8},
-		{name: "UTF-16 LE", xml: string([]byte{0xFF, 0xFE}) + `<r/>`, want: ErrUnsupportedNonUTF8},
-		{name: "declared encoding", xml: `<?xml version="1.0" encoding="latin1"?><r/>`, want: ErrUnsupportedNonUTF8},
+		{name: "declared encoding", xml: `<?xml version="1.0" encoding="EBCDIC-US"?><r/>`, want: UnsupportedEncodingError{Encoding: "EBCDIC-US"}},
+		{name: "decoded XML 1.1", xml: `<?xml version="1.1" encoding="latin1"?><r/>`, want: UnsupportedXMLVersionError{Version: "1.1"}},
 		{name: "XML 1.1", xml: `<?xml version="1.1"?><r/>`, want: UnsupportedXMLVersionError{Version: "1.1"}},
 	}
 	for _, test := range tests {
@@ -65,12 +64,15 @@ func TestParserResetBOMPreservesFullDeclarationPreview(t *testing.T) {
 	prefix := `<?xml version="1.0" `
 	suffix := `encoding="ISO-8859-1"?>`
 	declaration := prefix + strings.Repeat(" ", xmlInputBufferSize-len(prefix)-len(suffix)) + suffix
-	for _, bom := range []string{"", "\ufeff"} {
-		var parser Parser
-		err := parser.Reset(strings.NewReader(bom+declaration+`<r/>`), nil, nil)
-		if !errors.Is(err, ErrUnsupportedNonUTF8) {
-			t.Fatalf("BOM %t: Parser.Reset() error = %v, want %v", bom != "", err, ErrUnsupportedNonUTF8)
-		}
+	var parser Parser
+	if err := parser.Reset(strings.NewReader(declaration+`<r/>`), nil, nil); err != nil {
+		t.Fatalf("Parser.Reset() error = %v", err)
+	}
+	if parser.encoding != "ISO-8859-1" {
+		t.Fatalf("Parser.Reset() encoding = %q, want ISO-8859-1", parser.encoding)
+	}
+	if err := parser.Reset(strings.NewReader("\ufeff"+declaration+`<r/>`), nil, nil); err == nil {
+		t.Fatal("Parser.Reset() accepted ISO-8859-1 declaration after UTF-8 BOM")
 	}
 }
 
@@ -252,7 +254,7 @@ func TestParserNextPreservesUnsupportedDeclarationClassificationBeyondPreview(t
 		want    error
 	}{
 		{name: "version", content: `version="1.1"`, want: UnsupportedXMLVersionError{Version: "1.1"}},
-		{name: "encoding", content: `version="1.0" encoding="ISO-8859-1"`, want: ErrUnsupportedNonUTF8},
+		{name: "encoding", content: `version="1.0" encoding="ISO-8859-1"`, want: UnsupportedEncodingError{Encoding: "ISO-8859-1"}},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -288,3 +290,52 @@ func TestParserDetachDoesNotResetCallerBufferedReader(t *testing.T) {
 		t.Fatal("Parser.Detach() retained caller reader")
 	}
 }
+
+func TestParserXML11NormalizesLineEndsAndControlReferences(t *testing.T) {
+	const want = "a\nb\nc\n\nd\u0085\x01\x1f"
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "UTF-8", input: `<?xml version="1.1"?>` + "<r a=\"x\u0085y\">a\u0085b\r\u0085c\u2028\n<c/>d&#x85;&#x1;&#31;</r>"},
+		{name: "ISO-8859-1", input: `<?xml version="1.1" encoding="ISO-8859-1"?>` + "<r a=\"x\x85y\">a\x85b\r\x85c\n\n<c/>d&#x85;&#x1;&#31;</r>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attr, text, pos := transcodedSummary(t, []byte(test.input), Limits{XML11: true})
+			if attr != "x y" || text != want {
+				t.Fatalf("XML 1.1 attr/text = %q/%q, want %q/%q", attr, text, "x y", want)
+			}
+			// NEL in the attribute value also ends a line.
+			if pos[0] != 6 {
+				t.Fatalf("XML 1.1 line of c = %d, want 6", pos[0])
+			}
+		})
+	}
+}
+
+func TestParserXML11RejectsRestrictedCharacters(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "literal C1 control", input: "<r>\u0080</r>"},
+		{name: "literal DEL", input: "<r a=\"\x7f\"/>"},
+		{name: "literal C0 control", input: "<r>\x01</r>"},
+		{name: "NUL reference", input: "<r>&#0;</r>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var parser Parser
+			names, values := NewCache(), NewCache()
+			input := `<?xml version="1.1"?>` + test.input
+			err := parser.ResetWithLimits(strings.NewReader(input), &names, &values, Limits{XML11: true})
+			for err == nil {
+				_, err = parser.Next()
+			}
+			if errors.Is(err, io.EOF) {
+				t.Fatal("parser accepted restricted XML 1.1 character")
+			}
+		})
+	}
+}
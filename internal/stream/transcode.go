@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"math"
+	"unicode/utf8"
+
+	"github.com/jacoelho/xsd/internal/lex"
+)
+
+var errXML11Char = errors.New("invalid XML 1.1 character")
+
+// transcoder decodes raw input into UTF-8 for byteStream. widths holds, for
+// each decoded byte in the byteStream buffer, the number of raw bytes it
+// stands for: the encoded character width at the character's first byte and
+// zero at UTF-8 continuation bytes. Column tracking sums widths so positions
+// keep referring to the original document. With xml11 set, decoded NEL and
+// LS characters become line feeds, so CR NEL pairs reach the tokenizer as
+// CR LF, and literal XML 1.1 RestrictedChars are rejected.
+type transcoder struct {
+	charset Charset
+	err     error
+	name    string
+	off     int
+	end     int
+	xml11   bool
+	raw     [xmlInputBufferSize]byte
+	widths  [xmlInputBufferSize]uint8
+}
+
+// decodeWith switches b to decode the buffered and remaining input with
+// charset, after discarding skip raw bytes such as a byte order mark. It must
+// be called before any decoded byte is consumed.
+func (b *byteStream) decodeWith(charset Charset, name string, skip int) {
+	t := b.spare
+	if t == nil {
+		t = new(transcoder)
+		b.spare = t
+	}
+	t.charset = charset
+	t.name = name
+	t.end = copy(t.raw[:], b.buf[b.off+skip:b.end])
+	t.off = 0
+	t.xml11 = false
+	t.err = b.err
+	b.dec = t
+	b.err = nil
+	b.off = 0
+	b.end = 0
+	b.nlIndex = -1
+}
+
+// decode fills b.buf[start:] with decoded input and returns the decoded byte
+// count. Raw read errors are returned once the raw bytes before them are
+// decoded.
+func (t *transcoder) decode(b *byteStream, start int) (int, error) {
+	out := b.buf[start:]
+	n := 0
+	ok := true
+	for {
+		for len(out)-n >= utf8.UTFMax && t.off < t.end {
+			r, width := t.charset.DecodeRune(t.raw[t.off:t.end])
+			if width == 0 {
+				break
+			}
+			if r < 0 || !utf8.ValidRune(r) || width < 0 || width > t.end-t.off || width > math.MaxUint8 {
+				return n, errors.New("invalid " + t.name + " byte sequence")
+			}
+			if t.xml11 {
+				if r, ok = xml11Rune(r); !ok {
+					return n, errXML11Char
+				}
+			}
+			size := utf8.EncodeRune(out[n:], r)
+			t.widths[start+n] = uint8(width)
+			clear(t.widths[start+n+1 : start+n+size])
+			n += size
+			t.off += width
+		}
+		if n > 0 || len(out) < utf8.UTFMax {
+			return n, nil
+		}
+		if t.err != nil {
+			if t.off < t.end && errors.Is(t.err, io.EOF) {
+				return 0, errors.New("truncated " + t.name + " character at end of input")
+			}
+			return 0, t.err
+		}
+		t.end = copy(t.raw[:], t.raw[t.off:t.end])
+		t.off = 0
+		read, err := b.readRaw(t.raw[t.end:])
+		t.end += read
+		t.err = err
+		if read == 0 && err == nil {
+			return 0, nil
+		}
+	}
+}
+
+// filterXML11 enables the XML 1.1 filter for the rest of the input and applies
+// it in place to decoded bytes already buffered while reading the declaration.
+// A RestrictedChar truncates the buffer so the error surfaces at its position.
+func (b *byteStream) filterXML11() {
+	t := b.dec
+	t.xml11 = true
+	w := b.off
+	for i := b.off; i < b.end; {
+		r, size := utf8.DecodeRune(b.buf[i:b.end])
+		mapped, ok := xml11Rune(r)
+		if !ok {
+			b.err = errXML11Char
+			break
+		}
+		if mapped != r {
+			b.buf[w] = byte(mapped)
+			t.widths[w] = uint8(t.span(i, i+size))
+			w++
+		} else {
+			copy(b.buf[w:], b.buf[i:i+size])
+			copy(t.widths[w:], t.widths[i:i+size])
+			w += size
+		}
+		i += size
+	}
+	b.end = w
+	b.nlIndex = -1
+}
+
+// xml11Rune maps the XML 1.1 NEL and LS line ends to a line feed and reports
+// false for a literal RestrictedChar.
+func xml11Rune(r rune) (rune, bool) {
+	switch {
+	case r == 0x85, r == 0x2028:
+		return '\n', true
+	case lex.IsXML11RestrictedChar(r):
+		return r, false
+	default:
+		return r, true
+	}
+}
+
+// span returns the original input width of decoded bytes buf[from:to].
+func (t *transcoder) span(from, to int) int {
+	width := 0
+	for _, w := range t.widths[from:to] {
+		width += int(w)
+	}
+	return width
+}
@@ -79,6 +79,7 @@ type Parser struct {
 	entityBuf     []byte
 	textBuf       []byte
 	directive     []byte
+	encoding      string
 	attrs         []Attr
 	br            byteStream
 	maxAttrs      int
@@ -91,15 +92,21 @@ type Parser struct {
 	emitComments  bool
 	emitPI        bool
 	lazyAttrValue bool
+	xml11         bool
 }
 
 // Limits bounds parser-owned input and token state. Zero disables a limit;
 // production callers are responsible for supplying normalized finite values.
+// Charsets, when set, resolves declared encodings before the built-in
+// ISO-8859-1, windows-1252 and US-ASCII charsets. XML11 accepts documents
+// declaring version 1.1 and applies its line-end and character rules to them.
 type Limits struct {
 	Context       context.Context
+	Charsets      CharsetLookup
 	MaxInputBytes int64
 	MaxTokenBytes int64
 	MaxAttrs      int
+	XML11         bool
 }
 
 // Reset prepares p to read r using the supplied string caches.
@@ -133,7 +140,7 @@ func (p *Parser) ResetWithLimits(r io.Reader, names, values *Cache, limits Limit
 	p.emitComments = false
 	p.emitPI = false
 	p.lazyAttrValue = false
-	if err := p.prepareXMLProlog(); err != nil {
+	if err := p.prepareXMLProlog(limits); err != nil {
 		p.Detach()
 		return err
 	}
@@ -971,7 +978,17 @@ func (p *Parser) readXMLDeclContent() error {
 	if err != nil {
 		return err
 	}
-	return ValidateXMLDeclContent(p.directive)
+	version, enc, err := scanXMLDeclContent(p.directive)
+	if err != nil {
+		return err
+	}
+	if version != xmlVersion10 && (version != xmlVersion11 || !p.xml11) {
+		return UnsupportedXMLVersionError{Version: version}
+	}
+	if enc != "" && !IsUTF8Encoding(enc) && !strings.EqualFold(enc, p.encoding) {
+		return UnsupportedEncodingError{Encoding: enc}
+	}
+	return nil
 }
 
 func (p *Parser) skipPIContent() error {
@@ -1035,33 +1052,58 @@ func (p *Parser) skipUntil(term string) error {
 	}
 }
 
-// ValidateXMLDeclContent validates the content inside an XML declaration.
+// ValidateXMLDeclContent validates the content inside an XML 1.0 declaration.
 func ValidateXMLDeclContent(content []byte) error {
+	version, _, err := scanXMLDeclContent(content)
+	if err == nil && version != xmlVersion10 {
+		return UnsupportedXMLVersionError{Version: version}
+	}
+	return err
+}
+
+// scanXMLDeclContent validates XML declaration content and returns its
+// version and encoding name, if any.
+func scanXMLDeclContent(content []byte) (version, encoding string, err error) {
 	name, version, rest, ok := ScanXMLDeclAttr(content, XMLDeclFirstAttr)
 	if !ok || name != xsdAttrVersion {
-		return fmt.Errorf("invalid XML declaration")
-	}
-	if version != xmlVersion10 {
-		return UnsupportedXMLVersionError{Version: version}
+		return "", "", fmt.Errorf("invalid XML declaration")
 	}
 	name, value, next, ok := ScanXMLDeclAttr(rest, XMLDeclNextAttr)
 	if ok && name == "encoding" {
-		if !strings.EqualFold(value, "UTF-8") && !strings.EqualFold(value, "UTF8") {
-			return ErrUnsupportedNonUTF8
+		if !isEncName(value) {
+			return "", "", fmt.Errorf("invalid XML declaration encoding")
 		}
+		encoding = value
 		rest = next
 		name, value, next, ok = ScanXMLDeclAttr(rest, XMLDeclNextAttr)
 	}
 	if ok && name == "standalone" {
 		if value != "yes" && value != "no" {
-			return fmt.Errorf("invalid XML declaration")
+			return "", "", fmt.Errorf("invalid XML declaration")
 		}
 		rest = next
 	}
 	if len(lex.TrimXMLWhitespaceBytes(rest)) != 0 {
-		return fmt.Errorf("invalid XML declaration")
+		return "", "", fmt.Errorf("invalid XML declaration")
 	}
-	return nil
+	return version, encoding, nil
+}
+
+// isEncName reports whether name matches the XML EncName production.
+func isEncName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-'):
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 // XMLDeclAttrPosition identifies whether an XML declaration attribute is first
@@ -5,10 +5,12 @@ import (
 	"context"
 	"errors"
 	"io"
+	"unicode/utf8"
 )
 
 // byteStream tracks line and column positions in bytes, not runes; columns
-// inside multibyte UTF-8 sequences report byte offsets.
+// inside multibyte UTF-8 sequences report byte offsets. When dec is set the
+// buffer holds transcoded input and columns count original input bytes.
 //
 // nlIndex caches the index of the first '\n' at or after the position of the
 // last newline scan: -1 marks the buffer window as unscanned, end marks a
@@ -19,11 +21,14 @@ type byteStream struct {
 	ctx       context.Context
 	err       error
 	lastPos   bytePosition
+	dec       *transcoder
+	spare     *transcoder
 	off       int
 	end       int
 	nlIndex   int
 	line      int
 	col       int
+	lastWidth int
 	maxBytes  int64
 	readBytes int64
 	buf       [xmlInputBufferSize]byte
@@ -38,6 +43,7 @@ func (b *byteStream) reset(ctx context.Context, r io.Reader, maxBytes int64) {
 	b.ctx = ctx
 	b.err = nil
 	b.lastPos = bytePosition{}
+	b.dec = nil
 	b.off = 0
 	b.end = 0
 	b.nlIndex = -1
@@ -53,6 +59,7 @@ func (b *byteStream) detach() {
 	b.r = nil
 	b.ctx = nil
 	b.err = nil
+	b.dec = nil
 	b.off = 0
 	b.end = 0
 	b.nlIndex = -1
@@ -62,13 +69,21 @@ func (b *byteStream) detach() {
 	b.readBytes = 0
 }
 
-// read admits at most maxBytes raw input bytes to the parser. It may consume
+// read fills b.buf[start:] with input, decoding it when a charset is active.
+func (b *byteStream) read(start int) (int, error) {
+	if b.dec != nil {
+		return b.dec.decode(b, start)
+	}
+	return b.readRaw(b.buf[start:])
+}
+
+// readRaw admits at most maxBytes raw input bytes to the parser. It may consume
 // one additional byte from the caller to prove that the limit was exceeded,
 // but that byte is never exposed to tokenization. When the boundary-crossing
 // read also fails, both causes are retained. Cancellation observed after the
 // underlying read takes precedence over a simultaneous byte-limit crossing;
 // none of that read's bytes are exposed in that case.
-func (b *byteStream) read(p []byte) (int, error) {
+func (b *byteStream) readRaw(p []byte) (int, error) {
 	if cause := contextCause(b.ctx); cause != nil {
 		return 0, cause
 	}
@@ -115,14 +130,14 @@ func contextCause(ctx context.Context) error {
 // bytes are available, an error is known, or the fixed input buffer is full.
 // An error returned with bytes is deferred when the window already satisfies n.
 func (b *byteStream) ensure(n int) ([]byte, error) {
-	for b.end-b.off < n && b.end < len(b.buf) {
+	for b.end-b.off < n && len(b.buf)-b.end >= b.minRead() {
 		if b.err != nil {
 			return b.buf[b.off:b.end], b.err
 		}
 		if b.r == nil {
 			return b.buf[b.off:b.end], ErrXMLInputNilReader
 		}
-		read, err := b.read(b.buf[b.end:])
+		read, err := b.read(b.end)
 		if read > 0 {
 			b.end += read
 			b.err = err
@@ -137,6 +152,14 @@ func (b *byteStream) ensure(n int) ([]byte, error) {
 	return b.buf[b.off:b.end], nil
 }
 
+// minRead is the free buffer space one read needs to make progress.
+func (b *byteStream) minRead() int {
+	if b.dec != nil {
+		return utf8.UTFMax
+	}
+	return 1
+}
+
 func (b *byteStream) discardUTF8BOM() {
 	copy(b.buf[:], b.buf[utf8BOMLen:b.end])
 	b.end -= utf8BOMLen
@@ -164,7 +187,7 @@ func (b *byteStream) readByte() (byte, error) {
 		if b.r == nil {
 			return 0, ErrXMLInputNilReader
 		}
-		n, err := b.read(b.buf[:])
+		n, err := b.read(0)
 		if n <= 0 {
 			if err != nil {
 				return 0, err
@@ -179,6 +202,7 @@ func (b *byteStream) readByte() (byte, error) {
 	c := b.buf[b.off]
 	b.off++
 	b.last = c
+	b.lastWidth = b.span(b.off-1, b.off)
 	b.lastPos = bytePosition{line: b.line, col: b.col}
 	b.advance(c)
 	return c, nil
@@ -206,7 +230,7 @@ func (b *byteStream) fill() error {
 	if b.r == nil {
 		return ErrXMLInputNilReader
 	}
-	n, err := b.read(b.buf[:])
+	n, err := b.read(0)
 	if n > 0 {
 		b.off = 0
 		b.end = n
@@ -228,8 +252,8 @@ func (b *byteStream) consumeBuffered(n int) {
 		b.consumeBufferedSlow(n)
 		return
 	}
+	b.col += b.span(b.off, b.off+n)
 	b.off += n
-	b.col += n
 }
 
 func (b *byteStream) consumeBufferedSlow(n int) {
@@ -239,8 +263,8 @@ func (b *byteStream) consumeBufferedSlow(n int) {
 		return
 	}
 	start := b.off
+	b.col += b.span(start, start+n)
 	b.off += n
-	b.col += n
 	b.fixupLineBreaks(start)
 }
 
@@ -266,7 +290,7 @@ func (b *byteStream) fixupLineBreaks(start int) {
 		lines++
 	}
 	b.line += lines
-	b.col = b.off - last - 1
+	b.col = b.span(last+1, b.off)
 	b.nlIndex = b.nextNewline(b.off)
 }
 
@@ -292,7 +316,15 @@ func (b *byteStream) advance(c byte) {
 		b.col = 0
 		return
 	}
-	b.col++
+	b.col += b.lastWidth
+}
+
+// span returns the original input width of buffered bytes buf[from:to].
+func (b *byteStream) span(from, to int) int {
+	if b.dec == nil {
+		return to - from
+	}
+	return b.dec.span(from, to)
 }
 
 func (b *byteStream) pos() (int, int) {
@@ -219,10 +219,10 @@ func TestXMLStreamParserHandlesBareCRText(t *testing.T) {
 }
 
 func TestParseCharRefRejectsUppercaseHexMarker(t *testing.T) {
-	if r, ok := parseCharRef([]byte("x4F")); !ok || r != 'O' {
+	if r, ok := parseCharRef([]byte("x4F"), false); !ok || r != 'O' {
 		t.Fatalf("parseCharRef(x4F) = %q, %v; want O, true", r, ok)
 	}
-	if r, ok := parseCharRef([]byte("X4F")); ok || r != 0 {
+	if r, ok := parseCharRef([]byte("X4F"), false); ok || r != 0 {
 		t.Fatalf("parseCharRef(X4F) = %q, %v; want rejected", r, ok)
 	}
 }
@@ -86,7 +86,7 @@ func (p *Parser) readEntity(dst *[]byte) error {
 			}
 			return fmt.Errorf("invalid character entity")
 		}
-		r, ok := parseCharRef(p.entityBuf[1:])
+		r, ok := parseCharRef(p.entityBuf[1:], p.xml11)
 		if !ok {
 			return fmt.Errorf("invalid character entity")
 		}
@@ -107,7 +107,9 @@ func (p *Parser) appendEntityBytes(dst *[]byte, data []byte) error {
 	return nil
 }
 
-func parseCharRef(s []byte) (rune, bool) {
+// parseCharRef decodes a character reference body. XML 1.1 documents may
+// reference the C0 controls other than NUL.
+func parseCharRef(s []byte, xml11 bool) (rune, bool) {
 	if len(s) == 0 {
 		return 0, false
 	}
@@ -141,10 +143,13 @@ func parseCharRef(s []byte) (rune, bool) {
 		}
 	}
 	r := rune(v)
-	if !utf8.ValidRune(r) || !lex.IsXMLChar(r) {
+	if !utf8.ValidRune(r) {
 		return 0, false
 	}
-	return r, true
+	if xml11 {
+		return r, lex.IsXML11Char(r)
+	}
+	return r, lex.IsXMLChar(r)
 }
 
 func (p *Parser) readPastSpace() (byte, bool, error) {
@@ -12,6 +12,12 @@ func attributeValidation(ctx StartContext, msg string) error {
 	return validation(ctx, xsderrors.CodeValidationAttribute, msg)
 }
 
+// attributeExpected returns an attribute diagnostic that records the
+// attribute found and the attributes expected.
+func attributeExpected(ctx StartContext, msg string, found xml.Name, expected *xsderrors.Expected) error {
+	return validationFromIssue(ctx, validationIssue{code: xsderrors.CodeValidationAttribute, message: msg, found: found, expected: expected})
+}
+
 func isXSIAttributeName(name xml.Name) bool {
 	return name.Space == vocab.XSINamespaceURI &&
 		(name.Local == vocab.XSIAttrType ||
@@ -83,9 +89,18 @@ func MatchAttributeWildcard(rt *runtime.Schema, wildcard runtime.WildcardID, nam
 	if !ok {
 		return AttributeWildcardMatch{}, false
 	}
-	if !w.AllowsURI(name.NS) {
+	if !w.AllowsName(name) {
 		return AttributeWildcardMatch{}, true
 	}
+	if w.ExcludesDefined() && name.Known {
+		_, found, valid := rt.GlobalAttribute(name.Name)
+		if !valid {
+			return AttributeWildcardMatch{}, false
+		}
+		if found {
+			return AttributeWildcardMatch{}, true
+		}
+	}
 	if w.Process() == runtime.ProcessSkip {
 		return AttributeWildcardMatch{Matched: true, Skip: true}, true
 	}
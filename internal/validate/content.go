@@ -1,6 +1,8 @@
 package validate
 
 import (
+	"encoding/xml"
+
 	"github.com/jacoelho/xsd/internal/lex"
 	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/xsderrors"
@@ -18,9 +20,13 @@ func ValidateDocumentCharacterData(data []byte, cdata bool, ctx StartContext) er
 	return validation(ctx, xsderrors.CodeValidationText, "text outside root element")
 }
 
+// validationIssue is a validation diagnostic before it is located. Found
+// and expected carry the structured detail of content diagnostics.
 type validationIssue struct {
-	code    xsderrors.Code
-	message string
+	expected *xsderrors.Expected
+	code     xsderrors.Code
+	message  string
+	found    xml.Name
 }
 
 func (i validationIssue) valid() bool {
@@ -40,31 +46,46 @@ func childFramePolicy(nilled bool) childStartPolicy {
 
 func childContentPolicy(content runtime.ChildContentInfo, state runtime.ContentState, name runtime.RuntimeName) validationIssue {
 	if !content.Complex {
-		return validationIssue{code: xsderrors.CodeValidationContent, message: "simple type cannot contain child elements"}
+		return noChildIssue(name, "simple type cannot contain child elements")
 	}
 	if content.Simple {
-		return validationIssue{code: xsderrors.CodeValidationContent, message: "simple content cannot contain child elements"}
+		return noChildIssue(name, "simple content cannot contain child elements")
 	}
 	if !state.HasModel() {
-		return unexpectedChildIssue(name)
+		return unexpectedChildIssue(name, &xsderrors.Expected{})
 	}
 	return validationIssue{}
 }
 
-func unexpectedChildIssue(name runtime.RuntimeName) validationIssue {
-	return validationIssue{code: xsderrors.CodeValidationElement, message: "unexpected child element " + name.Label()}
+// noChildIssue rejects child element name where no element is accepted.
+func noChildIssue(name runtime.RuntimeName, msg string) validationIssue {
+	return validationIssue{
+		code:     xsderrors.CodeValidationContent,
+		message:  msg,
+		found:    foundName(name),
+		expected: &xsderrors.Expected{},
+	}
+}
+
+func unexpectedChildIssue(name runtime.RuntimeName, expected *xsderrors.Expected) validationIssue {
+	return validationIssue{
+		code:     xsderrors.CodeValidationElement,
+		message:  "unexpected child element " + name.Label(),
+		found:    foundName(name),
+		expected: expected,
+	}
 }
 
 func strictMissingChildIssue(name runtime.RuntimeName) validationIssue {
-	return validationIssue{code: xsderrors.CodeValidationElement, message: "wildcard requires declared element " + name.Label()}
+	return validationIssue{code: xsderrors.CodeValidationElement, message: "wildcard requires declared element " + name.Label(), found: foundName(name)}
 }
 
 func nilledContentIssue() validationIssue {
 	return validationIssue{code: xsderrors.CodeValidationNil, message: "nilled element must be empty"}
 }
 
-func missingRequiredChildIssue() validationIssue {
-	return validationIssue{code: xsderrors.CodeValidationContent, message: "missing required child element"}
+func missingRequiredChildIssue(expected *xsderrors.Expected) validationIssue {
+	return validationIssue{code: xsderrors.CodeValidationContent, message: "missing required child element", expected: expected}
 }
 
 func contentCompletionRequired(nilled bool, typ runtime.TypeID, content runtime.ContentState) bool {
@@ -72,5 +93,9 @@ func contentCompletionRequired(nilled bool, typ runtime.TypeID, content runtime.
 }
 
 func validationFromIssue(ctx StartContext, issue validationIssue) error {
-	return validation(ctx, issue.code, issue.message)
+	return xsderrors.ValidationExpected(issue.code, ctx.Line, ctx.Column, ctx.PathString(), issue.message, issue.found, issue.expected)
+}
+
+func foundName(name runtime.RuntimeName) xml.Name {
+	return xml.Name{Space: name.NS, Local: name.Local}
 }
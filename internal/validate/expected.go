@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"encoding/xml"
+	"slices"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// expectedContent returns what the content model of f accepts as its next
+// child element.
+func (s *session) expectedContent(f *frame) *xsderrors.Expected {
+	scratch := s.contentScratch(f)
+	next := s.rt.ExpectedContent(f.Content, &scratch)
+	out := &xsderrors.Expected{}
+	for _, q := range next.Elements {
+		out.Names = append(out.Names, expandedName(s.rt, q))
+	}
+	if next.Any {
+		out.Wildcards = append(out.Wildcards, xsderrors.Wildcard{Any: true})
+	}
+	out.Wildcards = appendExpectedWildcard(s.rt, out.Wildcards, next.Wildcards...)
+	return out
+}
+
+// expectedAttributes returns the attributes of set not yet seen on the
+// element, and its attribute wildcard.
+func (s *session) expectedAttributes(set runtime.AttributeUseSetRead, seen *AttributeSeen) *xsderrors.Expected {
+	out := &xsderrors.Expected{}
+	for slot := range set.UseCount() {
+		use, ok := set.UseAt(slot)
+		if ok && !seen.has(slot) {
+			out.Names = append(out.Names, expandedName(s.rt, use.Name()))
+		}
+	}
+	if set.Wildcard() != runtime.NoWildcard {
+		out.Wildcards = appendExpectedWildcard(s.rt, out.Wildcards, set.Wildcard())
+	}
+	return out
+}
+
+func expandedName(rt *runtime.Schema, q runtime.QName) xml.Name {
+	name := rt.ExpandedName(q)
+	return xml.Name{Space: name.Namespace, Local: name.Local}
+}
+
+func appendExpectedWildcard(rt *runtime.Schema, out []xsderrors.Wildcard, ids ...runtime.WildcardID) []xsderrors.Wildcard {
+	for _, id := range ids {
+		w, ok := rt.WildcardComponent(id)
+		if !ok {
+			continue
+		}
+		var namespaces []string
+		for _, ns := range w.Namespaces {
+			namespaces = append(namespaces, rt.Namespace(ns))
+		}
+		var expected xsderrors.Wildcard
+		switch w.Mode {
+		case runtime.WildcardAny:
+			expected.Any = true
+		case runtime.WildcardOther:
+			expected.Not = true
+			expected.Namespaces = []string{""}
+			if other := rt.Namespace(w.OtherThan); other != "" {
+				expected.Namespaces = []string{other, ""}
+			}
+		case runtime.WildcardLocal:
+			expected.Namespaces = []string{""}
+		case runtime.WildcardNot:
+			expected.Not = true
+			expected.Namespaces = namespaces
+		default:
+			expected.Namespaces = namespaces
+		}
+		if !slices.ContainsFunc(out, func(w xsderrors.Wildcard) bool {
+			return w.Any == expected.Any && w.Not == expected.Not && slices.Equal(w.Namespaces, expected.Namespaces)
+		}) {
+			out = append(out, expected)
+		}
+	}
+	return out
+}
@@ -1,23 +1,36 @@
 package validate
 
 import (
+	"context"
 	"encoding/xml"
 	"strings"
 
 	"github.com/jacoelho/xsd/internal/lex"
+	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/internal/uriref"
 	"github.com/jacoelho/xsd/internal/vocab"
 	"github.com/jacoelho/xsd/xsderrors"
 )
 
 // SchemaLocationHints records instance schema-location hints seen during
-// validation. Only namespaces are retained because loading external schemas is
-// intentionally unsupported at validation time.
+// validation. Only namespaces are retained; hints on the document element are
+// handed to a SchemaLocationLoader separately when one is configured.
 type SchemaLocationHints struct {
 	namespaces     map[string]struct{}
 	namespaceBytes int64
 }
 
+// SchemaLocationHint is one namespace/location pair from xsi:schemaLocation.
+// Namespace is empty for xsi:noNamespaceSchemaLocation.
+type SchemaLocationHint struct {
+	Namespace string
+	Location  string
+}
+
+// SchemaLocationLoader returns a runtime covering the engine schema and the
+// hinted schemas, or nil to keep validating with the current runtime.
+type SchemaLocationLoader func(ctx context.Context, hints []SchemaLocationHint) (*runtime.Schema, error)
+
 type schemaLocationHintLimits struct {
 	Namespaces     int
 	NamespaceBytes int64
@@ -132,6 +145,29 @@ func (h *SchemaLocationHints) add(ns string, limits schemaLocationHintLimits, ct
 	return nil
 }
 
+// AppendSchemaLocationHints appends the namespace/location pairs of one
+// already recorded xsi:schemaLocation or xsi:noNamespaceSchemaLocation value.
+func AppendSchemaLocationHints(dst []SchemaLocationHint, name xml.Name, value string) []SchemaLocationHint {
+	switch name.Local {
+	case vocab.XSIAttrSchemaLocation:
+		var namespace string
+		index := 0
+		for field := range lex.XMLFieldsSeq(value) {
+			if index%2 == 0 {
+				namespace = field
+			} else {
+				dst = append(dst, SchemaLocationHint{Namespace: strings.Clone(namespace), Location: strings.Clone(field)})
+			}
+			index++
+		}
+	case vocab.XSIAttrNoNamespaceSchemaLocation:
+		if location := lex.TrimXMLWhitespaceString(value); location != "" {
+			dst = append(dst, SchemaLocationHint{Location: strings.Clone(location)})
+		}
+	}
+	return dst
+}
+
 // IsSchemaLocationHintName reports whether name is an XSI schema-location hint.
 func IsSchemaLocationHintName(name xml.Name) bool {
 	return name.Space == vocab.XSINamespaceURI &&
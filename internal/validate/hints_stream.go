@@ -15,3 +15,14 @@ func (h *SchemaLocationHints) RecordAttributes(attrs []stream.Attr, values *stre
 	}
 	return nil
 }
+
+// appendSchemaLocationHintAttributes appends the hints carried by attrs.
+func appendSchemaLocationHintAttributes(dst []SchemaLocationHint, attrs []stream.Attr, values *stream.Cache) []SchemaLocationHint {
+	for i := range attrs {
+		attr := &attrs[i]
+		if IsSchemaLocationHintName(attr.Name) {
+			dst = AppendSchemaLocationHints(dst, attr.Name, attr.StringValue(values))
+		}
+	}
+	return dst
+}
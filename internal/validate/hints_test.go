@@ -3,6 +3,7 @@ package validate
 import (
 	"encoding/xml"
 	"errors"
+	"slices"
 	"testing"
 
 	"github.com/jacoelho/xsd/internal/stream"
@@ -53,6 +54,22 @@ func TestSchemaLocationHintsRecordEmptyNoNamespace(t *testing.T) {
 	}
 }
 
+func TestAppendSchemaLocationHintsPairsLocations(t *testing.T) {
+	t.Parallel()
+
+	hints := AppendSchemaLocationHints(nil, xsiHintName(vocab.XSIAttrSchemaLocation), "urn:a a.xsd\n urn:b\tb.xsd")
+	hints = AppendSchemaLocationHints(hints, xsiHintName(vocab.XSIAttrNoNamespaceSchemaLocation), "\tno-ns.xsd\n")
+	hints = AppendSchemaLocationHints(hints, xsiHintName(vocab.XSIAttrNoNamespaceSchemaLocation), " ")
+	want := []SchemaLocationHint{
+		{Namespace: "urn:a", Location: "a.xsd"},
+		{Namespace: "urn:b", Location: "b.xsd"},
+		{Location: "no-ns.xsd"},
+	}
+	if !slices.Equal(hints, want) {
+		t.Fatalf("AppendSchemaLocationHints() = %v, want %v", hints, want)
+	}
+}
+
 func TestSchemaLocationHintsRejectMalformedHints(t *testing.T) {
 	t.Parallel()
 
@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/jacoelho/xsd/internal/runtime"
@@ -126,11 +127,12 @@ type identityTableEntry struct {
 }
 
 type identityTupleRef struct {
-	key   string
-	path  string
-	line  int
-	col   int
-	refer runtime.IdentityConstraintID
+	key        string
+	path       string
+	line       int
+	col        int
+	refer      runtime.IdentityConstraintID
+	constraint runtime.IdentityConstraintID
 }
 
 type identitySelection struct {
@@ -367,14 +369,6 @@ func (s *IdentityState) attributeFieldMatches(rt *runtime.Schema, namePath []run
 	return s.FieldMatches(), nil
 }
 
-// SelectionPath returns the validation path for selection.
-func (s *IdentityState) SelectionPath(selection int) (string, bool) {
-	if s == nil || selection < 0 || selection >= len(s.selections) {
-		return "", false
-	}
-	return s.selections[selection].path, true
-}
-
 // matchSelectors starts selections whose selectors match the current element.
 func (s *IdentityState) matchSelectors(rt *runtime.Schema, namePath []runtime.RuntimeName, maxPending int, ctx StartContext) error {
 	if !s.HasScopes() {
@@ -425,11 +419,12 @@ func identitySelectorMatches(rt *runtime.Schema, id runtime.IdentityConstraintID
 }
 
 // CaptureFields records one identity value in all matched fields.
-func (s *IdentityState) CaptureFields(matches []IdentityFieldMatch, value string, ctx StartContext) error {
+func (s *IdentityState) CaptureFields(rt *runtime.Schema, matches []IdentityFieldMatch, value string, ctx StartContext) error {
 	if err := s.validateFieldMatches(matches); err != nil {
 		return err
 	}
 	var duplicatePath string
+	var duplicateConstraint runtime.IdentityConstraintID
 	for _, match := range matches {
 		sel := &s.selections[match.Selection]
 		field := &s.selectionFields(*sel)[match.Field]
@@ -442,7 +437,7 @@ func (s *IdentityState) CaptureFields(matches []IdentityFieldMatch, value string
 			field.state = identityFieldInvalid
 			s.scopes[sel.scope].invalid = true
 			if duplicatePath == "" {
-				duplicatePath = sel.path
+				duplicatePath, duplicateConstraint = sel.path, sel.constraint
 			}
 		case identityFieldInvalid:
 		default:
@@ -450,7 +445,7 @@ func (s *IdentityState) CaptureFields(matches []IdentityFieldMatch, value string
 		}
 	}
 	if duplicatePath != "" {
-		return validation(StartContext{Path: duplicatePath, Line: ctx.Line, Column: ctx.Column}, xsderrors.CodeValidationIdentity, "identity field selects multiple values")
+		return identityValidation(rt, duplicateConstraint, StartContext{Path: duplicatePath, Line: ctx.Line, Column: ctx.Column}, "identity field selects multiple values")
 	}
 	return nil
 }
@@ -464,7 +459,7 @@ func (s *IdentityState) CaptureSimpleValueFields(rt *runtime.Schema, matches []I
 	if !ok {
 		return xsderrors.InternalInvariant("identity field value references invalid simple type")
 	}
-	return s.CaptureFields(matches, key, ctx)
+	return s.CaptureFields(rt, matches, key, ctx)
 }
 
 // MarkNillableKeyFields records successfully captured key fields selected from
@@ -499,18 +494,19 @@ func (s *IdentityState) MarkNillableKeyFields(rt *runtime.Schema, matches []Iden
 
 // RejectFieldsWithoutSimpleValue invalidates selected field nodes that have no
 // assessment-derived simple value.
-func (s *IdentityState) RejectFieldsWithoutSimpleValue(matches []IdentityFieldMatch, ctx StartContext) error {
+func (s *IdentityState) RejectFieldsWithoutSimpleValue(rt *runtime.Schema, matches []IdentityFieldMatch, ctx StartContext) error {
 	if len(matches) == 0 {
 		return nil
 	}
 	if err := s.InvalidateFields(matches); err != nil {
 		return err
 	}
-	path, ok := s.SelectionPath(matches[0].Selection)
-	if !ok {
+	selection := matches[0].Selection
+	if selection < 0 || selection >= len(s.selections) {
 		return xsderrors.InternalInvariant("identity field match references invalid selection")
 	}
-	return validation(StartContext{Path: path, Line: ctx.Line, Column: ctx.Column}, xsderrors.CodeValidationIdentity, "identity field has no simple value")
+	sel := s.selections[selection]
+	return identityValidation(rt, sel.constraint, StartContext{Path: sel.path, Line: ctx.Line, Column: ctx.Column}, "identity field has no simple value")
 }
 
 // InvalidateFields prevents selected field nodes from being reclassified as
@@ -570,7 +566,7 @@ func (s *IdentityState) finishSelection(
 	if !ok {
 		return xsderrors.InternalInvariant("identity constraint metadata is invalid")
 	}
-	return s.finishSelectionWithInfo(info, sel, limits, ctx)
+	return locateIdentity(rt, s.finishSelectionWithInfo(info, sel, limits, ctx), sel.constraint)
 }
 
 func (s *IdentityState) finishSelectionWithInfo(
@@ -639,11 +635,12 @@ func (s *IdentityState) finishSelectionWithInfo(
 			return err
 		}
 		scope.refs = append(scope.refs, identityTupleRef{
-			refer: info.Refer,
-			key:   key,
-			path:  sel.path,
-			line:  sel.line,
-			col:   sel.col,
+			refer:      info.Refer,
+			constraint: sel.constraint,
+			key:        key,
+			path:       sel.path,
+			line:       sel.line,
+			col:        sel.col,
 		})
 	}
 	return nil
@@ -692,7 +689,7 @@ func identityTupleKey(fields []identityFieldValue, limits IdentityLimits, ctx St
 
 // CloseScopes closes identity scopes at depth, resolves keyrefs, and reports
 // whether constraints owned by the closed scopes failed.
-func (s *IdentityState) CloseScopes(depth int, report func(error) error) (bool, error) {
+func (s *IdentityState) CloseScopes(rt *runtime.Schema, depth int, report func(error) error) (bool, error) {
 	if s == nil {
 		return false, nil
 	}
@@ -703,7 +700,7 @@ func (s *IdentityState) CloseScopes(depth int, report func(error) error) (bool,
 			entry, ok := scope.tables[ref.refer][ref.key]
 			if !ok || entry.conflict {
 				scope.invalid = true
-				err := validation(StartContext{Path: ref.path, Line: ref.line, Column: ref.col}, xsderrors.CodeValidationIdentity, "keyref does not resolve")
+				err := identityValidation(rt, ref.constraint, StartContext{Path: ref.path, Line: ref.line, Column: ref.col}, "keyref does not resolve")
 				if recoverErr := report(err); recoverErr != nil {
 					return true, recoverErr
 				}
@@ -719,6 +716,21 @@ func (s *IdentityState) CloseScopes(depth int, report func(error) error) (bool,
 	return invalid, nil
 }
 
+// identityValidation returns an identity constraint diagnostic located at
+// constraint.
+func identityValidation(rt *runtime.Schema, constraint runtime.IdentityConstraintID, ctx StartContext, msg string) error {
+	return locate(rt, validation(ctx, xsderrors.CodeValidationIdentity, msg), identityKey(constraint))
+}
+
+// locateIdentity attaches the location of constraint to err when it is an
+// identity constraint diagnostic.
+func locateIdentity(rt *runtime.Schema, err error, constraint runtime.IdentityConstraintID) error {
+	if diagnostic, ok := errors.AsType[*xsderrors.Error](err); !ok || diagnostic.Code != xsderrors.CodeValidationIdentity {
+		return err
+	}
+	return locate(rt, err, identityKey(constraint))
+}
+
 func mergeIdentityTables(dst, src *identityScope) {
 	if len(src.tables) == 0 {
 		return
@@ -403,14 +403,14 @@ func TestIdentityStateCaptureFieldsRejectsInvalidAndDuplicateMatches(t *testing.
 	startIdentityScope(t, &state, []runtime.IdentityConstraintID{id}, 1, "/root")
 	state.StartSelection(0, 2, id, 1, StartContext{Path: "/row", Line: 4, Column: 5})
 
-	err := state.CaptureFields([]IdentityFieldMatch{{Selection: 1, Field: 0}}, "a", StartContext{Path: "/row/id", Line: 6, Column: 7})
+	err := state.CaptureFields(nil, []IdentityFieldMatch{{Selection: 1, Field: 0}}, "a", StartContext{Path: "/row/id", Line: 6, Column: 7})
 	expectXSDCode(t, err, xsderrors.CodeInternalInvariant)
 
-	err = state.CaptureFields([]IdentityFieldMatch{{Selection: 0, Field: 0}}, "a", StartContext{Path: "/row/id", Line: 6, Column: 7})
+	err = state.CaptureFields(nil, []IdentityFieldMatch{{Selection: 0, Field: 0}}, "a", StartContext{Path: "/row/id", Line: 6, Column: 7})
 	if err != nil {
 		t.Fatalf("CaptureFields(first) error = %v", err)
 	}
-	err = state.CaptureFields([]IdentityFieldMatch{{Selection: 0, Field: 0}}, "b", StartContext{Path: "/row/id", Line: 8, Column: 9})
+	err = state.CaptureFields(nil, []IdentityFieldMatch{{Selection: 0, Field: 0}}, "b", StartContext{Path: "/row/id", Line: 8, Column: 9})
 	expectXSDCode(t, err, xsderrors.CodeValidationIdentity)
 	expectXSDMessage(t, err, "identity field selects multiple values")
 	expectXSDLocation(t, err, "/row", 8, 9)
@@ -442,6 +442,7 @@ func TestIdentityStateRejectFieldsWithoutSimpleValueInvalidatesField(t *testing.
 	state.StartSelection(0, 2, id, 1, StartContext{Path: "/root/item", Line: 4, Column: 5})
 
 	err := state.RejectFieldsWithoutSimpleValue(
+		nil,
 		[]IdentityFieldMatch{{Selection: 0, Field: 0}},
 		StartContext{Path: "/root/item", Line: 6, Column: 7},
 	)
@@ -646,7 +647,7 @@ func TestIdentityStateCloseScopesResolvesKeyRefWithinScope(t *testing.T) {
 	if err := finishSelectionsForTest(&state, info, 2, StartContext{Path: "/root", Line: 8, Column: 9}, failIdentityReport(t)); err != nil {
 		t.Fatalf("FinishSelections() error = %v", err)
 	}
-	invalid, err := state.CloseScopes(1, failIdentityReport(t))
+	invalid, err := state.CloseScopes(nil, 1, failIdentityReport(t))
 	if err != nil {
 		t.Fatalf("CloseScopes() error = %v", err)
 	}
@@ -673,7 +674,7 @@ func TestIdentityStateCloseScopesReportsUnresolvedKeyRef(t *testing.T) {
 	}
 
 	var got error
-	invalid, err := state.CloseScopes(1, func(err error) error {
+	invalid, err := state.CloseScopes(nil, 1, func(err error) error {
 		got = err
 		return nil
 	})
@@ -708,7 +709,7 @@ func TestIdentityStateMergedChildKeyConflictKeepsParentKeyRefUnresolved(t *testi
 	if err := finishSelectionsForTest(&state, info, 3, StartContext{Path: "/root/a", Line: 6, Column: 7}, failIdentityReport(t)); err != nil {
 		t.Fatalf("FinishSelections(first child) error = %v", err)
 	}
-	if _, err := state.CloseScopes(2, failIdentityReport(t)); err != nil {
+	if _, err := state.CloseScopes(nil, 2, failIdentityReport(t)); err != nil {
 		t.Fatalf("CloseScopes(first child) error = %v", err)
 	}
 
@@ -718,7 +719,7 @@ func TestIdentityStateMergedChildKeyConflictKeepsParentKeyRefUnresolved(t *testi
 	if err := finishSelectionsForTest(&state, info, 3, StartContext{Path: "/root/b", Line: 10, Column: 11}, failIdentityReport(t)); err != nil {
 		t.Fatalf("FinishSelections(second child) error = %v", err)
 	}
-	if _, err := state.CloseScopes(2, failIdentityReport(t)); err != nil {
+	if _, err := state.CloseScopes(nil, 2, failIdentityReport(t)); err != nil {
 		t.Fatalf("CloseScopes(second child) error = %v", err)
 	}
 
@@ -729,7 +730,7 @@ func TestIdentityStateMergedChildKeyConflictKeepsParentKeyRefUnresolved(t *testi
 	}
 
 	var got error
-	if _, err := state.CloseScopes(1, func(err error) error {
+	if _, err := state.CloseScopes(nil, 1, func(err error) error {
 		got = err
 		return nil
 	}); err != nil {
@@ -760,7 +761,7 @@ func TestIdentityStateMergedChildKeyConflictUsesSelectedNodeNotPath(t *testing.T
 	if err := finishSelectionsForTest(&state, info, 3, StartContext{Path: "/root/group", Line: 6, Column: 7}, failIdentityReport(t)); err != nil {
 		t.Fatalf("FinishSelections(first child) error = %v", err)
 	}
-	if _, err := state.CloseScopes(2, failIdentityReport(t)); err != nil {
+	if _, err := state.CloseScopes(nil, 2, failIdentityReport(t)); err != nil {
 		t.Fatalf("CloseScopes(first child) error = %v", err)
 	}
 
@@ -770,7 +771,7 @@ func TestIdentityStateMergedChildKeyConflictUsesSelectedNodeNotPath(t *testing.T
 	if err := finishSelectionsForTest(&state, info, 3, StartContext{Path: "/root/group", Line: 10, Column: 11}, failIdentityReport(t)); err != nil {
 		t.Fatalf("FinishSelections(second child) error = %v", err)
 	}
-	if _, err := state.CloseScopes(2, failIdentityReport(t)); err != nil {
+	if _, err := state.CloseScopes(nil, 2, failIdentityReport(t)); err != nil {
 		t.Fatalf("CloseScopes(second child) error = %v", err)
 	}
 
@@ -781,7 +782,7 @@ func TestIdentityStateMergedChildKeyConflictUsesSelectedNodeNotPath(t *testing.T
 	}
 
 	var got error
-	if _, err := state.CloseScopes(1, func(err error) error {
+	if _, err := state.CloseScopes(nil, 1, func(err error) error {
 		got = err
 		return nil
 	}); err != nil {
@@ -900,7 +901,7 @@ func startIdentityScope(t *testing.T, state *IdentityState, constraints []runtim
 
 func captureIdentityField(t *testing.T, state *IdentityState, selection int, value string) {
 	t.Helper()
-	err := state.CaptureFields([]IdentityFieldMatch{{Selection: selection, Field: 0}}, value, StartContext{Path: "/field", Line: 1, Column: 1})
+	err := state.CaptureFields(nil, []IdentityFieldMatch{{Selection: selection, Field: 0}}, value, StartContext{Path: "/field", Line: 1, Column: 1})
 	if err != nil {
 		t.Fatalf("CaptureFields(selection=%d) error = %v", selection, err)
 	}
@@ -1,7 +1,10 @@
 // Package validate owns XML instance validation concerns.
 package validate
 
-import "github.com/jacoelho/xsd/xsderrors"
+import (
+	"github.com/jacoelho/xsd/internal/stream"
+	"github.com/jacoelho/xsd/xsderrors"
+)
 
 const (
 	defaultMaxErrors                       = 100
@@ -30,6 +33,27 @@ type Options struct {
 	MaxInstanceTextBytes            int64
 	MaxInstanceTokenBytes           int64
 	MaxInstanceBytes                int64
+	// SchemaLocations loads schema-location hints found on the document
+	// element before it is validated. Nil leaves hints unloaded.
+	SchemaLocations SchemaLocationLoader
+	// Charsets resolves declared instance encodings before the built-in
+	// charsets. Nil uses only the built-in charsets.
+	Charsets stream.CharsetLookup
+	// XML11 accepts instance documents declaring XML version 1.1.
+	XML11 bool
+	// Events receives post-schema-validation infoset events. Nil reports
+	// none.
+	Events EventHandler
+	// OnError receives recoverable validation errors instead of collecting
+	// them up to MaxErrors. Nil collects them.
+	OnError ErrorHandler
+	// EventText adds EventText events for character data that Events would
+	// not otherwise report.
+	EventText bool
+	// IndexedPaths adds sibling positions to error paths.
+	IndexedPaths bool
+	// ClarkPaths writes error path steps in a namespace as {namespace}local.
+	ClarkPaths bool
 }
 
 // Limits is the normalized internal form of Options.
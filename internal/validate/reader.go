@@ -11,11 +11,13 @@ func instanceReaderError(err error) error {
 	switch {
 	case errors.Is(err, stream.ErrXMLInputNilReader):
 		return xsderrors.Validation(xsderrors.CodeValidationXML, 0, 0, "", "instance reader is nil")
-	case errors.Is(err, stream.ErrUnsupportedNonUTF8):
-		return xsderrors.Unsupported(xsderrors.CodeUnsupportedNonUTF8, "instance documents must be UTF-8")
 	case stream.IsInputLimit(err) || stream.IsTokenLimit(err) || stream.IsAttributeLimit(err):
 		return validationReaderCause(xsderrors.CodeValidationLimit, 0, 0, "", err)
 	default:
+		var encodingErr stream.UnsupportedEncodingError
+		if errors.As(err, &encodingErr) {
+			return xsderrors.Unsupported(xsderrors.CodeUnsupportedNonUTF8, encodingErr.Error())
+		}
 		var versionErr stream.UnsupportedXMLVersionError
 		if errors.As(err, &versionErr) {
 			return xsderrors.Unsupported(xsderrors.CodeUnsupportedXML11, versionErr.Error())
@@ -26,8 +28,9 @@ func instanceReaderError(err error) error {
 
 // StreamError classifies parser errors as validation diagnostics.
 func StreamError(line, col int, path string, err error) error {
-	if errors.Is(err, stream.ErrUnsupportedNonUTF8) {
-		return xsderrors.UnsupportedAt(xsderrors.CodeUnsupportedNonUTF8, line, col, path, "instance documents must be UTF-8", err)
+	var encodingErr stream.UnsupportedEncodingError
+	if errors.As(err, &encodingErr) {
+		return xsderrors.UnsupportedAt(xsderrors.CodeUnsupportedNonUTF8, line, col, path, encodingErr.Error(), err)
 	}
 	var versionErr stream.UnsupportedXMLVersionError
 	if errors.As(err, &versionErr) {
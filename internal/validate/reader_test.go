@@ -32,9 +32,7 @@ func TestParserPreflightRejectsInvalidInputs(t *testing.T) {
 		in   string
 		code xsderrors.Code
 	}{
-		{name: "utf16 be bom", in: string([]byte{0xFE, 0xFF}) + "<root/>", code: xsderrors.CodeUnsupportedNonUTF8},
-		{name: "utf16 le bom", in: string([]byte{0xFF, 0xFE}) + "<root/>", code: xsderrors.CodeUnsupportedNonUTF8},
-		{name: "non utf8 declaration", in: `<?xml version="1.0" encoding="ISO-8859-1"?><root/>`, code: xsderrors.CodeUnsupportedNonUTF8},
+		{name: "unknown encoding declaration", in: `<?xml version="1.0" encoding="EBCDIC-US"?><root/>`, code: xsderrors.CodeUnsupportedNonUTF8},
 		{name: "xml 11 declaration", in: `<?xml version="1.1"?><root/>`, code: xsderrors.CodeUnsupportedXML11},
 	}
 	for _, tt := range tests {
@@ -69,7 +67,7 @@ func TestStreamErrorClassifiesParserErrors(t *testing.T) {
 	}{
 		{name: "token limit", err: parserErr(t, `<root>text</root>`, 1, 0), code: xsderrors.CodeValidationLimit},
 		{name: "attribute limit", err: parserErr(t, `<root a="1" b="2"/>`, 0, 1), code: xsderrors.CodeValidationLimit},
-		{name: "non utf8", err: stream.ErrUnsupportedNonUTF8, code: xsderrors.CodeUnsupportedNonUTF8},
+		{name: "unsupported encoding", err: stream.UnsupportedEncodingError{Encoding: "EBCDIC-US"}, code: xsderrors.CodeUnsupportedNonUTF8},
 		{name: "xml 11", err: stream.UnsupportedXMLVersionError{Version: "1.1"}, code: xsderrors.CodeUnsupportedXML11},
 		{name: "entity", err: parserErr(t, `<root>&missing;</root>`, 0, 0), code: xsderrors.CodeUnsupportedExternal},
 		{name: "syntax", err: errors.New("bad xml"), code: xsderrors.CodeValidationXML},
@@ -26,3 +26,19 @@ func RecoverableValidation(category xsderrors.Category, code xsderrors.Code) boo
 func RecoveryLimitReached(count, limit int) bool {
 	return limit > 0 && count >= limit
 }
+
+// ErrorAction tells validation what to do with an error reported to an
+// ErrorHandler.
+type ErrorAction uint8
+
+const (
+	// ErrorContinue keeps the error and continues validation.
+	ErrorContinue ErrorAction = iota
+	// ErrorStop keeps the error and stops validation.
+	ErrorStop
+	// ErrorSuppress drops the error and continues validation.
+	ErrorSuppress
+)
+
+// ErrorHandler receives recoverable validation errors as they are found.
+type ErrorHandler func(*xsderrors.Error) ErrorAction
@@ -101,3 +101,28 @@ func TestRecoveryLimitReached(t *testing.T) {
 		})
 	}
 }
+
+func TestReportNeverPassesNilToHandler(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	s := session{onError: func(x *xsderrors.Error) ErrorAction {
+		calls++
+		if x == nil {
+			t.Fatal("OnError received nil")
+		}
+		return ErrorSuppress
+	}}
+	if err := s.report((*xsderrors.Error)(nil)); err != nil {
+		t.Fatalf("report(nil diagnostic) = %v", err)
+	}
+	if calls != 0 || len(s.doc.errors) != 1 {
+		t.Fatalf("calls = %d, kept = %d, want the error kept without calling OnError", calls, len(s.doc.errors))
+	}
+	if err := s.report(xsderrors.Validation(xsderrors.CodeValidationElement, 1, 2, "/root", "unexpected element")); err != nil {
+		t.Fatalf("report() = %v", err)
+	}
+	if calls != 1 || len(s.doc.errors) != 1 {
+		t.Fatalf("calls = %d, kept = %d, want the suppressed error dropped", calls, len(s.doc.errors))
+	}
+}
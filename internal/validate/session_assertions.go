@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"encoding/xml"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/stream"
+	"github.com/jacoelho/xsd/internal/xmlns"
+	"github.com/jacoelho/xsd/internal/xpath"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// assertionNodeBytes approximates the fixed cost of one buffered node when
+// charging an assertion subtree against the instance text byte limit.
+const assertionNodeBytes = 64
+
+// startAssertionNode buffers the current element when its type carries
+// xs:assert assertions or an ancestor's subtree is already being buffered.
+// Elements outside asserted subtrees are never materialized.
+func (s *session) startAssertionNode(start schemaStart, parent *xpath.Node, se preparedXMLStart, attrs []stream.Attr, line, col int) error {
+	asserted := start.mode == elementAssessed && s.rt.ComplexAssertions(start.typ)
+	if parent == nil && !asserted {
+		return nil
+	}
+	f, ok := s.doc.Current()
+	if !ok {
+		return xsderrors.InternalInvariant("assertion start has no frame")
+	}
+	node := &xpath.Node{
+		Kind:   xpath.ElementNode,
+		Parent: parent,
+		Space:  se.name.Space,
+		Local:  se.name.Local,
+		Prefix: se.prefix,
+	}
+	if start.mode == elementAssessed {
+		s.annotateAssertionElement(node, f)
+	}
+	size := int64(assertionNodeBytes + len(se.name.Local))
+	for i := range attrs {
+		a := &attrs[i]
+		if xmlns.IsNamespaceName(a.Name) {
+			continue
+		}
+		value := a.StringValue(&s.valueStrings)
+		attr := &xpath.Node{
+			Kind:   xpath.AttributeNode,
+			Parent: node,
+			Space:  a.Name.Space,
+			Local:  a.Name.Local,
+			Text:   value,
+		}
+		if start.mode == elementAssessed {
+			attr.Type = s.assertionAttributeType(start.typ, a.Name)
+		}
+		node.Attrs = append(node.Attrs, attr)
+		size += int64(assertionNodeBytes + len(a.Name.Local) + len(value))
+	}
+	if err := s.chargeAssertionBytes(size, line, col); err != nil {
+		return err
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, node)
+	}
+	f.Assert = node
+	f.Asserted = asserted
+	return nil
+}
+
+func (s *session) annotateAssertionElement(node *xpath.Node, f *frame) {
+	if frameHasSimpleContent(f) {
+		node.Type = runtime.AssertionNodeType(f.SimpleContent)
+		return
+	}
+	content, ok := s.rt.ElementTextContent(f.Type, f.Element)
+	node.ElementOnly = ok && content.IsComplexType() && !content.AllowsMixedContent()
+}
+
+func (s *session) assertionAttributeType(typ runtime.TypeID, name xml.Name) uint32 {
+	set, isComplex, ok := s.attributeUseSetForType(typ)
+	if !isComplex || !ok {
+		return 0
+	}
+	rn := s.runtimeName(name)
+	if !rn.Known {
+		return 0
+	}
+	use, _, found := set.DeclaredUse(rn.Name)
+	if !found {
+		return 0
+	}
+	return runtime.AssertionNodeType(use.TypeID())
+}
+
+// bufferAssertionText appends character data to a buffered element,
+// coalescing adjacent text nodes.
+func (s *session) bufferAssertionText(node *xpath.Node, data []byte, line, col int) error {
+	if err := s.chargeAssertionBytes(int64(len(data)), line, col); err != nil {
+		return err
+	}
+	if n := len(node.Children); n != 0 && node.Children[n-1].Kind == xpath.TextNode {
+		last := node.Children[n-1]
+		last.Text += string(data)
+		return nil
+	}
+	node.Children = append(node.Children, &xpath.Node{Kind: xpath.TextNode, Parent: node, Text: string(data)})
+	return nil
+}
+
+func (s *session) chargeAssertionBytes(n int64, line, col int) error {
+	if s.maxInstanceTextBytes > 0 && s.doc.assertionBytes > s.maxInstanceTextBytes-n {
+		return validation(s.startContext(line, col), xsderrors.CodeValidationLimit, "assertion subtree byte limit exceeded")
+	}
+	s.doc.assertionBytes += n
+	return nil
+}
+
+// endAssertionNode evaluates the assertions of the current element against
+// its buffered subtree and releases the buffer once the outermost asserted
+// element ends.
+func (s *session) endAssertionNode(f *frame, line, col int) error {
+	node := f.Assert
+	if node == nil {
+		return nil
+	}
+	parent := node.Parent
+	if parent == nil {
+		s.doc.assertionBytes = 0
+	}
+	if !f.Asserted || f.Mode != elementAssessed {
+		return nil
+	}
+	// Assertions see the element as the root of its own tree.
+	node.Parent = nil
+	err := s.rt.ValidateComplexAssertions(f.Type, node, 0)
+	node.Parent = parent
+	if err != nil {
+		return validation(s.startContext(line, col), xsderrors.CodeValidationAssertion, err.Error())
+	}
+	return nil
+}
+
+// selectTypeAlternative evaluates the XSD 1.1 type alternatives of element
+// declaration id against the element's own attributes, which are untyped.
+func (s *session) selectTypeAlternative(id runtime.ElementID, name xml.Name, attrs []stream.Attr) (runtime.TypeID, bool) {
+	node := &xpath.Node{Kind: xpath.ElementNode, Space: name.Space, Local: name.Local}
+	for i := range attrs {
+		a := &attrs[i]
+		if xmlns.IsNamespaceName(a.Name) {
+			continue
+		}
+		node.Attrs = append(node.Attrs, &xpath.Node{
+			Kind:   xpath.AttributeNode,
+			Parent: node,
+			Space:  a.Name.Space,
+			Local:  a.Name.Local,
+			Text:   a.StringValue(&s.valueStrings),
+		})
+	}
+	return s.rt.SelectTypeAlternative(id, node, 0)
+}
@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"context"
 	"encoding/xml"
 
 	"github.com/jacoelho/xsd/internal/runtime"
@@ -75,7 +76,7 @@ func (s *session) validateAttributeSet(set runtime.AttributeUseSetRead, attrs []
 					continue
 				}
 				if err := s.validateDeclaredAttributeUse(use, rn, a, ctx, line, col, &seenIDAttr); err != nil {
-					if recoverErr := s.recoverAssessment(err); recoverErr != nil {
+					if recoverErr := s.recoverAssessment(s.locateAttribute(err, use.TypeID(), rn.Name)); recoverErr != nil {
 						return recoverErr
 					}
 				}
@@ -92,7 +93,8 @@ func (s *session) validateAttributeSet(set runtime.AttributeUseSetRead, attrs []
 		if handled {
 			continue
 		}
-		if err := s.recoverUnassessedIdentityAttribute(rn, ctx, attributeValidation(ctx, "attribute is not declared: "+rn.Label())); err != nil {
+		notDeclared := attributeExpected(ctx, "attribute is not declared: "+rn.Label(), foundName(rn), s.expectedAttributes(set, &seen))
+		if err := s.recoverUnassessedIdentityAttribute(rn, ctx, s.locateAttribute(notDeclared, runtime.NoSimpleType, rn.Name)); err != nil {
 			return err
 		}
 	}
@@ -118,7 +120,8 @@ func (s *session) validateSimpleTypeAttributes(attrs []stream.Attr, line, col in
 			}
 		}
 		rn := s.runtimeName(a.Name)
-		if err := s.recoverUnassessedIdentityAttribute(rn, ctx, attributeValidation(ctx, "simple type does not allow attributes")); err != nil {
+		notAllowed := attributeExpected(ctx, "simple type does not allow attributes", foundName(rn), &xsderrors.Expected{})
+		if err := s.recoverUnassessedIdentityAttribute(rn, ctx, s.locateAttribute(notAllowed, runtime.NoSimpleType, rn.Name)); err != nil {
 			return err
 		}
 	}
@@ -190,7 +193,7 @@ func (s *session) validateDeclaredAttributeUse(
 		if xsderrors.IsUnsupported(err) {
 			return err
 		}
-		return validation(ctx, xsderrors.CodeValidationFacet, "invalid attribute "+rn.Label()+": "+err.Error())
+		return validation(ctx, simpleValueErrorCode(err), "invalid attribute "+rn.Label()+": "+err.Error())
 	}
 	if err := s.recordAttributeIdentity(value, line, col, seenIDAttr); err != nil {
 		if invalidateErr := s.invalidateIdentityFields(identityFields); invalidateErr != nil {
@@ -278,7 +281,7 @@ func (s *session) rejectUnassessedIdentityAttribute(rn runtime.RuntimeName, ctx
 		return err
 	}
 	if report {
-		return s.doc.identity.RejectFieldsWithoutSimpleValue(fields, ctx)
+		return s.doc.identity.RejectFieldsWithoutSimpleValue(s.rt, fields, ctx)
 	}
 	return s.doc.identity.InvalidateFields(fields)
 }
@@ -329,7 +332,7 @@ func (s *session) validateKnownWildcardAttribute(
 		if xsderrors.IsUnsupported(err) {
 			return err
 		}
-		return validation(ctx, xsderrors.CodeValidationFacet, "invalid wildcard attribute "+rn.Label())
+		return locateValue(s.rt, validation(ctx, simpleValueErrorCode(err), "invalid wildcard attribute "+rn.Label()), err, typeID)
 	}
 	if err := s.recordAttributeIdentity(value, line, col, seenIDAttr); err != nil {
 		if invalidateErr := s.invalidateIdentityFields(identityFields); invalidateErr != nil {
@@ -400,7 +403,8 @@ func (s *session) validateRequiredAndDefaultAttributes(
 		if !ok {
 			return xsderrors.InternalInvariant("required attribute slot is invalid")
 		}
-		if err := s.recoverAssessment(attributeValidation(ctx, "missing required attribute "+use.Label())); err != nil {
+		missing := attributeExpected(ctx, "missing required attribute "+use.Label(), xml.Name{}, &xsderrors.Expected{Names: []xml.Name{expandedName(s.rt, use.Name())}})
+		if err := s.recoverAssessment(s.locateAttribute(missing, runtime.NoSimpleType, use.Name())); err != nil {
 			return err
 		}
 	}
@@ -478,6 +482,28 @@ func (s *session) recordSchemaLocationHints(attrs []stream.Attr, line, col int)
 	)
 }
 
+// loadDocumentSchemaLocations switches the session to a runtime that covers
+// the document element's hints. No element has been assessed yet, so the
+// switch cannot mix component IDs from two runtimes. Loaded hints are forgotten
+// so missing components report ordinary validation errors.
+func (s *session) loadDocumentSchemaLocations(ctx context.Context, attrs []stream.Attr) error {
+	hints := appendSchemaLocationHintAttributes(nil, attrs, &s.valueStrings)
+	rt, err := s.loadSchemaLocations(ctx, hints)
+	if err != nil {
+		return err
+	}
+	if rt != nil {
+		s.useSchema(rt)
+	}
+	s.doc.schemaLocationHints.Reset(maxRetainedMapLen)
+	return nil
+}
+
+func (s *session) useSchema(rt *runtime.Schema) {
+	s.rt = rt
+	s.hasIdentityConstraints = rt.HasIdentityConstraints()
+}
+
 func (s *session) hasSchemaLocationHint(ns string) bool {
 	return s.doc.schemaLocationHints.Has(ns)
 }
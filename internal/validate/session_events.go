@@ -0,0 +1,319 @@
+package validate
+
+import (
+	"encoding/xml"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/internal/stream"
+	"github.com/jacoelho/xsd/internal/xmlns"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// EventKind identifies a post-schema-validation infoset event.
+type EventKind uint8
+
+const (
+	// EventStartElement follows the assessment of an element start tag and
+	// its attributes.
+	EventStartElement EventKind = iota + 1
+	// EventEndElement follows the assessment of an element's content.
+	EventEndElement
+	// EventText reports character data of mixed content and of elements that
+	// were not assessed. It is only emitted when Options.EventText is set.
+	EventText
+)
+
+// Validity is the outcome of assessing an element or attribute.
+type Validity uint8
+
+const (
+	// ValidityNotKnown marks items that were not assessed.
+	ValidityNotKnown Validity = iota
+	// ValidityValid marks items that were assessed without errors.
+	ValidityValid
+	// ValidityInvalid marks items with at least one validation error.
+	ValidityInvalid
+)
+
+// Event is one post-schema-validation infoset event. Attributes is reused
+// between events and is only valid during the handler call. TypeID is the
+// governing type of an assessed element and ValueType the simple type Value
+// was assessed against.
+type Event struct {
+	Name              xml.Name
+	Type              xml.Name
+	Declaration       xml.Name
+	Attributes        []EventAttribute
+	Value             string
+	Canonical         string
+	Text              string
+	TypeID            runtime.TypeID
+	ValueType         runtime.SimpleTypeID
+	Line              int
+	Column            int
+	Kind              EventKind
+	Validity          Validity
+	Assessed          bool
+	GlobalDeclaration bool
+	Nilled            bool
+	HasValue          bool
+	DefaultApplied    bool
+}
+
+// EventAttribute is one attribute reported with an EventStartElement,
+// including attributes supplied by a default or fixed value constraint.
+// TypeID is NoSimpleType for attributes that were not assessed.
+type EventAttribute struct {
+	Name      xml.Name
+	Type      xml.Name
+	Value     string
+	Canonical string
+	TypeID    runtime.SimpleTypeID
+	Validity  Validity
+	Default   bool
+}
+
+// EventHandler receives events in document order. A non-nil error stops
+// validation and is returned unchanged.
+type EventHandler func(Event) error
+
+// emitStartEvent reports the current frame after its start tag has been
+// assessed.
+func (s *session) emitStartEvent(name xml.Name, attrs []stream.Attr, line, col int) error {
+	if s.events == nil || s.doc.syntaxOnly {
+		return nil
+	}
+	f, ok := s.doc.Current()
+	if !ok {
+		return xsderrors.InternalInvariant("start event has no schema frame")
+	}
+	ev := Event{Kind: EventStartElement, Name: name, Line: line, Column: col}
+	s.describeEventElement(&ev, f)
+	ev.Attributes = s.eventAttributes(f, attrs)
+	return s.events(ev)
+}
+
+// emitEndEvent reports the current frame after its content has been
+// assessed. It must run before the frame's text is released.
+func (s *session) emitEndEvent(f *frame, line, col int) (bool, error) {
+	ev := Event{Kind: EventEndElement, Name: s.doc.CurrentName(), Line: line, Column: col}
+	s.describeEventElement(&ev, f)
+	if f.Mode == elementAssessed {
+		ev.Validity = ValidityValid
+		if f.AssessmentInvalid || f.SubtreeInvalid {
+			ev.Validity = ValidityInvalid
+		}
+		if !f.Nilled {
+			if err := s.eventElementValue(&ev, f); err != nil {
+				return false, err
+			}
+		}
+	}
+	return ev.Validity == ValidityInvalid, s.events(ev)
+}
+
+// emitTextEvent reports character data of the current frame when text
+// events are requested.
+func (s *session) emitTextEvent(data []byte, line, col int) error {
+	if s.events == nil || !s.eventText || s.doc.syntaxOnly || len(data) == 0 {
+		return nil
+	}
+	return s.events(Event{Kind: EventText, Name: s.doc.CurrentName(), Text: string(data), Line: line, Column: col})
+}
+
+func (s *session) describeEventElement(ev *Event, f *frame) {
+	if f.Mode != elementAssessed {
+		return
+	}
+	ev.Assessed = true
+	ev.Nilled = f.Nilled
+	ev.Type = s.eventTypeName(f.Type)
+	ev.TypeID = f.Type
+	if f.Element == runtime.NoElement {
+		return
+	}
+	if name, global, ok := s.rt.ElementName(f.Element); ok {
+		ev.Declaration = s.eventName(name)
+		ev.GlobalDeclaration = global
+	}
+}
+
+func (s *session) eventElementValue(ev *Event, f *frame) error {
+	typeID := f.SimpleContent
+	hasSimpleContent := f.HasSimpleContent
+	if !f.SimpleContentKnown {
+		var ok bool
+		typeID, hasSimpleContent, ok = s.simpleContentType(f.Type)
+		if !ok {
+			return xsderrors.InternalInvariant("simple content type metadata is invalid")
+		}
+	}
+	if !hasSimpleContent {
+		return nil
+	}
+	rawText := s.doc.text[f.TextStart:]
+	lexical := s.valueStrings.Intern(rawText)
+	if len(rawText) == 0 && f.ElementDeclared && f.ElementHasValueConstraint {
+		constraints, declared, ok := s.elementValueConstraints(f.Element)
+		if !ok {
+			return xsderrors.InternalInvariant("element value constraint metadata is invalid")
+		}
+		if vc, ok := absentElementValueConstraint(constraints); ok && declared {
+			lexical = vc.LexicalText()
+			ev.DefaultApplied = true
+		}
+	}
+	ev.HasValue = true
+	ev.ValueType = typeID
+	ev.Value = s.rt.NormalizeSimpleValue(typeID, lexical)
+	if ev.Validity != ValidityValid {
+		return nil
+	}
+	value, err := s.validateSimpleValue(typeID, lexical, s.simpleValueQNameResolver(typeID), runtime.SimpleNeedCanonical)
+	if err == nil {
+		ev.Canonical = value.CanonicalText()
+	}
+	return nil
+}
+
+func absentElementValueConstraint(constraints runtime.ElementValueConstraints) (runtime.ValueConstraintRead, bool) {
+	if fixed, ok := constraints.FixedValue(); ok {
+		return fixed, true
+	}
+	return constraints.DefaultValueConstraint()
+}
+
+// eventAttributes assesses attrs again for reporting. Validation has already
+// recorded any errors, so failures here only set the attribute's validity.
+func (s *session) eventAttributes(f *frame, attrs []stream.Attr) []EventAttribute {
+	out := s.eventAttrs[:0]
+	var set runtime.AttributeUseSetRead
+	hasSet := false
+	if f.Mode == elementAssessed {
+		var isComplex, ok bool
+		set, isComplex, ok = s.attributeUseSetForType(f.Type)
+		hasSet = isComplex && ok
+	}
+	for i := range attrs {
+		a := &attrs[i]
+		if xmlns.IsNamespaceName(a.Name) {
+			continue
+		}
+		attr := EventAttribute{Name: a.Name, Value: a.StringValue(&s.valueStrings), TypeID: runtime.NoSimpleType}
+		if f.Mode == elementAssessed && !isXSIAttributeName(a.Name) {
+			s.assessEventAttribute(&attr, set, hasSet)
+		}
+		out = append(out, attr)
+	}
+	if hasSet {
+		out = s.appendDefaultEventAttributes(out, set, attrs)
+	}
+	s.eventAttrs = out
+	return out
+}
+
+func (s *session) assessEventAttribute(attr *EventAttribute, set runtime.AttributeUseSetRead, hasSet bool) {
+	attr.Validity = ValidityInvalid
+	if !hasSet {
+		return
+	}
+	rn := s.runtimeName(attr.Name)
+	if rn.Known {
+		if use, _, ok := set.DeclaredUse(rn.Name); ok {
+			fixed, hasFixed := use.FixedValue()
+			s.assessEventAttributeValue(attr, use.TypeID(), fixed, hasFixed, use.FixedUsesValueSpace())
+			return
+		}
+	}
+	match, ok := MatchAttributeWildcard(s.rt, set.Wildcard(), rn)
+	if !ok || !match.Matched {
+		return
+	}
+	switch {
+	case match.HasAttribute:
+		if decl, ok := s.attributeDecl(match.Attribute); ok {
+			fixed, hasFixed := decl.FixedValue()
+			s.assessEventAttributeValue(attr, decl.TypeID(), fixed, hasFixed, true)
+		}
+	case match.Skip, match.LaxMissing:
+		attr.Validity = ValidityNotKnown
+	}
+}
+
+func (s *session) assessEventAttributeValue(
+	attr *EventAttribute,
+	typeID runtime.SimpleTypeID,
+	fixed runtime.ValueConstraintRead,
+	hasFixed, valueSpace bool,
+) {
+	attr.Type = s.eventTypeName(runtime.SimpleRef(typeID))
+	attr.TypeID = typeID
+	lexical := attr.Value
+	attr.Value = s.rt.NormalizeSimpleValue(typeID, lexical)
+	needs := runtime.SimpleNeedCanonical
+	if hasFixed {
+		needs |= runtime.SimpleNeedIdentity
+	}
+	value, err := s.validateSimpleValue(typeID, lexical, s.simpleValueQNameResolver(typeID), needs)
+	if err != nil {
+		return
+	}
+	if hasFixed {
+		if equal, _ := runtime.FixedAttributeValueEqual(value, fixed, valueSpace); !equal {
+			return
+		}
+	}
+	attr.Canonical = value.CanonicalText()
+	attr.Validity = ValidityValid
+}
+
+func (s *session) appendDefaultEventAttributes(out []EventAttribute, set runtime.AttributeUseSetRead, attrs []stream.Attr) []EventAttribute {
+	slots := set.ValueConstraintSlots()
+	for i := range slots.Len() {
+		slot, ok := slots.At(i)
+		if !ok {
+			continue
+		}
+		use, ok := set.UseAt(int(slot))
+		if !ok || use.Required() || s.eventAttributePresent(use.Name(), attrs) {
+			continue
+		}
+		vc, ok := use.AbsentValueConstraint()
+		if !ok {
+			continue
+		}
+		out = append(out, EventAttribute{
+			Name:      s.eventName(use.Name()),
+			Type:      s.eventTypeName(runtime.SimpleRef(use.TypeID())),
+			Value:     s.rt.NormalizeSimpleValue(use.TypeID(), vc.LexicalText()),
+			Canonical: vc.CanonicalText(),
+			TypeID:    use.TypeID(),
+			Validity:  ValidityValid,
+			Default:   true,
+		})
+	}
+	return out
+}
+
+func (s *session) eventAttributePresent(name runtime.QName, attrs []stream.Attr) bool {
+	for i := range attrs {
+		rn := s.runtimeName(attrs[i].Name)
+		if rn.Known && rn.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *session) eventTypeName(id runtime.TypeID) xml.Name {
+	name, ok := s.rt.TypeName(id)
+	if !ok {
+		return xml.Name{}
+	}
+	return s.eventName(name)
+}
+
+func (s *session) eventName(q runtime.QName) xml.Name {
+	name := s.rt.ExpandedName(q)
+	return xml.Name{Space: name.Namespace, Local: name.Local}
+}
@@ -81,7 +81,7 @@ func (s *session) validateSimpleContent(f *frame, line, col int) (bool, error) {
 		if xsderrors.IsUnsupported(err) {
 			return false, err
 		}
-		return false, validation(ctx, xsderrors.CodeValidationFacet, "invalid simple content: "+err.Error())
+		return false, validation(ctx, simpleValueErrorCode(err), "invalid simple content: "+err.Error())
 	}
 	if err := s.recordIdentityValue(value, line, col); err != nil {
 		if invalidateErr := s.invalidateIdentityFields(identityFields); invalidateErr != nil {
@@ -312,7 +312,7 @@ func (s *session) captureIdentityFieldKey(fields []IdentityFieldMatch, key strin
 	if len(fields) == 0 {
 		return nil
 	}
-	return s.doc.identity.CaptureFields(fields, key, s.startContext(line, col))
+	return s.doc.identity.CaptureFields(s.rt, fields, key, s.startContext(line, col))
 }
 
 func (s *session) captureSimpleValueIdentityFields(fields []IdentityFieldMatch, value runtime.SimpleValue, ctx StartContext) error {
@@ -360,7 +360,7 @@ func (s *session) rejectIdentityElementWithoutSimpleValue(line, col int) error {
 	if err != nil {
 		return err
 	}
-	return s.doc.identity.RejectFieldsWithoutSimpleValue(fields, s.startContext(line, col))
+	return s.doc.identity.RejectFieldsWithoutSimpleValue(s.rt, fields, s.startContext(line, col))
 }
 
 func (s *session) rejectUnassessedIdentityElement(line, col int, report bool) error {
@@ -372,7 +372,7 @@ func (s *session) rejectUnassessedIdentityElement(line, col int, report bool) er
 		return err
 	}
 	if report {
-		return s.recover(s.doc.identity.RejectFieldsWithoutSimpleValue(fields, s.startContext(line, col)))
+		return s.recover(s.doc.identity.RejectFieldsWithoutSimpleValue(s.rt, fields, s.startContext(line, col)))
 	}
 	return s.doc.identity.InvalidateFields(fields)
 }
@@ -458,7 +458,7 @@ func (s *session) closeIdentityScopes(depth int) (bool, error) {
 	if !s.hasIdentityConstraints {
 		return false, nil
 	}
-	return s.doc.identity.CloseScopes(depth, func(err error) error {
+	return s.doc.identity.CloseScopes(s.rt, depth, func(err error) error {
 		return s.recover(err)
 	})
 }
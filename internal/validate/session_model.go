@@ -24,7 +24,7 @@ func (s *session) acceptPublishedSchemaChild(parent *frame, rn runtime.RuntimeNa
 	}
 	policy := childFramePolicy(parent.Nilled)
 	if policy.issue.valid() {
-		return s.recoverablePublishedSchemaChildIssue(line, col, policy.issue)
+		return s.recoverablePublishedSchemaChildIssue(parent, line, col, policy.issue)
 	}
 	parentContent := parent.Child
 	if !parent.ChildOK {
@@ -35,7 +35,7 @@ func (s *session) acceptPublishedSchemaChild(parent *frame, rn runtime.RuntimeNa
 		}
 	}
 	if issue := childContentPolicy(parentContent, parent.Content, rn); issue.valid() {
-		return s.recoverablePublishedSchemaChildIssue(line, col, issue)
+		return s.recoverablePublishedSchemaChildIssue(parent, line, col, issue)
 	}
 	st := parent.Content
 	scratch := s.contentScratch(parent)
@@ -47,14 +47,14 @@ func (s *session) acceptPublishedSchemaChild(parent *frame, rn runtime.RuntimeNa
 		return acceptedChild{}, xsderrors.InternalInvariant("content model state is invalid")
 	}
 	if status == runtime.ContentAdvanceNoMatch {
-		return s.recoverablePublishedSchemaChildIssue(line, col, unexpectedChildIssue(rn))
+		return s.recoverablePublishedSchemaChildIssue(parent, line, col, unexpectedChildIssue(rn, s.expectedContent(parent)))
 	}
 	if match.StrictMissing {
 		if hasSchemaLocation := s.schemaLocationHintLookup(); hasSchemaLocation != nil && hasSchemaLocation(rn.NS) {
 			return acceptedChild{}, unsupportedSchemaLocation(s.startContext(line, col), vocab.XSDElemElement, rn)
 		}
 		parent.Content = st
-		return s.recoverablePublishedSchemaChildIssue(line, col, strictMissingChildIssue(rn))
+		return s.recoverablePublishedSchemaChildIssue(parent, line, col, strictMissingChildIssue(rn))
 	}
 	parent.Content = st
 	if match.Element == runtime.NoElement {
@@ -70,14 +70,14 @@ func (s *session) acceptPublishedSchemaChild(parent *frame, rn runtime.RuntimeNa
 	return acceptedChild{start: assessedSchemaStart(match.Element, decl.Type)}, nil
 }
 
-func (s *session) recoverablePublishedSchemaChildIssue(line, col int, issue validationIssue) (acceptedChild, error) {
-	return s.recoverablePublishedSchemaChildIssueAt(s.startContext(line, col), issue)
+func (s *session) recoverablePublishedSchemaChildIssue(parent *frame, line, col int, issue validationIssue) (acceptedChild, error) {
+	return s.recoverablePublishedSchemaChildIssueAt(parent, s.startContext(line, col), issue)
 }
 
-func (s *session) recoverablePublishedSchemaChildIssueAt(ctx StartContext, issue validationIssue) (acceptedChild, error) {
+func (s *session) recoverablePublishedSchemaChildIssueAt(parent *frame, ctx StartContext, issue validationIssue) (acceptedChild, error) {
 	out := acceptedChild{start: recoverySchemaStart()}
 	out.recover = true
-	return out, validationFromIssue(ctx, issue)
+	return out, s.locateFrame(validationFromIssue(ctx, issue), parent)
 }
 
 func (s *session) end(line, col int, ee stream.EndElement) error {
@@ -92,6 +92,9 @@ func (s *session) end(line, col int, ee stream.EndElement) error {
 		return xsderrors.InternalInvariant("end element has no schema frame")
 	}
 	stop := s.validateFrameEnd(f, line, col)
+	if stop == nil {
+		stop = s.recoverAssessment(s.endAssertionNode(f, line, col))
+	}
 	if errors.Is(stop, errSemanticStop) {
 		stop = nil
 	} else if stop == nil && s.hasIdentityConstraints {
@@ -100,6 +103,14 @@ func (s *session) end(line, col int, ee stream.EndElement) error {
 			stop = nil
 		}
 	}
+	var subtreeInvalid bool
+	if s.events != nil && !s.doc.syntaxOnly && stop == nil {
+		invalid, err := s.emitEndEvent(f, line, col)
+		if err != nil {
+			return err
+		}
+		subtreeInvalid = invalid
+	}
 	s.doc.allBits = s.doc.allBits[:f.BitBase]
 	s.doc.text = s.doc.text[:f.TextStart]
 	if s.hasIdentityConstraints && len(s.doc.namePath) > 0 {
@@ -110,6 +121,9 @@ func (s *session) end(line, col int, ee stream.EndElement) error {
 	if err := s.doc.CommitEnd(); err != nil {
 		return err
 	}
+	if parent, ok := s.doc.Current(); ok && subtreeInvalid {
+		parent.SubtreeInvalid = true
+	}
 	return stop
 }
 
@@ -125,7 +139,7 @@ func (s *session) validateFrameEnd(f *frame, line, col int) error {
 	}
 	if !f.Nilled {
 		if err := s.completeFrame(f, line, col); err != nil {
-			if recoverErr := s.recoverAssessment(err); recoverErr != nil {
+			if recoverErr := s.recoverAssessment(s.locateFrame(err, f)); recoverErr != nil {
 				return recoverErr
 			}
 		}
@@ -137,7 +151,7 @@ func (s *session) validateFrameEnd(f *frame, line, col int) error {
 	}
 	contentCaptured, err := s.validateSimpleContent(f, line, col)
 	if err != nil {
-		return s.recoverAssessment(err)
+		return s.recoverAssessment(s.locateSimpleContent(err, f))
 	}
 	if !s.hasIdentityConstraints {
 		return nil
@@ -229,7 +243,7 @@ func (s *session) completePublishedSchemaFrame(f *frame, line, col int) error {
 	if status == runtime.ContentCompletionComplete {
 		return nil
 	}
-	return validationFromIssue(s.startContext(line, col), missingRequiredChildIssue())
+	return validationFromIssue(s.startContext(line, col), missingRequiredChildIssue(s.expectedContent(f)))
 }
 
 func (s *session) contentScratch(f *frame) runtime.ContentScratch {
@@ -328,6 +328,66 @@ func TestSessionPopPathReturnsCachedParentPath(t *testing.T) {
 	}
 }
 
+func TestSessionIndexedClarkPaths(t *testing.T) {
+	var s session
+	s.doc.indexedPaths = true
+	s.doc.clarkPaths = true
+	start := func(space, local string) {
+		s.doc.CommitStart(preparedXMLStart{name: xml.Name{Space: space, Local: local}, prefix: "p"}, false, frame{})
+	}
+	end := func() {
+		if err := s.doc.CommitEnd(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start("urn:order", "order")
+	for range 2 {
+		start("urn:order", "line")
+		end()
+	}
+	start("", "note")
+	end()
+	start("urn:order", "line")
+	start("", "qty")
+	if got, want := s.doc.PathString(), "/{urn:order}order/{urn:order}line[3]/qty[1]"; got != want {
+		t.Fatalf("PathString() after another sibling = %q, want %q", got, want)
+	}
+	end()
+	start("", "qty")
+	if got, want := s.doc.PathString(), "/{urn:order}order/{urn:order}line[3]/qty[2]"; got != want {
+		t.Fatalf("PathString() = %q, want %q", got, want)
+	}
+	end()
+	end()
+	start("urn:order", "line")
+	start("", "qty")
+	if got, want := s.doc.PathString(), "/{urn:order}order/{urn:order}line[4]/qty[1]"; got != want {
+		t.Fatalf("PathString() after closing the previous line = %q, want %q", got, want)
+	}
+}
+
+func TestSessionIndexedPathsBoundSiblingNames(t *testing.T) {
+	var s session
+	s.doc.indexedPaths = true
+	s.doc.maxSiblingNames = 2
+	s.doc.CommitStart(preparedXMLStart{name: xml.Name{Local: "order"}}, false, frame{})
+	for _, tt := range []struct{ local, want string }{
+		{local: "line", want: "/order/line[1]"},
+		{local: "note", want: "/order/note[1]"},
+		{local: "memo", want: "/order/memo"},
+		{local: "line", want: "/order/line[2]"},
+	} {
+		s.doc.CommitStart(preparedXMLStart{name: xml.Name{Local: tt.local}}, false, frame{})
+		if got := s.doc.PathString(); got != tt.want {
+			t.Fatalf("PathString() = %q, want %q", got, tt.want)
+		}
+		if err := s.doc.CommitEnd(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestSessionLifecycleZeroesReleasedReferences(t *testing.T) {
 	rt, err := compile.Compile(context.Background(), compile.Options{}, []source.Source{source.Bytes("schema.xsd", []byte(`
 <xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
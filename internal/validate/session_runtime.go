@@ -2,6 +2,7 @@ package validate
 
 import (
 	"context"
+	"encoding/xml"
 	"errors"
 	"io"
 	"slices"
@@ -11,6 +12,7 @@ import (
 	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/internal/stream"
 	"github.com/jacoelho/xsd/internal/vocab"
+	"github.com/jacoelho/xsd/internal/xpath"
 	"github.com/jacoelho/xsd/xsderrors"
 )
 
@@ -51,6 +53,13 @@ func initializeSession(s *session, rt *runtime.Schema, opts Options) error {
 	hasIdentityConstraints := rt.HasIdentityConstraints()
 	*s = session{
 		rt:                              rt,
+		schema:                          rt,
+		loadSchemaLocations:             opts.SchemaLocations,
+		charsets:                        opts.Charsets,
+		xml11:                           opts.XML11,
+		events:                          opts.Events,
+		onError:                         opts.OnError,
+		eventText:                       opts.EventText,
 		hasIdentityConstraints:          hasIdentityConstraints,
 		maxErrors:                       limits.Errors,
 		maxIdentityScopes:               limits.IdentityScopes,
@@ -64,6 +73,9 @@ func initializeSession(s *session, rt *runtime.Schema, opts Options) error {
 		maxInstanceTokenBytes:           limits.InstanceTokenBytes,
 		maxInstanceBytes:                limits.InstanceBytes,
 	}
+	s.doc.indexedPaths = opts.IndexedPaths
+	s.doc.maxSiblingNames = limits.InstanceAttributes
+	s.doc.clarkPaths = opts.ClarkPaths
 	return nil
 }
 
@@ -100,13 +112,19 @@ func (s *Session) Validate(ctx context.Context, r io.Reader) error {
 // documents: options, the reader buffer and parser, and the string caches.
 type session struct {
 	rt                              *runtime.Schema
+	schema                          *runtime.Schema
+	loadSchemaLocations             SchemaLocationLoader
+	charsets                        stream.CharsetLookup
 	resolveLexicalQNamePartsFunc    runtime.ResolveQNameParts
+	events                          EventHandler
+	onError                         ErrorHandler
 	doc                             documentState
 	nameStrings                     stream.Cache
 	valueStrings                    stream.Cache
 	derivationScratch               runtime.TypeDerivationScratch
 	stringPatternScratch            runtime.StringPatternScratch
 	attributeSeen                   []bool
+	eventAttrs                      []EventAttribute
 	parser                          stream.Parser
 	maxErrors                       int
 	maxIdentityScopes               int
@@ -120,6 +138,8 @@ type session struct {
 	maxInstanceTokenBytes           int64
 	maxInstanceBytes                int64
 	hasIdentityConstraints          bool
+	xml11                           bool
+	eventText                       bool
 }
 
 // documentState is the mutable state of one document validation. XML syntax
@@ -140,6 +160,7 @@ type documentState struct {
 	namePath            []runtime.RuntimeName
 	errors              []error
 	text                []byte
+	assertionBytes      int64
 	syntaxOnly          bool
 }
 
@@ -164,6 +185,13 @@ type frame struct {
 	ElementDeclared           bool
 	ElementHasValueConstraint bool
 	AssessmentInvalid         bool
+	// SubtreeInvalid marks elements with an invalid descendant. It is only
+	// tracked when events are reported.
+	SubtreeInvalid bool
+	// Assert is the buffered node of an element inside an asserted subtree;
+	// Asserted marks the elements whose own type carries assertions.
+	Assert   *xpath.Node
+	Asserted bool
 }
 
 type elementMode uint8
@@ -188,9 +216,11 @@ func (s *session) validate(ctx context.Context, r io.Reader) error {
 	done := ctx.Done()
 	if err := s.parser.ResetWithLimits(r, &s.nameStrings, &s.valueStrings, stream.Limits{
 		Context:       ctx,
+		Charsets:      s.charsets,
 		MaxInputBytes: s.maxInstanceBytes,
 		MaxTokenBytes: s.maxInstanceTokenBytes,
 		MaxAttrs:      s.maxInstanceAttributes,
+		XML11:         s.xml11,
 	}); err != nil {
 		if done != nil {
 			if contextErr := validationContextDoneError(ctx, done, err); contextErr != nil {
@@ -216,7 +246,7 @@ func (s *session) validate(ctx context.Context, r io.Reader) error {
 		syntaxOnly := s.doc.syntaxOnly
 		switch tok.Kind {
 		case stream.KindStart:
-			if err := s.start(tok.Line, tok.Column, tok.Start); err != nil {
+			if err := s.start(ctx, tok.Line, tok.Column, tok.Start); err != nil {
 				return err
 			}
 		case stream.KindEnd:
@@ -281,6 +311,9 @@ func (s *session) finishValidation(ctx context.Context, done <-chan struct{}) er
 // state across documents.
 func (s *session) reset() {
 	s.parser.Detach()
+	if s.rt != s.schema {
+		s.useSchema(s.schema)
+	}
 	s.derivationScratch.Reset(maxRetainedMapLen)
 	s.stringPatternScratch.Reset(maxRetainedSliceCap)
 	xmlDocument := s.doc.xmlDocument
@@ -340,6 +373,9 @@ func (s *session) recover(err error) error {
 	if !RecoverableError(err) {
 		return err
 	}
+	if s.onError != nil {
+		return s.report(err)
+	}
 	if !RecoveryLimitReached(len(s.doc.errors), s.maxErrors) {
 		s.doc.errors = append(s.doc.errors, err)
 		if RecoveryLimitReached(len(s.doc.errors), s.maxErrors) {
@@ -350,6 +386,27 @@ func (s *session) recover(err error) error {
 	return nil
 }
 
+// report passes a recoverable err to the OnError handler. Only the first
+// error the handler keeps is retained, as the result of validation; stopping
+// returns it so that validation ends with it. An err without a diagnostic is
+// kept without calling the handler, which never receives nil.
+func (s *session) report(err error) error {
+	action := ErrorContinue
+	if diagnostic, ok := errors.AsType[*xsderrors.Error](err); ok && diagnostic != nil {
+		action = s.onError(diagnostic)
+	}
+	if action == ErrorSuppress {
+		return nil
+	}
+	if len(s.doc.errors) == 0 {
+		s.doc.errors = append(s.doc.errors, err)
+	}
+	if action == ErrorStop {
+		return s.doc.errors[0]
+	}
+	return nil
+}
+
 func (s *session) recoverAssessment(err error) error {
 	if assessmentFailure(err) {
 		if current, ok := s.doc.Current(); ok && current.Mode == elementAssessed {
@@ -377,7 +434,7 @@ func (s *session) discardSemanticState() {
 	s.attributeSeen = nil
 }
 
-func (s *session) start(line, col int, token stream.StartElement) error {
+func (s *session) start(ctx context.Context, line, col int, token stream.StartElement) error {
 	if s.doc.syntaxOnly {
 		return s.syntaxStart(line, col, token)
 	}
@@ -397,6 +454,11 @@ func (s *session) start(line, col int, token stream.StartElement) error {
 				s.doc.AbortStart()
 				return recoverErr
 			}
+		} else if s.loadSchemaLocations != nil && s.doc.Depth() == 0 {
+			if loadErr := s.loadDocumentSchemaLocations(ctx, token.Attr); loadErr != nil {
+				s.doc.AbortStart()
+				return loadErr
+			}
 		}
 	}
 	rn := s.runtimeName(se.name)
@@ -415,7 +477,7 @@ func (s *session) start(line, col int, token stream.StartElement) error {
 	if start.mode == elementAssessed {
 		ctx := s.startContext(line, col)
 		decl, declared = s.rt.Element(start.element)
-		nilled, err = s.assessElementStart(&start, decl, declared, token.Attr, xsiFlags, ctx)
+		nilled, err = s.assessElementStart(&start, decl, declared, se.name, token.Attr, xsiFlags, ctx)
 		if err != nil {
 			if errors.Is(err, errSemanticStop) {
 				s.doc.CommitStart(se, false, frame{})
@@ -429,6 +491,10 @@ func (s *session) start(line, col int, token stream.StartElement) error {
 			declared = false
 		}
 	}
+	var assertParent *xpath.Node
+	if parent, ok := s.doc.Current(); ok {
+		assertParent = parent.Assert
+	}
 	schemaFrame := s.newSchemaFrame(
 		start,
 		nilled,
@@ -449,7 +515,10 @@ func (s *session) start(line, col int, token stream.StartElement) error {
 		}
 		return attrErr
 	}
-	return nil
+	if err := s.startAssertionNode(start, assertParent, se, token.Attr, line, col); err != nil {
+		return err
+	}
+	return s.emitStartEvent(se.name, token.Attr, line, col)
 }
 
 func (s *session) startFrameIdentity(start schemaStart, rn runtime.RuntimeName, line, col int) error {
@@ -492,6 +561,7 @@ func (s *session) assessElementStart(
 	start *schemaStart,
 	decl runtime.ElementStartInfo,
 	declared bool,
+	name xml.Name,
 	attrs []stream.Attr,
 	flags xsiStartAttributeFlags,
 	ctx StartContext,
@@ -501,6 +571,13 @@ func (s *session) assessElementStart(
 		err := validation(ctx, xsderrors.CodeValidationElement, "abstract element cannot appear directly")
 		return false, s.recoverElementStartAssessment(start, err)
 	}
+	if declared && decl.Alternatives {
+		selected, ok := s.selectTypeAlternative(start.element, name, attrs)
+		if !ok {
+			return false, xsderrors.InternalInvariant("element type alternatives are invalid")
+		}
+		start.typ = selected
+	}
 	info, infoKnown := s.rt.TypeInfo(start.typ)
 	if !infoKnown {
 		return false, xsderrors.InternalInvariant("start type metadata is invalid")
@@ -577,7 +654,7 @@ func (s *session) recoverElementStartAssessment(start *schemaStart, err error) e
 	if assessmentFailure(err) {
 		start.invalid = true
 	}
-	return s.recover(err)
+	return s.recover(locate(s.rt, err, elementKey(start.element)))
 }
 
 type schemaStart struct {
@@ -736,9 +813,17 @@ func (s *session) chars(line, col int, data []byte, cdata bool) error {
 	if !ok {
 		return ValidateDocumentCharacterData(data, cdata, s.startContext(line, col))
 	}
-	if len(data) == 0 || f.Mode != elementAssessed {
+	if f.Assert != nil && len(data) != 0 {
+		if err := s.bufferAssertionText(f.Assert, data, line, col); err != nil {
+			return err
+		}
+	}
+	if len(data) == 0 {
 		return nil
 	}
+	if f.Mode != elementAssessed {
+		return s.emitTextEvent(data, line, col)
+	}
 	if f.Nilled {
 		return validation(s.startContext(line, col), xsderrors.CodeValidationNil, "nilled element must be empty")
 	}
@@ -760,7 +845,7 @@ func (s *session) chars(line, col int, data []byte, cdata bool) error {
 		if content.HasFixedElementValue() {
 			return s.appendText(data, line, col)
 		}
-		return nil
+		return s.emitTextEvent(data, line, col)
 	}
 	if content.IsComplexType() && !whitespace {
 		ctx := s.startContext(line, col)
@@ -13,3 +13,12 @@ func simpleValueMetadataInvariant(err error) error {
 	}
 	return nil
 }
+
+// simpleValueErrorCode classifies a simple value validation failure: failed
+// xs:assertion facets report assertion errors, everything else a facet error.
+func simpleValueErrorCode(err error) xsderrors.Code {
+	if _, ok := errors.AsType[*runtime.AssertionError](err); ok {
+		return xsderrors.CodeValidationAssertion
+	}
+	return xsderrors.CodeValidationFacet
+}
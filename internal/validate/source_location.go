@@ -0,0 +1,131 @@
+package validate
+
+import (
+	"errors"
+
+	"github.com/jacoelho/xsd/internal/runtime"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// locate attaches to err the source location of the first component of keys
+// that rt retains one for.
+func locate(rt *runtime.Schema, err error, keys ...runtime.LocationKey) error {
+	if err == nil || !rt.HasSourceLocations() {
+		return err
+	}
+	for _, key := range keys {
+		if loc, ok := rt.SourceLocation(key); ok {
+			return withSourceLocation(loc, err)
+		}
+	}
+	return err
+}
+
+// locateValue attaches to err, a diagnostic for a value of simple type typ
+// that cause rejected, the source location of the failed facet, falling
+// back to the type and then to keys.
+func locateValue(rt *runtime.Schema, err, cause error, typ runtime.SimpleTypeID, keys ...runtime.LocationKey) error {
+	if err == nil || !rt.HasSourceLocations() {
+		return err
+	}
+	if facet, ok := runtime.FailedFacet(cause); ok {
+		if loc, ok := rt.FacetSourceLocation(typ, facet); ok {
+			return withSourceLocation(loc, err)
+		}
+	}
+	return locate(rt, err, append([]runtime.LocationKey{componentKey(runtime.AnnotatedSimpleType, uint32(typ))}, keys...)...)
+}
+
+func withSourceLocation(loc runtime.SourceLocation, err error) error {
+	return xsderrors.WithSchemaLocation(xsderrors.SchemaLocation{
+		Source:    loc.Source,
+		Component: loc.Component,
+		Line:      loc.Line,
+		Column:    loc.Column,
+	}, err)
+}
+
+func componentKey(kind runtime.AnnotatedKind, id uint32) runtime.LocationKey {
+	return runtime.LocationKey{AnnotationKey: runtime.AnnotationKey{Kind: kind, ID: id}}
+}
+
+func elementKey(id runtime.ElementID) runtime.LocationKey {
+	return componentKey(runtime.AnnotatedElement, uint32(id))
+}
+
+func identityKey(id runtime.IdentityConstraintID) runtime.LocationKey {
+	return componentKey(runtime.AnnotatedIdentity, uint32(id))
+}
+
+// typeKey returns the location key of type definition typ.
+func typeKey(typ runtime.TypeID) runtime.LocationKey {
+	if id, ok := typ.Complex(); ok {
+		return componentKey(runtime.AnnotatedComplexType, uint32(id))
+	}
+	id, _ := typ.Simple()
+	return componentKey(runtime.AnnotatedSimpleType, uint32(id))
+}
+
+// attributeUseKey returns the location key of the use of attribute name by
+// complex type typ.
+func attributeUseKey(rt *runtime.Schema, typ runtime.TypeID, name runtime.QName) runtime.LocationKey {
+	id, _ := typ.Complex()
+	ct, _ := rt.ComplexTypeComponent(id)
+	return runtime.LocationKey{AnnotationKey: runtime.AnnotationKey{Kind: runtime.AnnotatedAttributeUse, ID: uint32(ct.Attrs), Name: name}}
+}
+
+// locateFrame attaches to err, a diagnostic about the content of the element
+// of f, the location of its type, falling back to its declaration.
+func (s *session) locateFrame(err error, f *frame) error {
+	if err == nil || !s.rt.HasSourceLocations() {
+		return err
+	}
+	return locate(s.rt, err, typeKey(f.Type), elementKey(f.Element))
+}
+
+// locateSimpleContent attaches to err, a diagnostic about the simple content
+// of the element of f, the location of the facet or type that rejected the
+// value. Other diagnostics, such as a fixed value mismatch, get the location
+// of the element's declaration.
+func (s *session) locateSimpleContent(err error, f *frame) error {
+	if err == nil || !s.rt.HasSourceLocations() {
+		return err
+	}
+	typ := f.SimpleContent
+	if !f.SimpleContentKnown {
+		typ, _, _ = s.simpleContentType(f.Type)
+	}
+	if !valueError(err) {
+		return locate(s.rt, err, elementKey(f.Element), typeKey(f.Type))
+	}
+	return locateValue(s.rt, err, err, typ, typeKey(f.Type), elementKey(f.Element))
+}
+
+// locateAttribute attaches to err, a diagnostic about attribute name of the
+// element being validated, the location of the facet or type of simple type
+// typ that rejected its value or else of its attribute use, falling back to
+// the element's type and declaration.
+func (s *session) locateAttribute(err error, typ runtime.SimpleTypeID, name runtime.QName) error {
+	if err == nil || !s.rt.HasSourceLocations() {
+		return err
+	}
+	f, ok := s.doc.Current()
+	if !ok {
+		return err
+	}
+	keys := []runtime.LocationKey{typeKey(f.Type), elementKey(f.Element)}
+	if _, ok := f.Type.Complex(); ok {
+		keys = append([]runtime.LocationKey{attributeUseKey(s.rt, f.Type, name)}, keys...)
+	}
+	if typ == runtime.NoSimpleType || !valueError(err) {
+		return locate(s.rt, err, keys...)
+	}
+	return locateValue(s.rt, err, err, typ, keys...)
+}
+
+// valueError reports whether err is a diagnostic for a value its simple type
+// rejected.
+func valueError(err error) bool {
+	diagnostic, ok := errors.AsType[*xsderrors.Error](err)
+	return ok && (diagnostic.Code == xsderrors.CodeValidationFacet || diagnostic.Code == xsderrors.CodeValidationAssertion)
+}
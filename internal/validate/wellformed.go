@@ -21,6 +21,8 @@ func CheckXMLWellFormed(ctx context.Context, r io.Reader, opts Options) error {
 		maxAttributes: limits.InstanceAttributes,
 		maxTokenBytes: limits.InstanceTokenBytes,
 		maxInputBytes: limits.InstanceBytes,
+		charsets:      opts.Charsets,
+		xml11:         opts.XML11,
 	}
 	return c.check(ctx, r)
 }
@@ -31,6 +33,8 @@ type xmlWellFormedChecker struct {
 	maxAttributes int
 	maxTokenBytes int64
 	maxInputBytes int64
+	charsets      stream.CharsetLookup
+	xml11         bool
 }
 
 func (c *xmlWellFormedChecker) check(ctx context.Context, r io.Reader) error {
@@ -40,9 +44,11 @@ func (c *xmlWellFormedChecker) check(ctx context.Context, r io.Reader) error {
 	var parser stream.Parser
 	if err := parser.ResetWithLimits(r, &names, &values, stream.Limits{
 		Context:       ctx,
+		Charsets:      c.charsets,
 		MaxInputBytes: c.maxInputBytes,
 		MaxTokenBytes: c.maxTokenBytes,
 		MaxAttrs:      c.maxAttributes,
+		XML11:         c.xml11,
 	}); err != nil {
 		if done != nil {
 			if contextErr := validationContextDoneError(ctx, done, err); contextErr != nil {
@@ -2,6 +2,7 @@ package validate
 
 import (
 	"encoding/xml"
+	"strconv"
 	"strings"
 
 	"github.com/jacoelho/xsd/internal/stream"
@@ -10,11 +11,24 @@ import (
 )
 
 type xmlDocument[P any] struct {
-	pathText      string
-	elements      []xmlDocumentElement[P]
+	pathText string
+	elements []xmlDocumentElement[P]
+	// siblings counts, per open element depth, the children seen so far by
+	// expanded name. It is only kept for indexed paths; the table of a depth
+	// is reused by the next element opened there.
+	siblings      []map[xml.Name]int
 	ns            xmlns.Stack
 	pathTextDepth int
 	seenRoot      bool
+	// indexedPaths writes the sibling position of every element below the
+	// document element, as in /order/line[37]/qty[1].
+	indexedPaths bool
+	// maxSiblingNames bounds the distinct names counted among the children
+	// of one element; children with further names get unindexed steps. Zero
+	// counts every name.
+	maxSiblingNames int
+	// clarkPaths writes every element in a namespace as {namespace}local.
+	clarkPaths bool
 }
 
 type xmlDocumentElement[P any] struct {
@@ -22,6 +36,7 @@ type xmlDocumentElement[P any] struct {
 	name         xml.Name
 	prefix       string
 	pathLength   int
+	position     int
 	expandedPath bool
 }
 
@@ -73,10 +88,18 @@ func (d *xmlDocument[P]) PrepareStart(
 }
 
 func (d *xmlDocument[P]) CommitStart(start preparedXMLStart, expandedPath bool, payload P) {
+	expandedPath = expandedPath || d.clarkPaths && start.name.Space != ""
 	pathLength := 1 + len(start.name.Local)
 	if expandedPath {
 		pathLength += len(start.name.Space) + 2
 	}
+	position := 0
+	if d.indexedPaths && len(d.elements) != 0 {
+		position = d.siblingPosition(start.name)
+	}
+	if position != 0 {
+		pathLength += len(strconv.Itoa(position)) + 2
+	}
 	if len(d.elements) != 0 {
 		pathLength += d.elements[len(d.elements)-1].pathLength
 	}
@@ -85,11 +108,33 @@ func (d *xmlDocument[P]) CommitStart(start preparedXMLStart, expandedPath bool,
 		name:         start.name,
 		prefix:       start.prefix,
 		pathLength:   pathLength,
+		position:     position,
 		expandedPath: expandedPath,
 	})
 	d.seenRoot = true
 }
 
+// siblingPosition counts an element named name among the children of the
+// current element and returns its position among those of the same name, or
+// zero when the current element already has maxSiblingNames other names.
+func (d *xmlDocument[P]) siblingPosition(name xml.Name) int {
+	depth := len(d.elements)
+	for len(d.siblings) < depth {
+		d.siblings = append(d.siblings, nil)
+	}
+	counts := d.siblings[depth-1]
+	if counts == nil {
+		counts = make(map[xml.Name]int)
+		d.siblings[depth-1] = counts
+	}
+	n, ok := counts[name]
+	if !ok && d.maxSiblingNames > 0 && len(counts) >= d.maxSiblingNames {
+		return 0
+	}
+	counts[name] = n + 1
+	return n + 1
+}
+
 func (d *xmlDocument[P]) AbortStart() {
 	d.ns.Pop()
 }
@@ -122,6 +167,9 @@ func (d *xmlDocument[P]) CommitEnd() error {
 	d.elements[i] = xmlDocumentElement[P]{}
 	d.elements = d.elements[:i]
 	d.ns.Pop()
+	if i < len(d.siblings) && len(d.siblings[i]) != 0 {
+		clear(d.siblings[i])
+	}
 	if d.pathTextDepth <= i {
 		return nil
 	}
@@ -153,6 +201,12 @@ func (d *xmlDocument[P]) Reset(maxRetainedCap int) {
 		clear(d.elements)
 		d.elements = d.elements[:0]
 	}
+	if cap(d.siblings) > maxRetainedCap {
+		d.siblings = nil
+	} else {
+		clear(d.siblings)
+		d.siblings = d.siblings[:0]
+	}
 	d.pathText = ""
 	d.pathTextDepth = 0
 	d.seenRoot = false
@@ -169,6 +223,13 @@ func (d *xmlDocument[P]) Current() (*P, bool) {
 	return &d.elements[len(d.elements)-1].payload, true
 }
 
+func (d *xmlDocument[P]) CurrentName() xml.Name {
+	if len(d.elements) == 0 {
+		return xml.Name{}
+	}
+	return d.elements[len(d.elements)-1].name
+}
+
 func (d *xmlDocument[P]) clearPayloads() {
 	var zero P
 	for i := range d.elements {
@@ -205,6 +266,11 @@ func (d *xmlDocument[P]) PathString() string {
 			path.WriteByte('}')
 		}
 		path.WriteString(element.name.Local)
+		if element.position != 0 {
+			path.WriteByte('[')
+			path.WriteString(strconv.Itoa(element.position))
+			path.WriteByte(']')
+		}
 	}
 	d.pathText = path.String()
 	d.pathTextDepth = depth
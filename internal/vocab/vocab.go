@@ -14,6 +14,7 @@ const (
 // XML names and values.
 const (
 	XMLVersion10 = "1.0"
+	XMLVersion11 = "1.1"
 	XMLPrefix    = "xml"
 	XMLAttrBase  = "base"
 	XMLAttrID    = "id"
@@ -46,40 +47,47 @@ const (
 
 // XSD element names.
 const (
-	XSDElemAll            = "all"
-	XSDElemAnnotation     = "annotation"
-	XSDElemAny            = "any"
-	XSDElemAnyAttribute   = "anyAttribute"
-	XSDElemAppinfo        = "appinfo"
-	XSDElemAttribute      = "attribute"
-	XSDElemAttributeGroup = "attributeGroup"
-	XSDElemChoice         = "choice"
-	XSDElemComplexContent = "complexContent"
-	XSDElemComplexType    = "complexType"
-	XSDElemDocumentation  = "documentation"
-	XSDElemElement        = "element"
-	XSDElemExtension      = "extension"
-	XSDElemField          = "field"
-	XSDElemGroup          = "group"
-	XSDElemImport         = "import"
-	XSDElemInclude        = "include"
-	XSDElemKey            = "key"
-	XSDElemKeyref         = "keyref"
-	XSDElemList           = "list"
-	XSDElemNotation       = "notation"
-	XSDElemRestriction    = "restriction"
-	XSDElemSchema         = "schema"
-	XSDElemSelector       = "selector"
-	XSDElemSequence       = "sequence"
-	XSDElemSimpleContent  = "simpleContent"
-	XSDElemSimpleType     = "simpleType"
-	XSDElemUnion          = "union"
-	XSDElemUnique         = "unique"
+	XSDElemAll                = "all"
+	XSDElemAlternative        = "alternative"
+	XSDElemAnnotation         = "annotation"
+	XSDElemAny                = "any"
+	XSDElemAnyAttribute       = "anyAttribute"
+	XSDElemAppinfo            = "appinfo"
+	XSDElemAssert             = "assert"
+	XSDElemAttribute          = "attribute"
+	XSDElemAttributeGroup     = "attributeGroup"
+	XSDElemChoice             = "choice"
+	XSDElemComplexContent     = "complexContent"
+	XSDElemComplexType        = "complexType"
+	XSDElemDefaultOpenContent = "defaultOpenContent"
+	XSDElemDocumentation      = "documentation"
+	XSDElemElement            = "element"
+	XSDElemExtension          = "extension"
+	XSDElemField              = "field"
+	XSDElemGroup              = "group"
+	XSDElemImport             = "import"
+	XSDElemInclude            = "include"
+	XSDElemKey                = "key"
+	XSDElemKeyref             = "keyref"
+	XSDElemList               = "list"
+	XSDElemNotation           = "notation"
+	XSDElemOpenContent        = "openContent"
+	XSDElemOverride           = "override"
+	XSDElemRedefine           = "redefine"
+	XSDElemRestriction        = "restriction"
+	XSDElemSchema             = "schema"
+	XSDElemSelector           = "selector"
+	XSDElemSequence           = "sequence"
+	XSDElemSimpleContent      = "simpleContent"
+	XSDElemSimpleType         = "simpleType"
+	XSDElemUnion              = "union"
+	XSDElemUnique             = "unique"
 )
 
 // XSD attribute names.
 const (
 	XSDAttrAbstract             = "abstract"
+	XSDAttrAppliesToEmpty       = "appliesToEmpty"
 	XSDAttrAttributeFormDefault = "attributeFormDefault"
 	XSDAttrBase                 = "base"
 	XSDAttrBlock                = "block"
@@ -96,6 +104,7 @@ const (
 	XSDAttrMemberTypes          = "memberTypes"
 	XSDAttrMinOccurs            = "minOccurs"
 	XSDAttrMixed                = "mixed"
+	XSDAttrMode                 = "mode"
 	XSDAttrName                 = "name"
 	XSDAttrNamespace            = "namespace"
 	XSDAttrNillable             = "nillable"
@@ -110,15 +119,18 @@ const (
 	XSDAttrSubstitutionGroup    = "substitutionGroup"
 	XSDAttrSystem               = "system"
 	XSDAttrTargetNamespace      = "targetNamespace"
+	XSDAttrTest                 = "test"
 	XSDAttrType                 = "type"
 	XSDAttrUse                  = "use"
 	XSDAttrValue                = "value"
 	XSDAttrVersion              = "version"
 	XSDAttrXPath                = "xpath"
+	XSDAttrXPathDefaultNS       = "xpathDefaultNamespace"
 )
 
 // XSD facet names.
 const (
+	XSDFacetAssertion      = "assertion"
 	XSDFacetEnumeration    = "enumeration"
 	XSDFacetFractionDigits = "fractionDigits"
 	XSDFacetLength         = "length"
@@ -187,3 +199,20 @@ const (
 	XSDWhitespacePreserve = "preserve"
 	XSDWhitespaceReplace  = "replace"
 )
+
+// XSD 1.1 built-in type names.
+const (
+	XSDValueAnyAtomicType     = "anyAtomicType"
+	XSDValueDateTimeStamp     = "dateTimeStamp"
+	XSDValueDayTimeDuration   = "dayTimeDuration"
+	XSDValueYearMonthDuration = "yearMonthDuration"
+	XSDValueError             = "error"
+)
+
+// XSD 1.1 explicitTimezone facet name and values.
+const (
+	XSDFacetExplicitTimezone = "explicitTimezone"
+	XSDTimezoneOptional      = "optional"
+	XSDTimezoneRequired      = "required"
+	XSDTimezoneProhibited    = "prohibited"
+)
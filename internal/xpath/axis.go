@@ -0,0 +1,204 @@
+package xpath
+
+import "strconv"
+
+type axis uint8
+
+const (
+	axisChild axis = iota
+	axisDescendant
+	axisDescendantOrSelf
+	axisSelf
+	axisAttribute
+	axisParent
+	axisAncestor
+	axisAncestorOrSelf
+	axisFollowingSibling
+	axisPrecedingSibling
+)
+
+var axisByName = map[string]axis{
+	"child":              axisChild,
+	"descendant":         axisDescendant,
+	"descendant-or-self": axisDescendantOrSelf,
+	"self":               axisSelf,
+	"attribute":          axisAttribute,
+	"parent":             axisParent,
+	"ancestor":           axisAncestor,
+	"ancestor-or-self":   axisAncestorOrSelf,
+	"following-sibling":  axisFollowingSibling,
+	"preceding-sibling":  axisPrecedingSibling,
+}
+
+// String returns the name of a as written in an axis step.
+func (a axis) String() string {
+	for name, b := range axisByName {
+		if b == a {
+			return name
+		}
+	}
+	return "axis(" + strconv.Itoa(int(a)) + ")"
+}
+
+type testKind uint8
+
+const (
+	testName testKind = iota
+	testAnyKind
+	testText
+	testElement
+	testAttribute
+)
+
+// nodeTest matches nodes by kind and expanded name. Name tests match the
+// principal node kind of their axis.
+type nodeTest struct {
+	space    string
+	local    string
+	kind     testKind
+	anySpace bool
+	anyLocal bool
+}
+
+func (t nodeTest) matches(n *Node, principal NodeKind) bool {
+	switch t.kind {
+	case testAnyKind:
+		return true
+	case testText:
+		return n.Kind == TextNode
+	case testElement:
+		return n.Kind == ElementNode && t.matchesName(n)
+	case testAttribute:
+		return n.Kind == AttributeNode && t.matchesName(n)
+	default:
+		return n.Kind == principal && t.matchesName(n)
+	}
+}
+
+func (t nodeTest) matchesName(n *Node) bool {
+	return (t.anySpace || t.space == n.Space) && (t.anyLocal || t.local == n.Local)
+}
+
+type axisStep struct {
+	test       nodeTest
+	predicates []expr
+	axis       axis
+}
+
+func (s *axisStep) eval(ev *evaluator, f focus) (sequence, error) {
+	if f.size == 0 {
+		return nil, errNoContextNode
+	}
+	n := f.item.node
+	if n == nil {
+		return nil, errNoContextNode
+	}
+	principal := ElementNode
+	if s.axis == axisAttribute {
+		principal = AttributeNode
+	}
+	var out sequence
+	visit := func(candidate *Node) error {
+		if err := ev.charge(1); err != nil {
+			return err
+		}
+		if s.test.matches(candidate, principal) {
+			out = append(out, nodeItem(candidate))
+		}
+		return nil
+	}
+	if err := ev.walkAxis(s.axis, n, visit); err != nil {
+		return nil, err
+	}
+	out, err := ev.filter(out, s.predicates)
+	if err != nil {
+		return nil, err
+	}
+	if s.axis.reverse() {
+		return documentOrder(out), nil
+	}
+	return out, nil
+}
+
+func (a axis) reverse() bool {
+	return a == axisParent || a == axisAncestor || a == axisAncestorOrSelf || a == axisPrecedingSibling
+}
+
+// walkAxis visits the nodes of axis from n in axis order: reverse axes visit
+// the nearest node first.
+func (ev *evaluator) walkAxis(a axis, n *Node, visit func(*Node) error) error {
+	switch a {
+	case axisChild:
+		for _, child := range n.Children {
+			if err := visit(child); err != nil {
+				return err
+			}
+		}
+	case axisDescendant, axisDescendantOrSelf:
+		if a == axisDescendantOrSelf {
+			if err := visit(n); err != nil {
+				return err
+			}
+		}
+		return walkDescendants(n, visit)
+	case axisSelf:
+		return visit(n)
+	case axisAttribute:
+		for _, attr := range n.Attrs {
+			if err := visit(attr); err != nil {
+				return err
+			}
+		}
+	case axisParent:
+		if p := ev.parent(n); p != nil {
+			return visit(p)
+		}
+	case axisAncestor, axisAncestorOrSelf:
+		if a == axisAncestorOrSelf {
+			if err := visit(n); err != nil {
+				return err
+			}
+		}
+		for p := ev.parent(n); p != nil; p = ev.parent(p) {
+			if err := visit(p); err != nil {
+				return err
+			}
+		}
+	case axisFollowingSibling, axisPrecedingSibling:
+		p := ev.parent(n)
+		if p == nil || n.Kind == AttributeNode {
+			return nil
+		}
+		siblings := p.Children
+		at := 0
+		for at < len(siblings) && siblings[at] != n {
+			at++
+		}
+		if a == axisFollowingSibling {
+			for _, sibling := range siblings[min(at+1, len(siblings)):] {
+				if err := visit(sibling); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i := at - 1; i >= 0; i-- {
+			if err := visit(siblings[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func walkDescendants(n *Node, visit func(*Node) error) error {
+	for _, child := range n.Children {
+		if err := visit(child); err != nil {
+			return err
+		}
+		if err := walkDescendants(child, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,892 @@
+package xpath
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type expr interface {
+	eval(ev *evaluator, f focus) (sequence, error)
+}
+
+// focus is the context item with its position and size. size is zero when
+// the context item is absent.
+type focus struct {
+	item Item
+	pos  int
+	size int
+}
+
+type variable struct {
+	name  string
+	value sequence
+}
+
+type evaluator struct {
+	host     Host
+	root     *Node
+	value    sequence
+	vars     []variable
+	steps    int
+	maxSteps int
+}
+
+func newEvaluator(ctx Context) *evaluator {
+	ev := &evaluator{host: ctx.Host, root: ctx.Node, maxSteps: ctx.MaxSteps}
+	if ev.maxSteps <= 0 {
+		ev.maxSteps = DefaultMaxSteps
+	}
+	if ctx.Node != nil {
+		ev.steps = numberTree(ctx.Node)
+	}
+	ev.value = make(sequence, len(ctx.Value))
+	for i, v := range ctx.Value {
+		ev.value[i] = atomItem(v)
+	}
+	return ev
+}
+
+// charge counts n evaluation steps against the budget.
+func (ev *evaluator) charge(n int) error {
+	ev.steps += n
+	if ev.steps > ev.maxSteps {
+		return ErrStepLimit
+	}
+	return nil
+}
+
+func (ev *evaluator) lookup(name string) sequence {
+	for i := len(ev.vars) - 1; i >= 0; i-- {
+		if ev.vars[i].name == name {
+			return ev.vars[i].value
+		}
+	}
+	return ev.value
+}
+
+func (ev *evaluator) parent(n *Node) *Node {
+	if n == ev.root {
+		return nil
+	}
+	return n.Parent
+}
+
+type literalExpr struct {
+	value Atomic
+}
+
+func (e *literalExpr) eval(ev *evaluator, _ focus) (sequence, error) {
+	return singleton(e.value), ev.charge(1)
+}
+
+type variableExpr struct {
+	name string
+}
+
+func (e *variableExpr) eval(ev *evaluator, _ focus) (sequence, error) {
+	return ev.lookup(e.name), ev.charge(1)
+}
+
+type contextItemExpr struct{}
+
+func (contextItemExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	if f.size == 0 {
+		return nil, errors.New("context item is absent")
+	}
+	return sequence{f.item}, ev.charge(1)
+}
+
+type sequenceExpr struct {
+	items []expr
+}
+
+func (e *sequenceExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	var out sequence
+	for _, item := range e.items {
+		seq, err := item.eval(ev, f)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, seq...)
+	}
+	return out, nil
+}
+
+type ifExpr struct {
+	cond expr
+	then expr
+	els  expr
+}
+
+func (e *ifExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	ok, err := evalBoolean(ev, f, e.cond)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return e.then.eval(ev, f)
+	}
+	return e.els.eval(ev, f)
+}
+
+type logicExpr struct {
+	left  expr
+	right expr
+	or    bool
+}
+
+func (e *logicExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	left, err := evalBoolean(ev, f, e.left)
+	if err != nil {
+		return nil, err
+	}
+	if left == e.or {
+		return singleton(Boolean(left)), nil
+	}
+	right, err := evalBoolean(ev, f, e.right)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(Boolean(right)), nil
+}
+
+func evalBoolean(ev *evaluator, f focus, e expr) (bool, error) {
+	seq, err := e.eval(ev, f)
+	if err != nil {
+		return false, err
+	}
+	return effectiveBoolean(seq)
+}
+
+func effectiveBoolean(seq sequence) (bool, error) {
+	if len(seq) == 0 {
+		return false, nil
+	}
+	if seq[0].node != nil {
+		return true, nil
+	}
+	if len(seq) > 1 {
+		return false, errors.New("effective boolean value is undefined for a sequence of several atomic values")
+	}
+	a := seq[0].atom
+	switch {
+	case a.typ == TypeBoolean:
+		return a.boolean, nil
+	case a.isStringy():
+		return a.lexical != "", nil
+	case a.typ == TypeFloat || a.typ == TypeDouble:
+		return a.float != 0 && !math.IsNaN(a.float), nil
+	case a.isNumeric():
+		return a.rat.Sign() != 0, nil
+	default:
+		return false, errors.New("effective boolean value is undefined for xs:" + a.name)
+	}
+}
+
+type binding struct {
+	in   expr
+	name string
+}
+
+type forExpr struct {
+	ret      expr
+	bindings []binding
+}
+
+func (e *forExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	var out sequence
+	err := bindEach(ev, f, e.bindings, func() (bool, error) {
+		seq, err := e.ret.eval(ev, f)
+		out = append(out, seq...)
+		return true, err
+	})
+	return out, err
+}
+
+type quantifiedExpr struct {
+	satisfies expr
+	bindings  []binding
+	every     bool
+}
+
+func (e *quantifiedExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	result := e.every
+	err := bindEach(ev, f, e.bindings, func() (bool, error) {
+		ok, err := evalBoolean(ev, f, e.satisfies)
+		if err != nil {
+			return false, err
+		}
+		if ok != e.every {
+			result = ok
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return singleton(Boolean(result)), nil
+}
+
+// bindEach binds every combination of the binding sequences in turn and calls
+// body until it returns false.
+func bindEach(ev *evaluator, f focus, bindings []binding, body func() (bool, error)) error {
+	if len(bindings) == 0 {
+		_, err := body()
+		return err
+	}
+	_, err := bindFrom(ev, f, bindings, body)
+	return err
+}
+
+func bindFrom(ev *evaluator, f focus, bindings []binding, body func() (bool, error)) (bool, error) {
+	if len(bindings) == 0 {
+		return body()
+	}
+	seq, err := bindings[0].in.eval(ev, f)
+	if err != nil {
+		return false, err
+	}
+	ev.vars = append(ev.vars, variable{name: bindings[0].name})
+	defer func() { ev.vars = ev.vars[:len(ev.vars)-1] }()
+	for _, item := range seq {
+		if err := ev.charge(1); err != nil {
+			return false, err
+		}
+		ev.vars[len(ev.vars)-1].value = sequence{item}
+		more, err := bindFrom(ev, f, bindings[1:], body)
+		if err != nil || !more {
+			return more, err
+		}
+	}
+	return true, nil
+}
+
+type compareKind uint8
+
+const (
+	compareGeneral compareKind = iota
+	compareValue
+	compareNode
+)
+
+type compareExpr struct {
+	left  expr
+	right expr
+	op    string
+	kind  compareKind
+}
+
+func (e *compareExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	left, err := e.left.eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	switch e.kind {
+	case compareNode:
+		return compareNodes(e.op, left, right)
+	case compareValue:
+		return ev.compareValues(e.op, left, right)
+	default:
+		ok, err := ev.compareGeneral(e.op, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return singleton(Boolean(ok)), nil
+	}
+}
+
+func compareNodes(op string, left, right sequence) (sequence, error) {
+	if len(left) == 0 || len(right) == 0 {
+		return nil, nil
+	}
+	if len(left) > 1 || len(right) > 1 || left[0].node == nil || right[0].node == nil {
+		return nil, errors.New("node comparison requires single nodes")
+	}
+	a, b := left[0].node.order, right[0].node.order
+	switch op {
+	case "is":
+		return singleton(Boolean(a == b)), nil
+	case "<<":
+		return singleton(Boolean(a < b)), nil
+	default:
+		return singleton(Boolean(a > b)), nil
+	}
+}
+
+func (ev *evaluator) compareValues(op string, left, right sequence) (sequence, error) {
+	a, ok, err := ev.atomizeOptional(left)
+	if err != nil || !ok {
+		return nil, err
+	}
+	b, ok, err := ev.atomizeOptional(right)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if a.typ == TypeUntypedAtomic {
+		a = String(a.lexical)
+	}
+	if b.typ == TypeUntypedAtomic {
+		b = String(b.lexical)
+	}
+	result, err := compareAtomic(op, a, b)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(Boolean(result)), nil
+}
+
+func (ev *evaluator) compareGeneral(op string, left, right sequence) (bool, error) {
+	as, err := ev.atomize(left)
+	if err != nil {
+		return false, err
+	}
+	bs, err := ev.atomize(right)
+	if err != nil {
+		return false, err
+	}
+	if err := ev.charge(len(as) * len(bs)); err != nil {
+		return false, err
+	}
+	for _, a := range as {
+		for _, b := range bs {
+			a, b, err := ev.coerceGeneral(a, b)
+			if err != nil {
+				return false, err
+			}
+			ok, err := compareAtomic(op, a, b)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// coerceGeneral applies the general comparison rules for untyped operands.
+func (ev *evaluator) coerceGeneral(a, b Atomic) (Atomic, Atomic, error) {
+	var err error
+	switch {
+	case a.typ == TypeUntypedAtomic && b.typ == TypeUntypedAtomic:
+		return String(a.lexical), String(b.lexical), nil
+	case a.typ == TypeUntypedAtomic:
+		a, err = ev.coerceUntyped(a, b)
+	case b.typ == TypeUntypedAtomic:
+		b, err = ev.coerceUntyped(b, a)
+	}
+	return a, b, err
+}
+
+func (ev *evaluator) coerceUntyped(u, other Atomic) (Atomic, error) {
+	switch {
+	case other.isNumeric():
+		return cast(ev.host, u, "double")
+	case other.typ == TypeString:
+		return String(u.lexical), nil
+	default:
+		return cast(ev.host, u, other.name)
+	}
+}
+
+func compareAtomic(op string, a, b Atomic) (bool, error) {
+	var order int
+	switch {
+	case a.isNumeric() && b.isNumeric():
+		if a.typ == TypeFloat || a.typ == TypeDouble || b.typ == TypeFloat || b.typ == TypeDouble {
+			x, y := a.toFloat(), b.toFloat()
+			if math.IsNaN(x) || math.IsNaN(y) {
+				return op == "!=", nil
+			}
+			order = compareFloats(x, y)
+		} else {
+			order = a.rat.Cmp(b.rat)
+		}
+	case a.isStringy() && b.isStringy():
+		order = strings.Compare(a.lexical, b.lexical)
+	case a.typ == TypeBoolean && b.typ == TypeBoolean:
+		order = compareBools(a.boolean, b.boolean)
+	case a.typ == TypeOther && b.typ == TypeOther:
+		var ok bool
+		order, ok = a.value.Compare(b.value)
+		if !ok {
+			if a.name != b.name && !sameFamily(a.name, b.name) {
+				return false, errors.New("cannot compare xs:" + a.name + " with xs:" + b.name)
+			}
+			return op == "!=", nil
+		}
+	default:
+		return false, errors.New("cannot compare xs:" + a.name + " with xs:" + b.name)
+	}
+	switch op {
+	case "=":
+		return order == 0, nil
+	case "!=":
+		return order != 0, nil
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+// sameFamily reports whether two host datatypes are comparable in principle,
+// so an incomparable pair of values compares false rather than failing.
+func sameFamily(a, b string) bool {
+	return strings.HasSuffix(a, "uration") && strings.HasSuffix(b, "uration") ||
+		strings.HasPrefix(a, "dateTime") && strings.HasPrefix(b, "dateTime")
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// atomize returns the typed values of seq.
+func (ev *evaluator) atomize(seq sequence) ([]Atomic, error) {
+	out := make([]Atomic, 0, len(seq))
+	for _, item := range seq {
+		if item.node == nil {
+			out = append(out, item.atom)
+			continue
+		}
+		values, err := ev.atomizeNode(item.node)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, values...)
+	}
+	return out, ev.charge(len(out))
+}
+
+func (ev *evaluator) atomizeNode(n *Node) ([]Atomic, error) {
+	if n.Kind == ElementNode && n.ElementOnly {
+		return nil, errors.New("element " + n.name() + " has element-only content and no typed value")
+	}
+	text := n.StringValue()
+	if err := ev.charge(len(text) / 64); err != nil {
+		return nil, err
+	}
+	if n.Type == 0 || ev.host == nil || n.Kind == TextNode {
+		return []Atomic{Untyped(text)}, nil
+	}
+	return ev.host.Atomize(n.Type, text)
+}
+
+// atomizeOptional atomizes seq to at most one value.
+func (ev *evaluator) atomizeOptional(seq sequence) (Atomic, bool, error) {
+	values, err := ev.atomize(seq)
+	if err != nil {
+		return Atomic{}, false, err
+	}
+	switch len(values) {
+	case 0:
+		return Atomic{}, false, nil
+	case 1:
+		return values[0], true, nil
+	default:
+		return Atomic{}, false, errors.New("expected at most one atomic value, found a sequence of " + strconv.Itoa(len(values)))
+	}
+}
+
+type rangeExpr struct {
+	from expr
+	to   expr
+}
+
+func (e *rangeExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	from, ok, err := ev.integerOperand(f, e.from)
+	if err != nil || !ok {
+		return nil, err
+	}
+	to, ok, err := ev.integerOperand(f, e.to)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if !from.IsInt64() || !to.IsInt64() {
+		return nil, ErrStepLimit
+	}
+	lo, hi := from.Int64(), to.Int64()
+	if lo > hi {
+		return nil, nil
+	}
+	// The span is computed in uint64 so that it cannot overflow, and the
+	// items are counted rather than stepped so that a range ending at the
+	// largest int64 terminates.
+	span := uint64(hi) - uint64(lo)
+	if span >= uint64(ev.maxSteps) {
+		return nil, ErrStepLimit
+	}
+	n := int(span) + 1
+	if err := ev.charge(n); err != nil {
+		return nil, err
+	}
+	out := make(sequence, n)
+	for i := range out {
+		out[i] = atomItem(ratValue(TypeInteger, new(big.Rat).SetInt64(lo+int64(i))))
+	}
+	return out, nil
+}
+
+func (ev *evaluator) integerOperand(f focus, e expr) (*big.Int, bool, error) {
+	seq, err := e.eval(ev, f)
+	if err != nil {
+		return nil, false, err
+	}
+	a, ok, err := ev.atomizeOptional(seq)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if a.typ == TypeUntypedAtomic {
+		if a, err = ParseNumber(TypeInteger, a.lexical); err != nil {
+			return nil, false, err
+		}
+	}
+	if a.typ != TypeInteger {
+		return nil, false, errors.New("range operand must be an integer")
+	}
+	return a.rat.Num(), true, nil
+}
+
+type arithmeticExpr struct {
+	left  expr
+	right expr
+	op    string
+}
+
+func (e *arithmeticExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	a, ok, err := ev.numericOperand(f, e.left)
+	if err != nil || !ok {
+		return nil, err
+	}
+	b, ok, err := ev.numericOperand(f, e.right)
+	if err != nil || !ok {
+		return nil, err
+	}
+	result, err := arithmetic(e.op, a, b)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(result), ev.charge(1)
+}
+
+func (ev *evaluator) numericOperand(f focus, e expr) (Atomic, bool, error) {
+	seq, err := e.eval(ev, f)
+	if err != nil {
+		return Atomic{}, false, err
+	}
+	a, ok, err := ev.atomizeOptional(seq)
+	if err != nil || !ok {
+		return a, ok, err
+	}
+	if a.typ == TypeUntypedAtomic {
+		a, err = cast(ev.host, a, "double")
+		if err != nil {
+			return Atomic{}, false, err
+		}
+	}
+	if !a.isNumeric() {
+		return Atomic{}, false, errors.New("arithmetic on xs:" + a.name + " is not supported")
+	}
+	return a, true, nil
+}
+
+func arithmetic(op string, a, b Atomic) (Atomic, error) {
+	if a.typ == TypeFloat || a.typ == TypeDouble || b.typ == TypeFloat || b.typ == TypeDouble {
+		t := TypeFloat
+		if a.typ == TypeDouble || b.typ == TypeDouble {
+			t = TypeDouble
+		}
+		return floatArithmetic(op, t, a.toFloat(), b.toFloat())
+	}
+	integer := a.typ == TypeInteger && b.typ == TypeInteger
+	t := TypeDecimal
+	if integer {
+		t = TypeInteger
+	}
+	r := new(big.Rat)
+	switch op {
+	case "+":
+		r.Add(a.rat, b.rat)
+	case "-":
+		r.Sub(a.rat, b.rat)
+	case "*":
+		r.Mul(a.rat, b.rat)
+	case "div":
+		if b.rat.Sign() == 0 {
+			return Atomic{}, errors.New("division by zero")
+		}
+		r.Quo(a.rat, b.rat)
+		t = TypeDecimal
+	case "idiv":
+		if b.rat.Sign() == 0 {
+			return Atomic{}, errors.New("integer division by zero")
+		}
+		r = truncRat(r.Quo(a.rat, b.rat))
+		t = TypeInteger
+	case "mod":
+		if b.rat.Sign() == 0 {
+			return Atomic{}, errors.New("modulus by zero")
+		}
+		q := truncRat(new(big.Rat).Quo(a.rat, b.rat))
+		r.Sub(a.rat, q.Mul(q, b.rat))
+	}
+	return ratValue(t, r), nil
+}
+
+func floatArithmetic(op string, t Type, x, y float64) (Atomic, error) {
+	var r float64
+	switch op {
+	case "+":
+		r = x + y
+	case "-":
+		r = x - y
+	case "*":
+		r = x * y
+	case "div":
+		r = x / y
+	case "idiv":
+		if y == 0 || math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) {
+			return Atomic{}, errors.New("invalid integer division")
+		}
+		q := new(big.Rat).SetFloat64(math.Trunc(x / y))
+		if q == nil {
+			return Atomic{}, errors.New("invalid integer division")
+		}
+		return ratValue(TypeInteger, q), nil
+	case "mod":
+		r = math.Mod(x, y)
+	}
+	return floatValue(t, r), nil
+}
+
+type unaryExpr struct {
+	operand expr
+	negate  bool
+}
+
+func (e *unaryExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	a, ok, err := ev.numericOperand(f, e.operand)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if !e.negate {
+		return singleton(a), nil
+	}
+	if a.typ == TypeFloat || a.typ == TypeDouble {
+		return singleton(floatValue(a.typ, -a.float)), nil
+	}
+	return singleton(ratValue(a.typ, new(big.Rat).Neg(a.rat))), nil
+}
+
+type castExpr struct {
+	operand  expr
+	target   string
+	optional bool
+	castable bool
+}
+
+func (e *castExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	seq, err := e.operand.eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	values, err := ev.atomize(seq)
+	if err != nil {
+		return nil, err
+	}
+	var result Atomic
+	switch {
+	case len(values) == 0 && e.optional:
+		if e.castable {
+			return singleton(Boolean(true)), nil
+		}
+		return nil, nil
+	case len(values) != 1:
+		err = errors.New("cast to xs:" + e.target + " requires a single atomic value")
+	default:
+		result, err = cast(ev.host, values[0], e.target)
+	}
+	if e.castable {
+		return singleton(Boolean(err == nil)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return singleton(result), nil
+}
+
+// castTarget reports whether local names an atomic XSD datatype that cast,
+// castable and constructor functions accept.
+func castTarget(local string) bool {
+	switch local {
+	case "string", "normalizedString", "token", "language", "Name", "NCName", "ID", "IDREF", "ENTITY", "NMTOKEN",
+		"anyURI", "boolean", "decimal", "integer", "nonPositiveInteger", "negativeInteger", "long", "int", "short",
+		"byte", "nonNegativeInteger", "unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte", "positiveInteger",
+		"float", "double", "duration", "dayTimeDuration", "yearMonthDuration", "dateTime", "dateTimeStamp", "time",
+		"date", "gYearMonth", "gYear", "gMonthDay", "gDay", "gMonth", "hexBinary", "base64Binary", "untypedAtomic":
+		return true
+	default:
+		return false
+	}
+}
+
+type setExpr struct {
+	left  expr
+	right expr
+	op    string
+}
+
+func (e *setExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	left, err := e.left.eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range slices.Concat(left, right) {
+		if item.node == nil {
+			return nil, errors.New(e.op + " operands must be nodes")
+		}
+	}
+	if err := ev.charge(len(left) + len(right)); err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "union":
+		return documentOrder(slices.Concat(left, right)), nil
+	default:
+		keep := e.op == "intersect"
+		out := make(sequence, 0, len(left))
+		for _, item := range left {
+			in := slices.ContainsFunc(right, func(other Item) bool { return other.node == item.node })
+			if in == keep {
+				out = append(out, item)
+			}
+		}
+		return documentOrder(out), nil
+	}
+}
+
+// documentOrder sorts node items into document order without duplicates.
+func documentOrder(seq sequence) sequence {
+	slices.SortFunc(seq, func(a, b Item) int { return a.node.order - b.node.order })
+	return slices.CompactFunc(seq, func(a, b Item) bool { return a.node == b.node })
+}
+
+type pathExpr struct {
+	steps []expr
+}
+
+func (e *pathExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	current, err := e.steps[0].eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range e.steps[1:] {
+		var next sequence
+		nodes := 0
+		for i, item := range current {
+			if item.node == nil {
+				return nil, errors.New("path step applied to an atomic value")
+			}
+			seq, err := step.eval(ev, focus{item: item, pos: i + 1, size: len(current)})
+			if err != nil {
+				return nil, err
+			}
+			for _, out := range seq {
+				if out.node != nil {
+					nodes++
+				}
+			}
+			next = append(next, seq...)
+		}
+		switch nodes {
+		case len(next):
+			current = documentOrder(next)
+		case 0:
+			current = next
+		default:
+			return nil, errors.New("path step returned both nodes and atomic values")
+		}
+	}
+	return current, nil
+}
+
+type filterExpr struct {
+	primary    expr
+	predicates []expr
+}
+
+func (e *filterExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	seq, err := e.primary.eval(ev, f)
+	if err != nil {
+		return nil, err
+	}
+	return ev.filter(seq, e.predicates)
+}
+
+func (ev *evaluator) filter(seq sequence, predicates []expr) (sequence, error) {
+	for _, pred := range predicates {
+		out := seq[:0:0]
+		for i, item := range seq {
+			value, err := pred.eval(ev, focus{item: item, pos: i + 1, size: len(seq)})
+			if err != nil {
+				return nil, err
+			}
+			keep, err := predicateMatches(value, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				out = append(out, item)
+			}
+		}
+		seq = out
+	}
+	return seq, nil
+}
+
+func predicateMatches(value sequence, pos int) (bool, error) {
+	if len(value) == 1 && value[0].node == nil && value[0].atom.isNumeric() {
+		a := value[0].atom
+		if a.typ == TypeFloat || a.typ == TypeDouble {
+			return a.float == float64(pos), nil
+		}
+		return a.rat.Cmp(new(big.Rat).SetInt64(int64(pos))) == 0, nil
+	}
+	return effectiveBoolean(value)
+}
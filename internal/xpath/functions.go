@@ -0,0 +1,602 @@
+package xpath
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jacoelho/xsd/internal/lex"
+)
+
+var errNoContextNode = errors.New("context item is not a node")
+
+// function is one core function. maxArgs is negative for variadic functions.
+type function struct {
+	impl    func(ev *evaluator, f focus, args []sequence) (sequence, error)
+	minArgs int
+	maxArgs int
+}
+
+type callExpr struct {
+	fn   *function
+	name string
+	args []expr
+}
+
+func (e *callExpr) eval(ev *evaluator, f focus) (sequence, error) {
+	args := make([]sequence, len(e.args))
+	for i, arg := range e.args {
+		seq, err := arg.eval(ev, f)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = seq
+	}
+	if err := ev.charge(1); err != nil {
+		return nil, err
+	}
+	out, err := e.fn.impl(ev, f, args)
+	if errors.Is(err, ErrStepLimit) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New(e.name + "(): " + err.Error())
+	}
+	return out, nil
+}
+
+var functions map[string]*function
+
+func init() {
+	functions = map[string]*function{
+		"true":             {minArgs: 0, maxArgs: 0, impl: constantBoolean(true)},
+		"false":            {minArgs: 0, maxArgs: 0, impl: constantBoolean(false)},
+		"not":              {minArgs: 1, maxArgs: 1, impl: fnNot},
+		"boolean":          {minArgs: 1, maxArgs: 1, impl: fnBoolean},
+		"exists":           {minArgs: 1, maxArgs: 1, impl: fnExists},
+		"empty":            {minArgs: 1, maxArgs: 1, impl: fnEmpty},
+		"count":            {minArgs: 1, maxArgs: 1, impl: fnCount},
+		"data":             {minArgs: 1, maxArgs: 1, impl: fnData},
+		"string":           {minArgs: 0, maxArgs: 1, impl: fnString},
+		"number":           {minArgs: 0, maxArgs: 1, impl: fnNumber},
+		"string-length":    {minArgs: 0, maxArgs: 1, impl: fnStringLength},
+		"normalize-space":  {minArgs: 0, maxArgs: 1, impl: fnNormalizeSpace},
+		"contains":         {minArgs: 2, maxArgs: 2, impl: stringPredicate(strings.Contains)},
+		"starts-with":      {minArgs: 2, maxArgs: 2, impl: stringPredicate(strings.HasPrefix)},
+		"ends-with":        {minArgs: 2, maxArgs: 2, impl: stringPredicate(strings.HasSuffix)},
+		"substring":        {minArgs: 2, maxArgs: 3, impl: fnSubstring},
+		"substring-before": {minArgs: 2, maxArgs: 2, impl: fnSubstringBefore},
+		"substring-after":  {minArgs: 2, maxArgs: 2, impl: fnSubstringAfter},
+		"concat":           {minArgs: 2, maxArgs: -1, impl: fnConcat},
+		"string-join":      {minArgs: 2, maxArgs: 2, impl: fnStringJoin},
+		"upper-case":       {minArgs: 1, maxArgs: 1, impl: stringMap(strings.ToUpper)},
+		"lower-case":       {minArgs: 1, maxArgs: 1, impl: stringMap(strings.ToLower)},
+		"translate":        {minArgs: 3, maxArgs: 3, impl: fnTranslate},
+		"sum":              {minArgs: 1, maxArgs: 2, impl: fnSum},
+		"avg":              {minArgs: 1, maxArgs: 1, impl: fnAvg},
+		"min":              {minArgs: 1, maxArgs: 1, impl: extremum("<")},
+		"max":              {minArgs: 1, maxArgs: 1, impl: extremum(">")},
+		"abs":              {minArgs: 1, maxArgs: 1, impl: numericMap(math.Abs, (*big.Rat).Abs)},
+		"floor":            {minArgs: 1, maxArgs: 1, impl: numericMap(math.Floor, floorRat)},
+		"ceiling":          {minArgs: 1, maxArgs: 1, impl: numericMap(math.Ceil, ceilRat)},
+		"round":            {minArgs: 1, maxArgs: 1, impl: numericMap(roundFloat, roundRat)},
+		"local-name":       {minArgs: 0, maxArgs: 1, impl: nodeName(func(n *Node) string { return n.Local })},
+		"name":             {minArgs: 0, maxArgs: 1, impl: nodeName((*Node).name)},
+		"namespace-uri":    {minArgs: 0, maxArgs: 1, impl: nodeName(func(n *Node) string { return n.Space })},
+		"position":         {minArgs: 0, maxArgs: 0, impl: fnPosition},
+		"last":             {minArgs: 0, maxArgs: 0, impl: fnLast},
+		"distinct-values":  {minArgs: 1, maxArgs: 1, impl: fnDistinctValues},
+		"reverse":          {minArgs: 1, maxArgs: 1, impl: fnReverse},
+		"subsequence":      {minArgs: 2, maxArgs: 3, impl: fnSubsequence},
+		"zero-or-one":      {minArgs: 1, maxArgs: 1, impl: cardinality(0, 1)},
+		"one-or-more":      {minArgs: 1, maxArgs: 1, impl: cardinality(1, -1)},
+		"exactly-one":      {minArgs: 1, maxArgs: 1, impl: cardinality(1, 1)},
+	}
+}
+
+func constantBoolean(b bool) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(*evaluator, focus, []sequence) (sequence, error) {
+		return singleton(Boolean(b)), nil
+	}
+}
+
+func fnNot(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+	ok, err := effectiveBoolean(args[0])
+	return singleton(Boolean(!ok)), err
+}
+
+func fnBoolean(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+	ok, err := effectiveBoolean(args[0])
+	return singleton(Boolean(ok)), err
+}
+
+func fnExists(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+	return singleton(Boolean(len(args[0]) != 0)), nil
+}
+
+func fnEmpty(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+	return singleton(Boolean(len(args[0]) == 0)), nil
+}
+
+func fnCount(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+	return singleton(ratValue(TypeInteger, new(big.Rat).SetInt64(int64(len(args[0]))))), nil
+}
+
+func fnData(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	values, err := ev.atomize(args[0])
+	if err != nil {
+		return nil, err
+	}
+	out := make(sequence, len(values))
+	for i, v := range values {
+		out[i] = atomItem(v)
+	}
+	return out, nil
+}
+
+// contextArg returns the single argument or the context item for functions
+// whose argument defaults to ".".
+func contextArg(f focus, args []sequence) (sequence, error) {
+	if len(args) != 0 {
+		return args[0], nil
+	}
+	if f.size == 0 {
+		return nil, errors.New("context item is absent")
+	}
+	return sequence{f.item}, nil
+}
+
+// stringOf returns the string value of an optional item.
+func (ev *evaluator) stringOf(seq sequence) (string, error) {
+	switch {
+	case len(seq) == 0:
+		return "", nil
+	case len(seq) > 1:
+		return "", errors.New("expected at most one item")
+	case seq[0].node != nil:
+		return seq[0].node.StringValue(), nil
+	default:
+		return seq[0].atom.stringValue(), nil
+	}
+}
+
+// stringArg atomizes an optional argument to its string value.
+func (ev *evaluator) stringArg(seq sequence) (string, error) {
+	a, ok, err := ev.atomizeOptional(seq)
+	if err != nil || !ok {
+		return "", err
+	}
+	return a.stringValue(), nil
+}
+
+func fnString(ev *evaluator, f focus, args []sequence) (sequence, error) {
+	seq, err := contextArg(f, args)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ev.stringOf(seq)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(String(s)), nil
+}
+
+func fnNumber(ev *evaluator, f focus, args []sequence) (sequence, error) {
+	seq, err := contextArg(f, args)
+	if err != nil {
+		return nil, err
+	}
+	a, ok, err := ev.atomizeOptional(seq)
+	if err != nil || !ok {
+		return singleton(floatValue(TypeDouble, math.NaN())), nil
+	}
+	d, err := cast(ev.host, a, "double")
+	if err != nil {
+		return singleton(floatValue(TypeDouble, math.NaN())), nil
+	}
+	return singleton(d), nil
+}
+
+func fnStringLength(ev *evaluator, f focus, args []sequence) (sequence, error) {
+	seq, err := contextArg(f, args)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ev.stringOf(seq)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(ratValue(TypeInteger, new(big.Rat).SetInt64(int64(utf8.RuneCountInString(s))))), nil
+}
+
+func fnNormalizeSpace(ev *evaluator, f focus, args []sequence) (sequence, error) {
+	seq, err := contextArg(f, args)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ev.stringOf(seq)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(String(lex.CollapseXMLWhitespace(s))), nil
+}
+
+func stringPredicate(match func(s, sub string) bool) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+		s, err := ev.stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		sub, err := ev.stringArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return singleton(Boolean(match(s, sub))), nil
+	}
+}
+
+func stringMap(mapping func(string) string) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+		s, err := ev.stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return singleton(String(mapping(s))), nil
+	}
+}
+
+func fnSubstring(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	s, err := ev.stringArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := ev.doubleArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := math.Inf(1)
+	if len(args) == 3 {
+		length, err := ev.doubleArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		end = roundFloat(start) + roundFloat(length)
+	}
+	start = roundFloat(start)
+	var b strings.Builder
+	pos := 1.0
+	for _, r := range s {
+		if pos >= start && pos < end {
+			b.WriteRune(r)
+		}
+		pos++
+	}
+	return singleton(String(b.String())), nil
+}
+
+func fnSubstringBefore(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	s, sub, err := ev.twoStrings(args)
+	if err != nil {
+		return nil, err
+	}
+	before, _, found := strings.Cut(s, sub)
+	if !found {
+		before = ""
+	}
+	return singleton(String(before)), nil
+}
+
+func fnSubstringAfter(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	s, sub, err := ev.twoStrings(args)
+	if err != nil {
+		return nil, err
+	}
+	_, after, found := strings.Cut(s, sub)
+	if !found {
+		after = ""
+	}
+	return singleton(String(after)), nil
+}
+
+func (ev *evaluator) twoStrings(args []sequence) (string, string, error) {
+	a, err := ev.stringArg(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	b, err := ev.stringArg(args[1])
+	return a, b, err
+}
+
+func fnConcat(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		s, err := ev.stringArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(s)
+	}
+	return singleton(String(b.String())), nil
+}
+
+func fnStringJoin(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	values, err := ev.atomize(args[0])
+	if err != nil {
+		return nil, err
+	}
+	sep, err := ev.stringArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = v.stringValue()
+	}
+	return singleton(String(strings.Join(parts, sep))), nil
+}
+
+func fnTranslate(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	s, err := ev.stringArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	from, err := ev.stringArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	to, err := ev.stringArg(args[2])
+	if err != nil {
+		return nil, err
+	}
+	fromRunes, toRunes := []rune(from), []rune(to)
+	var b strings.Builder
+	for _, r := range s {
+		i := 0
+		for i < len(fromRunes) && fromRunes[i] != r {
+			i++
+		}
+		switch {
+		case i == len(fromRunes):
+			b.WriteRune(r)
+		case i < len(toRunes):
+			b.WriteRune(toRunes[i])
+		}
+	}
+	return singleton(String(b.String())), nil
+}
+
+func (ev *evaluator) doubleArg(seq sequence) (float64, error) {
+	a, ok, err := ev.atomizeOptional(seq)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return math.NaN(), nil
+	}
+	d, err := cast(ev.host, a, "double")
+	if err != nil {
+		return 0, err
+	}
+	return d.float, nil
+}
+
+// numbers atomizes seq, casting untyped values to xs:double.
+func (ev *evaluator) numbers(seq sequence) ([]Atomic, error) {
+	values, err := ev.atomize(seq)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if v.typ == TypeUntypedAtomic {
+			if values[i], err = cast(ev.host, v, "double"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+func fnSum(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	values, err := ev.numbers(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return singleton(ratValue(TypeInteger, new(big.Rat))), nil
+	}
+	total := values[0]
+	for _, v := range values[1:] {
+		if !total.isNumeric() || !v.isNumeric() {
+			return nil, errors.New("sum of non-numeric values")
+		}
+		if total, err = arithmetic("+", total, v); err != nil {
+			return nil, err
+		}
+	}
+	if !total.isNumeric() {
+		return nil, errors.New("sum of non-numeric values")
+	}
+	return singleton(total), nil
+}
+
+func fnAvg(ev *evaluator, f focus, args []sequence) (sequence, error) {
+	total, err := fnSum(ev, f, args[:1])
+	if err != nil || len(args[0]) == 0 {
+		return nil, err
+	}
+	count := ratValue(TypeInteger, new(big.Rat).SetInt64(int64(len(args[0]))))
+	avg, err := arithmetic("div", total[0].atom, count)
+	if err != nil {
+		return nil, err
+	}
+	return singleton(avg), nil
+}
+
+func extremum(op string) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+		values, err := ev.numbers(args[0])
+		if err != nil || len(values) == 0 {
+			return nil, err
+		}
+		best := values[0]
+		for _, v := range values[1:] {
+			better, err := compareAtomic(op, v, best)
+			if err != nil {
+				return nil, err
+			}
+			if better {
+				best = v
+			}
+		}
+		return singleton(best), nil
+	}
+}
+
+func numericMap(onFloat func(float64) float64, onRat func(z, x *big.Rat) *big.Rat) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+		values, err := ev.numbers(args[0])
+		if err != nil || len(values) == 0 {
+			return nil, err
+		}
+		if len(values) > 1 || !values[0].isNumeric() {
+			return nil, errors.New("expected one numeric value")
+		}
+		a := values[0]
+		if a.typ == TypeFloat || a.typ == TypeDouble {
+			return singleton(floatValue(a.typ, onFloat(a.float))), nil
+		}
+		return singleton(ratValue(a.typ, onRat(new(big.Rat), a.rat))), nil
+	}
+}
+
+func floorRat(z, x *big.Rat) *big.Rat {
+	q := new(big.Int)
+	m := new(big.Int)
+	q.DivMod(x.Num(), x.Denom(), m)
+	return z.SetInt(q)
+}
+
+func ceilRat(z, x *big.Rat) *big.Rat {
+	floorRat(z, x)
+	if z.Cmp(x) != 0 {
+		z.Add(z, big.NewRat(1, 1))
+	}
+	return z
+}
+
+func roundRat(z, x *big.Rat) *big.Rat {
+	return floorRat(z, new(big.Rat).Add(x, big.NewRat(1, 2)))
+}
+
+func roundFloat(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
+
+func nodeName(name func(*Node) string) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(_ *evaluator, f focus, args []sequence) (sequence, error) {
+		seq, err := contextArg(f, args)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(seq) == 0:
+			return singleton(String("")), nil
+		case len(seq) > 1 || seq[0].node == nil:
+			return nil, errNoContextNode
+		case seq[0].node.Kind == TextNode:
+			return singleton(String("")), nil
+		default:
+			return singleton(String(name(seq[0].node))), nil
+		}
+	}
+}
+
+func fnPosition(_ *evaluator, f focus, _ []sequence) (sequence, error) {
+	if f.size == 0 {
+		return nil, errors.New("context item is absent")
+	}
+	return singleton(ratValue(TypeInteger, new(big.Rat).SetInt64(int64(f.pos)))), nil
+}
+
+func fnLast(_ *evaluator, f focus, _ []sequence) (sequence, error) {
+	if f.size == 0 {
+		return nil, errors.New("context item is absent")
+	}
+	return singleton(ratValue(TypeInteger, new(big.Rat).SetInt64(int64(f.size)))), nil
+}
+
+func fnDistinctValues(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	values, err := ev.atomize(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var out sequence
+	if err := ev.charge(len(values) * len(values) / 2); err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		if v.typ == TypeUntypedAtomic {
+			v = String(v.lexical)
+		}
+		duplicate := false
+		for _, seen := range out {
+			if eq, err := compareAtomic("=", v, seen.atom); err == nil && eq {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			out = append(out, atomItem(v))
+		}
+	}
+	return out, nil
+}
+
+func fnReverse(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+	out := make(sequence, len(args[0]))
+	for i, item := range args[0] {
+		out[len(out)-1-i] = item
+	}
+	return out, nil
+}
+
+func fnSubsequence(ev *evaluator, _ focus, args []sequence) (sequence, error) {
+	start, err := ev.doubleArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	start = roundFloat(start)
+	end := math.Inf(1)
+	if len(args) == 3 {
+		length, err := ev.doubleArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		end = start + roundFloat(length)
+	}
+	var out sequence
+	for i, item := range args[0] {
+		if pos := float64(i + 1); pos >= start && pos < end {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func cardinality(lo, hi int) func(*evaluator, focus, []sequence) (sequence, error) {
+	return func(_ *evaluator, _ focus, args []sequence) (sequence, error) {
+		n := len(args[0])
+		if n < lo || hi >= 0 && n > hi {
+			return nil, errors.New("sequence has the wrong number of items")
+		}
+		return args[0], nil
+	}
+}
@@ -0,0 +1,239 @@
+package xpath
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jacoelho/xsd/internal/lex"
+)
+
+type tokenKind uint8
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokWildcard    // prefix:* or *:local; text holds "prefix:*" or "*:local"
+	tokString      // text holds the unescaped literal
+	tokNumber      // text holds the lexical number
+	tokSymbol      // punctuation and symbolic operators
+	tokDollar      // $
+	tokAxisColons  // ::
+	tokOpenParen   // (
+	tokCloseParen  // )
+	tokOpenSquare  // [
+	tokCloseSquare // ]
+)
+
+type token struct {
+	text string
+	pos  int
+	kind tokenKind
+}
+
+// tokenize splits an XPath 2.0 expression into tokens. Keywords are returned
+// as names; the parser decides from position whether a name is an operator.
+func tokenize(src string) ([]token, error) {
+	var out []token
+	i := 0
+	for {
+		i = skipSpaceAndComments(src, i)
+		if i < 0 {
+			return nil, syntaxError(len(src), "unterminated comment")
+		}
+		if i >= len(src) {
+			out = append(out, token{kind: tokEOF, pos: i})
+			return out, nil
+		}
+		start := i
+		c := src[i]
+		switch {
+		case c == '"' || c == '\'':
+			text, next, ok := scanStringLiteral(src, i)
+			if !ok {
+				return nil, syntaxError(start, "unterminated string literal")
+			}
+			out = append(out, token{kind: tokString, text: text, pos: start})
+			i = next
+		case isDigit(c) || c == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			next, ok := scanNumber(src, i)
+			if !ok {
+				return nil, syntaxError(start, "invalid numeric literal")
+			}
+			out = append(out, token{kind: tokNumber, text: src[i:next], pos: start})
+			i = next
+		case c == '*':
+			if strings.HasPrefix(src[i:], "*:") {
+				if n := scanNCName(src, i+2); n > i+2 {
+					out = append(out, token{kind: tokWildcard, text: src[i:n], pos: start})
+					i = n
+					continue
+				}
+			}
+			out = append(out, token{kind: tokSymbol, text: "*", pos: start})
+			i++
+		case c == '$':
+			out = append(out, token{kind: tokDollar, text: "$", pos: start})
+			i++
+		case c == '(':
+			out = append(out, token{kind: tokOpenParen, text: "(", pos: start})
+			i++
+		case c == ')':
+			out = append(out, token{kind: tokCloseParen, text: ")", pos: start})
+			i++
+		case c == '[':
+			out = append(out, token{kind: tokOpenSquare, text: "[", pos: start})
+			i++
+		case c == ']':
+			out = append(out, token{kind: tokCloseSquare, text: "]", pos: start})
+			i++
+		case c == ':' && strings.HasPrefix(src[i:], "::"):
+			out = append(out, token{kind: tokAxisColons, text: "::", pos: start})
+			i += 2
+		default:
+			if n := scanNCName(src, i); n > i {
+				end := n
+				if end+1 < len(src) && src[end] == ':' {
+					if src[end+1] == '*' {
+						out = append(out, token{kind: tokWildcard, text: src[i : end+2], pos: start})
+						i = end + 2
+						continue
+					}
+					if local := scanNCName(src, end+1); local > end+1 {
+						end = local
+					}
+				}
+				out = append(out, token{kind: tokName, text: src[i:end], pos: start})
+				i = end
+				continue
+			}
+			sym := scanSymbol(src[i:])
+			if sym == "" {
+				return nil, syntaxError(start, "unexpected character "+quoteRune(src[i:]))
+			}
+			out = append(out, token{kind: tokSymbol, text: sym, pos: start})
+			i += len(sym)
+		}
+	}
+}
+
+func skipSpaceAndComments(src string, i int) int {
+	for i < len(src) {
+		switch {
+		case lex.IsXMLWhitespaceByte(src[i]):
+			i++
+		case strings.HasPrefix(src[i:], "(:"):
+			i = skipComment(src, i)
+			if i < 0 {
+				return -1
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipComment skips a possibly nested (: ... :) comment starting at i and
+// returns the following offset, or -1 when the comment is unterminated.
+func skipComment(src string, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch {
+		case strings.HasPrefix(src[i:], "(:"):
+			depth++
+			i += 2
+		case strings.HasPrefix(src[i:], ":)"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+func scanStringLiteral(src string, i int) (string, int, bool) {
+	quote := src[i]
+	var b strings.Builder
+	for j := i + 1; j < len(src); j++ {
+		if src[j] != quote {
+			b.WriteByte(src[j])
+			continue
+		}
+		if j+1 < len(src) && src[j+1] == quote {
+			b.WriteByte(quote)
+			j++
+			continue
+		}
+		return b.String(), j + 1, true
+	}
+	return "", 0, false
+}
+
+func scanNumber(src string, i int) (int, bool) {
+	for i < len(src) && isDigit(src[i]) {
+		i++
+	}
+	if i < len(src) && src[i] == '.' {
+		i++
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		i++
+		if i < len(src) && (src[i] == '+' || src[i] == '-') {
+			i++
+		}
+		digits := i
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+		if i == digits {
+			return i, false
+		}
+	}
+	// A number immediately followed by a name character, such as 1div, is
+	// rejected rather than split.
+	if n := scanNCName(src, i); n > i {
+		return i, false
+	}
+	return i, true
+}
+
+func scanNCName(src string, i int) int {
+	r, size := utf8.DecodeRuneInString(src[i:])
+	if size == 0 || r == ':' || !lex.IsXMLNameStartChar(r) {
+		return i
+	}
+	i += size
+	for i < len(src) {
+		r, size = utf8.DecodeRuneInString(src[i:])
+		if r == ':' || !lex.IsXMLNameChar(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+var symbols = [...]string{"!=", "<=", ">=", "<<", ">>", "//", "..", "/", "=", "<", ">", "+", "-", ",", "|", "@", ".", "?"}
+
+func scanSymbol(s string) string {
+	for _, sym := range symbols {
+		if strings.HasPrefix(s, sym) {
+			return sym
+		}
+	}
+	return ""
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func quoteRune(s string) string {
+	r, _ := utf8.DecodeRuneInString(s)
+	return "'" + string(r) + "'"
+}
@@ -0,0 +1,87 @@
+package xpath
+
+import "strings"
+
+// NodeKind identifies a tree node kind.
+type NodeKind uint8
+
+const (
+	// ElementNode is an element.
+	ElementNode NodeKind = iota
+	// AttributeNode is an attribute of its Parent element.
+	AttributeNode
+	// TextNode is character data of its Parent element.
+	TextNode
+)
+
+// Node is one node of an evaluated tree. Callers build trees in document
+// order and must not modify them while an evaluation runs.
+type Node struct {
+	Parent   *Node
+	Space    string
+	Local    string
+	Prefix   string
+	Text     string
+	Attrs    []*Node
+	Children []*Node
+	// Type is a host type annotation passed to Host.Atomize. Zero atomizes the
+	// node's string value as xs:untypedAtomic.
+	Type uint32
+	// ElementOnly marks an element whose type has element-only content; such
+	// elements have no typed value and cannot be atomized.
+	ElementOnly bool
+	Kind        NodeKind
+	order       int
+}
+
+// StringValue returns the attribute or text value, or the concatenated
+// descendant text of an element.
+func (n *Node) StringValue() string {
+	if n.Kind != ElementNode {
+		return n.Text
+	}
+	if len(n.Children) == 1 && n.Children[0].Kind == TextNode {
+		return n.Children[0].Text
+	}
+	var b strings.Builder
+	n.appendText(&b)
+	return b.String()
+}
+
+func (n *Node) appendText(b *strings.Builder) {
+	for _, child := range n.Children {
+		if child.Kind == TextNode {
+			b.WriteString(child.Text)
+		} else {
+			child.appendText(b)
+		}
+	}
+}
+
+// name returns the lexical QName of n.
+func (n *Node) name() string {
+	if n.Prefix == "" {
+		return n.Local
+	}
+	return n.Prefix + ":" + n.Local
+}
+
+// numberTree assigns document order positions to the subtree of root and
+// returns the number of nodes visited.
+func numberTree(root *Node) int {
+	next := 0
+	var walk func(*Node)
+	walk = func(n *Node) {
+		next++
+		n.order = next
+		for _, attr := range n.Attrs {
+			next++
+			attr.order = next
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return next
+}
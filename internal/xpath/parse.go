@@ -0,0 +1,710 @@
+package xpath
+
+import (
+	"slices"
+	"strings"
+)
+
+type parser struct {
+	static StaticContext
+	// bindings records the prefixes resolved through static.Namespace.
+	bindings map[string]string
+	toks     []token
+	vars     []string
+	pos      int
+	// axes records the axes of the parsed steps, one bit per axis.
+	axes uint16
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isSymbol(sym string) bool {
+	tok := p.peek()
+	return tok.kind == tokSymbol && tok.text == sym
+}
+
+func (p *parser) isName(name string) bool {
+	tok := p.peek()
+	return tok.kind == tokName && tok.text == name
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	tok := p.peek()
+	if tok.kind != kind || text != "" && tok.text != text {
+		want := text
+		if want == "" {
+			want = "token"
+		}
+		return syntaxError(tok.pos, "expected "+want+", found "+describeToken(tok))
+	}
+	p.next()
+	return nil
+}
+
+func describeToken(tok token) string {
+	if tok.kind == tokEOF {
+		return "end of expression"
+	}
+	return "'" + tok.text + "'"
+}
+
+// parseExpr parses Expr ::= ExprSingle ("," ExprSingle)*.
+func (p *parser) parseExpr() (expr, error) {
+	first, err := p.parseExprSingle()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isSymbol(",") {
+		return first, nil
+	}
+	items := []expr{first}
+	for p.isSymbol(",") {
+		p.next()
+		e, err := p.parseExprSingle()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return &sequenceExpr{items: items}, nil
+}
+
+func (p *parser) parseExprSingle() (expr, error) {
+	tok := p.peek()
+	if tok.kind == tokName {
+		switch {
+		case (tok.text == "for" || tok.text == "some" || tok.text == "every") && p.peekAt(1).kind == tokDollar:
+			return p.parseBindingExpr(tok.text)
+		case tok.text == "if" && p.peekAt(1).kind == tokOpenParen:
+			return p.parseIf()
+		}
+	}
+	return p.parseOr()
+}
+
+func (p *parser) parseBindingExpr(keyword string) (expr, error) {
+	p.next()
+	var bindings []binding
+	scope := len(p.vars)
+	defer func() { p.vars = p.vars[:scope] }()
+	for {
+		if err := p.expect(tokDollar, ""); err != nil {
+			return nil, err
+		}
+		name := p.peek()
+		if name.kind != tokName {
+			return nil, syntaxError(name.pos, "expected variable name")
+		}
+		p.next()
+		if !p.isName("in") {
+			return nil, syntaxError(p.peek().pos, "expected 'in'")
+		}
+		p.next()
+		in, err := p.parseExprSingle()
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, binding{name: name.text, in: in})
+		p.vars = append(p.vars, name.text)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	body := "satisfies"
+	if keyword == "for" {
+		body = "return"
+	}
+	if !p.isName(body) {
+		return nil, syntaxError(p.peek().pos, "expected '"+body+"'")
+	}
+	p.next()
+	ret, err := p.parseExprSingle()
+	if err != nil {
+		return nil, err
+	}
+	if keyword == "for" {
+		return &forExpr{bindings: bindings, ret: ret}, nil
+	}
+	return &quantifiedExpr{bindings: bindings, satisfies: ret, every: keyword == "every"}, nil
+}
+
+func (p *parser) parseIf() (expr, error) {
+	p.next()
+	p.next()
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokCloseParen, ")"); err != nil {
+		return nil, err
+	}
+	if !p.isName("then") {
+		return nil, syntaxError(p.peek().pos, "expected 'then'")
+	}
+	p.next()
+	then, err := p.parseExprSingle()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isName("else") {
+		return nil, syntaxError(p.peek().pos, "expected 'else'")
+	}
+	p.next()
+	els, err := p.parseExprSingle()
+	if err != nil {
+		return nil, err
+	}
+	return &ifExpr{cond: cond, then: then, els: els}, nil
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isName("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{left: left, right: right, or: true}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isName("and") {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+var (
+	generalComparisons = []string{"=", "!=", "<", "<=", ">", ">="}
+	valueComparisons   = []string{"eq", "ne", "lt", "le", "gt", "ge"}
+	nodeComparisons    = []string{"is", "<<", ">>"}
+)
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseRange()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	var kind compareKind
+	var op string
+	switch {
+	case tok.kind == tokSymbol && slices.Contains(generalComparisons, tok.text):
+		kind, op = compareGeneral, tok.text
+	case tok.kind == tokName && slices.Contains(valueComparisons, tok.text):
+		kind, op = compareValue, valueToGeneral(tok.text)
+	case tok.kind == tokSymbol && slices.Contains(nodeComparisons, tok.text),
+		tok.kind == tokName && tok.text == "is":
+		kind, op = compareNode, tok.text
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseRange()
+	if err != nil {
+		return nil, err
+	}
+	return &compareExpr{left: left, right: right, op: op, kind: kind}, nil
+}
+
+func valueToGeneral(op string) string {
+	return generalComparisons[slices.Index(valueComparisons, op)]
+}
+
+func (p *parser) parseRange() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isName("to") {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return &rangeExpr{from: left, to: right}, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol("+") || p.isSymbol("-") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpr{left: left, right: right, op: op}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol("*") || p.isName("div") || p.isName("idiv") || p.isName("mod") {
+		op := p.next().text
+		right, err := p.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpr{left: left, right: right, op: op}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnion() (expr, error) {
+	left, err := p.parseIntersect()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol("|") || p.isName("union") {
+		p.next()
+		right, err := p.parseIntersect()
+		if err != nil {
+			return nil, err
+		}
+		left = &setExpr{left: left, right: right, op: "union"}
+	}
+	return left, nil
+}
+
+func (p *parser) parseIntersect() (expr, error) {
+	left, err := p.parseCastable()
+	if err != nil {
+		return nil, err
+	}
+	for p.isName("intersect") || p.isName("except") {
+		op := p.next().text
+		right, err := p.parseCastable()
+		if err != nil {
+			return nil, err
+		}
+		left = &setExpr{left: left, right: right, op: op}
+	}
+	return left, nil
+}
+
+func (p *parser) parseCastable() (expr, error) {
+	operand, err := p.parseCast()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isName("castable") || p.peekAt(1).kind != tokName || p.peekAt(1).text != "as" {
+		return operand, nil
+	}
+	p.next()
+	p.next()
+	target, optional, err := p.parseSingleType()
+	if err != nil {
+		return nil, err
+	}
+	return &castExpr{operand: operand, target: target, optional: optional, castable: true}, nil
+}
+
+func (p *parser) parseCast() (expr, error) {
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isName("cast") || p.peekAt(1).kind != tokName || p.peekAt(1).text != "as" {
+		return operand, nil
+	}
+	p.next()
+	p.next()
+	target, optional, err := p.parseSingleType()
+	if err != nil {
+		return nil, err
+	}
+	return &castExpr{operand: operand, target: target, optional: optional}, nil
+}
+
+// parseSingleType parses an atomic type name in the XSD namespace with an
+// optional occurrence indicator.
+func (p *parser) parseSingleType() (string, bool, error) {
+	tok := p.peek()
+	if tok.kind != tokName {
+		return "", false, syntaxError(tok.pos, "expected type name")
+	}
+	p.next()
+	space, local, err := p.resolveName(tok, "")
+	if err != nil {
+		return "", false, err
+	}
+	if space != XSDNamespace || !castTarget(local) {
+		return "", false, syntaxError(tok.pos, "unknown atomic type "+tok.text)
+	}
+	optional := false
+	if p.isSymbol("?") {
+		p.next()
+		optional = true
+	}
+	return local, optional, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	negate := false
+	signed := false
+	for p.isSymbol("-") || p.isSymbol("+") {
+		signed = true
+		if p.next().text == "-" {
+			negate = !negate
+		}
+	}
+	operand, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	if !signed {
+		return operand, nil
+	}
+	return &unaryExpr{operand: operand, negate: negate}, nil
+}
+
+func (p *parser) parsePath() (expr, error) {
+	if p.isSymbol("/") || p.isSymbol("//") {
+		return nil, syntaxError(p.peek().pos, "absolute paths are not supported: the assertion tree has no document node")
+	}
+	first, err := p.parseStep()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isSymbol("/") && !p.isSymbol("//") {
+		return first, nil
+	}
+	path := &pathExpr{steps: []expr{first}}
+	for p.isSymbol("/") || p.isSymbol("//") {
+		if p.next().text == "//" {
+			p.axes |= 1 << axisDescendantOrSelf
+			path.steps = append(path.steps, &axisStep{axis: axisDescendantOrSelf, test: nodeTest{kind: testAnyKind}})
+		}
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		path.steps = append(path.steps, step)
+	}
+	return path, nil
+}
+
+func (p *parser) parseStep() (expr, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokSymbol && tok.text == "..":
+		p.next()
+		p.axes |= 1 << axisParent
+		return &axisStep{axis: axisParent, test: nodeTest{kind: testAnyKind}}, nil
+	case tok.kind == tokSymbol && tok.text == "@":
+		p.next()
+		return p.parseAxisStep(axisAttribute)
+	case tok.kind == tokName && p.peekAt(1).kind == tokAxisColons:
+		axis, ok := axisByName[tok.text]
+		if !ok {
+			return nil, syntaxError(tok.pos, "unsupported axis "+tok.text)
+		}
+		p.next()
+		p.next()
+		return p.parseAxisStep(axis)
+	case tok.kind == tokWildcard, tok.kind == tokSymbol && tok.text == "*":
+		return p.parseAxisStep(axisChild)
+	case tok.kind == tokName && p.peekAt(1).kind == tokOpenParen && isKindTest(tok.text):
+		return p.parseAxisStep(axisChild)
+	case tok.kind == tokName && p.peekAt(1).kind != tokOpenParen:
+		return p.parseAxisStep(axisChild)
+	}
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	if len(preds) == 0 {
+		return primary, nil
+	}
+	return &filterExpr{primary: primary, predicates: preds}, nil
+}
+
+func (p *parser) parseAxisStep(axis axis) (expr, error) {
+	test, err := p.parseNodeTest(axis)
+	if err != nil {
+		return nil, err
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	p.axes |= 1 << axis
+	return &axisStep{axis: axis, test: test, predicates: preds}, nil
+}
+
+func isKindTest(name string) bool {
+	switch name {
+	case "node", "text", "element", "attribute":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *parser) parseNodeTest(axis axis) (nodeTest, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokSymbol && tok.text == "*":
+		return nodeTest{kind: testName, anySpace: true, anyLocal: true}, nil
+	case tok.kind == tokWildcard:
+		if local, ok := strings.CutPrefix(tok.text, "*:"); ok {
+			return nodeTest{kind: testName, anySpace: true, local: local}, nil
+		}
+		prefix := strings.TrimSuffix(tok.text, ":*")
+		space, ok := p.namespace(prefix)
+		if !ok {
+			return nodeTest{}, syntaxError(tok.pos, "undeclared namespace prefix "+prefix)
+		}
+		return nodeTest{kind: testName, space: space, anyLocal: true}, nil
+	case tok.kind == tokName && p.peek().kind == tokOpenParen && isKindTest(tok.text):
+		return p.parseKindTest(tok)
+	case tok.kind == tokName:
+		def := p.static.DefaultElementNamespace
+		if axis == axisAttribute {
+			def = ""
+		}
+		space, local, err := p.resolveName(tok, def)
+		if err != nil {
+			return nodeTest{}, err
+		}
+		return nodeTest{kind: testName, space: space, local: local}, nil
+	default:
+		return nodeTest{}, syntaxError(tok.pos, "expected node test, found "+describeToken(tok))
+	}
+}
+
+func (p *parser) parseKindTest(tok token) (nodeTest, error) {
+	p.next()
+	test := nodeTest{kind: testAnyKind}
+	switch tok.text {
+	case "text":
+		test.kind = testText
+	case "element", "attribute":
+		test = nodeTest{kind: testElement, anySpace: true, anyLocal: true}
+		def := p.static.DefaultElementNamespace
+		if tok.text == "attribute" {
+			test.kind = testAttribute
+			def = ""
+		}
+		name := p.peek()
+		switch {
+		case name.kind == tokSymbol && name.text == "*":
+			p.next()
+		case name.kind == tokName:
+			p.next()
+			space, local, err := p.resolveName(name, def)
+			if err != nil {
+				return nodeTest{}, err
+			}
+			test.space, test.local, test.anySpace, test.anyLocal = space, local, false, false
+		}
+	}
+	if err := p.expect(tokCloseParen, ")"); err != nil {
+		return nodeTest{}, err
+	}
+	return test, nil
+}
+
+func (p *parser) parsePredicates() ([]expr, error) {
+	var preds []expr
+	for p.peek().kind == tokOpenSquare {
+		p.next()
+		pred, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokCloseSquare, "]"); err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	return preds, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokString:
+		p.next()
+		return &literalExpr{value: String(tok.text)}, nil
+	case tokNumber:
+		p.next()
+		return p.numberLiteral(tok)
+	case tokDollar:
+		p.next()
+		name := p.peek()
+		if name.kind != tokName {
+			return nil, syntaxError(name.pos, "expected variable name")
+		}
+		p.next()
+		if name.text != "value" && !slices.Contains(p.vars, name.text) {
+			return nil, syntaxError(name.pos, "undeclared variable $"+name.text)
+		}
+		return &variableExpr{name: name.text}, nil
+	case tokOpenParen:
+		p.next()
+		if p.peek().kind == tokCloseParen {
+			p.next()
+			return &sequenceExpr{}, nil
+		}
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tokSymbol:
+		if tok.text == "." {
+			p.next()
+			return &contextItemExpr{}, nil
+		}
+	case tokName:
+		if p.peekAt(1).kind == tokOpenParen {
+			return p.parseFunctionCall()
+		}
+	}
+	return nil, syntaxError(tok.pos, "unexpected "+describeToken(tok))
+}
+
+func (p *parser) numberLiteral(tok token) (expr, error) {
+	t := TypeInteger
+	switch {
+	case strings.ContainsAny(tok.text, "eE"):
+		t = TypeDouble
+	case strings.Contains(tok.text, "."):
+		t = TypeDecimal
+	}
+	v, err := ParseNumber(t, tok.text)
+	if err != nil {
+		return nil, syntaxError(tok.pos, err.Error())
+	}
+	return &literalExpr{value: v}, nil
+}
+
+func (p *parser) parseFunctionCall() (expr, error) {
+	name := p.next()
+	p.next()
+	var args []expr
+	if p.peek().kind != tokCloseParen {
+		for {
+			arg, err := p.parseExprSingle()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(tokCloseParen, ")"); err != nil {
+		return nil, err
+	}
+	space, local, err := p.resolveName(name, FunctionsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	switch space {
+	case XSDNamespace:
+		if !castTarget(local) {
+			return nil, syntaxError(name.pos, "unknown constructor function "+name.text)
+		}
+		if len(args) != 1 {
+			return nil, syntaxError(name.pos, "constructor function "+name.text+" takes one argument")
+		}
+		return &castExpr{operand: args[0], target: local, optional: true}, nil
+	case FunctionsNamespace:
+		fn, ok := functions[local]
+		if !ok {
+			return nil, syntaxError(name.pos, "unsupported function "+name.text)
+		}
+		if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+			return nil, syntaxError(name.pos, "wrong number of arguments to "+name.text)
+		}
+		return &callExpr{fn: fn, name: local, args: args}, nil
+	default:
+		return nil, syntaxError(name.pos, "unsupported function "+name.text)
+	}
+}
+
+// resolveName expands a lexical QName; unprefixed names take def.
+func (p *parser) resolveName(tok token, def string) (space, local string, err error) {
+	prefix, local, ok := strings.Cut(tok.text, ":")
+	if !ok {
+		return def, tok.text, nil
+	}
+	space, found := p.namespace(prefix)
+	if !found {
+		return "", "", syntaxError(tok.pos, "undeclared namespace prefix "+prefix)
+	}
+	return space, local, nil
+}
+
+func (p *parser) namespace(prefix string) (string, bool) {
+	if p.static.Namespace != nil {
+		if uri, ok := p.static.Namespace(prefix); ok {
+			if p.bindings == nil {
+				p.bindings = make(map[string]string)
+			}
+			p.bindings[prefix] = uri
+			return uri, true
+		}
+	}
+	switch prefix {
+	case "fn":
+		return FunctionsNamespace, true
+	case "xml":
+		return xmlNamespace, true
+	case "xs":
+		return XSDNamespace, true
+	default:
+		return "", false
+	}
+}
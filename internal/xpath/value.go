@@ -0,0 +1,395 @@
+package xpath
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jacoelho/xsd/internal/lex"
+)
+
+// Type is the datatype family of an atomic value.
+type Type uint8
+
+const (
+	// TypeUntypedAtomic is xs:untypedAtomic, the value of untyped nodes.
+	TypeUntypedAtomic Type = iota
+	// TypeString is xs:string and the string-like host types.
+	TypeString
+	// TypeBoolean is xs:boolean.
+	TypeBoolean
+	// TypeDecimal is xs:decimal.
+	TypeDecimal
+	// TypeInteger is xs:integer and its derived types.
+	TypeInteger
+	// TypeFloat is xs:float.
+	TypeFloat
+	// TypeDouble is xs:double.
+	TypeDouble
+	// TypeOther is a host-modelled datatype carrying a Comparable value.
+	TypeOther
+)
+
+// Comparable is a host-modelled atomic value such as a date or a duration.
+type Comparable interface {
+	// Compare orders the receiver against other. ok is false when the two
+	// values are not comparable.
+	Compare(other Comparable) (order int, ok bool)
+}
+
+// Host supplies datatype knowledge the package does not model natively.
+type Host interface {
+	// Atomize returns the typed value of a node annotated with typ whose
+	// string value is lexical.
+	Atomize(typ uint32, lexical string) ([]Atomic, error)
+	// Cast converts lexical to the XSD built-in datatype with the given local
+	// name. It is consulted for datatypes other than string, untypedAtomic,
+	// boolean, decimal, integer, float and double.
+	Cast(local, lexical string) (Atomic, error)
+}
+
+// Atomic is an atomic value.
+type Atomic struct {
+	value   Comparable
+	rat     *big.Rat
+	lexical string
+	name    string
+	float   float64
+	typ     Type
+	boolean bool
+}
+
+// Item is a node or an atomic value.
+type Item struct {
+	node *Node
+	atom Atomic
+}
+
+type sequence []Item
+
+func nodeItem(n *Node) Item       { return Item{node: n} }
+func atomItem(a Atomic) Item      { return Item{atom: a} }
+func singleton(a Atomic) sequence { return sequence{atomItem(a)} }
+
+// String returns an xs:string value.
+func String(s string) Atomic { return Atomic{typ: TypeString, lexical: s, name: "string"} }
+
+// Untyped returns an xs:untypedAtomic value.
+func Untyped(s string) Atomic {
+	return Atomic{typ: TypeUntypedAtomic, lexical: s, name: "untypedAtomic"}
+}
+
+// StringLike returns a value of a string-like XSD datatype such as xs:anyURI
+// that compares as a string.
+func StringLike(name, s string) Atomic { return Atomic{typ: TypeString, lexical: s, name: name} }
+
+// Boolean returns an xs:boolean value.
+func Boolean(b bool) Atomic {
+	if b {
+		return Atomic{typ: TypeBoolean, boolean: true, lexical: "true", name: "boolean"}
+	}
+	return Atomic{typ: TypeBoolean, lexical: "false", name: "boolean"}
+}
+
+// Other returns a host-modelled value of the XSD datatype name.
+func Other(name, lexical string, v Comparable) Atomic {
+	return Atomic{typ: TypeOther, name: name, lexical: lexical, value: v}
+}
+
+// ParseNumber parses lexical as a numeric value of type t, which must be
+// TypeDecimal, TypeInteger, TypeFloat or TypeDouble.
+func ParseNumber(t Type, lexical string) (Atomic, error) {
+	s := lex.TrimXMLWhitespaceString(lexical)
+	switch t {
+	case TypeDecimal, TypeInteger:
+		if !validDecimalLexical(s, t == TypeInteger) {
+			return Atomic{}, errors.New("invalid " + typeName(t) + " " + strconv.Quote(lexical))
+		}
+		r, ok := new(big.Rat).SetString(strings.TrimPrefix(s, "+"))
+		if !ok {
+			return Atomic{}, errors.New("invalid " + typeName(t) + " " + strconv.Quote(lexical))
+		}
+		return ratValue(t, r), nil
+	case TypeFloat, TypeDouble:
+		f, ok := parseFloatLexical(s)
+		if !ok {
+			return Atomic{}, errors.New("invalid " + typeName(t) + " " + strconv.Quote(lexical))
+		}
+		return floatValue(t, f), nil
+	default:
+		return Atomic{}, errors.New("non-numeric type")
+	}
+}
+
+// Type returns the datatype family of a.
+func (a Atomic) Type() Type { return a.typ }
+
+// Lexical returns the string value of a.
+func (a Atomic) Lexical() string { return a.stringValue() }
+
+func ratValue(t Type, r *big.Rat) Atomic {
+	if t == TypeInteger && !r.IsInt() {
+		t = TypeDecimal
+	}
+	return Atomic{typ: t, rat: r, name: typeName(t)}
+}
+
+func floatValue(t Type, f float64) Atomic {
+	if t == TypeFloat {
+		f = float64(float32(f))
+	}
+	return Atomic{typ: t, float: f, name: typeName(t)}
+}
+
+func typeName(t Type) string {
+	switch t {
+	case TypeUntypedAtomic:
+		return "untypedAtomic"
+	case TypeString:
+		return "string"
+	case TypeBoolean:
+		return "boolean"
+	case TypeDecimal:
+		return "decimal"
+	case TypeInteger:
+		return "integer"
+	case TypeFloat:
+		return "float"
+	case TypeDouble:
+		return "double"
+	default:
+		return "anyAtomicType"
+	}
+}
+
+func (a Atomic) isNumeric() bool {
+	return a.typ >= TypeDecimal && a.typ <= TypeDouble
+}
+
+func (a Atomic) isStringy() bool {
+	return a.typ == TypeString || a.typ == TypeUntypedAtomic
+}
+
+// stringValue returns the canonical lexical form used by fn:string.
+func (a Atomic) stringValue() string {
+	switch a.typ {
+	case TypeDecimal:
+		return formatDecimal(a.rat)
+	case TypeInteger:
+		return a.rat.Num().String()
+	case TypeFloat, TypeDouble:
+		return formatFloat(a.float)
+	default:
+		return a.lexical
+	}
+}
+
+// toFloat converts a numeric value to float64.
+func (a Atomic) toFloat() float64 {
+	if a.typ == TypeFloat || a.typ == TypeDouble {
+		return a.float
+	}
+	f, _ := a.rat.Float64()
+	return f
+}
+
+func validDecimalLexical(s string, integer bool) bool {
+	if s == "" {
+		return false
+	}
+	if s[0] == '+' || s[0] == '-' {
+		s = s[1:]
+	}
+	digits, dot := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case isDigit(s[i]):
+			digits++
+		case s[i] == '.' && !dot && !integer:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+func parseFloatLexical(s string) (float64, bool) {
+	switch s {
+	case "INF", "+INF":
+		return math.Inf(1), true
+	case "-INF":
+		return math.Inf(-1), true
+	case "NaN":
+		return math.NaN(), true
+	}
+	if s == "" || strings.ContainsAny(s, "xXpP_") || strings.EqualFold(strings.TrimLeft(s, "+-"), "inf") ||
+		strings.EqualFold(strings.TrimLeft(s, "+-"), "infinity") || strings.EqualFold(strings.TrimLeft(s, "+-"), "nan") {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		if numErr, ok := errors.AsType[*strconv.NumError](err); ok && errors.Is(numErr.Err, strconv.ErrRange) {
+			return f, true
+		}
+		return 0, false
+	}
+	return f, true
+}
+
+func formatDecimal(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := r.FloatString(decimalDigits(r))
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// decimalDigits returns the number of fractional digits needed to print r
+// exactly, bounded for non-terminating fractions.
+func decimalDigits(r *big.Rat) int {
+	d := new(big.Int).Set(r.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	var m big.Int
+	twos, fives := 0, 0
+	for m.Mod(d, two).Sign() == 0 {
+		d.Quo(d, two)
+		twos++
+	}
+	for m.Mod(d, five).Sign() == 0 {
+		d.Quo(d, five)
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 18
+	}
+	return max(twos, fives)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	case f == 0:
+		if math.Signbit(f) {
+			return "-0"
+		}
+		return "0"
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e6 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'E', -1, 64)
+	mantissa, exp, _ := strings.Cut(s, "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp = strings.TrimPrefix(exp, "+")
+	return mantissa + "E" + exp
+}
+
+// cast converts a to the named XSD datatype.
+func cast(host Host, a Atomic, local string) (Atomic, error) {
+	switch local {
+	case "string":
+		return String(a.stringValue()), nil
+	case "untypedAtomic":
+		return Untyped(a.stringValue()), nil
+	case "boolean":
+		switch {
+		case a.typ == TypeBoolean:
+			return a, nil
+		case a.isNumeric():
+			if a.typ == TypeFloat || a.typ == TypeDouble {
+				return Boolean(a.float != 0 && !math.IsNaN(a.float)), nil
+			}
+			return Boolean(a.rat.Sign() != 0), nil
+		case a.isStringy():
+			switch lex.TrimXMLWhitespaceString(a.lexical) {
+			case "true", "1":
+				return Boolean(true), nil
+			case "false", "0":
+				return Boolean(false), nil
+			}
+		}
+		return Atomic{}, castError(a, local)
+	case "decimal", "integer":
+		t := TypeDecimal
+		if local == "integer" {
+			t = TypeInteger
+		}
+		return castNumberToRat(a, t)
+	case "double", "float":
+		t := TypeDouble
+		if local == "float" {
+			t = TypeFloat
+		}
+		switch {
+		case a.typ == TypeBoolean:
+			return floatValue(t, b2f(a.boolean)), nil
+		case a.isNumeric():
+			return floatValue(t, a.toFloat()), nil
+		case a.isStringy():
+			return ParseNumber(t, a.lexical)
+		}
+		return Atomic{}, castError(a, local)
+	}
+	if host == nil {
+		return Atomic{}, errors.New("cast to xs:" + local + " is not supported")
+	}
+	if a.typ == TypeOther && a.name == local {
+		return a, nil
+	}
+	return host.Cast(local, a.stringValue())
+}
+
+func castNumberToRat(a Atomic, t Type) (Atomic, error) {
+	switch {
+	case a.typ == TypeBoolean:
+		if a.boolean {
+			return ratValue(t, big.NewRat(1, 1)), nil
+		}
+		return ratValue(t, new(big.Rat)), nil
+	case a.typ == TypeFloat || a.typ == TypeDouble:
+		if math.IsNaN(a.float) || math.IsInf(a.float, 0) {
+			return Atomic{}, castError(a, typeName(t))
+		}
+		r := new(big.Rat).SetFloat64(a.float)
+		if t == TypeInteger {
+			r = truncRat(r)
+		}
+		return ratValue(t, r), nil
+	case a.typ == TypeDecimal || a.typ == TypeInteger:
+		r := a.rat
+		if t == TypeInteger {
+			r = truncRat(r)
+		}
+		return ratValue(t, r), nil
+	case a.isStringy():
+		return ParseNumber(t, a.lexical)
+	}
+	return Atomic{}, castError(a, typeName(t))
+}
+
+func castError(a Atomic, local string) error {
+	return errors.New("cannot cast " + strconv.Quote(a.stringValue()) + " to xs:" + local)
+}
+
+func b2f(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func truncRat(r *big.Rat) *big.Rat {
+	q := new(big.Int).Quo(r.Num(), r.Denom())
+	return new(big.Rat).SetInt(q)
+}
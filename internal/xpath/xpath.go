@@ -0,0 +1,168 @@
+// Package xpath compiles and evaluates the XPath 2.0 subset used by XML Schema
+// 1.1 assertions.
+//
+// Expressions are evaluated over small immutable Node trees built by the
+// caller, without a document node: the context element of an assertion is
+// the root of its tree, so absolute paths are rejected at compile time. The
+// subset covers path expressions over the child, attribute, self, parent,
+// ancestor, descendant and sibling axes with predicates; general, value and
+// node comparisons; arithmetic; sequence, range, union, intersect and except
+// operators; if, for, some and every expressions; cast and castable; and the
+// core string, numeric, boolean, sequence and node functions. String, boolean
+// and numeric values are modelled natively; other XSD datatypes such as dates
+// and durations are supplied by the Host as Comparable values.
+//
+// Evaluation is bounded: every evaluation step counts against a budget, so a
+// compiled expression cannot run unbounded work over a bounded tree.
+package xpath
+
+import (
+	"errors"
+	"maps"
+	"strconv"
+)
+
+// DefaultMaxSteps is the evaluation step budget used when Context.MaxSteps is
+// zero.
+const DefaultMaxSteps = 1_000_000
+
+// Namespace URIs with fixed meaning in expressions.
+const (
+	XSDNamespace       = "http://www.w3.org/2001/XMLSchema"
+	FunctionsNamespace = "http://www.w3.org/2005/xpath-functions"
+	xmlNamespace       = "http://www.w3.org/XML/1998/namespace"
+)
+
+// ErrStepLimit reports an evaluation that exceeded its step budget.
+var ErrStepLimit = errors.New("XPath evaluation exceeds step limit")
+
+// SyntaxError reports an expression the subset cannot compile.
+type SyntaxError struct {
+	Msg    string
+	Offset int
+}
+
+func (e *SyntaxError) Error() string {
+	return "XPath syntax error at offset " + strconv.Itoa(e.Offset) + ": " + e.Msg
+}
+
+func syntaxError(offset int, msg string) error {
+	return &SyntaxError{Offset: offset, Msg: msg}
+}
+
+// StaticContext supplies the compile-time environment of an expression.
+type StaticContext struct {
+	// Namespace resolves an in-scope namespace prefix. The fn, xs and xml
+	// prefixes resolve to their standard namespaces when Namespace does not
+	// bind them.
+	Namespace func(prefix string) (uri string, ok bool)
+	// DefaultElementNamespace applies to unprefixed element name tests.
+	DefaultElementNamespace string
+}
+
+// Expr is a compiled expression. It is immutable and safe for concurrent use.
+type Expr struct {
+	root expr
+	// namespaces and defaultElementNamespace are the parts of the static
+	// context the expression depends on.
+	namespaces              map[string]string
+	source                  string
+	defaultElementNamespace string
+	// axes records the axes of the expression's steps, one bit per axis.
+	axes uint16
+}
+
+// Compile parses src against ctx.
+func Compile(src string, ctx StaticContext) (*Expr, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{toks: toks, static: ctx}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, syntaxError(tok.pos, "unexpected "+describeToken(tok))
+	}
+	return &Expr{
+		root:                    root,
+		namespaces:              p.bindings,
+		source:                  src,
+		defaultElementNamespace: ctx.DefaultElementNamespace,
+		axes:                    p.axes,
+	}, nil
+}
+
+// ElementAxis returns the name of an axis other than attribute and self that
+// a step of e uses, and whether there is one. Expressions without one only
+// read the attributes of the context element, the subset XML Schema allows in
+// type alternative tests.
+func (e *Expr) ElementAxis() (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	for a := axisChild; a <= axisPrecedingSibling; a++ {
+		if a != axisAttribute && a != axisSelf && e.axes&(1<<a) != 0 {
+			return a.String(), true
+		}
+	}
+	return "", false
+}
+
+// String returns the source text of e.
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.source
+}
+
+// Namespaces returns the prefix bindings resolved through the static context
+// while e was compiled. Compiling the source of e against these bindings and
+// its DefaultElementNamespace yields an equivalent expression.
+func (e *Expr) Namespaces() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return maps.Clone(e.namespaces)
+}
+
+// DefaultElementNamespace returns the default element namespace e was
+// compiled with.
+func (e *Expr) DefaultElementNamespace() string {
+	if e == nil {
+		return ""
+	}
+	return e.defaultElementNamespace
+}
+
+// Context is the dynamic context of one evaluation.
+type Context struct {
+	// Node is the context item. It is the root of the evaluated tree: its
+	// parent, if any, is not visible to the expression. Nil means the context
+	// item is absent.
+	Node *Node
+	// Host atomizes typed nodes and casts to host-modelled datatypes. A nil
+	// Host atomizes every node to xs:untypedAtomic.
+	Host Host
+	// Value is bound to the $value variable.
+	Value []Atomic
+	// MaxSteps bounds evaluation work; zero selects DefaultMaxSteps.
+	MaxSteps int
+}
+
+// Test evaluates e and returns its effective boolean value.
+func (e *Expr) Test(ctx Context) (bool, error) {
+	ev := newEvaluator(ctx)
+	var f focus
+	if ctx.Node != nil {
+		f = focus{item: nodeItem(ctx.Node), pos: 1, size: 1}
+	}
+	seq, err := e.root.eval(ev, f)
+	if err != nil {
+		return false, err
+	}
+	return effectiveBoolean(seq)
+}
@@ -0,0 +1,296 @@
+package xpath
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testTree builds <order id="7" xmlns:p="urn:p"><line qty="2">a</line>
+// <line qty="5">b</line><p:note>n</p:note></order>.
+func testTree() *Node {
+	order := &Node{Kind: ElementNode, Local: "order"}
+	order.Attrs = []*Node{{Kind: AttributeNode, Local: "id", Text: "7", Parent: order}}
+	for _, line := range []struct{ qty, text string }{{"2", "a"}, {"5", "b"}} {
+		n := &Node{Kind: ElementNode, Local: "line", Parent: order}
+		n.Attrs = []*Node{{Kind: AttributeNode, Local: "qty", Text: line.qty, Parent: n}}
+		n.Children = []*Node{{Kind: TextNode, Text: line.text, Parent: n}}
+		order.Children = append(order.Children, n)
+	}
+	note := &Node{Kind: ElementNode, Space: "urn:p", Prefix: "p", Local: "note", Parent: order}
+	note.Children = []*Node{{Kind: TextNode, Text: "n", Parent: note}}
+	order.Children = append(order.Children, note)
+	return order
+}
+
+func testStatic() StaticContext {
+	return StaticContext{Namespace: func(prefix string) (string, bool) {
+		if prefix == "p" {
+			return "urn:p", true
+		}
+		return "", false
+	}}
+}
+
+func TestExprTest(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "count(line) = 2", want: true},
+		{expr: "@id = 7", want: true},
+		{expr: "@id eq '7'", want: true},
+		{expr: "line[1]/@qty < line[2]/@qty", want: true},
+		{expr: "sum(line/@qty) = 7", want: true},
+		{expr: "sum(line/@qty) div count(line) = 3.5", want: true},
+		{expr: "every $l in line satisfies $l/@qty > 1", want: true},
+		{expr: "some $l in line satisfies $l/@qty > 4", want: true},
+		{expr: "some $l in line satisfies $l/@qty > 5", want: false},
+		{expr: "line[last()] = 'b'", want: true},
+		{expr: "p:note = 'n' and not(note)", want: true},
+		{expr: "*:note and count(*) = 3", want: true},
+		{expr: "exists(line/..) and empty(..)", want: true},
+		{expr: "if (@id > 5) then true() else false()", want: true},
+		{expr: "string-join(line, ',') = 'a,b'", want: true},
+		{expr: "concat(line[1], '-', line[2]) eq 'a-b'", want: true},
+		{expr: "max(line/@qty) = 5 and min(line/@qty) = 2", want: true},
+		{expr: "(1 to 3)[. mod 2 = 0] = 2", want: true},
+		{expr: "(for $q in line/@qty return $q * 2) = 10", want: true},
+		{expr: "'10' castable as xs:integer and not('x' castable as xs:integer)", want: true},
+		{expr: "xs:decimal('1.50') eq 1.5", want: true},
+		{expr: "7 idiv 2 = 3 and -7 mod 2 = -1", want: true},
+		{expr: "1 div 3 * 3 = 1", want: true},
+		{expr: "string(0.1 + 0.2) = '0.3'", want: true},
+		{expr: "substring('12345', 2, 3) = '234'", want: true},
+		{expr: "normalize-space('  a   b ') = 'a b'", want: true},
+		{expr: "line[@qty = 5]/preceding-sibling::line = 'a'", want: true},
+		{expr: "descendant::text() = 'n'", want: true},
+		{expr: "line[1] << line[2] and line[1] is line[1]", want: true},
+		{expr: "count(line | line[1]) = 2 and count(line except line[1]) = 1", want: true},
+		{expr: "local-name(p:note) = 'note' and name(p:note) = 'p:note'", want: true},
+		{expr: "()", want: false},
+		{expr: "'' or 0", want: false},
+		{expr: "(: comment :) true()", want: true},
+	}
+	root := testTree()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr, testStatic())
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			got, err := e.Test(Context{Node: root})
+			if err != nil {
+				t.Fatalf("Test() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Test() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprValue(t *testing.T) {
+	e, err := Compile("$value > 0 and $value mod 2 = 0", StaticContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for lexical, want := range map[string]bool{"4": true, "3": false, "-2": false} {
+		v, err := ParseNumber(TypeInteger, lexical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := e.Test(Context{Value: []Atomic{v}})
+		if err != nil || got != want {
+			t.Fatalf("Test($value=%s) = %v, %v; want %v", lexical, got, err, want)
+		}
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	for _, src := range []string{
+		"/order",
+		"//line",
+		"count(",
+		"q:line",
+		"$undeclared",
+		"unknown-function()",
+		"xs:nope('1')",
+		"line[",
+		"1 +",
+		"'open",
+	} {
+		t.Run(src, func(t *testing.T) {
+			_, err := Compile(src, testStatic())
+			if _, ok := errors.AsType[*SyntaxError](err); !ok {
+				t.Fatalf("Compile(%q) error = %v, want *SyntaxError", src, err)
+			}
+		})
+	}
+}
+
+func TestExprElementAxis(t *testing.T) {
+	for src, want := range map[string]string{
+		"@id = 7":                  "",
+		"self::node()/@id":         "",
+		"attribute::id and . = ''": "",
+		"line":                     "child",
+		"@id and .//@id":           "descendant-or-self",
+		"../@id":                   "parent",
+		"ancestor::order":          "ancestor",
+		"following-sibling::*":     "following-sibling",
+	} {
+		expr, err := Compile(src, testStatic())
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", src, err)
+		}
+		if got, _ := expr.ElementAxis(); got != want {
+			t.Fatalf("ElementAxis(%q) = %q, want %q", src, got, want)
+		}
+	}
+}
+
+func TestExprDynamicErrors(t *testing.T) {
+	root := testTree()
+	for _, src := range []string{
+		"line = 1 div 0",
+		"('a', 'b')",
+		"@id + 'x'",
+	} {
+		e, err := Compile(src, testStatic())
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", src, err)
+		}
+		if _, err := e.Test(Context{Node: root}); err == nil {
+			t.Fatalf("Test(%q) error = nil, want dynamic error", src)
+		}
+	}
+}
+
+func TestExprStepLimit(t *testing.T) {
+	e, err := Compile("count(for $a in 1 to 1000, $b in 1 to 1000 return $a) > 0", StaticContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = e.Test(Context{MaxSteps: 10_000})
+	if !errors.Is(err, ErrStepLimit) {
+		t.Fatalf("Test() error = %v, want ErrStepLimit", err)
+	}
+}
+
+func TestExprRange(t *testing.T) {
+	for _, src := range []string{
+		"count(1 to 5) = 5 and (1 to 5)[3] = 3",
+		"(3 to 3) = 3 and count(3 to 3) = 1",
+		"empty(5 to 1) and empty(() to 3) and empty(1 to ())",
+		"sum(-2 to 2) = 0",
+		"count(9223372036854775806 to 9223372036854775807) = 2",
+		"(9223372036854775806 to 9223372036854775807)[2] = 9223372036854775807",
+		"count(-9223372036854775808 to -9223372036854775807) = 2",
+		"count(xs:integer('2') to 4.0 idiv 1) = 3",
+	} {
+		t.Run(src, func(t *testing.T) {
+			e, err := Compile(src, StaticContext{})
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if got, err := e.Test(Context{}); err != nil || !got {
+				t.Fatalf("Test() = %v, %v; want true", got, err)
+			}
+		})
+	}
+}
+
+func TestExprRangeLimit(t *testing.T) {
+	for _, src := range []string{
+		"count(-9223372036854775808 to 9223372036854775807) gt 0",
+		"count(-1 to 9223372036854775807) gt 0",
+		"count(-9223372036854775808 to 0) gt 0",
+		"count(0 to 9223372036854775808) gt 0",
+		"count(1 to 1000000) gt 0",
+	} {
+		t.Run(src, func(t *testing.T) {
+			e, err := Compile(src, StaticContext{})
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if _, err := e.Test(Context{}); !errors.Is(err, ErrStepLimit) {
+				t.Fatalf("Test() error = %v, want ErrStepLimit", err)
+			}
+		})
+	}
+}
+
+func TestExprStepBudget(t *testing.T) {
+	tests := []struct {
+		expr     string
+		node     bool
+		maxSteps int
+	}{
+		{expr: "count(1 to 500) = 500", maxSteps: 400},
+		{expr: "count(for $i in 1 to 50 return 1 to 50) = 2500", maxSteps: 2000},
+		{expr: "every $i in 1 to 1000 satisfies $i > 0", maxSteps: 1500},
+		{expr: "(1 to 200) = (1001 to 1200) or true()", maxSteps: 10_000},
+		{expr: "count(distinct-values(1 to 300)) = 300", maxSteps: 20_000},
+		{expr: "string-length(string-join(for $i in 1 to 300 return 'x', '')) = 300", maxSteps: 300},
+		{expr: "true()", node: true, maxSteps: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr, testStatic())
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			ctx := Context{MaxSteps: tt.maxSteps}
+			if tt.node {
+				ctx.Node = testTree()
+			}
+			if _, err := e.Test(ctx); !errors.Is(err, ErrStepLimit) {
+				t.Fatalf("Test(MaxSteps: %d) error = %v, want ErrStepLimit", tt.maxSteps, err)
+			}
+			ctx.MaxSteps = 0
+			if got, err := e.Test(ctx); err != nil || !got {
+				t.Fatalf("Test() = %v, %v; want true within DefaultMaxSteps", got, err)
+			}
+		})
+	}
+}
+
+type testHost struct{}
+
+type testDate string
+
+func (d testDate) Compare(other Comparable) (int, bool) {
+	o, ok := other.(testDate)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(string(d), string(o)), true
+}
+
+func (testHost) Atomize(_ uint32, lexical string) ([]Atomic, error) {
+	return []Atomic{Other("date", lexical, testDate(lexical))}, nil
+}
+
+func (testHost) Cast(local, lexical string) (Atomic, error) {
+	if local != "date" {
+		return Atomic{}, errors.New("unsupported")
+	}
+	return Other(local, lexical, testDate(lexical)), nil
+}
+
+func TestExprHostValues(t *testing.T) {
+	start := &Node{Kind: ElementNode, Local: "r"}
+	start.Attrs = []*Node{
+		{Kind: AttributeNode, Local: "from", Text: "2024-01-01", Type: 1, Parent: start},
+		{Kind: AttributeNode, Local: "to", Text: "2024-02-01", Type: 1, Parent: start},
+	}
+	e, err := Compile("@from < @to and @to = xs:date('2024-02-01')", StaticContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := e.Test(Context{Node: start, Host: testHost{}})
+	if err != nil || !got {
+		t.Fatalf("Test() = %v, %v; want true", got, err)
+	}
+}
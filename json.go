@@ -0,0 +1,75 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/jacoelho/xsd/internal/jsonconv"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// XMLToJSON validates the XML document read from r and writes its JSON form
+// to w. The mapping follows the schema rather than the instance:
+//
+//   - The document is an object with one member named after its root
+//     element.
+//   - Elements whose type is simple, or complex with simple content and no
+//     attributes, are scalars. Other elements are objects holding attributes
+//     as "@name", simple content as "#value", mixed character data as
+//     "#text" and child elements by name.
+//   - Child elements whose particle, or an enclosing group, has a maxOccurs
+//     above one are arrays even when they occur once.
+//   - Values of numeric and boolean types are JSON numbers and booleans in
+//     canonical form; INF, -INF and NaN stay strings. Lists are arrays and
+//     union values take the form of their first accepting member type.
+//   - Nilled elements are null, or an object whose "#value" is null when they
+//     have attributes.
+//
+// Members are named by local name where that is unambiguous among the
+// declarations of the parent's type, and by Clark notation, {namespace}local,
+// otherwise. xsi attributes are dropped. Nothing is written when the document
+// is invalid; the error is Validate's.
+func (e *Engine) XMLToJSON(ctx context.Context, w io.Writer, r io.Reader) error {
+	return e.XMLToJSONWithOptions(ctx, w, r, ValidateOptions{})
+}
+
+// XMLToJSONWithOptions is XMLToJSON with validation options. Schema
+// location hints are not loaded, since the mapping comes from e's schema.
+// The JSON form is held in memory until the document is known to be valid;
+// its size is charged against MaxInstanceBytes, and a larger form fails with
+// xsderrors.CodeValidationLimit.
+func (e *Engine) XMLToJSONWithOptions(ctx context.Context, w io.Writer, r io.Reader, opts ValidateOptions) error {
+	if e == nil || e.rt == nil {
+		return xsderrors.InternalInvariant("JSON conversion requires a compiled engine")
+	}
+	opts.SchemaLocationResolver = nil
+	internal, err := e.internalValidateOptions(opts)
+	if err != nil {
+		return err
+	}
+	return jsonconv.ToJSON(ctx, e.rt, w, r, internal)
+}
+
+// JSONToXML reads a JSON document in the form XMLToJSON writes and writes
+// the XML document it describes to w. Child elements are written in the
+// order of the particles that declare them, every namespace is declared on
+// the root element, and null values set xsi:nil. The document is validated
+// before it is written; malformed JSON and members that name no element or
+// attribute fail with xsderrors.CodeValidationJSON, as do members of one
+// repeated sequence that hold several elements each, since their
+// interleaving cannot be recovered.
+func (e *Engine) JSONToXML(ctx context.Context, w io.Writer, r io.Reader) error {
+	if e == nil || e.rt == nil {
+		return xsderrors.InternalInvariant("JSON conversion requires a compiled engine")
+	}
+	doc, err := jsonconv.ToXML(e.rt, r)
+	if err != nil {
+		return err
+	}
+	if err := e.Validate(ctx, bytes.NewReader(doc)); err != nil {
+		return err
+	}
+	_, err = w.Write(doc)
+	return err
+}
@@ -0,0 +1,68 @@
+package xsd
+
+import (
+	"github.com/jacoelho/xsd/internal/jsonconv"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// JSONSchema is a JSON Schema export of a compiled schema.
+type JSONSchema struct {
+	// Document is the JSON Schema 2020-12 document.
+	Document []byte
+	// Unrepresented lists the schema constructs Document does not express,
+	// or expresses more loosely than the schema does, sorted by component.
+	Unrepresented []UnrepresentedConstruct
+}
+
+// UnrepresentedConstruct is a schema construct a JSON Schema export leaves
+// out.
+type UnrepresentedConstruct struct {
+	// Component is the Clark name of the global declaration or type
+	// definition, followed by the member keys of the nested local
+	// declarations, such as "{urn:orders}order/line/@sku".
+	Component string
+	// Construct names the construct, such as "identity constraint",
+	// "mixed content" or "totalDigits".
+	Construct string
+	// Detail says what the export does not check.
+	Detail string
+}
+
+// JSONSchema exports e's schema as a JSON Schema 2020-12 document that
+// describes the JSON form XMLToJSON writes:
+//
+//   - Named types and global elements are $defs entries. The document is an
+//     object with one property for each global element that is not
+//     abstract.
+//   - Sequences require the keys of their required particles. Choices
+//     require one branch and forbid the keys of the others unless they
+//     repeat.
+//   - Repeating children are arrays whose minItems and maxItems come from
+//     the particle when it alone bounds their number.
+//   - Extensions of named complex types and restrictions of named simple
+//     types combine a $ref to their base with allOf.
+//   - Patterns are translated to ECMA-262 expressions; length, range,
+//     enumeration and fractionDigits facets map to their JSON Schema
+//     keywords.
+//
+// Identity constraints, assertions, mixed content, wildcards, type
+// alternatives and facets JSON Schema has no keyword for are reported in
+// Unrepresented rather than failing the export.
+func (e *Engine) JSONSchema() (JSONSchema, error) {
+	if e == nil || e.rt == nil {
+		return JSONSchema{}, xsderrors.InternalInvariant("JSON Schema export requires a compiled engine")
+	}
+	doc, losses, err := jsonconv.Schema(e.rt)
+	if err != nil {
+		return JSONSchema{}, err
+	}
+	out := JSONSchema{Document: doc}
+	for _, l := range losses {
+		out.Unrepresented = append(out.Unrepresented, UnrepresentedConstruct{
+			Component: l.Component,
+			Construct: l.Construct,
+			Detail:    l.Detail,
+		})
+	}
+	return out, nil
+}
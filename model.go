@@ -0,0 +1,625 @@
+package xsd
+
+import (
+	"encoding/xml"
+
+	"github.com/jacoelho/xsd/internal/introspect"
+)
+
+// SchemaModel is a read-only description of the components of a compiled
+// schema. Components refer to each other by pointer, so recursive schemas
+// produce cyclic graphs. Each Engine.Model call returns a new model that
+// shares nothing with the Engine or with other models.
+type SchemaModel struct {
+	// Elements are the top-level element declarations.
+	Elements []*ElementDeclaration
+	// Attributes are the top-level attribute declarations, including the
+	// built-in xml and xsi attributes.
+	Attributes []*AttributeDeclaration
+	// Types are the named type definitions, including the built-in types in
+	// the XML Schema namespace.
+	Types []*TypeDefinition
+	// AttributeGroups are the named attribute groups.
+	AttributeGroups []*AttributeGroup
+	// SubstitutionGroups are the top-level elements with substitution
+	// group members.
+	SubstitutionGroups []*SubstitutionGroup
+	// IdentityConstraints are the unique, key and keyref constraints of all
+	// element declarations.
+	IdentityConstraints []*IdentityConstraint
+	// Annotation holds the top-level annotations of every schema document.
+	Annotation Annotation
+}
+
+// Element returns the top-level element declaration named name, or nil.
+func (m *SchemaModel) Element(name xml.Name) *ElementDeclaration {
+	for _, elem := range m.Elements {
+		if elem.Name == name {
+			return elem
+		}
+	}
+	return nil
+}
+
+// Type returns the named type definition called name, or nil.
+func (m *SchemaModel) Type(name xml.Name) *TypeDefinition {
+	for _, typ := range m.Types {
+		if typ.Name == name {
+			return typ
+		}
+	}
+	return nil
+}
+
+// TypeKind distinguishes simple and complex type definitions.
+type TypeKind uint8
+
+const (
+	// TypeSimple is a simple type definition.
+	TypeSimple TypeKind = iota + 1
+	// TypeComplex is a complex type definition.
+	TypeComplex
+)
+
+// Derivation is the method by which a type definition derives from its base.
+type Derivation uint8
+
+const (
+	// DerivationNone is used only for xs:anyType, which has no base.
+	DerivationNone Derivation = iota
+	// DerivationRestriction is derivation by restriction. Every simple
+	// type, including list and union types, is a restriction of its base.
+	DerivationRestriction
+	// DerivationExtension is derivation by extension.
+	DerivationExtension
+)
+
+// Variety is the variety of a simple type definition.
+type Variety uint8
+
+const (
+	// VarietyAtomic is an atomic simple type.
+	VarietyAtomic Variety = iota
+	// VarietyList is a list simple type.
+	VarietyList
+	// VarietyUnion is a union simple type.
+	VarietyUnion
+)
+
+// ContentType is the content type variety of a complex type definition.
+type ContentType uint8
+
+const (
+	// ContentEmpty admits neither child elements nor character data.
+	ContentEmpty ContentType = iota
+	// ContentSimple admits character data of SimpleContentType.
+	ContentSimple
+	// ContentElementOnly admits child elements and whitespace.
+	ContentElementOnly
+	// ContentMixed admits child elements and character data.
+	ContentMixed
+)
+
+// TypeDefinition is a simple or complex type definition.
+type TypeDefinition struct {
+	// Name is empty for anonymous types.
+	Name xml.Name
+	// Base is the base type definition. It is nil only for xs:anyType.
+	Base *TypeDefinition
+	// Primitive is the primitive type of an atomic simple type. It is nil
+	// for other types and for xs:anySimpleType and xs:anyAtomicType.
+	Primitive *TypeDefinition
+	// ItemType is the item type of a list simple type.
+	ItemType *TypeDefinition
+	// SimpleContentType is the type of the character data of a complex type
+	// with simple content.
+	SimpleContentType *TypeDefinition
+	// Particle is the content model of a complex type with element-only or
+	// mixed content. It is nil for empty and simple content. Nested groups
+	// may be flattened where that does not change the content model.
+	Particle *Particle
+	// AttributeWildcard is the attribute wildcard of a complex type.
+	AttributeWildcard *Wildcard
+	// MemberTypes are the member types of a union simple type.
+	MemberTypes []*TypeDefinition
+	// Attributes are the attribute uses of a complex type, including
+	// inherited ones, sorted by name.
+	Attributes []*AttributeUse
+	// Facets are the facets in effect for a simple type, including those
+	// inherited from its base types.
+	Facets Facets
+	// Annotation includes the annotations of the type's content and
+	// derivation children, such as xs:restriction.
+	Annotation  Annotation
+	Kind        TypeKind
+	Derivation  Derivation
+	Variety     Variety
+	ContentType ContentType
+	Abstract    bool
+}
+
+// DerivationChain returns t followed by its base types up to xs:anyType.
+func (t *TypeDefinition) DerivationChain() []*TypeDefinition {
+	var chain []*TypeDefinition
+	for typ := t; typ != nil; typ = typ.Base {
+		chain = append(chain, typ)
+	}
+	return chain
+}
+
+// Facet identifies a constraining facet.
+type Facet uint16
+
+// Constraining facets.
+const (
+	FacetLength Facet = 1 << iota
+	FacetMinLength
+	FacetMaxLength
+	FacetTotalDigits
+	FacetFractionDigits
+	FacetMinInclusive
+	FacetMaxInclusive
+	FacetMinExclusive
+	FacetMaxExclusive
+	FacetEnumeration
+	FacetPattern
+	// FacetWhiteSpace appears only in Facets.Fixed; the whiteSpace facet
+	// of a simple type is always reported in Facets.WhiteSpace.
+	FacetWhiteSpace
+	FacetExplicitTimezone
+)
+
+// WhiteSpace is the value of the whiteSpace facet.
+type WhiteSpace uint8
+
+const (
+	// WhiteSpacePreserve keeps values unchanged.
+	WhiteSpacePreserve WhiteSpace = iota
+	// WhiteSpaceReplace replaces tabs, line feeds and carriage returns
+	// with spaces.
+	WhiteSpaceReplace
+	// WhiteSpaceCollapse replaces, then collapses and trims spaces.
+	WhiteSpaceCollapse
+)
+
+// ExplicitTimezone is the value of the XSD 1.1 explicitTimezone facet.
+type ExplicitTimezone uint8
+
+const (
+	// ExplicitTimezoneOptional admits values with or without a timezone.
+	ExplicitTimezoneOptional ExplicitTimezone = iota
+	// ExplicitTimezoneRequired admits only values with a timezone.
+	ExplicitTimezoneRequired
+	// ExplicitTimezoneProhibited admits only values without a timezone.
+	ExplicitTimezoneProhibited
+)
+
+// Facets are the constraining facets of a simple type. Values are the
+// lexical forms written in the schema. A field is meaningful only when
+// Present reports its facet.
+type Facets struct {
+	Enumeration []string
+	// Patterns holds one group per derivation step that added pattern
+	// facets, base type steps first. A value must match one pattern of
+	// every group.
+	Patterns         [][]string
+	MinInclusive     string
+	MaxInclusive     string
+	MinExclusive     string
+	MaxExclusive     string
+	Length           int
+	MinLength        int
+	MaxLength        int
+	TotalDigits      int
+	FractionDigits   int
+	Present          Facet
+	Fixed            Facet
+	WhiteSpace       WhiteSpace
+	ExplicitTimezone ExplicitTimezone
+}
+
+// Has reports whether facet constrains the type.
+func (f Facets) Has(facet Facet) bool {
+	return f.Present&facet != 0
+}
+
+// ParticleKind identifies the term of a particle.
+type ParticleKind uint8
+
+const (
+	// ParticleElement is an element declaration.
+	ParticleElement ParticleKind = iota + 1
+	// ParticleWildcard is an element wildcard.
+	ParticleWildcard
+	// ParticleSequence is an xs:sequence model group.
+	ParticleSequence
+	// ParticleChoice is an xs:choice model group.
+	ParticleChoice
+	// ParticleAll is an xs:all model group.
+	ParticleAll
+)
+
+// Unbounded is the Particle.MaxOccurs of maxOccurs="unbounded".
+const Unbounded = -1
+
+// Particle is a term of a content model with its occurrence bounds.
+type Particle struct {
+	// Element is the declaration of a ParticleElement.
+	Element *ElementDeclaration
+	// Wildcard is the wildcard of a ParticleWildcard.
+	Wildcard *Wildcard
+	// Particles are the children of a model group.
+	Particles []*Particle
+	MinOccurs int
+	// MaxOccurs is Unbounded for maxOccurs="unbounded".
+	MaxOccurs int
+	Kind      ParticleKind
+}
+
+// NamespaceConstraint is the variety of a wildcard namespace constraint.
+type NamespaceConstraint uint8
+
+const (
+	// NamespaceAny admits every namespace.
+	NamespaceAny NamespaceConstraint = iota
+	// NamespaceEnumeration admits only the namespaces in Namespaces.
+	NamespaceEnumeration
+	// NamespaceNot admits every namespace except those in Namespaces.
+	NamespaceNot
+)
+
+// ProcessContents is how items matched by a wildcard are validated.
+type ProcessContents uint8
+
+const (
+	// ProcessStrict requires a declaration and validates against it.
+	ProcessStrict ProcessContents = iota
+	// ProcessLax validates when a declaration is available.
+	ProcessLax
+	// ProcessSkip does not validate.
+	ProcessSkip
+)
+
+// Wildcard is an element or attribute wildcard.
+type Wildcard struct {
+	// Namespaces are the namespaces admitted or excluded; the empty string
+	// stands for no namespace.
+	Namespaces []string
+	// NotQNames are the names excluded by an XSD 1.1 notQName attribute.
+	NotQNames  []xml.Name
+	Constraint NamespaceConstraint
+	Process    ProcessContents
+	// NotDefined excludes names with a top-level declaration
+	// (notQName="##defined").
+	NotDefined bool
+	// NotDefinedSibling excludes names of element declarations in the same
+	// content model (notQName="##definedSibling").
+	NotDefinedSibling bool
+}
+
+// ElementDeclaration is an element declaration.
+type ElementDeclaration struct {
+	Name xml.Name
+	Type *TypeDefinition
+	// SubstitutionGroup is the head of the declaration's substitution group.
+	SubstitutionGroup   *ElementDeclaration
+	IdentityConstraints []*IdentityConstraint
+	Annotation          Annotation
+	Default             string
+	Fixed               string
+	// Global reports whether the declaration is top-level.
+	Global     bool
+	Nillable   bool
+	Abstract   bool
+	HasDefault bool
+	HasFixed   bool
+}
+
+// AttributeDeclaration is a top-level attribute declaration.
+type AttributeDeclaration struct {
+	Name       xml.Name
+	Type       *TypeDefinition
+	Annotation Annotation
+	Default    string
+	Fixed      string
+	HasDefault bool
+	HasFixed   bool
+}
+
+// AttributeUse is an attribute admitted by a complex type or an attribute
+// group.
+type AttributeUse struct {
+	Name xml.Name
+	Type *TypeDefinition
+	// Annotation is that of the local attribute declaration or attribute
+	// reference, which may come from an attribute group or a base type.
+	Annotation Annotation
+	Default    string
+	Fixed      string
+	Required   bool
+	HasDefault bool
+	HasFixed   bool
+}
+
+// AttributeGroup is a named attribute group with the attribute uses of its
+// nested attribute group references resolved.
+type AttributeGroup struct {
+	Name       xml.Name
+	Attributes []*AttributeUse
+	Wildcard   *Wildcard
+	Annotation Annotation
+}
+
+// SubstitutionGroup is a head element declaration with every declaration
+// that substitutes for it directly or transitively, including abstract and
+// blocked ones, sorted by name.
+type SubstitutionGroup struct {
+	Head    *ElementDeclaration
+	Members []*ElementDeclaration
+}
+
+// IdentityKind identifies the kind of an identity constraint.
+type IdentityKind uint8
+
+const (
+	// IdentityUnique is an xs:unique constraint.
+	IdentityUnique IdentityKind = iota
+	// IdentityKey is an xs:key constraint.
+	IdentityKey
+	// IdentityKeyRef is an xs:keyref constraint.
+	IdentityKeyRef
+)
+
+// IdentityConstraint is an identity constraint.
+type IdentityConstraint struct {
+	Name xml.Name
+	// Refer is the key or unique constraint an IdentityKeyRef refers to.
+	Refer *IdentityConstraint
+	// Selector and Fields are the XPath expressions as written.
+	Selector   string
+	Fields     []string
+	Annotation Annotation
+	Kind       IdentityKind
+}
+
+// Annotation is the xs:documentation and xs:appinfo content of a schema
+// component, in document order. It is empty unless the schema was compiled
+// with CompileOptions.RetainAnnotations.
+type Annotation struct {
+	Documentation []Documentation
+	AppInfo       []AppInfo
+}
+
+// Documentation is the content of one xs:documentation element.
+type Documentation struct {
+	// Source is the element's source attribute.
+	Source string
+	// Lang is the in-scope xml:lang value.
+	Lang string
+	// Text is the character data of the element and its descendants.
+	Text string
+	// Content is the element content serialized as XML, with the namespace
+	// declarations its markup needs.
+	Content string
+}
+
+// AppInfo is the content of one xs:appinfo element.
+type AppInfo struct {
+	// Source is the element's source attribute.
+	Source string
+	// Content is the element content serialized as XML, with the namespace
+	// declarations its markup needs.
+	Content string
+}
+
+// modelAdapter converts an internal component model, keeping the sharing
+// and cycles of its pointer graph.
+type modelAdapter struct {
+	types      map[*introspect.Type]*TypeDefinition
+	elements   map[*introspect.Element]*ElementDeclaration
+	identities map[*introspect.IdentityConstraint]*IdentityConstraint
+	wildcards  map[*introspect.Wildcard]*Wildcard
+}
+
+func adaptSchemaModel(in *introspect.Schema) *SchemaModel {
+	a := modelAdapter{
+		types:      make(map[*introspect.Type]*TypeDefinition),
+		elements:   make(map[*introspect.Element]*ElementDeclaration),
+		identities: make(map[*introspect.IdentityConstraint]*IdentityConstraint),
+		wildcards:  make(map[*introspect.Wildcard]*Wildcard),
+	}
+	out := &SchemaModel{Annotation: adaptAnnotation(in.Annotation)}
+	for _, elem := range in.Elements {
+		out.Elements = append(out.Elements, a.element(elem))
+	}
+	for _, attr := range in.Attributes {
+		out.Attributes = append(out.Attributes, &AttributeDeclaration{
+			Name:       attr.Name,
+			Type:       a.typ(attr.Type),
+			Default:    attr.Default,
+			Fixed:      attr.Fixed,
+			HasDefault: attr.HasDefault,
+			HasFixed:   attr.HasFixed,
+			Annotation: adaptAnnotation(attr.Annotation),
+		})
+	}
+	for _, typ := range in.Types {
+		out.Types = append(out.Types, a.typ(typ))
+	}
+	for _, group := range in.AttributeGroups {
+		out.AttributeGroups = append(out.AttributeGroups, &AttributeGroup{
+			Name:       group.Name,
+			Attributes: a.attributeUses(group.Attributes),
+			Wildcard:   a.wildcard(group.Wildcard),
+			Annotation: adaptAnnotation(group.Annotation),
+		})
+	}
+	for _, group := range in.SubstitutionGroups {
+		members := make([]*ElementDeclaration, len(group.Members))
+		for i, member := range group.Members {
+			members[i] = a.element(member)
+		}
+		out.SubstitutionGroups = append(out.SubstitutionGroups, &SubstitutionGroup{Head: a.element(group.Head), Members: members})
+	}
+	for _, ic := range in.IdentityConstraints {
+		out.IdentityConstraints = append(out.IdentityConstraints, a.identity(ic))
+	}
+	return out
+}
+
+func (a *modelAdapter) typ(in *introspect.Type) *TypeDefinition {
+	if in == nil {
+		return nil
+	}
+	if out, ok := a.types[in]; ok {
+		return out
+	}
+	out := &TypeDefinition{
+		Name:        in.Name,
+		Kind:        TypeKind(in.Kind),
+		Derivation:  Derivation(in.Derivation),
+		Variety:     Variety(in.Variety),
+		ContentType: ContentType(in.ContentType),
+		Abstract:    in.Abstract,
+		Annotation:  adaptAnnotation(in.Annotation),
+		Facets: Facets{
+			Enumeration:      in.Facets.Enumeration,
+			Patterns:         in.Facets.Patterns,
+			MinInclusive:     in.Facets.MinInclusive,
+			MaxInclusive:     in.Facets.MaxInclusive,
+			MinExclusive:     in.Facets.MinExclusive,
+			MaxExclusive:     in.Facets.MaxExclusive,
+			Length:           in.Facets.Length,
+			MinLength:        in.Facets.MinLength,
+			MaxLength:        in.Facets.MaxLength,
+			TotalDigits:      in.Facets.TotalDigits,
+			FractionDigits:   in.Facets.FractionDigits,
+			Present:          Facet(in.Facets.Present),
+			Fixed:            Facet(in.Facets.Fixed),
+			WhiteSpace:       WhiteSpace(in.Facets.WhiteSpace),
+			ExplicitTimezone: ExplicitTimezone(in.Facets.ExplicitTimezone),
+		},
+	}
+	a.types[in] = out
+	out.Base = a.typ(in.Base)
+	out.Primitive = a.typ(in.Primitive)
+	out.ItemType = a.typ(in.ItemType)
+	out.SimpleContentType = a.typ(in.SimpleContentType)
+	out.Particle = a.particle(in.Particle)
+	out.AttributeWildcard = a.wildcard(in.AttributeWildcard)
+	for _, member := range in.MemberTypes {
+		out.MemberTypes = append(out.MemberTypes, a.typ(member))
+	}
+	out.Attributes = a.attributeUses(in.Attributes)
+	return out
+}
+
+func (a *modelAdapter) particle(in *introspect.Particle) *Particle {
+	if in == nil {
+		return nil
+	}
+	out := &Particle{
+		Element:   a.element(in.Element),
+		Wildcard:  a.wildcard(in.Wildcard),
+		MinOccurs: in.MinOccurs,
+		MaxOccurs: in.MaxOccurs,
+		Kind:      ParticleKind(in.Kind),
+	}
+	for _, child := range in.Particles {
+		out.Particles = append(out.Particles, a.particle(child))
+	}
+	return out
+}
+
+func (a *modelAdapter) wildcard(in *introspect.Wildcard) *Wildcard {
+	if in == nil {
+		return nil
+	}
+	if out, ok := a.wildcards[in]; ok {
+		return out
+	}
+	out := &Wildcard{
+		Namespaces:        in.Namespaces,
+		NotQNames:         in.NotQNames,
+		Constraint:        NamespaceConstraint(in.Constraint),
+		Process:           ProcessContents(in.Process),
+		NotDefined:        in.NotDefined,
+		NotDefinedSibling: in.NotDefinedSibling,
+	}
+	a.wildcards[in] = out
+	return out
+}
+
+func (a *modelAdapter) element(in *introspect.Element) *ElementDeclaration {
+	if in == nil {
+		return nil
+	}
+	if out, ok := a.elements[in]; ok {
+		return out
+	}
+	out := &ElementDeclaration{
+		Name:       in.Name,
+		Default:    in.Default,
+		Fixed:      in.Fixed,
+		Global:     in.Global,
+		Nillable:   in.Nillable,
+		Abstract:   in.Abstract,
+		HasDefault: in.HasDefault,
+		HasFixed:   in.HasFixed,
+		Annotation: adaptAnnotation(in.Annotation),
+	}
+	a.elements[in] = out
+	out.Type = a.typ(in.Type)
+	out.SubstitutionGroup = a.element(in.SubstitutionGroup)
+	for _, ic := range in.IdentityConstraints {
+		out.IdentityConstraints = append(out.IdentityConstraints, a.identity(ic))
+	}
+	return out
+}
+
+func (a *modelAdapter) attributeUses(in []*introspect.AttributeUse) []*AttributeUse {
+	var out []*AttributeUse
+	for _, use := range in {
+		out = append(out, &AttributeUse{
+			Name:       use.Name,
+			Type:       a.typ(use.Type),
+			Default:    use.Default,
+			Fixed:      use.Fixed,
+			Required:   use.Required,
+			HasDefault: use.HasDefault,
+			HasFixed:   use.HasFixed,
+			Annotation: adaptAnnotation(use.Annotation),
+		})
+	}
+	return out
+}
+
+func (a *modelAdapter) identity(in *introspect.IdentityConstraint) *IdentityConstraint {
+	if in == nil {
+		return nil
+	}
+	if out, ok := a.identities[in]; ok {
+		return out
+	}
+	out := &IdentityConstraint{
+		Name:       in.Name,
+		Selector:   in.Selector,
+		Fields:     in.Fields,
+		Kind:       IdentityKind(in.Kind),
+		Annotation: adaptAnnotation(in.Annotation),
+	}
+	a.identities[in] = out
+	out.Refer = a.identity(in.Refer)
+	return out
+}
+
+func adaptAnnotation(in introspect.Annotation) Annotation {
+	var out Annotation
+	for _, doc := range in.Documentation {
+		out.Documentation = append(out.Documentation, Documentation(doc))
+	}
+	for _, info := range in.AppInfo {
+		out.AppInfo = append(out.AppInfo, AppInfo(info))
+	}
+	return out
+}
@@ -2,8 +2,10 @@ package xsd_test
 
 import (
 	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -18,8 +20,34 @@ func TestPublicTypesRemainRootDefined(t *testing.T) {
 		reflect.TypeFor[xsd.Session](),
 		reflect.TypeFor[xsd.CompileOptions](),
 		reflect.TypeFor[xsd.ValidateOptions](),
+		reflect.TypeFor[xsd.ErrorAction](),
 		reflect.TypeFor[xsd.SchemaSource](),
 		reflect.TypeFor[xsd.ResolverFunc](),
+		reflect.TypeFor[xsd.Charsets](),
+		reflect.TypeFor[xsd.CharsetFunc](),
+		reflect.TypeFor[xsd.Event](),
+		reflect.TypeFor[xsd.EventAttribute](),
+		reflect.TypeFor[xsd.EventHandler](),
+		reflect.TypeFor[xsd.SchemaModel](),
+		reflect.TypeFor[xsd.TypeDefinition](),
+		reflect.TypeFor[xsd.Facets](),
+		reflect.TypeFor[xsd.Particle](),
+		reflect.TypeFor[xsd.Wildcard](),
+		reflect.TypeFor[xsd.ElementDeclaration](),
+		reflect.TypeFor[xsd.AttributeDeclaration](),
+		reflect.TypeFor[xsd.AttributeUse](),
+		reflect.TypeFor[xsd.AttributeGroup](),
+		reflect.TypeFor[xsd.SubstitutionGroup](),
+		reflect.TypeFor[xsd.IdentityConstraint](),
+		reflect.TypeFor[xsd.Annotation](),
+		reflect.TypeFor[xsd.Documentation](),
+		reflect.TypeFor[xsd.AppInfo](),
+		reflect.TypeFor[xsd.SampleOptions](),
+		reflect.TypeFor[xsd.SampleChoice](),
+		reflect.TypeFor[xsd.SampleMutation](),
+		reflect.TypeFor[xsd.InvalidSample](),
+		reflect.TypeFor[xsd.JSONSchema](),
+		reflect.TypeFor[xsd.UnrepresentedConstruct](),
 	}
 	for _, typ := range tests {
 		if got := typ.PkgPath(); got != "github.com/jacoelho/xsd" {
@@ -55,6 +83,35 @@ func TestZeroAndNilValidationReceiversReturnPublicErrors(t *testing.T) {
 	err = nilSession.Validate(context.Background(), strings.NewReader(`<root/>`))
 	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
 	assertPublicErrorTree(t, err)
+
+	_, err = zero.Model()
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = nilEngine.Model()
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = zero.MarshalBinary()
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = nilEngine.MarshalBinary()
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = zero.Sample(context.Background(), xml.Name{Local: "root"}, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = nilEngine.Sample(context.Background(), xml.Name{Local: "root"}, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = zero.SampleInvalid(context.Background(), xml.Name{Local: "root"}, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = nilEngine.SampleInvalid(context.Background(), xml.Name{Local: "root"}, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	err = zero.XMLToJSON(context.Background(), io.Discard, strings.NewReader(`<root/>`))
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	err = nilEngine.XMLToJSON(context.Background(), io.Discard, strings.NewReader(`<root/>`))
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	err = zero.JSONToXML(context.Background(), io.Discard, strings.NewReader(`{"root":""}`))
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	err = nilEngine.JSONToXML(context.Background(), io.Discard, strings.NewReader(`{"root":""}`))
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = zero.JSONSchema()
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
+	_, err = nilEngine.JSONSchema()
+	expectCategoryCode(t, err, xsderrors.CategoryInternal, xsderrors.CodeInternalInvariant)
 }
 
 func TestPublicAggregateErrorsDoNotExposeInternalDiagnostics(t *testing.T) {
@@ -0,0 +1,167 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+
+	"github.com/jacoelho/xsd/internal/sample"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// SampleChoice selects the branch Engine.Sample generates for each
+// xs:choice.
+type SampleChoice uint8
+
+const (
+	// SampleChoiceShortest takes the branch that needs the fewest elements.
+	SampleChoiceShortest SampleChoice = iota
+	// SampleChoiceFirst takes the first branch that can be completed.
+	SampleChoiceFirst
+	// SampleChoiceRandom takes branches, substitution group members, xsi:type
+	// types and pattern text drawn from a generator seeded by Seed.
+	SampleChoiceRandom
+)
+
+// SampleOptions configures Engine.Sample. The zero value generates a minimal
+// instance: required particles and attributes only, each at its minOccurs,
+// and the shortest branch of every choice.
+type SampleOptions struct {
+	// Seed seeds the generator used by SampleChoiceRandom. Equal seeds
+	// generate equal instances.
+	Seed uint64
+	// Repeat is the number of occurrences generated for particles that may
+	// repeat, clamped to their maxOccurs. Values below minOccurs are ignored.
+	Repeat int
+	// Choice selects the branch generated for each xs:choice.
+	Choice SampleChoice
+	// Optional includes every optional particle and attribute once.
+	// Recursive content is only included where the schema requires it.
+	Optional bool
+}
+
+// Sample generates an XML instance document rooted at the global element
+// declaration named root. It walks the compiled content models and uses the
+// simple-type facets to produce values, and validates the result with e
+// before returning it.
+//
+// Constructs the generator cannot satisfy, such as assertions it does not
+// anticipate, strict wildcards with no matching declaration or facets no
+// generated value meets, are reported with xsderrors.CodeUnsupportedSample
+// wrapping the validation error. An undeclared root is reported with
+// xsderrors.CodeValidationRoot, and generation stops with
+// xsderrors.CodeValidationCanceled once ctx is done.
+func (e *Engine) Sample(ctx context.Context, root xml.Name, opts SampleOptions) ([]byte, error) {
+	if e == nil || e.rt == nil {
+		return nil, xsderrors.InternalInvariant("sample generation requires a compiled engine")
+	}
+	doc, err := sample.Generate(ctx, e.rt, root, sampleOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.validateSample(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// SampleMutation names the kind of constraint Engine.SampleInvalid violates.
+type SampleMutation uint8
+
+const (
+	// SampleMutationFacet replaces a generated element or attribute value with
+	// one its base type accepts and its own facets reject. Validation reports
+	// xsderrors.CodeValidationFacet.
+	SampleMutationFacet SampleMutation = iota + 1
+	// SampleMutationOccurrence repeats an element past its maxOccurs, which
+	// validation reports with xsderrors.CodeValidationElement, or removes one
+	// its particle requires, reported with xsderrors.CodeValidationContent.
+	// Only particles that alone match the element's name and are not inside
+	// a repeating group are mutated.
+	SampleMutationOccurrence
+	// SampleMutationIdentity copies the field values of an element selected
+	// by a key or unique constraint to another selected element, so both
+	// share a key. Validation reports xsderrors.CodeValidationIdentity.
+	SampleMutationIdentity
+	// SampleMutationNil sets xsi:nil on an element that is not nillable or
+	// has content. Validation reports xsderrors.CodeValidationNil.
+	SampleMutationNil
+)
+
+// InvalidSample is a generated instance document changed to violate one
+// constraint.
+type InvalidSample struct {
+	// Document is the mutated instance.
+	Document []byte
+	// Valid is the instance before the mutation, which validates.
+	Valid []byte
+	// Path is the path of the changed element, spelled as validation error
+	// paths are. A removed element is reported by its parent's path.
+	Path string
+	// Code is the error code validating Document is expected to report.
+	Code xsderrors.Code
+	// Mutation is the kind of mutation applied.
+	Mutation SampleMutation
+}
+
+// SampleInvalid generates an instance as Sample does and applies one
+// mutation of the listed kinds, or of any kind when none are listed. The
+// mutated site is drawn from a generator seeded by opts.Seed, so equal
+// options produce equal documents for any Choice.
+//
+// Mutations are built from the compiled schema alone, without validating
+// the result, so the expected Code is an independent oracle for validation.
+// A mutation may break further constraints as a consequence: a repeated
+// element can also repeat an ID, and a removed or changed value can leave a
+// keyref unresolved. Validating Document must report an error with Code
+// among them. Schemas whose instance admits none of the requested mutations are
+// reported with xsderrors.CodeUnsupportedSample.
+func (e *Engine) SampleInvalid(ctx context.Context, root xml.Name, opts SampleOptions, mutations ...SampleMutation) (InvalidSample, error) {
+	if e == nil || e.rt == nil {
+		return InvalidSample{}, xsderrors.InternalInvariant("sample generation requires a compiled engine")
+	}
+	kinds := make([]sample.Mutation, 0, len(mutations))
+	for _, m := range mutations {
+		kinds = append(kinds, sample.Mutation(m))
+	}
+	if len(kinds) == 0 {
+		kinds = []sample.Mutation{sample.MutationFacet, sample.MutationOccurrence, sample.MutationIdentity, sample.MutationNil}
+	}
+	mutant, err := sample.Mutate(ctx, e.rt, root, sampleOptions(opts), kinds)
+	if err != nil {
+		return InvalidSample{}, err
+	}
+	if err := e.validateSample(ctx, mutant.Original); err != nil {
+		return InvalidSample{}, err
+	}
+	return InvalidSample{
+		Document: mutant.Document,
+		Valid:    mutant.Original,
+		Path:     mutant.Path,
+		Code:     mutant.Code,
+		Mutation: SampleMutation(mutant.Mutation),
+	}, nil
+}
+
+func sampleOptions(opts SampleOptions) sample.Options {
+	return sample.Options{
+		Seed:     opts.Seed,
+		Repeat:   opts.Repeat,
+		Choice:   sample.ChoicePolicy(opts.Choice),
+		Optional: opts.Optional,
+	}
+}
+
+// validateSample checks a generated instance with e, reporting instances
+// that fail as unsupported by the generator.
+func (e *Engine) validateSample(ctx context.Context, doc []byte) error {
+	err := e.Validate(ctx, bytes.NewReader(doc))
+	if err == nil {
+		return nil
+	}
+	if xerr, ok := errors.AsType[*xsderrors.Error](err); ok && xerr.Category == xsderrors.CategoryCanceled {
+		return err
+	}
+	return xsderrors.UnsupportedAt(xsderrors.CodeUnsupportedSample, 0, 0, "", "generated instance does not validate", err)
+}
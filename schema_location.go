@@ -0,0 +1,143 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jacoelho/xsd/internal/compile"
+	"github.com/jacoelho/xsd/internal/validate"
+)
+
+const (
+	// schemaLocationHintsName names the synthetic schema document that imports
+	// hinted locations. The resolver sees an empty base for its references.
+	schemaLocationHintsName = "xsi-schema-location-hints.xsd"
+	// schemaLocationHintsNamespace is the synthetic document's target
+	// namespace, so it can import both named and absent namespaces.
+	schemaLocationHintsNamespace  = "urn:jacoelho:xsd:schema-location-hints"
+	maxSchemaLocationCacheEntries = 64
+)
+
+// schemaLocationCache keeps engines compiled for distinct resolvers and hint
+// sets. It is bounded and cleared when full.
+type schemaLocationCache struct {
+	entries map[schemaLocationKey]*Engine
+	mu      sync.Mutex
+}
+
+// schemaLocationKey identifies the engine compiled for hints by resolver. A
+// nil resolver stands for the one resolver of a cache owned by a validation
+// session.
+type schemaLocationKey struct {
+	resolver any
+	hints    string
+}
+
+// schemaLocationCacheFor returns the cache of engines compiled for hints
+// resolved by r and the identity r has in it. Resolvers that cannot be
+// compared, such as a ResolverFunc, are cached only for the validation
+// session or call they are given to.
+func (e *Engine) schemaLocationCacheFor(r Resolver) (*schemaLocationCache, any) {
+	if reflect.ValueOf(r).Comparable() {
+		return e.schemaLocations, r
+	}
+	return new(schemaLocationCache), nil
+}
+
+func (c *schemaLocationCache) load(key schemaLocationKey) (*Engine, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	engine, ok := c.entries[key]
+	return engine, ok
+}
+
+func (c *schemaLocationCache) store(key schemaLocationKey, engine *Engine) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil || len(c.entries) >= maxSchemaLocationCacheEntries {
+		c.entries = make(map[schemaLocationKey]*Engine)
+	}
+	c.entries[key] = engine
+}
+
+// schemaLocationEngine compiles the engine sources together with hinted
+// locations for namespaces the engine schema does not already declare, and
+// caches the result in cache under resolver. The engine's documents are
+// served from the bytes read by Compile when they were retained. It returns
+// nil when every hinted namespace is already declared.
+func (e *Engine) schemaLocationEngine(
+	ctx context.Context,
+	cache *schemaLocationCache,
+	resolver any,
+	r Resolver,
+	hints []validate.SchemaLocationHint,
+) (*Engine, error) {
+	pending := make([]validate.SchemaLocationHint, 0, len(hints))
+	for _, hint := range hints {
+		if e.rt.DeclaresNamespace(hint.Namespace) || slices.Contains(pending, hint) {
+			continue
+		}
+		pending = append(pending, hint)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	var hintKey strings.Builder
+	for _, hint := range pending {
+		hintKey.WriteString(hint.Namespace)
+		hintKey.WriteByte(0)
+		hintKey.WriteString(hint.Location)
+		hintKey.WriteByte(0)
+	}
+	key := schemaLocationKey{resolver: resolver, hints: hintKey.String()}
+	if engine, ok := cache.load(key); ok {
+		return engine, nil
+	}
+	hintSource := Bytes(schemaLocationHintsName, schemaLocationHintsDocument(pending)).WithResolver(ResolverFunc(
+		func(ctx context.Context, base, location string) (SchemaSource, error) {
+			if base == schemaLocationHintsName {
+				base = ""
+			}
+			return r.ResolveSchema(ctx, base, location)
+		},
+	))
+	opts, err := internalCompileOptions(e.opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.Documents = maps.Clone(e.documents)
+	sources := append(slices.Clone(e.sources), hintSource)
+	rt, err := compile.CompileMappedSources(ctx, opts, sources, internalSchemaSource)
+	if err != nil {
+		return nil, err
+	}
+	engine := &Engine{rt: rt}
+	cache.store(key, engine)
+	return engine, nil
+}
+
+func schemaLocationHintsDocument(hints []validate.SchemaLocationHint) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="`)
+	buf.WriteString(schemaLocationHintsNamespace)
+	buf.WriteString(`">`)
+	for _, hint := range hints {
+		buf.WriteString(`<xs:import`)
+		if hint.Namespace != "" {
+			buf.WriteString(` namespace="`)
+			_ = xml.EscapeText(&buf, []byte(hint.Namespace))
+			buf.WriteByte('"')
+		}
+		buf.WriteString(` schemaLocation="`)
+		_ = xml.EscapeText(&buf, []byte(hint.Location))
+		buf.WriteString(`"/>`)
+	}
+	buf.WriteString(`</xs:schema>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,444 @@
+// Package schematables holds the compiled schema tables of an xsd.Engine as
+// plain Go values, so cmd/xsdgen can write them as Go composite literals and
+// xsd.LoadEngineTables can publish them without parsing or compiling schema
+// documents.
+//
+// The tables mirror the engine's internal representation: IDs index the table
+// slices, kinds, masks and modes hold internal enumeration values, and
+// components refer to the shared Literals, Enumerations, PatternSteps and
+// Exprs by one-based position, with zero meaning none. They are meaningful
+// only to the module version that wrote them, which Version records; build
+// them with xsd.Engine.Tables rather than by hand.
+package schematables
+
+// Schema is the set of tables of one compiled schema. A nil entry slice keeps
+// its table absent where the engine distinguishes absent from empty, such as
+// annotations that were not retained.
+type Schema struct {
+	// Version is the layout version of the tables.
+	Version    uint32
+	Namespaces []string
+	Locals     []string
+	MaxNames   int
+	Builtin    Builtin
+	// Literals, Enumerations, PatternSteps and Exprs hold the values that
+	// components share.
+	Literals          []Literal
+	Enumerations      [][]Literal
+	PatternSteps      []PatternStep
+	Exprs             []Expr
+	SimpleTypes       []SimpleType
+	ComplexTypes      []ComplexType
+	Elements          []Element
+	Attributes        []Attribute
+	AttributeUseSets  []AttributeUseSet
+	Models            []ContentModel
+	CompiledModels    []CompiledModel
+	Wildcards         []Wildcard
+	Identities        []IdentityConstraint
+	SubstitutionSpans []SubstitutionSpan
+	Substitutions     []Substitution
+	GlobalAttributes  []NameID
+	GlobalElements    []NameID
+	GlobalTypes       []NameType
+	GlobalIdentities  []NameID
+	AttributeGroups   []NameID
+	Notations         []Notation
+	Annotations       []Annotation
+	Locations         []Location
+}
+
+// QName is a name as namespace and local name table positions.
+type QName struct {
+	Namespace uint32
+	Local     uint32
+}
+
+// TypeRef refers to a simple or complex type definition.
+type TypeRef struct {
+	Kind uint8
+	ID   uint32
+}
+
+// NameID maps a name to a table position.
+type NameID struct {
+	Name QName
+	ID   uint32
+}
+
+// NameType maps a name to a type definition.
+type NameType struct {
+	Name QName
+	Type TypeRef
+}
+
+// Notation records a declared notation name.
+type Notation struct {
+	Name  QName
+	Value bool
+}
+
+// Builtin holds the simple and complex type IDs of the built-in types the
+// validator refers to directly.
+type Builtin struct {
+	AnyType       uint32
+	AnySimpleType uint32
+	AnyAtomicType uint32
+	String        uint32
+	Boolean       uint32
+	Decimal       uint32
+	Integer       uint32
+	Int           uint32
+	Date          uint32
+	DateTime      uint32
+	Time          uint32
+	AnyURI        uint32
+	QName         uint32
+	ID            uint32
+	IDREF         uint32
+	IDREFS        uint32
+	NMTOKEN       uint32
+	NMTOKENS      uint32
+	ENTITY        uint32
+	ENTITIES      uint32
+	Error         uint32
+}
+
+// Literal is a facet or enumeration value compiled against its simple type.
+type Literal struct {
+	Lexical       string
+	Canonical     string
+	ResolvedNames []ResolvedName
+	Type          uint32
+}
+
+// ResolvedName is a QName or NOTATION value with its prefix resolved.
+type ResolvedName struct {
+	Lexical   string
+	Namespace string
+	Local     string
+}
+
+// PatternStep is one step of the pattern facets a simple type inherits: the
+// patterns declared by one derivation, and the step of its base type.
+type PatternStep struct {
+	Parent   uint32
+	Count    uint32
+	Patterns []string
+}
+
+// Expr is an XPath expression with the static context it was compiled in.
+type Expr struct {
+	Source                  string
+	DefaultElementNamespace string
+	Namespaces              []NamespaceBinding
+}
+
+// NamespaceBinding binds a prefix to a namespace name.
+type NamespaceBinding struct {
+	Prefix string
+	URI    string
+}
+
+// SimpleType is a simple type definition.
+type SimpleType struct {
+	Union        []uint32
+	UnionSources []uint32
+	Facets       Facets
+	Name         QName
+	Base         uint32
+	ListItem     uint32
+	Variety      uint8
+	Primitive    uint8
+	Final        uint8
+	Whitespace   uint8
+	Builtin      uint8
+	Identity     uint8
+	Fast         uint8
+	// Assertions refer to Exprs.
+	Assertions []uint32
+	Missing    bool
+	Scope      uint8
+}
+
+// Facets is the effective facet set of a simple type. Bounds refer to
+// Literals, Patterns to PatternSteps and Enumeration to Enumerations.
+type Facets struct {
+	Bounds           [4]uint32
+	Patterns         uint32
+	Enumeration      uint32
+	Length           uint32
+	MinLength        uint32
+	MaxLength        uint32
+	TotalDigits      uint32
+	FractionDigits   uint32
+	Present          uint16
+	Fixed            uint16
+	ExplicitTimezone uint8
+}
+
+// ValueConstraint is a default or fixed value.
+type ValueConstraint struct {
+	ResolvedNames []ResolvedName
+	Lexical       string
+	Canonical     string
+	Value         SimpleValue
+}
+
+// SimpleValue is the validated value of a value constraint.
+type SimpleValue struct {
+	Canonical string
+	IDs       string
+	IDRefs    string
+	Identity  string
+	Type      uint32
+}
+
+// ComplexType is a complex type definition.
+type ComplexType struct {
+	Name               QName
+	Base               TypeRef
+	Content            uint32
+	Attrs              uint32
+	TextType           uint32
+	ContentKind        uint8
+	Abstract           bool
+	Derivation         uint8
+	ExplicitDerivation bool
+	Block              uint8
+	Final              uint8
+	Scope              uint8
+	// Assertions refer to Exprs.
+	Assertions []uint32
+}
+
+// Element is an element declaration.
+type Element struct {
+	Default      *ValueConstraint
+	Fixed        *ValueConstraint
+	Identity     []uint32
+	Type         TypeRef
+	Name         QName
+	SubstHead    uint32
+	Nillable     bool
+	Abstract     bool
+	Block        uint8
+	Final        uint8
+	Scope        uint8
+	Alternatives []TypeAlternative
+}
+
+// TypeAlternative selects Type when the Expr that Test refers to is true.
+type TypeAlternative struct {
+	Test uint32
+	Type TypeRef
+}
+
+// Attribute is an attribute declaration.
+type Attribute struct {
+	Default *ValueConstraint
+	Fixed   *ValueConstraint
+	Name    QName
+	Type    uint32
+}
+
+// AttributeUseSet is the attribute uses and wildcard of a complex type or
+// attribute group.
+type AttributeUseSet struct {
+	Index            []NameID
+	Uses             []AttributeUse
+	Required         []uint32
+	ValueConstraints []uint32
+	Wildcard         uint32
+	WildcardBase     uint32
+	WildcardDeclared uint32
+	WildcardDerive   uint8
+}
+
+// AttributeUse is one attribute use of an AttributeUseSet.
+type AttributeUse struct {
+	Default              *ValueConstraint
+	Fixed                *ValueConstraint
+	Name                 QName
+	Type                 uint32
+	Required             bool
+	Prohibited           bool
+	FixedFromDeclaration bool
+}
+
+// Occurrence is a minOccurs and maxOccurs pair.
+type Occurrence struct {
+	Min       uint32
+	Max       uint32
+	Unbounded bool
+}
+
+// Particle is an element, model group or wildcard particle.
+type Particle struct {
+	Kind     uint8
+	Occurs   Occurrence
+	Element  uint32
+	Model    uint32
+	Wildcard uint32
+}
+
+// OpenContent is the open content wildcard of a content model.
+type OpenContent struct {
+	Wildcard uint32
+	Mode     uint8
+}
+
+// ContentModel is a model group as declared.
+type ContentModel struct {
+	Particles    []Particle
+	ChoiceLimits []uint32
+	Occurs       Occurrence
+	Kind         uint8
+	Mixed        bool
+	Open         OpenContent
+}
+
+// CompiledModel is the automaton or all-group table validation runs.
+type CompiledModel struct {
+	Rows      []CompiledModelRow
+	All       []AllTerm
+	Source    uint32
+	Start     uint32
+	AllBitLen uint32
+	Kind      uint8
+	Mixed     bool
+	Empty     bool
+	Open      OpenContent
+}
+
+// CompiledModelRow is one automaton state.
+type CompiledModelRow struct {
+	Edges         []Edge
+	NameToEdge    []NameID
+	WildcardEdges []uint32
+	Indexed       bool
+	CountParticle Particle
+	Min           uint32
+	Max           uint32
+	Accept        bool
+	Counted       bool
+	Unbounded     bool
+}
+
+// Edge is an automaton transition.
+type Edge struct {
+	Particle Particle
+	To       uint32
+}
+
+// AllTerm is one particle of an all group.
+type AllTerm struct {
+	Particle Particle
+	Required bool
+}
+
+// Wildcard is an element or attribute wildcard.
+type Wildcard struct {
+	Namespaces        []uint32
+	NotQNames         []QName
+	OtherThan         uint32
+	Mode              uint8
+	Process           uint8
+	NotDefined        bool
+	NotDefinedSibling bool
+}
+
+// IdentityConstraint is a key, keyref or unique constraint.
+type IdentityConstraint struct {
+	Selector      []IdentityPath
+	Fields        []IdentityField
+	SelectorXPath string
+	Name          QName
+	Refer         uint32
+	Kind          uint8
+}
+
+// IdentityPath is one branch of a selector.
+type IdentityPath struct {
+	Steps      []IdentityStep
+	Descendant bool
+	Self       bool
+}
+
+// IdentityStep is one child step of a selector or field path.
+type IdentityStep struct {
+	Name         QName
+	Wildcard     bool
+	NamespaceSet bool
+	Namespace    uint32
+}
+
+// IdentityField is one field of an identity constraint.
+type IdentityField struct {
+	Paths []IdentityFieldPath
+	XPath string
+}
+
+// IdentityFieldPath is one branch of a field.
+type IdentityFieldPath struct {
+	Steps            []IdentityStep
+	Attribute        QName
+	AttrNamespace    uint32
+	Descendant       bool
+	Self             bool
+	Attr             bool
+	AttrWildcard     bool
+	AttrNamespaceSet bool
+}
+
+// SubstitutionSpan is the range of Substitutions that belong to one element.
+type SubstitutionSpan struct {
+	Start int
+	Count int
+}
+
+// Substitution is one member of a substitution group.
+type Substitution struct {
+	Name      QName
+	Member    uint32
+	Effective bool
+}
+
+// AnnotationKey identifies the component an annotation or source location
+// belongs to.
+type AnnotationKey struct {
+	Name QName
+	ID   uint32
+	Kind uint8
+}
+
+// Annotation is the retained annotation content of one component.
+type Annotation struct {
+	Key           AnnotationKey
+	Documentation []Documentation
+	AppInfo       []AppInfo
+}
+
+// Documentation is one retained xs:documentation element.
+type Documentation struct {
+	Source  string
+	Lang    string
+	Text    string
+	Content string
+}
+
+// AppInfo is one retained xs:appinfo element.
+type AppInfo struct {
+	Source  string
+	Content string
+}
+
+// Location is the source location of one component or facet.
+type Location struct {
+	Key       AnnotationKey
+	Facet     uint16
+	Source    string
+	Component string
+	Line      int
+	Column    int
+}
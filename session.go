@@ -6,11 +6,27 @@ import (
 
 	"github.com/jacoelho/xsd/internal/runtime"
 	"github.com/jacoelho/xsd/internal/validate"
+	"github.com/jacoelho/xsd/xsderrors"
+)
+
+// ErrorAction tells validation what to do with an error passed to
+// ValidateOptions.OnError.
+type ErrorAction uint8
+
+const (
+	// ErrorContinue keeps the error and continues validation.
+	ErrorContinue ErrorAction = iota
+	// ErrorStop keeps the error and stops validation.
+	ErrorStop
+	// ErrorSuppress drops the error and continues validation. Suppressed
+	// errors are not returned.
+	ErrorSuppress
 )
 
 // ValidateOptions controls instance validation.
 type ValidateOptions struct {
 	// MaxErrors limits collected validation errors. Zero uses the default.
+	// It does not apply when OnError is set.
 	MaxErrors int
 	// MaxIdentityScopes limits active identity-constraint scopes. Zero uses the default.
 	MaxIdentityScopes int
@@ -29,7 +45,9 @@ type ValidateOptions struct {
 	MaxSchemaLocationNamespaceBytes int64
 	// MaxInstanceDepth limits nested XML elements. Zero uses the default.
 	MaxInstanceDepth int
-	// MaxInstanceAttributes limits attributes on one XML element. Zero uses the default.
+	// MaxInstanceAttributes limits attributes on one XML element, and the
+	// distinct child names IndexedPaths counts for one element. Zero uses the
+	// default.
 	MaxInstanceAttributes int
 	// MaxInstanceTextBytes limits retained character data bytes. Zero uses the default.
 	MaxInstanceTextBytes int64
@@ -38,6 +56,51 @@ type ValidateOptions struct {
 	MaxInstanceTokenBytes int64
 	// MaxInstanceBytes limits aggregate raw XML bytes read. Zero uses the default.
 	MaxInstanceBytes int64
+	// SchemaLocationResolver, when set, loads xsi:schemaLocation and
+	// xsi:noNamespaceSchemaLocation hints found on the document element.
+	// Hinted namespaces the Engine already declares are ignored. The rest
+	// are compiled together with the Engine's sources under its compile
+	// options, from the bytes Compile read when RetainSchemaDocuments is set
+	// and otherwise by reading File and Open sources again. The
+	// result is cached by hint list: on the Engine for resolvers that can
+	// be compared, so such a resolver must map a location to the same
+	// document for the Engine's lifetime, and otherwise per Session or per
+	// validation call. The resolver receives an empty base for hint
+	// locations and is the only way hinted documents are fetched. Hints on
+	// descendant elements are not loaded.
+	SchemaLocationResolver Resolver
+	// Charsets decodes instance documents whose XML declaration names an
+	// encoding beyond the built-in UTF-8, UTF-16, ISO-8859-1, windows-1252
+	// and US-ASCII support. Error lines and columns refer to the bytes of the
+	// original document.
+	Charsets Charsets
+	// OnError, when set, receives each validation error that validation can
+	// continue past as it is found, instead of collecting errors up to
+	// MaxErrors, and its result decides whether validation continues. It is
+	// never called with nil. The validation functions then return only the
+	// first error that OnError did not suppress; later errors are not
+	// retained, so a handler that needs them all must record them. Errors
+	// that end validation, such as malformed XML or an exceeded limit, are
+	// returned without being passed to OnError.
+	OnError func(*xsderrors.Error) ErrorAction
+	// XML11 accepts instance documents that declare XML version 1.1: NEL and
+	// LS end lines, C0 controls other than NUL may appear as character
+	// references, and literal RestrictedChars are rejected. XML 1.1 Name and
+	// NCName productions apply to names and to Name-based datatypes. Without
+	// it such documents fail with CodeUnsupportedXML11.
+	XML11 bool
+	// IndexedPaths writes in error paths the position of each element below
+	// the document element among its siblings of the same expanded name, as
+	// in /order/line[37]/qty[1]. Positions are counted while streaming; only
+	// the siblings of currently open elements are remembered, and at most
+	// MaxInstanceAttributes distinct names among the children of one
+	// element. Children with further names get steps without a position.
+	IndexedPaths bool
+	// ClarkPaths writes each error path step whose element is in a namespace
+	// as {namespace}local, independent of the prefix the document used.
+	// Without it the namespace is written only for elements the schema does
+	// not declare.
+	ClarkPaths bool
 }
 
 // Session validates XML instance documents against one Engine.
@@ -62,7 +125,35 @@ func (e *Engine) ValidateWithOptions(ctx context.Context, r io.Reader, opts Vali
 	if e != nil {
 		rt = e.rt
 	}
-	return validate.Validate(ctx, rt, r, internalValidateOptions(opts))
+	internal, err := e.internalValidateOptions(opts)
+	if err != nil {
+		return err
+	}
+	return validate.Validate(ctx, rt, r, internal)
+}
+
+// ValidateEvents validates one XML instance document and reports
+// post-schema-validation infoset events to handler while it streams. The
+// returned error is the same as Validate's. Once MaxErrors validation errors
+// are collected, only well-formedness is checked and no further events are
+// reported.
+func (e *Engine) ValidateEvents(ctx context.Context, r io.Reader, handler EventHandler) error {
+	return e.ValidateEventsWithOptions(ctx, r, ValidateOptions{}, handler)
+}
+
+// ValidateEventsWithOptions is ValidateEvents with options. ctx must be
+// non-nil.
+func (e *Engine) ValidateEventsWithOptions(ctx context.Context, r io.Reader, opts ValidateOptions, handler EventHandler) error {
+	var rt *runtime.Schema
+	if e != nil {
+		rt = e.rt
+	}
+	internal, err := e.internalValidateOptions(opts)
+	if err != nil {
+		return err
+	}
+	internal.Events = adaptEventHandler(handler)
+	return validate.Validate(ctx, rt, r, internal)
 }
 
 // NewSession creates a reusable validation session. Reused sessions retain
@@ -73,7 +164,11 @@ func (e *Engine) NewSession(opts ValidateOptions) (*Session, error) {
 	if e != nil {
 		rt = e.rt
 	}
-	inner, err := validate.NewSession(rt, internalValidateOptions(opts))
+	internal, err := e.internalValidateOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := validate.NewSession(rt, internal)
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +185,29 @@ func (s *Session) Validate(ctx context.Context, r io.Reader) error {
 	return s.session.Validate(ctx, r)
 }
 
-func internalValidateOptions(opts ValidateOptions) validate.Options {
+func (e *Engine) internalValidateOptions(opts ValidateOptions) (validate.Options, error) {
+	charsets, err := adaptPublicCharsets(opts.Charsets, validateOptionError)
+	if err != nil {
+		return validate.Options{}, err
+	}
+	var schemaLocations validate.SchemaLocationLoader
+	if r := opts.SchemaLocationResolver; r != nil && e != nil && e.rt != nil && e.schemaLocations != nil {
+		cache, resolver := e.schemaLocationCacheFor(r)
+		schemaLocations = func(ctx context.Context, hints []validate.SchemaLocationHint) (*runtime.Schema, error) {
+			hinted, err := e.schemaLocationEngine(ctx, cache, resolver, r, hints)
+			if hinted == nil {
+				return nil, err
+			}
+			return hinted.rt, nil
+		}
+	}
 	return validate.Options{
+		SchemaLocations:                 schemaLocations,
+		Charsets:                        charsets,
+		XML11:                           opts.XML11,
+		OnError:                         adaptErrorHandler(opts.OnError),
+		IndexedPaths:                    opts.IndexedPaths,
+		ClarkPaths:                      opts.ClarkPaths,
 		MaxErrors:                       opts.MaxErrors,
 		MaxIdentityScopes:               opts.MaxIdentityScopes,
 		MaxIdentityEntries:              opts.MaxIdentityEntries,
@@ -103,5 +219,18 @@ func internalValidateOptions(opts ValidateOptions) validate.Options {
 		MaxInstanceTextBytes:            opts.MaxInstanceTextBytes,
 		MaxInstanceTokenBytes:           opts.MaxInstanceTokenBytes,
 		MaxInstanceBytes:                opts.MaxInstanceBytes,
+	}, nil
+}
+
+func validateOptionError(msg string) error {
+	return xsderrors.Validation(xsderrors.CodeValidationOption, 0, 0, "", msg)
+}
+
+func adaptErrorHandler(onError func(*xsderrors.Error) ErrorAction) validate.ErrorHandler {
+	if onError == nil {
+		return nil
+	}
+	return func(err *xsderrors.Error) validate.ErrorAction {
+		return validate.ErrorAction(onError(err))
 	}
 }
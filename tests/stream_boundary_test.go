@@ -846,13 +846,16 @@ var allowedTokenDataCalls = map[streamBoundaryCall]bool{
 
 var allowedStartAttrCalls = map[streamBoundaryCall]bool{
 	{name: "len", builtin: true}: true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/xmlns", receiver: "Stack", name: "PushStream"}:                     true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/xmlns", name: "ValidateUniqueAttributes"}:                          true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "assessElementStart"}:        true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "recordSchemaLocationHints"}: true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "validateStartAttributes"}:   true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/validate", name: "RootStart"}:                                      true,
-	{pkgPath: "github.com/jacoelho/xsd/internal/validate", name: "xsiStartAttributeFlagsFor"}:                      true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/xmlns", receiver: "Stack", name: "PushStream"}:                       true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/xmlns", name: "ValidateUniqueAttributes"}:                            true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "assessElementStart"}:          true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "emitStartEvent"}:              true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "loadDocumentSchemaLocations"}: true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "recordSchemaLocationHints"}:   true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "startAssertionNode"}:          true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", receiver: "session", name: "validateStartAttributes"}:     true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", name: "RootStart"}:                                        true,
+	{pkgPath: "github.com/jacoelho/xsd/internal/validate", name: "xsiStartAttributeFlagsFor"}:                        true,
 }
 
 func allowedStreamBoundaryCall(info *types.Info, parent ast.Node, allowed map[streamBoundaryCall]bool) bool {
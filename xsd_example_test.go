@@ -2,6 +2,7 @@ package xsd_test
 
 import (
 	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -149,6 +150,163 @@ func ExampleEngine_Validate() {
 	// Output: valid: true
 }
 
+func ExampleEngine_ValidateEvents() {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(publicAPISchema)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = engine.ValidateEvents(context.Background(), strings.NewReader(`<root> 007 </root>`), func(ev xsd.Event) error {
+		if ev.Kind == xsd.EventEndElement {
+			fmt.Println(ev.Name.Local, ev.Type.Local, ev.Value, ev.Canonical, ev.Validity == xsd.ValidityValid)
+		}
+		return nil
+	})
+	fmt.Println("valid:", err == nil)
+	// Output:
+	// root int 007 7 true
+	// valid: true
+}
+
+func ExampleEngine_Model() {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="sku" type="xs:token"/>
+        <xs:element name="qty" type="xs:positiveInteger" maxOccurs="unbounded"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	model, err := engine.Model()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	order := model.Element(xml.Name{Local: "order"})
+	for _, p := range order.Type.Particle.Particles {
+		fmt.Println(p.Element.Name.Local, p.Element.Type.Name.Local, p.MinOccurs, p.MaxOccurs == xsd.Unbounded)
+	}
+	// Output:
+	// sku token 1 false
+	// qty positiveInteger 1 true
+}
+
+func ExampleLoadEngine() {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="qty" type="xs:positiveInteger"/>
+</xs:schema>`)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data, err := engine.MarshalBinary()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	loaded, err := xsd.LoadEngine(context.Background(), data)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(loaded.Validate(context.Background(), strings.NewReader(`<qty>3</qty>`)) == nil)
+	fmt.Println(loaded.Validate(context.Background(), strings.NewReader(`<qty>0</qty>`)) == nil)
+	// Output:
+	// true
+	// false
+}
+
+func ExampleEngine_Sample() {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="sku">
+          <xs:simpleType><xs:restriction base="xs:token"><xs:pattern value="[A-Z]{2}-\d{4}"/></xs:restriction></xs:simpleType>
+        </xs:element>
+        <xs:element name="qty" type="xs:positiveInteger" maxOccurs="unbounded"/>
+        <xs:element name="note" type="xs:string" minOccurs="0"/>
+      </xs:sequence>
+      <xs:attribute name="currency" type="xs:string" default="EUR"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc, err := engine.Sample(context.Background(), xml.Name{Local: "order"}, xsd.SampleOptions{Repeat: 2})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(doc))
+	// Output:
+	// <order>
+	//   <sku>AA-0000</sku>
+	//   <qty>1</qty>
+	//   <qty>2</qty>
+	// </order>
+}
+
+func ExampleEngine_XMLToJSON() {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="qty" type="xs:positiveInteger" maxOccurs="unbounded"/>
+        <xs:element name="paid" type="xs:boolean"/>
+      </xs:sequence>
+      <xs:attribute name="id" type="xs:string"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := engine.XMLToJSON(context.Background(), os.Stdout, strings.NewReader(`<order id="A1"><qty>01</qty><paid>1</paid></order>`)); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// {"order":{"@id":"A1","qty":[1],"paid":true}}
+}
+
+func ExampleEngine_JSONSchema() {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:complexType mixed="true">
+      <xs:sequence>
+        <xs:element name="qty" type="xs:positiveInteger" maxOccurs="3"/>
+      </xs:sequence>
+      <xs:attribute name="id" type="xs:ID" use="required"/>
+    </xs:complexType>
+    <xs:unique name="ids"><xs:selector xpath="qty"/><xs:field xpath="."/></xs:unique>
+  </xs:element>
+</xs:schema>`)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	export, err := engine.JSONSchema()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, u := range export.Unrepresented {
+		fmt.Printf("%s: %s\n", u.Component, u.Construct)
+	}
+	// Output:
+	// order: identity constraint
+	// order: mixed content
+}
+
 func TestPublicErrorInspection(t *testing.T) {
 	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(publicAPISchema)))
 	if err != nil {
@@ -3,15 +3,23 @@ package xsd_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/jacoelho/xsd"
+	"github.com/jacoelho/xsd/schematables"
 	"github.com/jacoelho/xsd/xsderrors"
 )
 
@@ -115,6 +123,64 @@ func TestResolverReceivesInheritedXMLBase(t *testing.T) {
 	}
 }
 
+func TestRedefineReplacesComponents(t *testing.T) {
+	t.Parallel()
+
+	const original = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:r" xmlns="urn:r" elementFormDefault="qualified">
+  <xs:simpleType name="code"><xs:restriction base="xs:string"><xs:maxLength value="4"/></xs:restriction></xs:simpleType>
+  <xs:complexType name="item"><xs:sequence><xs:element name="a" type="code"/></xs:sequence></xs:complexType>
+  <xs:group name="extra"><xs:sequence><xs:element name="x" minOccurs="0"/></xs:sequence></xs:group>
+  <xs:attributeGroup name="meta"><xs:attribute name="id" type="xs:string"/></xs:attributeGroup>
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence><xs:element name="item" type="item" maxOccurs="unbounded"/><xs:group ref="extra"/></xs:sequence>
+      <xs:attributeGroup ref="meta"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	const redefining = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:r" xmlns="urn:r" elementFormDefault="qualified">
+  <xs:redefine schemaLocation="original.xsd">
+    <xs:simpleType name="code"><xs:restriction base="code"><xs:pattern value="[A-Z]+"/></xs:restriction></xs:simpleType>
+    <xs:complexType name="item"><xs:complexContent><xs:extension base="item"><xs:sequence><xs:element name="b" type="xs:int"/></xs:sequence></xs:extension></xs:complexContent></xs:complexType>
+    <xs:group name="extra"><xs:sequence><xs:group ref="extra"/><xs:element name="y" minOccurs="0"/></xs:sequence></xs:group>
+    <xs:attributeGroup name="meta"><xs:attributeGroup ref="meta"/><xs:attribute name="lang" type="xs:language" use="required"/></xs:attributeGroup>
+  </xs:redefine>
+</xs:schema>`
+	resolver := xsd.ResolverFunc(func(_ context.Context, _, location string) (xsd.SchemaSource, error) {
+		if location != "original.xsd" {
+			return xsd.SchemaSource{}, errors.New("unexpected location " + location)
+		}
+		return xsd.Bytes("original.xsd", []byte(original)), nil
+	})
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("redefine.xsd", []byte(redefining)).WithResolver(resolver))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		instance string
+		code     xsderrors.Code
+	}{
+		{name: "valid", instance: `<root xmlns="urn:r" lang="en"><item><a>AB</a><b>1</b></item><x/><y/></root>`},
+		{name: "restricted simple type", instance: `<root xmlns="urn:r" lang="en"><item><a>ab</a><b>1</b></item></root>`, code: xsderrors.CodeValidationFacet},
+		{name: "original simple type facet", instance: `<root xmlns="urn:r" lang="en"><item><a>ABCDE</a><b>1</b></item></root>`, code: xsderrors.CodeValidationFacet},
+		{name: "extended complex type", instance: `<root xmlns="urn:r" lang="en"><item><a>AB</a></item></root>`, code: xsderrors.CodeValidationContent},
+		{name: "extended attribute group", instance: `<root xmlns="urn:r"><item><a>AB</a><b>1</b></item></root>`, code: xsderrors.CodeValidationAttribute},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(test.instance))
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, xsderrors.CategoryValidation, test.code)
+		})
+	}
+}
+
 func TestIdentityFieldsRejectComplexElements(t *testing.T) {
 	for _, test := range []struct {
 		name     string
@@ -380,6 +446,17 @@ func errorTreeContains(err error, text string) bool {
 	return false
 }
 
+func errorTreeHasCode(err error, code xsderrors.Code) bool {
+	if err == nil {
+		return false
+	}
+	if many, ok := err.(interface{ Unwrap() []error }); ok {
+		return slices.ContainsFunc(many.Unwrap(), func(child error) bool { return errorTreeHasCode(child, code) })
+	}
+	x, ok := errors.AsType[*xsderrors.Error](err)
+	return ok && x.Code == code
+}
+
 func errorTreeLeafCount(err error) int {
 	if err == nil {
 		return 0
@@ -1203,172 +1280,1671 @@ func TestValidateOptionsBoundSchemaLocationNamespaces(t *testing.T) {
 	}
 }
 
-func TestMaxIdentityEntriesRejectsPendingSelectionBeforeItsEnd(t *testing.T) {
-	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
-  <xs:complexType name="A"><xs:sequence><xs:element ref="a" minOccurs="0"/></xs:sequence><xs:attribute name="id" use="required"/></xs:complexType>
-  <xs:element name="a" type="A"/>
-  <xs:element name="root">
-    <xs:complexType><xs:sequence><xs:element ref="a" minOccurs="0"/></xs:sequence></xs:complexType>
-    <xs:key name="ids"><xs:selector xpath=".//a"/><xs:field xpath="@id"/></xs:key>
+func TestNonUTF8DocumentsAreTranscoded(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="city">
+    <xs:simpleType>
+      <xs:restriction base="xs:string">
+        <xs:enumeration value="Bras` + "\xed" + `lia"/>
+        <xs:enumeration value="S` + "\xe3" + `o Paulo"/>
+      </xs:restriction>
+    </xs:simpleType>
   </xs:element>
 </xs:schema>`
-	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(schema)))
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("cities.xsd", []byte(schema)))
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("Compile() error = %v", err)
 	}
-	session, err := engine.NewSession(xsd.ValidateOptions{MaxIdentityEntries: 1})
-	if err != nil {
-		t.Fatal(err)
+	utf16LE := func(s string) string {
+		out := []byte{0xFF, 0xFE}
+		for _, u := range utf16.Encode([]rune(s)) {
+			out = append(out, byte(u), byte(u>>8))
+		}
+		return string(out)
 	}
-	doc := `<root><a id="one"><a id="two"></a></a></root>`
-	reader := &oneByteCountingReader{data: doc}
-	err = session.Validate(context.Background(), reader)
-	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationLimit)
-	if firstClose := strings.Index(doc, `</a>`); reader.off > firstClose {
-		t.Fatalf("validation consumed %d bytes, want at most %d before the first selected element end", reader.off, firstClose)
+	latin1 := xsd.CharsetFunc(func(p []byte) (rune, int) {
+		if len(p) == 0 {
+			return 0, 0
+		}
+		return rune(p[0]), 1
+	})
+
+	valid := []struct {
+		name string
+		doc  string
+		opts xsd.ValidateOptions
+	}{
+		{name: "windows-1252", doc: `<?xml version="1.0" encoding="windows-1252"?>` + "<city>S\xe3o Paulo</city>"},
+		{name: "UTF-16", doc: utf16LE(`<?xml version="1.0" encoding="UTF-16"?><city>Brasília</city>`)},
+		{
+			name: "registered charset",
+			doc:  `<?xml version="1.0" encoding="x-partner"?>` + "<city>Bras\xedlia</city>",
+			opts: xsd.ValidateOptions{Charsets: xsd.Charsets{"X-Partner": latin1}},
+		},
 	}
-	if err := session.Validate(context.Background(), strings.NewReader(`<root><a id="one"/></root>`)); err != nil {
-		t.Fatalf("session reuse after pending identity limit: %v", err)
+	for _, test := range valid {
+		if err := engine.ValidateWithOptions(context.Background(), strings.NewReader(test.doc), test.opts); err != nil {
+			t.Fatalf("%s: ValidateWithOptions() error = %v", test.name, err)
+		}
+	}
+	unregistered := `<?xml version="1.0" encoding="x-partner"?><city>Rio</city>`
+	expectCategoryCode(t, engine.Validate(context.Background(), strings.NewReader(unregistered)), xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedNonUTF8)
+
+	narrow, ok := errors.AsType[*xsderrors.Error](engine.Validate(context.Background(), strings.NewReader("<?xml version=\"1.0\"?>\n  <city>Rio</city>")))
+	if !ok {
+		t.Fatal("Validate(UTF-8) accepted Rio")
+	}
+	wide, ok := errors.AsType[*xsderrors.Error](engine.Validate(context.Background(), strings.NewReader(utf16LE("<?xml version=\"1.0\"?>\n  <city>Rio</city>"))))
+	if !ok {
+		t.Fatal("Validate(UTF-16) accepted Rio")
+	}
+	if wide.Line != narrow.Line || wide.Column != 2*narrow.Column {
+		t.Fatalf("UTF-16 error position = %d:%d, want %d:%d", wide.Line, wide.Column, narrow.Line, 2*narrow.Column)
+	}
+
+	partnerSchema := xsd.Bytes("partner.xsd", []byte(strings.Replace(schema, "ISO-8859-1", "x-partner", 1)))
+	_, err = xsd.Compile(context.Background(), partnerSchema)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedNonUTF8)
+	if _, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{Charsets: xsd.Charsets{"x-partner": latin1}}, partnerSchema); err != nil {
+		t.Fatalf("CompileWithOptions(registered charset) error = %v", err)
 	}
 }
 
-func TestCompileOptionsAggregateSchemaSetLimits(t *testing.T) {
+func TestCharsetNamesDifferingOnlyInCaseAreRejected(t *testing.T) {
 	t.Parallel()
 
-	rootData := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:include schemaLocation="child.xsd"/><xs:include schemaLocation="child.xsd"/></xs:schema>`)
-	childData := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`)
-	resolver := xsd.ResolverFunc(func(_ context.Context, _, location string) (xsd.SchemaSource, error) {
-		if location == "child.xsd" {
-			return xsd.Bytes("child.xsd", childData), nil
+	latin1 := xsd.CharsetFunc(func(p []byte) (rune, int) {
+		if len(p) == 0 {
+			return 0, 0
 		}
-		return xsd.SchemaSource{}, xsderrors.ErrSchemaNotFound
+		return rune(p[0]), 1
 	})
-	root := xsd.Bytes("root.xsd", rootData).WithResolver(resolver)
-	// The duplicate reference is resolved and read again to verify that the
-	// resolver did not return different content for the same document identity.
-	totalBytes := int64(len(rootData) + 2*len(childData))
+	charsets := xsd.Charsets{"x-partner": latin1, "X-PARTNER": latin1}
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="city" type="xs:string"/></xs:schema>`
 
-	tests := []struct {
-		name    string
-		opts    xsd.CompileOptions
-		wantErr bool
-	}{
-		{name: "exact source count", opts: xsd.CompileOptions{MaxSchemaSources: 2}},
-		{name: "source count exceeded", opts: xsd.CompileOptions{MaxSchemaSources: 1}, wantErr: true},
-		{name: "exact total bytes", opts: xsd.CompileOptions{MaxSchemaTotalBytes: totalBytes}},
-		{name: "total bytes exceeded", opts: xsd.CompileOptions{MaxSchemaTotalBytes: totalBytes - 1}, wantErr: true},
-		{name: "exact references", opts: xsd.CompileOptions{MaxSchemaReferences: 2}},
-		{name: "references exceeded", opts: xsd.CompileOptions{MaxSchemaReferences: 1}, wantErr: true},
-		{name: "exact target contexts", opts: xsd.CompileOptions{MaxSchemaTargetContexts: 2}},
-		{name: "target contexts exceeded", opts: xsd.CompileOptions{MaxSchemaTargetContexts: 1}, wantErr: true},
-		{name: "exact instantiated nodes", opts: xsd.CompileOptions{MaxSchemaInstantiatedNodes: 4}},
-		{name: "instantiated nodes exceeded", opts: xsd.CompileOptions{MaxSchemaInstantiatedNodes: 3}, wantErr: true},
+	_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{Charsets: charsets}, xsd.Bytes("city.xsd", []byte(schema)))
+	expectCategoryCode(t, err, xsderrors.CategorySchemaCompile, xsderrors.CodeSchemaLimit)
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("city.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
+	err = engine.ValidateWithOptions(context.Background(), strings.NewReader("<city>Rio</city>"), xsd.ValidateOptions{Charsets: charsets})
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationOption)
+	_, err = engine.NewSession(xsd.ValidateOptions{Charsets: charsets})
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationOption)
+}
 
-			_, err := xsd.CompileWithOptions(context.Background(), tt.opts, root)
-			if !tt.wantErr {
-				if err != nil {
-					t.Fatalf("CompileWithOptions() error = %v", err)
-				}
-				return
-			}
-			if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeSchemaLimit {
-				t.Fatalf("CompileWithOptions() error = %v, want schema limit", err)
-			}
-		})
+func TestXML11DocumentsRequireOption(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<?xml version="1.1"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="r">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="id" type="xs:NCName"/>
+        <xs:element name="line">
+          <xs:simpleType>
+            <xs:restriction base="xs:string"><xs:pattern value="a\nb"/></xs:restriction>
+          </xs:simpleType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	source := xsd.Bytes("xml11.xsd", []byte(schema))
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXML11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XML11: true}, source)
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XML11) error = %v", err)
+	}
+
+	opts := xsd.ValidateOptions{XML11: true}
+	for _, line := range []string{"a\u0085b", "a\r\u0085b", "a\u2028b"} {
+		doc := `<?xml version="1.1"?><r><id>` + "x\u00b7y" + `</id><line>` + line + `</line></r>`
+		if err := engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), opts); err != nil {
+			t.Fatalf("ValidateWithOptions(%q) error = %v", line, err)
+		}
 	}
+	reference := `<?xml version="1.1"?><r><id>x</id><line>a&#x85;b</line></r>`
+	expectCategoryCode(t, engine.ValidateWithOptions(context.Background(), strings.NewReader(reference), opts), xsderrors.CategoryValidation, xsderrors.CodeValidationFacet)
+	control := `<?xml version="1.1"?><r><id>x</id><line>a&#x7;b</line></r>`
+	expectCategoryCode(t, engine.ValidateWithOptions(context.Background(), strings.NewReader(control), opts), xsderrors.CategoryValidation, xsderrors.CodeValidationFacet)
+	restricted := "<?xml version=\"1.1\"?><r><id>x</id><line>a\u0080b</line></r>"
+	expectCategoryCode(t, engine.ValidateWithOptions(context.Background(), strings.NewReader(restricted), opts), xsderrors.CategoryValidation, xsderrors.CodeValidationXML)
+	expectCategoryCode(t, engine.Validate(context.Background(), strings.NewReader(reference)), xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXML11)
 }
 
-func TestCompileExplicitSourceLimitPrecedesSameIdentityOpeners(t *testing.T) {
+func TestXSD11AssertionsRequireOption(t *testing.T) {
 	t.Parallel()
 
-	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`
-	openCalls := 0
-	sourceFor := func() xsd.SchemaSource {
-		return xsd.Open("same.xsd", func(context.Context) (io.ReadCloser, error) {
-			openCalls++
-			return io.NopCloser(strings.NewReader(schema)), nil
-		})
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:simpleType name="even">
+    <xs:restriction base="xs:integer"><xs:assertion test="$value mod 2 = 0"/></xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="range">
+    <xs:sequence><xs:element name="item" type="even" maxOccurs="unbounded"/></xs:sequence>
+    <xs:attribute name="min" type="xs:int"/>
+    <xs:attribute name="max" type="xs:int"/>
+    <xs:assert test="@min le @max"/>
+    <xs:assert test="every $i in item satisfies $i ge @min and $i le @max"/>
+  </xs:complexType>
+  <xs:complexType name="short">
+    <xs:complexContent>
+      <xs:extension base="range"><xs:assert test="count(item) lt 3"/></xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="ranges">
+    <xs:complexType>
+      <xs:sequence><xs:element name="range" type="range" maxOccurs="unbounded"/></xs:sequence>
+    </xs:complexType>
+  </xs:element>
+  <xs:element name="short" type="short"/>
+</xs:schema>`
+	source := xsd.Bytes("assert.xsd", []byte(schema))
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXSD11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, source)
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
 	}
-	_, err := xsd.CompileWithOptions(context.Background(),
-		xsd.CompileOptions{MaxSchemaSources: 1},
-		sourceFor(),
-		sourceFor())
 
-	if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeSchemaLimit {
-		t.Fatalf("CompileWithOptions() error = %v, want schema limit", err)
+	valid := `<ranges><range min="2" max="10"><item>2</item><item>10</item></range><range min="4" max="4"><item>4</item></range></ranges>`
+	if err := engine.Validate(context.Background(), strings.NewReader(valid)); err != nil {
+		t.Fatalf("Validate(valid) error = %v", err)
 	}
-	if openCalls != 0 {
-		t.Fatalf("schema opener calls = %d, want 0", openCalls)
+	for name, doc := range map[string]string{
+		"attributes": `<ranges><range min="10" max="2"><item>4</item></range></ranges>`,
+		"children":   `<ranges><range min="2" max="10"><item>12</item></range></ranges>`,
+		"facet":      `<ranges><range min="1" max="10"><item>3</item></range></ranges>`,
+		"inherited":  `<short min="10" max="2"><item>4</item></short>`,
+		"extension":  `<short min="0" max="10"><item>2</item><item>4</item><item>6</item></short>`,
+	} {
+		err := engine.Validate(context.Background(), strings.NewReader(doc))
+		expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationAssertion)
+		if name == "extension" && !strings.Contains(err.Error(), "count(item) lt 3") {
+			t.Fatalf("Validate(%s) error = %v, want extension assertion", name, err)
+		}
 	}
 }
 
-func TestCompileOptionsSubstitutionClosureLimit(t *testing.T) {
+func TestXSD11AssertionTestsCompileWithSchema(t *testing.T) {
 	t.Parallel()
 
-	schema := xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
-  <xs:element name="h0" type="xs:string"/>
-  <xs:element name="h1" substitutionGroup="h0"/>
-  <xs:element name="h2" substitutionGroup="h1"/>
-  <xs:element name="h3" substitutionGroup="h2"/>
-</xs:schema>`))
-	if _, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{MaxSubstitutionClosureEntries: 6}, schema); err != nil {
-		t.Fatalf("CompileWithOptions(exact limit) error = %v", err)
+	for name, test := range map[string]string{
+		"syntax":   "@a = ",
+		"prefix":   "q:item",
+		"absolute": "/root",
+		"function": "doc('x.xml')",
+	} {
+		schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="t"><xs:assert test="` + test + `"/></xs:complexType>
+</xs:schema>`
+		_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes(name+".xsd", []byte(schema)))
+		expectCategoryCode(t, err, xsderrors.CategorySchemaCompile, xsderrors.CodeSchemaAssertion)
 	}
-	_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{MaxSubstitutionClosureEntries: 5}, schema)
-	if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeSchemaLimit {
-		t.Fatalf("CompileWithOptions(over limit) error = %v, want schema limit", err)
+}
+
+func TestXSD11AssertionSubtreeCountsAgainstTextLimit(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="r">
+    <xs:complexType>
+      <xs:sequence><xs:element name="v" type="xs:string" maxOccurs="unbounded"/></xs:sequence>
+      <xs:assert test="count(v) gt 0"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes("limit.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "<r>" + strings.Repeat("<v>"+strings.Repeat("x", 64)+"</v>", 64) + "</r>"
+	err = engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), xsd.ValidateOptions{MaxInstanceTextBytes: 1024})
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationLimit)
+	if err := engine.Validate(context.Background(), strings.NewReader(doc)); err != nil {
+		t.Fatalf("Validate() error = %v", err)
 	}
 }
 
-func TestBytesSourceCopiesInput(t *testing.T) {
-	data := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="root"/></xs:schema>`)
-	source := xsd.Bytes("schema.xsd", data)
-	for i := range data {
-		data[i] = 0
+func TestXSD11AssertionRangeCountsAgainstStepLimit(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="r">
+    <xs:complexType><xs:assert test="count(-9223372036854775808 to 9223372036854775807) gt 0"/></xs:complexType>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes("range.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatal(err)
 	}
-	if _, err := xsd.Compile(context.Background(), source); err != nil {
-		t.Fatalf("Compile(Bytes(...)) error after caller mutation = %v", err)
+	err = engine.Validate(context.Background(), strings.NewReader("<r/>"))
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationAssertion)
+	if !strings.Contains(err.Error(), "step limit") {
+		t.Fatalf("Validate() error = %v, want the step limit", err)
 	}
 }
 
-func TestCopiedEngineSharesPublishedSchema(t *testing.T) {
-	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="root" type="xs:int"/></xs:schema>`)))
+func TestXSD11TypeAlternativesSelectTypeAtElementStart(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="value">
+    <xs:simpleContent>
+      <xs:extension base="xs:string"><xs:attribute name="kind" type="xs:string"/></xs:extension>
+    </xs:simpleContent>
+  </xs:complexType>
+  <xs:complexType name="number">
+    <xs:simpleContent><xs:restriction base="value"><xs:pattern value="[0-9]+"/></xs:restriction></xs:simpleContent>
+  </xs:complexType>
+  <xs:complexType name="flag">
+    <xs:simpleContent><xs:restriction base="value"><xs:enumeration value="yes"/><xs:enumeration value="no"/></xs:restriction></xs:simpleContent>
+  </xs:complexType>
+  <xs:element name="values">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="v" type="value" maxOccurs="unbounded">
+          <xs:alternative test="@kind = 'number'" type="number"/>
+          <xs:alternative test="@kind = 'flag'" type="flag"/>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	source := xsd.Bytes("alternative.xsd", []byte(schema))
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXSD11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, source)
 	if err != nil {
-		t.Fatalf("Compile() error = %v", err)
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
 	}
-	engineCopy := *engine
 
-	var wg sync.WaitGroup
-	for _, e := range []*xsd.Engine{engine, &engineCopy} {
-		wg.Add(1)
-		go func(e *xsd.Engine) {
-			defer wg.Done()
-			if err := e.Validate(context.Background(), strings.NewReader(`<root>7</root>`)); err != nil {
-				t.Errorf("Validate() error = %v", err)
-			}
-		}(e)
+	valid := `<values><v kind="number">42</v><v kind="flag">yes</v><v>anything</v></values>`
+	if err := engine.Validate(context.Background(), strings.NewReader(valid)); err != nil {
+		t.Fatalf("Validate(valid) error = %v", err)
+	}
+	for name, doc := range map[string]string{
+		"number":   `<values><v kind="number">x</v></values>`,
+		"flag":     `<values><v kind="flag">maybe</v></values>`,
+		"xsi:type": `<values xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><v kind="number" xsi:type="flag">yes</v></values>`,
+	} {
+		err := engine.Validate(context.Background(), strings.NewReader(doc))
+		if err == nil {
+			t.Fatalf("Validate(%s) error = nil, want validation error", name)
+		}
+		if xe, ok := errors.AsType[*xsderrors.Error](err); !ok || xe.Category != xsderrors.CategoryValidation {
+			t.Fatalf("Validate(%s) error = %v, want validation error", name, err)
+		}
 	}
-	wg.Wait()
 }
 
-// TestEngineConcurrentValidation is the executable form of the runtime schema
-// sharing contract: sessions only read the schema published to an Engine. The
-// schema routes workers through identity key/keyref tables, attribute default
-// and fixed values, a variable-length pattern facet, a wide DFA row index, a
-// substitution group, and xs:ID/xs:IDREF tracking.
-func TestEngineConcurrentValidation(t *testing.T) {
-	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
-  <xs:element name="head" type="xs:string" abstract="true"/>
-  <xs:element name="sub" type="xs:string" substitutionGroup="head"/>
+func TestXSD11TypeAlternativesCompileChecks(t *testing.T) {
+	t.Parallel()
+
+	for name, alternatives := range map[string]string{
+		"syntax":        `<xs:alternative test="@kind = " type="xs:token"/>`,
+		"missing type":  `<xs:alternative test="@kind"/>`,
+		"default first": `<xs:alternative type="xs:token"/><xs:alternative test="@kind" type="xs:token"/>`,
+		"not derived":   `<xs:alternative test="@kind" type="xs:int"/>`,
+		"child axis":    `<xs:alternative test="kind = 'a'" type="xs:token"/>`,
+		"descendant":    `<xs:alternative test="@kind and .//kind" type="xs:token"/>`,
+		"parent axis":   `<xs:alternative test="../@kind" type="xs:token"/>`,
+		"sibling axis":  `<xs:alternative test="following-sibling::e" type="xs:token"/>`,
+	} {
+		schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="e" type="xs:string">` + alternatives + `</xs:element>
+</xs:schema>`
+		_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes(name+".xsd", []byte(schema)))
+		expectCategoryCode(t, err, xsderrors.CategorySchemaCompile, xsderrors.CodeSchemaTypeAlternative)
+	}
+}
+
+func TestXSD11OpenContentAdmitsWildcardElements(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:o" xmlns="urn:o" elementFormDefault="qualified">
+  <xs:defaultOpenContent mode="suffix">
+    <xs:any namespace="urn:ext" processContents="skip"/>
+  </xs:defaultOpenContent>
+  <xs:complexType name="interleaved">
+    <xs:openContent><xs:any namespace="##other" processContents="lax"/></xs:openContent>
+    <xs:sequence><xs:element name="a"/><xs:element name="b"/></xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="closed">
+    <xs:openContent mode="none"/>
+    <xs:sequence><xs:element name="a"/></xs:sequence>
+  </xs:complexType>
   <xs:element name="root">
     <xs:complexType>
       <xs:sequence>
-        <xs:element name="item" maxOccurs="unbounded">
-          <xs:complexType>
+        <xs:element name="i" type="interleaved" minOccurs="0"/>
+        <xs:element name="c" type="closed" minOccurs="0"/>
+        <xs:element name="s" minOccurs="0">
+          <xs:complexType><xs:sequence><xs:element name="a"/></xs:sequence></xs:complexType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	source := xsd.Bytes("open.xsd", []byte(schema))
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXSD11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, source)
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		code     xsderrors.Code
+	}{
+		{name: "interleave", instance: `<root xmlns="urn:o"><i><x:e xmlns:x="urn:x"/><a/><x:e xmlns:x="urn:x"/><b/></i></root>`},
+		{name: "interleave namespace", instance: `<root xmlns="urn:o"><i><a/><z/><b/></i></root>`, code: xsderrors.CodeValidationElement},
+		{name: "suffix", instance: `<root xmlns="urn:o"><s><a/><e:x xmlns:e="urn:ext"/><e:y xmlns:e="urn:ext"/></s></root>`},
+		{name: "suffix before model", instance: `<root xmlns="urn:o"><s><e:x xmlns:e="urn:ext"/><a/></s></root>`, code: xsderrors.CodeValidationElement},
+		{name: "mode none", instance: `<root xmlns="urn:o"><c><a/><e:x xmlns:e="urn:ext"/></c></root>`, code: xsderrors.CodeValidationElement},
+		{name: "default on anonymous type", instance: `<root xmlns="urn:o"><e:x xmlns:e="urn:ext"/></root>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(test.instance))
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, xsderrors.CategoryValidation, test.code)
+		})
+	}
+}
+
+func TestXSD11OpenContentCompileChecks(t *testing.T) {
+	t.Parallel()
+
+	for name, types := range map[string]string{
+		"missing any":          `<xs:complexType name="t"><xs:openContent/><xs:sequence/></xs:complexType>`,
+		"occurs on any":        `<xs:complexType name="t"><xs:openContent><xs:any maxOccurs="2"/></xs:openContent><xs:sequence/></xs:complexType>`,
+		"namespace and not":    `<xs:complexType name="t"><xs:sequence><xs:any namespace="##any" notNamespace="urn:x"/></xs:sequence></xs:complexType>`,
+		"attribute sibling":    `<xs:complexType name="t"><xs:anyAttribute notQName="##definedSibling"/></xs:complexType>`,
+		"restriction adds":     `<xs:complexType name="b"><xs:sequence><xs:element name="a"/></xs:sequence></xs:complexType><xs:complexType name="t"><xs:complexContent><xs:restriction base="b"><xs:openContent><xs:any/></xs:openContent><xs:sequence><xs:element name="a"/></xs:sequence></xs:restriction></xs:complexContent></xs:complexType>`,
+		"extension to suffix":  `<xs:complexType name="b"><xs:openContent><xs:any/></xs:openContent><xs:sequence/></xs:complexType><xs:complexType name="t"><xs:complexContent><xs:extension base="b"><xs:openContent mode="suffix"><xs:any/></xs:openContent><xs:sequence/></xs:extension></xs:complexContent></xs:complexType>`,
+		"default content none": `<xs:defaultOpenContent mode="none"><xs:any/></xs:defaultOpenContent>`,
+	} {
+		schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">` + types + `</xs:schema>`
+		_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes(name+".xsd", []byte(schema)))
+		if xe, ok := errors.AsType[*xsderrors.Error](err); !ok || xe.Category != xsderrors.CategorySchemaCompile {
+			t.Fatalf("CompileWithOptions(%s) error = %v, want schema compile error", name, err)
+		}
+	}
+}
+
+func TestXSD11NegatedWildcards(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:w" xmlns="urn:w" elementFormDefault="qualified">
+  <xs:element name="known" type="xs:int"/>
+  <xs:attribute name="flag" type="xs:boolean"/>
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="first" minOccurs="0"/>
+        <xs:any notNamespace="urn:blocked" notQName="##defined ##definedSibling hidden" processContents="skip" minOccurs="0" maxOccurs="unbounded"/>
+      </xs:sequence>
+      <xs:anyAttribute notNamespace="##local" notQName="##defined" processContents="skip"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	source := xsd.Bytes("negated.xsd", []byte(schema))
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXSD11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, source)
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		code     xsderrors.Code
+	}{
+		{name: "valid", instance: `<root xmlns="urn:w" xmlns:o="urn:other" o:a="1"><other/><o:e/></root>`},
+		{name: "not namespace", instance: `<root xmlns="urn:w"><b:e xmlns:b="urn:blocked"/></root>`, code: xsderrors.CodeValidationElement},
+		{name: "not qname", instance: `<root xmlns="urn:w"><hidden/></root>`, code: xsderrors.CodeValidationElement},
+		{name: "defined", instance: `<root xmlns="urn:w"><known>1</known></root>`, code: xsderrors.CodeValidationElement},
+		{name: "defined sibling", instance: `<root xmlns="urn:w"><first/><first/></root>`, code: xsderrors.CodeValidationElement},
+		{name: "attribute not namespace", instance: `<root xmlns="urn:w" local="1"/>`, code: xsderrors.CodeValidationAttribute},
+		{name: "attribute defined", instance: `<root xmlns="urn:w" xmlns:w="urn:w" w:flag="true"/>`, code: xsderrors.CodeValidationAttribute},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(test.instance))
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, xsderrors.CategoryValidation, test.code)
+		})
+	}
+}
+
+func TestXSD11OverrideReplacesComponents(t *testing.T) {
+	t.Parallel()
+
+	const original = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:r" xmlns="urn:r" elementFormDefault="qualified">
+  <xs:simpleType name="code"><xs:restriction base="xs:string"><xs:maxLength value="4"/></xs:restriction></xs:simpleType>
+  <xs:element name="note" type="xs:string"/>
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence><xs:element name="c" type="code"/><xs:element ref="note" minOccurs="0"/></xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	const overriding = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:r" xmlns="urn:r" elementFormDefault="qualified">
+  <xs:override schemaLocation="original.xsd">
+    <xs:simpleType name="code"><xs:restriction base="xs:int"/></xs:simpleType>
+    <xs:element name="note" type="xs:boolean"/>
+    <xs:element name="unused" type="xs:string"/>
+  </xs:override>
+</xs:schema>`
+	resolver := xsd.ResolverFunc(func(_ context.Context, _, location string) (xsd.SchemaSource, error) {
+		if location != "original.xsd" {
+			return xsd.SchemaSource{}, errors.New("unexpected location " + location)
+		}
+		return xsd.Bytes("original.xsd", []byte(original)), nil
+	})
+	source := xsd.Bytes("override.xsd", []byte(overriding)).WithResolver(resolver)
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXSD11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, source)
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		code     xsderrors.Code
+	}{
+		{name: "valid", instance: `<root xmlns="urn:r"><c>123456</c><note>true</note></root>`},
+		{name: "overridden simple type", instance: `<root xmlns="urn:r"><c>ab</c></root>`, code: xsderrors.CodeValidationFacet},
+		{name: "overridden element", instance: `<root xmlns="urn:r"><c>1</c><note>text</note></root>`, code: xsderrors.CodeValidationFacet},
+		{name: "unmatched override ignored", instance: `<unused xmlns="urn:r">x</unused>`, code: xsderrors.CodeValidationRoot},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(test.instance))
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, xsderrors.CategoryValidation, test.code)
+		})
+	}
+}
+
+func TestXSD11BuiltinDatatypes(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="stamp" type="xs:dateTimeStamp"/>
+  <xs:element name="dayTime" type="xs:dayTimeDuration"/>
+  <xs:element name="yearMonth" type="xs:yearMonthDuration"/>
+  <xs:element name="atomic" type="xs:anyAtomicType"/>
+  <xs:element name="never" type="xs:error"/>
+  <xs:complexType name="kinded">
+    <xs:simpleContent><xs:extension base="xs:string"><xs:attribute name="kind"/></xs:extension></xs:simpleContent>
+  </xs:complexType>
+  <xs:element name="v" type="kinded">
+    <xs:alternative test="@kind = 'none'" type="xs:error"/>
+  </xs:element>
+</xs:schema>`
+	for _, name := range []string{"dateTimeStamp", "dayTimeDuration", "yearMonthDuration", "anyAtomicType", "error"} {
+		doc := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="e" type="xs:` + name + `"/></xs:schema>`
+		_, err := xsd.Compile(context.Background(), xsd.Bytes(name+".xsd", []byte(doc)))
+		expectCategoryCode(t, err, xsderrors.CategorySchemaCompile, xsderrors.CodeSchemaReference)
+	}
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes("builtins.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		code     xsderrors.Code
+	}{
+		{name: "dateTimeStamp", instance: `<stamp>2024-01-02T03:04:05Z</stamp>`},
+		{name: "dateTimeStamp without timezone", instance: `<stamp>2024-01-02T03:04:05</stamp>`, code: xsderrors.CodeValidationFacet},
+		{name: "dayTimeDuration", instance: `<dayTime>P1DT2H</dayTime>`},
+		{name: "dayTimeDuration with years", instance: `<dayTime>P1Y</dayTime>`, code: xsderrors.CodeValidationFacet},
+		{name: "yearMonthDuration", instance: `<yearMonth>P1Y2M</yearMonth>`},
+		{name: "yearMonthDuration with days", instance: `<yearMonth>P1D</yearMonth>`, code: xsderrors.CodeValidationFacet},
+		{name: "anyAtomicType", instance: `<atomic>anything</atomic>`},
+		{name: "error", instance: `<never></never>`, code: xsderrors.CodeValidationFacet},
+		{name: "error alternative", instance: `<v kind="none">x</v>`, code: xsderrors.CodeValidationAttribute},
+		{name: "other alternative", instance: `<v kind="some">x</v>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(test.instance))
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, xsderrors.CategoryValidation, test.code)
+		})
+	}
+}
+
+func TestXSD11ExplicitTimezoneFacet(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:simpleType name="localDate">
+    <xs:restriction base="xs:date"><xs:explicitTimezone value="prohibited"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="zonedTime">
+    <xs:restriction base="xs:time"><xs:explicitTimezone value="required"/></xs:restriction>
+  </xs:simpleType>
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="d" type="localDate"/>
+        <xs:element name="t" type="zonedTime"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	source := xsd.Bytes("timezone.xsd", []byte(schema))
+	_, err := xsd.Compile(context.Background(), source)
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedXSD11)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, source)
+	if err != nil {
+		t.Fatalf("CompileWithOptions(XSD11) error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		code     xsderrors.Code
+	}{
+		{name: "valid", instance: `<root><d>2024-01-02</d><t>03:04:05+01:00</t></root>`},
+		{name: "prohibited timezone", instance: `<root><d>2024-01-02Z</d><t>03:04:05Z</t></root>`, code: xsderrors.CodeValidationFacet},
+		{name: "required timezone", instance: `<root><d>2024-01-02</d><t>03:04:05</t></root>`, code: xsderrors.CodeValidationFacet},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(test.instance))
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, xsderrors.CategoryValidation, test.code)
+		})
+	}
+}
+
+func TestXSD11BuiltinDatatypesCompileChecks(t *testing.T) {
+	t.Parallel()
+
+	for name, types := range map[string]string{
+		"restrict anyAtomicType": `<xs:simpleType name="t"><xs:restriction base="xs:anyAtomicType"/></xs:simpleType>`,
+		"invalid value":          `<xs:simpleType name="t"><xs:restriction base="xs:date"><xs:explicitTimezone value="always"/></xs:restriction></xs:simpleType>`,
+		"non temporal base":      `<xs:simpleType name="t"><xs:restriction base="xs:string"><xs:explicitTimezone value="required"/></xs:restriction></xs:simpleType>`,
+		"loosen required":        `<xs:simpleType name="t"><xs:restriction base="xs:dateTimeStamp"><xs:explicitTimezone value="optional"/></xs:restriction></xs:simpleType>`,
+	} {
+		schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">` + types + `</xs:schema>`
+		_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes(name+".xsd", []byte(schema)))
+		if xe, ok := errors.AsType[*xsderrors.Error](err); !ok || xe.Category != xsderrors.CategorySchemaCompile {
+			t.Fatalf("CompileWithOptions(%s) error = %v, want schema compile error", name, err)
+		}
+	}
+}
+
+func TestValidateEventsReportsPSVI(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:o" xmlns="urn:o" elementFormDefault="qualified">
+  <xs:simpleType name="qty"><xs:restriction base="xs:int"><xs:maxInclusive value="10"/></xs:restriction></xs:simpleType>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="qty" type="qty" maxOccurs="unbounded"/>
+        <xs:element name="note" type="xs:token" default="none"/>
+        <xs:any namespace="##other" processContents="skip" minOccurs="0"/>
+      </xs:sequence>
+      <xs:attribute name="id" type="xs:ID"/>
+      <xs:attribute name="currency" type="xs:token" default="EUR"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("order.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	doc := `<order xmlns="urn:o" id=" a1 "><qty> 007 </qty><qty>11</qty><note/><x xmlns="urn:x"/></order>`
+	var events []xsd.Event
+	err = engine.ValidateEvents(context.Background(), strings.NewReader(doc), func(ev xsd.Event) error {
+		ev.Attributes = slices.Clone(ev.Attributes)
+		events = append(events, ev)
+		return nil
+	})
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationFacet)
+
+	xs := func(local string) xml.Name { return xml.Name{Space: "http://www.w3.org/2001/XMLSchema", Local: local} }
+	o := func(local string) xml.Name { return xml.Name{Space: "urn:o", Local: local} }
+	want := []xsd.Event{
+		{Kind: xsd.EventStartElement, Name: o("order"), Declaration: o("order"), GlobalDeclaration: true, Assessed: true, Attributes: []xsd.EventAttribute{
+			{Name: xml.Name{Local: "id"}, Type: xs("ID"), Value: "a1", Canonical: "a1", Validity: xsd.ValidityValid},
+			{Name: xml.Name{Local: "currency"}, Type: xs("token"), Value: "EUR", Canonical: "EUR", Validity: xsd.ValidityValid, Default: true},
+		}},
+		{Kind: xsd.EventStartElement, Name: o("qty"), Type: o("qty"), Declaration: o("qty"), Assessed: true},
+		{Kind: xsd.EventEndElement, Name: o("qty"), Type: o("qty"), Declaration: o("qty"), Assessed: true, HasValue: true, Value: "007", Canonical: "7", Validity: xsd.ValidityValid},
+		{Kind: xsd.EventStartElement, Name: o("qty"), Type: o("qty"), Declaration: o("qty"), Assessed: true},
+		{Kind: xsd.EventEndElement, Name: o("qty"), Type: o("qty"), Declaration: o("qty"), Assessed: true, HasValue: true, Value: "11", Validity: xsd.ValidityInvalid},
+		{Kind: xsd.EventStartElement, Name: o("note"), Type: xs("token"), Declaration: o("note"), Assessed: true},
+		{Kind: xsd.EventEndElement, Name: o("note"), Type: xs("token"), Declaration: o("note"), Assessed: true, HasValue: true, Value: "none", Canonical: "none", DefaultApplied: true, Validity: xsd.ValidityValid},
+		{Kind: xsd.EventStartElement, Name: xml.Name{Space: "urn:x", Local: "x"}},
+		{Kind: xsd.EventEndElement, Name: xml.Name{Space: "urn:x", Local: "x"}},
+		{Kind: xsd.EventEndElement, Name: o("order"), Declaration: o("order"), GlobalDeclaration: true, Assessed: true, Validity: xsd.ValidityInvalid},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("ValidateEvents() reported %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		got := events[i]
+		got.Line, got.Column = 0, 0
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestValidateEventsHandlerErrorStopsValidation(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="r"><xs:complexType><xs:sequence><xs:element name="c" type="xs:int" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	stop := errors.New("stop")
+	calls := 0
+	err = engine.ValidateEvents(context.Background(), strings.NewReader(`<r><c>1</c><c>2</c></r>`), func(ev xsd.Event) error {
+		calls++
+		if ev.Kind == xsd.EventEndElement {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("ValidateEvents() error = %v, want handler error", err)
+	}
+	if calls != 3 {
+		t.Fatalf("handler calls = %d, want 3", calls)
+	}
+}
+
+func TestValidateOptionsOnError(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="r"><xs:complexType><xs:sequence><xs:element name="c" type="xs:int" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	doc := "<r>" + strings.Repeat("<c>x</c>", 150) + "<c>1</c></r>"
+	tests := []struct {
+		action    func(calls int) xsd.ErrorAction
+		name      string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "continue", action: func(int) xsd.ErrorAction { return xsd.ErrorContinue }, wantCalls: 150, wantErr: true},
+		{name: "stop", action: func(calls int) xsd.ErrorAction {
+			if calls == 2 {
+				return xsd.ErrorStop
+			}
+			return xsd.ErrorContinue
+		}, wantCalls: 2, wantErr: true},
+		{name: "suppress", action: func(int) xsd.ErrorAction { return xsd.ErrorSuppress }, wantCalls: 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var columns []int
+			err := engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), xsd.ValidateOptions{
+				OnError: func(x *xsderrors.Error) xsd.ErrorAction {
+					if x.Code != xsderrors.CodeValidationFacet && x.Code != xsderrors.CodeValidationType {
+						t.Errorf("OnError(%v), want a value error", x)
+					}
+					columns = append(columns, x.Column)
+					return tt.action(len(columns))
+				},
+			})
+			if len(columns) != tt.wantCalls {
+				t.Fatalf("OnError calls = %d, want %d", len(columns), tt.wantCalls)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ValidateWithOptions() error = %v, want nil", err)
+				}
+				return
+			}
+			x, ok := err.(*xsderrors.Error)
+			if !ok {
+				t.Fatalf("ValidateWithOptions() error = %T %v, want the first reported *xsderrors.Error", err, err)
+			}
+			if x.Column != columns[0] {
+				t.Fatalf("ValidateWithOptions() error column = %d, want first reported column %d", x.Column, columns[0])
+			}
+		})
+	}
+}
+
+func TestEngineModelDescribesSchemaComponents(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:m" xmlns="urn:m" elementFormDefault="qualified">
+  <xs:simpleType name="code">
+    <xs:restriction base="xs:token">
+      <xs:pattern value="[A-Z]{3}"/>
+      <xs:enumeration value="ABC"/>
+      <xs:enumeration value="XYZ"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="qty">
+    <xs:restriction base="xs:int">
+      <xs:minInclusive value="1"/>
+      <xs:maxInclusive value="10" fixed="true"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:attributeGroup name="audit">
+    <xs:attribute name="by" type="xs:string" use="required"/>
+    <xs:attribute name="at" type="xs:dateTime"/>
+  </xs:attributeGroup>
+  <xs:complexType name="base">
+    <xs:sequence>
+      <xs:element name="id" type="code"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="line">
+    <xs:complexContent>
+      <xs:extension base="base">
+        <xs:choice minOccurs="0" maxOccurs="unbounded">
+          <xs:element name="qty" type="qty"/>
+          <xs:any namespace="##other" processContents="lax"/>
+        </xs:choice>
+        <xs:attributeGroup ref="audit"/>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="item" type="line" abstract="true"/>
+  <xs:element name="book" type="line" substitutionGroup="item"/>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element ref="item" maxOccurs="5"/>
+      </xs:sequence>
+    </xs:complexType>
+    <xs:key name="itemKey">
+      <xs:selector xpath="book"/>
+      <xs:field xpath="id"/>
+    </xs:key>
+    <xs:keyref name="itemRef" refer="itemKey">
+      <xs:selector xpath=".//book"/>
+      <xs:field xpath="@by"/>
+    </xs:keyref>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("model.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	model, err := engine.Model()
+	if err != nil {
+		t.Fatalf("Model() error = %v", err)
+	}
+
+	xs := func(local string) xml.Name { return xml.Name{Space: "http://www.w3.org/2001/XMLSchema", Local: local} }
+	m := func(local string) xml.Name { return xml.Name{Space: "urn:m", Local: local} }
+	var elements []xml.Name
+	for _, elem := range model.Elements {
+		if elem.Name.Space == "urn:m" {
+			elements = append(elements, elem.Name)
+		}
+	}
+	if want := []xml.Name{m("book"), m("item"), m("order")}; !reflect.DeepEqual(elements, want) {
+		t.Fatalf("Elements = %v, want %v", elements, want)
+	}
+
+	code := model.Type(m("code"))
+	if code == nil || code.Kind != xsd.TypeSimple || code.Variety != xsd.VarietyAtomic {
+		t.Fatalf("Type(code) = %+v, want atomic simple type", code)
+	}
+	if code.Primitive == nil || code.Primitive.Name != xs("string") {
+		t.Fatalf("code primitive = %+v, want xs:string", code.Primitive)
+	}
+	if !code.Facets.Has(xsd.FacetEnumeration) || !reflect.DeepEqual(code.Facets.Enumeration, []string{"ABC", "XYZ"}) {
+		t.Fatalf("code enumeration = %v", code.Facets.Enumeration)
+	}
+	if !reflect.DeepEqual(code.Facets.Patterns, [][]string{{"[A-Z]{3}"}}) {
+		t.Fatalf("code patterns = %v", code.Facets.Patterns)
+	}
+	if code.Facets.WhiteSpace != xsd.WhiteSpaceCollapse {
+		t.Fatalf("code whiteSpace = %v, want collapse", code.Facets.WhiteSpace)
+	}
+	var chain []xml.Name
+	for _, typ := range code.DerivationChain() {
+		chain = append(chain, typ.Name)
+	}
+	want := []xml.Name{m("code"), xs("token"), xs("normalizedString"), xs("string"), xs("anyAtomicType"), xs("anySimpleType"), xs("anyType")}
+	if !reflect.DeepEqual(chain, want) {
+		t.Fatalf("code derivation chain = %v, want %v", chain, want)
+	}
+
+	qty := model.Type(m("qty"))
+	if qty.Facets.MinInclusive != "1" || qty.Facets.MaxInclusive != "10" || qty.Facets.Fixed != xsd.FacetMaxInclusive || qty.Facets.Has(xsd.FacetMinExclusive) {
+		t.Fatalf("qty facets = %+v", qty.Facets)
+	}
+
+	line := model.Type(m("line"))
+	if line.Derivation != xsd.DerivationExtension || line.Base != model.Type(m("base")) || line.ContentType != xsd.ContentElementOnly {
+		t.Fatalf("Type(line) = %+v, want element-only extension of base", line)
+	}
+	p := line.Particle
+	if p == nil || p.Kind != xsd.ParticleSequence || len(p.Particles) != 2 {
+		t.Fatalf("line particle = %+v, want sequence of two particles", p)
+	}
+	if id := p.Particles[0]; id.Kind != xsd.ParticleElement || id.Element.Name != m("id") || id.Element.Type != code || id.MinOccurs != 1 || id.MaxOccurs != 1 {
+		t.Fatalf("line first particle = %+v", id)
+	}
+	choice := p.Particles[1]
+	if choice.Kind != xsd.ParticleChoice || choice.MinOccurs != 0 || choice.MaxOccurs != xsd.Unbounded || len(choice.Particles) != 2 {
+		t.Fatalf("line choice = %+v", choice)
+	}
+	if w := choice.Particles[1]; w.Kind != xsd.ParticleWildcard || w.Wildcard.Constraint != xsd.NamespaceNot ||
+		!reflect.DeepEqual(w.Wildcard.Namespaces, []string{"urn:m", ""}) || w.Wildcard.Process != xsd.ProcessLax {
+		t.Fatalf("line wildcard = %+v", w.Wildcard)
+	}
+	var attrs []string
+	for _, use := range line.Attributes {
+		attrs = append(attrs, fmt.Sprintf("%s:%t", use.Name.Local, use.Required))
+	}
+	if want := []string{"at:false", "by:true"}; !reflect.DeepEqual(attrs, want) {
+		t.Fatalf("line attributes = %v, want %v", attrs, want)
+	}
+	if len(model.AttributeGroups) != 1 || model.AttributeGroups[0].Name != m("audit") || len(model.AttributeGroups[0].Attributes) != 2 {
+		t.Fatalf("AttributeGroups = %+v", model.AttributeGroups)
+	}
+
+	item, book, order := model.Element(m("item")), model.Element(m("book")), model.Element(m("order"))
+	if !item.Abstract || book.SubstitutionGroup != item || order.Type.Particle.Particles[0].Element != item {
+		t.Fatalf("item = %+v, book = %+v", item, book)
+	}
+	if ref := order.Type.Particle.Particles[0]; ref.MaxOccurs != 5 {
+		t.Fatalf("order item particle = %+v, want maxOccurs 5", ref)
+	}
+	if len(model.SubstitutionGroups) != 1 || model.SubstitutionGroups[0].Head != item ||
+		!reflect.DeepEqual(model.SubstitutionGroups[0].Members, []*xsd.ElementDeclaration{book}) {
+		t.Fatalf("SubstitutionGroups = %+v", model.SubstitutionGroups)
+	}
+
+	if len(order.IdentityConstraints) != 2 || len(model.IdentityConstraints) != 2 {
+		t.Fatalf("identity constraints = %+v", order.IdentityConstraints)
+	}
+	key, keyref := order.IdentityConstraints[0], order.IdentityConstraints[1]
+	if key.Kind != xsd.IdentityKey || key.Name != m("itemKey") || key.Selector != "book" || !reflect.DeepEqual(key.Fields, []string{"id"}) {
+		t.Fatalf("key = %+v", key)
+	}
+	if keyref.Kind != xsd.IdentityKeyRef || keyref.Refer != key || keyref.Selector != ".//book" || !reflect.DeepEqual(keyref.Fields, []string{"@by"}) {
+		t.Fatalf("keyref = %+v", keyref)
+	}
+}
+
+func TestEngineModelHandlesRecursiveTypes(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("tree.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="node" mixed="true">
+    <xs:sequence><xs:element name="node" type="node" minOccurs="0" maxOccurs="unbounded"/></xs:sequence>
+  </xs:complexType>
+  <xs:element name="tree" type="node"/>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	model, err := engine.Model()
+	if err != nil {
+		t.Fatalf("Model() error = %v", err)
+	}
+	node := model.Type(xml.Name{Local: "node"})
+	if node.ContentType != xsd.ContentMixed {
+		t.Fatalf("node content type = %v, want mixed", node.ContentType)
+	}
+	child := node.Particle.Particles[0]
+	if child.Element.Type != node || child.MinOccurs != 0 || child.MaxOccurs != xsd.Unbounded {
+		t.Fatalf("node child particle = %+v", child)
+	}
+	again, err := engine.Model()
+	if err != nil {
+		t.Fatalf("Model() error = %v", err)
+	}
+	if again.Type(xml.Name{Local: "node"}) == node {
+		t.Fatal("Model() returned shared components across calls")
+	}
+}
+
+func TestRetainAnnotationsReportsDocumentationAndAppInfo(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:ui="urn:ui" xml:lang="en">
+  <xs:annotation><xs:documentation>Orders schema.</xs:documentation></xs:annotation>
+  <xs:attributeGroup name="audit">
+    <xs:attribute name="by" type="xs:string">
+      <xs:annotation><xs:documentation>Author of the change.</xs:documentation></xs:annotation>
+    </xs:attribute>
+  </xs:attributeGroup>
+  <xs:complexType name="base">
+    <xs:attribute name="id" type="xs:ID">
+      <xs:annotation><xs:appinfo><ui:hidden/></xs:appinfo></xs:annotation>
+    </xs:attribute>
+  </xs:complexType>
+  <xs:complexType name="order">
+    <xs:annotation>
+      <xs:documentation source="https://example.com/order" xml:lang="fr">Une <b>commande</b> &amp; ses lignes.</xs:documentation>
+    </xs:annotation>
+    <xs:complexContent>
+      <xs:extension base="base">
+        <xs:annotation><xs:appinfo source="form"><ui:widget ui:kind="panel" label="a&quot;b">x</ui:widget></xs:appinfo></xs:annotation>
+        <xs:attributeGroup ref="audit"/>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="order" type="order">
+    <xs:annotation><xs:documentation>Root element.</xs:documentation></xs:annotation>
+    <xs:key name="orderKey">
+      <xs:annotation><xs:documentation>Orders are unique by id.</xs:documentation></xs:annotation>
+      <xs:selector xpath="."/>
+      <xs:field xpath="@id"/>
+    </xs:key>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{RetainAnnotations: true}, xsd.Bytes("order.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("CompileWithOptions() error = %v", err)
+	}
+	model, err := engine.Model()
+	if err != nil {
+		t.Fatalf("Model() error = %v", err)
+	}
+	if want := []xsd.Documentation{{Lang: "en", Text: "Orders schema.", Content: "Orders schema."}}; !reflect.DeepEqual(model.Annotation.Documentation, want) {
+		t.Fatalf("schema documentation = %+v, want %+v", model.Annotation.Documentation, want)
+	}
+	order := model.Type(xml.Name{Local: "order"})
+	wantType := xsd.Annotation{
+		Documentation: []xsd.Documentation{{
+			Source:  "https://example.com/order",
+			Lang:    "fr",
+			Text:    "Une commande & ses lignes.",
+			Content: "Une <b>commande</b> &amp; ses lignes.",
+		}},
+		AppInfo: []xsd.AppInfo{{
+			Source:  "form",
+			Content: `<ui:widget xmlns:ui="urn:ui" ui:kind="panel" label="a&quot;b">x</ui:widget>`,
+		}},
+	}
+	if !reflect.DeepEqual(order.Annotation, wantType) {
+		t.Fatalf("order annotation = %+v, want %+v", order.Annotation, wantType)
+	}
+	uses := make(map[string]string)
+	for _, use := range order.Attributes {
+		a := use.Annotation
+		switch {
+		case len(a.Documentation) != 0:
+			uses[use.Name.Local] = a.Documentation[0].Text
+		case len(a.AppInfo) != 0:
+			uses[use.Name.Local] = a.AppInfo[0].Content
+		}
+	}
+	if want := map[string]string{"by": "Author of the change.", "id": `<ui:hidden xmlns:ui="urn:ui"></ui:hidden>`}; !reflect.DeepEqual(uses, want) {
+		t.Fatalf("order attribute annotations = %v, want %v", uses, want)
+	}
+	elem := model.Element(xml.Name{Local: "order"})
+	if got := elem.Annotation.Documentation; len(got) != 1 || got[0].Text != "Root element." {
+		t.Fatalf("element documentation = %+v", got)
+	}
+	if got := elem.IdentityConstraints[0].Annotation.Documentation; len(got) != 1 || got[0].Text != "Orders are unique by id." {
+		t.Fatalf("key documentation = %+v", got)
+	}
+	if got := model.AttributeGroups[0].Attributes[0].Annotation.Documentation; len(got) != 1 || got[0].Text != "Author of the change." {
+		t.Fatalf("attribute group use documentation = %+v", got)
+	}
+
+	plain, err := xsd.Compile(context.Background(), xsd.Bytes("order.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	model, err = plain.Model()
+	if err != nil {
+		t.Fatalf("Model() error = %v", err)
+	}
+	if got := model.Type(xml.Name{Local: "order"}).Annotation; !reflect.DeepEqual(got, xsd.Annotation{}) {
+		t.Fatalf("annotation without RetainAnnotations = %+v, want none", got)
+	}
+}
+
+func TestRetainAnnotationsBoundsPayloadByTokenLimit(t *testing.T) {
+	t.Parallel()
+
+	schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root"><xs:annotation><xs:appinfo>` + strings.Repeat("<a>x</a>", 64) + `</xs:appinfo></xs:annotation></xs:element>
+</xs:schema>`
+	opts := xsd.CompileOptions{MaxSchemaTokenBytes: 256}
+	if _, err := xsd.CompileWithOptions(context.Background(), opts, xsd.Bytes("schema.xsd", []byte(schema))); err != nil {
+		t.Fatalf("CompileWithOptions() without retention error = %v", err)
+	}
+	opts.RetainAnnotations = true
+	_, err := xsd.CompileWithOptions(context.Background(), opts, xsd.Bytes("schema.xsd", []byte(schema)))
+	expectCategoryCode(t, err, xsderrors.CategorySchemaParse, xsderrors.CodeSchemaLimit)
+}
+
+func TestLoadEngineRestoresMarshaledEngine(t *testing.T) {
+	t.Parallel()
+
+	schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:annotation><xs:documentation>An order.</xs:documentation></xs:annotation>
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="sku" maxOccurs="unbounded">
+          <xs:simpleType><xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}-\d{4}"/></xs:restriction></xs:simpleType>
+        </xs:element>
+      </xs:sequence>
+      <xs:attribute name="total" use="required">
+        <xs:simpleType><xs:restriction base="xs:decimal"><xs:minInclusive value="0.01"/></xs:restriction></xs:simpleType>
+      </xs:attribute>
+    </xs:complexType>
+    <xs:unique name="skus"><xs:selector xpath="sku"/><xs:field xpath="."/></xs:unique>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{RetainAnnotations: true, RetainSourceLocations: true}, xsd.Bytes("order.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := engine.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := xsd.LoadEngine(context.Background(), data)
+	if err != nil {
+		t.Fatalf("LoadEngine() error = %v", err)
+	}
+	documents := map[string]bool{
+		`<order total="1.50"><sku>AB-1234</sku><sku>CD-5678</sku></order>`: true,
+		`<order total="0"><sku>AB-1234</sku></order>`:                      false,
+		`<order total="1"><sku>ab-1234</sku></order>`:                      false,
+		`<order total="1"><sku>AB-1234</sku><sku>AB-1234</sku></order>`:    false,
+		`<order><sku>AB-1234</sku></order>`:                                false,
+	}
+	for doc, valid := range documents {
+		want := engine.Validate(context.Background(), strings.NewReader(doc))
+		got := loaded.Validate(context.Background(), strings.NewReader(doc))
+		if (got == nil) != valid || fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("loaded Validate(%s) = %v, compiled = %v", doc, got, want)
+		}
+		if got != nil && (schemaLocation(want) == nil || !reflect.DeepEqual(schemaLocation(got), schemaLocation(want))) {
+			t.Errorf("loaded Validate(%s) schema location = %+v, compiled = %+v", doc, schemaLocation(got), schemaLocation(want))
+		}
+	}
+	model, err := loaded.Model()
+	if err != nil {
+		t.Fatalf("Model() error = %v", err)
+	}
+	order := model.Element(xml.Name{Local: "order"})
+	if order == nil || len(order.Annotation.Documentation) != 1 || order.Annotation.Documentation[0].Text != "An order." {
+		t.Fatalf("loaded order annotation = %+v", order)
+	}
+	again, err := loaded.MarshalBinary()
+	if err != nil || !bytes.Equal(again, data) {
+		t.Fatalf("loaded MarshalBinary() = %d bytes, %v; want the loaded snapshot", len(again), err)
+	}
+}
+
+func TestMarshalBinaryDoesNotReadSources(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "schema.xsd")
+	if err := os.WriteFile(path, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="root" type="xs:int"/></xs:schema>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := xsd.Compile(context.Background(), xsd.File(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := engine.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() after removing the schema file error = %v", err)
+	}
+	loaded, err := xsd.LoadEngine(context.Background(), data)
+	if err != nil {
+		t.Fatalf("LoadEngine() error = %v", err)
+	}
+	if err := loaded.Validate(context.Background(), strings.NewReader(`<root>x</root>`)); err == nil {
+		t.Fatal("loaded Validate(<root>x</root>) = nil, want an xs:int error")
+	}
+}
+
+func TestLoadEngineRejectsDamagedSnapshots(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root" type="xs:string"/>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := engine.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := bytes.Clone(data)
+	corrupted[len(corrupted)-1] ^= 0xff
+	for name, snapshot := range map[string][]byte{
+		"empty":     nil,
+		"truncated": data[:len(data)-1],
+		"corrupted": corrupted,
+		"schema":    []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`),
+	} {
+		_, err := xsd.LoadEngine(context.Background(), snapshot)
+		if err == nil {
+			t.Fatalf("LoadEngine(%s) succeeded", name)
+		}
+		expectCategoryCode(t, err, xsderrors.CategorySchemaCompile, xsderrors.CodeSchemaSnapshot)
+	}
+}
+
+func TestLoadEngineTablesRestoresEngine(t *testing.T) {
+	t.Parallel()
+
+	schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:annotation><xs:documentation>An order.</xs:documentation></xs:annotation>
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="sku" maxOccurs="unbounded">
+          <xs:simpleType><xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}-\d{4}"/></xs:restriction></xs:simpleType>
+        </xs:element>
+      </xs:sequence>
+      <xs:attribute name="total" use="required">
+        <xs:simpleType><xs:restriction base="xs:decimal"><xs:minInclusive value="0.01"/></xs:restriction></xs:simpleType>
+      </xs:attribute>
+      <xs:attribute name="state" default="open">
+        <xs:simpleType><xs:restriction base="xs:token"><xs:enumeration value="open"/><xs:enumeration value="closed"/></xs:restriction></xs:simpleType>
+      </xs:attribute>
+      <xs:assert test="count(sku) le 2"/>
+    </xs:complexType>
+    <xs:unique name="skus"><xs:selector xpath="sku"/><xs:field xpath="."/></xs:unique>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true, RetainAnnotations: true, RetainSourceLocations: true}, xsd.Bytes("order.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := engine.Tables()
+	if err != nil {
+		t.Fatalf("Tables() error = %v", err)
+	}
+	loaded, err := xsd.LoadEngineTables(context.Background(), tables)
+	if err != nil {
+		t.Fatalf("LoadEngineTables() error = %v", err)
+	}
+	documents := map[string]bool{
+		`<order total="1.50" state="closed"><sku>AB-1234</sku><sku>CD-5678</sku></order>`: true,
+		`<order total="0"><sku>AB-1234</sku></order>`:                                     false,
+		`<order total="1" state="lost"><sku>AB-1234</sku></order>`:                        false,
+		`<order total="1"><sku>ab-1234</sku></order>`:                                     false,
+		`<order total="1"><sku>AB-1234</sku><sku>AB-1234</sku></order>`:                   false,
+		`<order total="1"><sku>AB-1234</sku><sku>CD-5678</sku><sku>EF-9012</sku></order>`: false,
+	}
+	for doc, valid := range documents {
+		want := engine.Validate(context.Background(), strings.NewReader(doc))
+		got := loaded.Validate(context.Background(), strings.NewReader(doc))
+		if (got == nil) != valid || fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("loaded Validate(%s) = %v, compiled = %v", doc, got, want)
+		}
+	}
+	want, err := engine.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.MarshalBinary()
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("loaded MarshalBinary() = %d bytes, %v; want the compiled snapshot", len(got), err)
+	}
+}
+
+func TestLoadEngineTablesRejectsMalformedTables(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root" type="xs:string"/>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, damage := range map[string]func(*schematables.Schema) *schematables.Schema{
+		"nil": func(*schematables.Schema) *schematables.Schema { return nil },
+		"version": func(tables *schematables.Schema) *schematables.Schema {
+			tables.Version++
+			return tables
+		},
+		"literal_reference": func(tables *schematables.Schema) *schematables.Schema {
+			tables.SimpleTypes[0].Facets.Bounds[0] = uint32(len(tables.Literals) + 1)
+			return tables
+		},
+		"type_reference": func(tables *schematables.Schema) *schematables.Schema {
+			tables.Elements[0].Type.Kind = 9
+			return tables
+		},
+	} {
+		tables, err := engine.Tables()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = xsd.LoadEngineTables(context.Background(), damage(tables))
+		if err == nil {
+			t.Fatalf("LoadEngineTables(%s) succeeded", name)
+		}
+		expectCategoryCode(t, err, xsderrors.CategorySchemaCompile, xsderrors.CodeSchemaSnapshot)
+	}
+}
+
+func TestValidateOptionsSchemaLocationResolverLoadsDocumentHints(t *testing.T) {
+	const envelope = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:env" elementFormDefault="qualified">
+  <xs:element name="envelope">
+    <xs:complexType><xs:sequence><xs:any processContents="strict"/></xs:sequence></xs:complexType>
+  </xs:element>
+</xs:schema>`
+	const payload = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:payload">
+  <xs:element name="order" type="xs:int"/>
+</xs:schema>`
+	const local = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="note" type="xs:string"/></xs:schema>`
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("envelope.xsd", []byte(envelope)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	calls := map[string]int{}
+	resolver := xsd.ResolverFunc(func(_ context.Context, base, location string) (xsd.SchemaSource, error) {
+		mu.Lock()
+		calls[location]++
+		mu.Unlock()
+		if base != "" {
+			return xsd.SchemaSource{}, errors.New("unexpected base " + base)
+		}
+		switch location {
+		case "payload.xsd":
+			return xsd.Bytes("payload.xsd", []byte(payload)), nil
+		case "local.xsd":
+			return xsd.Bytes("local.xsd", []byte(local)), nil
+		default:
+			return xsd.SchemaSource{}, xsderrors.ErrSchemaNotFound
+		}
+	})
+	hinted := func(hint, body string) string {
+		return `<e:envelope xmlns:e="urn:env" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" ` + hint + `>` + body + `</e:envelope>`
+	}
+	valid := hinted(`xsi:schemaLocation="urn:payload payload.xsd"`, `<p:order xmlns:p="urn:payload">7</p:order>`)
+	opts := xsd.ValidateOptions{SchemaLocationResolver: resolver}
+
+	expectCategoryCode(t, engine.Validate(context.Background(), strings.NewReader(valid)), xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedSchemaHint)
+	if err := engine.ValidateWithOptions(context.Background(), strings.NewReader(valid), opts); err != nil {
+		t.Fatalf("ValidateWithOptions() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		doc      string
+		category xsderrors.Category
+		code     xsderrors.Code
+	}{
+		{
+			name:     "invalid payload",
+			doc:      hinted(`xsi:schemaLocation="urn:payload payload.xsd"`, `<p:order xmlns:p="urn:payload">seven</p:order>`),
+			category: xsderrors.CategoryValidation,
+			code:     xsderrors.CodeValidationFacet,
+		},
+		{
+			name: "no namespace hint",
+			doc:  hinted(`xsi:noNamespaceSchemaLocation="local.xsd"`, `<note>text</note>`),
+		},
+		{
+			name:     "unresolved hint",
+			doc:      hinted(`xsi:schemaLocation="urn:payload missing.xsd"`, `<p:order xmlns:p="urn:payload">7</p:order>`),
+			category: xsderrors.CategoryValidation,
+			code:     xsderrors.CodeValidationElement,
+		},
+		{
+			name:     "namespace mismatch",
+			doc:      hinted(`xsi:schemaLocation="urn:other payload.xsd"`, `<p:order xmlns:p="urn:payload">7</p:order>`),
+			category: xsderrors.CategorySchemaCompile,
+			code:     xsderrors.CodeSchemaReference,
+		},
+		{
+			name: "declared namespace is not reloaded",
+			doc:  hinted(`xsi:schemaLocation="urn:env missing-envelope.xsd urn:payload payload.xsd"`, `<p:order xmlns:p="urn:payload">7</p:order>`),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := engine.ValidateWithOptions(context.Background(), strings.NewReader(test.doc), opts)
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("ValidateWithOptions() error = %v", err)
+				}
+				return
+			}
+			expectCategoryCode(t, err, test.category, test.code)
+		})
+	}
+	if calls["missing-envelope.xsd"] != 0 {
+		t.Fatal("hint for a namespace declared by the engine was resolved")
+	}
+
+	session, err := engine.NewSession(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	clear(calls)
+	mu.Unlock()
+	for range 2 {
+		if err := session.Validate(context.Background(), strings.NewReader(valid)); err != nil {
+			t.Fatalf("session Validate() error = %v", err)
+		}
+	}
+	if calls["payload.xsd"] != 1 {
+		t.Fatalf("payload.xsd resolved %d times, want cached by the session after first load", calls["payload.xsd"])
+	}
+	unhinted := `<e:envelope xmlns:e="urn:env"><p:order xmlns:p="urn:payload">7</p:order></e:envelope>`
+	expectCategoryCode(t, session.Validate(context.Background(), strings.NewReader(unhinted)), xsderrors.CategoryValidation, xsderrors.CodeValidationElement)
+}
+
+// mapResolver resolves locations to in-memory documents and counts the
+// resolutions.
+type mapResolver struct {
+	docs  map[string]string
+	calls atomic.Int64
+}
+
+func (r *mapResolver) ResolveSchema(_ context.Context, _, location string) (xsd.SchemaSource, error) {
+	r.calls.Add(1)
+	doc, ok := r.docs[location]
+	if !ok {
+		return xsd.SchemaSource{}, xsderrors.ErrSchemaNotFound
+	}
+	return xsd.Bytes(location, []byte(doc)), nil
+}
+
+func TestSchemaLocationEnginesAreCachedPerResolver(t *testing.T) {
+	const envelope = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:env" elementFormDefault="qualified">
+  <xs:element name="envelope">
+    <xs:complexType><xs:sequence><xs:any processContents="strict"/></xs:sequence></xs:complexType>
+  </xs:element>
+</xs:schema>`
+	payload := func(typ string) string {
+		return `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:payload">
+  <xs:element name="order" type="` + typ + `"/>
+</xs:schema>`
+	}
+	path := filepath.Join(t.TempDir(), "envelope.xsd")
+	if err := os.WriteFile(path, []byte(envelope), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{RetainSchemaDocuments: true}, xsd.File(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	doc := `<e:envelope xmlns:e="urn:env" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:payload payload.xsd"><p:order xmlns:p="urn:payload">seven</p:order></e:envelope>`
+	ints := &mapResolver{docs: map[string]string{"payload.xsd": payload("xs:int")}}
+	strs := &mapResolver{docs: map[string]string{"payload.xsd": payload("xs:string")}}
+	for range 2 {
+		err := engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), xsd.ValidateOptions{SchemaLocationResolver: ints})
+		expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationFacet)
+		if err := engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), xsd.ValidateOptions{SchemaLocationResolver: strs}); err != nil {
+			t.Fatalf("ValidateWithOptions() with another resolver error = %v", err)
+		}
+	}
+	if ints.calls.Load() != 1 || strs.calls.Load() != 1 {
+		t.Fatalf("resolutions = %d and %d, want each resolver cached on the engine after its first load", ints.calls.Load(), strs.calls.Load())
+	}
+}
+
+func TestSchemaLocationEnginesReadSourcesUnlessDocumentsAreRetained(t *testing.T) {
+	const envelope = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:env" elementFormDefault="qualified">
+  <xs:element name="envelope">
+    <xs:complexType><xs:sequence><xs:any processContents="strict"/></xs:sequence></xs:complexType>
+  </xs:element>
+</xs:schema>`
+	const payload = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:payload">
+  <xs:element name="order" type="xs:int"/>
+</xs:schema>`
+	doc := `<e:envelope xmlns:e="urn:env" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:payload payload.xsd"><p:order xmlns:p="urn:payload">7</p:order></e:envelope>`
+	for _, retain := range []bool{false, true} {
+		var opens atomic.Int64
+		source := xsd.Open("envelope.xsd", func(context.Context) (io.ReadCloser, error) {
+			opens.Add(1)
+			return io.NopCloser(strings.NewReader(envelope)), nil
+		})
+		engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{RetainSchemaDocuments: retain}, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolver := &mapResolver{docs: map[string]string{"payload.xsd": payload}}
+		if err := engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), xsd.ValidateOptions{SchemaLocationResolver: resolver}); err != nil {
+			t.Fatalf("RetainSchemaDocuments=%v: ValidateWithOptions() error = %v", retain, err)
+		}
+		want := int64(2)
+		if retain {
+			want = 1
+		}
+		if got := opens.Load(); got != want {
+			t.Fatalf("RetainSchemaDocuments=%v: envelope.xsd opened %d times, want %d", retain, got, want)
+		}
+	}
+}
+
+func TestMaxIdentityEntriesRejectsPendingSelectionBeforeItsEnd(t *testing.T) {
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="A"><xs:sequence><xs:element ref="a" minOccurs="0"/></xs:sequence><xs:attribute name="id" use="required"/></xs:complexType>
+  <xs:element name="a" type="A"/>
+  <xs:element name="root">
+    <xs:complexType><xs:sequence><xs:element ref="a" minOccurs="0"/></xs:sequence></xs:complexType>
+    <xs:key name="ids"><xs:selector xpath=".//a"/><xs:field xpath="@id"/></xs:key>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := engine.NewSession(xsd.ValidateOptions{MaxIdentityEntries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := `<root><a id="one"><a id="two"></a></a></root>`
+	reader := &oneByteCountingReader{data: doc}
+	err = session.Validate(context.Background(), reader)
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationLimit)
+	if firstClose := strings.Index(doc, `</a>`); reader.off > firstClose {
+		t.Fatalf("validation consumed %d bytes, want at most %d before the first selected element end", reader.off, firstClose)
+	}
+	if err := session.Validate(context.Background(), strings.NewReader(`<root><a id="one"/></root>`)); err != nil {
+		t.Fatalf("session reuse after pending identity limit: %v", err)
+	}
+}
+
+func TestCompileOptionsAggregateSchemaSetLimits(t *testing.T) {
+	t.Parallel()
+
+	rootData := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:include schemaLocation="child.xsd"/><xs:include schemaLocation="child.xsd"/></xs:schema>`)
+	childData := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`)
+	resolver := xsd.ResolverFunc(func(_ context.Context, _, location string) (xsd.SchemaSource, error) {
+		if location == "child.xsd" {
+			return xsd.Bytes("child.xsd", childData), nil
+		}
+		return xsd.SchemaSource{}, xsderrors.ErrSchemaNotFound
+	})
+	root := xsd.Bytes("root.xsd", rootData).WithResolver(resolver)
+	// The duplicate reference is resolved and read again to verify that the
+	// resolver did not return different content for the same document identity.
+	totalBytes := int64(len(rootData) + 2*len(childData))
+
+	tests := []struct {
+		name    string
+		opts    xsd.CompileOptions
+		wantErr bool
+	}{
+		{name: "exact source count", opts: xsd.CompileOptions{MaxSchemaSources: 2}},
+		{name: "source count exceeded", opts: xsd.CompileOptions{MaxSchemaSources: 1}, wantErr: true},
+		{name: "exact total bytes", opts: xsd.CompileOptions{MaxSchemaTotalBytes: totalBytes}},
+		{name: "total bytes exceeded", opts: xsd.CompileOptions{MaxSchemaTotalBytes: totalBytes - 1}, wantErr: true},
+		{name: "exact references", opts: xsd.CompileOptions{MaxSchemaReferences: 2}},
+		{name: "references exceeded", opts: xsd.CompileOptions{MaxSchemaReferences: 1}, wantErr: true},
+		{name: "exact target contexts", opts: xsd.CompileOptions{MaxSchemaTargetContexts: 2}},
+		{name: "target contexts exceeded", opts: xsd.CompileOptions{MaxSchemaTargetContexts: 1}, wantErr: true},
+		{name: "exact instantiated nodes", opts: xsd.CompileOptions{MaxSchemaInstantiatedNodes: 4}},
+		{name: "instantiated nodes exceeded", opts: xsd.CompileOptions{MaxSchemaInstantiatedNodes: 3}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := xsd.CompileWithOptions(context.Background(), tt.opts, root)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("CompileWithOptions() error = %v", err)
+				}
+				return
+			}
+			if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeSchemaLimit {
+				t.Fatalf("CompileWithOptions() error = %v, want schema limit", err)
+			}
+		})
+	}
+}
+
+func TestCompileExplicitSourceLimitPrecedesSameIdentityOpeners(t *testing.T) {
+	t.Parallel()
+
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`
+	openCalls := 0
+	sourceFor := func() xsd.SchemaSource {
+		return xsd.Open("same.xsd", func(context.Context) (io.ReadCloser, error) {
+			openCalls++
+			return io.NopCloser(strings.NewReader(schema)), nil
+		})
+	}
+	_, err := xsd.CompileWithOptions(context.Background(),
+		xsd.CompileOptions{MaxSchemaSources: 1},
+		sourceFor(),
+		sourceFor())
+
+	if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeSchemaLimit {
+		t.Fatalf("CompileWithOptions() error = %v, want schema limit", err)
+	}
+	if openCalls != 0 {
+		t.Fatalf("schema opener calls = %d, want 0", openCalls)
+	}
+}
+
+func TestCompileOptionsSubstitutionClosureLimit(t *testing.T) {
+	t.Parallel()
+
+	schema := xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="h0" type="xs:string"/>
+  <xs:element name="h1" substitutionGroup="h0"/>
+  <xs:element name="h2" substitutionGroup="h1"/>
+  <xs:element name="h3" substitutionGroup="h2"/>
+</xs:schema>`))
+	if _, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{MaxSubstitutionClosureEntries: 6}, schema); err != nil {
+		t.Fatalf("CompileWithOptions(exact limit) error = %v", err)
+	}
+	_, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{MaxSubstitutionClosureEntries: 5}, schema)
+	if xerr, ok := errors.AsType[*xsderrors.Error](err); !ok || xerr.Code != xsderrors.CodeSchemaLimit {
+		t.Fatalf("CompileWithOptions(over limit) error = %v, want schema limit", err)
+	}
+}
+
+func TestBytesSourceCopiesInput(t *testing.T) {
+	data := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="root"/></xs:schema>`)
+	source := xsd.Bytes("schema.xsd", data)
+	for i := range data {
+		data[i] = 0
+	}
+	if _, err := xsd.Compile(context.Background(), source); err != nil {
+		t.Fatalf("Compile(Bytes(...)) error after caller mutation = %v", err)
+	}
+}
+
+func TestCopiedEngineSharesPublishedSchema(t *testing.T) {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="root" type="xs:int"/></xs:schema>`)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	engineCopy := *engine
+
+	var wg sync.WaitGroup
+	for _, e := range []*xsd.Engine{engine, &engineCopy} {
+		wg.Add(1)
+		go func(e *xsd.Engine) {
+			defer wg.Done()
+			if err := e.Validate(context.Background(), strings.NewReader(`<root>7</root>`)); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		}(e)
+	}
+	wg.Wait()
+}
+
+// TestEngineConcurrentValidation is the executable form of the runtime schema
+// sharing contract: sessions only read the schema published to an Engine. The
+// schema routes workers through identity key/keyref tables, attribute default
+// and fixed values, a variable-length pattern facet, a wide DFA row index, a
+// substitution group, and xs:ID/xs:IDREF tracking.
+func TestEngineConcurrentValidation(t *testing.T) {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="head" type="xs:string" abstract="true"/>
+  <xs:element name="sub" type="xs:string" substitutionGroup="head"/>
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="item" maxOccurs="unbounded">
+          <xs:complexType>
             <xs:choice>
               <xs:element name="c1" type="xs:string"/>
               <xs:element name="c2" type="xs:string"/>
@@ -1445,127 +3021,927 @@ func TestEngineConcurrentValidation(t *testing.T) {
 		wg.Go(func() {
 			session, err := engine.NewSession(xsd.ValidateOptions{})
 			if err != nil {
-				t.Errorf("NewSession() error = %v", err)
-				return
+				t.Errorf("NewSession() error = %v", err)
+				return
+			}
+			for range 25 {
+				check("session", func(r io.Reader) error { return session.Validate(context.Background(), r) })
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestCopiedSessionResolvesQNameValuesWithCopyState(t *testing.T) {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root" type="xs:QName"/>
+</xs:schema>`)))
+
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	session, err := engine.NewSession(xsd.ValidateOptions{})
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	copySession := *session
+	if err := copySession.Validate(context.Background(), strings.NewReader(`<root xmlns:p="urn:test">p:item</root>`)); err != nil {
+		t.Fatalf("copied Session.Validate() error = %v", err)
+	}
+}
+
+func TestValidationPathsPreserveNameSpelling(t *testing.T) {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="known" type="xs:int"/>
+  <xs:element name="root">
+    <xs:complexType><xs:sequence><xs:any processContents="lax" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
+  </xs:element>
+  <xs:element name="strictRoot">
+    <xs:complexType><xs:sequence><xs:any processContents="strict" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
+  </xs:element>
+</xs:schema>`)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		doc  string
+		path string
+	}{
+		{name: "known", doc: `<root><known>x</known></root>`, path: "/root/known"},
+		{name: "unknown local", doc: `<root><unknown></other></root>`, path: "/root/unknown"},
+		{name: "unknown namespaced lax", doc: `<root><o:unknown xmlns:o="urn:o"></o:other></root>`, path: "/root/{urn:o}unknown"},
+		{name: "skipped", doc: `<strictRoot><o:unknown xmlns:o="urn:o"></o:other></strictRoot>`, path: "/strictRoot/unknown"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(tc.doc))
+			x, ok := errors.AsType[*xsderrors.Error](err)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want structured error", err)
+			}
+			if x.Path != tc.path {
+				t.Fatalf("path = %q, want %q; err=%v", x.Path, tc.path, err)
+			}
+		})
+	}
+}
+
+func TestLaxWildcardValidationAllocationsMatchKnownName(t *testing.T) {
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:s" xmlns:s="urn:s" elementFormDefault="qualified">
+  <xs:element name="known" type="xs:anyType"/>
+  <xs:element name="root">
+    <xs:complexType><xs:sequence><xs:any processContents="lax" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
+  </xs:element>
+</xs:schema>`)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	known, err := engine.NewSession(xsd.ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lax, err := engine.NewSession(xsd.ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	knownDoc := `<s:root xmlns:s="urn:s"><k:known xmlns:k="urn:s"/></s:root>`
+	laxDoc := `<s:root xmlns:s="urn:s"><o:unknown xmlns:o="urn:o"/></s:root>`
+	for range 10 {
+		if err := known.Validate(context.Background(), strings.NewReader(knownDoc)); err != nil {
+			t.Fatal(err)
+		}
+		if err := lax.Validate(context.Background(), strings.NewReader(laxDoc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	knownAllocs := testing.AllocsPerRun(100, func() {
+		if err := known.Validate(context.Background(), strings.NewReader(knownDoc)); err != nil {
+			panic(err)
+		}
+	})
+	laxAllocs := testing.AllocsPerRun(100, func() {
+		if err := lax.Validate(context.Background(), strings.NewReader(laxDoc)); err != nil {
+			panic(err)
+		}
+	})
+	if laxAllocs != knownAllocs {
+		t.Fatalf("lax wildcard allocations = %.0f, known name = %.0f", laxAllocs, knownAllocs)
+	}
+}
+
+func expectCategoryCode(t *testing.T, err error, category xsderrors.Category, code xsderrors.Code) {
+	t.Helper()
+	x, ok := errors.AsType[*xsderrors.Error](err)
+	if !ok {
+		t.Fatalf("error %v is not *xsderrors.Error", err)
+	}
+	if x.Category != category || x.Code != code {
+		t.Fatalf("error = %s/%s, want %s/%s; err=%v", x.Category, x.Code, category, code, err)
+	}
+}
+
+const sampleSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+  xmlns:o="urn:orders" targetNamespace="urn:orders" elementFormDefault="qualified">
+  <xs:simpleType name="sku">
+    <xs:restriction base="xs:token"><xs:pattern value="[A-Z]{2}-\d{4}"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="price">
+    <xs:restriction base="xs:decimal">
+      <xs:minExclusive value="0"/><xs:maxInclusive value="999.99"/><xs:fractionDigits value="2"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="status">
+    <xs:restriction base="xs:string"><xs:enumeration value="open"/><xs:enumeration value="closed"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="kind">
+    <xs:restriction base="xs:QName"><xs:enumeration value="o:retail"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="tags"><xs:list itemType="xs:NCName"/></xs:simpleType>
+  <xs:simpleType name="quantity"><xs:union memberTypes="xs:positiveInteger o:status"/></xs:simpleType>
+  <xs:complexType name="party" abstract="true">
+    <xs:sequence><xs:element name="name" type="xs:string"/></xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="person">
+    <xs:complexContent>
+      <xs:extension base="o:party">
+        <xs:sequence><xs:element name="born" type="xs:date" minOccurs="0"/></xs:sequence>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="note" type="xs:string" abstract="true"/>
+  <xs:element name="comment" type="xs:string" substitutionGroup="o:note"/>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="customer" type="o:party"/>
+        <xs:choice>
+          <xs:element name="pickup" type="xs:dateTime"/>
+          <xs:sequence>
+            <xs:element name="street" type="xs:string"/>
+            <xs:element name="city" type="xs:string"/>
+          </xs:sequence>
+        </xs:choice>
+        <xs:element name="line" maxOccurs="unbounded">
+          <xs:complexType>
+            <xs:sequence>
+              <xs:element name="sku" type="o:sku"/>
+              <xs:element name="qty" type="o:quantity"/>
+              <xs:element name="price" type="o:price"/>
+            </xs:sequence>
+            <xs:attribute name="id" type="xs:ID" use="required"/>
+            <xs:attribute name="tags" type="o:tags"/>
+          </xs:complexType>
+        </xs:element>
+        <xs:element ref="o:note" minOccurs="0" maxOccurs="2"/>
+        <xs:any namespace="##other" processContents="lax" minOccurs="0"/>
+      </xs:sequence>
+      <xs:attribute name="status" type="o:status" use="required"/>
+      <xs:attribute name="kind" type="o:kind"/>
+      <xs:attribute name="version" type="xs:string" fixed="1.0"/>
+    </xs:complexType>
+    <xs:key name="skus"><xs:selector xpath="o:line"/><xs:field xpath="o:sku"/></xs:key>
+  </xs:element>
+</xs:schema>`
+
+func TestSampleGeneratesValidInstances(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(sampleSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := xml.Name{Space: "urn:orders", Local: "order"}
+	for _, opts := range []xsd.SampleOptions{
+		{},
+		{Choice: xsd.SampleChoiceFirst},
+		{Optional: true, Repeat: 3},
+		{Choice: xsd.SampleChoiceRandom, Seed: 1, Optional: true, Repeat: 4},
+		{Choice: xsd.SampleChoiceRandom, Seed: 2, Repeat: 2},
+	} {
+		doc, err := engine.Sample(context.Background(), root, opts)
+		if err != nil {
+			t.Fatalf("Sample(%+v) error = %v", opts, err)
+		}
+		if err := engine.Validate(context.Background(), bytes.NewReader(doc)); err != nil {
+			t.Fatalf("Sample(%+v) = %s, does not validate: %v", opts, doc, err)
+		}
+		if opts.Repeat > 1 && bytes.Count(doc, []byte(":line ")) != opts.Repeat {
+			t.Fatalf("Sample(%+v) = %s, want %d lines", opts, doc, opts.Repeat)
+		}
+	}
+}
+
+func TestSampleMinimalInstance(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(sampleSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := engine.Sample(context.Background(), xml.Name{Space: "urn:orders", Local: "order"}, xsd.SampleOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<ns1:order xmlns:ns1="urn:orders" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" status="open">
+  <ns1:customer xsi:type="ns1:person">
+    <ns1:name>sample</ns1:name>
+  </ns1:customer>
+  <ns1:pickup>2000-01-01T00:00:00</ns1:pickup>
+  <ns1:line id="sample">
+    <ns1:sku>AA-0000</ns1:sku>
+    <ns1:qty>1</ns1:qty>
+    <ns1:price>1</ns1:price>
+  </ns1:line>
+</ns1:order>
+`
+	if string(doc) != want {
+		t.Fatalf("Sample() =\n%s\nwant\n%s", doc, want)
+	}
+}
+
+func TestSampleIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(sampleSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := xml.Name{Space: "urn:orders", Local: "order"}
+	opts := xsd.SampleOptions{Choice: xsd.SampleChoiceRandom, Seed: 42, Optional: true, Repeat: 3}
+	first, err := engine.Sample(context.Background(), root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := engine.Sample(context.Background(), root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, again) {
+		t.Fatalf("Sample() with equal seeds differs:\n%s\n%s", first, again)
+	}
+}
+
+func TestSampleReportsUnsupportedSchemas(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{XSD11: true}, xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root">
+    <xs:simpleType>
+      <xs:restriction base="xs:integer"><xs:assertion test="$value = 12345"/></xs:restriction>
+    </xs:simpleType>
+  </xs:element>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = engine.Sample(context.Background(), xml.Name{Local: "root"}, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedSample)
+
+	_, err = engine.Sample(context.Background(), xml.Name{Local: "missing"}, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryValidation, xsderrors.CodeValidationRoot)
+}
+
+func TestSampleGeneratesNullableRepetitions(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{
+		"(a?)*",
+		"(a|)*",
+		`(((((boy)|(girl))[0-1][x-z]{2})?)|(man|woman)[0-1]?[y|n])*`,
+		`(([\.\\\?\*\+\{\}\[\]\(\)\|]?)*)+`,
+	} {
+		engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root">
+    <xs:simpleType><xs:restriction base="xs:string"><xs:pattern value="`+pattern+`"/></xs:restriction></xs:simpleType>
+  </xs:element>
+</xs:schema>`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, opts := range []xsd.SampleOptions{{}, {Choice: xsd.SampleChoiceRandom, Seed: 7}} {
+			if _, err := engine.Sample(context.Background(), xml.Name{Local: "root"}, opts); err != nil {
+				t.Fatalf("Sample(%q) error = %v", pattern, err)
+			}
+			if _, err := engine.SampleInvalid(context.Background(), xml.Name{Local: "root"}, opts); err != nil {
+				expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedSample)
+			}
+		}
+	}
+}
+
+func TestSampleStopsWhenCanceled(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(sampleSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	root := xml.Name{Space: "urn:orders", Local: "order"}
+	_, err = engine.Sample(ctx, root, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryCanceled, xsderrors.CodeValidationCanceled)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Sample() error = %v, want context.Canceled", err)
+	}
+	_, err = engine.SampleInvalid(ctx, root, xsd.SampleOptions{})
+	expectCategoryCode(t, err, xsderrors.CategoryCanceled, xsderrors.CodeValidationCanceled)
+}
+
+func TestSampleInvalidReportsExpectedCodes(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(sampleSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := xml.Name{Space: "urn:orders", Local: "order"}
+	codes := map[xsd.SampleMutation][]xsderrors.Code{
+		xsd.SampleMutationFacet:      {xsderrors.CodeValidationFacet},
+		xsd.SampleMutationOccurrence: {xsderrors.CodeValidationElement, xsderrors.CodeValidationContent},
+		xsd.SampleMutationIdentity:   {xsderrors.CodeValidationIdentity},
+		xsd.SampleMutationNil:        {xsderrors.CodeValidationNil},
+	}
+	for mutation, want := range codes {
+		for seed := range uint64(16) {
+			opts := xsd.SampleOptions{Seed: seed, Repeat: 2, Optional: seed%2 == 0, Choice: xsd.SampleChoice(seed % 3)}
+			got, err := engine.SampleInvalid(context.Background(), root, opts, mutation)
+			if err != nil {
+				t.Fatalf("SampleInvalid(%d, %+v) error = %v", mutation, opts, err)
 			}
-			for range 25 {
-				check("session", func(r io.Reader) error { return session.Validate(context.Background(), r) })
+			if got.Mutation != mutation || !slices.Contains(want, got.Code) || got.Path == "" {
+				t.Fatalf("SampleInvalid(%d, %+v) = mutation %d, code %s, path %q", mutation, opts, got.Mutation, got.Code, got.Path)
 			}
-		})
+			if err := engine.Validate(context.Background(), bytes.NewReader(got.Valid)); err != nil {
+				t.Fatalf("SampleInvalid(%d, %+v) valid instance error = %v\n%s", mutation, opts, err, got.Valid)
+			}
+			if err := engine.Validate(context.Background(), bytes.NewReader(got.Document)); !errorTreeHasCode(err, got.Code) {
+				t.Fatalf("SampleInvalid(%d, %+v) mutant error = %v, want %s\n%s", mutation, opts, err, got.Code, got.Document)
+			}
+			again, err := engine.SampleInvalid(context.Background(), root, opts, mutation)
+			if err != nil || !bytes.Equal(again.Document, got.Document) {
+				t.Fatalf("SampleInvalid(%d, %+v) is not deterministic: %v", mutation, opts, err)
+			}
+		}
 	}
-	wg.Wait()
 }
 
-func TestCopiedSessionResolvesQNameValuesWithCopyState(t *testing.T) {
-	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`
-<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
-  <xs:element name="root" type="xs:QName"/>
-</xs:schema>`)))
+func TestSampleInvalidReportsSchemasWithoutSites(t *testing.T) {
+	t.Parallel()
 
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root" type="xs:string" nillable="true"/>
+</xs:schema>`)))
 	if err != nil {
-		t.Fatalf("Compile() error = %v", err)
+		t.Fatal(err)
 	}
-	session, err := engine.NewSession(xsd.ValidateOptions{})
+	root := xml.Name{Local: "root"}
+	for _, mutation := range []xsd.SampleMutation{xsd.SampleMutationFacet, xsd.SampleMutationOccurrence, xsd.SampleMutationIdentity} {
+		_, err := engine.SampleInvalid(context.Background(), root, xsd.SampleOptions{}, mutation)
+		expectCategoryCode(t, err, xsderrors.CategoryUnsupported, xsderrors.CodeUnsupportedSample)
+	}
+	got, err := engine.SampleInvalid(context.Background(), root, xsd.SampleOptions{})
 	if err != nil {
-		t.Fatalf("NewSession() error = %v", err)
+		t.Fatalf("SampleInvalid() error = %v", err)
 	}
-	copySession := *session
-	if err := copySession.Validate(context.Background(), strings.NewReader(`<root xmlns:p="urn:test">p:item</root>`)); err != nil {
-		t.Fatalf("copied Session.Validate() error = %v", err)
+	if got.Mutation != xsd.SampleMutationNil || got.Path != "/root" {
+		t.Fatalf("SampleInvalid() = %+v", got)
 	}
 }
 
-func TestValidationPathsPreserveNameSpelling(t *testing.T) {
-	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`
-<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
-  <xs:element name="known" type="xs:int"/>
-  <xs:element name="root">
-    <xs:complexType><xs:sequence><xs:any processContents="lax" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
-  </xs:element>
-  <xs:element name="strictRoot">
-    <xs:complexType><xs:sequence><xs:any processContents="strict" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
+const jsonSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders"
+    targetNamespace="urn:orders" elementFormDefault="qualified">
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="id" type="xs:string"/>
+        <xs:element name="line" type="o:line" maxOccurs="unbounded"/>
+        <xs:element name="note" minOccurs="0">
+          <xs:complexType mixed="true">
+            <xs:sequence><xs:element name="em" type="xs:string" minOccurs="0"/></xs:sequence>
+          </xs:complexType>
+        </xs:element>
+        <xs:any namespace="##other" processContents="lax" minOccurs="0"/>
+      </xs:sequence>
+      <xs:attribute name="status" type="xs:token" use="required"/>
+      <xs:attribute name="urgent" type="xs:boolean" default="false"/>
+    </xs:complexType>
   </xs:element>
-</xs:schema>`)))
+  <xs:complexType name="line">
+    <xs:sequence>
+      <xs:element name="qty" type="xs:unsignedShort"/>
+      <xs:element name="price" type="o:money"/>
+      <xs:element name="weight" type="xs:double" minOccurs="0"/>
+      <xs:element name="sizes" type="o:sizes" minOccurs="0"/>
+      <xs:element name="ref" type="o:ref" minOccurs="0"/>
+      <xs:element name="gift" type="xs:string" nillable="true" minOccurs="0"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="money">
+    <xs:simpleContent>
+      <xs:extension base="xs:decimal"><xs:attribute name="currency" type="xs:string"/></xs:extension>
+    </xs:simpleContent>
+  </xs:complexType>
+  <xs:simpleType name="sizes"><xs:list itemType="xs:integer"/></xs:simpleType>
+  <xs:simpleType name="ref"><xs:union memberTypes="xs:int xs:NCName"/></xs:simpleType>
+</xs:schema>`
+
+const jsonDocument = `<order xmlns="urn:orders" status=" open ">
+  <id>A-1</id>
+  <line>
+    <qty>007</qty>
+    <price currency="EUR">9.50</price>
+    <weight>1e2</weight>
+    <sizes> 1 02 </sizes>
+    <ref>42</ref>
+    <gift xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"/>
+  </line>
+  <note>Ship <em>fast</em></note>
+  <t:trace xmlns:t="urn:trace" at="1">abc</t:trace>
+</order>`
+
+const jsonForm = `{"order":{"@status":"open","@urgent":false,"id":"A-1","line":[{"qty":7,"price":{"@currency":"EUR","#value":9.5},"weight":100,"sizes":[1,2],"ref":42,"gift":null}],"note":{"#text":"Ship ","em":"fast"},"{urn:trace}trace":{"@at":"1","#text":"abc"}}}
+`
+
+func TestXMLToJSONFollowsSchema(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(jsonSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := engine.XMLToJSON(context.Background(), &out, strings.NewReader(jsonDocument)); err != nil {
+		t.Fatalf("XMLToJSON() error = %v", err)
+	}
+	if out.String() != jsonForm {
+		t.Fatalf("XMLToJSON() =\n%s\nwant\n%s", out.String(), jsonForm)
+	}
+}
+
+func TestJSONToXMLRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(jsonSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Members out of schema order, and a line that repeats.
+	in := `{"order":{"line":[{"price":{"#value":1},"qty":2,"ref":"r1"},{"gift":null,"qty":3,"price":{"#value":4,"@currency":"USD"}}],"id":"B","@status":"held"}}`
+	var doc bytes.Buffer
+	if err := engine.JSONToXML(context.Background(), &doc, strings.NewReader(in)); err != nil {
+		t.Fatalf("JSONToXML() error = %v", err)
+	}
+	want := `<ns1:order xmlns:ns1="urn:orders" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" status="held"><ns1:id>B</ns1:id><ns1:line><ns1:qty>2</ns1:qty><ns1:price>1</ns1:price><ns1:ref>r1</ns1:ref></ns1:line><ns1:line><ns1:qty>3</ns1:qty><ns1:price currency="USD">4</ns1:price><ns1:gift xsi:nil="true"/></ns1:line></ns1:order>` + "\n"
+	if doc.String() != want {
+		t.Fatalf("JSONToXML() =\n%s\nwant\n%s", doc.String(), want)
+	}
+}
+
+func TestJSONConversionReportsErrors(t *testing.T) {
+	t.Parallel()
 
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(jsonSchema)))
 	if err != nil {
 		t.Fatal(err)
 	}
+	var out bytes.Buffer
+	err = engine.XMLToJSON(context.Background(), &out, strings.NewReader(`<order xmlns="urn:orders" status="open"><id>A</id></order>`))
+	if !errorTreeHasCode(err, xsderrors.CodeValidationContent) || out.Len() != 0 {
+		t.Fatalf("XMLToJSON() = %q, error = %v, want %s", out.String(), err, xsderrors.CodeValidationContent)
+	}
+
 	tests := []struct {
 		name string
-		doc  string
-		path string
+		json string
+		code xsderrors.Code
 	}{
-		{name: "known", doc: `<root><known>x</known></root>`, path: "/root/known"},
-		{name: "unknown local", doc: `<root><unknown></other></root>`, path: "/root/unknown"},
-		{name: "unknown namespaced lax", doc: `<root><o:unknown xmlns:o="urn:o"></o:other></root>`, path: "/root/{urn:o}unknown"},
-		{name: "skipped", doc: `<strictRoot><o:unknown xmlns:o="urn:o"></o:other></strictRoot>`, path: "/strictRoot/unknown"},
+		{name: "malformed", json: `{"order":`, code: xsderrors.CodeValidationJSON},
+		{name: "trailing", json: `{"order":{}} {}`, code: xsderrors.CodeValidationJSON},
+		{name: "two_roots", json: `{"order":{},"id":"A"}`, code: xsderrors.CodeValidationJSON},
+		{name: "object_attribute", json: `{"order":{"@status":{}}}`, code: xsderrors.CodeValidationJSON},
+		{name: "numeric_text", json: `{"order":{"note":{"#text":1}}}`, code: xsderrors.CodeValidationJSON},
+		{name: "invalid_document", json: `{"order":{"@status":"open","id":"A","line":[{"qty":-1,"price":1}]}}`, code: xsderrors.CodeValidationFacet},
 	}
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			err := engine.Validate(context.Background(), strings.NewReader(tc.doc))
-			x, ok := errors.AsType[*xsderrors.Error](err)
-			if !ok {
-				t.Fatalf("Validate() error = %v, want structured error", err)
-			}
-			if x.Path != tc.path {
-				t.Fatalf("path = %q, want %q; err=%v", x.Path, tc.path, err)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var doc bytes.Buffer
+			err := engine.JSONToXML(context.Background(), &doc, strings.NewReader(test.json))
+			if !errorTreeHasCode(err, test.code) || doc.Len() != 0 {
+				t.Fatalf("JSONToXML() = %q, error = %v, want %s", doc.String(), err, test.code)
 			}
 		})
 	}
 }
 
-func TestLaxWildcardValidationAllocationsMatchKnownName(t *testing.T) {
-	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(`
-<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:s" xmlns:s="urn:s" elementFormDefault="qualified">
-  <xs:element name="known" type="xs:anyType"/>
-  <xs:element name="root">
-    <xs:complexType><xs:sequence><xs:any processContents="lax" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
+func TestJSONToXMLRejectsInterleavedSequences(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("pairs.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="pairs">
+    <xs:complexType>
+      <xs:sequence maxOccurs="unbounded">
+        <xs:element name="a" type="xs:int"/>
+        <xs:element name="b" type="xs:int"/>
+      </xs:sequence>
+    </xs:complexType>
   </xs:element>
 </xs:schema>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bytes.Buffer
+	if err := engine.JSONToXML(context.Background(), &doc, strings.NewReader(`{"pairs":{"a":[1],"b":[2]}}`)); err != nil {
+		t.Fatalf("JSONToXML(one pair) error = %v", err)
+	}
+	doc.Reset()
+	err = engine.JSONToXML(context.Background(), &doc, strings.NewReader(`{"pairs":{"a":[1,3],"b":[2,4]}}`))
+	x, ok := errors.AsType[*xsderrors.Error](err)
+	if !ok || x.Code != xsderrors.CodeValidationJSON || !strings.Contains(x.Message, `"a", "b"`) || doc.Len() != 0 {
+		t.Fatalf("JSONToXML(two pairs) = %q, error = %v, want %s naming a and b", doc.String(), err, xsderrors.CodeValidationJSON)
+	}
+}
+
+func TestXMLToJSONChargesInstanceBytes(t *testing.T) {
+	t.Parallel()
 
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("list.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="r">
+    <xs:complexType><xs:sequence><xs:element name="a" type="xs:int" maxOccurs="unbounded"/></xs:sequence></xs:complexType>
+  </xs:element>
+</xs:schema>`)))
 	if err != nil {
 		t.Fatal(err)
 	}
-	known, err := engine.NewSession(xsd.ValidateOptions{})
+	doc := "<r>" + strings.Repeat("<a>1</a>", 20) + "</r>"
+	var out bytes.Buffer
+	if err := engine.XMLToJSONWithOptions(context.Background(), &out, strings.NewReader(doc), xsd.ValidateOptions{MaxInstanceBytes: int64(len(doc))}); err == nil {
+		t.Fatal("XMLToJSONWithOptions() = nil, want the JSON form to exceed MaxInstanceBytes")
+	} else if !errorTreeHasCode(err, xsderrors.CodeValidationLimit) || out.Len() != 0 {
+		t.Fatalf("XMLToJSONWithOptions() = %q, error = %v, want %s", out.String(), err, xsderrors.CodeValidationLimit)
+	}
+	if err := engine.XMLToJSONWithOptions(context.Background(), &out, strings.NewReader(doc), xsd.ValidateOptions{MaxInstanceBytes: 4 * int64(len(doc))}); err != nil {
+		t.Fatalf("XMLToJSONWithOptions() error = %v", err)
+	}
+}
+
+const jsonSchemaExport = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders"
+    targetNamespace="urn:orders" elementFormDefault="qualified">
+  <xs:element name="order" type="o:order">
+    <xs:key name="lineKey"><xs:selector xpath="o:line"/><xs:field xpath="@no"/></xs:key>
+  </xs:element>
+  <xs:complexType name="base">
+    <xs:sequence><xs:element name="id" type="o:code"/></xs:sequence>
+    <xs:attribute name="status" type="xs:token" use="required"/>
+  </xs:complexType>
+  <xs:complexType name="order">
+    <xs:complexContent>
+      <xs:extension base="o:base">
+        <xs:sequence>
+          <xs:element name="line" maxOccurs="10">
+            <xs:complexType>
+              <xs:choice>
+                <xs:element name="sku" type="o:code"/>
+                <xs:element name="text" type="xs:string"/>
+              </xs:choice>
+              <xs:attribute name="no" type="xs:positiveInteger"/>
+            </xs:complexType>
+          </xs:element>
+          <xs:element name="note" minOccurs="0">
+            <xs:complexType mixed="true">
+              <xs:sequence><xs:element name="em" type="xs:string" minOccurs="0"/></xs:sequence>
+            </xs:complexType>
+          </xs:element>
+          <xs:element name="total" type="o:small"/>
+        </xs:sequence>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:simpleType name="code">
+    <xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}-[0-9]{3}"/><xs:maxLength value="6"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="amount">
+    <xs:restriction base="xs:decimal"><xs:minInclusive value="0"/><xs:fractionDigits value="2"/><xs:totalDigits value="8"/></xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="small">
+    <xs:restriction base="o:amount"><xs:maxExclusive value="100"/></xs:restriction>
+  </xs:simpleType>
+</xs:schema>`
+
+func TestJSONSchemaExport(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("orders.xsd", []byte(jsonSchemaExport)))
 	if err != nil {
 		t.Fatal(err)
 	}
-	lax, err := engine.NewSession(xsd.ValidateOptions{})
+	export, err := engine.JSONSchema()
 	if err != nil {
 		t.Fatal(err)
 	}
-	knownDoc := `<s:root xmlns:s="urn:s"><k:known xmlns:k="urn:s"/></s:root>`
-	laxDoc := `<s:root xmlns:s="urn:s"><o:unknown xmlns:o="urn:o"/></s:root>`
-	for range 10 {
-		if err := known.Validate(context.Background(), strings.NewReader(knownDoc)); err != nil {
-			t.Fatal(err)
-		}
-		if err := lax.Validate(context.Background(), strings.NewReader(laxDoc)); err != nil {
-			t.Fatal(err)
+	var doc map[string]any
+	if err := json.Unmarshal(export.Document, &doc); err != nil {
+		t.Fatalf("Document is not JSON: %v", err)
+	}
+	lookup := func(path ...string) any {
+		var v any = doc
+		for _, key := range path {
+			switch node := v.(type) {
+			case map[string]any:
+				v = node[key]
+			case []any:
+				var i int
+				fmt.Sscan(key, &i)
+				v = node[i]
+			default:
+				t.Fatalf("no member %q in path %q", key, path)
+			}
 		}
+		return v
 	}
-	knownAllocs := testing.AllocsPerRun(100, func() {
-		if err := known.Validate(context.Background(), strings.NewReader(knownDoc)); err != nil {
-			panic(err)
-		}
-	})
-	laxAllocs := testing.AllocsPerRun(100, func() {
-		if err := lax.Validate(context.Background(), strings.NewReader(laxDoc)); err != nil {
-			panic(err)
+	tests := []struct {
+		want any
+		path []string
+	}{
+		{path: []string{"$schema"}, want: "https://json-schema.org/draft/2020-12/schema"},
+		{path: []string{"properties", "order", "$ref"}, want: "#/$defs/orderElement"},
+		{path: []string{"$defs", "orderElement", "$ref"}, want: "#/$defs/order"},
+		{path: []string{"$defs", "orderElement", "unevaluatedProperties"}, want: false},
+		{path: []string{"$defs", "order", "allOf", "0", "$ref"}, want: "#/$defs/base"},
+		{path: []string{"$defs", "order", "required"}, want: []any{"line", "total"}},
+		{path: []string{"$defs", "base", "required"}, want: []any{"@status", "id"}},
+		{path: []string{"$defs", "order", "properties", "line", "maxItems"}, want: 10.0},
+		{path: []string{"$defs", "order", "properties", "line", "items", "properties", "@no", "type"}, want: "integer"},
+		{path: []string{"$defs", "order", "properties", "line", "items", "properties", "@no", "minimum"}, want: 1.0},
+		{path: []string{"$defs", "order", "properties", "line", "items", "allOf", "0", "anyOf", "0", "not", "required"}, want: []any{"text"}},
+		{path: []string{"$defs", "order", "properties", "note", "properties", "#text", "type"}, want: "string"},
+		{path: []string{"$defs", "code", "pattern"}, want: `^(?:[A-Z]{2}-[0-9]{3})$`},
+		{path: []string{"$defs", "code", "maxLength"}, want: 6.0},
+		{path: []string{"$defs", "amount", "type"}, want: "number"},
+		{path: []string{"$defs", "amount", "multipleOf"}, want: 0.01},
+		{path: []string{"$defs", "small", "allOf", "0", "$ref"}, want: "#/$defs/amount"},
+		{path: []string{"$defs", "small", "exclusiveMaximum"}, want: 100.0},
+	}
+	for _, test := range tests {
+		if got := lookup(test.path...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s = %#v, want %#v", strings.Join(test.path, "/"), got, test.want)
 		}
-	})
-	if laxAllocs != knownAllocs {
-		t.Fatalf("lax wildcard allocations = %.0f, known name = %.0f", laxAllocs, knownAllocs)
+	}
+
+	var got []string
+	for _, u := range export.Unrepresented {
+		got = append(got, u.Component+" "+u.Construct)
+	}
+	want := []string{
+		"{urn:orders}amount totalDigits",
+		"{urn:orders}order identity constraint",
+		"{urn:orders}order/note mixed content",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Unrepresented = %q, want %q", got, want)
 	}
 }
 
-func expectCategoryCode(t *testing.T, err error, category xsderrors.Category, code xsderrors.Code) {
-	t.Helper()
-	x, ok := errors.AsType[*xsderrors.Error](err)
+func TestValidationErrorsReportExpectedAndFound(t *testing.T) {
+	const schema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:pay" xmlns:p="urn:pay" elementFormDefault="qualified">
+  <xs:element name="payment">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="Id" type="xs:string"/>
+        <xs:choice>
+          <xs:element name="Amount" type="xs:decimal"/>
+          <xs:element name="Currency" type="xs:string"/>
+        </xs:choice>
+        <xs:any namespace="##other" processContents="lax" minOccurs="0"/>
+      </xs:sequence>
+      <xs:attribute name="ref" use="required"/>
+      <xs:attribute name="note"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("schema.xsd", []byte(schema)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	amount := xml.Name{Space: "urn:pay", Local: "Amount"}
+	currency := xml.Name{Space: "urn:pay", Local: "Currency"}
+	tests := []struct {
+		name     string
+		doc      string
+		found    xml.Name
+		expected xsderrors.Expected
+		code     xsderrors.Code
+	}{
+		{
+			name:     "unexpected child",
+			doc:      `<payment xmlns="urn:pay" ref="1"><Id>1</Id><Total>2</Total></payment>`,
+			code:     xsderrors.CodeValidationElement,
+			found:    xml.Name{Space: "urn:pay", Local: "Total"},
+			expected: xsderrors.Expected{Names: []xml.Name{amount, currency}},
+		},
+		{
+			name:     "missing child",
+			doc:      `<payment xmlns="urn:pay" ref="1"><Id>1</Id></payment>`,
+			code:     xsderrors.CodeValidationContent,
+			expected: xsderrors.Expected{Names: []xml.Name{amount, currency}},
+		},
+		{
+			name:  "child after complete content",
+			doc:   `<payment xmlns="urn:pay" ref="1"><Id>1</Id><Amount>2</Amount><Id>3</Id></payment>`,
+			code:  xsderrors.CodeValidationElement,
+			found: xml.Name{Space: "urn:pay", Local: "Id"},
+			expected: xsderrors.Expected{Wildcards: []xsderrors.Wildcard{
+				{Namespaces: []string{"urn:pay", ""}, Not: true},
+			}},
+		},
+		{
+			name:     "undeclared attribute",
+			doc:      `<payment xmlns="urn:pay" ref="1" extra="2"><Id>1</Id><Amount>2</Amount></payment>`,
+			code:     xsderrors.CodeValidationAttribute,
+			found:    xml.Name{Local: "extra"},
+			expected: xsderrors.Expected{Names: []xml.Name{{Local: "note"}}},
+		},
+		{
+			name:     "missing attribute",
+			doc:      `<payment xmlns="urn:pay"><Id>1</Id><Amount>2</Amount></payment>`,
+			code:     xsderrors.CodeValidationAttribute,
+			expected: xsderrors.Expected{Names: []xml.Name{{Local: "ref"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(tt.doc))
+			xerr, ok := errors.AsType[*xsderrors.Error](err)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *xsderrors.Error", err)
+			}
+			if xerr.Code != tt.code {
+				t.Fatalf("Code = %s, want %s", xerr.Code, tt.code)
+			}
+			if xerr.Found != tt.found {
+				t.Fatalf("Found = %v, want %v", xerr.Found, tt.found)
+			}
+			if xerr.Expected == nil {
+				t.Fatal("Expected = nil")
+			}
+			if !reflect.DeepEqual(*xerr.Expected, tt.expected) {
+				t.Fatalf("Expected = %+v, want %+v", *xerr.Expected, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsReportSchemaLocation(t *testing.T) {
+	t.Parallel()
+
+	schema := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:simpleType name="Code">
+    <xs:restriction base="xs:string">
+      <xs:maxLength value="4"/>
+      <xs:pattern value="[A-Z]+"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="line" maxOccurs="unbounded">
+          <xs:complexType>
+            <xs:sequence><xs:element name="sku" type="Code"/></xs:sequence>
+            <xs:attribute name="qty" type="xs:int" use="required"/>
+          </xs:complexType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+    <xs:key name="sku">
+      <xs:selector xpath="line"/>
+      <xs:field xpath="sku"/>
+    </xs:key>
+  </xs:element>
+</xs:schema>`)
+	engine, err := xsd.CompileWithOptions(context.Background(), xsd.CompileOptions{RetainSourceLocations: true}, xsd.Bytes("order.xsd", schema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	tests := []struct {
+		want xsderrors.SchemaLocation
+		name string
+		doc  string
+	}{
+		{
+			name: "pattern facet",
+			doc:  `<order><line qty="1"><sku>ab</sku></line></order>`,
+			want: xsderrors.SchemaLocation{Source: "order.xsd", Component: "pattern", Line: 5, Column: 7},
+		},
+		{
+			name: "maxLength facet",
+			doc:  `<order><line qty="1"><sku>ABCDE</sku></line></order>`,
+			want: xsderrors.SchemaLocation{Source: "order.xsd", Component: "maxLength", Line: 4, Column: 7},
+		},
+		{
+			name: "attribute use",
+			doc:  `<order><line><sku>AB</sku></line></order>`,
+			want: xsderrors.SchemaLocation{Source: "order.xsd", Component: "attribute", Line: 14, Column: 13},
+		},
+		{
+			name: "content model",
+			doc:  `<order><line qty="1"/></order>`,
+			want: xsderrors.SchemaLocation{Source: "order.xsd", Component: "complexType", Line: 12, Column: 11},
+		},
+		{
+			name: "identity constraint",
+			doc:  `<order><line qty="1"><sku>AB</sku></line><line qty="2"><sku>AB</sku></line></order>`,
+			want: xsderrors.SchemaLocation{Source: "order.xsd", Component: "key", Line: 19, Column: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), strings.NewReader(tt.doc))
+			xerr, ok := errors.AsType[*xsderrors.Error](err)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *xsderrors.Error", err)
+			}
+			if xerr.SchemaLocation == nil {
+				t.Fatalf("Validate() error %v has no SchemaLocation", xerr)
+			}
+			if *xerr.SchemaLocation != tt.want {
+				t.Fatalf("SchemaLocation = %+v, want %+v", *xerr.SchemaLocation, tt.want)
+			}
+		})
+	}
+
+	plain, err := xsd.Compile(context.Background(), xsd.Bytes("order.xsd", schema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	err = plain.Validate(context.Background(), strings.NewReader(tests[0].doc))
+	xerr, ok := errors.AsType[*xsderrors.Error](err)
 	if !ok {
-		t.Fatalf("error %v is not *xsderrors.Error", err)
+		t.Fatalf("Validate() error = %v, want *xsderrors.Error", err)
 	}
-	if x.Category != category || x.Code != code {
-		t.Fatalf("error = %s/%s, want %s/%s; err=%v", x.Category, x.Code, category, code, err)
+	if xerr.SchemaLocation != nil {
+		t.Fatalf("SchemaLocation = %+v without RetainSourceLocations, want nil", *xerr.SchemaLocation)
+	}
+}
+
+func TestValidateOptionsPathFormats(t *testing.T) {
+	t.Parallel()
+
+	engine, err := xsd.Compile(context.Background(), xsd.Bytes("order.xsd", []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:order" xmlns="urn:order" elementFormDefault="qualified">
+  <xs:complexType name="Line"><xs:sequence><xs:element name="qty" type="xs:int"/></xs:sequence></xs:complexType>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:choice maxOccurs="unbounded">
+        <xs:element name="line" type="Line"/>
+        <xs:element name="note" type="xs:string"/>
+      </xs:choice>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`)))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	doc := `<o:order xmlns:o="urn:order"><o:line><o:qty>1</o:qty></o:line><o:line><o:qty>x</o:qty></o:line><o:note/><o:line><o:qty>y</o:qty></o:line></o:order>`
+	tests := []struct {
+		name string
+		opts xsd.ValidateOptions
+		want []string
+	}{
+		{name: "default", want: []string{"/order/line/qty", "/order/line/qty"}},
+		{name: "indexed", opts: xsd.ValidateOptions{IndexedPaths: true}, want: []string{"/order/line[2]/qty[1]", "/order/line[3]/qty[1]"}},
+		{name: "clark", opts: xsd.ValidateOptions{ClarkPaths: true}, want: []string{"/{urn:order}order/{urn:order}line/{urn:order}qty", "/{urn:order}order/{urn:order}line/{urn:order}qty"}},
+		{
+			name: "indexed clark",
+			opts: xsd.ValidateOptions{IndexedPaths: true, ClarkPaths: true},
+			want: []string{"/{urn:order}order/{urn:order}line[2]/{urn:order}qty[1]", "/{urn:order}order/{urn:order}line[3]/{urn:order}qty[1]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			tt.opts.OnError = func(x *xsderrors.Error) xsd.ErrorAction {
+				paths = append(paths, x.Path)
+				return xsd.ErrorContinue
+			}
+			if err := engine.ValidateWithOptions(context.Background(), strings.NewReader(doc), tt.opts); err == nil {
+				t.Fatal("ValidateWithOptions() = nil, want an error")
+			}
+			if !slices.Equal(paths, tt.want) {
+				t.Fatalf("paths = %q, want %q", paths, tt.want)
+			}
+		})
+	}
+}
+
+func schemaLocation(err error) *xsderrors.SchemaLocation {
+	xerr, ok := errors.AsType[*xsderrors.Error](err)
+	if !ok {
+		return nil
 	}
+	return xerr.SchemaLocation
 }
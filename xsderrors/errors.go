@@ -2,6 +2,7 @@
 package xsderrors
 
 import (
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"slices"
@@ -42,7 +43,10 @@ const (
 	CodeSchemaNoSources        Code = "schema.no_sources"
 	CodeSchemaInvalidAttribute Code = "schema.invalid_attribute"
 	CodeSchemaIdentity         Code = "schema.identity"
+	CodeSchemaAssertion        Code = "schema.assertion"
+	CodeSchemaTypeAlternative  Code = "schema.type_alternative"
 	CodeSchemaLimit            Code = "schema.limit"
+	CodeSchemaSnapshot         Code = "schema.snapshot"
 	CodeCompileCanceled        Code = "compile.canceled"
 	CodeUnsupportedDTD         Code = "unsupported.dtd"
 	CodeUnsupportedExternal    Code = "unsupported.external_entity"
@@ -50,10 +54,12 @@ const (
 	CodeUnsupportedNonUTF8     Code = "unsupported.non_utf8"
 	CodeUnsupportedRedefine    Code = "unsupported.xs_redefine"
 	CodeUnsupportedRegex       Code = "unsupported.regex"
+	CodeUnsupportedSample      Code = "unsupported.sample"
 	CodeUnsupportedSchemaHint  Code = "unsupported.xsi_schema_location"
 	CodeUnsupportedXML11       Code = "unsupported.xml_1_1"
 	CodeUnsupportedXSD11       Code = "unsupported.xsd_1_1"
 	CodeValidationXML          Code = "validation.xml"
+	CodeValidationJSON         Code = "validation.json"
 	CodeValidationRoot         Code = "validation.root"
 	CodeValidationElement      Code = "validation.element"
 	CodeValidationAttribute    Code = "validation.attribute"
@@ -63,6 +69,7 @@ const (
 	CodeValidationContent      Code = "validation.content"
 	CodeValidationNil          Code = "validation.nil"
 	CodeValidationIdentity     Code = "validation.identity"
+	CodeValidationAssertion    Code = "validation.assertion"
 	CodeValidationOption       Code = "validation.option"
 	CodeValidationSession      Code = "validation.session"
 	CodeValidationLimit        Code = "validation.limit"
@@ -73,13 +80,57 @@ const (
 // Error is the public structured diagnostic returned by compile and validation
 // operations.
 type Error struct {
-	Err      error
-	Category Category
-	Code     Code
-	Path     string
-	Message  string
-	Line     int
-	Column   int
+	Err error
+	// Expected lists what content and attribute diagnostics would have
+	// accepted where Found is. It is nil when the diagnostic has no such
+	// detail, and empty when nothing was acceptable there.
+	Expected *Expected
+	// SchemaLocation is where the schema component a validation diagnostic
+	// concerns is declared, such as the violated facet, the element
+	// declaration or the identity constraint. It is nil when the schema does
+	// not retain source locations.
+	SchemaLocation *SchemaLocation
+	Category       Category
+	Code           Code
+	Path           string
+	Message        string
+	// Found is the name of the rejected element or attribute. It is zero for
+	// diagnostics about something missing.
+	Found  xml.Name
+	Line   int
+	Column int
+}
+
+// SchemaLocation is where a schema component is declared.
+type SchemaLocation struct {
+	// Source is the name of the schema document.
+	Source string
+	// Component is the local name of the declaring schema element, such as
+	// element, complexType or pattern.
+	Component string
+	Line      int
+	Column    int
+}
+
+// Expected lists the element or attribute names, and the wildcards, that a
+// diagnostic would have accepted.
+type Expected struct {
+	// Names are the declared names, in schema order.
+	Names []xml.Name
+	// Wildcards are the namespace constraints of the wildcards that would
+	// have accepted further names.
+	Wildcards []Wildcard
+}
+
+// Wildcard is the namespace constraint of a wildcard.
+type Wildcard struct {
+	// Namespaces are the namespaces the wildcard admits, or those it
+	// excludes when Not is set. The empty string stands for no namespace.
+	Namespaces []string
+	// Any is set when the wildcard admits every namespace.
+	Any bool
+	// Not is set when the wildcard admits every namespace except Namespaces.
+	Not bool
 }
 
 // Errors is returned when validation finds multiple recoverable errors.
@@ -228,6 +279,21 @@ func WithPath(path string, err error) error {
 	return &y
 }
 
+// WithSchemaLocation attaches the location of a schema component to a
+// structured diagnostic when it has none.
+func WithSchemaLocation(loc SchemaLocation, err error) error {
+	if err == nil {
+		return nil
+	}
+	x, ok := directDiagnostic(err)
+	if !ok || x.SchemaLocation != nil {
+		return err
+	}
+	y := *x
+	y.SchemaLocation = &loc
+	return &y
+}
+
 func directDiagnostic(err error) (*Error, bool) {
 	// Decoration is intentionally restricted to a top-level diagnostic. Traversing
 	// wrappers or aggregates would discard their error-tree structure when cloning.
@@ -250,6 +316,58 @@ func Validation(code Code, line, col int, path, msg string) error {
 	return &Error{Category: CategoryValidation, Code: code, Line: line, Column: col, Path: path, Message: msg}
 }
 
+// String lists the expected names and wildcards for display, such as
+// "Amount, Currency or any name in urn:ext". Namespaced names use Clark
+// notation, {namespace}local.
+func (e *Expected) String() string {
+	if e == nil {
+		return ""
+	}
+	items := make([]string, 0, len(e.Names)+len(e.Wildcards))
+	for _, name := range e.Names {
+		if name.Space == "" {
+			items = append(items, name.Local)
+		} else {
+			items = append(items, "{"+name.Space+"}"+name.Local)
+		}
+	}
+	for _, w := range e.Wildcards {
+		items = append(items, w.String())
+	}
+	switch n := len(items); n {
+	case 0:
+		return "nothing"
+	case 1:
+		return items[0]
+	default:
+		return strings.Join(items[:n-1], ", ") + " or " + items[n-1]
+	}
+}
+
+// String describes the namespaces w admits.
+func (w Wildcard) String() string {
+	if w.Any {
+		return "any name"
+	}
+	namespaces := make([]string, len(w.Namespaces))
+	for i, ns := range w.Namespaces {
+		namespaces[i] = ns
+		if ns == "" {
+			namespaces[i] = "no namespace"
+		}
+	}
+	if w.Not {
+		return "any name not in " + strings.Join(namespaces, ", ")
+	}
+	return "any name in " + strings.Join(namespaces, ", ")
+}
+
+// ValidationExpected returns a document validation diagnostic that records
+// the name found and what was expected in its place.
+func ValidationExpected(code Code, line, col int, path, msg string, found xml.Name, expected *Expected) error {
+	return &Error{Category: CategoryValidation, Code: code, Line: line, Column: col, Path: path, Message: msg, Found: found, Expected: expected}
+}
+
 // Canceled returns a structured cancellation diagnostic that preserves cause.
 func Canceled(code Code, msg string, cause error) error {
 	return &Error{Category: CategoryCanceled, Code: code, Message: msg, Err: cause}
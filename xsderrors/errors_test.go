@@ -2,6 +2,7 @@ package xsderrors
 
 import (
 	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"testing"
@@ -156,6 +157,14 @@ func TestLocationDecoratorsCloneDirectDiagnostics(t *testing.T) {
 	if withLocation == original || withLocation.Path != "schema.xsd" || withLocation.Line != 3 || withLocation.Column != 4 {
 		t.Fatalf("WithSchemaCompileLocation() = %#v, original %#v", withLocation, original)
 	}
+	loc := SchemaLocation{Source: "schema.xsd", Component: "pattern", Line: 5, Column: 7}
+	withSchemaLocation := requireDiagnostic(t, WithSchemaLocation(loc, original))
+	if withSchemaLocation == original || withSchemaLocation.SchemaLocation == nil || *withSchemaLocation.SchemaLocation != loc || original.SchemaLocation != nil {
+		t.Fatalf("WithSchemaLocation() = %#v, original %#v", withSchemaLocation, original)
+	}
+	if got := WithSchemaLocation(SchemaLocation{Source: "other.xsd"}, withSchemaLocation); got != withSchemaLocation { //nolint:errorlint // Require the located diagnostic unchanged.
+		t.Fatalf("WithSchemaLocation(located) = %#v, want the first location kept", got)
+	}
 }
 
 func TestLocationDecoratorsPreserveIneligibleDirectDiagnostics(t *testing.T) {
@@ -190,3 +199,30 @@ func isTypedNilDiagnostic(err error) bool {
 	xerr, ok := err.(*Error) //nolint:errorlint // Verify the exact top-level typed-nil result.
 	return ok && xerr == nil
 }
+
+func TestExpectedString(t *testing.T) {
+	tests := []struct {
+		expected *Expected
+		want     string
+	}{
+		{expected: nil, want: ""},
+		{expected: &Expected{}, want: "nothing"},
+		{expected: &Expected{Names: []xml.Name{{Local: "Amount"}}}, want: "Amount"},
+		{
+			expected: &Expected{Names: []xml.Name{{Local: "Amount"}, {Space: "urn:x", Local: "Currency"}}},
+			want:     "Amount or {urn:x}Currency",
+		},
+		{
+			expected: &Expected{
+				Names:     []xml.Name{{Local: "a"}, {Local: "b"}},
+				Wildcards: []Wildcard{{Namespaces: []string{"urn:ext", ""}}, {Not: true, Namespaces: []string{"urn:x"}}, {Any: true}},
+			},
+			want: "a, b, any name in urn:ext, no namespace, any name not in urn:x or any name",
+		},
+	}
+	for _, test := range tests {
+		if got := test.expected.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
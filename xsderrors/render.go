@@ -0,0 +1,360 @@
+package xsderrors
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// excerptContext is the number of bytes quoted either side of the reported
+// column when a source line is too long to quote whole.
+const excerptContext = 80
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiError  = "\x1b[1;31m"
+	ansiNote   = "\x1b[1;32m"
+	ansiGutter = "\x1b[1;34m"
+	ansiBold   = "\x1b[1m"
+)
+
+// Renderer writes diagnostics as source excerpts in the style of compiler
+// messages: the code and message, the position, the offending line with a
+// caret under the column and, for validation diagnostics with a
+// SchemaLocation, the schema component that was violated.
+//
+//	error[validation.facet]: invalid simple content: pattern facet failed
+//	 --> order.xml:3:12
+//	  |
+//	3 |   <sku>ab</sku>
+//	  |            ^ /order/line/sku
+//	note: violates xs:pattern
+//	 --> order.xsd:5:7
+//	  |
+//	5 |       <xs:pattern value="[A-Z]+"/>
+//	  |       ^
+type Renderer struct {
+	// Open opens the document called name so that the lines diagnostics
+	// refer to can be quoted. Each document is opened at most once per
+	// Render call. When Open is nil or fails, diagnostics are rendered
+	// without excerpts.
+	Open func(name string) (io.ReadCloser, error)
+	// Document names the instance document that validation diagnostics
+	// refer to. Leave it empty when rendering schema diagnostics, whose Path
+	// names the schema document instead.
+	Document string
+	// Color writes ANSI terminal escape sequences.
+	Color bool
+}
+
+// OpenBytes returns an Open function for Renderer that serves the in-memory
+// documents of sources, keyed by name.
+func OpenBytes(sources map[string][]byte) func(name string) (io.ReadCloser, error) {
+	return func(name string) (io.ReadCloser, error) {
+		data, ok := sources[name]
+		if !ok {
+			return nil, errors.New("document " + strconv.Quote(name) + " not found")
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+// Render writes err to w. Each error of an Errors aggregate is rendered in
+// turn, separated by a blank line; errors other than *Error are written as
+// their message.
+func (r *Renderer) Render(w io.Writer, err error) error {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if aggregate, ok := err.(Errors); ok { //nolint:errorlint // Only a top-level aggregate is split into diagnostics.
+		errs = slices.DeleteFunc(slices.Clone(aggregate), func(err error) bool { return err == nil })
+	}
+	excerpts := r.readExcerpts(errs)
+	var b strings.Builder
+	for i, err := range errs {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		x, ok := err.(*Error) //nolint:errorlint // Wrapped diagnostics are rendered as their message.
+		if !ok || x == nil {
+			r.writeHeader(&b, ansiError, "error", "", err.Error())
+			continue
+		}
+		r.writeDiagnostic(&b, x, excerpts)
+	}
+	_, writeErr := io.WriteString(w, b.String())
+	return writeErr
+}
+
+// excerptKey identifies one quoted source position.
+type excerptKey struct {
+	source string
+	line   int
+	column int
+}
+
+// excerpt is the quoted part of a source line: the whole line, or a window
+// around the column when the line is long.
+type excerpt struct {
+	text []byte
+	// offset is the byte offset of text within the line.
+	offset int
+	// more is set when the line continues past text.
+	more bool
+}
+
+func (r *Renderer) source(x *Error) string {
+	if r.Document != "" || x.Category == CategoryValidation {
+		return r.Document
+	}
+	return x.Path
+}
+
+// readExcerpts opens each document the diagnostics of errs refer to once,
+// and quotes the lines they report.
+func (r *Renderer) readExcerpts(errs []error) map[excerptKey]*excerpt {
+	if r.Open == nil {
+		return nil
+	}
+	wants := make(map[string][]excerptKey)
+	want := func(key excerptKey) {
+		if key.source != "" && key.line > 0 {
+			wants[key.source] = append(wants[key.source], key)
+		}
+	}
+	for _, err := range errs {
+		x, ok := err.(*Error) //nolint:errorlint // Matches the diagnostics Render quotes.
+		if !ok || x == nil {
+			continue
+		}
+		want(excerptKey{source: r.source(x), line: x.Line, column: x.Column})
+		if loc := x.SchemaLocation; loc != nil {
+			want(excerptKey{source: loc.Source, line: loc.Line, column: loc.Column})
+		}
+	}
+	excerpts := make(map[excerptKey]*excerpt)
+	for name, keys := range wants {
+		rc, err := r.Open(name)
+		if err != nil {
+			continue
+		}
+		slices.SortFunc(keys, func(a, b excerptKey) int { return a.line - b.line })
+		readSourceExcerpts(rc, keys, excerpts)
+		_ = rc.Close()
+	}
+	return excerpts
+}
+
+// readSourceExcerpts quotes the lines of rd that keys, sorted by line, refer
+// to. Lines are read in chunks, so a long line is never held whole.
+func readSourceExcerpts(rd io.Reader, keys []excerptKey, out map[excerptKey]*excerpt) {
+	br := bufio.NewReader(rd)
+	line, pos := 1, 0
+	for len(keys) != 0 {
+		chunk, err := br.ReadSlice('\n')
+		content := bytes.TrimSuffix(chunk, []byte{'\n'})
+		for _, key := range keys {
+			if key.line != line {
+				break
+			}
+			appendExcerpt(out, key, content, pos)
+		}
+		pos += len(content)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		for len(keys) != 0 && keys[0].line <= line {
+			if e := out[keys[0]]; e != nil {
+				e.finish()
+			}
+			keys = keys[1:]
+		}
+		if err != nil {
+			return
+		}
+		line, pos = line+1, 0
+	}
+}
+
+// appendExcerpt adds to the excerpt of key the part of content, found at
+// byte offset pos of its line, that falls in the quoted window.
+func appendExcerpt(out map[excerptKey]*excerpt, key excerptKey, content []byte, pos int) {
+	lo := max(0, key.column-1-excerptContext)
+	hi := max(key.column-1, 0) + excerptContext
+	if lo < excerptContext {
+		lo, hi = 0, max(hi, 2*excerptContext)
+	}
+	e := out[key]
+	if e == nil {
+		e = &excerpt{offset: lo}
+		out[key] = e
+	}
+	end := pos + len(content)
+	if end > hi {
+		e.more = true
+	}
+	from, to := max(pos, lo), min(end, hi)
+	if from < to {
+		e.text = append(e.text, content[from-pos:to-pos]...)
+	}
+}
+
+// finish trims the line ending and the partial characters at the edges of
+// a window.
+func (e *excerpt) finish() {
+	if !e.more {
+		e.text = bytes.TrimSuffix(e.text, []byte{'\r'})
+	}
+	for e.offset > 0 && len(e.text) != 0 && !utf8.RuneStart(e.text[0]) {
+		e.text = e.text[1:]
+		e.offset++
+	}
+	if e.more && len(e.text) != 0 {
+		start := len(e.text) - 1
+		for start > 0 && len(e.text)-start < utf8.UTFMax && !utf8.RuneStart(e.text[start]) {
+			start--
+		}
+		if !utf8.FullRune(e.text[start:]) {
+			e.text = e.text[:start]
+		}
+	}
+}
+
+func (r *Renderer) writeDiagnostic(b *strings.Builder, x *Error, excerpts map[excerptKey]*excerpt) {
+	msg := x.Message
+	if x.Err != nil {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += x.Err.Error()
+	}
+	code := string(x.Code)
+	if code == "" {
+		code = string(x.Category)
+	}
+	r.writeHeader(b, ansiError, "error", code, msg)
+	source := r.source(x)
+	label := ""
+	if source == r.Document {
+		label = x.Path
+	}
+	r.writeExcerpt(b, ansiError, excerptKey{source: source, line: x.Line, column: x.Column}, label, excerpts)
+	if x.Expected != nil {
+		b.WriteString(strings.Repeat(" ", gutterWidth(x.Line)))
+		b.WriteString(" = ")
+		b.WriteString(r.paint(ansiBold, "expected"))
+		b.WriteString(": ")
+		b.WriteString(x.Expected.String())
+		b.WriteByte('\n')
+	}
+	if loc := x.SchemaLocation; loc != nil {
+		r.writeHeader(b, ansiNote, "note", "", "violates xs:"+loc.Component)
+		r.writeExcerpt(b, ansiNote, excerptKey{source: loc.Source, line: loc.Line, column: loc.Column}, "", excerpts)
+	}
+}
+
+func (r *Renderer) writeHeader(b *strings.Builder, color, level, code, msg string) {
+	if code != "" {
+		level += "[" + code + "]"
+	}
+	b.WriteString(r.paint(color, level))
+	if msg != "" {
+		b.WriteString(r.paint(ansiBold, ": "+msg))
+	}
+	b.WriteByte('\n')
+}
+
+// writeExcerpt writes the position of key and, when its line was read, the
+// line with a caret under the column followed by label.
+func (r *Renderer) writeExcerpt(b *strings.Builder, color string, key excerptKey, label string, excerpts map[excerptKey]*excerpt) {
+	if key.line <= 0 && key.source == "" {
+		if label != "" {
+			b.WriteString(r.paint(ansiGutter, " --> "))
+			b.WriteString(label)
+			b.WriteByte('\n')
+		}
+		return
+	}
+	width := gutterWidth(key.line)
+	b.WriteString(strings.Repeat(" ", width))
+	b.WriteString(r.paint(ansiGutter, "--> "))
+	b.WriteString(position(key))
+	e := excerpts[key]
+	if e == nil {
+		if label != "" {
+			b.WriteByte(' ')
+			b.WriteString(label)
+		}
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	r.writeGutter(b, width, "")
+	b.WriteByte('\n')
+	r.writeGutter(b, width, strconv.Itoa(key.line))
+	b.WriteByte(' ')
+	var caret strings.Builder
+	if e.offset > 0 {
+		b.WriteString("...")
+		caret.WriteString("   ")
+	}
+	b.Write(e.text)
+	if e.more {
+		b.WriteString("...")
+	}
+	b.WriteByte('\n')
+	column := key.column - 1 - e.offset
+	for i := 0; i < column; {
+		c, size := utf8.DecodeRune(e.text[min(i, len(e.text)):])
+		if c == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+		i += max(size, 1)
+	}
+	r.writeGutter(b, width, "")
+	b.WriteByte(' ')
+	b.WriteString(caret.String())
+	b.WriteString(r.paint(color, "^"))
+	if label != "" {
+		b.WriteByte(' ')
+		b.WriteString(r.paint(color, label))
+	}
+	b.WriteByte('\n')
+}
+
+func (r *Renderer) writeGutter(b *strings.Builder, width int, number string) {
+	b.WriteString(r.paint(ansiGutter, strings.Repeat(" ", width-len(number))+number+" |"))
+}
+
+func (r *Renderer) paint(color, text string) string {
+	if !r.Color || text == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+func position(key excerptKey) string {
+	var b strings.Builder
+	b.WriteString(key.source)
+	if key.line > 0 {
+		if key.source != "" {
+			b.WriteByte(':')
+		}
+		b.WriteString(strconv.Itoa(key.line))
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(key.column))
+	}
+	return b.String()
+}
+
+func gutterWidth(line int) int {
+	return len(strconv.Itoa(max(line, 0)))
+}
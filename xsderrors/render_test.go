@@ -0,0 +1,131 @@
+package xsderrors
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRendererQuotesSourceLines(t *testing.T) {
+	r := Renderer{
+		Open: OpenBytes(map[string][]byte{
+			"order.xml": []byte("<order>\r\n  <line>\r\n\t<sku>ab</sku>\r\n</line></order>\r\n"),
+			"order.xsd": []byte("<xs:simpleType>\n  <xs:restriction>\n    <xs:pattern value=\"[A-Z]+\"/>\n"),
+		}),
+		Document: "order.xml",
+	}
+	err := Errors{
+		&Error{
+			Category:       CategoryValidation,
+			Code:           CodeValidationFacet,
+			Line:           3,
+			Column:         7,
+			Path:           "/order/line/sku",
+			Message:        "invalid simple content: pattern facet failed",
+			SchemaLocation: &SchemaLocation{Source: "order.xsd", Component: "pattern", Line: 3, Column: 5},
+		},
+		&Error{
+			Category: CategoryValidation,
+			Code:     CodeValidationElement,
+			Line:     2,
+			Column:   3,
+			Path:     "/order/line",
+			Message:  "unexpected element line",
+			Expected: &Expected{Names: []xml.Name{{Local: "item"}}},
+		},
+		errors.New("plain failure"),
+	}
+	var b strings.Builder
+	if renderErr := r.Render(&b, err); renderErr != nil {
+		t.Fatal(renderErr)
+	}
+	want := `error[validation.facet]: invalid simple content: pattern facet failed
+ --> order.xml:3:7
+  |
+3 | 	<sku>ab</sku>
+  | 	     ^ /order/line/sku
+note: violates xs:pattern
+ --> order.xsd:3:5
+  |
+3 |     <xs:pattern value="[A-Z]+"/>
+  |     ^
+
+error[validation.element]: unexpected element line
+ --> order.xml:2:3
+  |
+2 |   <line>
+  |   ^ /order/line
+  = expected: item
+
+error: plain failure
+`
+	if got := b.String(); got != want {
+		t.Fatalf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRendererQuotesWindowOfLongLines(t *testing.T) {
+	line := strings.Repeat("é", 200) + "<bad/>" + strings.Repeat("x", 300)
+	column := len(strings.Repeat("é", 200)) + 1
+	r := Renderer{Open: OpenBytes(map[string][]byte{"doc.xml": []byte(line)}), Document: "doc.xml"}
+	var b strings.Builder
+	if err := r.Render(&b, &Error{Category: CategoryValidation, Code: CodeValidationElement, Line: 1, Column: column}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	if len(lines) < 5 {
+		t.Fatalf("Render() = %q", b.String())
+	}
+	quoted, caret := lines[3], lines[4]
+	if !strings.HasPrefix(quoted, "1 | ...é") || !strings.HasSuffix(quoted, "x...") || !strings.Contains(quoted, "<bad/>") {
+		t.Fatalf("quoted line = %q", quoted)
+	}
+	text := strings.TrimPrefix(quoted, "1 | ")
+	prefix := text[:strings.Index(text, "<bad/>")]
+	if want := "  | " + strings.Repeat(" ", len([]rune(prefix))) + "^"; caret != want {
+		t.Fatalf("caret line = %q, want %q", caret, want)
+	}
+}
+
+func TestRendererWithoutSource(t *testing.T) {
+	tests := []struct {
+		renderer Renderer
+		err      error
+		want     string
+	}{
+		{
+			renderer: Renderer{Document: "doc.xml"},
+			err:      Validation(CodeValidationType, 4, 2, "/root", "bad type"),
+			want:     "error[validation.type]: bad type\n --> doc.xml:4:2 /root\n",
+		},
+		{
+			renderer: Renderer{Open: OpenBytes(nil)},
+			err:      SchemaCompileAt("schema.xsd", 3, 1, CodeSchemaReference, "missing type"),
+			want:     "error[schema.reference]: missing type\n --> schema.xsd:3:1\n",
+		},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := tt.renderer.Render(&b, tt.err); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != tt.want {
+			t.Fatalf("Render(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRendererColor(t *testing.T) {
+	r := Renderer{Open: OpenBytes(map[string][]byte{"doc.xml": []byte("<root/>")}), Document: "doc.xml", Color: true}
+	var b strings.Builder
+	if err := r.Render(&b, Validation(CodeValidationRoot, 1, 1, "/root", "not declared")); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{ansiError + "error[validation.root]" + ansiReset, ansiError + "^" + ansiReset, ansiGutter + "1 |" + ansiReset} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("Render() = %q, want it to contain %q", got, want)
+		}
+	}
+}